@@ -17,6 +17,7 @@ package main
 
 import (
 	"context"
+	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/signal"
 	"log"
 	"net/http"
@@ -26,21 +27,91 @@ import (
 	"github.com/pkg/errors"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/logger"
+	"github.com/wentaojin/transferdb/metrics"
+	"github.com/wentaojin/transferdb/progress"
 
 	"github.com/wentaojin/transferdb/server"
 	"go.uber.org/zap"
 )
 
+// 子命令 transferdb <subcommand> [action] [flags]，args 为子命令名之后的参数
+var subcommands = map[string]func(args []string) error{
+	// 密码加密 transferdb secret [keygen|encrypt]，无需配置文件
+	"secret": func(args []string) error {
+		return config.RunSecret(args, os.Stdin, os.Stdout)
+	},
+	// 配置校验 transferdb config check [flags]，仅校验配置不连接数据库
+	"config": func(args []string) error {
+		cfg := config.NewConfig()
+		if err := cfg.ParseConfig(args); err != nil {
+			return err
+		}
+		return config.Check(cfg, os.Stdout)
+	},
+	// 声明式规则文件 transferdb rules [validate|sync|export] [flags]
+	"rules": func(args []string) error {
+		cfg := config.NewConfig()
+		if err := cfg.ParseRules(args); err != nil {
+			return err
+		}
+		initLogger(cfg)
+		return server.RunRules(context.Background(), cfg)
+	},
+	// 任务管理 transferdb task <action> [flags]
+	// 除 resume 外仅访问元数据库，不监听 pprof 端口，避免与运行中任务端口冲突
+	"task": func(args []string) error {
+		cfg := config.NewConfig()
+		if err := cfg.ParseTask(args); err != nil {
+			return err
+		}
+		initLogger(cfg)
+		if cfg.TaskAction != common.TaskActionResume {
+			return server.RunTask(context.Background(), cfg)
+		}
+		return serve(cfg, server.RunTask)
+	},
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				zap.L().Error("subcommand run failed", zap.String("subcommand", os.Args[1]), zap.Error(errors.Cause(err)))
+				log.Fatalf("%s subcommand run failed. error is [%s], Use '--help' for help.", os.Args[1], err)
+			}
+			return
+		}
+	}
+
 	cfg := config.NewConfig()
 	if err := cfg.Parse(os.Args[1:]); err != nil {
 		log.Fatalf("start meta failed. error is [%s], Use '--help' for help.", err)
 	}
+	initLogger(cfg)
+	if err := serve(cfg, server.Run); err != nil {
+		zap.L().Fatal("server run failed", zap.Error(errors.Cause(err)))
+	}
+}
 
-	// 初始化日志 logger
+// 初始化日志 logger
+func initLogger(cfg *config.Config) {
 	logger.NewZapLogger(cfg)
+	for _, key := range cfg.UnknownKeys {
+		zap.L().Warn("config item is unknown, please check whether it is misspelled", zap.String("config", cfg.ConfigFile), zap.String("key", key))
+	}
+	for _, key := range cfg.EnvKeys {
+		zap.L().Info("config item is overridden by environment variable", zap.String("key", key), zap.String("env", config.ConfigEnvName(key)))
+	}
+}
+
+// 任务运行，监听 pprof 端口以及信号量
+func serve(cfg *config.Config, run func(ctx context.Context, cfg *config.Config) error) error {
 	config.RecordAppVersion("transferdb", cfg)
 
+	// pprof 端口同时提供全量 full/csv JSON 格式进度
+	http.Handle("/progress", progress.JSONHandler())
+	// 各模块 Prometheus 指标，包括全量 full/csv 进度指标
+	http.Handle("/metrics", metrics.Handler())
 	go func() {
 		if err := http.ListenAndServe(cfg.AppConfig.PprofPort, nil); err != nil {
 			zap.L().Fatal("listen and serve pprof failed", zap.Error(errors.Cause(err)))
@@ -48,14 +119,17 @@ func main() {
 		os.Exit(0)
 	}()
 
-	// 信号量监听处理
-	signal.SetupSignalHandler(func() {
-		os.Exit(1)
-	})
+	// 信号量监听处理，首次信号优雅停止，停止调度新的 chunk 并等待进行中的 chunk 完成以及断点持久化，再次信号强制退出
+	ctx, stop := signal.WithGracefulStop(context.Background())
+	signal.SetupSignalHandler(stop)
 
 	// 程序运行
-	ctx := context.Background()
-	if err := server.Run(ctx, cfg); err != nil {
-		zap.L().Fatal("server run failed", zap.Error(errors.Cause(err)))
+	if err := run(ctx, cfg); err != nil {
+		if errors.Is(err, signal.ErrGracefulStop) {
+			zap.L().Warn("server graceful stopped", zap.Error(errors.Cause(err)))
+			return nil
+		}
+		return err
 	}
+	return nil
 }
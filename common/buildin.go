@@ -29,6 +29,12 @@ var BuildInOracleO2MColumnDefaultValueMap = map[string]string{
 	BuildInOracleColumnDefaultValueSYSGUID: "UUID()",
 }
 
+// ORACLE 默认值规则映射规则 O2P
+var BuildInOracleO2PColumnDefaultValueMap = map[string]string{
+	BuildInOracleColumnDefaultValueSysdate: "CURRENT_TIMESTAMP(0)",
+	BuildInOracleColumnDefaultValueSYSGUID: "GEN_RANDOM_UUID()",
+}
+
 // MySQL 默认值规则映射规则 M2O
 const (
 	BuildInMySQLColumnDefaultValueCurrentTimestamp = "CURRENT_TIMESTAMP"
@@ -198,6 +204,82 @@ var BuildInOracleO2MDatatypeNameMap = map[string]string{
 	BuildInOracleDatatypeIntervalDay:                 "VARCHAR",
 }
 
+// Oracle 数据类型名映射规则 O2P
+var BuildInOracleO2PDatatypeNameMap = map[string]string{
+	BuildInOracleDatatypeNumber:                      "SMALLINT/INTEGER/BIGINT/NUMERIC",
+	BuildInOracleDatatypeBfile:                       "VARCHAR",
+	BuildInOracleDatatypeChar:                        "CHAR",
+	BuildInOracleDatatypeCharacter:                   "CHAR",
+	BuildInOracleDatatypeClob:                        "TEXT",
+	BuildInOracleDatatypeBlob:                        "BYTEA",
+	BuildInOracleDatatypeDate:                        "TIMESTAMP(0)",
+	BuildInOracleDatatypeDecimal:                     "NUMERIC",
+	BuildInOracleDatatypeDec:                         "NUMERIC",
+	BuildInOracleDatatypeDoublePrecision:             "DOUBLE PRECISION",
+	BuildInOracleDatatypeFloat:                       "DOUBLE PRECISION",
+	BuildInOracleDatatypeInteger:                     "NUMERIC(38)",
+	BuildInOracleDatatypeInt:                         "NUMERIC(38)",
+	BuildInOracleDatatypeLong:                        "TEXT",
+	BuildInOracleDatatypeLongRAW:                     "BYTEA",
+	BuildInOracleDatatypeBinaryFloat:                 "REAL",
+	BuildInOracleDatatypeBinaryDouble:                "DOUBLE PRECISION",
+	BuildInOracleDatatypeNchar:                       "CHAR",
+	BuildInOracleDatatypeNcharVarying:                "VARCHAR",
+	BuildInOracleDatatypeNclob:                       "TEXT",
+	BuildInOracleDatatypeNumeric:                     "NUMERIC",
+	BuildInOracleDatatypeNvarchar2:                   "VARCHAR",
+	BuildInOracleDatatypeRaw:                         "BYTEA",
+	BuildInOracleDatatypeReal:                        "DOUBLE PRECISION",
+	BuildInOracleDatatypeRowid:                       "CHAR",
+	BuildInOracleDatatypeSmallint:                    "NUMERIC(38)",
+	BuildInOracleDatatypeUrowid:                      "VARCHAR",
+	BuildInOracleDatatypeVarchar2:                    "VARCHAR",
+	BuildInOracleDatatypeVarchar:                     "VARCHAR",
+	BuildInOracleDatatypeXmltype:                     "XML",
+	BuildInOracleDatatypeIntervalYearMonth0:          "INTERVAL YEAR TO MONTH",
+	BuildInOracleDatatypeIntervalYearMonth1:          "INTERVAL YEAR TO MONTH",
+	BuildInOracleDatatypeIntervalYearMonth2:          "INTERVAL YEAR TO MONTH",
+	BuildInOracleDatatypeIntervalYearMonth3:          "INTERVAL YEAR TO MONTH",
+	BuildInOracleDatatypeIntervalYearMonth4:          "INTERVAL YEAR TO MONTH",
+	BuildInOracleDatatypeIntervalYearMonth5:          "INTERVAL YEAR TO MONTH",
+	BuildInOracleDatatypeIntervalYearMonth6:          "INTERVAL YEAR TO MONTH",
+	BuildInOracleDatatypeIntervalYearMonth7:          "INTERVAL YEAR TO MONTH",
+	BuildInOracleDatatypeIntervalYearMonth8:          "INTERVAL YEAR TO MONTH",
+	BuildInOracleDatatypeIntervalYearMonth9:          "INTERVAL YEAR TO MONTH",
+	BuildInOracleDatatypeTimestamp:                   "TIMESTAMP",
+	BuildInOracleDatatypeTimestamp0:                  "TIMESTAMP",
+	BuildInOracleDatatypeTimestamp1:                  "TIMESTAMP",
+	BuildInOracleDatatypeTimestamp2:                  "TIMESTAMP",
+	BuildInOracleDatatypeTimestamp3:                  "TIMESTAMP",
+	BuildInOracleDatatypeTimestamp4:                  "TIMESTAMP",
+	BuildInOracleDatatypeTimestamp5:                  "TIMESTAMP",
+	BuildInOracleDatatypeTimestamp6:                  "TIMESTAMP",
+	BuildInOracleDatatypeTimestamp7:                  "TIMESTAMP",
+	BuildInOracleDatatypeTimestamp8:                  "TIMESTAMP",
+	BuildInOracleDatatypeTimestamp9:                  "TIMESTAMP",
+	BuildInOracleDatatypeTimestampWithTimeZone0:      "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithTimeZone1:      "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithTimeZone2:      "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithTimeZone3:      "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithTimeZone4:      "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithTimeZone5:      "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithTimeZone6:      "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithTimeZone7:      "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithTimeZone8:      "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithTimeZone9:      "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone0: "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone1: "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone2: "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone3: "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone4: "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone5: "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone6: "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone7: "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone8: "TIMESTAMPTZ",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone9: "TIMESTAMPTZ",
+	BuildInOracleDatatypeIntervalDay:                 "INTERVAL DAY TO SECOND",
+}
+
 // MySQL 数据类型名
 const (
 	BuildInMySQLDatatypeBigint          = "BIGINT"
@@ -233,7 +315,7 @@ const (
 	BuildInMySQLDatatypeBinary    = "BINARY"
 	BuildInMySQLDatatypeVarbinary = "VARBINARY"
 
-	// ORACLE ISN'T SUPPORT
+	// ORACLE ISN'T SUPPORT，转换 VARCHAR2 + CHECK 约束
 	BuildInMySQLDatatypeSet  = "SET"
 	BuildInMySQLDatatypeEnum = "ENUM"
 )
@@ -270,6 +352,8 @@ var BuildInMySQLM2ODatatypeNameMap = map[string]string{
 	BuildInMySQLDatatypeVarchar:         "VARCHAR2",
 	BuildInMySQLDatatypeBinary:          "RAW",
 	BuildInMySQLDatatypeVarbinary:       "RAW",
+	BuildInMySQLDatatypeSet:             "VARCHAR2",
+	BuildInMySQLDatatypeEnum:            "VARCHAR2",
 }
 
 /*
@@ -36,3 +36,81 @@ const (
 // 当值 == 0 启用 filterOracleIncrRecord 大于或者等于逻辑
 // 当值 == 1 启用 filterOracleIncrRecord 大于逻辑，避免已被消费得日志一直被重复消费
 var MigrateCurrentResetFlag = 0
+
+// 全量数据写入方式
+const (
+	FullApplyModeInsert   = "insert"
+	FullApplyModeLoadData = "load-data"
+	// TiDB v7.0.0 之前 LOAD DATA 按 tidb_dml_batch_size 分批提交，事务回滚无法撤销已提交数据
+	TiDBLoadDataTransactionVersion = "7.0.0"
+)
+
+// 全量自适应 chunk 以及并发
+const (
+	// chunk 目标数据量默认值，单位 MB
+	FullDefaultChunkTargetSize = 64
+	// 自适应并发观测窗口最小样本数
+	FullAdaptiveMinWindowSamples = 8
+	// 写入重试退避默认初始间隔以及最大间隔，单位毫秒
+	FullDefaultApplyRetryInterval = 500
+	FullMaxApplyRetryInterval     = 30000
+)
+
+// 目标端 MySQL/TiDB 可重试错误码，Busy 标识繁忙、锁冲突以及 backoff 类错误（自适应并发减半）
+//   - 连接中断类错误由驱动错误判断，不在错误码列表
+type MigrateRetryableError struct {
+	Number uint16
+	Busy   bool
+}
+
+var MigrateRetryableErrors = []MigrateRetryableError{
+	{Number: 1205, Busy: true}, // Lock wait timeout exceeded
+	{Number: 1213, Busy: true}, // Deadlock found when trying to get lock
+	{Number: 9001, Busy: true}, // PD server timeout
+	{Number: 9002, Busy: true}, // TiKV server timeout
+	{Number: 9003, Busy: true}, // TiKV server is busy
+	{Number: 9004, Busy: true}, // Resolve lock timeout
+	{Number: 9005, Busy: true}, // Region is unavailable
+	{Number: 9007, Busy: true}, // Write conflict
+	{Number: 8027},             // Information schema is out of date
+	{Number: 8028},             // Information schema is changed
+}
+
+// 表级别数据迁移规则字段值转换方式
+//   - hash：STANDARD_HASH 摘要小写十六进制输出，value 指定算法 SHA1/SHA256/SHA384/SHA512/MD5，默认 SHA256
+//   - redact：固定值替换，value 指定替换值，默认 ******，NULL 值保持 NULL
+//   - trim：RTRIM 去除 CHAR 尾部填充空格
+//   - remap：mapping 指定值映射，value 指定未命中映射默认值，未配置保持原值
+const (
+	TableRuleTransformHash   = "hash"
+	TableRuleTransformRedact = "redact"
+	TableRuleTransformTrim   = "trim"
+	TableRuleTransformRemap  = "remap"
+
+	TableRuleDefaultHashAlgorithm = "SHA256"
+	TableRuleDefaultRedactValue   = "******"
+)
+
+var TableRuleHashAlgorithms = []string{"SHA1", "SHA256", "SHA384", "SHA512", "MD5"}
+
+// STANDARD_HASH 不支持 LOB、LONG 以及对象类型字段
+var TableRuleHashUnsupportedDataTypes = []string{"BLOB", "CLOB", "NCLOB", "BFILE", "LONG", "LONG RAW", "XMLTYPE"}
+
+// 全量数据迁移进度
+const (
+	// 控制台进度汇总默认输出间隔，单位秒
+	ProgressDefaultInterval = 30
+	// 进度汇总最慢表数
+	ProgressSlowestTables = 5
+)
+
+// 元数据库存储类型
+const (
+	MetaDBTypeMySQL  = "mysql"
+	MetaDBTypeSQLite = "sqlite"
+
+	// 内嵌 SQLite 元数据库默认文件
+	MetaDefaultSQLiteFile = "./transferdb_meta.db"
+	// 内嵌 SQLite 元数据库锁等待超时，单位毫秒
+	MetaSQLiteBusyTimeout = 30000
+)
@@ -24,6 +24,8 @@ const (
 	MySQLCheckConsVersion = "8.0.15"
 	// MySQL 版本分隔符号
 	MySQLVersionDelimiter = "-"
+	// MySQL 支持生成列版本 >= 5.7.6，information_schema.COLUMNS 存在 GENERATION_EXPRESSION
+	MySQLGeneratedColumnVersion = "5.7.6"
 	// MySQL 字符集
 	MySQLCharacterSet = "UTF8MB4"
 
@@ -31,6 +33,14 @@ const (
 	// 需要 oracle 12.2g 及以上
 	OracleTableColumnCollationDBVersion = "12.2"
 
+	// 允许 Oracle IDENTITY 字段
+	// 需要 oracle 12c 及以上，以下版本使用 SEQUENCE + TRIGGER
+	OracleIdentityColumnDBVersion = "12.1"
+
+	// Oracle 对象名长度限制，12.2 及以上版本 128，以下版本 30
+	OracleObjectNameLengthLimit   = 30
+	OracleObjectNameLengthLimitV2 = 128
+
 	// Oracle 用户、表、字段默认使用 DB 排序规则
 	OracleUserTableColumnDefaultCollation = "USING_NLS_COMP"
 
@@ -38,6 +48,22 @@ const (
 	UTF8CharacterSetCSV = "UTF8"
 	GBKCharacterSetCSV  = "GBK"
 
+	// CSV 模式导出文件格式
+	CSVFileFormatCSV     = "CSV"
+	CSVFileFormatSQL     = "SQL"
+	CSVFileFormatParquet = "PARQUET"
+
+	// Lightning/Dumpling 目录格式，数据文件序号位数
+	LightningFileSerialWidth = 9
+
+	// CSV 文件 NULL 值默认输出
+	CSVDefaultNullValue = "NULL"
+
+	// CSV 文件二进制字段输出格式，默认原样输出
+	CSVBinaryFormatRaw    = "RAW"
+	CSVBinaryFormatHex    = "HEX"
+	CSVBinaryFormatBase64 = "BASE64"
+
 	// Struct JSON 格式化 -> Check 阶段
 	JSONColumns      = "COLUMN"
 	JSONIndex        = "INDEX"
@@ -45,6 +71,11 @@ const (
 	JSONFKConstraint = "FK"
 	JSONCKConstraint = "CK"
 	JSONPartition    = "PARTITION"
+
+	// Check 阶段 check 文件输出格式
+	// SECTION 按差异分段输出（默认，保持原输出格式），TABLE 统一表格输出差异级别以及期望值
+	CheckTextFormatSection = "SECTION"
+	CheckTextFormatTable   = "TABLE"
 )
 
 /*
@@ -55,6 +86,17 @@ const (
 	TiDBClusteredIndexIntOnlyValue = "INT_ONLY"
 	TiDBClusteredIndexONValue      = "ON"
 	TiDBClusteredIndexOFFValue     = "OFF"
+
+	// TiDB 表级别选项规则，表数据打散方式
+	// AUTO 单列整型主键使用 AUTO_RANDOM，否则使用 SHARD_ROW_ID_BITS
+	TiDBShardModeAuto            = "AUTO"
+	TiDBShardModeAutoRandom      = "AUTO_RANDOM"
+	TiDBShardModeShardRowIDBits  = "SHARD_ROW_ID_BITS"
+	TiDBShardModeNone            = "NONE"
+	TiDBDefaultAutoRandomBits    = 5
+	TiDBDefaultShardRowIDBits    = 4
+	TiDBClusteredIndexComment    = "/*T![clustered_index] CLUSTERED */"
+	TiDBNonClusteredIndexComment = "/*T![clustered_index] NONCLUSTERED */"
 )
 
 // alter-primary-key = fase 主键整型数据类型列表
@@ -125,8 +167,15 @@ var MySQLDBCollationMap = map[string]string{
 	"utf8_bin": "BINARY/BINARY_CS",
 }
 
-// Oracle 不支持数据类型 -> M2O
-var OracleIsNotSupportDataType = []string{"ENUM", "SET"}
+// M2O 需要额外对象转换的字段特性
+// ENUM/SET -> VARCHAR2 + CHECK 约束
+// AUTO_INCREMENT -> 12c 及以上 IDENTITY 字段，以下版本 SEQUENCE + TRIGGER
+// 生成列 -> 虚拟列，ON UPDATE CURRENT_TIMESTAMP -> TRIGGER
+const (
+	MySQLColumnFeatureAutoIncrement = "AUTO_INCREMENT"
+	MySQLColumnFeatureGenerated     = "GENERATED"
+	MySQLColumnFeatureOnUpdate      = "ON UPDATE CURRENT_TIMESTAMP"
+)
 
 // MySQL Reverse M2O
 // mysql 默认值未区分，字符数据、数值数据，用于匹配 mysql 字符串默认值，判断是否需单引号
@@ -0,0 +1,35 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+// 声明式规则文件操作
+//   - validate：校验规则文件，数据类型、重复或者冲突规则以及源端表是否存在
+//   - sync：校验通过后幂等同步至元数据库，规则存在则更新，不存在则写入
+//   - export：导出元数据库当前规则至规则文件
+const (
+	RuleActionValidate = "validate"
+	RuleActionSync     = "sync"
+	RuleActionExport   = "export"
+
+	// 规则同步元数据库 batch 写入数
+	RuleSyncBatchSize = 100
+)
+
+// 规则文件格式，根据文件后缀判断
+const (
+	RuleFileFormatTOML = "toml"
+	RuleFileFormatYAML = "yaml"
+)
@@ -187,6 +187,14 @@ func StringsBuilder(str ...string) string {
 	return b.String() // no copying
 }
 
+// 字符串截断，用于日志输出超长 SQL
+func TruncateString(str string, maxLen int) string {
+	if len(str) <= maxLen {
+		return str
+	}
+	return StringsBuilder(str[:maxLen], "...")
+}
+
 // 字符串大写
 func StringUPPER(str string) string {
 	return strings.ToUpper(str)
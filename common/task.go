@@ -32,7 +32,9 @@ const BufferSize = 1024
 const (
 	ReverseO2MMode = "ReverseO2M"
 	ReverseM2OMode = "ReverseM2O"
+	ReverseO2PMode = "ReverseO2P"
 	CheckO2MMode   = "CheckO2M"
+	CheckO2PMode   = "CheckO2P"
 	CompareO2MMode = "CompareO2M"
 	CSVO2MMode     = "CsvO2M"
 	FullO2MMode    = "FullO2M"
@@ -41,9 +43,10 @@ const (
 
 // 任务DB
 const (
-	TaskDBOracle = "ORACLE"
-	TaskDBTiDB   = "TIDB"
-	TaskDBMySQL  = "MYSQL"
+	TaskDBOracle   = "ORACLE"
+	TaskDBTiDB     = "TIDB"
+	TaskDBMySQL    = "MYSQL"
+	TaskDBPostgres = "POSTGRESQL"
 )
 
 // 任务类型
@@ -53,9 +56,40 @@ const (
 	TaskTypeObjectAssess  = "OBJECT_ASSESS"
 	TaskTypeObjectReverse = "OBJECT_REVERSE"
 	TaskTypeObjectCheck   = "OBJECT_CHECK"
+	TaskTypeObjectRule    = "OBJECT_RULE"
+	TaskTypeTaskManage    = "TASK_MANAGE"
 
 	TaskTypeDataCompare     = "DATA_COMPARE"
 	TaskTypeDataSQLMigrate  = "DATA_SQL_MIGRATE"
 	TaskTypeDataIncrMigrate = "DATA_INCR_MIGRATE"
 	TaskTypeDataCSVMigrate  = "DATA_CSV_MIGRATE"
 )
+
+// 任务管理子命令 transferdb task <action>
+//   - status：查看表级别断点、剩余 chunk、增量 SCN 位点以及错误数
+//   - reset：重置表级别断点，下次运行重新迁移/校验
+//   - errors list/clear：查看/清理 error_log_detail 错误记录
+//   - resume：校验断点后开启断点续传运行
+const (
+	TaskActionStatus      = "status"
+	TaskActionReset       = "reset"
+	TaskActionErrorsList  = "errors list"
+	TaskActionErrorsClear = "errors clear"
+	TaskActionResume      = "resume"
+)
+
+// server 模式任务状态
+const (
+	ServerTaskStateQueued   = "QUEUED"
+	ServerTaskStateRunning  = "RUNNING"
+	ServerTaskStateSuccess  = "SUCCESS"
+	ServerTaskStateFailed   = "FAILED"
+	ServerTaskStateCanceled = "CANCELED"
+
+	// HTTP API 默认监听地址
+	ServerDefaultAddr = "127.0.0.1:9697"
+	// 默认任务并发数
+	ServerDefaultConcurrency = 1
+	// 保留已结束任务记录数，超过清理最早结束任务
+	ServerTaskHistory = 1000
+)
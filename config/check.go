@@ -0,0 +1,45 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// 配置管理子命令 transferdb config check，校验配置并输出合并 --set 以及环境变量后的最终配置
+const ConfigActionCheck = "check"
+
+// 输出未识别配置项、脱敏后的最终配置以及校验结果，校验失败返回错误
+func Check(cfg *Config, out io.Writer) error {
+	for _, key := range cfg.UnknownKeys {
+		fmt.Fprintf(out, "[WARN] config item [%s] is unknown, please check whether it is misspelled\n", key)
+	}
+	for _, key := range cfg.EnvKeys {
+		fmt.Fprintf(out, "[INFO] config item [%s] is overridden by environment variable [%s]\n", key, ConfigEnvName(key))
+	}
+	effective, err := json.MarshalIndent(cfg.Redacted(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("config json marshal failed: %v", err)
+	}
+	fmt.Fprintf(out, "effective config:\n%s\n", effective)
+	if err = cfg.Validate(); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "config validate passed")
+	return nil
+}
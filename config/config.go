@@ -19,26 +19,47 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/errors"
 	"os"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 )
 
 // 程序配置文件
 type Config struct {
-	*flag.FlagSet `json:"-"`
-	AppConfig     AppConfig    `toml:"app" json:"app"`
-	FullConfig    FullConfig   `toml:"full" json:"full"`
-	CSVConfig     CSVConfig    `toml:"csv" json:"csv"`
-	AllConfig     AllConfig    `toml:"all" json:"all"`
-	OracleConfig  OracleConfig `toml:"oracle" json:"oracle"`
-	MySQLConfig   MySQLConfig  `toml:"mysql" json:"mysql"`
-	LogConfig     LogConfig    `toml:"log" json:"log"`
-	DiffConfig    DiffConfig   `toml:"diff" json:"diff"`
-	ConfigFile    string       `json:"config-file"`
-	PrintVersion  bool
-	Mode          string `json:"mode"`
+	*flag.FlagSet  `json:"-"`
+	AppConfig      AppConfig      `toml:"app" json:"app"`
+	FullConfig     FullConfig     `toml:"full" json:"full"`
+	CSVConfig      CSVConfig      `toml:"csv" json:"csv"`
+	AllConfig      AllConfig      `toml:"all" json:"all"`
+	OracleConfig   OracleConfig   `toml:"oracle" json:"oracle"`
+	MySQLConfig    MySQLConfig    `toml:"mysql" json:"mysql"`
+	MetaConfig     MetaConfig     `toml:"meta" json:"meta"`
+	ServerConfig   ServerConfig   `toml:"server" json:"server"`
+	PostgresConfig PostgresConfig `toml:"postgres" json:"postgres"`
+	LogConfig      LogConfig      `toml:"log" json:"log"`
+	DiffConfig     DiffConfig     `toml:"diff" json:"diff"`
+	TableRules     []TableRule    `toml:"table-rule" json:"table-rule"`
+	ConfigFile     string         `json:"config-file"`
+	PrintVersion   bool
+	Mode           string `json:"mode"`
+	RuleAction     string `json:"rule-action"`
+	RuleFile       string `json:"rule-file"`
+	RuleDBTypeS    string `json:"rule-db-type-s"`
+	RuleDBTypeT    string `json:"rule-db-type-t"`
+	DryRun         bool   `json:"dry-run"`
+	TaskAction     string `json:"task-action"`
+	TaskTables     string `json:"task-tables"`
+	TaskAllTables  bool   `json:"task-all-tables"`
+	ConfigAction   string `json:"config-action"`
+	// 命令行 --set 配置覆盖项
+	Overrides overrideFlag `json:"-"`
+	// 配置文件未识别配置项，例如拼写错误
+	UnknownKeys []string `json:"-"`
+	// 环境变量覆盖的配置项
+	EnvKeys []string `json:"-"`
 }
 
 type AppConfig struct {
@@ -46,6 +67,11 @@ type AppConfig struct {
 	SlowlogThreshold int    `toml:"slowlog-threshold" json:"slowlog-threshold"`
 	Threads          int    `toml:"threads" json:"threads"`
 	PprofPort        string `toml:"pprof-port" json:"pprof-port"`
+	ProgressInterval int    `toml:"progress-interval" json:"progress-interval"`
+	// check 文件输出格式 SECTION/TABLE
+	CheckTextFormat string `toml:"check-text-format" json:"check-text-format"`
+	// 加密密码 ENC(...) 解密密钥文件
+	EncryptionKeyFile string `toml:"encryption-key-file" json:"encryption-key-file"`
 }
 
 type DiffConfig struct {
@@ -64,28 +90,78 @@ type TableConfig struct {
 	Range       string `toml:"range" json:"range"`
 }
 
+// 表级别数据迁移规则，作用于 full/csv/compare 模式
+//   - column-fields 指定迁移字段子集，未配置迁移全部字段
+//   - where 指定数据过滤条件（Oracle 语法）
+//   - where-target 指定 compare 目标端数据过滤条件（MySQL 语法），未配置沿用 where
+//   - column-rule 指定字段值转换规则
+type TableRule struct {
+	SourceTable  string       `toml:"source-table" json:"source-table"`
+	ColumnFields []string     `toml:"column-fields" json:"column-fields"`
+	Where        string       `toml:"where" json:"where"`
+	WhereTarget  string       `toml:"where-target" json:"where-target"`
+	ColumnRules  []ColumnRule `toml:"column-rule" json:"column-rule"`
+}
+
+// 字段值转换规则 hash/redact/trim/remap
+type ColumnRule struct {
+	ColumnName string            `toml:"column-name" json:"column-name"`
+	Transform  string            `toml:"transform" json:"transform"`
+	Value      string            `toml:"value" json:"value"`
+	Mapping    map[string]string `toml:"mapping" json:"mapping"`
+}
+
 type CSVConfig struct {
-	Header           bool   `toml:"header" json:"header"`
-	Separator        string `toml:"separator" json:"separator"`
-	Terminator       string `toml:"terminator" json:"terminator"`
-	Delimiter        string `toml:"delimiter" json:"delimiter"`
-	EscapeBackslash  bool   `toml:"escape-backslash" json:"escape-backslash"`
-	Charset          string `toml:"charset" json:"charset"`
-	Rows             int    `toml:"rows" json:"rows"`
-	OutputDir        string `toml:"output-dir" json:"output-dir"`
-	TaskThreads      int    `toml:"task-threads" json:"task-threads"`
-	TableThreads     int    `toml:"table-threads" json:"table-threads"`
-	SQLThreads       int    `toml:"sql-threads" json:"sql-threads"`
-	EnableCheckpoint bool   `toml:"enable-checkpoint" json:"enable-checkpoint"`
+	Header             bool     `toml:"header" json:"header"`
+	Separator          string   `toml:"separator" json:"separator"`
+	Terminator         string   `toml:"terminator" json:"terminator"`
+	Delimiter          string   `toml:"delimiter" json:"delimiter"`
+	EscapeBackslash    bool     `toml:"escape-backslash" json:"escape-backslash"`
+	Charset            string   `toml:"charset" json:"charset"`
+	Rows               int      `toml:"rows" json:"rows"`
+	OutputDir          string   `toml:"output-dir" json:"output-dir"`
+	TaskThreads        int      `toml:"task-threads" json:"task-threads"`
+	TableThreads       int      `toml:"table-threads" json:"table-threads"`
+	SQLThreads         int      `toml:"sql-threads" json:"sql-threads"`
+	EnableCheckpoint   bool     `toml:"enable-checkpoint" json:"enable-checkpoint"`
+	FileFormat         string   `toml:"file-format" json:"file-format"`
+	LightningLayout    bool     `toml:"lightning-layout" json:"lightning-layout"`
+	Compress           string   `toml:"compress" json:"compress"`
+	MaxFileSize        int      `toml:"max-file-size" json:"max-file-size"`
+	NullValue          string   `toml:"null-value" json:"null-value"`
+	BinaryFormat       string   `toml:"binary-format" json:"binary-format"`
+	DateFormat         string   `toml:"date-format" json:"date-format"`
+	TimestampFormat    string   `toml:"timestamp-format" json:"timestamp-format"`
+	ConsistentSnapshot bool     `toml:"consistent-snapshot" json:"consistent-snapshot"`
+	S3                 S3Config `toml:"s3" json:"s3"`
+}
+
+// S3 兼容对象存储，output-dir 以 s3://{bucket}/{prefix} 指定
+type S3Config struct {
+	Endpoint       string `toml:"endpoint" json:"endpoint"`
+	Region         string `toml:"region" json:"region"`
+	AccessKey      string `toml:"access-key" json:"access-key"`
+	SecretKey      string `toml:"secret-key" json:"secret-key"`
+	ForcePathStyle bool   `toml:"force-path-style" json:"force-path-style"`
+	PartSize       int    `toml:"part-size" json:"part-size"`
+	MaxRetries     int    `toml:"max-retries" json:"max-retries"`
 }
 
 type FullConfig struct {
-	ChunkSize        int  `toml:"chunk-size" json:"chunk-size"`
-	TaskThreads      int  `toml:"task-threads" json:"task-threads"`
-	TableThreads     int  `toml:"table-threads" json:"table-threads"`
-	SQLThreads       int  `toml:"sql-threads" json:"sql-threads"`
-	ApplyThreads     int  `toml:"apply-threads" json:"apply-threads"`
-	EnableCheckpoint bool `toml:"enable-checkpoint" json:"enable-checkpoint"`
+	ChunkSize           int    `toml:"chunk-size" json:"chunk-size"`
+	TaskThreads         int    `toml:"task-threads" json:"task-threads"`
+	TableThreads        int    `toml:"table-threads" json:"table-threads"`
+	SQLThreads          int    `toml:"sql-threads" json:"sql-threads"`
+	ApplyThreads        int    `toml:"apply-threads" json:"apply-threads"`
+	EnableCheckpoint    bool   `toml:"enable-checkpoint" json:"enable-checkpoint"`
+	ConsistentSnapshot  bool   `toml:"consistent-snapshot" json:"consistent-snapshot"`
+	AdaptiveChunk       bool   `toml:"adaptive-chunk" json:"adaptive-chunk"`
+	ChunkTargetSize     int    `toml:"chunk-target-size" json:"chunk-target-size"`
+	AdaptiveConcurrency bool   `toml:"adaptive-concurrency" json:"adaptive-concurrency"`
+	ApplyRetryTimes     int    `toml:"apply-retry-times" json:"apply-retry-times"`
+	ApplyRetryInterval  int    `toml:"apply-retry-interval" json:"apply-retry-interval"`
+	SkipBadRows         bool   `toml:"skip-bad-rows" json:"skip-bad-rows"`
+	ApplyMode           string `toml:"apply-mode" json:"apply-mode"`
 }
 
 type AllConfig struct {
@@ -97,25 +173,37 @@ type AllConfig struct {
 }
 
 type OracleConfig struct {
-	OraArch       string   `toml:"ora-arch" json:"ora-arch"`
-	Username      string   `toml:"username" json:"username"`
-	Password      string   `toml:"password" json:"password"`
-	Host          string   `toml:"host" json:"host"`
-	Port          int      `toml:"port" json:"port"`
-	ServiceName   string   `toml:"service-name" json:"service-name"`
-	LibDir        string   `toml:"lib-dir" json:"lib-dir"`
-	NLSLang       string   `toml:"nls-lang" json:"nls-lang"`
-	ConnectParams string   `toml:"connect-params" json:"connect-params"`
-	SessionParams []string `toml:"session-params" json:"session-params"`
-	SchemaName    string   `toml:"schema-name" json:"schema-name"`
-	IncludeTable  []string `toml:"include-table" json:"include-table"`
-	ExcludeTable  []string `toml:"exclude-table" json:"exclude-table"`
+	OraArch        string   `toml:"ora-arch" json:"ora-arch"`
+	Username       string   `toml:"username" json:"username"`
+	Password       string   `toml:"password" json:"password"`
+	PasswordEnv    string   `toml:"password-env" json:"password-env"`
+	PasswordFile   string   `toml:"password-file" json:"password-file"`
+	WalletDir      string   `toml:"wallet-dir" json:"wallet-dir"`
+	WalletPassword string   `toml:"wallet-password" json:"wallet-password"`
+	SSL            bool     `toml:"ssl" json:"ssl"`
+	SSLSkipVerify  bool     `toml:"ssl-skip-verify" json:"ssl-skip-verify"`
+	Host           string   `toml:"host" json:"host"`
+	Port           int      `toml:"port" json:"port"`
+	ServiceName    string   `toml:"service-name" json:"service-name"`
+	LibDir         string   `toml:"lib-dir" json:"lib-dir"`
+	NLSLang        string   `toml:"nls-lang" json:"nls-lang"`
+	ConnectParams  string   `toml:"connect-params" json:"connect-params"`
+	SessionParams  []string `toml:"session-params" json:"session-params"`
+	SchemaName     string   `toml:"schema-name" json:"schema-name"`
+	IncludeTable   []string `toml:"include-table" json:"include-table"`
+	ExcludeTable   []string `toml:"exclude-table" json:"exclude-table"`
 }
 
 type MySQLConfig struct {
 	DBType        string `toml:"db-type" json:"db-type"`
 	Username      string `toml:"username" json:"username"`
 	Password      string `toml:"password" json:"password"`
+	PasswordEnv   string `toml:"password-env" json:"password-env"`
+	PasswordFile  string `toml:"password-file" json:"password-file"`
+	SSLCA         string `toml:"ssl-ca" json:"ssl-ca"`
+	SSLCert       string `toml:"ssl-cert" json:"ssl-cert"`
+	SSLKey        string `toml:"ssl-key" json:"ssl-key"`
+	SSLSkipVerify bool   `toml:"ssl-skip-verify" json:"ssl-skip-verify"`
 	Host          string `toml:"host" json:"host"`
 	Port          int    `toml:"port" json:"port"`
 	ConnectParams string `toml:"connect-params" json:"connect-params"`
@@ -125,9 +213,45 @@ type MySQLConfig struct {
 	Overwrite     bool   `toml:"overwrite" json:"overwrite"`
 }
 
+// 元数据库存储配置
+// db-type 为 mysql 时元数据库沿用 [mysql] 连接以及 meta-schema，db-type 为 sqlite 时使用内嵌 SQLite 文件
+type MetaConfig struct {
+	DBType     string `toml:"db-type" json:"db-type"`
+	SQLiteFile string `toml:"sqlite-file" json:"sqlite-file"`
+	// 只读打开，不创建元数据库以及目标库，dry-run 使用
+	ReadOnly bool `toml:"-" json:"-"`
+}
+
+// server 模式 HTTP API 服务配置
+// HTTP API 需鉴权，token 与 ssl-ca（mTLS）至少配置其一
+type ServerConfig struct {
+	Addr        string `toml:"addr" json:"addr"`
+	Concurrency int    `toml:"concurrency" json:"concurrency"`
+	Token       string `toml:"token" json:"token"`
+	SSLCA       string `toml:"ssl-ca" json:"ssl-ca"`
+	SSLCert     string `toml:"ssl-cert" json:"ssl-cert"`
+	SSLKey      string `toml:"ssl-key" json:"ssl-key"`
+}
+
+// reverseO2P 仅生成 PostgreSQL DDL 文件，不连接目标端；checkO2P 连接目标端校验表结构
+type PostgresConfig struct {
+	Username      string `toml:"username" json:"username"`
+	Password      string `toml:"password" json:"password"`
+	PasswordEnv   string `toml:"password-env" json:"password-env"`
+	PasswordFile  string `toml:"password-file" json:"password-file"`
+	Host          string `toml:"host" json:"host"`
+	Port          int    `toml:"port" json:"port"`
+	DBName        string `toml:"db-name" json:"db-name"`
+	SSLMode       string `toml:"ssl-mode" json:"ssl-mode"`
+	ConnectParams string `toml:"connect-params" json:"connect-params"`
+	SchemaName    string `toml:"schema-name" json:"schema-name"`
+}
+
 type LogConfig struct {
 	LogLevel   string `toml:"log-level" json:"log-level"`
 	LogFile    string `toml:"log-file" json:"log-file"`
+	LogFormat  string `toml:"log-format" json:"log-format"`
+	LogStdout  bool   `toml:"log-stdout" json:"log-stdout"`
 	MaxSize    int    `toml:"max-size" json:"max-size"`
 	MaxDays    int    `toml:"max-days" json:"max-days"`
 	MaxBackups int    `toml:"max-backups" json:"max-backups"`
@@ -139,12 +263,24 @@ func NewConfig() *Config {
 	fs := cfg.FlagSet
 	fs.Usage = func() {
 		fmt.Fprintln(os.Stderr, "Usage of transferdb:")
+		fmt.Fprintln(os.Stderr, "  transferdb [flags]")
+		fmt.Fprintln(os.Stderr, "  transferdb task [status|reset|errors list|errors clear|resume] --config config.toml --mode full [--table T1,T2] [--all-tables]")
+		fmt.Fprintln(os.Stderr, "  transferdb secret [keygen|encrypt] --key-file ./transferdb.key")
+		fmt.Fprintln(os.Stderr, "  transferdb config check --config config.toml [--mode full] [--set section.key=value]")
+		fmt.Fprintln(os.Stderr, "  transferdb rules [validate|sync|export] --config config.toml --rule-file rules.toml")
 		fs.
 			PrintDefaults()
 	}
 	fs.BoolVar(&cfg.PrintVersion, "V", false, "print version information and exit")
 	fs.StringVar(&cfg.ConfigFile, "config", "./config.toml", "path to the configuration file")
-	fs.StringVar(&cfg.Mode, "mode", "", "specify the program running mode: [prepare assess reverseO2M reverseM2O full csv all check diff]")
+	fs.StringVar(&cfg.Mode, "mode", "", "specify the program running mode: [prepare assess reverseO2M reverseO2P reverseM2O full csv all check checkO2P diff server]")
+	fs.StringVar(&cfg.RuleFile, "rule-file", "./rules.toml", "rules subcommand declarative rule file path, .toml or .yaml/.yml")
+	fs.StringVar(&cfg.RuleDBTypeS, "rule-db-type-s", "", "rules export subcommand source db type, default oracle")
+	fs.StringVar(&cfg.RuleDBTypeT, "rule-db-type-t", "", "rules export subcommand target db type, default [mysql] db-type")
+	fs.BoolVar(&cfg.DryRun, "dry-run", false, "full/csv/all/compare mode only run pre-check and print the plan without side effects")
+	fs.StringVar(&cfg.TaskTables, "table", "", "task subcommand source table names, separated by comma, default all tables")
+	fs.BoolVar(&cfg.TaskAllTables, "all-tables", false, "task reset subcommand confirm resetting all tables of the schema when flag [table] is empty")
+	fs.Var(&cfg.Overrides, "set", "override config item with format section.key=value, e.g. --set full.chunk-size=1000, can be specified multiple times, priority: --set > env TRANSFERDB_{SECTION}_{KEY} > config file")
 
 	return cfg
 }
@@ -168,22 +304,125 @@ func (cfg *Config) Parse(args []string) error {
 		if err = cfg.configFromFile(cfg.ConfigFile); err != nil {
 			return err
 		}
+		// 配置优先级 --set > 环境变量 > 配置文件
+		if err = cfg.applyEnvOverrides(); err != nil {
+			return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_CONFIG, err)
+		}
+		if err = cfg.applySetOverrides(); err != nil {
+			return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_CONFIG, err)
+		}
+		if err = cfg.ResolveSecrets(); err != nil {
+			return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_CONFIG, err)
+		}
 	} else {
 		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_CONFIG, fmt.Errorf("no config file"))
 	}
 	return nil
 }
 
+// 解析任务管理子命令 transferdb task <action> [flags]，flag 之前的参数作为子命令
+func (cfg *Config) ParseTask(args []string) error {
+	var actions []string
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		actions = append(actions, strings.ToLower(args[0]))
+		args = args[1:]
+	}
+	if len(actions) == 0 {
+		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_CONFIG, fmt.Errorf("task subcommand can not null, please configure [%s/%s/%s/%s/%s]",
+			common.TaskActionStatus, common.TaskActionReset, common.TaskActionErrorsList, common.TaskActionErrorsClear, common.TaskActionResume))
+	}
+	cfg.TaskAction = strings.Join(actions, " ")
+	return cfg.Parse(args)
+}
+
+// 解析配置管理子命令 transferdb config <action> [flags]
+func (cfg *Config) ParseConfig(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_CONFIG, fmt.Errorf("config subcommand can not null, please configure [%s]", ConfigActionCheck))
+	}
+	cfg.ConfigAction = strings.ToLower(args[0])
+	if cfg.ConfigAction != ConfigActionCheck {
+		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_CONFIG, fmt.Errorf("config subcommand [%s] isn't support, only support [%s]", args[0], ConfigActionCheck))
+	}
+	return cfg.Parse(args[1:])
+}
+
+// 解析声明式规则文件子命令 transferdb rules <action> [flags]
+func (cfg *Config) ParseRules(args []string) error {
+	actions := []string{common.RuleActionValidate, common.RuleActionSync, common.RuleActionExport}
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_CONFIG, fmt.Errorf("rules subcommand can not null, please configure [%s]", strings.Join(actions, "/")))
+	}
+	cfg.RuleAction = strings.ToLower(args[0])
+	if !common.IsContainString(actions, cfg.RuleAction) {
+		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_CONFIG, fmt.Errorf("rules subcommand [%s] isn't support, only support [%s]", args[0], strings.Join(actions, "/")))
+	}
+	if err := cfg.Parse(args[1:]); err != nil {
+		return err
+	}
+	// 子命令即运行模式，用于配置校验 section
+	cfg.Mode = "rules"
+	return nil
+}
+
+// 任务管理子命令指定源端表名，未指定返回空
+func (cfg *Config) GetTaskTables() []string {
+	var tables []string
+	for _, t := range strings.Split(cfg.TaskTables, ",") {
+		if strings.TrimSpace(t) != "" {
+			tables = append(tables, common.StringUPPER(strings.TrimSpace(t)))
+		}
+	}
+	return tables
+}
+
 // 加载配置文件并解析
 func (c *Config) configFromFile(file string) error {
-	if _, err := toml.DecodeFile(file, c); err != nil {
+	md, err := toml.DecodeFile(file, c)
+	if err != nil {
 		return fmt.Errorf("failed decode toml config file %s: %v", file, err)
 	}
+	for _, key := range md.Undecoded() {
+		c.UnknownKeys = append(c.UnknownKeys, key.String())
+	}
+	// csv null-value 未配置默认 NULL，配置为空字符串表示 NULL 输出空字段
+	if !md.IsDefined("csv", "null-value") {
+		c.CSVConfig.NullValue = common.CSVDefaultNullValue
+	}
 	return nil
 }
 
+// 获取源端表数据迁移规则，未配置返回 false
+func (c *Config) GetTableRule(sourceTable string) (TableRule, bool) {
+	for _, rule := range c.TableRules {
+		if strings.EqualFold(rule.SourceTable, sourceTable) {
+			return rule, true
+		}
+	}
+	return TableRule{}, false
+}
+
+// 获取目标端数据过滤条件，未配置 where-target 沿用 where
+func (r TableRule) GetWhereTarget() string {
+	if strings.TrimSpace(r.WhereTarget) != "" {
+		return r.WhereTarget
+	}
+	return r.Where
+}
+
+// 获取字段值转换规则，未配置返回 false
+func (r TableRule) GetColumnRule(columnName string) (ColumnRule, bool) {
+	for _, rule := range r.ColumnRules {
+		if strings.EqualFold(rule.ColumnName, columnName) {
+			return rule, true
+		}
+	}
+	return ColumnRule{}, false
+}
+
+// 配置 JSON 输出，密码以及密钥脱敏
 func (c *Config) String() string {
-	cfg, err := json.Marshal(c)
+	cfg, err := json.Marshal(c.Redacted())
 	if err != nil {
 		return "<nil>"
 	}
@@ -0,0 +1,177 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// 配置环境变量前缀，变量名 TRANSFERDB_{SECTION}_{KEY}，例如 TRANSFERDB_FULL_CHUNK_SIZE、TRANSFERDB_CSV_S3_SECRET_KEY
+const ConfigEnvPrefix = "TRANSFERDB_"
+
+// --set 命令行配置覆盖项，格式 section.key=value，可多次指定
+type overrideFlag []string
+
+func (o *overrideFlag) String() string {
+	return strings.Join(*o, ",")
+}
+
+func (o *overrideFlag) Set(value string) error {
+	*o = append(*o, value)
+	return nil
+}
+
+// 配置项环境变量名
+func ConfigEnvName(key string) string {
+	return ConfigEnvPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// 环境变量覆盖配置文件，仅支持字符串、数值、布尔以及字符串数组（逗号分隔）配置项，覆盖配置项记录至 EnvKeys 便于日志以及 config check 输出来源
+func (c *Config) applyEnvOverrides() error {
+	return walkConfigFields(reflect.ValueOf(c).Elem(), "", func(key string, field reflect.Value) error {
+		envName := ConfigEnvName(key)
+		value, ok := os.LookupEnv(envName)
+		if !ok {
+			return nil
+		}
+		if !isOverridable(field) {
+			return fmt.Errorf("config environment variable [%s] config item [%s] type [%s] isn't support override", envName, key, field.Type())
+		}
+		if err := setConfigField(field, value); err != nil {
+			return fmt.Errorf("config environment variable [%s] override failed: %v", envName, err)
+		}
+		c.EnvKeys = append(c.EnvKeys, key)
+		return nil
+	})
+}
+
+// 命令行 --set 覆盖环境变量以及配置文件
+func (c *Config) applySetOverrides() error {
+	for _, set := range c.Overrides {
+		idx := strings.Index(set, "=")
+		if idx <= 0 {
+			return fmt.Errorf("flag [set] value [%s] format isn't section.key=value", set)
+		}
+		key, value := strings.ToLower(strings.TrimSpace(set[:idx])), set[idx+1:]
+		var found bool
+		err := walkConfigFields(reflect.ValueOf(c).Elem(), "", func(k string, field reflect.Value) error {
+			// 数组表配置项，例如 [[table-rule]]，其下 table-rule.xxx 同样不支持覆盖
+			if k != key && !(isStructSlice(field) && strings.HasPrefix(key, k+".")) {
+				return nil
+			}
+			found = true
+			if !isOverridable(field) {
+				return fmt.Errorf("flag [set] config item [%s] type [%s] isn't support override", key, field.Type())
+			}
+			if err := setConfigField(field, value); err != nil {
+				return fmt.Errorf("flag [set] config item [%s] override failed: %v", key, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("flag [set] config item [%s] isn't exist", key)
+		}
+	}
+	return nil
+}
+
+// 遍历 toml 配置项，key 为 section.key 格式，嵌套 section 递归，例如 csv.s3.endpoint
+// 数组表配置项（例如 [[table-rule]]）不展开，整体交由 fn 处理，fn 返回错误终止遍历
+func walkConfigFields(v reflect.Value, prefix string, fn func(key string, field reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("toml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := walkConfigFields(field, prefix+name+".", fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(prefix+name, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isStructSlice(field reflect.Value) bool {
+	if field.Kind() != reflect.Slice {
+		return false
+	}
+	elem := field.Type().Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Struct
+}
+
+func isOverridable(field reflect.Value) bool {
+	switch field.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return true
+	case reflect.Slice:
+		return field.Type().Elem().Kind() == reflect.String
+	default:
+		return false
+	}
+}
+
+func setConfigField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("value [%s] isn't bool", value)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("value [%s] isn't integer", value)
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("value [%s] isn't unsigned integer", value)
+		}
+		field.SetUint(n)
+	case reflect.Slice:
+		var items []string
+		for _, s := range strings.Split(value, ",") {
+			if strings.TrimSpace(s) != "" {
+				items = append(items, strings.TrimSpace(s))
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("type [%s] isn't support", field.Type())
+	}
+	return nil
+}
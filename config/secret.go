@@ -0,0 +1,281 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// 加密密码格式 ENC(base64(nonce+ciphertext))，AES-256-GCM
+	EncryptedSecretPrefix = "ENC("
+	EncryptedSecretSuffix = ")"
+	// 日志以及字符串输出脱敏显示
+	RedactedSecret = "******"
+	// 密钥文件内容为 32 字节密钥 hex 编码
+	secretKeySize = 32
+)
+
+// 解析配置文件内密码，优先级 password-env > password-file > password，ENC(...) 格式使用 [app] encryption-key-file 解密
+// 多次调用结果一致，server 模式任务配置覆盖后重复解析
+func (c *Config) ResolveSecrets() error {
+	var key []byte
+	decrypt := func(name, value string) (string, error) {
+		if !IsEncryptedSecret(value) {
+			return value, nil
+		}
+		if key == nil {
+			if c.AppConfig.EncryptionKeyFile == "" {
+				return "", fmt.Errorf("config [%s] is encrypted, but [app] encryption-key-file isn't configured", name)
+			}
+			k, err := ReadSecretKey(c.AppConfig.EncryptionKeyFile)
+			if err != nil {
+				return "", err
+			}
+			key = k
+		}
+		plain, err := DecryptSecret(value, key)
+		if err != nil {
+			return "", fmt.Errorf("config [%s] decrypt failed: %v", name, err)
+		}
+		return plain, nil
+	}
+
+	var err error
+	if c.OracleConfig.Password, err = resolvePassword(c.OracleConfig.Password, c.OracleConfig.PasswordEnv, c.OracleConfig.PasswordFile); err != nil {
+		return fmt.Errorf("config [oracle] password resolve failed: %v", err)
+	}
+	if c.OracleConfig.Password, err = decrypt("oracle password", c.OracleConfig.Password); err != nil {
+		return err
+	}
+	if c.OracleConfig.WalletPassword, err = decrypt("oracle wallet-password", c.OracleConfig.WalletPassword); err != nil {
+		return err
+	}
+	if c.MySQLConfig.Password, err = resolvePassword(c.MySQLConfig.Password, c.MySQLConfig.PasswordEnv, c.MySQLConfig.PasswordFile); err != nil {
+		return fmt.Errorf("config [mysql] password resolve failed: %v", err)
+	}
+	if c.MySQLConfig.Password, err = decrypt("mysql password", c.MySQLConfig.Password); err != nil {
+		return err
+	}
+	if c.PostgresConfig.Password, err = resolvePassword(c.PostgresConfig.Password, c.PostgresConfig.PasswordEnv, c.PostgresConfig.PasswordFile); err != nil {
+		return fmt.Errorf("config [postgres] password resolve failed: %v", err)
+	}
+	if c.PostgresConfig.Password, err = decrypt("postgres password", c.PostgresConfig.Password); err != nil {
+		return err
+	}
+	if c.ServerConfig.Token, err = decrypt("server token", c.ServerConfig.Token); err != nil {
+		return err
+	}
+	if c.CSVConfig.S3.SecretKey, err = decrypt("csv.s3 secret-key", c.CSVConfig.S3.SecretKey); err != nil {
+		return err
+	}
+	return nil
+}
+
+// 环境变量以及密码文件获取密码，密码文件去除末尾换行
+func resolvePassword(password, passwordEnv, passwordFile string) (string, error) {
+	if passwordEnv != "" {
+		value, ok := os.LookupEnv(passwordEnv)
+		if !ok {
+			return "", fmt.Errorf("password environment variable [%s] isn't exist", passwordEnv)
+		}
+		return value, nil
+	}
+	if passwordFile != "" {
+		content, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return "", fmt.Errorf("read password file [%s] failed: %v", passwordFile, err)
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	}
+	return password, nil
+}
+
+// 配置脱敏副本，密码以及密钥替换为 ******，用于日志以及字符串输出
+func (c *Config) Redacted() *Config {
+	r := *c
+	r.OracleConfig.Password = redactSecret(r.OracleConfig.Password)
+	r.OracleConfig.WalletPassword = redactSecret(r.OracleConfig.WalletPassword)
+	r.MySQLConfig.Password = redactSecret(r.MySQLConfig.Password)
+	r.PostgresConfig.Password = redactSecret(r.PostgresConfig.Password)
+	r.CSVConfig.S3.SecretKey = redactSecret(r.CSVConfig.S3.SecretKey)
+	r.ServerConfig.Token = redactSecret(r.ServerConfig.Token)
+	r.OracleConfig.ConnectParams = redactConnectParams(r.OracleConfig.ConnectParams)
+	r.MySQLConfig.ConnectParams = redactConnectParams(r.MySQLConfig.ConnectParams)
+	r.PostgresConfig.ConnectParams = redactConnectParams(r.PostgresConfig.ConnectParams)
+	return &r
+}
+
+// 连接参数脱敏，参数名包含 password/passwd/pwd/secret/token 的参数值替换为 ******，其余参数保持原样
+func redactConnectParams(params string) string {
+	if params == "" {
+		return params
+	}
+	pairs := strings.Split(params, "&")
+	for i, pair := range pairs {
+		key, _, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		lowerKey := strings.ToLower(key)
+		for _, sensitive := range []string{"password", "passwd", "pwd", "secret", "token"} {
+			if strings.Contains(lowerKey, sensitive) {
+				pairs[i] = key + "=" + RedactedSecret
+				break
+			}
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func redactSecret(s string) string {
+	if s == "" {
+		return s
+	}
+	return RedactedSecret
+}
+
+func IsEncryptedSecret(s string) bool {
+	return strings.HasPrefix(s, EncryptedSecretPrefix) && strings.HasSuffix(s, EncryptedSecretSuffix)
+}
+
+// 生成密钥文件，文件已存在报错，避免覆盖导致已加密密码无法解密
+func GenerateSecretKey(file string) error {
+	key := make([]byte, secretKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return fmt.Errorf("generate secret key failed: %v", err)
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("create secret key file [%s] failed: %v", file, err)
+	}
+	if _, err = f.WriteString(hex.EncodeToString(key) + "\n"); err != nil {
+		f.Close()
+		return fmt.Errorf("write secret key file [%s] failed: %v", file, err)
+	}
+	return f.Close()
+}
+
+// 读取密钥文件
+func ReadSecretKey(file string) ([]byte, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read secret key file [%s] failed: %v", file, err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil || len(key) != secretKeySize {
+		return nil, fmt.Errorf("secret key file [%s] content isn't %d bytes hex encoding", file, secretKeySize)
+	}
+	return key, nil
+}
+
+// 加密明文，输出 ENC(...) 格式
+func EncryptSecret(plain string, key []byte) (string, error) {
+	gcm, err := newSecretGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return EncryptedSecretPrefix + base64.StdEncoding.EncodeToString(sealed) + EncryptedSecretSuffix, nil
+}
+
+// 解密 ENC(...) 格式密文
+func DecryptSecret(secret string, key []byte) (string, error) {
+	if !IsEncryptedSecret(secret) {
+		return "", fmt.Errorf("secret isn't %s...%s format", EncryptedSecretPrefix, EncryptedSecretSuffix)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(secret, EncryptedSecretPrefix), EncryptedSecretSuffix))
+	if err != nil {
+		return "", fmt.Errorf("secret base64 decode failed: %v", err)
+	}
+	gcm, err := newSecretGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("secret ciphertext is too short")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("secret decrypt failed, please check encryption key file: %v", err)
+	}
+	return string(plain), nil
+}
+
+func newSecretGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// 密码加密子命令 transferdb secret [keygen|encrypt] --key-file ./transferdb.key
+// encrypt 从标准输入读取明文，输出 ENC(...) 格式密文，用于配置文件 password 等字段
+func RunSecret(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf("secret subcommand can not null, please configure [keygen/encrypt]")
+	}
+	action := strings.ToLower(args[0])
+	fs := flag.NewFlagSet("transferdb secret", flag.ContinueOnError)
+	keyFile := fs.String("key-file", "./transferdb.key", "path to the encryption key file")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	switch action {
+	case "keygen":
+		if err := GenerateSecretKey(*keyFile); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "encryption key file [%s] generated, please configure [app] encryption-key-file\n", *keyFile)
+		return nil
+	case "encrypt":
+		key, err := ReadSecretKey(*keyFile)
+		if err != nil {
+			return err
+		}
+		plain, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("read plaintext from stdin failed: %v", err)
+		}
+		plain = strings.TrimRight(plain, "\r\n")
+		if plain == "" {
+			return fmt.Errorf("plaintext from stdin can not null")
+		}
+		secret, err := EncryptSecret(plain, key)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, secret)
+		return nil
+	default:
+		return fmt.Errorf("secret subcommand [%s] isn't support, please configure [keygen/encrypt]", action)
+	}
+}
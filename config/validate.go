@@ -0,0 +1,330 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 各模式校验配置 section，mode 为空校验全部 section
+var (
+	// 连接 Oracle 模式
+	validateOracleModes = []string{"assess", "reverseo2m", "reverseo2p", "reversem2o", "check", "checko2p", "compare", "csv", "full", "all"}
+	// 连接 MySQL/TiDB 目标端模式
+	validateMySQLModes = []string{"reverseo2m", "reversem2o", "check", "compare", "full", "all"}
+	// 连接元数据库模式
+	validateMetaModes = []string{"prepare", "assess", "reverseo2m", "reverseo2p", "reversem2o", "check", "checko2p", "compare", "csv", "full", "all", "rules", "server"}
+)
+
+type validator struct {
+	errs []string
+}
+
+func (v *validator) addf(section, format string, args ...interface{}) {
+	v.errs = append(v.errs, fmt.Sprintf("[%s] %s", section, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) positive(section, key string, value int) {
+	if value <= 0 {
+		v.addf(section, "%s [%d] must be greater than 0", key, value)
+	}
+}
+
+func (v *validator) nonNegative(section, key string, value int) {
+	if value < 0 {
+		v.addf(section, "%s [%d] can't be negative", key, value)
+	}
+}
+
+func (v *validator) required(section, key, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.addf(section, "%s can't be null, please configure", key)
+	}
+}
+
+// 枚举值校验，忽略大小写，允许为空表示默认值
+func (v *validator) oneOf(section, key, value string, options ...string) {
+	if value == "" {
+		return
+	}
+	for _, o := range options {
+		if strings.EqualFold(value, o) {
+			return
+		}
+	}
+	v.addf(section, "%s [%s] isn't support, only support [%s]", key, value, strings.Join(options, "/"))
+}
+
+func (v *validator) port(section string, value int) {
+	if value <= 0 || value > 65535 {
+		v.addf(section, "port [%d] must be between 1 and 65535", value)
+	}
+}
+
+// 校验配置，按 --mode 校验对应 section，mode 为空校验全部 section，返回全部校验错误
+func (c *Config) Validate() error {
+	mode := strings.ToLower(strings.TrimSpace(c.Mode))
+	in := func(modes ...string) bool {
+		return mode == "" || common.IsContainString(modes, mode)
+	}
+	v := &validator{}
+
+	c.validateAppConfig(v, in)
+	c.validateLogConfig(v)
+	if in(validateMetaModes...) {
+		c.validateMetaConfig(v)
+	}
+	if in(validateOracleModes...) {
+		// assess 未配置 schema-name 评估全部 schema
+		c.validateOracleConfig(v, mode != "assess")
+	}
+	// 元数据库 db-type 为 mysql 时同样连接 [mysql]
+	if in(validateMySQLModes...) {
+		c.validateMySQLConfig(v, true)
+	} else if in(validateMetaModes...) && !strings.EqualFold(c.MetaConfig.DBType, common.MetaDBTypeSQLite) {
+		c.validateMySQLConfig(v, false)
+	}
+	if in("full", "all") {
+		c.validateFullConfig(v)
+	}
+	if in("all") {
+		c.validateAllConfig(v)
+	}
+	// 增量同步基于 logminer 重做 SQL，无法应用表级别字段子集、过滤条件以及转换规则，全量与增量数据将不一致
+	if mode == "all" && len(c.TableRules) > 0 {
+		v.addf("table-rule", "table-rule isn't support mode [all] incremental sync, please remove [[table-rule]] or use mode full")
+	}
+	if in("csv") {
+		c.validateCSVConfig(v)
+	}
+	if in("compare") {
+		c.validateDiffConfig(v)
+	}
+	if in("reverseo2p", "checko2p") {
+		c.validatePostgresConfig(v, in("checko2p"))
+	}
+	if in("server") {
+		c.validateServerConfig(v, mode == "server")
+	}
+
+	if len(v.errs) > 0 {
+		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_CONFIG,
+			fmt.Errorf("config validate failed:\n  - %s", strings.Join(v.errs, "\n  - ")))
+	}
+	return nil
+}
+
+func (c *Config) validateAppConfig(v *validator, in func(modes ...string) bool) {
+	if in("full", "all", "csv") {
+		v.positive("app", "insert-batch-size", c.AppConfig.InsertBatchSize)
+	}
+	if in("reverseo2m", "reverseo2p", "reversem2o", "check", "checko2p") {
+		v.positive("app", "threads", c.AppConfig.Threads)
+	}
+	if in("check", "checko2p") {
+		v.oneOf("app", "check-text-format", c.AppConfig.CheckTextFormat, common.CheckTextFormatSection, common.CheckTextFormatTable)
+	}
+	v.nonNegative("app", "slowlog-threshold", c.AppConfig.SlowlogThreshold)
+	v.nonNegative("app", "progress-interval", c.AppConfig.ProgressInterval)
+	if c.AppConfig.EncryptionKeyFile != "" {
+		if _, err := os.Stat(c.AppConfig.EncryptionKeyFile); err != nil {
+			v.addf("app", "encryption-key-file [%s] isn't accessible: %v", c.AppConfig.EncryptionKeyFile, err)
+		}
+	}
+}
+
+func (c *Config) validateLogConfig(v *validator) {
+	v.oneOf("log", "log-level", c.LogConfig.LogLevel, "debug", "info", "warn", "error", "dpanic", "panic", "fatal")
+	v.oneOf("log", "log-format", c.LogConfig.LogFormat, "text", "json")
+	v.nonNegative("log", "max-size", c.LogConfig.MaxSize)
+	v.nonNegative("log", "max-days", c.LogConfig.MaxDays)
+	v.nonNegative("log", "max-backups", c.LogConfig.MaxBackups)
+}
+
+func (c *Config) validateMetaConfig(v *validator) {
+	v.oneOf("meta", "db-type", c.MetaConfig.DBType, common.MetaDBTypeMySQL, common.MetaDBTypeSQLite)
+}
+
+func (c *Config) validateOracleConfig(v *validator, requireSchema bool) {
+	v.required("oracle", "username", c.OracleConfig.Username)
+	v.required("oracle", "host", c.OracleConfig.Host)
+	v.port("oracle", c.OracleConfig.Port)
+	v.required("oracle", "service-name", c.OracleConfig.ServiceName)
+	if requireSchema {
+		v.required("oracle", "schema-name", c.OracleConfig.SchemaName)
+	}
+	if len(c.OracleConfig.IncludeTable) > 0 && len(c.OracleConfig.ExcludeTable) > 0 {
+		v.addf("oracle", "include-table and exclude-table can't be configured at the same time")
+	}
+	if c.OracleConfig.SSLSkipVerify && !c.OracleConfig.SSL {
+		v.addf("oracle", "ssl-skip-verify only takes effect when ssl = true")
+	}
+}
+
+func (c *Config) validateMySQLConfig(v *validator, requireSchema bool) {
+	v.oneOf("mysql", "db-type", c.MySQLConfig.DBType, common.TaskDBMySQL, common.TaskDBTiDB)
+	v.required("mysql", "db-type", c.MySQLConfig.DBType)
+	v.required("mysql", "username", c.MySQLConfig.Username)
+	v.required("mysql", "host", c.MySQLConfig.Host)
+	v.port("mysql", c.MySQLConfig.Port)
+	if requireSchema {
+		v.required("mysql", "schema-name", c.MySQLConfig.SchemaName)
+	}
+	if !strings.EqualFold(c.MetaConfig.DBType, common.MetaDBTypeSQLite) {
+		v.required("mysql", "meta-schema", c.MySQLConfig.MetaSchema)
+	}
+	if (c.MySQLConfig.SSLCert == "") != (c.MySQLConfig.SSLKey == "") {
+		v.addf("mysql", "ssl-cert and ssl-key must be configured at the same time")
+	}
+}
+
+// server 模式 HTTP API 需鉴权，mTLS 需同时配置服务端证书
+func (c *Config) validateServerConfig(v *validator, requireAuth bool) {
+	v.nonNegative("server", "concurrency", c.ServerConfig.Concurrency)
+	if requireAuth && c.ServerConfig.Token == "" && c.ServerConfig.SSLCA == "" {
+		v.addf("server", "token or ssl-ca (mTLS) must be configured, http api requires authentication")
+	}
+	if (c.ServerConfig.SSLCert == "") != (c.ServerConfig.SSLKey == "") {
+		v.addf("server", "ssl-cert and ssl-key must be configured at the same time")
+	}
+	if c.ServerConfig.SSLCA != "" && c.ServerConfig.SSLCert == "" {
+		v.addf("server", "ssl-ca (mTLS) requires ssl-cert and ssl-key")
+	}
+}
+
+// reverseO2P 仅生成 DDL 文件只需 schema-name，checkO2P 连接目标端
+func (c *Config) validatePostgresConfig(v *validator, requireConn bool) {
+	v.required("postgres", "schema-name", c.PostgresConfig.SchemaName)
+	if !requireConn {
+		return
+	}
+	v.required("postgres", "username", c.PostgresConfig.Username)
+	v.required("postgres", "host", c.PostgresConfig.Host)
+	v.port("postgres", c.PostgresConfig.Port)
+	v.required("postgres", "db-name", c.PostgresConfig.DBName)
+	v.oneOf("postgres", "ssl-mode", c.PostgresConfig.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+}
+
+func (c *Config) validateFullConfig(v *validator) {
+	v.positive("full", "chunk-size", c.FullConfig.ChunkSize)
+	v.positive("full", "task-threads", c.FullConfig.TaskThreads)
+	v.positive("full", "table-threads", c.FullConfig.TableThreads)
+	v.positive("full", "sql-threads", c.FullConfig.SQLThreads)
+	v.positive("full", "apply-threads", c.FullConfig.ApplyThreads)
+	v.nonNegative("full", "chunk-target-size", c.FullConfig.ChunkTargetSize)
+	v.nonNegative("full", "apply-retry-times", c.FullConfig.ApplyRetryTimes)
+	v.nonNegative("full", "apply-retry-interval", c.FullConfig.ApplyRetryInterval)
+	v.oneOf("full", "apply-mode", c.FullConfig.ApplyMode, common.FullApplyModeInsert, common.FullApplyModeLoadData)
+}
+
+func (c *Config) validateAllConfig(v *validator) {
+	v.positive("all", "logminer-query-timeout", c.AllConfig.LogminerQueryTimeout)
+	v.positive("all", "filter-threads", c.AllConfig.FilterThreads)
+	v.positive("all", "apply-threads", c.AllConfig.ApplyThreads)
+	v.positive("all", "worker-queue", c.AllConfig.WorkerQueue)
+	v.positive("all", "worker-threads", c.AllConfig.WorkerThreads)
+}
+
+// csv 导出不连接目标端，仅需目标端 schema-name 以及 db-type（lightning 表结构方言）
+func (c *Config) validateCSVConfig(v *validator) {
+	v.required("mysql", "schema-name", c.MySQLConfig.SchemaName)
+	if strings.EqualFold(c.MetaConfig.DBType, common.MetaDBTypeSQLite) {
+		v.oneOf("mysql", "db-type", c.MySQLConfig.DBType, common.TaskDBMySQL, common.TaskDBTiDB)
+	}
+	v.positive("csv", "rows", c.CSVConfig.Rows)
+	v.positive("csv", "task-threads", c.CSVConfig.TaskThreads)
+	v.positive("csv", "table-threads", c.CSVConfig.TableThreads)
+	v.positive("csv", "sql-threads", c.CSVConfig.SQLThreads)
+	v.nonNegative("csv", "max-file-size", c.CSVConfig.MaxFileSize)
+	v.oneOf("csv", "file-format", c.CSVConfig.FileFormat, common.CSVFileFormatCSV, common.CSVFileFormatSQL, common.CSVFileFormatParquet)
+	// parquet 日期、时间戳以 TIMESTAMP_MICROS 类型存储，不支持自定义输出格式
+	if strings.EqualFold(c.CSVConfig.FileFormat, common.CSVFileFormatParquet) {
+		if c.CSVConfig.DateFormat != "" {
+			v.addf("csv", "date-format [%s] isn't support when file-format is [%s]", c.CSVConfig.DateFormat, c.CSVConfig.FileFormat)
+		}
+		if c.CSVConfig.TimestampFormat != "" {
+			v.addf("csv", "timestamp-format [%s] isn't support when file-format is [%s]", c.CSVConfig.TimestampFormat, c.CSVConfig.FileFormat)
+		}
+	}
+	v.oneOf("csv", "binary-format", c.CSVConfig.BinaryFormat, common.CSVBinaryFormatRaw, common.CSVBinaryFormatHex, common.CSVBinaryFormatBase64)
+	v.oneOf("csv", "charset", c.CSVConfig.Charset, common.UTF8CharacterSetCSV, common.GBKCharacterSetCSV)
+	v.oneOf("csv", "compress", c.CSVConfig.Compress, "GZIP", "ZSTD", "SNAPPY")
+	if c.CSVConfig.S3.PartSize != 0 && c.CSVConfig.S3.PartSize < 5 {
+		v.addf("csv.s3", "part-size [%d] must be at least 5", c.CSVConfig.S3.PartSize)
+	}
+	v.nonNegative("csv.s3", "max-retries", c.CSVConfig.S3.MaxRetries)
+
+	// 本地输出目录不存在运行时自动创建
+	outputDir := c.CSVConfig.OutputDir
+	if strings.TrimSpace(outputDir) == "" {
+		v.required("csv", "output-dir", outputDir)
+		return
+	}
+	localDir := outputDir
+	if strings.Contains(outputDir, "://") {
+		u, err := url.Parse(outputDir)
+		if err != nil {
+			v.addf("csv", "output-dir [%s] parse failed: %v", outputDir, err)
+			return
+		}
+		switch strings.ToLower(u.Scheme) {
+		case "file", "local":
+			localDir = u.Path
+		case "s3":
+			if u.Host == "" {
+				v.addf("csv", "output-dir [%s] bucket can't be null", outputDir)
+			}
+			return
+		default:
+			v.addf("csv", "output-dir [%s] scheme [%s] isn't support, only support local path, file:// and s3://", outputDir, u.Scheme)
+			return
+		}
+	}
+	// 目录不存在时向上查找已存在的上级路径，非目录运行时无法创建
+	for dir := filepath.Clean(localDir); ; dir = filepath.Dir(dir) {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				v.addf("csv", "output-dir [%s] path [%s] isn't a directory", outputDir, dir)
+			}
+			return
+		}
+		if !os.IsNotExist(err) {
+			v.addf("csv", "output-dir [%s] isn't accessible: %v", outputDir, err)
+			return
+		}
+		if filepath.Dir(dir) == dir {
+			return
+		}
+	}
+}
+
+func (c *Config) validateDiffConfig(v *validator) {
+	v.positive("diff", "chunk-size", c.DiffConfig.ChunkSize)
+	v.positive("diff", "diff-threads", c.DiffConfig.DiffThreads)
+	v.required("diff", "fix-sql-file", c.DiffConfig.FixSqlFile)
+	for i, t := range c.DiffConfig.TableConfig {
+		if strings.TrimSpace(t.SourceTable) == "" {
+			v.addf("diff", "table-config [%d] source-table can't be null", i)
+		}
+	}
+}
@@ -516,6 +516,460 @@ func (rw *BuildinDatatypeRule) InitO2MBuildinDatatypeRule(ctx context.Context) e
 	}).CreateInBatches(buildinDataTypeR, 20).Error
 }
 
+func (rw *BuildinDatatypeRule) InitO2PBuildinDatatypeRule(ctx context.Context) error {
+	var buildinDataTypeR []*BuildinDatatypeRule
+	/*
+		O2P Build-IN Compatible Rule
+	*/
+	// oracle column datatype name
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeNumber,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeNumber],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeBfile,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeBfile],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeChar,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeChar],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeCharacter,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeCharacter],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeClob,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeClob],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeBlob,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeBlob],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeDate,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeDate],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeDecimal,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeDecimal],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeDec,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeDec],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeDoublePrecision,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeDoublePrecision],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeFloat,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeFloat],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeInteger,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeInteger],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeInt,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeInt],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeLong,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeLong],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeLongRAW,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeLongRAW],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeBinaryFloat,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeBinaryFloat],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeBinaryDouble,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeBinaryDouble],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeNchar,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeNchar],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeNcharVarying,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeNcharVarying],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeNclob,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeNclob],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeNumeric,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeNumeric],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeNvarchar2,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeNvarchar2],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeRaw,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeRaw],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeReal,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeReal],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeRowid,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeRowid],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeUrowid,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeUrowid],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeSmallint,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeSmallint],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeVarchar2,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeVarchar2],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeVarchar,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeVarchar],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeXmltype,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeXmltype],
+	})
+
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestamp,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestamp],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestamp0,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestamp0],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestamp1,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestamp1],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestamp2,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestamp2],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestamp3,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestamp3],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestamp4,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestamp4],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestamp5,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestamp5],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestamp6,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestamp6],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestamp7,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestamp7],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestamp8,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestamp8],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestamp9,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestamp9],
+	})
+
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeIntervalYearMonth0,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeIntervalYearMonth0],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeIntervalYearMonth1,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeIntervalYearMonth1],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeIntervalYearMonth2,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeIntervalYearMonth2],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeIntervalYearMonth3,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeIntervalYearMonth3],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeIntervalYearMonth4,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeIntervalYearMonth4],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeIntervalYearMonth5,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeIntervalYearMonth5],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeIntervalYearMonth6,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeIntervalYearMonth6],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeIntervalYearMonth7,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeIntervalYearMonth7],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeIntervalYearMonth8,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeIntervalYearMonth8],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeIntervalYearMonth9,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeIntervalYearMonth9],
+	})
+
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithTimeZone0,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithTimeZone0],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithTimeZone1,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithTimeZone1],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithTimeZone2,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithTimeZone2],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithTimeZone3,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithTimeZone3],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithTimeZone4,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithTimeZone4],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithTimeZone5,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithTimeZone5],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithTimeZone6,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithTimeZone6],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithTimeZone7,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithTimeZone7],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithTimeZone8,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithTimeZone8],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithTimeZone9,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithTimeZone9],
+	})
+
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithLocalTimeZone0,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithLocalTimeZone0],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithLocalTimeZone1,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithLocalTimeZone1],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithLocalTimeZone2,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithLocalTimeZone2],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithLocalTimeZone3,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithLocalTimeZone3],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithLocalTimeZone4,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithLocalTimeZone4],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithLocalTimeZone5,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithLocalTimeZone5],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithLocalTimeZone6,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithLocalTimeZone6],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithLocalTimeZone7,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithLocalTimeZone7],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithLocalTimeZone8,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithLocalTimeZone8],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeTimestampWithLocalTimeZone9,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithLocalTimeZone9],
+	})
+
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DatatypeNameS: common.BuildInOracleDatatypeIntervalDay,
+		DatatypeNameT: common.BuildInOracleO2PDatatypeNameMap[common.BuildInOracleDatatypeIntervalDay],
+	})
+
+	return rw.DB(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "db_type_s"},
+			{Name: "db_type_t"},
+			{Name: "datatype_name_s"},
+		},
+		DoNothing: true,
+	}).CreateInBatches(buildinDataTypeR, 20).Error
+}
+
 func (rw *BuildinDatatypeRule) InitM2OBuildinDatatypeRule(ctx context.Context) error {
 	var buildinDataTypeR []*BuildinDatatypeRule
 	/*
@@ -702,6 +1156,18 @@ func (rw *BuildinDatatypeRule) InitM2OBuildinDatatypeRule(ctx context.Context) e
 		DatatypeNameS: common.BuildInMySQLDatatypeVarbinary,
 		DatatypeNameT: common.BuildInMySQLM2ODatatypeNameMap[common.BuildInMySQLDatatypeVarbinary],
 	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBMySQL,
+		DBTypeT:       common.TaskDBOracle,
+		DatatypeNameS: common.BuildInMySQLDatatypeSet,
+		DatatypeNameT: common.BuildInMySQLM2ODatatypeNameMap[common.BuildInMySQLDatatypeSet],
+	})
+	buildinDataTypeR = append(buildinDataTypeR, &BuildinDatatypeRule{
+		DBTypeS:       common.TaskDBMySQL,
+		DBTypeT:       common.TaskDBOracle,
+		DatatypeNameS: common.BuildInMySQLDatatypeEnum,
+		DatatypeNameT: common.BuildInMySQLM2ODatatypeNameMap[common.BuildInMySQLDatatypeEnum],
+	})
 	return rw.DB(ctx).Clauses(clause.OnConflict{
 		Columns: []clause.Column{
 			{Name: "db_type_s"},
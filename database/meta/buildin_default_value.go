@@ -99,8 +99,36 @@ func (rw *BuildinColumnDefaultval) InitO2MBuildinColumnDefaultValue(ctx context.
 
 	return rw.DB(ctx).Clauses(clause.OnConflict{
 		Columns: []clause.Column{
-			{Name: "source_default_value"},
-			{Name: "reverse_mode"},
+			{Name: "db_type_s"},
+			{Name: "db_type_t"},
+			{Name: "default_value_s"},
+		},
+		DoNothing: true,
+	}).CreateInBatches(buildinColumDefaultvals, 2).Error
+}
+
+func (rw *BuildinColumnDefaultval) InitO2PBuildinColumnDefaultValue(ctx context.Context) error {
+	var buildinColumDefaultvals []*BuildinColumnDefaultval
+
+	buildinColumDefaultvals = append(buildinColumDefaultvals, &BuildinColumnDefaultval{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DefaultValueS: common.BuildInOracleColumnDefaultValueSysdate,
+		DefaultValueT: common.BuildInOracleO2PColumnDefaultValueMap[common.BuildInOracleColumnDefaultValueSysdate],
+	})
+
+	buildinColumDefaultvals = append(buildinColumDefaultvals, &BuildinColumnDefaultval{
+		DBTypeS:       common.TaskDBOracle,
+		DBTypeT:       common.TaskDBPostgres,
+		DefaultValueS: common.BuildInOracleColumnDefaultValueSYSGUID,
+		DefaultValueT: common.BuildInOracleO2PColumnDefaultValueMap[common.BuildInOracleColumnDefaultValueSYSGUID],
+	})
+
+	return rw.DB(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "db_type_s"},
+			{Name: "db_type_t"},
+			{Name: "default_value_s"},
 		},
 		DoNothing: true,
 	}).CreateInBatches(buildinColumDefaultvals, 2).Error
@@ -117,9 +145,48 @@ func (rw *BuildinColumnDefaultval) InitM2OBuildinColumnDefaultValue(ctx context.
 	})
 	return rw.DB(ctx).Clauses(clause.OnConflict{
 		Columns: []clause.Column{
-			{Name: "source_default_value"},
-			{Name: "reverse_mode"},
+			{Name: "db_type_s"},
+			{Name: "db_type_t"},
+			{Name: "default_value_s"},
 		},
 		DoNothing: true,
 	}).CreateInBatches(buildinColumDefaultvals, 1).Error
 }
+
+// 按上下游数据库类型查询全部规则
+func (rw *BuildinColumnDefaultval) QueryColumnDefaultValByDBType(ctx context.Context, queryS *BuildinColumnDefaultval) ([]BuildinColumnDefaultval, error) {
+	var buildinColumnDefaultvals []BuildinColumnDefaultval
+
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return buildinColumnDefaultvals, err
+	}
+	if err = rw.DB(ctx).Where("UPPER(db_type_s) = ? AND UPPER(db_type_t) = ?",
+		common.StringUPPER(queryS.DBTypeS),
+		common.StringUPPER(queryS.DBTypeT)).Order("id").Find(&buildinColumnDefaultvals).Error; err != nil {
+		return buildinColumnDefaultvals, fmt.Errorf("query table [%s] record by db type failed: %v", table, err)
+	}
+	return buildinColumnDefaultvals, nil
+}
+
+// 规则存在则更新，不存在则写入
+func (rw *BuildinColumnDefaultval) UpsertColumnDefaultVal(ctx context.Context, upsertS []BuildinColumnDefaultval, batchSize int) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if len(upsertS) == 0 {
+		return nil
+	}
+	if err = rw.DB(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "db_type_s"},
+			{Name: "db_type_t"},
+			{Name: "default_value_s"},
+		},
+		DoUpdates: clause.AssignmentColumns([]string{"default_value_t", "updated_at"}),
+	}).CreateInBatches(upsertS, batchSize).Error; err != nil {
+		return fmt.Errorf("upsert table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
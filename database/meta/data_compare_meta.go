@@ -36,6 +36,7 @@ type DataCompareMeta struct {
 	ColumnInfoT string `gorm:"type:text;comment:'目标端查询字段信息'" json:"column_info_t"`
 	WhereColumn string `gorm:"comment:'查询类型字段列'" json:"where_column"`
 	WhereRange  string `gorm:"not null;index:idx_dbtype_st_obj,unique;comment:'查询 where 条件'" json:"where_range"`
+	WhereRangeT string `gorm:"type:text;comment:'目标端查询 where 条件'" json:"where_range_t"`
 	IsPartition string `gorm:"comment:'是否是分区表'" json:"is_partition"` // 同步转换统一转换成非分区表，此处只做标志
 	*BaseModel
 }
@@ -113,12 +114,42 @@ func (rw *DataCompareMeta) DistinctDataCompareMetaTableNameS(ctx context.Context
 	return tableNames, nil
 }
 
+// 按表统计未完成 chunk 数
+func (rw *DataCompareMeta) CountsDataCompareMetaGroupByTableNameS(ctx context.Context, detailS *DataCompareMeta) (map[string]int, error) {
+	var (
+		tableCounts []struct {
+			TableNameS string
+			Counts     int
+		}
+		counts = make(map[string]int)
+	)
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return counts, err
+	}
+	if err = rw.DB(ctx).Model(&DataCompareMeta{}).
+		Select("table_name_s, COUNT(1) AS counts").
+		Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ?",
+			common.StringUPPER(detailS.DBTypeS),
+			common.StringUPPER(detailS.DBTypeT),
+			common.StringUPPER(detailS.SchemaNameS)).
+		Group("table_name_s").
+		Scan(&tableCounts).Error; err != nil {
+		return counts, fmt.Errorf("get table [%s] counts group by table_name_s failed: %v", table, err)
+	}
+	for _, c := range tableCounts {
+		counts[common.StringUPPER(c.TableNameS)] = c.Counts
+	}
+	return counts, nil
+}
+
 func (rw *DataCompareMeta) TruncateDataCompareMeta(ctx context.Context) error {
 	table, err := rw.ParseSchemaTable()
 	if err != nil {
 		return err
 	}
-	err = rw.DB(ctx).Raw(fmt.Sprintf("TRUNCATE TABLE %s", table)).Error
+	// 兼容不同元数据库存储，全表删除替代 TRUNCATE TABLE
+	err = rw.DB(ctx).Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&DataCompareMeta{}).Error
 	if err != nil {
 		return fmt.Errorf("truncate table [%s] record failed: %v", table, err)
 	}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 /*
@@ -84,3 +85,43 @@ func (rw *ColumnDatatypeRule) DetailColumnRule(ctx context.Context, detailS *Col
 
 	return columnRuleMap, nil
 }
+
+// 按上下游数据库类型查询全部规则
+func (rw *ColumnDatatypeRule) QueryColumnRuleByDBType(ctx context.Context, queryS *ColumnDatatypeRule) ([]ColumnDatatypeRule, error) {
+	var columnDatatypeRules []ColumnDatatypeRule
+
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return columnDatatypeRules, err
+	}
+	if err = rw.DB(ctx).Where("UPPER(db_type_s) = ? AND UPPER(db_type_t) = ?",
+		common.StringUPPER(queryS.DBTypeS),
+		common.StringUPPER(queryS.DBTypeT)).Order("id").Find(&columnDatatypeRules).Error; err != nil {
+		return columnDatatypeRules, fmt.Errorf("query table [%s] record by db type failed: %v", table, err)
+	}
+	return columnDatatypeRules, nil
+}
+
+// 规则存在则更新，不存在则写入
+func (rw *ColumnDatatypeRule) UpsertColumnRule(ctx context.Context, upsertS []ColumnDatatypeRule, batchSize int) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if len(upsertS) == 0 {
+		return nil
+	}
+	if err = rw.DB(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "db_type_s"},
+			{Name: "db_type_t"},
+			{Name: "schema_name_s"},
+			{Name: "table_name_s"},
+			{Name: "column_name_s"},
+		},
+		DoUpdates: clause.AssignmentColumns([]string{"column_type_s", "column_type_t", "updated_at"}),
+	}).CreateInBatches(upsertS, batchSize).Error; err != nil {
+		return fmt.Errorf("upsert table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
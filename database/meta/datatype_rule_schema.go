@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // 自定义库转换规则 - schema 级别
@@ -63,3 +64,42 @@ func (rw *SchemaDatatypeRule) DetailSchemaRule(ctx context.Context, detailS *Sch
 	}
 	return schemaRuleMap, nil
 }
+
+// 按上下游数据库类型查询全部规则
+func (rw *SchemaDatatypeRule) QuerySchemaRuleByDBType(ctx context.Context, queryS *SchemaDatatypeRule) ([]SchemaDatatypeRule, error) {
+	var schemaDatatypeRules []SchemaDatatypeRule
+
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return schemaDatatypeRules, err
+	}
+	if err = rw.DB(ctx).Where("UPPER(db_type_s) = ? AND UPPER(db_type_t) = ?",
+		common.StringUPPER(queryS.DBTypeS),
+		common.StringUPPER(queryS.DBTypeT)).Order("id").Find(&schemaDatatypeRules).Error; err != nil {
+		return schemaDatatypeRules, fmt.Errorf("query table [%s] record by db type failed: %v", table, err)
+	}
+	return schemaDatatypeRules, nil
+}
+
+// 规则存在则更新，不存在则写入
+func (rw *SchemaDatatypeRule) UpsertSchemaRule(ctx context.Context, upsertS []SchemaDatatypeRule, batchSize int) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if len(upsertS) == 0 {
+		return nil
+	}
+	if err = rw.DB(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "db_type_s"},
+			{Name: "db_type_t"},
+			{Name: "schema_name_s"},
+			{Name: "column_type_s"},
+		},
+		DoUpdates: clause.AssignmentColumns([]string{"column_type_t", "updated_at"}),
+	}).CreateInBatches(upsertS, batchSize).Error; err != nil {
+		return fmt.Errorf("upsert table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
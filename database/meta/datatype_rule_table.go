@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // 自定义表转换规则 - table 级别
@@ -66,3 +67,43 @@ func (rw *TableDatatypeRule) DetailTableRule(ctx context.Context, detailS *Table
 	}
 	return tableRuleMap, nil
 }
+
+// 按上下游数据库类型查询全部规则
+func (rw *TableDatatypeRule) QueryTableRuleByDBType(ctx context.Context, queryS *TableDatatypeRule) ([]TableDatatypeRule, error) {
+	var tableDatatypeRules []TableDatatypeRule
+
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return tableDatatypeRules, err
+	}
+	if err = rw.DB(ctx).Where("UPPER(db_type_s) = ? AND UPPER(db_type_t) = ?",
+		common.StringUPPER(queryS.DBTypeS),
+		common.StringUPPER(queryS.DBTypeT)).Order("id").Find(&tableDatatypeRules).Error; err != nil {
+		return tableDatatypeRules, fmt.Errorf("query table [%s] record by db type failed: %v", table, err)
+	}
+	return tableDatatypeRules, nil
+}
+
+// 规则存在则更新，不存在则写入
+func (rw *TableDatatypeRule) UpsertTableRule(ctx context.Context, upsertS []TableDatatypeRule, batchSize int) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if len(upsertS) == 0 {
+		return nil
+	}
+	if err = rw.DB(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "db_type_s"},
+			{Name: "db_type_t"},
+			{Name: "schema_name_s"},
+			{Name: "table_name_s"},
+			{Name: "column_type_s"},
+		},
+		DoUpdates: clause.AssignmentColumns([]string{"column_type_t", "updated_at"}),
+	}).CreateInBatches(upsertS, batchSize).Error; err != nil {
+		return fmt.Errorf("upsert table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
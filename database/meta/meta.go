@@ -19,54 +19,106 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
+	dbMySQL "github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/errors"
 	"github.com/wentaojin/transferdb/logger"
 	"go.uber.org/zap"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/schema"
+	"strings"
 )
 
 type Meta struct {
 	GormDB *gorm.DB
 }
 
-func NewMetaDBEngine(ctx context.Context, mysqlCfg config.MySQLConfig, slowThreshold int) (*Meta, error) {
-	// 创建元数据库
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/?charset=utf8mb4&parseTime=True&loc=Local",
-		mysqlCfg.Username, mysqlCfg.Password, mysqlCfg.Host, mysqlCfg.Port)
+// NewMetaDBEngine 根据 [meta] db-type 初始化元数据库
+//   - mysql：元数据库位于下游 MySQL/TiDB meta-schema，默认
+//   - sqlite：元数据库位于本地内嵌 SQLite 文件，无需连接下游
+//
+// ReadOnly 只读打开元数据库，不创建元数据库、目标库以及 SQLite 文件，元数据库需已初始化
+func NewMetaDBEngine(ctx context.Context, mysqlCfg config.MySQLConfig, metaCfg config.MetaConfig, slowThreshold int) (*Meta, error) {
+	switch strings.ToLower(strings.TrimSpace(metaCfg.DBType)) {
+	case common.MetaDBTypeMySQL, "":
+		return NewMySQLMetaDBEngine(ctx, mysqlCfg, slowThreshold, metaCfg.ReadOnly)
+	case common.MetaDBTypeSQLite:
+		return NewSQLiteMetaDBEngine(ctx, metaCfg.SQLiteFile, slowThreshold, metaCfg.ReadOnly)
+	default:
+		return nil, errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, fmt.Errorf("meta db-type [%s] isn't support, please configure [%s] or [%s]", metaCfg.DBType, common.MetaDBTypeMySQL, common.MetaDBTypeSQLite))
+	}
+}
+
+// 关闭元数据库连接
+func (m *Meta) Close() error {
+	sqlDB, err := m.GormDB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+func NewMySQLMetaDBEngine(ctx context.Context, mysqlCfg config.MySQLConfig, slowThreshold int, readOnly bool) (*Meta, error) {
+	if !readOnly {
+		if err := createMySQLMetaSchema(ctx, mysqlCfg); err != nil {
+			return &Meta{}, err
+		}
+	}
+
+	// 初始化 MetaDB
+	dsn, err := dbMySQL.NewMySQLDSN(mysqlCfg, mysqlCfg.MetaSchema, "charset=utf8mb4&parseTime=True&loc=Local")
+	if err != nil {
+		return nil, errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, err)
+	}
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		DriverName: "mysql",
+		DSN:        dsn,
+	}), newGormConfig(slowThreshold))
+
+	if err != nil {
+		return nil, errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, fmt.Errorf("error on open meta database connection: %v", err))
+	}
+
+	return &Meta{GormDB: gormDB}, nil
+}
+
+// 创建元数据库以及目标库
+func createMySQLMetaSchema(ctx context.Context, mysqlCfg config.MySQLConfig) error {
+	dsn, err := dbMySQL.NewMySQLDSN(mysqlCfg, "", "charset=utf8mb4&parseTime=True&loc=Local")
+	if err != nil {
+		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, err)
+	}
 
 	mysqlDB, err := sql.Open("mysql", dsn)
 	if err != nil {
-		return &Meta{}, errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, fmt.Errorf("error on open general database connection [%v]: %v", mysqlCfg.MetaSchema, err))
+		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, fmt.Errorf("error on open general database connection [%v]: %v", mysqlCfg.MetaSchema, err))
 	}
 
 	createSchema := fmt.Sprintf(`CREATE DATABASE IF NOT EXISTS %s`, mysqlCfg.MetaSchema)
 	_, err = mysqlDB.ExecContext(ctx, createSchema)
 	if err != nil {
-		return &Meta{}, errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, fmt.Errorf("error on exec meta database sql [%v]: %v", createSchema, err))
+		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, fmt.Errorf("error on exec meta database sql [%v]: %v", createSchema, err))
 	}
 	createSchema = fmt.Sprintf(`CREATE DATABASE IF NOT EXISTS %s`, mysqlCfg.SchemaName)
 	_, err = mysqlDB.ExecContext(ctx, createSchema)
 	if err != nil {
-		return &Meta{}, errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, fmt.Errorf("error on exec target database sql [%v]: %v", createSchema, err))
+		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, fmt.Errorf("error on exec target database sql [%v]: %v", createSchema, err))
 	}
 	err = mysqlDB.Close()
 	if err != nil {
-		return &Meta{}, errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, fmt.Errorf("error on close general database sql [%v]: %v", createSchema, err))
+		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, fmt.Errorf("error on close general database sql [%v]: %v", createSchema, err))
 	}
+	return nil
+}
 
-	// 初始化 MetaDB
+// newGormConfig 元数据库 gorm 通用配置
+func newGormConfig(slowThreshold int) *gorm.Config {
 	// 初始化 gorm 日志记录器
-	dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		mysqlCfg.Username, mysqlCfg.Password, mysqlCfg.Host, mysqlCfg.Port, mysqlCfg.MetaSchema)
 	l := logger.NewGormLogger(zap.L(), slowThreshold)
 	l.SetAsDefault()
-	gormDB, err := gorm.Open(mysql.New(mysql.Config{
-		DriverName: "mysql",
-		DSN:        dsn,
-	}), &gorm.Config{
+	return &gorm.Config{
 		// 禁用外键（指定外键时不会在 mysql 创建真实的外键约束）
 		DisableForeignKeyConstraintWhenMigrating: true,
 		PrepareStmt:                              true,
@@ -74,13 +126,7 @@ func NewMetaDBEngine(ctx context.Context, mysqlCfg config.MySQLConfig, slowThres
 		NamingStrategy: schema.NamingStrategy{
 			SingularTable: true, // 使用单数表名
 		},
-	})
-
-	if err != nil {
-		return nil, errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, fmt.Errorf("error on open meta database connection: %v", err))
 	}
-
-	return &Meta{GormDB: gormDB}, nil
 }
 
 func WrapGormDB(gormDB *gorm.DB) *Meta {
@@ -103,6 +149,13 @@ func (m *Meta) DB(ctx context.Context) *gorm.DB {
 	return m.GormDB.WithContext(ctx)
 }
 
+// Transaction 事务内执行 fn，fn 内通过 DB(txnCtx) 获取事务连接
+func (m *Meta) Transaction(ctx context.Context, fn func(txnCtx context.Context) error) error {
+	return m.GormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, ctxTxnKey, tx))
+	})
+}
+
 func (m *Meta) MigrateTables() (err error) {
 	return m.migrateStream(
 		new(ColumnDatatypeRule),
@@ -113,10 +166,12 @@ func (m *Meta) MigrateTables() (err error) {
 		new(FullSyncMeta),
 		new(IncrSyncMeta),
 		new(ErrorLogDetail),
+		new(FullSyncErrorRow),
 		new(BuildinColumnDefaultval),
 		new(BuildinObjectCompatible),
 		new(BuildinDatatypeRule),
 		new(TableNameRule),
+		new(TableOptionRule),
 	)
 }
 
@@ -129,6 +184,10 @@ func (m *Meta) InitDefaultValue(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	err = NewBuildinColumnDefaultvalModel(m).InitO2PBuildinColumnDefaultValue(ctx)
+	if err != nil {
+		return err
+	}
 	err = NewBuildinObjectCompatibleModel(m).InitO2MBuildinObjectCompatible(ctx)
 	if err != nil {
 		return err
@@ -141,6 +200,10 @@ func (m *Meta) InitDefaultValue(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	err = NewBuildinDatatypeRuleModel(m).InitO2PBuildinDatatypeRule(ctx)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
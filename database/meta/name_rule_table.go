@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // 上下游数据表名字映射规则
@@ -66,3 +67,42 @@ func (rw *TableNameRule) DetailTableNameRule(ctx context.Context, detailS *Table
 	}
 	return tableRuleMap, nil
 }
+
+// 按上下游数据库类型查询全部规则
+func (rw *TableNameRule) QueryTableNameRuleByDBType(ctx context.Context, queryS *TableNameRule) ([]TableNameRule, error) {
+	var tableNameRules []TableNameRule
+
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return tableNameRules, err
+	}
+	if err = rw.DB(ctx).Where("UPPER(db_type_s) = ? AND UPPER(db_type_t) = ?",
+		common.StringUPPER(queryS.DBTypeS),
+		common.StringUPPER(queryS.DBTypeT)).Order("id").Find(&tableNameRules).Error; err != nil {
+		return tableNameRules, fmt.Errorf("query table [%s] record by db type failed: %v", table, err)
+	}
+	return tableNameRules, nil
+}
+
+// 规则存在则更新，不存在则写入
+func (rw *TableNameRule) UpsertTableNameRule(ctx context.Context, upsertS []TableNameRule, batchSize int) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if len(upsertS) == 0 {
+		return nil
+	}
+	if err = rw.DB(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "db_type_s"},
+			{Name: "db_type_t"},
+			{Name: "schema_name_s"},
+			{Name: "table_name_s"},
+		},
+		DoUpdates: clause.AssignmentColumns([]string{"schema_name_t", "table_name_t", "updated_at"}),
+	}).CreateInBatches(upsertS, batchSize).Error; err != nil {
+		return fmt.Errorf("upsert table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"gorm.io/gorm"
+)
+
+// 目标端表级别选项规则，Only 适用于 TiDB，存在记录的表 table-option 不生效
+type TableOptionRule struct {
+	ID              uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	DBTypeS         string `gorm:"type:varchar(15);index:idx_dbtype_st_option,unique;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT         string `gorm:"type:varchar(15);index:idx_dbtype_st_option,unique;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS     string `gorm:"not null;index:idx_dbtype_st_option,unique;comment:'源端库 schema'" json:"schema_name_s"`
+	TableNameS      string `gorm:"not null;index:idx_dbtype_st_option,unique;comment:'源端表名'" json:"table_name_s"`
+	ShardMode       string `gorm:"type:varchar(30);comment:'数据打散方式 AUTO/AUTO_RANDOM/SHARD_ROW_ID_BITS/NONE'" json:"shard_mode"`
+	AutoRandomBits  int    `gorm:"comment:'AUTO_RANDOM 分片位数，0 使用默认值 5'" json:"auto_random_bits"`
+	ShardRowIDBits  int    `gorm:"comment:'SHARD_ROW_ID_BITS 分片位数，0 使用默认值 4'" json:"shard_row_id_bits"`
+	PreSplitRegions int    `gorm:"comment:'PRE_SPLIT_REGIONS 预切分 region 位数，0 不预切分'" json:"pre_split_regions"`
+	PlacementPolicy string `gorm:"type:varchar(300);comment:'放置策略名'" json:"placement_policy"`
+	TTLExpr         string `gorm:"type:varchar(300);comment:'TTL 表达式，例如 CREATED_AT + INTERVAL 90 DAY'" json:"ttl_expr"`
+	*BaseModel
+}
+
+func NewTableOptionRuleModel(m *Meta) *TableOptionRule {
+	return &TableOptionRule{BaseModel: &BaseModel{
+		Meta: m,
+	}}
+}
+
+func (rw *TableOptionRule) ParseSchemaTable() (string, error) {
+	stmt := &gorm.Statement{DB: rw.GormDB}
+	err := stmt.Parse(rw)
+	if err != nil {
+		return "", fmt.Errorf("parse struct [TableOptionRule] get table_name failed: %v", err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+func (rw *TableOptionRule) DetailTableOptionRule(ctx context.Context, detailS *TableOptionRule) ([]TableOptionRule, error) {
+	var tableOptionRules []TableOptionRule
+
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rw.DB(ctx).Where("UPPER(db_type_s) = ? AND UPPER(db_type_t) = ? AND UPPER(schema_name_s) = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS)).Find(&tableOptionRules).Error; err != nil {
+		return tableOptionRules, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+	return tableOptionRules, nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"github.com/glebarez/sqlite"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// NewSQLiteMetaDBEngine 初始化内嵌 SQLite 元数据库，文件不存在自动创建
+// SQLite 同一时刻仅允许单个写入，连接池限制单连接，并发访问串行化，避免 database is locked
+// 只读打开时文件需已存在，连接开启 query_only 拒绝任何写入
+func NewSQLiteMetaDBEngine(ctx context.Context, sqliteFile string, slowThreshold int, readOnly bool) (*Meta, error) {
+	if strings.TrimSpace(sqliteFile) == "" {
+		sqliteFile = common.MetaDefaultSQLiteFile
+	}
+	dsn := fmt.Sprintf("%s?_pragma=busy_timeout(%d)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)",
+		sqliteFile, common.MetaSQLiteBusyTimeout)
+	if readOnly {
+		if _, err := os.Stat(sqliteFile); err != nil {
+			return nil, errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, fmt.Errorf("error on stat meta sqlite file [%s], please run prepare mode first: %v", sqliteFile, err))
+		}
+		dsn = common.StringsBuilder(dsn, "&_pragma=query_only(1)")
+	} else if sqliteFile != ":memory:" {
+		if err := os.MkdirAll(filepath.Dir(sqliteFile), os.ModePerm); err != nil {
+			return nil, errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, fmt.Errorf("error on create meta sqlite file [%s] dir: %v", sqliteFile, err))
+		}
+	}
+
+	gormDB, err := gorm.Open(&sqliteDialector{Dialector: sqlite.Dialector{DSN: dsn}}, newGormConfig(slowThreshold))
+	if err != nil {
+		return nil, errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, fmt.Errorf("error on open meta sqlite file [%s]: %v", sqliteFile, err))
+	}
+
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return nil, errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, fmt.Errorf("error on get meta sqlite file [%s] connection: %v", sqliteFile, err))
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err = sqlDB.PingContext(ctx); err != nil {
+		return nil, errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, fmt.Errorf("error on ping meta sqlite file [%s]: %v", sqliteFile, err))
+	}
+
+	return &Meta{GormDB: gormDB}, nil
+}
+
+// sqliteDialector 兼容 MySQL 元数据表定义
+//   - SQLite 索引名库级别唯一，元数据表存在同名索引（例如 idx_dbtype_st_map），索引名统一追加表名前缀
+//   - 字符串字段使用 NOCASE 排序规则，与 MySQL 默认大小写不敏感比较保持一致
+type sqliteDialector struct {
+	sqlite.Dialector
+}
+
+func (d *sqliteDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return sqliteMigrator{Migrator: d.Dialector.Migrator(db).(sqlite.Migrator)}
+}
+
+var sqliteDefaultTimestampRegex = regexp.MustCompile(`(?i)\s+default\s+current_timestamp(\(\d*\))?(\s+on\s+update\s+current_timestamp(\(\d*\))?)?`)
+
+type sqliteMigrator struct {
+	sqlite.Migrator
+}
+
+func (m sqliteMigrator) FullDataTypeOf(field *schema.Field) clause.Expr {
+	expr := m.Migrator.FullDataTypeOf(field)
+	// BaseModel 时间字段 MySQL 默认值以及 on update 子句 SQLite 不支持，时间字段由 BaseModel 钩子写入
+	expr.SQL = sqliteDefaultTimestampRegex.ReplaceAllString(expr.SQL, "")
+	if field.GORMDataType == schema.String {
+		expr.SQL += " COLLATE NOCASE"
+	}
+	return expr
+}
+
+func (m sqliteMigrator) CreateIndex(value interface{}, name string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		idx := stmt.Schema.LookIndex(name)
+		if idx == nil {
+			return fmt.Errorf("failed to create index with name %v", name)
+		}
+		createIndexSQL := "CREATE "
+		if idx.Class != "" {
+			createIndexSQL += idx.Class + " "
+		}
+		createIndexSQL += "INDEX IF NOT EXISTS ? ON ??"
+		return m.DB.Exec(createIndexSQL,
+			clause.Column{Name: sqliteIndexName(stmt.Table, idx.Name)},
+			clause.Table{Name: stmt.Table},
+			m.BuildIndexOptions(idx.Fields, stmt)).Error
+	})
+}
+
+func (m sqliteMigrator) HasIndex(value interface{}, name string) bool {
+	var count int
+	_ = m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		if idx := stmt.Schema.LookIndex(name); idx != nil {
+			name = idx.Name
+		}
+		return m.DB.Raw("SELECT count(*) FROM sqlite_master WHERE type = ? AND tbl_name = ? AND name = ?",
+			"index", stmt.Table, sqliteIndexName(stmt.Table, name)).Row().Scan(&count)
+	})
+	return count > 0
+}
+
+func (m sqliteMigrator) DropIndex(value interface{}, name string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		if idx := stmt.Schema.LookIndex(name); idx != nil {
+			name = idx.Name
+		}
+		return m.DB.Exec("DROP INDEX IF EXISTS ?", clause.Column{Name: sqliteIndexName(stmt.Table, name)}).Error
+	})
+}
+
+func sqliteIndexName(table, name string) string {
+	return common.StringsBuilder(table, "_", name)
+}
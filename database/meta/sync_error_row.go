@@ -0,0 +1,135 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"gorm.io/gorm"
+)
+
+// 全量同步错误行详情
+// 用于 full 模式开启 skip-bad-rows 时，二分定位写入失败的数据行，记录行数据以及错误，不影响任务运行
+type FullSyncErrorRow struct {
+	ID          uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	DBTypeS     string `gorm:"type:varchar(15);index:idx_dbtype_st_map;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT     string `gorm:"type:varchar(15);index:idx_dbtype_st_map;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS string `gorm:"not null;index:idx_dbtype_st_map;comment:'源端 schema'" json:"schema_name_s"`
+	TableNameS  string `gorm:"not null;index:idx_dbtype_st_map;comment:'源端表名'" json:"table_name_s"`
+	SchemaNameT string `gorm:"not null;comment:'目标端 schema'" json:"schema_name_t"`
+	TableNameT  string `gorm:"not null;comment:'目标端表名'" json:"table_name_t"`
+	RowidInfoS  string `gorm:"type:varchar(300);comment:'表 rowid 切分信息'" json:"rowid_info_s"`
+	Mode        string `gorm:"not null;index:idx_dbtype_st_map;comment:'同步模式'" json:"mode"`
+	RowInfo     string `gorm:"type:longtext;comment:'错误行数据'" json:"row_info"`
+	ErrorDetail string `gorm:"type:text;comment:'错误详情'" json:"error_detail"`
+	*BaseModel
+}
+
+func NewFullSyncErrorRowModel(m *Meta) *FullSyncErrorRow {
+	return &FullSyncErrorRow{
+		BaseModel: &BaseModel{
+			Meta: m,
+		},
+	}
+}
+
+func (rw *FullSyncErrorRow) ParseSchemaTable() (string, error) {
+	stmt := &gorm.Statement{DB: rw.GormDB}
+	err := stmt.Parse(rw)
+	if err != nil {
+		return "", fmt.Errorf("parse struct [FullSyncErrorRow] get table_name failed: %v", err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+func (rw *FullSyncErrorRow) CreateFullSyncErrorRow(ctx context.Context, createS *FullSyncErrorRow) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).Create(createS).Error; err != nil {
+		return fmt.Errorf("create table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *FullSyncErrorRow) DetailFullSyncErrorRow(ctx context.Context, detailS *FullSyncErrorRow) ([]FullSyncErrorRow, error) {
+	var errorRows []FullSyncErrorRow
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return errorRows, err
+	}
+	if err = rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND mode = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS),
+		detailS.Mode).Find(&errorRows).Error; err != nil {
+		return errorRows, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+	return errorRows, nil
+}
+
+func (rw *FullSyncErrorRow) CountsFullSyncErrorRowBySchema(ctx context.Context, detailS *FullSyncErrorRow) (int64, error) {
+	var totals int64
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return totals, err
+	}
+	if err = rw.DB(ctx).Model(&FullSyncErrorRow{}).
+		Where(`db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND mode = ?`,
+			common.StringUPPER(detailS.DBTypeS),
+			common.StringUPPER(detailS.DBTypeT),
+			common.StringUPPER(detailS.SchemaNameS),
+			detailS.Mode).
+		Count(&totals).Error; err != nil {
+		return totals, fmt.Errorf("get table [%s] counts failed: %v", table, err)
+	}
+	return totals, nil
+}
+
+func (rw *FullSyncErrorRow) DeleteFullSyncErrorRowBySchema(ctx context.Context, deleteS *FullSyncErrorRow) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).Where(`db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND mode = ?`,
+		common.StringUPPER(deleteS.DBTypeS),
+		common.StringUPPER(deleteS.DBTypeT),
+		common.StringUPPER(deleteS.SchemaNameS),
+		deleteS.Mode).Delete(&FullSyncErrorRow{}).Error; err != nil {
+		return fmt.Errorf("delete table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+// 清理单个 chunk 错误行，用于 chunk 重跑前避免重复记录
+func (rw *FullSyncErrorRow) DeleteFullSyncErrorRowByChunk(ctx context.Context, deleteS *FullSyncErrorRow) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).Where(`db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND mode = ? AND UPPER(rowid_info_s) = ?`,
+		common.StringUPPER(deleteS.DBTypeS),
+		common.StringUPPER(deleteS.DBTypeT),
+		common.StringUPPER(deleteS.SchemaNameS),
+		common.StringUPPER(deleteS.TableNameS),
+		deleteS.Mode,
+		common.StringUPPER(deleteS.RowidInfoS)).Delete(&FullSyncErrorRow{}).Error; err != nil {
+		return fmt.Errorf("delete table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
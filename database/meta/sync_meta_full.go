@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/logger"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -39,6 +40,7 @@ type FullSyncMeta struct {
 	Mode        string `gorm:"not null;index:idx_dbtype_st_map,unique;index:idx_schema_mode;comment:'同步模式'" json:"mode"`
 	IsPartition string `gorm:"comment:'是否是分区表'" json:"is_partition"` // 同步转换统一转换成非分区表，此处只做标志
 	CSVFile     string `gorm:"type:varchar(300);comment:'csv 文件名'" json:"csv_file"`
+	CSVParts    string `gorm:"type:text;comment:'csv 已完成分片文件'" json:"csv_parts"`
 	*BaseModel
 }
 
@@ -92,6 +94,36 @@ func (rw *FullSyncMeta) DistinctFullSyncMetaByTableNameS(ctx context.Context, de
 	return tableNames, nil
 }
 
+// 按表统计未完成 chunk 数
+func (rw *FullSyncMeta) CountsFullSyncMetaGroupByTableNameS(ctx context.Context, detailS *FullSyncMeta) (map[string]int, error) {
+	var (
+		tableCounts []struct {
+			TableNameS string
+			Counts     int
+		}
+		counts = make(map[string]int)
+	)
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return counts, err
+	}
+	if err = rw.DB(ctx).Model(&FullSyncMeta{}).
+		Select("table_name_s, COUNT(1) AS counts").
+		Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND mode = ?",
+			common.StringUPPER(detailS.DBTypeS),
+			common.StringUPPER(detailS.DBTypeT),
+			common.StringUPPER(detailS.SchemaNameS),
+			detailS.Mode).
+		Group("table_name_s").
+		Scan(&tableCounts).Error; err != nil {
+		return counts, fmt.Errorf("get table [%s] counts group by table_name_s failed: %v", table, err)
+	}
+	for _, c := range tableCounts {
+		counts[common.StringUPPER(c.TableNameS)] = c.Counts
+	}
+	return counts, nil
+}
+
 func (rw *FullSyncMeta) DetailFullSyncMeta(ctx context.Context, detailS *FullSyncMeta) ([]FullSyncMeta, error) {
 	var dsMetas []FullSyncMeta
 	table, err := rw.ParseSchemaTable()
@@ -122,12 +154,36 @@ func (rw *FullSyncMeta) DeleteFullSyncMetaBySchemaTableRowid(ctx context.Context
 	if err != nil {
 		return fmt.Errorf("delete table [%s] reocrd failed: %v", table, err)
 	}
-	zap.L().Info("delete table record",
-		zap.String("table", deleteS.String()),
+	logger.L(ctx).Info("delete table record",
+		logger.TableS(deleteS.TableNameS),
+		zap.String("rowid", deleteS.RowidInfoS),
 		zap.String("status", "success"))
 	return nil
 }
 
+// 记录 chunk 已完成分片文件，断点续传跳过已完成分片
+func (rw *FullSyncMeta) UpdateFullSyncMetaCSVParts(ctx context.Context, detailS *FullSyncMeta) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	err = rw.DB(ctx).Model(&FullSyncMeta{}).
+		Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND mode = ?  AND UPPER(rowid_info_s) = ?",
+			common.StringUPPER(detailS.DBTypeS),
+			common.StringUPPER(detailS.DBTypeT),
+			common.StringUPPER(detailS.SchemaNameS),
+			common.StringUPPER(detailS.TableNameS),
+			detailS.Mode,
+			common.StringUPPER(detailS.RowidInfoS)).
+		Updates(map[string]interface{}{
+			"CSVParts": detailS.CSVParts,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("update table [%s] column [csv_parts] failed: %v", table, err)
+	}
+	return nil
+}
+
 func (rw *FullSyncMeta) BatchCreateFullSyncMeta(ctx context.Context, createS []FullSyncMeta, batchSize int) error {
 	table, err := rw.ParseSchemaTable()
 	if err != nil {
@@ -118,3 +118,42 @@ func (rw *ErrorLogDetail) CountsErrorLogBySchemaTable(ctx context.Context, detai
 	}
 	return totals, nil
 }
+
+// 按 schema、运行模式查询错误详情，表名为空不过滤表
+// 不区分上下游数据库类型，用于 task errors 子命令
+func (rw *ErrorLogDetail) DetailErrorLogBySchemaMode(ctx context.Context, detailS *ErrorLogDetail) ([]ErrorLogDetail, error) {
+	var tableErrDetails []ErrorLogDetail
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return tableErrDetails, err
+	}
+	query := rw.DB(ctx).Where("schema_name_s = ? AND run_mode = ?",
+		common.StringUPPER(detailS.SchemaNameS),
+		detailS.RunMode)
+	if detailS.TableNameS != "" {
+		query = query.Where("table_name_s = ?", common.StringUPPER(detailS.TableNameS))
+	}
+	if err = query.Order("id").Find(&tableErrDetails).Error; err != nil {
+		return tableErrDetails, fmt.Errorf("detail table [%s] record by schema_mode failed: %v", table, err)
+	}
+	return tableErrDetails, nil
+}
+
+// 按 schema、运行模式清理错误详情，表名为空清理 schema 全部记录，返回清理记录数
+func (rw *ErrorLogDetail) DeleteErrorLogBySchemaMode(ctx context.Context, deleteS *ErrorLogDetail) (int64, error) {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return 0, err
+	}
+	query := rw.DB(ctx).Where("schema_name_s = ? AND run_mode = ?",
+		common.StringUPPER(deleteS.SchemaNameS),
+		deleteS.RunMode)
+	if deleteS.TableNameS != "" {
+		query = query.Where("table_name_s = ?", common.StringUPPER(deleteS.TableNameS))
+	}
+	res := query.Delete(&ErrorLogDetail{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("delete table [%s] record by schema_mode failed: %v", table, res.Error)
+	}
+	return res.RowsAffected, nil
+}
@@ -34,6 +34,7 @@ func (rw *Transaction) CreateDataCompareMetaAndUpdateWaitSyncMeta(ctx context.Co
 	txn := rw.DB(ctx).Begin()
 	err := txn.Create(dataDiffMeta).Error
 	if err != nil {
+		txn.Rollback()
 		return fmt.Errorf("create table [data_diff_meta] reocrd by transaction failed: %v", err)
 	}
 	err = txn.Model(&WaitSyncMeta{}).
@@ -49,9 +50,12 @@ func (rw *Transaction) CreateDataCompareMetaAndUpdateWaitSyncMeta(ctx context.Co
 			"IsPartition":    waitSyncMeta.IsPartition,
 		}).Error
 	if err != nil {
+		txn.Rollback()
 		return fmt.Errorf("update table [wait_sync_meta] reocrd by transaction failed: %v", err)
 	}
-	txn.Commit()
+	if err = txn.Commit().Error; err != nil {
+		return fmt.Errorf("commit table [wait_sync_meta] reocrd by transaction failed: %v", err)
+	}
 	return nil
 }
 
@@ -59,6 +63,7 @@ func (rw *Transaction) CreateFullSyncMetaAndUpdateWaitSyncMeta(ctx context.Conte
 	txn := rw.DB(ctx).Begin()
 	err := txn.Create(fullSyncMeta).Error
 	if err != nil {
+		txn.Rollback()
 		return fmt.Errorf("create table [full_sync_meta] reocrd by transaction failed: %v", err)
 	}
 	err = txn.Model(&WaitSyncMeta{}).
@@ -74,9 +79,12 @@ func (rw *Transaction) CreateFullSyncMetaAndUpdateWaitSyncMeta(ctx context.Conte
 			"IsPartition":    waitSyncMeta.IsPartition,
 		}).Error
 	if err != nil {
+		txn.Rollback()
 		return fmt.Errorf("update table [wait_sync_meta] reocrd by transaction failed: %v", err)
 	}
-	txn.Commit()
+	if err = txn.Commit().Error; err != nil {
+		return fmt.Errorf("commit table [wait_sync_meta] reocrd by transaction failed: %v", err)
+	}
 	return nil
 }
 
@@ -108,6 +116,64 @@ func (rw *Transaction) DeleteIncrSyncMetaAndWaitSyncMeta(ctx context.Context, in
 	return nil
 }
 
+// 重置表级别断点记录，单事务清理 wait_sync_meta 以及对应模式断点记录
+//   - CompareO2M：data_compare_meta
+//   - AllO2M：全量阶段断点记录模式为 FullO2M，同时清理 incr_sync_meta
+//   - 其余：full_sync_meta
+func (rw *Transaction) DeleteTableSyncMetaByMode(ctx context.Context, waitSyncMeta *WaitSyncMeta) error {
+	mode := waitSyncMeta.Mode
+	if mode == common.AllO2MMode {
+		mode = common.FullO2MMode
+	}
+	if err := rw.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		switch waitSyncMeta.Mode {
+		case common.CompareO2MMode:
+			if err := tx.Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ?",
+				common.StringUPPER(waitSyncMeta.DBTypeS),
+				common.StringUPPER(waitSyncMeta.DBTypeT),
+				common.StringUPPER(waitSyncMeta.SchemaNameS),
+				common.StringUPPER(waitSyncMeta.TableNameS)).
+				Delete(&DataCompareMeta{}).Error; err != nil {
+				return fmt.Errorf("delete table [data_compare_meta] record by transaction failed: %v", err)
+			}
+		case common.AllO2MMode:
+			if err := tx.Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ?",
+				common.StringUPPER(waitSyncMeta.DBTypeS),
+				common.StringUPPER(waitSyncMeta.DBTypeT),
+				common.StringUPPER(waitSyncMeta.SchemaNameS),
+				common.StringUPPER(waitSyncMeta.TableNameS)).
+				Delete(&IncrSyncMeta{}).Error; err != nil {
+				return fmt.Errorf("delete table [incr_sync_meta] record by transaction failed: %v", err)
+			}
+			fallthrough
+		default:
+			if err := tx.Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND mode = ?",
+				common.StringUPPER(waitSyncMeta.DBTypeS),
+				common.StringUPPER(waitSyncMeta.DBTypeT),
+				common.StringUPPER(waitSyncMeta.SchemaNameS),
+				common.StringUPPER(waitSyncMeta.TableNameS),
+				mode).
+				Delete(&FullSyncMeta{}).Error; err != nil {
+				return fmt.Errorf("delete table [full_sync_meta] record by transaction failed: %v", err)
+			}
+		}
+
+		if err := tx.Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND mode = ?",
+			common.StringUPPER(waitSyncMeta.DBTypeS),
+			common.StringUPPER(waitSyncMeta.DBTypeT),
+			common.StringUPPER(waitSyncMeta.SchemaNameS),
+			common.StringUPPER(waitSyncMeta.TableNameS),
+			mode).
+			Delete(&WaitSyncMeta{}).Error; err != nil {
+			return fmt.Errorf("delete table [wait_sync_meta] record by transaction failed: %v", err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (rw *Transaction) UpdateIncrSyncMetaSCNByCurrentRedo(ctx context.Context,
 	dbTypeS, dbTypeT, sourceSchemaName string, lastRedoLogMaxSCN, logFileStartSCN, logFileEndSCN uint64) error {
 	var logFileSCN uint64
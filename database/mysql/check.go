@@ -17,6 +17,7 @@ package mysql
 
 import (
 	"fmt"
+	"github.com/wentaojin/transferdb/common"
 	"strings"
 )
 
@@ -28,6 +29,23 @@ func (m *MySQL) GetMySQLDBVersion() (string, error) {
 	return res[0]["VERSION"], nil
 }
 
+// LOAD DATA 是否事务内写入，MySQL 以及 TiDB v7.0.0 及以上版本整体提交，回滚可撤销写入
+// TiDB version() 形如 5.7.25-TiDB-v6.5.0，无法解析 TiDB 版本时按非事务处理
+func IsLoadDataTransactional(version string) bool {
+	idx := strings.Index(strings.ToUpper(version), "-TIDB-")
+	if idx < 0 {
+		return true
+	}
+	tidbVersion := strings.TrimPrefix(strings.ToLower(version[idx+len("-TIDB-"):]), "v")
+	if strings.Contains(tidbVersion, common.MySQLVersionDelimiter) {
+		tidbVersion = strings.Split(tidbVersion, common.MySQLVersionDelimiter)[0]
+	}
+	if tidbVersion == "" || tidbVersion[0] < '0' || tidbVersion[0] > '9' {
+		return false
+	}
+	return common.VersionOrdinal(tidbVersion) >= common.VersionOrdinal(common.TiDBLoadDataTransactionVersion)
+}
+
 func (m *MySQL) GetMySQLTableCharacterSetAndCollation(schemaName, tableName string) (string, string, error) {
 	_, res, err := Query(m.Ctx, m.MySQLDB, fmt.Sprintf(`SELECT
 	IFNULL(CCSA.CHARACTER_SET_NAME,'UNKNOWN') CHARACTER_SET_NAME,
@@ -45,12 +63,22 @@ WHERE
 	return res[0]["CHARACTER_SET_NAME"], res[0]["COLLATION"], nil
 }
 
-func (m *MySQL) GetMySQLTableColumn(schemaName, tableName string) ([]map[string]string, error) {
+// 获取表字段信息，version 为 MySQL/TiDB version() 返回值，低于 5.7.6 版本不存在 GENERATION_EXPRESSION 返回空值
+func (m *MySQL) GetMySQLTableColumn(schemaName, tableName, version string) ([]map[string]string, error) {
 	var (
 		res []map[string]string
 		err error
 	)
 
+	dbVersion := version
+	if strings.Contains(version, common.MySQLVersionDelimiter) {
+		dbVersion = strings.Split(version, common.MySQLVersionDelimiter)[0]
+	}
+	generationExpr := `IFNULL(GENERATION_EXPRESSION,'') GENERATION_EXPRESSION`
+	if common.VersionOrdinal(dbVersion) < common.VersionOrdinal(common.MySQLGeneratedColumnVersion) {
+		generationExpr = `'' GENERATION_EXPRESSION`
+	}
+
 	_, res, err = Query(m.Ctx, m.MySQLDB, fmt.Sprintf(`SELECT COLUMN_NAME,
 		DATA_TYPE,
 		IFNULL(CHARACTER_MAXIMUM_LENGTH,0) DATA_LENGTH,
@@ -61,11 +89,14 @@ func (m *MySQL) GetMySQLTableColumn(schemaName, tableName string) ([]map[string]
 		IFNULL(COLUMN_DEFAULT,'') DATA_DEFAULT,
 		IFNULL(COLUMN_COMMENT,'') COMMENTS,
 		IFNULL(CHARACTER_SET_NAME,'UNKNOWN') CHARACTER_SET_NAME,
-		IFNULL(COLLATION_NAME,'UNKNOWN') COLLATION_NAME
+		IFNULL(COLLATION_NAME,'UNKNOWN') COLLATION_NAME,
+		COLUMN_TYPE,
+		IFNULL(EXTRA,'') EXTRA,
+		%s
  FROM information_schema.COLUMNS
  WHERE UPPER(TABLE_SCHEMA) = UPPER('%s')
    AND UPPER(TABLE_NAME) = UPPER('%s')
- ORDER BY ORDINAL_POSITION`, schemaName, tableName))
+ ORDER BY ORDINAL_POSITION`, generationExpr, schemaName, tableName))
 
 	if err != nil {
 		return res, err
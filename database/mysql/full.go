@@ -16,18 +16,32 @@ limitations under the License.
 package mysql
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	driver "github.com/go-sql-driver/mysql"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/logger"
 	"go.uber.org/zap"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
 )
 
+// LOAD DATA LOCAL INFILE reader 注册序号
+var loadDataReaderID uint64
+
 func (m *MySQL) TruncateMySQLTable(targetSchema string, targetTable string) error {
 	_, err := m.MySQLDB.ExecContext(m.Ctx, fmt.Sprintf("TRUNCATE TABLE %s.%s", targetSchema, targetTable))
 	if err != nil {
 		return fmt.Errorf("truncate mysql schema [%v] table [%v] reocrd failed: %v", targetSchema, targetTable, err.Error())
 	}
-	zap.L().Info("truncate table",
-		zap.String("schema", targetSchema),
-		zap.String("table", targetTable),
+	logger.L(m.Ctx).Info("truncate table",
+		logger.SchemaT(targetSchema),
+		logger.TableT(targetTable),
 		zap.String("status", "success"))
 	return nil
 }
@@ -35,7 +49,113 @@ func (m *MySQL) TruncateMySQLTable(targetSchema string, targetTable string) erro
 func (m *MySQL) WriteMySQLTable(sql string) error {
 	_, err := m.MySQLDB.ExecContext(m.Ctx, sql)
 	if err != nil {
-		return fmt.Errorf("source schema table sql [%v] write failed: %v", sql, err)
+		return fmt.Errorf("source schema table sql [%v] write failed: %w", sql, err)
+	}
+	return nil
+}
+
+// LOAD DATA LOCAL INFILE 写入行数与 batch 行数不一致或存在 warning
+var ErrLoadDataRowsMismatch = errors.New("load data rows mismatch")
+
+// LOAD DATA LOCAL INFILE 流式写入，数据通过 go-sql-driver reader 注册传输，无需落盘
+// 数据格式：字段以 , 分隔，字符值以 ' 包裹、反斜杠转义，行以 \n 分隔，未包裹的 NULL 表示 NULL 值
+// safeMode 为 true 以 REPLACE 写入，否则以 IGNORE 写入
+// LOCAL 模式下主键/唯一键冲突、数据截断以及类型转换错误均降级为 warning，故事务内写入并校验 affected rows 以及 SHOW WARNINGS，
+// 不一致回滚并返回 ErrLoadDataRowsMismatch，由调用方回退 INSERT/REPLACE 写入
+func (m *MySQL) LoadMySQLTable(targetSchema, targetTable string, columns []string, safeMode bool, rows int, data io.Reader) error {
+	readerName := common.StringsBuilder("transferdb_", strconv.FormatUint(atomic.AddUint64(&loadDataReaderID, 1), 10))
+	driver.RegisterReaderHandler(readerName, func() io.Reader {
+		return data
+	})
+	defer driver.DeregisterReaderHandler(readerName)
+
+	loadSQL := GenMySQLLoadDataSQL(targetSchema, targetTable, columns, safeMode, m.loadDataCharset(), readerName)
+	txn, err := m.MySQLDB.BeginTx(m.Ctx, nil)
+	if err != nil {
+		return fmt.Errorf("source schema table sql [%v] load begin transaction failed: %w", loadSQL, err)
+	}
+	res, err := txn.ExecContext(m.Ctx, loadSQL)
+	if err != nil {
+		_ = txn.Rollback()
+		return fmt.Errorf("source schema table sql [%v] load failed: %w", loadSQL, err)
+	}
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		_ = txn.Rollback()
+		return fmt.Errorf("source schema table sql [%v] load affected rows failed: %w", loadSQL, err)
+	}
+	warnings, err := showMySQLWarnings(m.Ctx, txn)
+	if err != nil {
+		_ = txn.Rollback()
+		return fmt.Errorf("source schema table sql [%v] load show warnings failed: %w", loadSQL, err)
+	}
+
+	// REPLACE 冲突数据行 affected rows 计 2
+	if len(warnings) > 0 || (safeMode && affectedRows < int64(rows)) || (!safeMode && affectedRows != int64(rows)) {
+		if err = txn.Rollback(); err != nil {
+			return fmt.Errorf("source schema table sql [%v] load rollback failed: %v", loadSQL, err)
+		}
+		return fmt.Errorf("%w: target schema table [%s.%s] batch rows [%d], affected rows [%d], warnings [%s]",
+			ErrLoadDataRowsMismatch, targetSchema, targetTable, rows, affectedRows, common.TruncateString(strings.Join(warnings, "; "), 512))
+	}
+	if err = txn.Commit(); err != nil {
+		return fmt.Errorf("source schema table sql [%v] load commit failed: %w", loadSQL, err)
 	}
 	return nil
 }
+
+// LOAD DATA 字符集，未经 NewMySQLDBEngine 初始化时默认 utf8mb4
+func (m *MySQL) loadDataCharset() string {
+	if m.Charset == "" {
+		return common.MySQLCharacterSet
+	}
+	return m.Charset
+}
+
+func showMySQLWarnings(ctx context.Context, txn *sql.Tx) ([]string, error) {
+	rows, err := txn.QueryContext(ctx, "SHOW WARNINGS")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var warnings []string
+	for rows.Next() {
+		var (
+			level, message string
+			code           int
+		)
+		if err = rows.Scan(&level, &code, &message); err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, fmt.Sprintf("%s %d: %s", level, code, message))
+	}
+	return warnings, rows.Err()
+}
+
+// LOAD DATA LOCAL INFILE 语句
+func GenMySQLLoadDataSQL(targetSchema, targetTable string, columns []string, safeMode bool, charset, readerName string) string {
+	var duplicate string
+	if safeMode {
+		duplicate = "REPLACE"
+	} else {
+		duplicate = "IGNORE"
+	}
+	return common.StringsBuilder(`LOAD DATA LOCAL INFILE 'Reader::`, readerName, `' `, duplicate,
+		` INTO TABLE `, targetSchema, ".", targetTable,
+		` CHARACTER SET `, charset, ` FIELDS TERMINATED BY ',' ENCLOSED BY '\'' ESCAPED BY '\\' LINES TERMINATED BY '\n' (`,
+		strings.Join(columns, ","), `)`)
+}
+
+// connect-params charset 参数，形如 charset=utf8mb4,utf8 取首个字符集，未配置默认 utf8mb4
+func ParseMySQLCharset(connectParams string) string {
+	values, err := url.ParseQuery(connectParams)
+	if err != nil {
+		return common.MySQLCharacterSet
+	}
+	charset := strings.TrimSpace(strings.Split(values.Get("charset"), ",")[0])
+	if charset == "" {
+		return common.MySQLCharacterSet
+	}
+	return charset
+}
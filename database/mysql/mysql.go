@@ -17,21 +17,32 @@ package mysql
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
 	"fmt"
+	driver "github.com/go-sql-driver/mysql"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/errors"
+	"net"
+	"os"
+	"strconv"
 )
 
 type MySQL struct {
 	Ctx     context.Context
 	MySQLDB *sql.DB
+	// 连接字符集，LOAD DATA CHARACTER SET 使用
+	Charset string
 }
 
 func NewMySQLDBEngine(ctx context.Context, mysqlCfg config.MySQLConfig) (*MySQL, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?%s",
-		mysqlCfg.Username, mysqlCfg.Password, mysqlCfg.Host, mysqlCfg.Port, mysqlCfg.SchemaName, mysqlCfg.ConnectParams)
+	dsn, err := NewMySQLDSN(mysqlCfg, mysqlCfg.SchemaName, mysqlCfg.ConnectParams)
+	if err != nil {
+		return nil, errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, err)
+	}
 
 	mysqlDB, err := sql.Open("mysql", dsn)
 	if err != nil {
@@ -50,9 +61,76 @@ func NewMySQLDBEngine(ctx context.Context, mysqlCfg config.MySQLConfig) (*MySQL,
 	return &MySQL{
 		Ctx:     ctx,
 		MySQLDB: mysqlDB,
+		Charset: ParseMySQLCharset(mysqlCfg.ConnectParams),
 	}, nil
 }
 
+// 生成 MySQL 连接 DSN，配置 ssl-ca/ssl-cert/ssl-key/ssl-skip-verify 时开启 TLS
+// 由驱动 Config 格式化生成，用户名、密码特殊字符以及 IPv6 地址无需手工转义
+func NewMySQLDSN(mysqlCfg config.MySQLConfig, schemaName, params string) (string, error) {
+	dsnCfg, err := driver.ParseDSN("/?" + params)
+	if err != nil {
+		return "", fmt.Errorf("parse mysql connect-params [%s] failed: %v", params, err)
+	}
+	tlsName, err := registerMySQLTLSConfig(mysqlCfg)
+	if err != nil {
+		return "", err
+	}
+	if tlsName != "" {
+		dsnCfg.TLSConfig = tlsName
+	}
+	dsnCfg.User = mysqlCfg.Username
+	dsnCfg.Passwd = mysqlCfg.Password
+	dsnCfg.Net = "tcp"
+	dsnCfg.Addr = net.JoinHostPort(mysqlCfg.Host, strconv.Itoa(mysqlCfg.Port))
+	dsnCfg.DBName = schemaName
+	return dsnCfg.FormatDSN(), nil
+}
+
+// 注册 MySQL TLS 配置，名称由连接以及证书配置生成，server 模式不同任务配置互不覆盖，未配置 TLS 返回空
+func registerMySQLTLSConfig(mysqlCfg config.MySQLConfig) (string, error) {
+	if mysqlCfg.SSLCA == "" && mysqlCfg.SSLCert == "" && mysqlCfg.SSLKey == "" && !mysqlCfg.SSLSkipVerify {
+		return "", nil
+	}
+	tlsCfg := &tls.Config{
+		ServerName:         mysqlCfg.Host,
+		InsecureSkipVerify: mysqlCfg.SSLSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if mysqlCfg.SSLCA != "" {
+		caPEM, err := os.ReadFile(mysqlCfg.SSLCA)
+		if err != nil {
+			return "", fmt.Errorf("read mysql ssl-ca [%s] failed: %v", mysqlCfg.SSLCA, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return "", fmt.Errorf("mysql ssl-ca [%s] isn't valid PEM certificate", mysqlCfg.SSLCA)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if mysqlCfg.SSLCert != "" || mysqlCfg.SSLKey != "" {
+		if mysqlCfg.SSLCert == "" || mysqlCfg.SSLKey == "" {
+			return "", fmt.Errorf("mysql ssl-cert and ssl-key must be configured at the same time")
+		}
+		cert, err := tls.LoadX509KeyPair(mysqlCfg.SSLCert, mysqlCfg.SSLKey)
+		if err != nil {
+			return "", fmt.Errorf("load mysql ssl-cert [%s] ssl-key [%s] failed: %v", mysqlCfg.SSLCert, mysqlCfg.SSLKey, err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	tlsName := fmt.Sprintf("transferdb-%x", sha256.Sum256([]byte(fmt.Sprintf("%s:%d|%s|%s|%s|%v",
+		mysqlCfg.Host, mysqlCfg.Port, mysqlCfg.SSLCA, mysqlCfg.SSLCert, mysqlCfg.SSLKey, mysqlCfg.SSLSkipVerify))))[:24]
+	if err := driver.RegisterTLSConfig(tlsName, tlsCfg); err != nil {
+		return "", fmt.Errorf("register mysql tls config failed: %v", err)
+	}
+	return tlsName, nil
+}
+
+// 关闭数据库连接
+func (m *MySQL) Close() error {
+	return m.MySQLDB.Close()
+}
+
 func Query(ctx context.Context, db *sql.DB, querySQL string) ([]string, []map[string]string, error) {
 	var (
 		cols []string
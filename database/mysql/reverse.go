@@ -18,6 +18,7 @@ package mysql
 import (
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/logger"
 	"go.uber.org/zap"
 	"strings"
 )
@@ -70,9 +71,9 @@ func (m *MySQL) FilterIntersectionMySQLTable(schemaName string, includeTables []
 func (m *MySQL) RenameMySQLTableName(schemaName string, tableName string) error {
 	backupTable := fmt.Sprintf("%s_bak", tableName)
 	querySQL := fmt.Sprintf("RENAME TABLE `%s`.`%s` TO `%s`.`%s`", schemaName, tableName, schemaName, backupTable)
-	zap.L().Info("Exec SQL",
-		zap.String("schema", schemaName),
-		zap.String("table", tableName),
+	logger.L(m.Ctx).Info("Exec SQL",
+		logger.SchemaT(schemaName),
+		logger.TableT(tableName),
 		zap.String("sql", fmt.Sprintf("%v", querySQL)))
 	_, _, err := Query(m.Ctx, m.MySQLDB, querySQL)
 	if err != nil {
@@ -309,7 +310,7 @@ GROUP BY INDEX_NAME,UNIQUENESS,INDEX_TYPE,COLUMN_EXPRESSION`, schemaName, tableN
 }
 
 func (m *MySQL) GetMySQLTableComment(schemaName, tableName string) ([]map[string]string, error) {
-	_, res, err := Query(m.Ctx, m.MySQLDB, fmt.Sprintf(`SELECT TABLE_NAME,TABLE_COMMENT
+	_, res, err := Query(m.Ctx, m.MySQLDB, fmt.Sprintf(`SELECT TABLE_NAME,TABLE_COMMENT,IFNULL(AUTO_INCREMENT,1) AUTO_INCREMENT
 	FROM
 	INFORMATION_SCHEMA.TABLES
 	WHERE
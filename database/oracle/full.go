@@ -21,6 +21,10 @@ import (
 	"github.com/shopspring/decimal"
 	"github.com/thinkeridea/go-extend/exstrings"
 	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/logger"
+	"go.uber.org/zap"
+	"strconv"
 )
 
 func (o *Oracle) GetOracleCurrentSnapshotSCN() (uint64, error) {
@@ -37,6 +41,88 @@ func (o *Oracle) GetOracleCurrentSnapshotSCN() (uint64, error) {
 	return globalSCN, nil
 }
 
+// 获取全量同步全局 SCN
+// 开启 consistent-snapshot 时，断点续传以及新增表复用已初始化表的全局 SCN，保证所有表基于同一 SCN 一致性读，复用前校验 SCN 仍在 undo 保留期内
+func (o *Oracle) GetOracleGlobalSCN(ctx context.Context, metaDB *meta.Meta, schemaName, mode string, consistentSnapshot bool) (uint64, error) {
+	if consistentSnapshot {
+		waitSyncMetas, err := meta.NewWaitSyncMetaModel(metaDB).DetailWaitSyncMeta(ctx, &meta.WaitSyncMeta{
+			DBTypeS:     common.TaskDBOracle,
+			DBTypeT:     common.TaskDBMySQL,
+			SchemaNameS: common.StringUPPER(schemaName),
+			Mode:        mode,
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, w := range waitSyncMetas {
+			if w.FullGlobalSCN > 0 {
+				if err = o.CheckOracleSCNUndoRetention(w.FullGlobalSCN); err != nil {
+					return 0, err
+				}
+				logger.L(ctx).Info("reuse consistent snapshot scn",
+					zap.String("mode", mode),
+					logger.SCN(w.FullGlobalSCN))
+				return w.FullGlobalSCN, nil
+			}
+		}
+	}
+	globalSCN, err := o.GetOracleCurrentSnapshotSCN()
+	if err != nil {
+		return globalSCN, err
+	}
+	logger.L(ctx).Info("get global scn",
+		zap.String("mode", mode),
+		zap.Bool("consistent snapshot", consistentSnapshot),
+		logger.SCN(globalSCN))
+	return globalSCN, nil
+}
+
+// 校验 SCN 距今未超出 undo 保留时长（undo_retention 以及自动调整的 tuned_undoretention 取大），否则 AS OF SCN 查询报 ORA-01555
+func (o *Oracle) CheckOracleSCNUndoRetention(scn uint64) error {
+	_, res, err := Query(o.Ctx, o.OracleDB, `SELECT GREATEST(
+	(SELECT TO_NUMBER(VALUE) FROM V$PARAMETER WHERE NAME = 'undo_retention'),
+	(SELECT NVL(MAX(TUNED_UNDORETENTION), 0) FROM V$UNDOSTAT WHERE BEGIN_TIME = (SELECT MAX(BEGIN_TIME) FROM V$UNDOSTAT))) AS UNDO_RETENTION FROM DUAL`)
+	if err != nil {
+		return err
+	}
+	undoRetention, err := common.StrconvIntBitSize(res[0]["UNDO_RETENTION"], 64)
+	if err != nil {
+		return fmt.Errorf("get oracle undo retention [%s] common.StrconvIntBitSize failed: %v", res[0]["UNDO_RETENTION"], err)
+	}
+
+	// SCN 超出 SCN 与时间映射范围 SCN_TO_TIMESTAMP 报 ORA-08181，同样视为超出保留期
+	_, res, err = Query(o.Ctx, o.OracleDB, common.StringsBuilder(`SELECT ROUND((SYSDATE - CAST(SCN_TO_TIMESTAMP(`,
+		strconv.FormatUint(scn, 10), `) AS DATE)) * 86400) AS ELAPSED_SECONDS FROM DUAL`))
+	if err != nil {
+		return fmt.Errorf("oracle consistent snapshot scn [%d] is too old to resume (%v), AS OF SCN query would fail with ORA-01555 snapshot too old, please set enable-checkpoint = false and rerun", scn, err)
+	}
+	elapsed, err := common.StrconvIntBitSize(res[0]["ELAPSED_SECONDS"], 64)
+	if err != nil {
+		return fmt.Errorf("get oracle scn [%d] elapsed seconds [%s] common.StrconvIntBitSize failed: %v", scn, res[0]["ELAPSED_SECONDS"], err)
+	}
+	if elapsed > undoRetention {
+		return fmt.Errorf("oracle consistent snapshot scn [%d] was taken %d seconds ago, exceeds undo retention [%d] seconds, AS OF SCN query would fail with ORA-01555 snapshot too old, please increase undo_retention or set enable-checkpoint = false and rerun", scn, elapsed, undoRetention)
+	}
+	return nil
+}
+
+// 获取表统计信息平均行长度，单位 bytes，无统计信息返回 0
+func (o *Oracle) GetOracleTableAvgRowLengthByStatistics(schemaName, tableName string) (int, error) {
+	querySQL := common.StringsBuilder(`SELECT NVL(AVG_ROW_LEN,0) AS AVG_ROW_LEN FROM DBA_TABLES WHERE UPPER(OWNER) = UPPER('`, schemaName, `') AND UPPER(TABLE_NAME) = UPPER('`, tableName, `')`)
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return 0, err
+	}
+	if len(res) != 1 {
+		return 0, fmt.Errorf("get oracle schema table [%s.%s] avg row length by statistics falied, results: [%v]", schemaName, tableName, res)
+	}
+	avgRowLen, err := common.StrconvIntBitSize(res[0]["AVG_ROW_LEN"], 64)
+	if err != nil {
+		return 0, fmt.Errorf("get oracle schema table [%s.%s] avg row length [%s] by statistics common.StrconvIntBitSize falied: %v", schemaName, tableName, res[0]["AVG_ROW_LEN"], err)
+	}
+	return int(avgRowLen), nil
+}
+
 func (o *Oracle) StartOracleChunkCreateTask(taskName string) error {
 	querySQL := common.StringsBuilder(`SELECT COUNT(1) COUNT FROM user_parallel_execute_chunks WHERE TASK_NAME='`, taskName, `'`)
 	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
@@ -103,12 +189,12 @@ END;`)
 }
 
 // 获取表字段名以及行数据 -> 用于 FULL/ALL
-func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int) ([]string, []string, error) {
+func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int) ([]string, [][]string, error) {
 	var (
 		err          error
 		rowsResult   []string
 		rowsTMP      []string
-		batchResults []string
+		batchResults [][]string
 		cols         []string
 	)
 	rows, err := o.OracleDB.QueryContext(o.Ctx, querySQL)
@@ -203,18 +289,18 @@ func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int) ([
 				case "godror.Number":
 					r, err := decimal.NewFromString(string(raw))
 					if err != nil {
-						return cols, rowsResult, err
+						return cols, batchResults, err
 					}
 					if r.IsInteger() {
 						si, err := common.StrconvIntBitSize(string(raw), 64)
 						if err != nil {
-							return cols, rowsResult, err
+							return cols, batchResults, err
 						}
 						rowsResult = append(rowsResult, fmt.Sprintf("%v", si))
 					} else {
 						rf, err := common.StrconvFloatBitSize(string(raw), 64)
 						if err != nil {
-							return cols, rowsResult, err
+							return cols, batchResults, err
 						}
 						rowsResult = append(rowsResult, fmt.Sprintf("%v", rf))
 					}
@@ -232,9 +318,9 @@ func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int) ([
 
 		// batch 批次
 		if len(rowsTMP) == insertBatchSize {
-			batchResults = append(batchResults, exstrings.Join(rowsTMP, ","))
-			// 数组清空
-			rowsTMP = rowsTMP[0:0]
+			batchResults = append(batchResults, rowsTMP)
+			// 新数组，batch 保留行切片
+			rowsTMP = make([]string, 0, insertBatchSize)
 		}
 	}
 
@@ -244,7 +330,7 @@ func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int) ([
 
 	// 非 batch 批次
 	if len(rowsTMP) > 0 {
-		batchResults = append(batchResults, exstrings.Join(rowsTMP, ","))
+		batchResults = append(batchResults, rowsTMP)
 	}
 
 	return cols, batchResults, nil
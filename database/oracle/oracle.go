@@ -19,7 +19,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	_ "github.com/sijms/go-ora/v2"
+	go_ora "github.com/sijms/go-ora/v2"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
 	"strings"
@@ -38,7 +38,7 @@ func NewOracleDBEngine(ctx context.Context, oraCfg config.OracleConfig) (*Oracle
 	// You can specify connection timeout seconds with "?connect_timeout=15" - Ping uses this timeout, NOT the Deadline in Context!
 	// For more connection options, see [Godor Connection Handling](https://godror.github.io/godror/doc/connection.html).
 
-	connString := fmt.Sprintf("oracle://%s:%s@%s:%d/%s", oraCfg.Username, oraCfg.Password, oraCfg.Host, oraCfg.Port, oraCfg.ServiceName)
+	connString := go_ora.BuildUrl(oraCfg.Host, oraCfg.Port, oraCfg.ServiceName, oraCfg.Username, oraCfg.Password, NewOracleURLOptions(oraCfg))
 	// godror logger 日志输出
 	// godror.SetLogger(zapr.NewLogger(zap.L()))
 
@@ -58,6 +58,32 @@ func NewOracleDBEngine(ctx context.Context, oraCfg config.OracleConfig) (*Oracle
 	}, nil
 }
 
+// Oracle 连接 wallet 以及 SSL（TCPS）选项，未配置返回 nil
+func NewOracleURLOptions(oraCfg config.OracleConfig) map[string]string {
+	options := make(map[string]string)
+	if oraCfg.WalletDir != "" {
+		options["WALLET"] = oraCfg.WalletDir
+		if oraCfg.WalletPassword != "" {
+			options["WALLET PASSWORD"] = oraCfg.WalletPassword
+		}
+	}
+	if oraCfg.SSL {
+		options["SSL"] = "true"
+		if oraCfg.SSLSkipVerify {
+			options["SSL VERIFY"] = "false"
+		}
+	}
+	if len(options) == 0 {
+		return nil
+	}
+	return options
+}
+
+// 关闭数据库连接
+func (o *Oracle) Close() error {
+	return o.OracleDB.Close()
+}
+
 func Query(ctx context.Context, db *sql.DB, querySQL string) ([]string, []map[string]string, error) {
 	var (
 		cols []string
@@ -0,0 +1,123 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package oracle
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"sort"
+	"strings"
+)
+
+// 根据表级别数据迁移规则筛选迁移字段，字段顺序以表字段顺序为准
+// 规则配置的字段（column-fields、column-rule）不存在则报错，hash 转换字段为 LOB/LONG 类型则报错
+func FilterOracleTableColumnByRule(sourceTable string, columnsINFO []map[string]string, rule config.TableRule) ([]map[string]string, error) {
+	columnTypes := make(map[string]string)
+	for _, rowCol := range columnsINFO {
+		columnTypes[common.StringUPPER(rowCol["COLUMN_NAME"])] = common.StringUPPER(rowCol["DATA_TYPE"])
+	}
+
+	var ruleColumns []string
+	ruleColumns = append(ruleColumns, rule.ColumnFields...)
+	for _, colRule := range rule.ColumnRules {
+		ruleColumns = append(ruleColumns, colRule.ColumnName)
+	}
+	for _, c := range ruleColumns {
+		if _, ok := columnTypes[common.StringUPPER(c)]; !ok {
+			return nil, fmt.Errorf("oracle table [%s] rule column [%s] isn't exist", sourceTable, c)
+		}
+	}
+	for _, colRule := range rule.ColumnRules {
+		dataType := columnTypes[common.StringUPPER(colRule.ColumnName)]
+		if strings.EqualFold(colRule.Transform, common.TableRuleTransformHash) && common.IsContainString(common.TableRuleHashUnsupportedDataTypes, dataType) {
+			return nil, fmt.Errorf("oracle table [%s] rule column [%s] data type [%s] isn't support transform [%s], STANDARD_HASH can't apply to LOB/LONG column",
+				sourceTable, colRule.ColumnName, dataType, colRule.Transform)
+		}
+	}
+
+	if len(rule.ColumnFields) == 0 {
+		return columnsINFO, nil
+	}
+
+	var (
+		filterColumns []map[string]string
+		ruleFields    []string
+	)
+	for _, c := range rule.ColumnFields {
+		ruleFields = append(ruleFields, common.StringUPPER(c))
+	}
+	for _, rowCol := range columnsINFO {
+		if common.IsContainString(ruleFields, common.StringUPPER(rowCol["COLUMN_NAME"])) {
+			filterColumns = append(filterColumns, rowCol)
+		}
+	}
+	return filterColumns, nil
+}
+
+// 生成字段值转换查询表达式（不含别名），转换结果统一为字符类型
+func GenOracleColumnRuleExpr(columnName string, rule config.ColumnRule) (string, error) {
+	switch strings.ToLower(rule.Transform) {
+	case common.TableRuleTransformHash:
+		algorithm := common.TableRuleDefaultHashAlgorithm
+		if rule.Value != "" {
+			algorithm = common.StringUPPER(rule.Value)
+		}
+		if !common.IsContainString(common.TableRuleHashAlgorithms, algorithm) {
+			return "", fmt.Errorf("column [%s] transform [%s] algorithm [%s] isn't support, only support %v", columnName, rule.Transform, rule.Value, common.TableRuleHashAlgorithms)
+		}
+		return common.StringsBuilder("NVL2(", columnName, ",LOWER(RAWTOHEX(STANDARD_HASH(", columnName, ",'", algorithm, "'))),NULL)"), nil
+	case common.TableRuleTransformRedact:
+		value := common.TableRuleDefaultRedactValue
+		if rule.Value != "" {
+			value = rule.Value
+		}
+		return common.StringsBuilder("NVL2(", columnName, ",'", common.SpecialLettersUsingOracle([]byte(value)), "',NULL)"), nil
+	case common.TableRuleTransformTrim:
+		return common.StringsBuilder("RTRIM(", columnName, ")"), nil
+	case common.TableRuleTransformRemap:
+		if len(rule.Mapping) == 0 {
+			return "", fmt.Errorf("column [%s] transform [%s] mapping can't be empty", columnName, rule.Transform)
+		}
+		var keys []string
+		for k := range rule.Mapping {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var b strings.Builder
+		b.WriteString(common.StringsBuilder("CASE TO_CHAR(", columnName, ")"))
+		for _, k := range keys {
+			b.WriteString(common.StringsBuilder(" WHEN '", common.SpecialLettersUsingOracle([]byte(k)), "' THEN '", common.SpecialLettersUsingOracle([]byte(rule.Mapping[k])), "'"))
+		}
+		if rule.Value != "" {
+			b.WriteString(common.StringsBuilder(" ELSE '", common.SpecialLettersUsingOracle([]byte(rule.Value)), "' END"))
+		} else {
+			b.WriteString(common.StringsBuilder(" ELSE TO_CHAR(", columnName, ") END"))
+		}
+		return b.String(), nil
+	default:
+		return "", fmt.Errorf("column [%s] transform [%s] isn't support, only support hash/redact/trim/remap", columnName, rule.Transform)
+	}
+}
+
+// 查询条件追加表级别数据过滤条件
+func GenOracleTableRuleWhere(where, filter string) string {
+	if strings.TrimSpace(filter) == "" {
+		return where
+	}
+	return common.StringsBuilder("(", where, ") AND (", filter, ")")
+}
@@ -0,0 +1,134 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package postgres
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PostgreSQL 未加双引号的列别名折叠为小写，查询结果字段别名统一使用双引号保持大写
+
+func (p *Postgres) GetPostgresDBVersion() (string, error) {
+	_, res, err := Query(p.Ctx, p.PGDB, `SHOW server_version`)
+	if err != nil {
+		return "", err
+	}
+	if len(res) == 0 {
+		return "", fmt.Errorf("postgres db version query result is null")
+	}
+	return res[0]["server_version"], nil
+}
+
+// 获取 schema 下普通表以及分区表，表名原样返回
+func (p *Postgres) GetPostgresTable(schemaName string) ([]string, error) {
+	_, res, err := Query(p.Ctx, p.PGDB, fmt.Sprintf(`SELECT c.relname AS "TABLE_NAME"
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = '%s'
+AND c.relkind IN ('r', 'p')`, schemaName))
+	if err != nil {
+		return []string{}, err
+	}
+	var tables []string
+	for _, r := range res {
+		tables = append(tables, r["TABLE_NAME"])
+	}
+	return tables, nil
+}
+
+// 获取表字段信息，DATA_TYPE 为 format_type 格式，例如 character varying(10)、numeric(10,2)、timestamp(0) without time zone
+func (p *Postgres) GetPostgresTableColumn(schemaName, tableName string) ([]map[string]string, error) {
+	_, res, err := Query(p.Ctx, p.PGDB, fmt.Sprintf(`SELECT a.attname AS "COLUMN_NAME",
+	pg_catalog.format_type(a.atttypid, a.atttypmod) AS "DATA_TYPE",
+	CASE WHEN a.attnotnull THEN 'N' ELSE 'Y' END AS "NULLABLE",
+	COALESCE(pg_catalog.pg_get_expr(d.adbin, d.adrelid), '') AS "DATA_DEFAULT",
+	COALESCE(pg_catalog.col_description(c.oid, a.attnum), '') AS "COMMENTS"
+FROM pg_catalog.pg_attribute a
+JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+WHERE n.nspname = '%s'
+AND c.relname = '%s'
+AND a.attnum > 0
+AND NOT a.attisdropped
+ORDER BY a.attnum`, schemaName, tableName))
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// 获取表主键以及唯一约束，CONSTRAINT_TYPE 取值 PK/UK，字段按约束定义顺序逗号分隔
+func (p *Postgres) GetPostgresTablePrimaryAndUniqueKey(schemaName, tableName string) ([]map[string]string, error) {
+	_, res, err := Query(p.Ctx, p.PGDB, fmt.Sprintf(`SELECT con.conname AS "CONSTRAINT_NAME",
+	CASE con.contype WHEN 'p' THEN 'PK' ELSE 'UK' END AS "CONSTRAINT_TYPE",
+	string_agg(a.attname, ',' ORDER BY k.n) AS "COLUMN_LIST"
+FROM pg_catalog.pg_constraint con
+JOIN pg_catalog.pg_class c ON c.oid = con.conrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+CROSS JOIN LATERAL unnest(con.conkey) WITH ORDINALITY AS k(attnum, n)
+JOIN pg_catalog.pg_attribute a ON a.attrelid = c.oid AND a.attnum = k.attnum
+WHERE n.nspname = '%s'
+AND c.relname = '%s'
+AND con.contype IN ('p', 'u')
+GROUP BY con.conname, con.contype`, schemaName, tableName))
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// 获取表索引，排除主键以及唯一约束自动创建的索引，表达式索引 COLUMN_LIST 为表达式
+func (p *Postgres) GetPostgresTableIndex(schemaName, tableName string) ([]map[string]string, error) {
+	_, res, err := Query(p.Ctx, p.PGDB, fmt.Sprintf(`SELECT i.relname AS "INDEX_NAME",
+	CASE WHEN ix.indisunique THEN 'UNIQUE' ELSE 'NONUNIQUE' END AS "UNIQUENESS",
+	string_agg(pg_catalog.pg_get_indexdef(ix.indexrelid, k.n::int, true), ',' ORDER BY k.n) AS "COLUMN_LIST"
+FROM pg_catalog.pg_index ix
+JOIN pg_catalog.pg_class c ON c.oid = ix.indrelid
+JOIN pg_catalog.pg_class i ON i.oid = ix.indexrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+CROSS JOIN LATERAL unnest(ix.indkey::int2[]) WITH ORDINALITY AS k(attnum, n)
+WHERE n.nspname = '%s'
+AND c.relname = '%s'
+AND NOT EXISTS (
+	SELECT 1 FROM pg_catalog.pg_constraint con
+	WHERE con.conindid = ix.indexrelid AND con.contype IN ('p', 'u')
+)
+GROUP BY i.relname, ix.indisunique`, schemaName, tableName))
+	if err != nil {
+		return res, err
+	}
+	for _, r := range res {
+		r["COLUMN_LIST"] = strings.ReplaceAll(r["COLUMN_LIST"], `"`, "")
+	}
+	return res, nil
+}
+
+func (p *Postgres) GetPostgresTableComment(schemaName, tableName string) (string, error) {
+	_, res, err := Query(p.Ctx, p.PGDB, fmt.Sprintf(`SELECT COALESCE(pg_catalog.obj_description(c.oid, 'pg_class'), '') AS "COMMENTS"
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = '%s'
+AND c.relname = '%s'`, schemaName, tableName))
+	if err != nil {
+		return "", err
+	}
+	if len(res) == 0 {
+		return "", nil
+	}
+	return res[0]["COMMENTS"], nil
+}
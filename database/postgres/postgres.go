@@ -19,6 +19,12 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	_ "github.com/lib/pq"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/errors"
+	"net/url"
+	"strconv"
 	"strings"
 )
 
@@ -27,21 +33,53 @@ type Postgres struct {
 	PGDB *sql.DB
 }
 
-func NewPostgresEngine(ctx context.Context, dbUser, dbPassword, ipAddr, dbPort, dbName string) (*Postgres, error) {
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		ipAddr, dbPort, dbUser, dbPassword, dbName)
-	db, err := sql.Open("ora", dsn) // this does not really open a new connection
+func NewPostgresDBEngine(ctx context.Context, pgCfg config.PostgresConfig) (*Postgres, error) {
+	pgDB, err := sql.Open("postgres", NewPostgresDSN(pgCfg))
 	if err != nil {
-		return nil, fmt.Errorf("error on initializing database connection: %s", err.Error())
+		return nil, errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, fmt.Errorf("error on open postgres database connection [%v]: %v", pgCfg.DBName, err))
 	}
-	db.SetMaxIdleConns(100)
 
-	err = db.Ping() // This DOES open a connection if necessary. This makes sure the database is accessible
-	if err != nil {
-		return nil, fmt.Errorf("error on opening database connection: %s", err.Error())
+	pgDB.SetMaxIdleConns(common.MysqlMaxIdleConn)
+	pgDB.SetMaxOpenConns(common.MysqlMaxConn)
+	pgDB.SetConnMaxLifetime(common.MysqlConnMaxLifeTime)
+	pgDB.SetConnMaxIdleTime(common.MysqlConnMaxIdleTime)
+
+	if err = pgDB.Ping(); err != nil {
+		return nil, errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_DB, fmt.Errorf("error on ping postgres database connection [%v]: %v", pgCfg.DBName, err))
+	}
+
+	return &Postgres{
+		Ctx:  ctx,
+		PGDB: pgDB,
+	}, nil
+}
+
+// 生成 PostgreSQL 连接 DSN，ssl-mode 未配置默认 disable，connect-params 格式同 URL 查询参数
+func NewPostgresDSN(pgCfg config.PostgresConfig) string {
+	params := url.Values{}
+	if pgCfg.ConnectParams != "" {
+		if values, err := url.ParseQuery(pgCfg.ConnectParams); err == nil {
+			params = values
+		}
+	}
+	sslMode := pgCfg.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
 	}
+	params.Set("sslmode", sslMode)
+	u := &url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(pgCfg.Username, pgCfg.Password),
+		Host:     fmt.Sprintf("%s:%s", pgCfg.Host, strconv.Itoa(pgCfg.Port)),
+		Path:     "/" + pgCfg.DBName,
+		RawQuery: params.Encode(),
+	}
+	return u.String()
+}
 
-	return &Postgres{Ctx: ctx, PGDB: db}, nil
+// 关闭数据库连接
+func (p *Postgres) Close() error {
+	return p.PGDB.Close()
 }
 
 func Query(ctx context.Context, db *sql.DB, querySQL string) ([]string, []map[string]string, error) {
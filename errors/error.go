@@ -48,6 +48,10 @@ func (e MSError) Error() string {
 	return errInfo
 }
 
+func (e MSError) Unwrap() error {
+	return e.cause
+}
+
 func (e MSError) GetCodeText() string {
 	return e.errType.Explain()
 }
@@ -34,6 +34,8 @@ const (
 	DOMAIN_ASSESS  MSErrorDomain = common.TaskTypeObjectAssess
 	DOMAIN_REVERSE MSErrorDomain = common.TaskTypeObjectReverse
 	DOMAIN_CHECK   MSErrorDomain = common.TaskTypeObjectCheck
+	DOMAIN_RULE    MSErrorDomain = common.TaskTypeObjectRule
+	DOMAIN_TASK    MSErrorDomain = common.TaskTypeTaskManage
 
 	DOMAIN_SQL_MIGRATION  MSErrorDomain = common.TaskTypeDataSQLMigrate
 	DOMAIN_CSV_MIGRATION  MSErrorDomain = common.TaskTypeDataCSVMigrate
@@ -60,6 +62,8 @@ var explainMSErrorDomain = map[MSErrorDomain]string{
 	DOMAIN_ASSESS:         common.TaskTypeObjectAssess,
 	DOMAIN_REVERSE:        common.TaskTypeObjectReverse,
 	DOMAIN_CHECK:          common.TaskTypeObjectCheck,
+	DOMAIN_RULE:           common.TaskTypeObjectRule,
+	DOMAIN_TASK:           common.TaskTypeTaskManage,
 	DOMAIN_SQL_MIGRATION:  common.TaskTypeDataSQLMigrate,
 	DOMAIN_CSV_MIGRATION:  common.TaskTypeDataCSVMigrate,
 	DOMAIN_INCR_MIGRATION: common.TaskTypeDataIncrMigrate,
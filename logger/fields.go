@@ -0,0 +1,92 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// 日志关联字段，各模块统一字段名，便于日志聚合按任务、表以及 chunk 过滤
+const (
+	FieldTaskID  = "task_id"
+	FieldMode    = "mode"
+	FieldSchemaS = "schema_s"
+	FieldTableS  = "table_s"
+	FieldSchemaT = "schema_t"
+	FieldTableT  = "table_t"
+	FieldChunkID = "chunk_id"
+	FieldSCN     = "scn"
+)
+
+func TaskID(taskID string) zap.Field {
+	return zap.String(FieldTaskID, taskID)
+}
+
+func Mode(mode string) zap.Field {
+	return zap.String(FieldMode, mode)
+}
+
+func SchemaS(schemaName string) zap.Field {
+	return zap.String(FieldSchemaS, schemaName)
+}
+
+func TableS(tableName string) zap.Field {
+	return zap.String(FieldTableS, tableName)
+}
+
+func SchemaT(schemaName string) zap.Field {
+	return zap.String(FieldSchemaT, schemaName)
+}
+
+func TableT(tableName string) zap.Field {
+	return zap.String(FieldTableT, tableName)
+}
+
+// chunk 编号，元数据表 full_sync_meta/data_compare_meta 自增编号
+func ChunkID(chunkID uint) zap.Field {
+	return zap.Uint(FieldChunkID, chunkID)
+}
+
+func SCN(scn uint64) zap.Field {
+	return zap.Uint64(FieldSCN, scn)
+}
+
+type fieldsKey struct{}
+
+// WithFields 返回追加日志关联字段的 context，子 context 继承父 context 已有字段
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	parent, _ := ctx.Value(fieldsKey{}).([]zap.Field)
+	merged := make([]zap.Field, 0, len(parent)+len(fields))
+	merged = append(merged, parent...)
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// Fields 获取 context 携带的日志关联字段
+func Fields(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).([]zap.Field)
+	return fields
+}
+
+// L 返回携带 context 日志关联字段的子 logger
+func L(ctx context.Context) *zap.Logger {
+	return zap.L().With(Fields(ctx)...)
+}
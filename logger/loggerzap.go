@@ -16,6 +16,7 @@ limitations under the License.
 package logger
 
 import (
+	"os"
 	"strings"
 	"time"
 
@@ -27,24 +28,34 @@ import (
 
 const (
 	logTmFmt = "2006-01-02 15:04:05.000"
+
+	LogFormatText = "text"
+	LogFormatJSON = "json"
 )
 
 // 初始化日志记录器
 func NewZapLogger(cfg *config.Config) {
-	Encoder := GetEncoder()
-	WriteSyncer := GetWriteSyncer(cfg)
+	Encoder := GetEncoder(cfg.LogConfig.LogFormat)
 	LevelEnabler := GetLevelEnabler(cfg.LogConfig.LogLevel)
-	// ConsoleEncoder := GetConsoleEncoder()
-	newCore := zapcore.NewTee(
-		zapcore.NewCore(Encoder, WriteSyncer, LevelEnabler), // 写入文件
-		//zapcore.NewCore(ConsoleEncoder, zapcore.Lock(os.Stdout), zapcore.DebugLevel), // 写入控制台
-	)
-	logger := zap.New(newCore, zap.AddCaller())
+
+	var cores []zapcore.Core
+	// 写入文件
+	if cfg.LogConfig.LogFile != "" {
+		cores = append(cores, zapcore.NewCore(Encoder, GetWriteSyncer(cfg), LevelEnabler))
+	}
+	// 写入控制台，未配置日志文件时默认输出控制台
+	if cfg.LogConfig.LogStdout || cfg.LogConfig.LogFile == "" {
+		cores = append(cores, zapcore.NewCore(Encoder, zapcore.Lock(os.Stdout), LevelEnabler))
+	}
+	logger := zap.New(zapcore.NewTee(cores...), zap.AddCaller())
 	zap.ReplaceGlobals(logger)
 }
 
-// GetEncoder 自定义的Encoder
-func GetEncoder() zapcore.Encoder {
+// GetEncoder 自定义的Encoder，logFormat 为 json 时输出 JSON 格式，否则输出文本格式
+func GetEncoder(logFormat string) zapcore.Encoder {
+	if strings.EqualFold(logFormat, LogFormatJSON) {
+		return GetJSONEncoder()
+	}
 	return zapcore.NewConsoleEncoder(
 		zapcore.EncoderConfig{
 			TimeKey:        "ts",
@@ -62,6 +73,25 @@ func GetEncoder() zapcore.Encoder {
 		})
 }
 
+// GetJSONEncoder 输出 JSON 格式日志，字段名与文本格式保持一致
+func GetJSONEncoder() zapcore.Encoder {
+	return zapcore.NewJSONEncoder(
+		zapcore.EncoderConfig{
+			TimeKey:        "ts",
+			LevelKey:       "level",
+			NameKey:        "logger",
+			CallerKey:      "caller_line",
+			FunctionKey:    zapcore.OmitKey,
+			MessageKey:     "msg",
+			StacktraceKey:  "stacktrace",
+			LineEnding:     zapcore.DefaultLineEnding,
+			EncodeLevel:    zapcore.CapitalLevelEncoder,
+			EncodeTime:     zapcore.TimeEncoderOfLayout(logTmFmt),
+			EncodeDuration: zapcore.SecondsDurationEncoder,
+			EncodeCaller:   zapcore.ShortCallerEncoder,
+		})
+}
+
 // GetConsoleEncoder 输出日志到控制台
 func GetConsoleEncoder() zapcore.Encoder {
 	return zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
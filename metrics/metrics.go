@@ -0,0 +1,214 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/signal"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// 数据读写方向
+const (
+	DirectionRead  = "read"
+	DirectionWrite = "write"
+)
+
+// chunk 处理阶段
+const (
+	StageSplit   = "split"
+	StageExtract = "extract"
+	StageApply   = "apply"
+	StageWrite   = "write"
+	StageCompare = "compare"
+)
+
+// 数据校验不一致行类型
+const (
+	MismatchSourceMore = "source_more"
+	MismatchTargetMore = "target_more"
+)
+
+// 任务运行结果
+const (
+	TaskStatusSuccess  = "success"
+	TaskStatusFailed   = "failed"
+	TaskStatusCanceled = "canceled"
+)
+
+const namespace = "transferdb"
+
+var (
+	registry = prometheus.NewRegistry()
+
+	rowsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rows_total",
+		Help:      "Rows read from source or written to target per table.",
+	}, []string{"mode", "schema", "table", "direction"})
+	bytesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bytes_total",
+		Help:      "Bytes read from source or written to target per table.",
+	}, []string{"mode", "schema", "table", "direction"})
+	chunkDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "chunk_duration_seconds",
+		Help:      "Chunk processing duration by stage.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 14),
+	}, []string{"mode", "schema", "stage"})
+	applyErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "apply_errors_total",
+		Help:      "Target apply errors by MySQL error code, retried errors included.",
+	}, []string{"mode", "schema", "code"})
+	logminerQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "logminer_query_duration_seconds",
+		Help:      "Oracle logminer content query duration.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+	}, []string{"schema"})
+	incrLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "incr_lag_scn",
+		Help:      "Oracle current redo max SCN minus table applied SCN in incr_sync_meta.",
+	}, []string{"schema", "table"})
+	incrWorkerQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "incr_worker_queue_depth",
+		Help:      "Increment apply tasks waiting in worker pool queue.",
+	}, []string{"schema", "table"})
+	compareMismatchChunks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "compare_mismatch_chunks_total",
+		Help:      "Compare chunks whose source and target data aren't equal.",
+	}, []string{"schema", "table"})
+	compareMismatchRows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "compare_mismatch_rows_total",
+		Help:      "Compare mismatch rows, source_more means target missing rows, target_more means target extra rows.",
+	}, []string{"schema", "table", "kind"})
+	taskRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "task_runs_total",
+		Help:      "Task runs by mode and result.",
+	}, []string{"mode", "schema", "status"})
+	taskDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "task_last_duration_seconds",
+		Help:      "Last task run duration.",
+	}, []string{"mode", "schema"})
+)
+
+func init() {
+	registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		rowsTotal, bytesTotal, chunkDuration, applyErrors,
+		logminerQueryDuration, incrLag, incrWorkerQueueDepth,
+		compareMismatchChunks, compareMismatchRows,
+		taskRuns, taskDuration,
+	)
+}
+
+// 其他模块指标注册至共享 registry，随 /metrics 输出
+func MustRegister(cs ...prometheus.Collector) {
+	registry.MustRegister(cs...)
+}
+
+// Prometheus 指标输出，GET /metrics
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// 表级别读写数据行数以及字节数
+func AddRows(mode, schemaName, tableName, direction string, rows, bytes int64) {
+	schemaName, tableName = common.StringUPPER(schemaName), common.StringUPPER(tableName)
+	rowsTotal.WithLabelValues(mode, schemaName, tableName, direction).Add(float64(rows))
+	bytesTotal.WithLabelValues(mode, schemaName, tableName, direction).Add(float64(bytes))
+}
+
+// chunk 阶段耗时
+func ObserveChunk(mode, schemaName, stage string, d time.Duration) {
+	chunkDuration.WithLabelValues(mode, common.StringUPPER(schemaName), stage).Observe(d.Seconds())
+}
+
+// 目标端写入错误，按 MySQL 错误码统计
+func IncApplyError(mode, schemaName string, err error) {
+	if err == nil {
+		return
+	}
+	applyErrors.WithLabelValues(mode, common.StringUPPER(schemaName), MySQLErrorCode(err)).Inc()
+}
+
+var mysqlErrorCodeRegexp = regexp.MustCompile(`Error (\d+)`)
+
+// 获取 MySQL 错误码，错误信息形如 Error 1062: ... 或者 Error 1062 (23000): ...，非 MySQL 服务端错误返回 unknown
+func MySQLErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	if m := mysqlErrorCodeRegexp.FindStringSubmatch(err.Error()); m != nil {
+		return m[1]
+	}
+	return "unknown"
+}
+
+// logminer 查询耗时
+func ObserveLogminerQuery(schemaName string, d time.Duration) {
+	logminerQueryDuration.WithLabelValues(common.StringUPPER(schemaName)).Observe(d.Seconds())
+}
+
+// 增量同步延迟，当前重做日志最大 SCN 与表已应用 SCN 差值
+func SetIncrLag(schemaName, tableName string, currentSCN, appliedSCN uint64) {
+	var lag float64
+	if currentSCN > appliedSCN {
+		lag = float64(currentSCN - appliedSCN)
+	}
+	incrLag.WithLabelValues(common.StringUPPER(schemaName), common.StringUPPER(tableName)).Set(lag)
+}
+
+// 增量数据应用工作池队列深度
+func SetIncrWorkerQueueDepth(schemaName, tableName string, depth int) {
+	incrWorkerQueueDepth.WithLabelValues(common.StringUPPER(schemaName), common.StringUPPER(tableName)).Set(float64(depth))
+}
+
+// 数据校验不一致 chunk 以及行数
+func AddCompareMismatch(schemaName, tableName string, sourceMore, targetMore int64) {
+	schemaName, tableName = common.StringUPPER(schemaName), common.StringUPPER(tableName)
+	compareMismatchChunks.WithLabelValues(schemaName, tableName).Inc()
+	compareMismatchRows.WithLabelValues(schemaName, tableName, MismatchSourceMore).Add(float64(sourceMore))
+	compareMismatchRows.WithLabelValues(schemaName, tableName, MismatchTargetMore).Add(float64(targetMore))
+}
+
+// 任务运行结果以及耗时，优雅停止记为 canceled
+func ObserveTask(mode, schemaName string, d time.Duration, err error) {
+	status := TaskStatusSuccess
+	switch {
+	case errors.Is(err, signal.ErrGracefulStop):
+		status = TaskStatusCanceled
+	case err != nil:
+		status = TaskStatusFailed
+	}
+	schemaName = common.StringUPPER(schemaName)
+	taskRuns.WithLabelValues(mode, schemaName, status).Inc()
+	taskDuration.WithLabelValues(mode, schemaName).Set(d.Seconds())
+}
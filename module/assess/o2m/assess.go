@@ -22,6 +22,7 @@ import (
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/logger"
 	"go.uber.org/zap"
 	"os"
 	"path/filepath"
@@ -47,9 +48,8 @@ func NewAssess(ctx context.Context, cfg *config.Config, metaDB *meta.Meta, oracl
 
 func (r *Assess) Assess() error {
 	startTime := time.Now()
-	zap.L().Info("assess oracle migrate myoracle cost start",
-		zap.String("oracleSchema", r.cfg.OracleConfig.SchemaName),
-		zap.String("myoracleSchema", r.cfg.MySQLConfig.SchemaName))
+	logger.L(r.ctx).Info("assess oracle migrate myoracle cost start",
+		logger.SchemaT(r.cfg.MySQLConfig.SchemaName))
 
 	var (
 		usernameSQL   string
@@ -17,24 +17,61 @@ package check
 
 import (
 	"bufio"
+	"fmt"
 	"os"
+	"sort"
 	"sync"
 )
 
+// 结构修复 SQL 阶段
+// 修复语句按阶段统一输出（跨表），保证依次执行不会因依赖关系失败
+// 1、先删除冲突的主键、索引
+// 2、再修改表字符集、字段，新增字段
+// 3、字段就绪后新增主键、唯一约束以及索引
+// 4、外键依赖被引用表主键/唯一约束，放在所有表约束、索引之后
+const (
+	FixStageDrop = iota
+	FixStageTable
+	FixStageColumn
+	FixStageKey
+	FixStageIndex
+	FixStageConstraint
+	FixStageComment
+)
+
+var fixStageComments = map[int]string{
+	FixStageDrop:       "drop conflicting primary key and indexes",
+	FixStageTable:      "modify table character set and collation",
+	FixStageColumn:     "modify and add columns",
+	FixStageKey:        "add primary key and unique key",
+	FixStageIndex:      "add indexes",
+	FixStageConstraint: "add foreign key and check constraints",
+	FixStageComment:    "modify table comment",
+}
+
 type File struct {
 	CFile   *os.File
+	FFile   *os.File
 	CWriter *bufio.Writer
+	FWriter *bufio.Writer
 	Mutex   *sync.Mutex
+	// check 文件输出格式，默认 SECTION
+	TextFormat string
+
+	fixFile string
+	fixSQL  map[int][][]string
+	diffs   []Difference
 }
 
-func NewWriter(checkFile string) (*File, error) {
+func NewWriter(checkFile, fixFile string) (*File, error) {
 	f := &File{}
-	err := f.initOutFile(checkFile)
+	err := f.initOutFile(checkFile, fixFile)
 	if err != nil {
 		return nil, err
 	}
 
 	f.Mutex = &sync.Mutex{}
+	f.fixSQL = make(map[int][][]string)
 	return f, nil
 }
 
@@ -44,12 +81,88 @@ func (f *File) CWriteString(s string) (nn int, err error) {
 	return f.CWriter.WriteString(s)
 }
 
-func (f *File) initOutFile(checkFile string) error {
+// FWriteString 暂存单表修复 SQL，Close 时按阶段顺序统一写入修复文件，同一批次语句保持生成顺序
+func (f *File) FWriteString(stage int, sqls ...string) {
+	if len(sqls) == 0 {
+		return
+	}
+	f.Mutex.Lock()
+	defer f.Mutex.Unlock()
+	f.fixSQL[stage] = append(f.fixSQL[stage], sqls)
+}
+
+// AddDifference 暂存表结构差异记录，用于生成 JSON/HTML 报告
+func (f *File) AddDifference(diffs ...Difference) {
+	f.Mutex.Lock()
+	defer f.Mutex.Unlock()
+	f.diffs = append(f.diffs, diffs...)
+}
+
+func (f *File) Differences() []Difference {
+	f.Mutex.Lock()
+	defer f.Mutex.Unlock()
+	return f.diffs
+}
+
+// 存在修复 SQL 并已写入修复文件
+func (f *File) HasFixSQL() bool {
+	return f.FFile != nil
+}
+
+func (f *File) initOutFile(checkFile, fixFile string) error {
 	outCheckFile, err := os.OpenFile(checkFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND|os.O_TRUNC, 0666)
 	if err != nil {
 		return err
 	}
 	f.CWriter, f.CFile = bufio.NewWriter(outCheckFile), outCheckFile
+	// 修复文件 Close 时存在修复 SQL 才创建，清理历史运行遗留的修复文件
+	f.fixFile = fixFile
+	if err = os.Remove(fixFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (f *File) flushFixSQL() error {
+	var exist bool
+	for _, groups := range f.fixSQL {
+		if len(groups) > 0 {
+			exist = true
+			break
+		}
+	}
+	if !exist {
+		return nil
+	}
+	outFixFile, err := os.OpenFile(f.fixFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	f.FWriter, f.FFile = bufio.NewWriter(outFixFile), outFixFile
+
+	for stage := FixStageDrop; stage <= FixStageComment; stage++ {
+		groups := f.fixSQL[stage]
+		if len(groups) == 0 {
+			continue
+		}
+		// 表间并发检查，按表批次排序保证输出稳定，批次内语句存在依赖（例如先删除后新增）保持生成顺序
+		sort.SliceStable(groups, func(i, j int) bool {
+			return groups[i][0] < groups[j][0]
+		})
+		if _, err = f.FWriter.WriteString(fmt.Sprintf("-- %s\n", fixStageComments[stage])); err != nil {
+			return err
+		}
+		for _, sqls := range groups {
+			for _, s := range sqls {
+				if _, err = f.FWriter.WriteString(s + "\n"); err != nil {
+					return err
+				}
+			}
+		}
+		if _, err = f.FWriter.WriteString("\n"); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -64,5 +177,18 @@ func (f *File) Close() error {
 			return err
 		}
 	}
+	if err := f.flushFixSQL(); err != nil {
+		return err
+	}
+	if f.FFile != nil {
+		err := f.FWriter.Flush()
+		if err != nil {
+			return err
+		}
+		err = f.FFile.Close()
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -16,17 +16,17 @@ limitations under the License.
 package check
 
 type Checker interface {
-	CheckPartitionTableType() string
-	CheckTableComment() string
-	CheckTableCharacterSetAndCollation() string
-	CheckColumnCharacterSetAndCollation() string
-	CheckColumnCounts() (string, error)
-	CheckPrimaryAndUniqueKey() (string, error)
-	CheckForeignKey() (string, error)
-	CheckCheckKey() (string, error)
-	CheckIndex() (string, error)
-	CheckPartitionTable() (string, error)
-	CheckColumn() (string, error)
+	CheckPartitionTableType() []Difference
+	CheckTableComment() []Difference
+	CheckTableCharacterSetAndCollation() []Difference
+	CheckColumnCharacterSetAndCollation() []Difference
+	CheckColumnCounts() ([]Difference, error)
+	CheckPrimaryAndUniqueKey() ([]Difference, error)
+	CheckForeignKey() ([]Difference, error)
+	CheckCheckKey() ([]Difference, error)
+	CheckIndex() ([]Difference, error)
+	CheckPartitionTable() ([]Difference, error)
+	CheckColumn() ([]Difference, error)
 }
 
 type Writer interface {
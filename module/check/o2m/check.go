@@ -23,7 +23,9 @@ import (
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/logger"
 	"github.com/wentaojin/transferdb/module/check"
+	"github.com/wentaojin/transferdb/signal"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	"os"
@@ -52,9 +54,8 @@ func NewO2MCheck(ctx context.Context, cfg *config.Config, oracle *oracle.Oracle,
 
 func (r *O2M) NewCheck() error {
 	startTime := time.Now()
-	zap.L().Info("check oracle and mysql table start",
-		zap.String("oracleSchema", r.cfg.OracleConfig.SchemaName),
-		zap.String("mysqlSchema", r.cfg.MySQLConfig.SchemaName))
+	logger.L(r.ctx).Info("check oracle and mysql table start",
+		logger.SchemaT(r.cfg.MySQLConfig.SchemaName))
 
 	exporters, err := filterCFGTable(r.cfg, r.oracle)
 	if err != nil {
@@ -123,8 +124,7 @@ func (r *O2M) NewCheck() error {
 		oracleDBCollation = true
 	}
 	finishTime := time.Now()
-	zap.L().Info("get oracle db character and version finished",
-		zap.String("schema", r.cfg.OracleConfig.SchemaName),
+	logger.L(r.ctx).Info("get oracle db character and version finished",
 		zap.String("db version", oracleDBVersion),
 		zap.String("db character", oracleDBCharacterSet),
 		zap.Int("table totals", len(exporters)),
@@ -147,8 +147,7 @@ func (r *O2M) NewCheck() error {
 			return err
 		}
 		finishTime = time.Now()
-		zap.L().Info("get oracle schema and table collation finished",
-			zap.String("schema", r.cfg.OracleConfig.SchemaName),
+		logger.L(r.ctx).Info("get oracle schema and table collation finished",
 			zap.String("db version", oracleDBVersion),
 			zap.String("db character", oracleDBCharacterSet),
 			zap.Int("table totals", len(exporters)),
@@ -185,17 +184,23 @@ func (r *O2M) NewCheck() error {
 	}
 
 	checkFile := filepath.Join(pwdDir, fmt.Sprintf("check_%s.sql", r.cfg.OracleConfig.SchemaName))
+	fixFile := filepath.Join(pwdDir, fmt.Sprintf("fix_structure_%s.sql", r.cfg.OracleConfig.SchemaName))
 
 	// file writer
-	f, err := check.NewWriter(checkFile)
+	f, err := check.NewWriter(checkFile, fixFile)
 	if err != nil {
 		return err
 	}
+	f.TextFormat = r.cfg.AppConfig.CheckTextFormat
 
 	g := &errgroup.Group{}
 	g.SetLimit(r.cfg.AppConfig.Threads)
 
+	// 收到优雅停止信号后不再调度新的表，进行中的表完成并写入文件后退出
 	for _, task := range tasks {
+		if signal.IsStopping(r.ctx) {
+			break
+		}
 		t := task
 		g.Go(func() error {
 			oracleTableInfo, err := t.GenOracleTable()
@@ -222,6 +227,18 @@ func (r *O2M) NewCheck() error {
 	if err = f.Close(); err != nil {
 		return err
 	}
+	if signal.IsStopping(r.ctx) {
+		return signal.ErrGracefulStop
+	}
+
+	// 结构化差异报告 JSON/HTML
+	report := check.NewReport(common.StringUPPER(r.cfg.OracleConfig.SchemaName), common.StringUPPER(r.cfg.MySQLConfig.SchemaName),
+		len(exporters), f.Differences())
+	jsonFile := filepath.Join(pwdDir, fmt.Sprintf("check_%s.json", r.cfg.OracleConfig.SchemaName))
+	htmlFile := filepath.Join(pwdDir, fmt.Sprintf("check_%s.html", r.cfg.OracleConfig.SchemaName))
+	if err = genCheckReport(report, jsonFile, htmlFile); err != nil {
+		return err
+	}
 
 	checkError, err := meta.NewErrorLogDetailModel(r.metaDB).CountsErrorLogBySchema(r.ctx, &meta.ErrorLogDetail{
 		DBTypeS:     common.TaskDBOracle,
@@ -233,8 +250,16 @@ func (r *O2M) NewCheck() error {
 		return err
 	}
 
+	// 无结构差异不生成修复文件
+	if !f.HasFixSQL() {
+		fixFile = "none"
+	}
 	endTime := time.Now()
-	zap.L().Info("check", zap.String("output", filepath.Join(pwdDir, fmt.Sprintf("check_%s.sql", r.cfg.OracleConfig.SchemaName))))
+	zap.L().Info("check", zap.String("output", checkFile), zap.String("fix structure", fixFile),
+		zap.String("json report", jsonFile), zap.String("html report", htmlFile),
+		zap.Int("diff errors", report.Errors),
+		zap.Int("diff warns", report.Warns),
+		zap.Int("diff infos", report.Infos))
 	if checkError == 0 {
 		zap.L().Info("check table oracle to mysql finished",
 			zap.Int("table totals", len(exporters)),
@@ -249,5 +274,31 @@ func (r *O2M) NewCheck() error {
 			zap.String("failed tips", "failed detail, please see table [error_log_detail]"),
 			zap.String("cost", endTime.Sub(startTime).String()))
 	}
+
+	// 存在 ERROR 级别差异，非零状态退出，便于 CI 流水线判定
+	if report.Errors > 0 {
+		return fmt.Errorf("check schema [%s] table struct exist [%d] error level differences, please see report [%s]", common.StringUPPER(r.cfg.OracleConfig.SchemaName), report.Errors, htmlFile)
+	}
+	return nil
+}
+
+func genCheckReport(report *check.Report, jsonFile, htmlFile string) error {
+	jf, err := os.OpenFile(jsonFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer jf.Close()
+	if err = check.GenJSONReport(report, jf); err != nil {
+		return err
+	}
+
+	hf, err := os.OpenFile(htmlFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer hf.Close()
+	if err = check.GenHTMLReport(report, hf); err != nil {
+		return err
+	}
 	return nil
 }
@@ -19,12 +19,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/logger"
 	"github.com/wentaojin/transferdb/module/check"
 	"go.uber.org/zap"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -36,6 +37,9 @@ type Check struct {
 	MySQLDBVersion  string     `json:"mysqldb_version"`
 	MySQLDBType     string     `json:"mysqldb_type"`
 	MetaDB          *meta.Meta `json:"-"`
+
+	// 结构修复 SQL，按修复阶段分类，由 Writer 写入修复文件
+	FixSQL map[int][]string `json:"-"`
 }
 
 func NewChecker(ctx context.Context, oracleTableInfo, mysqlTableInfo *Table, mysqlDBVersion, targetDBType string, metaDB *meta.Meta) *Check {
@@ -46,6 +50,30 @@ func NewChecker(ctx context.Context, oracleTableInfo, mysqlTableInfo *Table, mys
 		MySQLDBVersion:  mysqlDBVersion,
 		MySQLDBType:     targetDBType,
 		MetaDB:          metaDB,
+		FixSQL:          make(map[int][]string),
+	}
+}
+
+// addFixSQL 记录修复阶段 SQL，返回去除首尾空白的 SQL 用于差异记录
+func (c *Check) addFixSQL(stage int, sql string) string {
+	sql = strings.TrimSpace(sql)
+	c.FixSQL[stage] = append(c.FixSQL[stage], sql)
+	return sql
+}
+
+func (c *Check) newDifference(section int, object, attribute, severity, sourceValue, targetValue, expectedValue, suggest string, fixSQL ...string) check.Difference {
+	return check.Difference{
+		SchemaName:    c.OracleTableINFO.SchemaName,
+		TableName:     c.OracleTableINFO.TableName,
+		Object:        object,
+		Attribute:     attribute,
+		SourceValue:   sourceValue,
+		TargetValue:   targetValue,
+		ExpectedValue: expectedValue,
+		Severity:      severity,
+		Suggest:       suggest,
+		FixSQL:        fixSQL,
+		Section:       section,
 	}
 }
 
@@ -55,61 +83,49 @@ func NewChecker(ctx context.Context, oracleTableInfo, mysqlTableInfo *Table, mys
 // 2、忽略上下游不同索引名、约束名对比，只对比下游是否存在同等约束下同等字段是否存在
 // 3、分区只对比分区类型、分区键、分区表达式等，不对比具体每个分区下的情况
 
-func (c *Check) CheckPartitionTableType() string {
+func (c *Check) CheckPartitionTableType() []check.Difference {
 	// 表类型检查 - only 分区表
-	zap.L().Info("check table",
-		zap.String("table partition type check", fmt.Sprintf("%s.%s", c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName)))
+	logger.L(c.Ctx).Info("check table partition type",
+		logger.TableS(c.OracleTableINFO.TableName))
 
-	var builder strings.Builder
+	var diffs []check.Difference
 	if c.OracleTableINFO.IsPartition != c.MySQLTableINFO.IsPartition {
-		builder.WriteString("/*\n")
-		builder.WriteString(fmt.Sprintf(" oracle table type is different from mysql table type\n"))
-
-		t := table.NewWriter()
-		t.SetStyle(table.StyleLight)
-		t.AppendHeader(table.Row{"TABLE", "PARTITION", "ORACLE", "MYSQL", "SUGGEST"})
-		t.AppendRows([]table.Row{
-			{c.OracleTableINFO.TableName, "PARTITION", c.OracleTableINFO.IsPartition, c.MySQLTableINFO.IsPartition, "Manual Create Partition Table"},
-		})
-		builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
-		builder.WriteString("*/\n")
-
-		zap.L().Warn("table type different",
-			zap.String("oracle table", fmt.Sprintf("%s.%s partition [%t]", c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableCharacterSet, c.OracleTableINFO.IsPartition)),
-			zap.String("mysql table", fmt.Sprintf("%s.%s partition [%t]", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, c.MySQLTableINFO.IsPartition)))
-	}
-	return builder.String()
-
+		diffs = append(diffs, c.newDifference(check.SectionPartitionType, c.OracleTableINFO.TableName, check.AttributePartitionType, check.SeverityWarn,
+			strconv.FormatBool(c.OracleTableINFO.IsPartition),
+			strconv.FormatBool(c.MySQLTableINFO.IsPartition),
+			strconv.FormatBool(c.OracleTableINFO.IsPartition),
+			"Manual Create Partition Table"))
+
+		logger.L(c.Ctx).Warn("table type different",
+			logger.TableS(c.OracleTableINFO.TableName),
+			zap.Bool("oracle partition", c.OracleTableINFO.IsPartition),
+			logger.SchemaT(c.MySQLTableINFO.SchemaName),
+			logger.TableT(c.MySQLTableINFO.TableName),
+			zap.Bool("mysql partition", c.MySQLTableINFO.IsPartition))
+	}
+	return diffs
 }
 
-func (c *Check) CheckTableComment() string {
+func (c *Check) CheckTableComment() []check.Difference {
 	// 表注释检查
-	zap.L().Info("check table",
-		zap.String("table comment check", fmt.Sprintf("%s.%s", c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName)))
+	logger.L(c.Ctx).Info("check table comment",
+		logger.TableS(c.OracleTableINFO.TableName))
 
-	var builder strings.Builder
+	var diffs []check.Difference
 	if !strings.EqualFold(c.OracleTableINFO.TableComment, c.MySQLTableINFO.TableComment) {
-		builder.WriteString("/*\n")
-		builder.WriteString(fmt.Sprintf(" oracle and mysql table comment\n"))
-
-		t := table.NewWriter()
-		t.SetStyle(table.StyleLight)
-		t.AppendHeader(table.Row{"TABLE", "COMMENT", "ORACLE", "MYSQL", "SUGGEST"})
-		t.AppendRows([]table.Row{
-			{c.OracleTableINFO.TableName, "COMMENT", c.OracleTableINFO.TableComment, c.MySQLTableINFO.TableComment, "Create Table Comment"},
-		})
-		builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
-
-		builder.WriteString("*/\n")
-		builder.WriteString(fmt.Sprintf("ALTER TABLE %s.%s COMMENT '%s';\n", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, c.OracleTableINFO.TableComment))
+		sql := c.addFixSQL(check.FixStageComment,
+			fmt.Sprintf("ALTER TABLE %s.%s COMMENT '%s';", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, c.OracleTableINFO.TableComment))
+		diffs = append(diffs, c.newDifference(check.SectionComment, c.OracleTableINFO.TableName, check.AttributeComment, check.SeverityInfo,
+			c.OracleTableINFO.TableComment, c.MySQLTableINFO.TableComment, c.OracleTableINFO.TableComment,
+			"Create Table Comment", sql))
 	}
-	return builder.String()
+	return diffs
 }
 
-func (c *Check) CheckTableCharacterSetAndCollation() string {
+func (c *Check) CheckTableCharacterSetAndCollation() []check.Difference {
 	// 表级别字符集以及排序规则检查
-	zap.L().Info("check table",
-		zap.String("table character set and collation check", fmt.Sprintf("%s.%s", c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName)))
+	logger.L(c.Ctx).Info("check table character set and collation",
+		logger.TableS(c.OracleTableINFO.TableName))
 	// GBK 处理，统一 UTF8MB4 处理
 	var oracleCharacterSet string
 	if common.OracleDBCharacterSetMap[c.OracleTableINFO.TableCharacterSet] == "GBK" {
@@ -118,297 +134,217 @@ func (c *Check) CheckTableCharacterSetAndCollation() string {
 		oracleCharacterSet = common.OracleDBCharacterSetMap[c.OracleTableINFO.TableCharacterSet]
 	}
 
-	var builder strings.Builder
+	var diffs []check.Difference
 
 	if c.MySQLTableINFO.TableCharacterSet != oracleCharacterSet || c.MySQLTableINFO.TableCollation != strings.ToUpper(common.OracleCollationMap[c.OracleTableINFO.TableCollation]) {
-		builder.WriteString("/*\n")
-		builder.WriteString(fmt.Sprintf(" oracle and mysql table character set and collation\n"))
-
-		t := table.NewWriter()
-		t.SetStyle(table.StyleLight)
-		t.AppendHeader(table.Row{"TABLE", "CHARACTER AND COLLATION", "ORACLE", "MYSQL", "SUGGEST"})
-		t.AppendRows([]table.Row{
-			{c.OracleTableINFO.TableName, "CHARACTER AND COLLATION",
-				fmt.Sprintf("character set [%s] collation [%s]", c.OracleTableINFO.TableCharacterSet, c.OracleTableINFO.TableCollation),
-				fmt.Sprintf("character set [%s] collation [%s]", c.MySQLTableINFO.TableCharacterSet, c.MySQLTableINFO.TableCollation),
-				"Create Table Character Collation"},
-		})
-		builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
-
-		builder.WriteString("*/\n")
-
 		// GBK 处理，统一 UTF8MB4 处理
 		var mysqlCharacterSet string
 		if strings.ToUpper(common.OracleDBCharacterSetMap[c.OracleTableINFO.TableCharacterSet]) == "GBK" {
 			mysqlCharacterSet = "UTF8MB4"
-			zap.L().Warn("check oracle table",
-				zap.String("schema", c.OracleTableINFO.SchemaName),
-				zap.String("table", c.OracleTableINFO.TableName),
+			logger.L(c.Ctx).Warn("check oracle table",
+				logger.TableS(c.OracleTableINFO.TableName),
 				zap.String("characterSet", c.OracleTableINFO.TableCharacterSet),
 				zap.String("msg", "GBK TO UTF8MB4"))
 		} else {
 			mysqlCharacterSet = common.OracleDBCharacterSetMap[c.OracleTableINFO.TableCharacterSet]
 		}
-		builder.WriteString(fmt.Sprintf("ALTER TABLE %s.%s CHARACTER SET %s COLLATE %s;\n\n", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName,
-			strings.ToLower(mysqlCharacterSet),
-			strings.ToLower(common.OracleCollationMap[c.OracleTableINFO.TableCollation])))
+		sql := c.addFixSQL(check.FixStageTable,
+			fmt.Sprintf("ALTER TABLE %s.%s CHARACTER SET %s COLLATE %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName,
+				strings.ToLower(mysqlCharacterSet),
+				strings.ToLower(common.OracleCollationMap[c.OracleTableINFO.TableCollation])))
+
+		diffs = append(diffs, c.newDifference(check.SectionTableCharacterSet, c.OracleTableINFO.TableName, check.AttributeCharacterSet, check.SeverityWarn,
+			fmt.Sprintf("character set [%s] collation [%s]", c.OracleTableINFO.TableCharacterSet, c.OracleTableINFO.TableCollation),
+			fmt.Sprintf("character set [%s] collation [%s]", c.MySQLTableINFO.TableCharacterSet, c.MySQLTableINFO.TableCollation),
+			fmt.Sprintf("character set [%s] collation [%s]", strings.ToUpper(mysqlCharacterSet), strings.ToUpper(common.OracleCollationMap[c.OracleTableINFO.TableCollation])),
+			"Create Table Character Collation", sql))
 	}
 
-	return builder.String()
+	return diffs
 }
 
-func (c *Check) CheckColumnCharacterSetAndCollation() string {
+func (c *Check) CheckColumnCharacterSetAndCollation() []check.Difference {
 	// 1、表字段级别字符集以及排序规则校验 -> 基于原表字段类型以及字符集、排序规则
 	// 2、下游表字段数检查多了
-	zap.L().Info("check table",
-		zap.String("table column character set and collation check", fmt.Sprintf("%s.%s", c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName)))
-
-	var builder strings.Builder
+	logger.L(c.Ctx).Info("check table column character set and collation",
+		logger.TableS(c.OracleTableINFO.TableName))
 
-	tableColumnsMap := make(map[string]Column)
-	delColumnsMap := make(map[string]Column)
+	var diffs []check.Difference
 
 	for mysqlColName, mysqlColInfo := range c.MySQLTableINFO.Columns {
-		if _, ok := c.OracleTableINFO.Columns[strings.ToUpper(mysqlColName)]; ok {
-			if mysqlColInfo.CharacterSet != "UNKNOWN" || mysqlColInfo.Collation != "UNKNOWN" {
-				// GBK 处理，统一视作 UTF8MB4 处理
-				var oracleCharacterSet string
-				if strings.ToUpper(common.OracleDBCharacterSetMap[c.OracleTableINFO.Columns[strings.ToUpper(mysqlColName)].CharacterSet]) == "GBK" {
-					oracleCharacterSet = common.MySQLCharacterSet
-				} else {
-					oracleCharacterSet = strings.ToUpper(common.OracleDBCharacterSetMap[c.OracleTableINFO.Columns[strings.ToUpper(mysqlColName)].CharacterSet])
-				}
-				if mysqlColInfo.CharacterSet != oracleCharacterSet || mysqlColInfo.Collation !=
-					strings.ToUpper(common.OracleCollationMap[c.OracleTableINFO.Columns[strings.ToUpper(mysqlColName)].Collation]) {
-					tableColumnsMap[mysqlColName] = mysqlColInfo
-				}
-			}
-		} else {
-			delColumnsMap[mysqlColName] = mysqlColInfo
-		}
-	}
-
-	if len(tableColumnsMap) > 0 {
-		builder.WriteString("/*\n")
-		builder.WriteString(fmt.Sprintf(" mysql column character set and collation modify, generate created sql\n"))
-
-		t := table.NewWriter()
-		t.SetStyle(table.StyleLight)
-		t.AppendHeader(table.Row{"TABLE", "COLUMN", "MYSQL", "SUGGEST"})
-
-		var sqlStrings []string
-		for mysqlColName, mysqlColInfo := range tableColumnsMap {
-			t.AppendRows([]table.Row{
-				{c.OracleTableINFO.TableName, mysqlColName,
-					fmt.Sprintf("%s(%s)", mysqlColInfo.DataType, mysqlColInfo.DataLength), "Create Table Column Character Collation"},
-			})
-
-			// GBK 处理，统一 UTF8MB4 处理
-			var mysqlCharacterSet string
-			if strings.ToUpper(common.OracleDBCharacterSetMap[c.OracleTableINFO.Columns[strings.ToUpper(mysqlColName)].CharacterSet]) == "GBK" {
-				mysqlCharacterSet = "UTF8MB4"
-				zap.L().Warn("check oracle table",
-					zap.String("schema", c.OracleTableINFO.SchemaName),
-					zap.String("table", c.OracleTableINFO.TableName),
-					zap.String("column", mysqlColName),
-					zap.String("characterSet", c.OracleTableINFO.Columns[strings.ToUpper(mysqlColName)].CharacterSet),
-					zap.String("msg", "GBK TO UTF8MB4"))
-			} else {
-				mysqlCharacterSet = common.OracleDBCharacterSetMap[c.OracleTableINFO.Columns[strings.ToUpper(mysqlColName)].CharacterSet]
-			}
-			sqlStrings = append(sqlStrings, fmt.Sprintf("ALTER TABLE %s.%s MODIFY %s %s(%s) CHARACTER SET %s COLLATE %s;",
-				c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, mysqlColName, mysqlColInfo.DataType, mysqlColInfo.DataLength,
-				strings.ToLower(mysqlCharacterSet),
-				strings.ToLower(common.OracleCollationMap[c.OracleTableINFO.Columns[strings.ToUpper(mysqlColName)].Collation])))
-		}
-
-		builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
-		builder.WriteString("*/\n")
-		builder.WriteString(strings.Join(sqlStrings, "\n") + "\n\n")
-	}
-
-	if len(delColumnsMap) > 0 {
-		builder.WriteString("/*\n")
-		builder.WriteString(fmt.Sprintf(" mysql column character set and collation drop [oracle column isn't exist], generate drop sql\n"))
-
-		t := table.NewWriter()
-		t.SetStyle(table.StyleLight)
-		t.AppendHeader(table.Row{"TABLE", "COLUMN", "MYSQL", "SUGGEST"})
-
-		var sqlStrings []string
-		for mysqlColName, mysqlColInfo := range delColumnsMap {
+		oracleColInfo, ok := c.OracleTableINFO.Columns[strings.ToUpper(mysqlColName)]
+		if !ok {
 			// TIMESTAMP/DATETIME 时间字段特殊处理
 			// 数据类型内自带精度
+			var mysqlColType string
 			if (strings.Contains(strings.ToUpper(mysqlColInfo.DataType), "TIMESTAMP")) || strings.Contains(strings.ToUpper(mysqlColInfo.DataType), "DATETIME") {
-				t.AppendRows([]table.Row{
-					{c.OracleTableINFO.TableName, mysqlColName,
-						fmt.Sprintf("%s(%s)", mysqlColInfo.DataType, mysqlColInfo.DatetimePrecision), "Drop MySQL Table Column"},
-				})
+				mysqlColType = fmt.Sprintf("%s(%s)", mysqlColInfo.DataType, mysqlColInfo.DatetimePrecision)
 			} else {
-				t.AppendRows([]table.Row{
-					{c.OracleTableINFO.TableName, mysqlColName,
-						fmt.Sprintf("%s(%s)", mysqlColInfo.DataType, mysqlColInfo.DataLength), "Drop MySQL Table Column"},
-				})
+				mysqlColType = fmt.Sprintf("%s(%s)", mysqlColInfo.DataType, mysqlColInfo.DataLength)
 			}
-
-			sqlStrings = append(sqlStrings, fmt.Sprintf("ALTER TABLE %s.%s DROP COLUMN %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, mysqlColName))
+			sql := c.addFixSQL(check.FixStageColumn,
+				fmt.Sprintf("ALTER TABLE %s.%s DROP COLUMN %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, mysqlColName))
+			diffs = append(diffs, c.newDifference(check.SectionColumnDrop, mysqlColName, check.AttributeColumn, check.SeverityWarn,
+				"", mysqlColType, "", "Drop MySQL Table Column", sql))
+			continue
+		}
+		if mysqlColInfo.CharacterSet == "UNKNOWN" && mysqlColInfo.Collation == "UNKNOWN" {
+			continue
 		}
 
-		builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
-		builder.WriteString("*/\n")
-		builder.WriteString(strings.Join(sqlStrings, "\n") + "\n\n")
+		// GBK 处理，统一视作 UTF8MB4 处理
+		var mysqlCharacterSet string
+		if strings.ToUpper(common.OracleDBCharacterSetMap[oracleColInfo.CharacterSet]) == "GBK" {
+			mysqlCharacterSet = common.MySQLCharacterSet
+			logger.L(c.Ctx).Warn("check oracle table",
+				logger.TableS(c.OracleTableINFO.TableName),
+				zap.String("column", mysqlColName),
+				zap.String("characterSet", oracleColInfo.CharacterSet),
+				zap.String("msg", "GBK TO UTF8MB4"))
+		} else {
+			mysqlCharacterSet = strings.ToUpper(common.OracleDBCharacterSetMap[oracleColInfo.CharacterSet])
+		}
+		mysqlCollation := strings.ToUpper(common.OracleCollationMap[oracleColInfo.Collation])
+
+		if mysqlColInfo.CharacterSet != mysqlCharacterSet || mysqlColInfo.Collation != mysqlCollation {
+			sql := c.addFixSQL(check.FixStageColumn,
+				fmt.Sprintf("ALTER TABLE %s.%s MODIFY %s %s(%s) CHARACTER SET %s COLLATE %s;",
+					c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, mysqlColName, mysqlColInfo.DataType, mysqlColInfo.DataLength,
+					strings.ToLower(mysqlCharacterSet),
+					strings.ToLower(mysqlCollation)))
+			diffs = append(diffs, c.newDifference(check.SectionColumnCharacterSet, mysqlColName, check.AttributeCharacterSet, check.SeverityWarn,
+				fmt.Sprintf("character set [%s] collation [%s]", oracleColInfo.CharacterSet, oracleColInfo.Collation),
+				fmt.Sprintf("character set [%s] collation [%s]", mysqlColInfo.CharacterSet, mysqlColInfo.Collation),
+				fmt.Sprintf("character set [%s] collation [%s]", mysqlCharacterSet, mysqlCollation),
+				"Create Table Column Character Collation", sql))
+		}
 	}
-	return builder.String()
+
+	return diffs
 }
 
-func (c *Check) CheckColumnCounts() (string, error) {
+func (c *Check) CheckColumnCounts() ([]check.Difference, error) {
 	// 上游表字段数检查
-	zap.L().Info("check table",
-		zap.String("oracle table column counts check", fmt.Sprintf("%s.%s", c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName)))
+	logger.L(c.Ctx).Info("check table oracle column counts",
+		logger.TableS(c.OracleTableINFO.TableName))
 
-	var builder strings.Builder
-
-	addColumnsMap := make(map[string]Column)
+	var diffs []check.Difference
 
 	for oracleColName, oracleColInfo := range c.OracleTableINFO.Columns {
-		if _, ok := c.MySQLTableINFO.Columns[strings.ToUpper(oracleColName)]; !ok {
-			addColumnsMap[oracleColName] = oracleColInfo
+		if _, ok := c.MySQLTableINFO.Columns[strings.ToUpper(oracleColName)]; ok {
+			continue
 		}
-	}
-	if len(addColumnsMap) > 0 {
-		builder.WriteString("/*\n")
-		builder.WriteString(fmt.Sprintf(" mysql column character set and collation add [mysql column isn't exist], generate add sql\n"))
-
-		t := table.NewWriter()
-		t.SetStyle(table.StyleLight)
-		t.AppendHeader(table.Row{"TABLE", "COLUMN", "ORACLE", "SUGGEST"})
-
-		var sqlStrings []string
-		for oracleColName, oracleColInfo := range addColumnsMap {
-			var (
-				columnMeta string
-				err        error
-			)
-			columnMeta, err = GenOracleTableColumnMeta(c.Ctx, c.MetaDB, c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName, oracleColName, oracleColInfo)
-			if err != nil {
-				return columnMeta, err
-			}
-			// TIMESTAMP 时间字段特殊处理
-			// 数据类型内自带精度
-			if strings.Contains(strings.ToUpper(oracleColInfo.DataType), "TIMESTAMP") {
-				t.AppendRows([]table.Row{
-					{c.OracleTableINFO.TableName, oracleColName,
-						fmt.Sprintf("%s", oracleColInfo.DataType), "Add MySQL Table Column"},
-				})
-			} else {
-				t.AppendRows([]table.Row{
-					{c.OracleTableINFO.TableName, oracleColName,
-						fmt.Sprintf("%s(%s)", oracleColInfo.DataType, oracleColInfo.DataLength), "Add MySQL Table Column"},
-				})
-			}
-			sqlStrings = append(sqlStrings, fmt.Sprintf("ALTER TABLE %s.%s ADD COLUMN %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, columnMeta))
+		columnMeta, err := GenOracleTableColumnMeta(c.Ctx, c.MetaDB, c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName, oracleColName, oracleColInfo)
+		if err != nil {
+			return diffs, err
 		}
-
-		builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
-		builder.WriteString("*/\n")
-		builder.WriteString(strings.Join(sqlStrings, "\n") + "\n\n")
+		// TIMESTAMP 时间字段特殊处理
+		// 数据类型内自带精度
+		var oracleColType string
+		if strings.Contains(strings.ToUpper(oracleColInfo.DataType), "TIMESTAMP") {
+			oracleColType = oracleColInfo.DataType
+		} else {
+			oracleColType = fmt.Sprintf("%s(%s)", oracleColInfo.DataType, oracleColInfo.DataLength)
+		}
+		sql := c.addFixSQL(check.FixStageColumn,
+			fmt.Sprintf("ALTER TABLE %s.%s ADD COLUMN %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, columnMeta))
+		diffs = append(diffs, c.newDifference(check.SectionColumnAdd, oracleColName, check.AttributeColumn, check.SeverityError,
+			oracleColType, "", columnMeta, "Add MySQL Table Column", sql))
 	}
 
-	return builder.String(), nil
+	return diffs, nil
 }
 
-func (c *Check) CheckPrimaryAndUniqueKey() (string, error) {
+func (c *Check) CheckPrimaryAndUniqueKey() ([]check.Difference, error) {
 	// 表主键/唯一约束检查
-	zap.L().Info("check table",
-		zap.String("table pk and uk constraint check", fmt.Sprintf("%s.%s", c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName)),
+	logger.L(c.Ctx).Info("check table pk and uk constraint",
+		logger.TableS(c.OracleTableINFO.TableName),
 		zap.String("oracle struct", c.OracleTableINFO.String(common.JSONPUConstraint)),
 		zap.String("mysql struct", c.MySQLTableINFO.String(common.JSONPUConstraint)))
 	// 函数 utils.DiffStructArray 都忽略 structA 空，但 structB 存在情况
 	addDiffPU, _, isOK := common.DiffStructArray(c.OracleTableINFO.PUConstraints, c.MySQLTableINFO.PUConstraints)
 
-	var builder strings.Builder
+	var diffs []check.Difference
 
 	if len(addDiffPU) != 0 && !isOK {
-		builder.WriteString("/*\n")
-		builder.WriteString(" oracle and mysql table primary key and unique key\n")
-
-		t := table.NewWriter()
-		t.SetStyle(table.StyleLight)
-		t.AppendHeader(table.Row{"TABLE", "PK AND UK", "SUGGEST"})
-		t.AppendRows([]table.Row{
-			{c.OracleTableINFO.TableName, "Oracle And Mysql Different", "Create Table Primary And Unique Key"},
-		})
-		builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
-
-		builder.WriteString("*/\n")
 		for _, pu := range addDiffPU {
 			value, ok := pu.(ConstraintPUKey)
-			if ok {
-				switch value.ConstraintType {
-				case "PK":
-					builder.WriteString(fmt.Sprintf("ALTER TABLE %s.%s ADD PRIMARY KEY(%s);\n", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.ConstraintColumn))
-					continue
-				case "UK":
-					builder.WriteString(fmt.Sprintf("ALTER TABLE %s.%s ADD UNIQUE(%s);\n", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.ConstraintColumn))
-					continue
-				default:
-					return builder.String(), fmt.Errorf("table constraint primary and unique key diff failed: not support type [%s]", value.ConstraintType)
+			if !ok {
+				return diffs, fmt.Errorf("oracle table [%s] constraint primary and unique key [%v] assert ConstraintPUKey failed, type: [%v]", c.OracleTableINFO.TableName, pu, reflect.TypeOf(pu))
+			}
+			switch value.ConstraintType {
+			case "PK":
+				// 下游已存在不同主键，需先删除原主键再新增
+				var (
+					fixSQL   []string
+					mysqlPKs []string
+				)
+				for _, mysqlPU := range c.MySQLTableINFO.PUConstraints {
+					if mysqlPU.ConstraintType == "PK" {
+						mysqlPKs = append(mysqlPKs, mysqlPU.ConstraintColumn)
+					}
+				}
+				if len(mysqlPKs) > 0 {
+					fixSQL = append(fixSQL, c.addFixSQL(check.FixStageDrop,
+						fmt.Sprintf("ALTER TABLE %s.%s DROP PRIMARY KEY;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName)))
 				}
+				fixSQL = append(fixSQL, c.addFixSQL(check.FixStageKey,
+					fmt.Sprintf("ALTER TABLE %s.%s ADD PRIMARY KEY(%s);", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.ConstraintColumn)))
+				diffs = append(diffs, c.newDifference(check.SectionPUKey, value.ConstraintType, check.AttributePUKey, check.SeverityError,
+					value.ConstraintColumn, strings.Join(mysqlPKs, ","), value.ConstraintColumn,
+					"Create Table Primary Key", fixSQL...))
+			case "UK":
+				sql := c.addFixSQL(check.FixStageKey,
+					fmt.Sprintf("ALTER TABLE %s.%s ADD UNIQUE(%s);", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.ConstraintColumn))
+				diffs = append(diffs, c.newDifference(check.SectionPUKey, value.ConstraintType, check.AttributePUKey, check.SeverityError,
+					value.ConstraintColumn, "", value.ConstraintColumn,
+					"Create Table Unique Key", sql))
+			default:
+				return diffs, fmt.Errorf("table constraint primary and unique key diff failed: not support type [%s]", value.ConstraintType)
 			}
-			return builder.String(), fmt.Errorf("oracle table [%s] constraint primary and unique key [%v] assert ConstraintPUKey failed, type: [%v]", c.OracleTableINFO.TableName, pu, reflect.TypeOf(pu))
 		}
 	}
-	return builder.String(), nil
+	return diffs, nil
 }
 
-func (c *Check) CheckForeignKey() (string, error) {
+func (c *Check) CheckForeignKey() ([]check.Difference, error) {
 	isTiDB := false
 	if strings.ToUpper(c.MySQLDBType) == common.TaskDBTiDB {
 		isTiDB = true
 	}
-	var builder strings.Builder
+	var diffs []check.Difference
 	// TiDB 版本排除外键以及检查约束检查
 	if !isTiDB {
-		zap.L().Info("check table",
-			zap.String("table fk constraint check", fmt.Sprintf("%s.%s", c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName)),
+		logger.L(c.Ctx).Info("check table fk constraint",
+			logger.TableS(c.OracleTableINFO.TableName),
 			zap.String("oracle struct", c.OracleTableINFO.String(common.JSONFKConstraint)),
 			zap.String("mysql struct", c.MySQLTableINFO.String(common.JSONFKConstraint)))
 
 		// 外键约束检查
 		addDiffFK, _, isOK := common.DiffStructArray(c.OracleTableINFO.ForeignConstraints, c.MySQLTableINFO.ForeignConstraints)
 		if len(addDiffFK) != 0 && !isOK {
-			builder.WriteString("/*\n")
-			builder.WriteString(" oracle and mysql table foreign key\n")
-
-			t := table.NewWriter()
-			t.SetStyle(table.StyleLight)
-			t.AppendHeader(table.Row{"TABLE", "FOREIGN KEY", "SUGGEST"})
-			t.AppendRows([]table.Row{
-				{c.OracleTableINFO.TableName, "Oracle And Mysql Different", "Create Table Foreign Key"},
-			})
-			builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
-
-			builder.WriteString("*/\n")
-
 			for _, fk := range addDiffFK {
 				value, ok := fk.(ConstraintForeign)
-				if ok {
-					builder.WriteString(fmt.Sprintf("ALTER TABLE %s.%s ADD FOREIGN KEY(%s) REFERENCES %s.%s(%s）ON DELETE %s;\n", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.ColumnName, c.MySQLTableINFO.SchemaName, value.ReferencedTableName, value.ReferencedColumnName, value.DeleteRule))
-					continue
+				if !ok {
+					return diffs, fmt.Errorf("oracle table [%s] constraint foreign key [%v] assert ConstraintForeign failed, type: [%v]", c.OracleTableINFO.TableName, fk, reflect.TypeOf(fk))
 				}
-				return builder.String(), fmt.Errorf("oracle table [%s] constraint foreign key [%v] assert ConstraintForeign failed, type: [%v]", c.OracleTableINFO.TableName, fk, reflect.TypeOf(fk))
+				reference := fmt.Sprintf("(%s) REFERENCES %s(%s) ON DELETE %s", value.ColumnName, value.ReferencedTableName, value.ReferencedColumnName, value.DeleteRule)
+				sql := c.addFixSQL(check.FixStageConstraint,
+					fmt.Sprintf("ALTER TABLE %s.%s ADD FOREIGN KEY(%s) REFERENCES %s.%s(%s) ON DELETE %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.ColumnName, c.MySQLTableINFO.SchemaName, value.ReferencedTableName, value.ReferencedColumnName, value.DeleteRule))
+				diffs = append(diffs, c.newDifference(check.SectionForeignKey, value.ColumnName, check.AttributeForeignKey, check.SeverityWarn,
+					reference, "", reference, "Create Table Foreign Key", sql))
 			}
 		}
 	}
-	return builder.String(), nil
+	return diffs, nil
 }
 
-func (c *Check) CheckCheckKey() (string, error) {
+func (c *Check) CheckCheckKey() ([]check.Difference, error) {
 	isTiDB := false
 	if strings.ToUpper(c.MySQLDBType) == common.TaskDBTiDB {
 		isTiDB = true
 	}
-	var builder strings.Builder
+	var diffs []check.Difference
 
 	// TiDB 版本排除外键以及检查约束检查
 	if !isTiDB {
@@ -419,197 +355,218 @@ func (c *Check) CheckCheckKey() (string, error) {
 			dbVersion = c.MySQLDBVersion
 		}
 		if common.VersionOrdinal(dbVersion) > common.VersionOrdinal(common.MySQLCheckConsVersion) {
-			zap.L().Info("check table",
-				zap.String("table ck constraint check", fmt.Sprintf("%s.%s", c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName)),
+			logger.L(c.Ctx).Info("check table ck constraint",
+				logger.TableS(c.OracleTableINFO.TableName),
 				zap.String("oracle struct", c.OracleTableINFO.String(common.JSONCKConstraint)),
 				zap.String("mysql struct", c.MySQLTableINFO.String(common.JSONCKConstraint)))
 			// 检查约束检查
 			addDiffCK, _, isOK := common.DiffStructArray(c.OracleTableINFO.CheckConstraints, c.MySQLTableINFO.CheckConstraints)
 			if len(addDiffCK) != 0 && !isOK {
-				builder.WriteString("/*\n")
-				builder.WriteString(" oracle and mysql table check key\n")
-
-				t := table.NewWriter()
-				t.SetStyle(table.StyleLight)
-				t.AppendHeader(table.Row{"TABLE", "CHECK KEY", "SUGGEST"})
-				t.AppendRows([]table.Row{
-					{c.OracleTableINFO.TableName, "Oracle And Mysql Different", "Create Table Check Key"},
-				})
-				builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
-
-				builder.WriteString("*/\n")
 				for _, ck := range addDiffCK {
 					value, ok := ck.(ConstraintCheck)
-					if ok {
-						builder.WriteString(fmt.Sprintf("ALTER TABLE %s.%s ADD CONSTRAINT %s CHECK(%s);\n", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, fmt.Sprintf("%s_check_key", c.MySQLTableINFO.TableName), value.ConstraintExpression))
-						continue
+					if !ok {
+						return diffs, fmt.Errorf("oracle table [%s] constraint check key [%v] assert ConstraintCheck failed, type: [%v]", c.OracleTableINFO.TableName, ck, reflect.TypeOf(ck))
 					}
-					return builder.String(), fmt.Errorf("oracle table [%s] constraint check key [%v] assert ConstraintCheck failed, type: [%v]", c.OracleTableINFO.TableName, ck, reflect.TypeOf(ck))
+					sql := c.addFixSQL(check.FixStageConstraint,
+						fmt.Sprintf("ALTER TABLE %s.%s ADD CONSTRAINT %s CHECK(%s);", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, fmt.Sprintf("%s_check_key", c.MySQLTableINFO.TableName), value.ConstraintExpression))
+					diffs = append(diffs, c.newDifference(check.SectionCheckKey, "CK", check.AttributeCheckKey, check.SeverityWarn,
+						value.ConstraintExpression, "", value.ConstraintExpression, "Create Table Check Key", sql))
 				}
 			}
 		}
 	}
-	return builder.String(), nil
+	return diffs, nil
 }
 
-func (c *Check) CheckIndex() (string, error) {
+func (c *Check) CheckIndex() ([]check.Difference, error) {
 	// 索引检查
-	zap.L().Info("check table",
-		zap.String("table indexes check", fmt.Sprintf("%s.%s", c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName)),
+	logger.L(c.Ctx).Info("check table indexes",
+		logger.TableS(c.OracleTableINFO.TableName),
 		zap.String("oracle struct", c.OracleTableINFO.String(common.JSONIndex)),
 		zap.String("mysql struct", c.MySQLTableINFO.String(common.JSONIndex)))
 
-	var builder strings.Builder
-	var createIndexSQL []string
+	var diffs []check.Difference
+
 	addDiffIndex, _, isOK := common.DiffStructArray(c.OracleTableINFO.Indexes, c.MySQLTableINFO.Indexes)
-	if len(addDiffIndex) != 0 && !isOK {
-		for _, idx := range addDiffIndex {
-			value, ok := idx.(Index)
-			if ok {
-				if value.Uniqueness == "UNIQUE" && value.IndexType == "NORMAL" {
-					// 考虑 MySQL 索引类型 BTREE，额外判断处理
-					var equalArray []interface{}
-					for _, mysqlIndexInfo := range c.MySQLTableINFO.Indexes {
-						if reflect.DeepEqual(value.IndexInfo, mysqlIndexInfo.IndexInfo) {
-							equalArray = append(equalArray, value.IndexInfo)
-						}
-					}
-					if len(equalArray) == 0 {
-						createIndexSQL = append(createIndexSQL, fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s.%s (%s);\n",
-							value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn))
-					}
-					continue
-				}
-				if value.Uniqueness == "UNIQUE" && value.IndexType == "FUNCTION-BASED NORMAL" {
-					// 考虑 MySQL 索引类型 BTREE，额外判断处理
-					var equalArray []interface{}
-					for _, mysqlIndexInfo := range c.MySQLTableINFO.Indexes {
-						if reflect.DeepEqual(value.IndexInfo, mysqlIndexInfo.IndexInfo) {
-							equalArray = append(equalArray, value.IndexInfo)
-						}
-					}
-					if len(equalArray) == 0 {
-						createIndexSQL = append(createIndexSQL, fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s.%s (%s);\n",
-							value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn))
-					}
-					continue
-				}
-				if value.Uniqueness == "NONUNIQUE" && value.IndexType == "NORMAL" {
-					// 考虑 MySQL 索引类型 BTREE，额外判断处理
-					var equalArray []interface{}
-					for _, mysqlIndexInfo := range c.MySQLTableINFO.Indexes {
-						if reflect.DeepEqual(value.IndexInfo, mysqlIndexInfo.IndexInfo) {
-							equalArray = append(equalArray, value.IndexInfo)
-						}
-					}
-					if len(equalArray) == 0 {
-						createIndexSQL = append(createIndexSQL, fmt.Sprintf("CREATE INDEX %s ON %s.%s (%s);\n",
-							value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn))
-					}
-					continue
-				}
-				if value.Uniqueness == "NONUNIQUE" && value.IndexType == "BITMAP" {
-					createIndexSQL = append(createIndexSQL, fmt.Sprintf("CREATE BITMAP INDEX %s ON %s.%s (%s);\n",
-						value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn))
-					continue
-				}
-				if value.Uniqueness == "NONUNIQUE" && value.IndexType == "FUNCTION-BASED NORMAL" {
-					createIndexSQL = append(createIndexSQL, fmt.Sprintf("CREATE INDEX %s ON %s.%s (%s);\n",
-						value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn))
-					continue
-				}
-				if value.Uniqueness == "NONUNIQUE" && value.IndexType == "FUNCTION-BASED BITMAP" {
-					createIndexSQL = append(createIndexSQL, fmt.Sprintf("CREATE BITMAP INDEX %s ON %s.%s (%s);\n",
-						value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn))
-					continue
-				}
-				if value.Uniqueness == "NONUNIQUE" && value.IndexType == "DOMAIN" {
-					createIndexSQL = append(createIndexSQL,
-						fmt.Sprintf("CREATE INDEX %s ON %s.%s (%s) INDEXTYPE IS %s.%s PARAMETERS ('%s');\n",
-							value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn,
-							value.DomainIndexOwner, value.DomainIndexName, value.DomainParameters))
-					continue
+	if len(addDiffIndex) == 0 || isOK {
+		return diffs, nil
+	}
+
+	for _, idx := range addDiffIndex {
+		value, ok := idx.(Index)
+		if !ok {
+			return diffs, fmt.Errorf("oracle table [%s] index [%v] assert Index failed, type: [%v]", c.OracleTableINFO.TableName, idx, reflect.TypeOf(idx))
+		}
+
+		var (
+			createIndexSQL string
+			// MySQL 不支持的索引类型，修复文件注释输出需人工处理
+			notSupport bool
+			// 考虑 MySQL 索引类型 BTREE，额外判断处理
+			checkEqual bool
+		)
+		switch {
+		case value.Uniqueness == "UNIQUE" && (value.IndexType == "NORMAL" || value.IndexType == "FUNCTION-BASED NORMAL"):
+			createIndexSQL = fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s.%s (%s);",
+				value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn)
+			checkEqual = true
+		case value.Uniqueness == "NONUNIQUE" && value.IndexType == "NORMAL":
+			createIndexSQL = fmt.Sprintf("CREATE INDEX %s ON %s.%s (%s);",
+				value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn)
+			checkEqual = true
+		case value.Uniqueness == "NONUNIQUE" && value.IndexType == "FUNCTION-BASED NORMAL":
+			createIndexSQL = fmt.Sprintf("CREATE INDEX %s ON %s.%s (%s);",
+				value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn)
+		case value.Uniqueness == "NONUNIQUE" && (value.IndexType == "BITMAP" || value.IndexType == "FUNCTION-BASED BITMAP"):
+			createIndexSQL = fmt.Sprintf("CREATE BITMAP INDEX %s ON %s.%s (%s);",
+				value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn)
+			notSupport = true
+		case value.Uniqueness == "NONUNIQUE" && value.IndexType == "DOMAIN":
+			createIndexSQL = fmt.Sprintf("CREATE INDEX %s ON %s.%s (%s) INDEXTYPE IS %s.%s PARAMETERS ('%s');",
+				value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn,
+				value.DomainIndexOwner, value.DomainIndexName, value.DomainParameters)
+			notSupport = true
+		default:
+			return diffs, fmt.Errorf("oracle table [%s] diff failed, not support index: [%v]", c.OracleTableINFO.TableName, value)
+		}
+
+		// 下游同名索引定义一致（包括函数索引表达式）跳过，避免无意义的删除重建
+		var (
+			mysqlIndexColumn string
+			sameNameIndex    *Index
+		)
+		for i, mysqlIndexInfo := range c.MySQLTableINFO.Indexes {
+			if strings.EqualFold(value.IndexName, mysqlIndexInfo.IndexName) {
+				sameNameIndex = &c.MySQLTableINFO.Indexes[i]
+				break
+			}
+		}
+		if sameNameIndex != nil && isIndexDefinitionEqual(value.IndexInfo, sameNameIndex.IndexInfo) {
+			continue
+		}
+		if sameNameIndex == nil && checkEqual {
+			isEqual := false
+			for _, mysqlIndexInfo := range c.MySQLTableINFO.Indexes {
+				if isIndexDefinitionEqual(value.IndexInfo, mysqlIndexInfo.IndexInfo) {
+					isEqual = true
 				}
-				return builder.String(), fmt.Errorf("oracle table [%s] diff failed, not support index: [%v]", c.OracleTableINFO.TableName, value)
 			}
-			return builder.String(), fmt.Errorf("oracle table [%s] index [%v] assert Index failed, type: [%v]", c.OracleTableINFO.TableName, idx, reflect.TypeOf(idx))
+			if isEqual {
+				continue
+			}
+		}
+
+		var fixSQL []string
+		if notSupport {
+			fixSQL = append(fixSQL, c.addFixSQL(check.FixStageIndex,
+				fmt.Sprintf("-- mysql not support, please manual modify: %s", createIndexSQL)))
+		} else {
+			// 下游同名索引定义不一致，先删除再重建
+			if sameNameIndex != nil {
+				mysqlIndexColumn = sameNameIndex.IndexColumn
+				fixSQL = append(fixSQL, c.addFixSQL(check.FixStageDrop,
+					fmt.Sprintf("DROP INDEX %s ON %s.%s;", sameNameIndex.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName)))
+			}
+			fixSQL = append(fixSQL, c.addFixSQL(check.FixStageIndex, createIndexSQL))
 		}
+
+		diffs = append(diffs, c.newDifference(check.SectionIndex, value.IndexName, check.AttributeIndex, check.SeverityWarn,
+			fmt.Sprintf("%s %s (%s)", value.Uniqueness, value.IndexType, value.IndexColumn),
+			mysqlIndexColumn,
+			fmt.Sprintf("%s (%s)", value.Uniqueness, value.IndexColumn),
+			"Create Table Index", fixSQL...))
 	}
 
-	if len(createIndexSQL) != 0 {
-		builder.WriteString("/*\n")
-		builder.WriteString(" oracle and mysql table indexes\n")
-
-		t := table.NewWriter()
-		t.SetStyle(table.StyleLight)
-		t.AppendHeader(table.Row{"TABLE", "INDEXES", "SUGGEST"})
-		t.AppendRows([]table.Row{
-			{c.OracleTableINFO.TableName, "Oracle And Mysql Different", "Create Table Index"},
-		})
-		builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
-
-		builder.WriteString("*/\n")
-		for _, indexSQL := range createIndexSQL {
-			builder.WriteString(indexSQL)
+	return diffs, nil
+}
+
+// 索引定义对比，忽略大小写、标识符引号以及空白，函数索引对比表达式
+func isIndexDefinitionEqual(oracleIndex, mysqlIndex IndexInfo) bool {
+	return strings.EqualFold(oracleIndex.Uniqueness, mysqlIndex.Uniqueness) &&
+		normalizeIndexColumn(oracleIndex.IndexColumn) == normalizeIndexColumn(mysqlIndex.IndexColumn)
+}
+
+func normalizeIndexColumn(indexColumn string) string {
+	var (
+		cols  []string
+		depth int
+		start int
+	)
+	expr := strings.NewReplacer("`", "", "\"", "", " ", "", "\t", "", "\n", "").Replace(strings.ToUpper(indexColumn))
+	// 仅按最外层逗号拆分索引字段，函数参数内逗号保留
+	for i, r := range expr {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				cols = append(cols, trimIndexParentheses(expr[start:i]))
+				start = i + 1
+			}
 		}
 	}
+	cols = append(cols, trimIndexParentheses(expr[start:]))
+	return strings.Join(cols, ",")
+}
 
-	return builder.String(), nil
+// 去除 MySQL 函数索引表达式最外层括号，例如 (UPPER(NAME)) -> UPPER(NAME)
+func trimIndexParentheses(col string) string {
+	for strings.HasPrefix(col, "(") && strings.HasSuffix(col, ")") {
+		depth := 0
+		for i, r := range col {
+			if r == '(' {
+				depth++
+			} else if r == ')' {
+				depth--
+			}
+			// 首个左括号在末尾前闭合，例如 (A)+(B)
+			if depth == 0 && i < len(col)-1 {
+				return col
+			}
+		}
+		col = col[1 : len(col)-1]
+	}
+	return col
 }
 
-func (c *Check) CheckPartitionTable() (string, error) {
+func (c *Check) CheckPartitionTable() ([]check.Difference, error) {
 	// 分区表检查
-	var builder strings.Builder
+	var diffs []check.Difference
 	if c.MySQLTableINFO.IsPartition && c.OracleTableINFO.IsPartition {
-		zap.L().Info("check table",
-			zap.String("table partition check", fmt.Sprintf("%s.%s", c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName)),
+		logger.L(c.Ctx).Info("check table partition",
+			logger.TableS(c.OracleTableINFO.TableName),
 			zap.String("oracle struct", c.OracleTableINFO.String(common.JSONPartition)),
 			zap.String("mysql struct", c.MySQLTableINFO.String(common.JSONPartition)))
 
 		addDiffParts, _, isOK := common.DiffStructArray(c.OracleTableINFO.Partitions, c.MySQLTableINFO.Partitions)
 		if len(addDiffParts) != 0 && !isOK {
-			builder.WriteString("/*\n")
-			builder.WriteString(" oracle and mysql table partitions\n")
-
-			t := table.NewWriter()
-			t.SetStyle(table.StyleLight)
-			t.AppendHeader(table.Row{"TABLE", "PARTITIONS", "SUGGEST"})
-			t.AppendRows([]table.Row{
-				{c.OracleTableINFO.TableName, "Oracle And Mysql Different", "Manual Create Partition Table"},
-			})
-			builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
-
-			builder.WriteString("*/\n")
-			builder.WriteString("-- oracle partition info exist, mysql partition isn't exist, please manual modify\n")
-
+			// oracle partition info exist, mysql partition isn't exist, please manual modify
 			for _, part := range addDiffParts {
 				value, ok := part.(Partition)
-				if ok {
-					partJSON, err := json.Marshal(value)
-					if err != nil {
-						return builder.String(), err
-					}
-					builder.WriteString(fmt.Sprintf("# oracle partition info: %s, ", partJSON))
-					continue
+				if !ok {
+					return diffs, fmt.Errorf("oracle table [%s] paritions [%v] assert Partition failed, type: [%v]", c.OracleTableINFO.TableName, part, reflect.TypeOf(part))
 				}
-				return builder.String(), fmt.Errorf("oracle table [%s] paritions [%v] assert Partition failed, type: [%v]", c.OracleTableINFO.TableName, part, reflect.TypeOf(part))
+				partJSON, err := json.Marshal(value)
+				if err != nil {
+					return diffs, err
+				}
+				diffs = append(diffs, c.newDifference(check.SectionPartition, value.PartitionKey, check.AttributePartition, check.SeverityWarn,
+					string(partJSON), c.MySQLTableINFO.String(common.JSONPartition), string(partJSON), "Manual Create Partition Table"))
 			}
 		}
 	}
-	return builder.String(), nil
+	return diffs, nil
 }
 
-func (c *Check) CheckColumn() (string, error) {
+func (c *Check) CheckColumn() ([]check.Difference, error) {
 	// 表字段检查
 	// 注释格式化
-	zap.L().Info("check table",
-		zap.String("table column info check", fmt.Sprintf("%s.%s", c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName)))
+	logger.L(c.Ctx).Info("check table column info",
+		logger.TableS(c.OracleTableINFO.TableName))
 
-	var (
-		diffColumnMsgs []string
-		tableRowArray  []table.Row
-		builder        strings.Builder
-	)
+	var diffs []check.Difference
 
 	for oracleColName, oracleColInfo := range c.OracleTableINFO.Columns {
 		mysqlColInfo, ok := c.MySQLTableINFO.Columns[oracleColName]
@@ -622,122 +579,77 @@ func (c *Check) CheckColumn() (string, error) {
 				oracleColInfo,
 				mysqlColInfo)
 			if err != nil {
-				return builder.String(), err
+				return diffs, err
 			}
-			if diffColumnMsg != "" && len(tableRows) != 0 {
-				diffColumnMsgs = append(diffColumnMsgs, diffColumnMsg)
-				tableRowArray = append(tableRowArray, tableRows)
+			// tableRows: 表名、字段名、上游字段定义、下游字段定义、期望字段定义
+			if diffColumnMsg != "" && len(tableRows) == 5 {
+				sql := c.addFixSQL(check.FixStageColumn, diffColumnMsg)
+				diffs = append(diffs, c.newDifference(check.SectionColumn, oracleColName, check.AttributeColumn, check.SeverityError,
+					fmt.Sprintf("%v", tableRows[2]), fmt.Sprintf("%v", tableRows[3]), fmt.Sprintf("%v", tableRows[4]),
+					"Modify MySQL Table Column", sql))
 			}
 			continue
 		}
 		// 如果源端字段不存在,则目标段字段忽略，功能与 OracleTableColumnMapRuleCheck 函数相同，对于源端存在目标端不存在的新增
 	}
 
-	if len(tableRowArray) != 0 && len(diffColumnMsgs) != 0 {
-		zap.L().Info("check table",
-			zap.String("table column info check, generate fixed sql", fmt.Sprintf("%s.%s", c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName)),
+	if len(diffs) != 0 {
+		logger.L(c.Ctx).Info("check table column info, generate fixed sql",
+			logger.TableS(c.OracleTableINFO.TableName),
 			zap.String("oracle struct", c.OracleTableINFO.String(common.JSONColumns)),
 			zap.String("mysql struct", c.MySQLTableINFO.String(common.JSONColumns)))
-
-		textTable := table.NewWriter()
-		textTable.SetStyle(table.StyleLight)
-		textTable.AppendHeader(table.Row{"Table", "Column", "ORACLE", "MySQL", "Suggest"})
-		textTable.AppendRows(tableRowArray)
-
-		builder.WriteString("/*\n")
-		builder.WriteString(fmt.Sprintf(" oracle table columns info is different from mysql\n"))
-		builder.WriteString(fmt.Sprintf("%s\n", textTable.Render()))
-		builder.WriteString("*/\n")
-		builder.WriteString(fmt.Sprintf("-- oracle table columns info is different from mysql, generate fixed sql\n"))
-		for _, diffColMsg := range diffColumnMsgs {
-			builder.WriteString(diffColMsg)
-		}
-		builder.WriteString("\n")
 	}
 
-	return builder.String(), nil
+	return diffs, nil
 }
 
 func (c *Check) Writer(f *check.File) error {
 	startTime := time.Now()
-	zap.L().Info("check table start",
-		zap.String("oracle table", fmt.Sprintf("%s.%s", c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName)),
-		zap.String("mysql table", fmt.Sprintf("%s.%s", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName)))
-
-	var builder strings.Builder
-
-	if !strings.EqualFold(c.CheckPartitionTableType(), "") {
-		builder.WriteString(c.CheckPartitionTableType())
-	}
-	if !strings.EqualFold(c.CheckTableComment(), "") {
-		builder.WriteString(c.CheckTableComment())
-	}
-	if !strings.EqualFold(c.CheckTableCharacterSetAndCollation(), "") {
-		builder.WriteString(c.CheckTableCharacterSetAndCollation())
-	}
-
-	counts, err := c.CheckColumnCounts()
-	if err != nil {
-		return err
-	}
-	if !strings.EqualFold(counts, "") {
-		builder.WriteString(counts)
-	}
-	key, err := c.CheckPrimaryAndUniqueKey()
-	if err != nil {
-		return err
-	}
-	if !strings.EqualFold(key, "") {
-		builder.WriteString(key)
-	}
-	foreignKey, err := c.CheckForeignKey()
-	if err != nil {
-		return err
-	}
-	if !strings.EqualFold(foreignKey, "") {
-		builder.WriteString(foreignKey)
-	}
-	checkKey, err := c.CheckCheckKey()
-	if err != nil {
-		return err
-	}
-	if !strings.EqualFold(checkKey, "") {
-		builder.WriteString(checkKey)
-	}
-	index, err := c.CheckIndex()
-	if err != nil {
-		return err
-	}
-	if !strings.EqualFold(index, "") {
-		builder.WriteString(index)
-	}
-
-	partitionTable, err := c.CheckPartitionTable()
-	if err != nil {
-		return err
-	}
-	if !strings.EqualFold(partitionTable, "") {
-		builder.WriteString(partitionTable)
+	logger.L(c.Ctx).Info("check table start",
+		logger.TableS(c.OracleTableINFO.TableName),
+		logger.SchemaT(c.MySQLTableINFO.SchemaName),
+		logger.TableT(c.MySQLTableINFO.TableName))
+
+	var diffs []check.Difference
+
+	diffs = append(diffs, c.CheckPartitionTableType()...)
+	diffs = append(diffs, c.CheckTableComment()...)
+	diffs = append(diffs, c.CheckTableCharacterSetAndCollation()...)
+
+	checkFuncs := []func() ([]check.Difference, error){
+		c.CheckColumnCounts,
+		c.CheckPrimaryAndUniqueKey,
+		c.CheckForeignKey,
+		c.CheckCheckKey,
+		c.CheckIndex,
+		c.CheckPartitionTable,
+		c.CheckColumn,
+	}
+	for _, fn := range checkFuncs {
+		d, err := fn()
+		if err != nil {
+			return err
+		}
+		diffs = append(diffs, d...)
 	}
 
-	column, err := c.CheckColumn()
-	if err != nil {
-		return err
-	}
-	if !strings.EqualFold(column, "") {
-		builder.WriteString(column)
-	}
 	// diff 记录不为空
-	if builder.String() != "" {
-		if _, err := f.CWriteString(builder.String()); err != nil {
+	if len(diffs) > 0 {
+		if _, err := f.CWriteString(check.GenTextReport(diffs, f.TextFormat)); err != nil {
 			return err
 		}
+		f.AddDifference(diffs...)
+	}
+	for stage, sqls := range c.FixSQL {
+		f.FWriteString(stage, sqls...)
 	}
 
 	endTime := time.Now()
-	zap.L().Info("check table finished",
-		zap.String("oracle table", fmt.Sprintf("%s.%s", c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName)),
-		zap.String("mysql table", fmt.Sprintf("%s.%s", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName)),
+	logger.L(c.Ctx).Info("check table finished",
+		logger.TableS(c.OracleTableINFO.TableName),
+		logger.SchemaT(c.MySQLTableINFO.SchemaName),
+		logger.TableT(c.MySQLTableINFO.TableName),
+		zap.Int("differences", len(diffs)),
 		zap.String("cost", endTime.Sub(startTime).String()))
 
 	return nil
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/reverse"
 	reverseO2M "github.com/wentaojin/transferdb/module/reverse/o2m"
 	"strings"
 )
@@ -181,7 +182,7 @@ func ChangeTableColumnType(ctx context.Context, metaDB *meta.Meta, sourceSchema,
 			DataDefault:       columnINFO.DataDefault,
 			Comment:           columnINFO.Comment,
 		},
-	}, buildinDatatypeNames)
+	}, buildinDatatypeNames, &reverse.MySQLDialect{})
 	if err != nil {
 		return columnType, err
 	}
@@ -412,7 +412,7 @@ func NewMySQLTableINFO(schemaName, tableName, targetDBType string, mysql *mysql.
 	}
 	mysqlTable.TableComment = strings.ToUpper(comment[0]["COMMENTS"])
 
-	columns, err := getMySQLTableColumn(schemaName, tableName, mysql)
+	columns, err := getMySQLTableColumn(schemaName, tableName, version, mysql)
 	if err != nil {
 		return mysqlTable, version, err
 	}
@@ -460,8 +460,8 @@ func NewMySQLTableINFO(schemaName, tableName, targetDBType string, mysql *mysql.
 	return mysqlTable, version, nil
 }
 
-func getMySQLTableColumn(schemaName, tableName string, mysql *mysql.MySQL) (map[string]Column, error) {
-	columnInfo, err := mysql.GetMySQLTableColumn(schemaName, tableName)
+func getMySQLTableColumn(schemaName, tableName, version string, mysql *mysql.MySQL) (map[string]Column, error) {
+	columnInfo, err := mysql.GetMySQLTableColumn(schemaName, tableName, version)
 	if err != nil {
 		return nil, err
 	}
@@ -520,10 +520,15 @@ func getMySQLTableIndex(schemaName, tableName string, mysql *mysql.MySQL) ([]Ind
 		return indexes, err
 	}
 	for _, indexCol := range indexInfo {
+		// MySQL 函数索引字段为空，使用索引表达式
+		indexColumn := indexCol["COLUMN_LIST"]
+		if indexColumn == "" {
+			indexColumn = indexCol["COLUMN_EXPRESSION"]
+		}
 		indexes = append(indexes, Index{
 			IndexInfo: IndexInfo{
 				Uniqueness:  strings.ToUpper(indexCol["UNIQUENESS"]),
-				IndexColumn: strings.ToUpper(indexCol["COLUMN_LIST"]),
+				IndexColumn: strings.ToUpper(indexColumn),
 			},
 			IndexName: strings.ToUpper(indexCol["INDEX_NAME"]),
 			IndexType: strings.ToUpper(indexCol["INDEX_TYPE"]),
@@ -0,0 +1,232 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2p
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/database/postgres"
+	"github.com/wentaojin/transferdb/logger"
+	"github.com/wentaojin/transferdb/module/check"
+	"github.com/wentaojin/transferdb/module/reverse"
+	reverseO2M "github.com/wentaojin/transferdb/module/reverse/o2m"
+	"github.com/wentaojin/transferdb/signal"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// O2P 以 reverseO2P 转换规则生成期望表结构，校验下游 PostgreSQL 表结构
+type O2P struct {
+	ctx      context.Context
+	cfg      *config.Config
+	oracle   *oracle.Oracle
+	postgres *postgres.Postgres
+	metaDB   *meta.Meta
+}
+
+func NewO2PCheck(ctx context.Context, cfg *config.Config, oracle *oracle.Oracle, postgres *postgres.Postgres, metaDB *meta.Meta) *O2P {
+	return &O2P{
+		ctx:      ctx,
+		cfg:      cfg,
+		oracle:   oracle,
+		postgres: postgres,
+		metaDB:   metaDB,
+	}
+}
+
+func (r *O2P) NewCheck() error {
+	startTime := time.Now()
+	logger.L(r.ctx).Info("check oracle and postgres table start",
+		logger.SchemaT(r.cfg.PostgresConfig.SchemaName))
+
+	dialect, err := reverse.NewDialect(common.TaskDBPostgres)
+	if err != nil {
+		return err
+	}
+
+	exporters, err := filterCFGTable(r.cfg, r.oracle)
+	if err != nil {
+		return err
+	}
+
+	// 判断 error_log_detail 是否存在错误记录，是否可进行 check
+	errTotals, err := meta.NewErrorLogDetailModel(r.metaDB).CountsErrorLogBySchema(r.ctx, &meta.ErrorLogDetail{
+		DBTypeS:     common.TaskDBOracle,
+		DBTypeT:     common.TaskDBPostgres,
+		SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
+		RunMode:     common.CheckO2PMode,
+	})
+	if errTotals > 0 || err != nil {
+		return fmt.Errorf("check schema [%s] mode [%s] table task failed: %v, table [error_log_detail] exist failed error, please clear and rerunning", strings.ToUpper(r.cfg.OracleConfig.SchemaName), common.CheckO2PMode, err)
+	}
+
+	// 获取表名自定义规则
+	tableNameRules, err := meta.NewTableNameRuleModel(r.metaDB).DetailTableNameRule(r.ctx, &meta.TableNameRule{
+		DBTypeS:     common.TaskDBOracle,
+		DBTypeT:     common.TaskDBPostgres,
+		SchemaNameS: r.cfg.OracleConfig.SchemaName,
+		SchemaNameT: r.cfg.PostgresConfig.SchemaName,
+	})
+	if err != nil {
+		return err
+	}
+	tableNameRuleMap := make(map[string]string)
+	for _, tr := range tableNameRules {
+		tableNameRuleMap[common.StringUPPER(tr.TableNameS)] = common.StringUPPER(tr.TableNameT)
+	}
+
+	// 判断下游数据库是否存在 oracle 表，PostgreSQL 表名小写
+	pgSchema := strings.ToLower(r.cfg.PostgresConfig.SchemaName)
+	pgTables, err := r.postgres.GetPostgresTable(pgSchema)
+	if err != nil {
+		return err
+	}
+	var targetTables []string
+	for _, t := range exporters {
+		if val, ok := tableNameRuleMap[common.StringUPPER(t)]; ok {
+			targetTables = append(targetTables, strings.ToLower(val))
+		} else {
+			targetTables = append(targetTables, strings.ToLower(t))
+		}
+	}
+	ok, noExistTables := common.IsSubsetString(pgTables, targetTables)
+	if !ok {
+		return fmt.Errorf("oracle tables %v isn't exist in the postgres schema [%v], please create", noExistTables, pgSchema)
+	}
+
+	nlsComp, err := r.oracle.GetOracleDBCharacterNLSCompCollation()
+	if err != nil {
+		return err
+	}
+	nlsSort, err := r.oracle.GetOracleDBCharacterNLSSortCollation()
+	if err != nil {
+		return err
+	}
+
+	// 复用 reverseO2P 表任务以及转换规则，保证期望结构与 reverse 输出一致
+	tables, err := reverseO2M.GenReverseTableTask(r.ctx, r.cfg, nil, r.oracle, dialect, tableNameRuleMap, nil, exporters, nlsSort, nlsComp)
+	if err != nil {
+		return err
+	}
+
+	pwdDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	checkFile := filepath.Join(pwdDir, fmt.Sprintf("check_o2p_%s.sql", r.cfg.OracleConfig.SchemaName))
+	fixFile := filepath.Join(pwdDir, fmt.Sprintf("fix_structure_o2p_%s.sql", r.cfg.OracleConfig.SchemaName))
+
+	f, err := check.NewWriter(checkFile, fixFile)
+	if err != nil {
+		return err
+	}
+	f.TextFormat = r.cfg.AppConfig.CheckTextFormat
+
+	g := &errgroup.Group{}
+	g.SetLimit(r.cfg.AppConfig.Threads)
+
+	// 收到优雅停止信号后不再调度新的表，进行中的表完成并写入文件后退出
+	for _, table := range tables {
+		if signal.IsStopping(r.ctx) {
+			break
+		}
+		t := table
+		g.Go(func() error {
+			rule, err := reverseO2M.IReader(r.ctx, r.metaDB, t, t)
+			if err != nil {
+				return err
+			}
+			oracleTableInfo, err := NewOracleTableINFO(rule)
+			if err != nil {
+				return err
+			}
+			postgresTableInfo, err := NewPostgresTableINFO(strings.ToLower(t.TargetSchemaName), strings.ToLower(t.TargetTableName), r.postgres)
+			if err != nil {
+				return err
+			}
+			return NewChecker(r.ctx, oracleTableInfo, postgresTableInfo, dialect).Writer(f)
+		})
+	}
+
+	if err = g.Wait(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if signal.IsStopping(r.ctx) {
+		return signal.ErrGracefulStop
+	}
+
+	// 结构化差异报告 JSON/HTML
+	report := check.NewReport(common.StringUPPER(r.cfg.OracleConfig.SchemaName), pgSchema, len(exporters), f.Differences())
+	jsonFile := filepath.Join(pwdDir, fmt.Sprintf("check_o2p_%s.json", r.cfg.OracleConfig.SchemaName))
+	htmlFile := filepath.Join(pwdDir, fmt.Sprintf("check_o2p_%s.html", r.cfg.OracleConfig.SchemaName))
+	if err = genCheckReport(report, jsonFile, htmlFile); err != nil {
+		return err
+	}
+
+	// 无结构差异不生成修复文件
+	if !f.HasFixSQL() {
+		fixFile = "none"
+	}
+	endTime := time.Now()
+	zap.L().Info("check", zap.String("output", checkFile), zap.String("fix structure", fixFile),
+		zap.String("json report", jsonFile), zap.String("html report", htmlFile),
+		zap.Int("diff errors", report.Errors),
+		zap.Int("diff warns", report.Warns),
+		zap.Int("diff infos", report.Infos))
+	zap.L().Info("check table oracle to postgres finished",
+		zap.Int("table totals", len(exporters)),
+		zap.Int("table diffs", report.DiffTables),
+		zap.String("cost", endTime.Sub(startTime).String()))
+
+	// 存在 ERROR 级别差异，非零状态退出，便于 CI 流水线判定
+	if report.Errors > 0 {
+		return fmt.Errorf("check schema [%s] table struct exist [%d] error level differences, please see report [%s]", common.StringUPPER(r.cfg.OracleConfig.SchemaName), report.Errors, htmlFile)
+	}
+	return nil
+}
+
+func genCheckReport(report *check.Report, jsonFile, htmlFile string) error {
+	jf, err := os.OpenFile(jsonFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer jf.Close()
+	if err = check.GenJSONReport(report, jf); err != nil {
+		return err
+	}
+
+	hf, err := os.OpenFile(htmlFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer hf.Close()
+	if err = check.GenHTMLReport(report, hf); err != nil {
+		return err
+	}
+	return nil
+}
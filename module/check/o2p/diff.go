@@ -0,0 +1,363 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2p
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/logger"
+	"github.com/wentaojin/transferdb/module/check"
+	"github.com/wentaojin/transferdb/module/reverse"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+type Check struct {
+	Ctx               context.Context
+	OracleTableINFO   *Table
+	PostgresTableINFO *Table
+	Dialect           reverse.Dialect
+
+	// 结构修复 SQL，按修复阶段分类，由 Writer 写入修复文件
+	FixSQL map[int][]string
+}
+
+func NewChecker(ctx context.Context, oracleTableInfo, postgresTableInfo *Table, dialect reverse.Dialect) *Check {
+	return &Check{
+		Ctx:               ctx,
+		OracleTableINFO:   oracleTableInfo,
+		PostgresTableINFO: postgresTableInfo,
+		Dialect:           dialect,
+		FixSQL:            make(map[int][]string),
+	}
+}
+
+func (c *Check) addFixSQL(stage int, sql string) string {
+	sql = strings.TrimSpace(sql)
+	c.FixSQL[stage] = append(c.FixSQL[stage], sql)
+	return sql
+}
+
+func (c *Check) newDifference(object, attribute, severity, sourceValue, targetValue, expectedValue, suggest string, fixSQL ...string) check.Difference {
+	return check.Difference{
+		SchemaName:    c.OracleTableINFO.SchemaName,
+		TableName:     c.OracleTableINFO.TableName,
+		Object:        object,
+		Attribute:     attribute,
+		SourceValue:   sourceValue,
+		TargetValue:   targetValue,
+		ExpectedValue: expectedValue,
+		Severity:      severity,
+		Suggest:       suggest,
+		FixSQL:        fixSQL,
+	}
+}
+
+func (c *Check) tableName() string {
+	return fmt.Sprintf("%s.%s", c.Dialect.QuoteIdentifier(c.PostgresTableINFO.SchemaName), c.Dialect.QuoteIdentifier(c.PostgresTableINFO.TableName))
+}
+
+// 表结构对比
+// 以上游 oracle 表按 reverseO2P 规则转换后的结构为基准，对比下游 PostgreSQL 表结构
+// 1、若上游存在，下游不存在，则输出记录，若上游不存在，下游存在，则默认不输出
+// 2、主键、唯一约束以及索引忽略名称，只对比下游是否存在同等字段
+// 3、字段默认值 PostgreSQL 存储为表达式（例如 'a'::character varying），不做对比
+
+func (c *Check) CheckTableComment() []check.Difference {
+	logger.L(c.Ctx).Info("check table comment",
+		logger.TableS(c.OracleTableINFO.TableName))
+
+	var diffs []check.Difference
+	if c.OracleTableINFO.TableComment != c.PostgresTableINFO.TableComment {
+		sql := c.addFixSQL(check.FixStageComment,
+			c.Dialect.GenTableComment(c.PostgresTableINFO.SchemaName, c.PostgresTableINFO.TableName, c.OracleTableINFO.TableComment))
+		diffs = append(diffs, c.newDifference(c.OracleTableINFO.TableName, check.AttributeComment, check.SeverityInfo,
+			c.OracleTableINFO.TableComment, c.PostgresTableINFO.TableComment, c.OracleTableINFO.TableComment,
+			"Create Table Comment", sql))
+	}
+	return diffs
+}
+
+func (c *Check) CheckColumn() ([]check.Difference, error) {
+	logger.L(c.Ctx).Info("check table column",
+		logger.TableS(c.OracleTableINFO.TableName),
+		zap.String("oracle struct", c.OracleTableINFO.String(common.JSONColumns)),
+		zap.String("postgres struct", c.PostgresTableINFO.String(common.JSONColumns)))
+
+	var diffs []check.Difference
+	for _, columnName := range c.OracleTableINFO.ColumnNames {
+		oraCol := c.OracleTableINFO.Columns[columnName]
+		nullable := "NULL"
+		if strings.EqualFold(oraCol.NULLABLE, "N") {
+			nullable = "NOT NULL"
+		}
+		expectedType := fmt.Sprintf("%s %s", oraCol.DataType, nullable)
+
+		pgCol, ok := c.PostgresTableINFO.Columns[columnName]
+		if !ok {
+			sql := c.addFixSQL(check.FixStageColumn, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", c.tableName(),
+				c.Dialect.GenColumnDefinition(columnName, oraCol.DataType, "", nullable, oraCol.DataDefault, "")))
+			diffs = append(diffs, c.newDifference(columnName, check.AttributeColumn, check.SeverityError,
+				expectedType, "", expectedType, "Add Missing Column", sql))
+			continue
+		}
+
+		pgNullable := "NULL"
+		if strings.EqualFold(pgCol.NULLABLE, "N") {
+			pgNullable = "NOT NULL"
+		}
+		targetType := fmt.Sprintf("%s %s", pgCol.DataType, pgNullable)
+
+		if !IsPostgresDatatypeEqual(oraCol.DataType, pgCol.DataType) {
+			sql := c.addFixSQL(check.FixStageColumn, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;",
+				c.tableName(), c.Dialect.QuoteIdentifier(columnName), oraCol.DataType))
+			diffs = append(diffs, c.newDifference(columnName, check.AttributeColumn, check.SeverityError,
+				expectedType, targetType, expectedType, "Modify Column Datatype", sql))
+		}
+		if nullable != pgNullable {
+			action := "SET NOT NULL"
+			if nullable == "NULL" {
+				action = "DROP NOT NULL"
+			}
+			sql := c.addFixSQL(check.FixStageColumn, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s;",
+				c.tableName(), c.Dialect.QuoteIdentifier(columnName), action))
+			diffs = append(diffs, c.newDifference(columnName, check.AttributeColumn, check.SeverityWarn,
+				expectedType, targetType, expectedType, "Modify Column Nullable", sql))
+		}
+		if oraCol.Comment != pgCol.Comment {
+			sql := c.addFixSQL(check.FixStageComment,
+				c.Dialect.GenColumnComment(c.PostgresTableINFO.SchemaName, c.PostgresTableINFO.TableName, columnName, oraCol.Comment))
+			diffs = append(diffs, c.newDifference(columnName, check.AttributeComment, check.SeverityInfo,
+				oraCol.Comment, pgCol.Comment, oraCol.Comment, "Modify Column Comment", sql))
+		}
+	}
+	return diffs, nil
+}
+
+func (c *Check) CheckPrimaryAndUniqueKey() ([]check.Difference, error) {
+	logger.L(c.Ctx).Info("check table pk and uk constraint",
+		logger.TableS(c.OracleTableINFO.TableName),
+		zap.String("oracle struct", c.OracleTableINFO.String(common.JSONPUConstraint)),
+		zap.String("postgres struct", c.PostgresTableINFO.String(common.JSONPUConstraint)))
+
+	var diffs []check.Difference
+	for _, oraPU := range c.OracleTableINFO.PUConstraints {
+		var exist bool
+		for _, pgPU := range c.PostgresTableINFO.PUConstraints {
+			if oraPU.ConstraintType == pgPU.ConstraintType && isColumnListEqual(oraPU.ConstraintColumn, pgPU.ConstraintColumn) {
+				exist = true
+				break
+			}
+		}
+		if exist {
+			continue
+		}
+		columns := strings.Split(oraPU.ConstraintColumn, ",")
+		switch oraPU.ConstraintType {
+		case "PK":
+			// 下游已存在不同主键，需先删除原主键再新增
+			var (
+				fixSQL []string
+				pgPKs  []string
+			)
+			for _, pgPU := range c.PostgresTableINFO.PUConstraints {
+				if pgPU.ConstraintType == "PK" {
+					pgPKs = append(pgPKs, pgPU.ConstraintColumn)
+					fixSQL = append(fixSQL, c.addFixSQL(check.FixStageDrop, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;",
+						c.tableName(), c.Dialect.QuoteIdentifier(pgPU.ConstraintName))))
+				}
+			}
+			fixSQL = append(fixSQL, c.addFixSQL(check.FixStageKey, fmt.Sprintf("ALTER TABLE %s ADD %s;",
+				c.tableName(), c.Dialect.GenPrimaryKey(columns))))
+			diffs = append(diffs, c.newDifference(oraPU.ConstraintType, check.AttributePUKey, check.SeverityError,
+				oraPU.ConstraintColumn, strings.Join(pgPKs, ","), oraPU.ConstraintColumn,
+				"Create Table Primary Key", fixSQL...))
+		case "UK":
+			sql := c.addFixSQL(check.FixStageKey, fmt.Sprintf("ALTER TABLE %s ADD %s;",
+				c.tableName(), c.Dialect.GenUniqueKey(oraPU.ConstraintName, columns)))
+			diffs = append(diffs, c.newDifference(oraPU.ConstraintType, check.AttributePUKey, check.SeverityError,
+				oraPU.ConstraintColumn, "", oraPU.ConstraintColumn,
+				"Create Table Unique Key", sql))
+		default:
+			return diffs, fmt.Errorf("table constraint primary and unique key diff failed: not support type [%s]", oraPU.ConstraintType)
+		}
+	}
+	return diffs, nil
+}
+
+func (c *Check) CheckIndex() ([]check.Difference, error) {
+	logger.L(c.Ctx).Info("check table indexes",
+		logger.TableS(c.OracleTableINFO.TableName),
+		zap.String("oracle struct", c.OracleTableINFO.String(common.JSONIndex)),
+		zap.String("postgres struct", c.PostgresTableINFO.String(common.JSONIndex)))
+
+	var diffs []check.Difference
+	for _, oraIdx := range c.OracleTableINFO.Indexes {
+		var (
+			exist       bool
+			sameNameIdx *Index
+		)
+		for i, pgIdx := range c.PostgresTableINFO.Indexes {
+			if oraIdx.Uniqueness == pgIdx.Uniqueness && isColumnListEqual(oraIdx.IndexColumn, pgIdx.IndexColumn) {
+				exist = true
+				break
+			}
+			if strings.EqualFold(oraIdx.IndexName, pgIdx.IndexName) {
+				sameNameIdx = &c.PostgresTableINFO.Indexes[i]
+			}
+		}
+		if exist {
+			continue
+		}
+
+		// 同名索引定义不一致，需先删除再重建
+		var (
+			fixSQL      []string
+			targetValue string
+		)
+		if sameNameIdx != nil {
+			targetValue = fmt.Sprintf("%s %s(%s)", sameNameIdx.IndexName, sameNameIdx.Uniqueness, sameNameIdx.IndexColumn)
+			fixSQL = append(fixSQL, c.addFixSQL(check.FixStageDrop, fmt.Sprintf("DROP INDEX %s.%s;",
+				c.Dialect.QuoteIdentifier(c.PostgresTableINFO.SchemaName), c.Dialect.QuoteIdentifier(sameNameIdx.IndexName))))
+		}
+		columns := strings.Split(oraIdx.IndexColumn, ",")
+		var sql string
+		if oraIdx.Uniqueness == "UNIQUE" {
+			sql = c.Dialect.GenUniqueIndex(c.PostgresTableINFO.SchemaName, c.PostgresTableINFO.TableName, oraIdx.IndexName, columns)
+		} else {
+			sql = c.Dialect.GenNormalIndex(c.PostgresTableINFO.SchemaName, c.PostgresTableINFO.TableName, oraIdx.IndexName, columns)
+		}
+		fixSQL = append(fixSQL, c.addFixSQL(check.FixStageIndex, sql))
+		expected := fmt.Sprintf("%s %s(%s)", oraIdx.IndexName, oraIdx.Uniqueness, oraIdx.IndexColumn)
+		diffs = append(diffs, c.newDifference(oraIdx.IndexName, check.AttributeIndex, check.SeverityWarn,
+			expected, targetValue, expected, "Create Index", fixSQL...))
+	}
+	return diffs, nil
+}
+
+func (c *Check) Writer(f *check.File) error {
+	startTime := time.Now()
+	logger.L(c.Ctx).Info("check table start",
+		logger.TableS(c.OracleTableINFO.TableName),
+		logger.SchemaT(c.PostgresTableINFO.SchemaName),
+		logger.TableT(c.PostgresTableINFO.TableName))
+
+	var diffs []check.Difference
+	diffs = append(diffs, c.CheckTableComment()...)
+
+	checkFuncs := []func() ([]check.Difference, error){
+		c.CheckPrimaryAndUniqueKey,
+		c.CheckIndex,
+		c.CheckColumn,
+	}
+	for _, fn := range checkFuncs {
+		d, err := fn()
+		if err != nil {
+			return err
+		}
+		diffs = append(diffs, d...)
+	}
+
+	if len(diffs) > 0 {
+		if _, err := f.CWriteString(check.GenTextReport(diffs, f.TextFormat)); err != nil {
+			return err
+		}
+		f.AddDifference(diffs...)
+	}
+	for stage, sqls := range c.FixSQL {
+		f.FWriteString(stage, sqls...)
+	}
+
+	endTime := time.Now()
+	logger.L(c.Ctx).Info("check table finished",
+		logger.TableS(c.OracleTableINFO.TableName),
+		logger.SchemaT(c.PostgresTableINFO.SchemaName),
+		logger.TableT(c.PostgresTableINFO.TableName),
+		zap.Int("differences", len(diffs)),
+		zap.String("cost", endTime.Sub(startTime).String()))
+	return nil
+}
+
+// 字段列表对比，忽略大小写、空白以及双引号
+func isColumnListEqual(a, b string) bool {
+	normalize := func(s string) string {
+		return strings.NewReplacer(" ", "", `"`, "").Replace(strings.ToLower(s))
+	}
+	return normalize(a) == normalize(b)
+}
+
+// PostgreSQL 数据类型同义名称
+var postgresDatatypeAlias = map[string]string{
+	"CHARACTER VARYING": "VARCHAR",
+	"CHARACTER":         "CHAR",
+	"BPCHAR":            "CHAR",
+	"DECIMAL":           "NUMERIC",
+	"INT":               "INTEGER",
+	"INT4":              "INTEGER",
+	"INT2":              "SMALLINT",
+	"INT8":              "BIGINT",
+	"FLOAT8":            "DOUBLE PRECISION",
+	"FLOAT4":            "REAL",
+	"BOOL":              "BOOLEAN",
+}
+
+// NormalizePostgresDatatype 数据类型统一格式，期望类型（VARCHAR(10)、TIMESTAMP(0)）与 format_type 返回值（character varying(10)、timestamp(0) without time zone）可比较
+// 时间类型默认精度 6、CHAR 默认长度 1、NUMERIC(p) 等同 NUMERIC(p,0)
+func NormalizePostgresDatatype(datatype string) string {
+	dt := strings.Join(strings.Fields(common.StringUPPER(datatype)), " ")
+	dt = strings.ReplaceAll(dt, " (", "(")
+	withTimeZone := strings.Contains(dt, " WITH TIME ZONE")
+	dt = strings.ReplaceAll(dt, " WITHOUT TIME ZONE", "")
+	dt = strings.ReplaceAll(dt, " WITH TIME ZONE", "")
+
+	name, modifier := dt, ""
+	if idx := strings.Index(dt, "("); idx > 0 {
+		name, modifier = dt[:idx], strings.ReplaceAll(dt[idx:], " ", "")
+	}
+	if val, ok := postgresDatatypeAlias[name]; ok {
+		name = val
+	}
+	if withTimeZone {
+		switch name {
+		case "TIMESTAMP":
+			name = "TIMESTAMPTZ"
+		case "TIME":
+			name = "TIMETZ"
+		}
+	}
+
+	switch {
+	case name == "TIMESTAMP" || name == "TIMESTAMPTZ" || name == "TIME" || name == "TIMETZ" || strings.HasPrefix(name, "INTERVAL"):
+		if modifier == "(6)" {
+			modifier = ""
+		}
+	case name == "CHAR":
+		if modifier == "(1)" {
+			modifier = ""
+		}
+	case name == "NUMERIC":
+		if modifier != "" && !strings.Contains(modifier, ",") {
+			modifier = strings.TrimSuffix(modifier, ")") + ",0)"
+		}
+	}
+	return name + modifier
+}
+
+func IsPostgresDatatypeEqual(expected, actual string) bool {
+	return NormalizePostgresDatatype(expected) == NormalizePostgresDatatype(actual)
+}
@@ -0,0 +1,205 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2p
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/postgres"
+	"github.com/wentaojin/transferdb/logger"
+	reverseO2M "github.com/wentaojin/transferdb/module/reverse/o2m"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// Table 上游 oracle 表按 reverseO2P 规则转换后的期望结构，或者下游 PostgreSQL 实际表结构
+// PostgreSQL 未引用标识符折叠小写，字段、约束以及索引字段统一小写对比
+type Table struct {
+	SchemaName    string
+	TableName     string
+	TableComment  string
+	ColumnNames   []string          // 字段顺序
+	Columns       map[string]Column // KEY 字段名
+	PUConstraints []ConstraintPUKey
+	Indexes       []Index
+}
+
+type Column struct {
+	DataType    string
+	NULLABLE    string
+	DataDefault string
+	Comment     string
+}
+
+type ConstraintPUKey struct {
+	ConstraintName   string
+	ConstraintType   string
+	ConstraintColumn string
+}
+
+type Index struct {
+	IndexName   string
+	Uniqueness  string
+	IndexColumn string
+}
+
+// 上游 oracle 表结构，字段类型、默认值按内置以及自定义规则转换，函数、位图以及 DOMAIN 索引 reverse 输出兼容性文件，不做对比
+func NewOracleTableINFO(rule *reverseO2M.Rule) (*Table, error) {
+	t := &Table{
+		SchemaName: rule.SourceSchema,
+		TableName:  rule.SourceTableName,
+		Columns:    make(map[string]Column),
+	}
+	if len(rule.TableCommentINFO) > 0 {
+		t.TableComment = rule.TableCommentINFO[0]["COMMENTS"]
+	}
+
+	for _, rowCol := range rule.TableColumnINFO {
+		columnType, err := rule.ChangeTableColumnType(rule.SourceSchema, rule.SourceTableName, rowCol["COLUMN_NAME"], reverseO2M.Column{
+			DataType: rowCol["DATA_TYPE"],
+			ColumnInfo: reverseO2M.ColumnInfo{
+				DataLength:    rowCol["DATA_LENGTH"],
+				DataPrecision: rowCol["DATA_PRECISION"],
+				DataScale:     rowCol["DATA_SCALE"],
+				NULLABLE:      rowCol["NULLABLE"],
+				DataDefault:   rowCol["DATA_DEFAULT"],
+				Comment:       rowCol["COMMENTS"],
+			},
+		})
+		if err != nil {
+			return t, err
+		}
+		var dataDefault string
+		if !strings.EqualFold(rowCol["DATA_DEFAULT"], "") {
+			dataDefault, err = rule.ChangeTableColumnDefaultValue(rowCol["DATA_DEFAULT"])
+			if err != nil {
+				return t, err
+			}
+		}
+		columnName := strings.ToLower(rowCol["COLUMN_NAME"])
+		t.ColumnNames = append(t.ColumnNames, columnName)
+		t.Columns[columnName] = Column{
+			DataType:    columnType,
+			NULLABLE:    rowCol["NULLABLE"],
+			DataDefault: dataDefault,
+			Comment:     rowCol["COMMENTS"],
+		}
+	}
+
+	if len(rule.PrimaryKeyINFO) > 1 {
+		return t, fmt.Errorf("oracle schema [%s] table [%s] primary key exist multiple values: [%v]", rule.SourceSchema, rule.SourceTableName, rule.PrimaryKeyINFO)
+	}
+	for _, pk := range rule.PrimaryKeyINFO {
+		t.PUConstraints = append(t.PUConstraints, ConstraintPUKey{
+			ConstraintName:   strings.ToLower(pk["CONSTRAINT_NAME"]),
+			ConstraintType:   "PK",
+			ConstraintColumn: strings.ToLower(pk["COLUMN_LIST"]),
+		})
+	}
+	for _, uk := range rule.UniqueKeyINFO {
+		t.PUConstraints = append(t.PUConstraints, ConstraintPUKey{
+			ConstraintName:   strings.ToLower(uk["CONSTRAINT_NAME"]),
+			ConstraintType:   "UK",
+			ConstraintColumn: strings.ToLower(uk["COLUMN_LIST"]),
+		})
+	}
+
+	for _, idx := range append(append([]map[string]string{}, rule.UniqueIndexINFO...), rule.NormalIndexINFO...) {
+		if idx["INDEX_TYPE"] != "NORMAL" {
+			zap.L().Warn("check oracle table index skip",
+				logger.SchemaS(rule.SourceSchema),
+				logger.TableS(rule.SourceTableName),
+				zap.String("index name", idx["INDEX_NAME"]),
+				zap.String("index type", idx["INDEX_TYPE"]),
+				zap.String("suggest", "index would be written to compatibility file by reverseO2P, please manual check"))
+			continue
+		}
+		t.Indexes = append(t.Indexes, Index{
+			IndexName:   strings.ToLower(idx["INDEX_NAME"]),
+			Uniqueness:  common.StringUPPER(idx["UNIQUENESS"]),
+			IndexColumn: strings.ToLower(idx["COLUMN_LIST"]),
+		})
+	}
+	return t, nil
+}
+
+// 下游 PostgreSQL 表结构，schemaName、tableName 为 PostgreSQL 实际名称
+func NewPostgresTableINFO(schemaName, tableName string, pg *postgres.Postgres) (*Table, error) {
+	t := &Table{
+		SchemaName: schemaName,
+		TableName:  tableName,
+		Columns:    make(map[string]Column),
+	}
+
+	comment, err := pg.GetPostgresTableComment(schemaName, tableName)
+	if err != nil {
+		return t, err
+	}
+	t.TableComment = comment
+
+	columns, err := pg.GetPostgresTableColumn(schemaName, tableName)
+	if err != nil {
+		return t, err
+	}
+	for _, rowCol := range columns {
+		t.ColumnNames = append(t.ColumnNames, rowCol["COLUMN_NAME"])
+		t.Columns[rowCol["COLUMN_NAME"]] = Column{
+			DataType:    rowCol["DATA_TYPE"],
+			NULLABLE:    rowCol["NULLABLE"],
+			DataDefault: rowCol["DATA_DEFAULT"],
+			Comment:     rowCol["COMMENTS"],
+		}
+	}
+
+	puKeys, err := pg.GetPostgresTablePrimaryAndUniqueKey(schemaName, tableName)
+	if err != nil {
+		return t, err
+	}
+	for _, pu := range puKeys {
+		t.PUConstraints = append(t.PUConstraints, ConstraintPUKey{
+			ConstraintName:   pu["CONSTRAINT_NAME"],
+			ConstraintType:   pu["CONSTRAINT_TYPE"],
+			ConstraintColumn: pu["COLUMN_LIST"],
+		})
+	}
+
+	indexes, err := pg.GetPostgresTableIndex(schemaName, tableName)
+	if err != nil {
+		return t, err
+	}
+	for _, idx := range indexes {
+		t.Indexes = append(t.Indexes, Index{
+			IndexName:   idx["INDEX_NAME"],
+			Uniqueness:  idx["UNIQUENESS"],
+			IndexColumn: idx["COLUMN_LIST"],
+		})
+	}
+	return t, nil
+}
+
+func (t *Table) String(jsonType string) string {
+	var jsonStr []byte
+	switch jsonType {
+	case common.JSONColumns:
+		jsonStr, _ = json.Marshal(t.Columns)
+	case common.JSONPUConstraint:
+		jsonStr, _ = json.Marshal(t.PUConstraints)
+	case common.JSONIndex:
+		jsonStr, _ = json.Marshal(t.Indexes)
+	}
+	return string(jsonStr)
+}
@@ -0,0 +1,369 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package check
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/wentaojin/transferdb/common"
+	"html/template"
+	"os"
+	"sort"
+	"strings"
+)
+
+//go:embed template
+var fs embed.FS
+
+// 差异级别
+// ERROR 上下游结构不一致，影响数据迁移正确性，check 以非零状态退出
+// WARN  上下游结构不一致，不影响数据迁移，建议修复
+// INFO  仅提示，例如注释差异
+const (
+	SeverityError = "ERROR"
+	SeverityWarn  = "WARN"
+	SeverityInfo  = "INFO"
+)
+
+// 差异对象属性
+const (
+	AttributePartitionType = "PARTITION TYPE"
+	AttributeComment       = "COMMENT"
+	AttributeCharacterSet  = "CHARACTER SET AND COLLATION"
+	AttributeColumn        = "COLUMN"
+	AttributePUKey         = "PRIMARY AND UNIQUE KEY"
+	AttributeForeignKey    = "FOREIGN KEY"
+	AttributeCheckKey      = "CHECK KEY"
+	AttributeIndex         = "INDEX"
+	AttributePartition     = "PARTITION"
+)
+
+// check 文件差异分段，SECTION 格式按分段顺序输出，与原 check 文件格式保持一致
+// SectionNone 未归属原分段，按差异属性输出
+const (
+	SectionNone = iota
+	SectionPartitionType
+	SectionComment
+	SectionTableCharacterSet
+	SectionColumnCharacterSet
+	SectionColumnDrop
+	SectionColumnAdd
+	SectionPUKey
+	SectionForeignKey
+	SectionCheckKey
+	SectionIndex
+	SectionPartition
+	SectionColumn
+)
+
+// Difference 表结构差异记录
+// SourceValue 上游 oracle 值，TargetValue 下游 mysql 值，ExpectedValue 按内置/自定义规则期望的下游值
+type Difference struct {
+	SchemaName    string   `json:"schema_name"`
+	TableName     string   `json:"table_name"`
+	Object        string   `json:"object"`
+	Attribute     string   `json:"attribute"`
+	SourceValue   string   `json:"source_value"`
+	TargetValue   string   `json:"target_value"`
+	ExpectedValue string   `json:"expected_value"`
+	Severity      string   `json:"severity"`
+	Suggest       string   `json:"suggest"`
+	FixSQL        []string `json:"fix_sql"`
+	Section       int      `json:"-"`
+}
+
+type ReportSummary struct {
+	SchemaNameS string `json:"schema_name_s"`
+	SchemaNameT string `json:"schema_name_t"`
+	TableTotals int    `json:"table_totals"`
+	DiffTables  int    `json:"diff_tables"`
+	Errors      int    `json:"errors"`
+	Warns       int    `json:"warns"`
+	Infos       int    `json:"infos"`
+}
+
+type Report struct {
+	*ReportSummary
+	Differences []Difference `json:"differences"`
+}
+
+func NewReport(schemaNameS, schemaNameT string, tableTotals int, diffs []Difference) *Report {
+	// 表间并发检查，排序保证输出稳定
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if diffs[i].TableName != diffs[j].TableName {
+			return diffs[i].TableName < diffs[j].TableName
+		}
+		return diffs[i].Attribute < diffs[j].Attribute
+	})
+
+	summary := &ReportSummary{
+		SchemaNameS: schemaNameS,
+		SchemaNameT: schemaNameT,
+		TableTotals: tableTotals,
+	}
+	diffTables := make(map[string]struct{})
+	for _, d := range diffs {
+		diffTables[d.TableName] = struct{}{}
+		switch d.Severity {
+		case SeverityError:
+			summary.Errors++
+		case SeverityWarn:
+			summary.Warns++
+		default:
+			summary.Infos++
+		}
+	}
+	summary.DiffTables = len(diffTables)
+
+	return &Report{
+		ReportSummary: summary,
+		Differences:   diffs,
+	}
+}
+
+// GenTextReport 生成 check 文件差异说明以及修复 SQL：注释块内表格说明差异，注释块外输出修复 SQL
+// SECTION 格式（默认）按原 check 文件分段输出，TABLE 格式按差异属性统一输出差异级别以及期望值
+func GenTextReport(diffs []Difference, format string) string {
+	if strings.EqualFold(format, common.CheckTextFormatTable) {
+		return genTableText(diffs)
+	}
+	return genSectionText(diffs)
+}
+
+func genSectionText(diffs []Difference) string {
+	var (
+		builder strings.Builder
+		tables  []string
+		others  []Difference
+	)
+	sections := make(map[string]map[int][]Difference)
+	for _, d := range diffs {
+		if d.Section == SectionNone {
+			others = append(others, d)
+			continue
+		}
+		if _, ok := sections[d.TableName]; !ok {
+			tables = append(tables, d.TableName)
+			sections[d.TableName] = make(map[int][]Difference)
+		}
+		sections[d.TableName][d.Section] = append(sections[d.TableName][d.Section], d)
+	}
+
+	for _, t := range tables {
+		for section := SectionPartitionType; section <= SectionColumn; section++ {
+			if ds, ok := sections[t][section]; ok {
+				builder.WriteString(genSection(section, ds))
+			}
+		}
+	}
+	// 未归属原分段的差异按差异属性输出
+	builder.WriteString(genTableText(others))
+	return builder.String()
+}
+
+func genSection(section int, diffs []Difference) string {
+	var (
+		builder strings.Builder
+		title   string
+		header  table.Row
+		rows    []table.Row
+	)
+	tableName := diffs[0].TableName
+
+	switch section {
+	case SectionPartitionType:
+		title = " oracle table type is different from mysql table type"
+		header = table.Row{"TABLE", "PARTITION", "ORACLE", "MYSQL", "SUGGEST"}
+		for _, d := range diffs {
+			rows = append(rows, table.Row{d.TableName, "PARTITION", d.SourceValue, d.TargetValue, d.Suggest})
+		}
+	case SectionComment:
+		title = " oracle and mysql table comment"
+		header = table.Row{"TABLE", "COMMENT", "ORACLE", "MYSQL", "SUGGEST"}
+		for _, d := range diffs {
+			rows = append(rows, table.Row{d.TableName, "COMMENT", d.SourceValue, d.TargetValue, d.Suggest})
+		}
+	case SectionTableCharacterSet:
+		title = " oracle and mysql table character set and collation"
+		header = table.Row{"TABLE", "CHARACTER AND COLLATION", "ORACLE", "MYSQL", "SUGGEST"}
+		for _, d := range diffs {
+			rows = append(rows, table.Row{d.TableName, "CHARACTER AND COLLATION", d.SourceValue, d.TargetValue, d.Suggest})
+		}
+	case SectionColumnCharacterSet:
+		title = " mysql column character set and collation modify, generate created sql"
+		header = table.Row{"TABLE", "COLUMN", "MYSQL", "SUGGEST"}
+		for _, d := range diffs {
+			rows = append(rows, table.Row{d.TableName, d.Object, d.TargetValue, d.Suggest})
+		}
+	case SectionColumnDrop:
+		title = " mysql column character set and collation drop [oracle column isn't exist], generate drop sql"
+		header = table.Row{"TABLE", "COLUMN", "MYSQL", "SUGGEST"}
+		for _, d := range diffs {
+			rows = append(rows, table.Row{d.TableName, d.Object, d.TargetValue, d.Suggest})
+		}
+	case SectionColumnAdd:
+		title = " mysql column character set and collation add [mysql column isn't exist], generate add sql"
+		header = table.Row{"TABLE", "COLUMN", "ORACLE", "SUGGEST"}
+		for _, d := range diffs {
+			rows = append(rows, table.Row{d.TableName, d.Object, d.SourceValue, d.Suggest})
+		}
+	case SectionPUKey:
+		title = " oracle and mysql table primary key and unique key"
+		header = table.Row{"TABLE", "PK AND UK", "SUGGEST"}
+		rows = append(rows, table.Row{tableName, "Oracle And Mysql Different", "Create Table Primary And Unique Key"})
+	case SectionForeignKey:
+		title = " oracle and mysql table foreign key"
+		header = table.Row{"TABLE", "FOREIGN KEY", "SUGGEST"}
+		rows = append(rows, table.Row{tableName, "Oracle And Mysql Different", "Create Table Foreign Key"})
+	case SectionCheckKey:
+		title = " oracle and mysql table check key"
+		header = table.Row{"TABLE", "CHECK KEY", "SUGGEST"}
+		rows = append(rows, table.Row{tableName, "Oracle And Mysql Different", "Create Table Check Key"})
+	case SectionIndex:
+		title = " oracle and mysql table indexes"
+		header = table.Row{"TABLE", "INDEXES", "SUGGEST"}
+		rows = append(rows, table.Row{tableName, "Oracle And Mysql Different", "Create Table Index"})
+	case SectionPartition:
+		title = " oracle and mysql table partitions"
+		header = table.Row{"TABLE", "PARTITIONS", "SUGGEST"}
+		rows = append(rows, table.Row{tableName, "Oracle And Mysql Different", "Manual Create Partition Table"})
+	case SectionColumn:
+		title = " oracle table columns info is different from mysql"
+		header = table.Row{"Table", "Column", "ORACLE", "MySQL", "Suggest"}
+		for _, d := range diffs {
+			rows = append(rows, table.Row{d.TableName, common.StringsBuilder("`", d.Object, "`"), d.SourceValue, d.TargetValue, d.ExpectedValue})
+		}
+	default:
+		return genTableText(diffs)
+	}
+
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.AppendHeader(header)
+	t.AppendRows(rows)
+
+	builder.WriteString("/*\n")
+	builder.WriteString(title + "\n")
+	builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
+	builder.WriteString("*/\n")
+
+	var sqlStrings []string
+	for _, d := range diffs {
+		sqlStrings = append(sqlStrings, d.FixSQL...)
+	}
+	switch section {
+	case SectionTableCharacterSet, SectionColumnCharacterSet, SectionColumnDrop, SectionColumnAdd:
+		if len(sqlStrings) > 0 {
+			builder.WriteString(strings.Join(sqlStrings, "\n") + "\n\n")
+		}
+	case SectionPartition:
+		builder.WriteString("-- oracle partition info exist, mysql partition isn't exist, please manual modify\n")
+		for _, d := range diffs {
+			builder.WriteString(fmt.Sprintf("# oracle partition info: %s, ", d.SourceValue))
+		}
+		builder.WriteString("\n")
+	case SectionColumn:
+		builder.WriteString("-- oracle table columns info is different from mysql, generate fixed sql\n")
+		for _, sql := range sqlStrings {
+			builder.WriteString(sql + "\n")
+		}
+		builder.WriteString("\n")
+	default:
+		for _, sql := range sqlStrings {
+			builder.WriteString(sql + "\n")
+		}
+	}
+	return builder.String()
+}
+
+// genTableText 按差异属性分段，统一表格输出差异对象、级别、上下游值以及期望值
+func genTableText(diffs []Difference) string {
+	var (
+		builder    strings.Builder
+		attributes []string
+	)
+	groups := make(map[string][]Difference)
+	for _, d := range diffs {
+		if _, ok := groups[d.Attribute]; !ok {
+			attributes = append(attributes, d.Attribute)
+		}
+		groups[d.Attribute] = append(groups[d.Attribute], d)
+	}
+
+	for _, attr := range attributes {
+		builder.WriteString("/*\n")
+		builder.WriteString(fmt.Sprintf(" oracle and mysql table %s different\n", strings.ToLower(attr)))
+
+		t := table.NewWriter()
+		t.SetStyle(table.StyleLight)
+		t.AppendHeader(table.Row{"TABLE", "OBJECT", "SEVERITY", "ORACLE", "MYSQL", "EXPECTED", "SUGGEST"})
+
+		var sqlStrings []string
+		for _, d := range groups[attr] {
+			t.AppendRows([]table.Row{
+				{d.TableName, d.Object, d.Severity, d.SourceValue, d.TargetValue, d.ExpectedValue, d.Suggest},
+			})
+			sqlStrings = append(sqlStrings, d.FixSQL...)
+		}
+		builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
+		builder.WriteString("*/\n")
+		if len(sqlStrings) > 0 {
+			builder.WriteString(strings.Join(sqlStrings, "\n") + "\n\n")
+		}
+	}
+	return builder.String()
+}
+
+func GenJSONReport(report *Report, file *os.File) error {
+	enc := json.NewEncoder(file)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("json encode check report failed: %v", err)
+	}
+	return nil
+}
+
+func GenHTMLReport(report *Report, file *os.File) error {
+	tf, err := template.ParseFS(fs, "template/*.html")
+	if err != nil {
+		return fmt.Errorf("template parse FS failed: %v", err)
+	}
+
+	if err = tf.ExecuteTemplate(file, "report_header", nil); err != nil {
+		return fmt.Errorf("template FS Execute [report_header] template HTML failed: %v", err)
+	}
+
+	if err = tf.ExecuteTemplate(file, "report_body", nil); err != nil {
+		return fmt.Errorf("template FS Execute [report_body] template HTML failed: %v", err)
+	}
+
+	if err = tf.ExecuteTemplate(file, "report_summary", report.ReportSummary); err != nil {
+		return fmt.Errorf("template FS Execute [report_summary] template HTML failed: %v", err)
+	}
+
+	if err = tf.ExecuteTemplate(file, "report_detail", report.Differences); err != nil {
+		return fmt.Errorf("template FS Execute [report_detail] template HTML failed: %v", err)
+	}
+
+	if err = tf.ExecuteTemplate(file, "report_footer", nil); err != nil {
+		return fmt.Errorf("template FS Execute [report_footer] template HTML failed: %v", err)
+	}
+
+	return nil
+}
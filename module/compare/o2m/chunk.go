@@ -24,6 +24,7 @@ import (
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/logger"
 	"go.uber.org/zap"
 	"strconv"
 	"strings"
@@ -79,8 +80,8 @@ func (c *Chunk) CustomTableConfig() (customColumn string, customRange string, er
 			if tableCfg.IndexFields != "" && tableCfg.Range == "" {
 				isNUMBER, err := c.Oracle.IsNumberColumnTYPE(c.Cfg.OracleConfig.SchemaName, tableCfg.SourceTable, tableCfg.IndexFields)
 				if err != nil || !isNUMBER {
-					zap.L().Warn("compare table config index filed isn't number data type",
-						zap.String("table", tableCfg.SourceTable),
+					logger.L(c.Ctx).Warn("compare table config index filed isn't number data type",
+						logger.TableS(tableCfg.SourceTable),
 						zap.String("index filed", tableCfg.IndexFields),
 						zap.String("range", tableCfg.Range))
 					return customColumn, customRange, fmt.Errorf("config file index-filed isn't number type, error: %v", err)
@@ -130,7 +131,8 @@ func (c *Chunk) Split() error {
 			TableNameT:  common.StringUPPER(c.TargetTable),
 			ColumnInfoT: c.TargetColumnInfo,
 			WhereColumn: c.WhereColumn,
-			WhereRange:  c.WhereRange,
+			WhereRange:  c.ruleWhereRange(c.WhereRange),
+			WhereRangeT: c.ruleWhereRangeT(c.WhereRange),
 			IsPartition: c.IsPartition,
 		}, &meta.WaitSyncMeta{
 			DBTypeS:        common.TaskDBOracle,
@@ -171,7 +173,8 @@ func (c *Chunk) Split() error {
 			TableNameT:  common.StringUPPER(c.TargetTable),
 			ColumnInfoT: c.TargetColumnInfo,
 			WhereColumn: c.WhereColumn,
-			WhereRange:  c.WhereRange,
+			WhereRange:  c.ruleWhereRange(c.WhereRange),
+			WhereRangeT: c.ruleWhereRangeT(c.WhereRange),
 			IsPartition: c.IsPartition,
 		}, &meta.WaitSyncMeta{
 			DBTypeS:        common.TaskDBOracle,
@@ -196,9 +199,7 @@ func (c *Chunk) Split() error {
 	}
 	// 统计信息数据行数 0，直接全表扫
 	if tableRowsByStatistics == 0 {
-		zap.L().Warn("get oracle table rows",
-			zap.String("schema", common.StringUPPER(c.Cfg.OracleConfig.SchemaName)),
-			zap.String("table", c.SourceTable),
+		logger.L(c.Ctx).Warn("get oracle table rows",
 			zap.String("where", "1 = 1"),
 			zap.Int("statistics rows", tableRowsByStatistics))
 		c.WhereRange = "1 = 1"
@@ -213,7 +214,8 @@ func (c *Chunk) Split() error {
 			TableNameT:  common.StringUPPER(c.TargetTable),
 			ColumnInfoT: c.TargetColumnInfo,
 			WhereColumn: c.WhereColumn,
-			WhereRange:  c.WhereRange,
+			WhereRange:  c.ruleWhereRange(c.WhereRange),
+			WhereRangeT: c.ruleWhereRangeT(c.WhereRange),
 			IsPartition: c.IsPartition,
 		}, &meta.WaitSyncMeta{
 			DBTypeS:        common.TaskDBOracle,
@@ -231,9 +233,7 @@ func (c *Chunk) Split() error {
 		return nil
 	}
 
-	zap.L().Info("get oracle table statistics rows",
-		zap.String("schema", common.StringUPPER(c.Cfg.OracleConfig.SchemaName)),
-		zap.String("table", c.SourceTable),
+	logger.L(c.Ctx).Info("get oracle table statistics rows",
 		zap.Int("rows", tableRowsByStatistics))
 
 	// forth
@@ -247,6 +247,17 @@ func (c *Chunk) Split() error {
 	if err = c.Oracle.StartOracleChunkCreateTask(taskName); err != nil {
 		return err
 	}
+	// 异常或者提前退出时释放 Oracle DBMS_PARALLEL_EXECUTE 任务，正常结束已显式释放
+	taskClosed := false
+	defer func() {
+		if !taskClosed {
+			if errClose := c.Oracle.CloseOracleChunkTask(taskName); errClose != nil {
+				logger.L(c.Ctx).Warn("close oracle chunk task failed",
+					zap.String("task", taskName),
+					zap.Error(errClose))
+			}
+		}
+	}()
 
 	err = c.Oracle.StartOracleCreateChunkByNUMBER(taskName, common.StringUPPER(c.Cfg.OracleConfig.SchemaName), common.StringUPPER(c.SourceTable), c.WhereColumn, strconv.Itoa(c.Cfg.DiffConfig.ChunkSize))
 	if err != nil {
@@ -260,9 +271,7 @@ func (c *Chunk) Split() error {
 
 	// 判断数据是否存在，更新 data_diff_meta 记录
 	if len(chunkRes) == 0 {
-		zap.L().Warn("get oracle table rowids rows",
-			zap.String("schema", common.StringUPPER(c.Cfg.OracleConfig.SchemaName)),
-			zap.String("table", c.SourceTable),
+		logger.L(c.Ctx).Warn("get oracle table rowids rows",
 			zap.String("where", "1 = 1"),
 			zap.Int("rows", len(chunkRes)))
 
@@ -278,7 +287,8 @@ func (c *Chunk) Split() error {
 			TableNameT:  common.StringUPPER(c.TargetTable),
 			ColumnInfoT: c.TargetColumnInfo,
 			WhereColumn: c.WhereColumn,
-			WhereRange:  c.WhereRange,
+			WhereRange:  c.ruleWhereRange(c.WhereRange),
+			WhereRangeT: c.ruleWhereRangeT(c.WhereRange),
 			IsPartition: c.IsPartition,
 		}, &meta.WaitSyncMeta{
 			DBTypeS:        common.TaskDBOracle,
@@ -307,7 +317,8 @@ func (c *Chunk) Split() error {
 			TableNameT:  common.StringUPPER(c.TargetTable),
 			ColumnInfoS: c.SourceColumnInfo,
 			ColumnInfoT: c.TargetColumnInfo,
-			WhereRange:  r["CMD"],
+			WhereRange:  c.ruleWhereRange(r["CMD"]),
+			WhereRangeT: c.ruleWhereRangeT(r["CMD"]),
 			WhereColumn: c.WhereColumn,
 			IsPartition: c.IsPartition,
 		})
@@ -332,7 +343,8 @@ func (c *Chunk) Split() error {
 			TableNameT:  common.StringUPPER(c.TargetTable),
 			ColumnInfoS: c.SourceColumnInfo,
 			ColumnInfoT: c.TargetColumnInfo,
-			WhereRange:  common.StringsBuilder(c.WhereColumn, " < ", r["START_ID"]),
+			WhereRange:  c.ruleWhereRange(common.StringsBuilder(c.WhereColumn, " < ", r["START_ID"])),
+			WhereRangeT: c.ruleWhereRangeT(common.StringsBuilder(c.WhereColumn, " < ", r["START_ID"])),
 			WhereColumn: c.WhereColumn,
 			IsPartition: c.IsPartition,
 		})
@@ -345,7 +357,8 @@ func (c *Chunk) Split() error {
 			TableNameT:  common.StringUPPER(c.TargetTable),
 			ColumnInfoS: c.SourceColumnInfo,
 			ColumnInfoT: c.TargetColumnInfo,
-			WhereRange:  common.StringsBuilder(c.WhereColumn, " > ", res[0]["END_ID"]),
+			WhereRange:  c.ruleWhereRange(common.StringsBuilder(c.WhereColumn, " > ", res[0]["END_ID"])),
+			WhereRangeT: c.ruleWhereRangeT(common.StringsBuilder(c.WhereColumn, " > ", res[0]["END_ID"])),
 			WhereColumn: c.WhereColumn,
 			IsPartition: c.IsPartition,
 		})
@@ -373,15 +386,27 @@ func (c *Chunk) Split() error {
 	if err = c.Oracle.CloseOracleChunkTask(taskName); err != nil {
 		return err
 	}
+	taskClosed = true
 
 	endTime := time.Now()
-	zap.L().Info("pre split oracle and mysql table chunk finished",
-		zap.String("schema", c.Cfg.OracleConfig.SchemaName),
+	logger.L(c.Ctx).Info("pre split oracle and mysql table chunk finished",
 		zap.String("cost", endTime.Sub(startTime).String()))
 	return nil
 
 }
 
+// 数据校验范围追加表级别数据过滤条件，与 full/csv 数据迁移范围保持一致
+func (c *Chunk) ruleWhereRange(whereRange string) string {
+	tableRule, _ := c.Cfg.GetTableRule(c.SourceTable)
+	return oracle.GenOracleTableRuleWhere(whereRange, tableRule.Where)
+}
+
+// 目标端数据校验范围追加 where-target 过滤条件，where 为 Oracle 语法，MySQL 语法不兼容时需配置 where-target
+func (c *Chunk) ruleWhereRangeT(whereRange string) string {
+	tableRule, _ := c.Cfg.GetTableRule(c.SourceTable)
+	return oracle.GenOracleTableRuleWhere(whereRange, tableRule.GetWhereTarget())
+}
+
 func (c *Chunk) String() string {
 	jsonByte, _ := json.Marshal(c)
 	return string(jsonByte)
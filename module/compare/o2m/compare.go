@@ -17,13 +17,17 @@ package o2m
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/logger"
+	"github.com/wentaojin/transferdb/metrics"
 	"github.com/wentaojin/transferdb/module/compare"
+	"github.com/wentaojin/transferdb/signal"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	"os"
@@ -52,42 +56,18 @@ func NewO2MCompare(ctx context.Context, cfg *config.Config, oracle *oracle.Oracl
 
 func (r *O2M) NewCompare() error {
 	startTime := time.Now()
-	zap.L().Info("diff table oracle to mysql start",
-		zap.String("schema", r.cfg.OracleConfig.SchemaName))
+	logger.L(r.ctx).Info("diff table oracle to mysql start")
 
-	// 判断上游 Oracle 数据库版本
-	// 需要 oracle 11g 及以上
-	oraDBVersion, err := r.oracle.GetOracleDBVersion()
-	if err != nil {
-		return err
-	}
-	if common.VersionOrdinal(oraDBVersion) < common.VersionOrdinal(common.RequireOracleDBVersion) {
-		return fmt.Errorf("oracle db version [%v] is less than 11g, can't be using transferdb tools", oraDBVersion)
-	}
-
-	// 获取配置文件待同步表列表
-	exporters, err := filterCFGTable(r.cfg, r.oracle)
+	oraDBVersion, exporters, err := r.preCheck()
 	if err != nil {
 		return err
 	}
 
 	if len(exporters) == 0 {
-		zap.L().Warn("there are no table objects in the oracle schema",
-			zap.String("schema", r.cfg.OracleConfig.SchemaName))
+		logger.L(r.ctx).Warn("there are no table objects in the oracle schema")
 		return nil
 	}
 
-	// 判断 error_log_detail 是否存在错误记录，是否可进行 compare
-	errTotals, err := meta.NewErrorLogDetailModel(r.metaDB).CountsErrorLogBySchema(r.ctx, &meta.ErrorLogDetail{
-		DBTypeS:     common.TaskDBOracle,
-		DBTypeT:     common.TaskDBMySQL,
-		SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
-		RunMode:     common.CompareO2MMode,
-	})
-	if errTotals > 0 || err != nil {
-		return fmt.Errorf("compare schema [%s] table mode [%s] task failed: %v, table [error_log_detail] exist failed error, please clear and rerunning", strings.ToUpper(r.cfg.OracleConfig.SchemaName), common.CompareO2MMode, err)
-	}
-
 	// 判断并记录待同步表列表
 	for _, tableName := range exporters {
 		waitSyncMetas, err := meta.NewWaitSyncMetaModel(r.metaDB).DetailWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
@@ -213,8 +193,7 @@ func (r *O2M) NewCompare() error {
 
 	if len(waitSyncTableMetas) == 0 && len(partSyncTableMetas) == 0 {
 		endTime := time.Now()
-		zap.L().Info("all oracle table data diff finished",
-			zap.String("schema", r.cfg.OracleConfig.SchemaName),
+		logger.L(r.ctx).Info("all oracle table data diff finished",
 			zap.String("cost", endTime.Sub(startTime).String()))
 		return nil
 	}
@@ -238,8 +217,7 @@ func (r *O2M) NewCompare() error {
 
 	if len(panicTblFullSlice) != 0 {
 		endTime := time.Now()
-		zap.L().Error("all oracle table data diff error",
-			zap.String("schema", r.cfg.OracleConfig.SchemaName),
+		logger.L(r.ctx).Error("all oracle table data diff error",
 			zap.String("cost", endTime.Sub(startTime).String()),
 			zap.Strings("panic tables", panicTblFullSlice))
 		return fmt.Errorf("checkpoint isn't consistent, please reruning [enable-checkpoint = fase]")
@@ -247,32 +225,10 @@ func (r *O2M) NewCompare() error {
 
 	// ORACLE 环境信息
 	beginTime := time.Now()
-	oracleDBCharacterSet, err := r.oracle.GetOracleDBCharacterSet()
-	if err != nil {
-		return err
-	}
-	if _, ok := common.OracleDBCharacterSetMap[strings.Split(oracleDBCharacterSet, ".")[1]]; !ok {
-		return fmt.Errorf("oracle db character set [%v] isn't support", oracleDBCharacterSet)
-	}
-
-	// oracle db collation
-	nlsSort, err := r.oracle.GetOracleDBCharacterNLSSortCollation()
-	if err != nil {
-		return err
-	}
-	nlsComp, err := r.oracle.GetOracleDBCharacterNLSCompCollation()
+	oracleDBCharacterSet, _, err := r.checkDBCharacterCollation()
 	if err != nil {
 		return err
 	}
-	if _, ok := common.OracleCollationMap[strings.ToUpper(nlsSort)]; !ok {
-		return fmt.Errorf("oracle db nls sort [%s] isn't support", nlsSort)
-	}
-	if _, ok := common.OracleCollationMap[strings.ToUpper(nlsComp)]; !ok {
-		return fmt.Errorf("oracle db nls comp [%s] isn't support", nlsComp)
-	}
-	if !strings.EqualFold(nlsSort, nlsComp) {
-		return fmt.Errorf("oracle db nls_sort [%s] and nls_comp [%s] isn't different, need be equal; because mysql db isn't support", nlsSort, nlsComp)
-	}
 
 	// oracle 版本是否存在 collation
 	oracleCollation := false
@@ -280,8 +236,7 @@ func (r *O2M) NewCompare() error {
 		oracleCollation = true
 	}
 	finishTime := time.Now()
-	zap.L().Info("get oracle db character and version finished",
-		zap.String("schema", r.cfg.OracleConfig.SchemaName),
+	logger.L(r.ctx).Info("get oracle db character and version finished",
 		zap.String("db version", oraDBVersion),
 		zap.String("db character", oracleDBCharacterSet),
 		zap.Int("table totals", len(exporters)),
@@ -289,38 +244,16 @@ func (r *O2M) NewCompare() error {
 		zap.String("cost", finishTime.Sub(beginTime).String()))
 
 	// 判断下游是否存在 ORACLE 表
-	var tables []string
-	for _, t := range exporters {
-		tables = append(tables, common.StringsBuilder("'", t, "'"))
-	}
-	mysqlTables, err := r.mysql.GetMySQLTableName(r.cfg.MySQLConfig.SchemaName, strings.Join(tables, ","))
-	if err != nil {
+	if err = r.checkTargetTable(exporters); err != nil {
 		return err
 	}
 
-	diffItems := common.FilterDifferenceStringItems(exporters, mysqlTables)
-	if len(diffItems) != 0 {
-		return fmt.Errorf("table [%v] target db isn't exists, please create table", diffItems)
-	}
-
 	// compare 任务列表
 	// 获取表名自定义规则
-	tableNameRules, err := meta.NewTableNameRuleModel(r.metaDB).DetailTableNameRule(r.ctx, &meta.TableNameRule{
-		DBTypeS:     common.TaskDBOracle,
-		DBTypeT:     common.TaskDBMySQL,
-		SchemaNameS: r.cfg.OracleConfig.SchemaName,
-		SchemaNameT: r.cfg.MySQLConfig.SchemaName,
-	})
+	tableNameRuleMap, err := r.getTableNameRule()
 	if err != nil {
 		return err
 	}
-	tableNameRuleMap := make(map[string]string)
-
-	if len(tableNameRules) > 0 {
-		for _, tr := range tableNameRules {
-			tableNameRuleMap[common.StringUPPER(tr.TableNameS)] = common.StringUPPER(tr.TableNameT)
-		}
-	}
 
 	partTableTasks := NewPartCompareTableTask(r.ctx, r.cfg, partSyncTables, r.mysql, r.oracle, tableNameRuleMap)
 	waitTableTasks := NewWaitCompareTableTask(r.ctx, r.cfg, waitSyncTables, oracleCollation, r.mysql, r.oracle, tableNameRuleMap)
@@ -348,7 +281,7 @@ func (r *O2M) NewCompare() error {
 		}
 		err = r.comparePartTableTasks(f, partTableTasks)
 		if err != nil {
-			return err
+			return r.closeGracefulStop(f, err)
 		}
 	}
 	if len(waitTableTasks) > 0 {
@@ -358,7 +291,7 @@ func (r *O2M) NewCompare() error {
 		}
 		err = r.compareWaitTableTasks(f, waitTableTasks)
 		if err != nil {
-			return err
+			return r.closeGracefulStop(f, err)
 		}
 	}
 
@@ -368,7 +301,7 @@ func (r *O2M) NewCompare() error {
 	}
 
 	// 错误核对
-	errTotals, err = meta.NewErrorLogDetailModel(r.metaDB).CountsErrorLogBySchema(r.ctx, &meta.ErrorLogDetail{
+	errTotals, err := meta.NewErrorLogDetailModel(r.metaDB).CountsErrorLogBySchema(r.ctx, &meta.ErrorLogDetail{
 		DBTypeS:     common.TaskDBOracle,
 		DBTypeT:     common.TaskDBMySQL,
 		SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
@@ -379,15 +312,15 @@ func (r *O2M) NewCompare() error {
 	}
 
 	endTime := time.Now()
-	zap.L().Info("diff", zap.String("fix sql file output", filepath.Join(pwdDir, r.cfg.DiffConfig.FixSqlFile)))
+	logger.L(r.ctx).Info("diff", zap.String("fix sql file output", filepath.Join(pwdDir, r.cfg.DiffConfig.FixSqlFile)))
 	if errTotals == 0 {
-		zap.L().Info("diff table oracle to mysql finished",
+		logger.L(r.ctx).Info("diff table oracle to mysql finished",
 			zap.Int("table totals", len(exporters)),
 			zap.Int("table success", len(exporters)),
 			zap.Int("table failed", int(errTotals)),
 			zap.String("cost", endTime.Sub(startTime).String()))
 	} else {
-		zap.L().Warn("diff table oracle to mysql finished",
+		logger.L(r.ctx).Warn("diff table oracle to mysql finished",
 			zap.Int("table totals", len(exporters)),
 			zap.Int("table success", len(exporters)-int(errTotals)),
 			zap.Int("table failed", int(errTotals)),
@@ -397,8 +330,129 @@ func (r *O2M) NewCompare() error {
 	return nil
 }
 
+// 运行前检查，上游 Oracle 数据库版本、配置文件待校验表列表以及 error_log_detail 错误记录
+func (r *O2M) preCheck() (string, []string, error) {
+	// 判断上游 Oracle 数据库版本
+	// 需要 oracle 11g 及以上
+	oraDBVersion, err := r.oracle.GetOracleDBVersion()
+	if err != nil {
+		return oraDBVersion, nil, err
+	}
+	if common.VersionOrdinal(oraDBVersion) < common.VersionOrdinal(common.RequireOracleDBVersion) {
+		return oraDBVersion, nil, fmt.Errorf("oracle db version [%v] is less than 11g, can't be using transferdb tools", oraDBVersion)
+	}
+
+	// 获取配置文件待同步表列表
+	exporters, err := filterCFGTable(r.cfg, r.oracle)
+	if err != nil {
+		return oraDBVersion, exporters, err
+	}
+
+	// 判断 error_log_detail 是否存在错误记录，是否可进行 compare
+	errTotals, err := meta.NewErrorLogDetailModel(r.metaDB).CountsErrorLogBySchema(r.ctx, &meta.ErrorLogDetail{
+		DBTypeS:     common.TaskDBOracle,
+		DBTypeT:     common.TaskDBMySQL,
+		SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
+		RunMode:     common.CompareO2MMode,
+	})
+	if errTotals > 0 || err != nil {
+		return oraDBVersion, exporters, fmt.Errorf("compare schema [%s] table mode [%s] task failed: %v, table [error_log_detail] exist failed error, please clear and rerunning", strings.ToUpper(r.cfg.OracleConfig.SchemaName), common.CompareO2MMode, err)
+	}
+	return oraDBVersion, exporters, nil
+}
+
+// ORACLE 字符集以及 nls_sort/nls_comp 排序规则检查
+func (r *O2M) checkDBCharacterCollation() (string, string, error) {
+	oracleDBCharacterSet, err := r.oracle.GetOracleDBCharacterSet()
+	if err != nil {
+		return oracleDBCharacterSet, "", err
+	}
+	charset := strings.Split(oracleDBCharacterSet, ".")
+	if len(charset) != 2 {
+		return oracleDBCharacterSet, "", fmt.Errorf("oracle db character set [%v] isn't support", oracleDBCharacterSet)
+	}
+	if _, ok := common.OracleDBCharacterSetMap[charset[1]]; !ok {
+		return oracleDBCharacterSet, "", fmt.Errorf("oracle db character set [%v] isn't support", oracleDBCharacterSet)
+	}
+
+	// oracle db collation
+	nlsSort, err := r.oracle.GetOracleDBCharacterNLSSortCollation()
+	if err != nil {
+		return oracleDBCharacterSet, nlsSort, err
+	}
+	nlsComp, err := r.oracle.GetOracleDBCharacterNLSCompCollation()
+	if err != nil {
+		return oracleDBCharacterSet, nlsSort, err
+	}
+	if _, ok := common.OracleCollationMap[strings.ToUpper(nlsSort)]; !ok {
+		return oracleDBCharacterSet, nlsSort, fmt.Errorf("oracle db nls sort [%s] isn't support", nlsSort)
+	}
+	if _, ok := common.OracleCollationMap[strings.ToUpper(nlsComp)]; !ok {
+		return oracleDBCharacterSet, nlsSort, fmt.Errorf("oracle db nls comp [%s] isn't support", nlsComp)
+	}
+	if !strings.EqualFold(nlsSort, nlsComp) {
+		return oracleDBCharacterSet, nlsSort, fmt.Errorf("oracle db nls_sort [%s] and nls_comp [%s] isn't different, need be equal; because mysql db isn't support", nlsSort, nlsComp)
+	}
+	return oracleDBCharacterSet, nlsSort, nil
+}
+
+// 判断下游是否存在 ORACLE 表
+func (r *O2M) checkTargetTable(exporters []string) error {
+	var tables []string
+	for _, t := range exporters {
+		tables = append(tables, common.StringsBuilder("'", t, "'"))
+	}
+	mysqlTables, err := r.mysql.GetMySQLTableName(r.cfg.MySQLConfig.SchemaName, strings.Join(tables, ","))
+	if err != nil {
+		return err
+	}
+
+	diffItems := common.FilterDifferenceStringItems(exporters, mysqlTables)
+	if len(diffItems) != 0 {
+		return fmt.Errorf("table [%v] target db isn't exists, please create table", diffItems)
+	}
+	return nil
+}
+
+func (r *O2M) getTableNameRule() (map[string]string, error) {
+	// 获取表名自定义规则
+	tableNameRules, err := meta.NewTableNameRuleModel(r.metaDB).DetailTableNameRule(r.ctx, &meta.TableNameRule{
+		DBTypeS:     common.TaskDBOracle,
+		DBTypeT:     common.TaskDBMySQL,
+		SchemaNameS: r.cfg.OracleConfig.SchemaName,
+		SchemaNameT: r.cfg.MySQLConfig.SchemaName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	tableNameRuleMap := make(map[string]string)
+
+	if len(tableNameRules) > 0 {
+		for _, tr := range tableNameRules {
+			tableNameRuleMap[common.StringUPPER(tr.TableNameS)] = common.StringUPPER(tr.TableNameT)
+		}
+	}
+	return tableNameRuleMap, nil
+}
+
+// 优雅停止时关闭 fix sql 文件，保证已完成校验 chunk 的修复语句落盘
+func (r *O2M) closeGracefulStop(f *compare.File, err error) error {
+	if errors.Is(err, signal.ErrGracefulStop) {
+		if errClose := f.Close(); errClose != nil {
+			return errClose
+		}
+	}
+	return err
+}
+
+// 收到优雅停止信号后不再调度新的表以及 chunk，进行中的 chunk 校验完成并清理 chunk 断点后退出
 func (r *O2M) comparePartTableTasks(f *compare.File, partTableTasks []*Task) error {
 	for _, task := range partTableTasks {
+		if signal.IsStopping(r.ctx) {
+			return signal.ErrGracefulStop
+		}
+		// 表级别日志关联字段
+		tableCtx := logger.WithFields(r.ctx, logger.TableS(task.sourceTableName))
 		// 获取对比记录
 		diffStartTime := time.Now()
 		compareMetas, err := meta.NewDataCompareMetaModel(r.metaDB).DetailDataCompareMeta(r.ctx, &meta.DataCompareMeta{
@@ -419,10 +473,19 @@ func (r *O2M) comparePartTableTasks(f *compare.File, partTableTasks []*Task) err
 		g1.SetLimit(r.cfg.DiffConfig.DiffThreads)
 
 		for _, compareMeta := range compareMetas.([]meta.DataCompareMeta) {
+			if signal.IsStopping(r.ctx) {
+				break
+			}
 			newReport := NewReport(compareMeta, r.mysql, r.oracle, r.cfg.DiffConfig.OnlyCheckRows)
 			g1.Go(func() error {
+				if signal.IsStopping(r.ctx) {
+					return nil
+				}
 				// 数据对比报告
-				if err = IReport(newReport, f); err != nil {
+				compareTime := time.Now()
+				err := IReport(newReport, f)
+				metrics.ObserveChunk(common.CompareO2MMode, newReport.DataCompareMeta.SchemaNameS, metrics.StageCompare, time.Since(compareTime))
+				if err != nil {
 					err = meta.NewErrorLogDetailModel(r.metaDB).CreateErrorLog(r.ctx, &meta.ErrorLogDetail{
 						DBTypeS:     common.TaskDBOracle,
 						DBTypeT:     common.TaskDBMySQL,
@@ -465,17 +528,20 @@ func (r *O2M) comparePartTableTasks(f *compare.File, partTableTasks []*Task) err
 				if err != nil {
 					return err
 				}
-				zap.L().Info("delete mysql [data_diff_meta] meta",
-					zap.String("table", newReport.String()),
+				logger.L(tableCtx).Info("delete mysql [data_diff_meta] meta",
+					logger.ChunkID(newReport.DataCompareMeta.ID),
+					zap.String("report", newReport.String()),
 					zap.String("status", "success"))
 				return nil
 			})
 		}
 
-		if err := g1.Wait(); err != nil {
-			zap.L().Error("diff table oracle to mysql failed",
-				zap.String("schema", r.cfg.OracleConfig.SchemaName),
-				zap.String("table", task.sourceTableName),
+		err = g1.Wait()
+		if signal.IsStopping(r.ctx) {
+			return signal.ErrGracefulStop
+		}
+		if err != nil {
+			logger.L(tableCtx).Error("diff table oracle to mysql failed",
 				zap.Error(fmt.Errorf("diff table task failed, detail see [error_log_detail], please rerunning")))
 			// 忽略错误 continue
 			continue
@@ -494,10 +560,7 @@ func (r *O2M) comparePartTableTasks(f *compare.File, partTableTasks []*Task) err
 		}
 		// 若存在错误，skip 清理，统一忽略，最后显示
 		if errCounts >= 1 {
-			zap.L().Warn("update mysql [wait_sync_meta] meta",
-				zap.String("schema", r.cfg.OracleConfig.SchemaName),
-				zap.String("table", task.sourceTableName),
-				zap.String("mode", common.CompareO2MMode),
+			logger.L(tableCtx).Warn("update mysql [wait_sync_meta] meta",
 				zap.String("updated", "skip"))
 			return nil
 		}
@@ -514,9 +577,7 @@ func (r *O2M) comparePartTableTasks(f *compare.File, partTableTasks []*Task) err
 			return err
 		}
 		diffEndTime := time.Now()
-		zap.L().Info("diff single table oracle to mysql finished",
-			zap.String("schema", r.cfg.OracleConfig.SchemaName),
-			zap.String("table", task.sourceTableName),
+		logger.L(tableCtx).Info("diff single table oracle to mysql finished",
 			zap.String("cost", diffEndTime.Sub(diffStartTime).String()))
 	}
 	return nil
@@ -542,7 +603,7 @@ func (r *O2M) compareWaitTableTasks(f *compare.File, waitTableTasks []*Task) err
 		if err != nil {
 			return err
 		}
-		chunks = append(chunks, NewChunk(r.ctx, r.cfg, r.oracle, r.mysql, r.metaDB,
+		chunks = append(chunks, NewChunk(logger.WithFields(r.ctx, logger.TableS(task.sourceTableName)), r.cfg, r.oracle, r.mysql, r.metaDB,
 			cid, globalSCN, task.sourceTableName, task.targetTableName, isPartition, sourceColumnInfo, targetColumnInfo,
 			whereColumn, common.CompareO2MMode))
 	}
@@ -551,12 +612,20 @@ func (r *O2M) compareWaitTableTasks(f *compare.File, waitTableTasks []*Task) err
 	g := &errgroup.Group{}
 	g.SetLimit(r.cfg.DiffConfig.DiffThreads)
 	for _, chunk := range chunks {
+		if signal.IsStopping(r.ctx) {
+			break
+		}
 		c := chunk
 		g.Go(func() error {
+			if signal.IsStopping(r.ctx) {
+				return signal.ErrGracefulStop
+			}
+			splitTime := time.Now()
 			err := IChunker(c)
 			if err != nil {
 				return err
 			}
+			metrics.ObserveChunk(common.CompareO2MMode, r.cfg.OracleConfig.SchemaName, metrics.StageSplit, time.Since(splitTime))
 			return nil
 		})
 	}
@@ -564,6 +633,9 @@ func (r *O2M) compareWaitTableTasks(f *compare.File, waitTableTasks []*Task) err
 	if err = g.Wait(); err != nil {
 		return err
 	}
+	if signal.IsStopping(r.ctx) {
+		return signal.ErrGracefulStop
+	}
 
 	err = r.comparePartTableTasks(f, waitTableTasks)
 	if err != nil {
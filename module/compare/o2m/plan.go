@@ -0,0 +1,189 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/plan"
+	"strings"
+)
+
+// dry-run 数据校验计划
+// 与实际运行执行相同的预检查，仅只读查询，不写入元数据库、不输出修复文件、不创建 Oracle chunk 切分任务
+func (r *O2M) NewPlan() (*plan.Plan, error) {
+	pl := plan.NewPlan(common.CompareO2MMode, r.cfg.OracleConfig.SchemaName, r.cfg.MySQLConfig.SchemaName)
+
+	oraDBVersion, exporters, err := r.preCheck()
+	if err != nil {
+		return nil, err
+	}
+	pl.AddCheck("oracle db version", oraDBVersion)
+	pl.AddCheck("filter config table", fmt.Sprintf("%d tables", len(exporters)))
+	pl.AddCheck("error_log_detail", fmt.Sprintf("mode [%s] no failed records", common.CompareO2MMode))
+	oracleCollation := false
+	if common.VersionOrdinal(oraDBVersion) >= common.VersionOrdinal(common.OracleTableColumnCollationDBVersion) {
+		oracleCollation = true
+	}
+
+	oracleDBCharacterSet, nlsSort, err := r.checkDBCharacterCollation()
+	if err != nil {
+		return nil, err
+	}
+	pl.AddCheck("oracle db character set", oracleDBCharacterSet)
+	pl.AddCheck("oracle db nls_sort/nls_comp", strings.ToUpper(nlsSort))
+
+	if err = r.checkTargetTable(exporters); err != nil {
+		return nil, err
+	}
+	pl.AddCheck("target table exist", fmt.Sprintf("%d tables", len(exporters)))
+
+	tableNameRule, err := r.getTableNameRule()
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.cfg.DiffConfig.EnableCheckpoint {
+		pl.AddAction(fmt.Sprintf("enable-checkpoint is false, truncate data_compare_meta and delete schema [%s] mode [%s] wait_sync_meta records",
+			common.StringUPPER(r.cfg.OracleConfig.SchemaName), common.CompareO2MMode))
+	}
+	if !r.cfg.DiffConfig.IgnoreStructCheck {
+		pl.AddAction("run check mode table struct check before comparing, table struct must be equal")
+	}
+	pl.AddAction(fmt.Sprintf("overwrite fix sql file [%s]", r.cfg.DiffConfig.FixSqlFile))
+
+	for _, t := range exporters {
+		waitSyncMetas, err := meta.NewWaitSyncMetaModel(r.metaDB).DetailWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
+			DBTypeS:     common.TaskDBOracle,
+			DBTypeT:     common.TaskDBMySQL,
+			SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
+			TableNameS:  t,
+			Mode:        common.CompareO2MMode,
+		})
+		if err != nil {
+			return nil, err
+		}
+		task := NewWaitCompareTableTask(r.ctx, r.cfg, []string{t}, oracleCollation, r.mysql, r.oracle, tableNameRule)[0]
+		tbl := &plan.Table{
+			TableNameS:     common.StringUPPER(t),
+			TableNameT:     task.targetTableName,
+			State:          plan.TableState(waitSyncMetas, r.cfg.DiffConfig.EnableCheckpoint),
+			StatisticsRows: -1,
+			ChunkSize:      -1,
+		}
+
+		switch tbl.State {
+		case plan.TableStateFinished:
+		case plan.TableStateResume:
+			compareMetas, err := meta.NewDataCompareMetaModel(r.metaDB).DetailDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+				DBTypeS:     common.TaskDBOracle,
+				DBTypeT:     common.TaskDBMySQL,
+				SchemaNameS: r.cfg.OracleConfig.SchemaName,
+				TableNameS:  task.sourceTableName,
+				SchemaNameT: r.cfg.MySQLConfig.SchemaName,
+				TableNameT:  task.targetTableName,
+			})
+			if err != nil {
+				return nil, err
+			}
+			metas := compareMetas.([]meta.DataCompareMeta)
+			if len(metas) == 0 {
+				return nil, fmt.Errorf("table [%s] checkpoint isn't consistent, please reruning [enable-checkpoint = fase]", t)
+			}
+			tbl.Chunks = len(metas)
+			r.planSampleChunk(tbl, metas[0])
+		default:
+			if err = r.planSplitTable(tbl, task); err != nil {
+				return nil, err
+			}
+		}
+		pl.AddTable(tbl)
+	}
+	return pl, nil
+}
+
+// 预估 chunk 切分，切分优先级同 Chunk.Split
+// onlyCheckRows > configRange > configIndexFiled > DBFilter Integer Column
+func (r *O2M) planSplitTable(tbl *plan.Table, task *Task) error {
+	sourceColumnInfo, targetColumnInfo, err := task.AdjustDBSelectColumn()
+	if err != nil {
+		return err
+	}
+	whereColumn, err := task.FilterDBWhereColumn()
+	if err != nil {
+		return err
+	}
+	c := NewChunk(r.ctx, r.cfg, r.oracle, r.mysql, r.metaDB, 0, 0, task.sourceTableName, task.targetTableName, "",
+		sourceColumnInfo, targetColumnInfo, whereColumn, common.CompareO2MMode)
+
+	compareMeta := meta.DataCompareMeta{
+		SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
+		TableNameS:  common.StringUPPER(task.sourceTableName),
+		SchemaNameT: common.StringUPPER(r.cfg.MySQLConfig.SchemaName),
+		TableNameT:  common.StringUPPER(task.targetTableName),
+		ColumnInfoS: sourceColumnInfo,
+		ColumnInfoT: targetColumnInfo,
+	}
+	tbl.Chunks = 1
+
+	if r.cfg.DiffConfig.OnlyCheckRows {
+		compareMeta.ColumnInfoS, compareMeta.ColumnInfoT = "COUNT(1)", "COUNT(1)"
+		compareMeta.WhereRange = c.ruleWhereRange("1 = 1")
+		compareMeta.WhereRangeT = c.ruleWhereRangeT("1 = 1")
+		r.planSampleChunk(tbl, compareMeta)
+		return nil
+	}
+
+	customColumn, customRange, err := c.CustomTableConfig()
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(customRange, "") {
+		compareMeta.WhereRange = c.ruleWhereRange(customRange)
+		compareMeta.WhereRangeT = c.ruleWhereRangeT(customRange)
+		r.planSampleChunk(tbl, compareMeta)
+		return nil
+	}
+
+	tableRows, err := r.oracle.GetOracleTableRowsByStatistics(common.StringUPPER(r.cfg.OracleConfig.SchemaName), task.sourceTableName)
+	if err != nil {
+		return err
+	}
+	tbl.StatisticsRows, tbl.ChunkSize = tableRows, r.cfg.DiffConfig.ChunkSize
+	if tableRows == 0 {
+		compareMeta.WhereRange = c.ruleWhereRange("1 = 1")
+		compareMeta.WhereRangeT = c.ruleWhereRangeT("1 = 1")
+		r.planSampleChunk(tbl, compareMeta)
+		return nil
+	}
+
+	if !strings.EqualFold(customColumn, "") {
+		whereColumn = customColumn
+	}
+	// NUMBER 字段范围切分，另追加最小值以下以及最大值以上两个边界 chunk
+	tbl.Chunks = plan.EstimateChunks(tableRows, r.cfg.DiffConfig.ChunkSize) + 2
+	compareMeta.WhereColumn = whereColumn
+	compareMeta.WhereRange = c.ruleWhereRange(common.StringsBuilder(whereColumn, " BETWEEN <start_id> AND <end_id>"))
+	compareMeta.WhereRangeT = c.ruleWhereRangeT(common.StringsBuilder(whereColumn, " BETWEEN <start_id> AND <end_id>"))
+	r.planSampleChunk(tbl, compareMeta)
+	return nil
+}
+
+func (r *O2M) planSampleChunk(tbl *plan.Table, compareMeta meta.DataCompareMeta) {
+	tbl.SampleChunk = compareMeta.WhereRange
+	tbl.SampleSourceSQL, tbl.SampleTargetSQL = NewReport(compareMeta, r.mysql, r.oracle, r.cfg.DiffConfig.OnlyCheckRows).GenDBQuery()
+}
@@ -25,6 +25,8 @@ import (
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/logger"
+	"github.com/wentaojin/transferdb/metrics"
 	"github.com/wentaojin/transferdb/module/compare"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
@@ -60,18 +62,26 @@ func (r *Report) GenDBQuery() (oracleQuery string, mysqlQuery string) {
 			"SELECT ", r.DataCompareMeta.ColumnInfoS, " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange)
 
 		mysqlQuery = common.StringsBuilder(
-			"SELECT ", r.DataCompareMeta.ColumnInfoT, " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.DataCompareMeta.WhereRange)
+			"SELECT ", r.DataCompareMeta.ColumnInfoT, " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.targetWhereRange())
 	} else {
 		oracleQuery = common.StringsBuilder(
 			"SELECT ", r.DataCompareMeta.ColumnInfoS, " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange,
 			" ORDER BY ", r.DataCompareMeta.WhereColumn, " DESC")
 
 		mysqlQuery = common.StringsBuilder(
-			"SELECT ", r.DataCompareMeta.ColumnInfoT, " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.DataCompareMeta.WhereRange, " ORDER BY ", r.DataCompareMeta.WhereColumn, " DESC")
+			"SELECT ", r.DataCompareMeta.ColumnInfoT, " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.targetWhereRange(), " ORDER BY ", r.DataCompareMeta.WhereColumn, " DESC")
 	}
 	return
 }
 
+// 目标端查询范围，升级前生成的校验元数据未记录目标端范围沿用源端范围
+func (r *Report) targetWhereRange() string {
+	if r.DataCompareMeta.WhereRangeT != "" {
+		return r.DataCompareMeta.WhereRangeT
+	}
+	return r.DataCompareMeta.WhereRange
+}
+
 func (r *Report) CheckOracleRows(oracleQuery string) (int64, error) {
 	rows, err := r.Oracle.GetOracleTableActualRows(oracleQuery)
 	if err != nil {
@@ -125,10 +135,11 @@ func (r *Report) ReportCheckRows(f *compare.File) error {
 
 	if oracleRows == mysqlRows {
 		zap.L().Info("oracle table chunk diff equal",
-			zap.String("oracle schema", r.DataCompareMeta.SchemaNameS),
-			zap.String("mysql schema", r.DataCompareMeta.SchemaNameT),
-			zap.String("oracle table", r.DataCompareMeta.TableNameS),
-			zap.String("mysql table", r.DataCompareMeta.TableNameT),
+			logger.SchemaS(r.DataCompareMeta.SchemaNameS),
+			logger.SchemaT(r.DataCompareMeta.SchemaNameT),
+			logger.TableS(r.DataCompareMeta.TableNameS),
+			logger.TableT(r.DataCompareMeta.TableNameT),
+			logger.ChunkID(r.DataCompareMeta.ID),
 			zap.Int64("oracle rows count", oracleRows),
 			zap.Int64("mysql rows count", mysqlRows),
 			zap.String("oracle sql", oracleQuery),
@@ -137,15 +148,22 @@ func (r *Report) ReportCheckRows(f *compare.File) error {
 	}
 
 	zap.L().Info("oracle table chunk diff isn't equal",
-		zap.String("oracle schema", r.DataCompareMeta.SchemaNameS),
-		zap.String("mysql schema", r.DataCompareMeta.SchemaNameT),
-		zap.String("oracle table", r.DataCompareMeta.TableNameS),
-		zap.String("mysql table", r.DataCompareMeta.TableNameT),
+		logger.SchemaS(r.DataCompareMeta.SchemaNameS),
+		logger.SchemaT(r.DataCompareMeta.SchemaNameT),
+		logger.TableS(r.DataCompareMeta.TableNameS),
+		logger.TableT(r.DataCompareMeta.TableNameT),
+		logger.ChunkID(r.DataCompareMeta.ID),
 		zap.Int64("oracle rows count", oracleRows),
 		zap.Int64("mysql rows count", mysqlRows),
 		zap.String("oracle sql", oracleQuery),
 		zap.String("mysql sql", mysqlQuery))
 
+	if oracleRows > mysqlRows {
+		metrics.AddCompareMismatch(r.DataCompareMeta.SchemaNameS, r.DataCompareMeta.TableNameS, oracleRows-mysqlRows, 0)
+	} else {
+		metrics.AddCompareMismatch(r.DataCompareMeta.SchemaNameS, r.DataCompareMeta.TableNameS, 0, mysqlRows-oracleRows)
+	}
+
 	sw := table.NewWriter()
 	sw.SetStyle(table.StyleLight)
 	sw.AppendHeader(table.Row{"SOURCE TABLE", "SOURCE SQL", "SOURCE COUNTS", "TARGET TABLE", "TARGET SQL", "TARGET TABLE COUNTS", "RANGE"})
@@ -217,10 +235,11 @@ func (r *Report) ReportCheckCRC32(f *compare.File) error {
 	// 数据相同
 	if oraReport.Crc32Val == mysqlReport.Crc32Val {
 		zap.L().Info("oracle table chunk diff equal",
-			zap.String("oracle schema", r.DataCompareMeta.SchemaNameS),
-			zap.String("mysql schema", r.DataCompareMeta.SchemaNameT),
-			zap.String("oracle table", r.DataCompareMeta.TableNameS),
-			zap.String("mysql table", r.DataCompareMeta.TableNameT),
+			logger.SchemaS(r.DataCompareMeta.SchemaNameS),
+			logger.SchemaT(r.DataCompareMeta.SchemaNameT),
+			logger.TableS(r.DataCompareMeta.TableNameS),
+			logger.TableT(r.DataCompareMeta.TableNameT),
+			logger.ChunkID(r.DataCompareMeta.ID),
 			zap.Uint32("oracle crc32 values", oraReport.Crc32Val),
 			zap.Uint32("mysql crc32 values", mysqlReport.Crc32Val),
 			zap.String("oracle sql", oracleQuery),
@@ -229,10 +248,11 @@ func (r *Report) ReportCheckCRC32(f *compare.File) error {
 	}
 
 	zap.L().Info("oracle table chunk diff isn't equal",
-		zap.String("oracle schema", r.DataCompareMeta.SchemaNameS),
-		zap.String("mysql schema", r.DataCompareMeta.SchemaNameT),
-		zap.String("oracle table", r.DataCompareMeta.TableNameS),
-		zap.String("mysql table", r.DataCompareMeta.TableNameT),
+		logger.SchemaS(r.DataCompareMeta.SchemaNameS),
+		logger.SchemaT(r.DataCompareMeta.SchemaNameT),
+		logger.TableS(r.DataCompareMeta.TableNameS),
+		logger.TableT(r.DataCompareMeta.TableNameT),
+		logger.ChunkID(r.DataCompareMeta.ID),
 		zap.Uint32("oracle crc32 values", oraReport.Crc32Val),
 		zap.Uint32("mysql crc32 values", mysqlReport.Crc32Val),
 		zap.String("oracle sql", oracleQuery),
@@ -249,7 +269,7 @@ func (r *Report) ReportCheckCRC32(f *compare.File) error {
 	targetMore := strset.Difference(mysqlReport.StringSet, oraReport.StringSet).List()
 	if len(targetMore) > 0 {
 		fixSQL.WriteString("/*\n")
-		fixSQL.WriteString(fmt.Sprintf(" mysql table [%s.%s] chunk [%s] data rows are more \n", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT, r.DataCompareMeta.WhereRange))
+		fixSQL.WriteString(fmt.Sprintf(" mysql table [%s.%s] chunk [%s] data rows are more \n", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT, r.targetWhereRange()))
 
 		sw := table.NewWriter()
 		sw.SetStyle(table.StyleLight)
@@ -259,7 +279,7 @@ func (r *Report) ReportCheckCRC32(f *compare.File) error {
 				common.StringsBuilder("SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange),
 				oraReport.Crc32Val},
 			{"MySQL", common.StringsBuilder(
-				"SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange),
+				"SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.targetWhereRange()),
 				mysqlReport.Crc32Val},
 		})
 		fixSQL.WriteString(fmt.Sprintf("%v\n", sw.Render()))
@@ -286,7 +306,7 @@ func (r *Report) ReportCheckCRC32(f *compare.File) error {
 	sourceMore := strset.Difference(oraReport.StringSet, mysqlReport.StringSet).List()
 	if len(sourceMore) > 0 {
 		fixSQL.WriteString("/*\n")
-		fixSQL.WriteString(fmt.Sprintf(" mysql table [%s.%s] chunk [%s] data rows are less \n", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameS, r.DataCompareMeta.WhereRange))
+		fixSQL.WriteString(fmt.Sprintf(" mysql table [%s.%s] chunk [%s] data rows are less \n", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameS, r.targetWhereRange()))
 
 		sw := table.NewWriter()
 		sw.SetStyle(table.StyleLight)
@@ -296,7 +316,7 @@ func (r *Report) ReportCheckCRC32(f *compare.File) error {
 				common.StringsBuilder("SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange),
 				oraReport.Crc32Val},
 			{"MySQL", common.StringsBuilder(
-				"SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange),
+				"SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.targetWhereRange()),
 				mysqlReport.Crc32Val},
 		})
 		fixSQL.WriteString(fmt.Sprintf("%v\n", sw.Render()))
@@ -307,6 +327,8 @@ func (r *Report) ReportCheckCRC32(f *compare.File) error {
 		}
 	}
 
+	metrics.AddCompareMismatch(r.DataCompareMeta.SchemaNameS, r.DataCompareMeta.TableNameS, int64(len(sourceMore)), int64(len(targetMore)))
+
 	// 文件写入
 	if fixSQL.String() != "" {
 		if _, err := f.CWriteString(fixSQL.String()); err != nil {
@@ -23,6 +23,7 @@ import (
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/logger"
 	"github.com/wentaojin/transferdb/module/check"
 	"github.com/wentaojin/transferdb/module/check/o2m"
 	"go.uber.org/zap"
@@ -139,7 +140,7 @@ func PreTableStructCheck(ctx context.Context, cfg *config.Config, oracledb *orac
 
 		endTime := time.Now()
 		zap.L().Info("pre check schema oracle to mysql finished",
-			zap.String("schema", strings.ToUpper(cfg.OracleConfig.SchemaName)),
+			logger.SchemaS(strings.ToUpper(cfg.OracleConfig.SchemaName)),
 			zap.String("cost", endTime.Sub(startTime).String()))
 	}
 
@@ -158,8 +159,24 @@ func (t *Task) AdjustDBSelectColumn() (sourceColumnInfo string, targetColumnInfo
 		return sourceColumnInfo, targetColumnInfo, err
 	}
 
+	// 表级别数据迁移规则，只校验迁移字段子集，源端字段值按转换规则转换后与目标端对比
+	tableRule, _ := t.cfg.GetTableRule(t.sourceTableName)
+	columnInfo, err = oracle.FilterOracleTableColumnByRule(t.sourceTableName, columnInfo, tableRule)
+	if err != nil {
+		return sourceColumnInfo, targetColumnInfo, err
+	}
+
 	for _, colsInfo := range columnInfo {
 		colName := colsInfo["COLUMN_NAME"]
+		if colRule, ok := tableRule.GetColumnRule(colName); ok {
+			ruleExpr, err := oracle.GenOracleColumnRuleExpr(colName, colRule)
+			if err != nil {
+				return sourceColumnInfo, targetColumnInfo, err
+			}
+			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("NVL(", ruleExpr, ",'') AS ", colName))
+			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("IFNULL(CAST(", colName, " AS CHAR),'') AS ", colName))
+			continue
+		}
 		switch strings.ToUpper(colsInfo["DATA_TYPE"]) {
 		// 数字
 		case "NUMBER":
@@ -0,0 +1,73 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package csv
+
+import (
+	"compress/gzip"
+	"fmt"
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"strings"
+)
+
+// 导出文件压缩格式，文件后缀同 TiDB Lightning 识别后缀
+const (
+	CompressNone   = ""
+	CompressGzip   = "GZIP"
+	CompressZstd   = "ZSTD"
+	CompressSnappy = "SNAPPY"
+)
+
+// 压缩文件后缀
+func CompressFileSuffix(compress string) (string, error) {
+	switch strings.ToUpper(compress) {
+	case CompressNone:
+		return "", nil
+	case CompressGzip:
+		return ".gz", nil
+	case CompressZstd:
+		return ".zst", nil
+	case CompressSnappy:
+		return ".snappy", nil
+	default:
+		return "", fmt.Errorf("csv config paramter compress [%s] isn't support, only support gzip/zstd/snappy", compress)
+	}
+}
+
+// 流式压缩 Writer，Close 仅结束压缩流，不关闭底层 Writer
+// zstd 基于 klauspost/compress/zstd，snappy 基于 klauspost/compress/s2 兼容 snappy framing 格式
+// 多表多文件并发写入，单个压缩流不再并发压缩
+func NewCompressWriter(w io.Writer, compress string) (io.WriteCloser, error) {
+	switch strings.ToUpper(compress) {
+	case CompressNone:
+		return nopWriteCloser{w}, nil
+	case CompressGzip:
+		return gzip.NewWriter(w), nil
+	case CompressZstd:
+		return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	case CompressSnappy:
+		return s2.NewWriter(w, s2.WriterSnappyCompat(), s2.WriterConcurrency(1)), nil
+	default:
+		return nil, fmt.Errorf("csv config paramter compress [%s] isn't support, only support gzip/zstd/snappy", compress)
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -18,75 +18,65 @@ package o2m
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/meta"
-	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/logger"
+	"github.com/wentaojin/transferdb/metrics"
+	"github.com/wentaojin/transferdb/progress"
+	"github.com/wentaojin/transferdb/signal"
+	"github.com/wentaojin/transferdb/storage"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
-	"path/filepath"
+	"path"
 	"strconv"
 	"strings"
 	"time"
 )
 
 type O2M struct {
-	ctx    context.Context
-	cfg    *config.Config
-	oracle *oracle.Oracle
-	mysql  *mysql.MySQL
-	metaDB *meta.Meta
+	ctx     context.Context
+	cfg     *config.Config
+	oracle  *oracle.Oracle
+	metaDB  *meta.Meta
+	storage storage.ExternalStorage
+	// 导出进度
+	progress *progress.Tracker
 }
 
+// csv 导出以及 lightning 表结构生成仅依赖源端 Oracle 元数据，不连接目标端 MySQL
 func NewO2MCSVer(ctx context.Context, cfg *config.Config,
-	oracle *oracle.Oracle, mysql *mysql.MySQL, metaDB *meta.Meta) *O2M {
+	oracle *oracle.Oracle, metaDB *meta.Meta) *O2M {
 	return &O2M{
 		ctx:    ctx,
 		cfg:    cfg,
 		oracle: oracle,
-		mysql:  mysql,
 		metaDB: metaDB,
 	}
 }
 
 func (r *O2M) NewCSVer() error {
 	startTime := time.Now()
-	zap.L().Info("source schema full table data csv start",
-		zap.String("schema", r.cfg.OracleConfig.SchemaName))
+	logger.L(r.ctx).Info("source schema full table data csv start")
 
-	// 判断上游 Oracle 数据库版本
-	// 需要 oracle 11g 及以上
-	oraDBVersion, err := r.oracle.GetOracleDBVersion()
+	oraDBVersion, exporters, err := r.preCheck()
 	if err != nil {
 		return err
 	}
-	if common.VersionOrdinal(oraDBVersion) < common.VersionOrdinal(common.RequireOracleDBVersion) {
-		return fmt.Errorf("oracle db version [%v] is less than 11g, can't be using transferdb tools", oraDBVersion)
-	}
 	oracleCollation := false
 	if common.VersionOrdinal(oraDBVersion) >= common.VersionOrdinal(common.OracleTableColumnCollationDBVersion) {
 		oracleCollation = true
 	}
 
-	// 获取配置文件待同步表列表
-	exporters, err := filterCFGTable(r.cfg, r.oracle)
+	// 数据文件输出存储，本地目录或 S3 兼容对象存储
+	r.storage, err = storage.NewStorage(r.ctx, r.cfg.CSVConfig.OutputDir, r.cfg.CSVConfig.S3)
 	if err != nil {
 		return err
 	}
 
-	// 判断 error_log_detail 是否存在错误记录，是否可进行 CSV
-	errTotals, err := meta.NewErrorLogDetailModel(r.metaDB).CountsErrorLogBySchema(r.ctx, &meta.ErrorLogDetail{
-		DBTypeS:     common.TaskDBOracle,
-		DBTypeT:     common.TaskDBMySQL,
-		SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
-		RunMode:     common.CSVO2MMode,
-	})
-	if errTotals > 0 || err != nil {
-		return fmt.Errorf("csv schema [%s] table mode [%s] task failed: %v, table [error_log_detail] exist failed error, please clear and rerunning", strings.ToUpper(r.cfg.OracleConfig.SchemaName), common.CompareO2MMode, err)
-	}
-
 	// 判断并记录待同步表列表
 	for _, tableName := range exporters {
 		waitSyncMetas, err := meta.NewWaitSyncMetaModel(r.metaDB).DetailWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
@@ -221,8 +211,7 @@ func (r *O2M) NewCSVer() error {
 
 	if len(waitSyncTableMetas) == 0 && len(partSyncTableMetas) == 0 {
 		endTime := time.Now()
-		zap.L().Info("all full table data csv finished",
-			zap.String("schema", r.cfg.OracleConfig.SchemaName),
+		logger.L(r.ctx).Info("all full table data csv finished",
 			zap.String("cost", endTime.Sub(startTime).String()))
 		return nil
 	}
@@ -246,13 +235,24 @@ func (r *O2M) NewCSVer() error {
 
 	if len(panicTblFullSlice) != 0 {
 		endTime := time.Now()
-		zap.L().Error("all full table data loader error",
-			zap.String("schema", r.cfg.OracleConfig.SchemaName),
+		logger.L(r.ctx).Error("all full table data loader error",
 			zap.String("cost", endTime.Sub(startTime).String()),
 			zap.Strings("panic tables", panicTblFullSlice))
 		return fmt.Errorf("checkpoint isn't consistent, please reruning [enable-checkpoint = fase]")
 	}
 
+	// 断点续传复用已记录的一致性快照 SCN，校验仍在 undo 保留期内
+	if r.cfg.CSVConfig.ConsistentSnapshot && len(partSyncTables) > 0 {
+		if _, err = r.oracle.GetOracleGlobalSCN(r.ctx, r.metaDB, r.cfg.OracleConfig.SchemaName, common.CSVO2MMode, true); err != nil {
+			return err
+		}
+	}
+
+	// 进度跟踪，定期输出进度汇总
+	r.progress = progress.NewTracker(r.ctx, r.metaDB, common.TaskDBOracle, common.TaskDBMySQL, r.cfg.OracleConfig.SchemaName, common.CSVO2MMode)
+	stopProgress := r.progress.Run(time.Duration(r.cfg.AppConfig.ProgressInterval) * time.Second)
+	defer stopProgress()
+
 	// 数据 CSV
 	// 优先存在断点的表
 	// partTableTask -> waitTableTasks
@@ -264,22 +264,18 @@ func (r *O2M) NewCSVer() error {
 	}
 	if len(waitSyncTables) > 0 {
 		// 获取表名自定义规则
-		tableNameRules, err := meta.NewTableNameRuleModel(r.metaDB).DetailTableNameRule(r.ctx, &meta.TableNameRule{
-			DBTypeS:     common.TaskDBOracle,
-			DBTypeT:     common.TaskDBMySQL,
-			SchemaNameS: r.cfg.OracleConfig.SchemaName,
-			SchemaNameT: r.cfg.MySQLConfig.SchemaName,
-		})
+		tableNameRuleMap, err := r.getTableNameRule()
 		if err != nil {
 			return err
 		}
-		tableNameRuleMap := make(map[string]string)
-
-		if len(tableNameRules) > 0 {
-			for _, tr := range tableNameRules {
-				tableNameRuleMap[common.StringUPPER(tr.TableNameS)] = common.StringUPPER(tr.TableNameT)
+		// Lightning 目录格式，输出库、表结构文件
+		if r.cfg.CSVConfig.LightningLayout {
+			err = r.genLightningSchemaFile(waitSyncTables, tableNameRuleMap)
+			if err != nil {
+				return err
 			}
 		}
+
 		err = r.csvWaitSyncTable(waitSyncTables, tableNameRuleMap, oracleCollation)
 		if err != nil {
 			return err
@@ -287,16 +283,61 @@ func (r *O2M) NewCSVer() error {
 	}
 
 	endTime := time.Now()
-	zap.L().Info("source schema all table data csv finished",
-		zap.String("schema", r.cfg.OracleConfig.SchemaName),
+	logger.L(r.ctx).Info("source schema all table data csv finished",
 		zap.String("cost", endTime.Sub(startTime).String()))
 	return nil
 }
 
+// 运行前检查，上游 Oracle 数据库版本、配置文件待导出表列表以及 error_log_detail 错误记录
+func (r *O2M) preCheck() (string, []string, error) {
+	// 判断上游 Oracle 数据库版本
+	// 需要 oracle 11g 及以上
+	oraDBVersion, err := r.oracle.GetOracleDBVersion()
+	if err != nil {
+		return oraDBVersion, nil, err
+	}
+	if common.VersionOrdinal(oraDBVersion) < common.VersionOrdinal(common.RequireOracleDBVersion) {
+		return oraDBVersion, nil, fmt.Errorf("oracle db version [%v] is less than 11g, can't be using transferdb tools", oraDBVersion)
+	}
+
+	// 获取配置文件待同步表列表
+	exporters, err := filterCFGTable(r.cfg, r.oracle)
+	if err != nil {
+		return oraDBVersion, exporters, err
+	}
+
+	// 判断 error_log_detail 是否存在错误记录，是否可进行 CSV
+	errTotals, err := meta.NewErrorLogDetailModel(r.metaDB).CountsErrorLogBySchema(r.ctx, &meta.ErrorLogDetail{
+		DBTypeS:     common.TaskDBOracle,
+		DBTypeT:     common.TaskDBMySQL,
+		SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
+		RunMode:     common.CSVO2MMode,
+	})
+	if errTotals > 0 || err != nil {
+		return oraDBVersion, exporters, fmt.Errorf("csv schema [%s] table mode [%s] task failed: %v, table [error_log_detail] exist failed error, please clear and rerunning", strings.ToUpper(r.cfg.OracleConfig.SchemaName), common.CSVO2MMode, err)
+	}
+	return oraDBVersion, exporters, nil
+}
+
+// chunk 数据抽取语句
+func (r *O2M) genTableChunkSQL(m meta.FullSyncMeta, where string) string {
+	querySQL := common.StringsBuilder(`SELECT `, m.ColumnInfoS, ` FROM `, m.SchemaNameS, `.`, m.TableNameS)
+	// 一致性快照读，所有 chunk 基于同一全局 SCN
+	if r.cfg.CSVConfig.ConsistentSnapshot {
+		querySQL = common.StringsBuilder(querySQL, ` AS OF SCN `, strconv.FormatUint(m.GlobalScnS, 10))
+	}
+	// 表级别数据过滤条件
+	querySQL = common.StringsBuilder(querySQL, ` WHERE `, oracle.GenOracleTableRuleWhere(m.RowidInfoS, where))
+	// 分片文件断点续传按行数跳过已完成分片，要求快照内行顺序固定
+	if r.cfg.CSVConfig.MaxFileSize > 0 && r.cfg.CSVConfig.ConsistentSnapshot {
+		querySQL = common.StringsBuilder(querySQL, ` ORDER BY ROWID`)
+	}
+	return querySQL
+}
+
 func (r *O2M) csvPartSyncTable(csvPartTables []string) error {
 	startTime := time.Now()
-	zap.L().Info("source schema csv sync start",
-		zap.String("schema", r.cfg.OracleConfig.SchemaName))
+	logger.L(r.ctx).Info("source schema csv sync start")
 
 	oracleDBCharacterSet, err := r.oracle.GetOracleDBCharacterSet()
 	if err != nil {
@@ -306,13 +347,20 @@ func (r *O2M) csvPartSyncTable(csvPartTables []string) error {
 	g := &errgroup.Group{}
 	g.SetLimit(r.cfg.CSVConfig.TableThreads)
 
+	// 收到优雅停止信号后不再调度新的表以及 chunk，进行中的 chunk 文件写入完成并清理 chunk 断点后退出
 	for _, table := range csvPartTables {
+		if signal.IsStopping(r.ctx) {
+			break
+		}
 		t := table
 		g.Go(func() error {
+			if signal.IsStopping(r.ctx) {
+				return signal.ErrGracefulStop
+			}
 			startTime := time.Now()
-			zap.L().Info("source schema table csv data start",
-				zap.String("schema", r.cfg.OracleConfig.SchemaName),
-				zap.String("table", t))
+			// 表级别日志关联字段
+			tableCtx := logger.WithFields(r.ctx, logger.TableS(t))
+			logger.L(tableCtx).Info("source schema table csv data start")
 
 			fullMetas, err := meta.NewFullSyncMetaModel(r.metaDB).DetailFullSyncMeta(r.ctx, &meta.FullSyncMeta{
 				DBTypeS:     common.TaskDBOracle,
@@ -325,14 +373,55 @@ func (r *O2M) csvPartSyncTable(csvPartTables []string) error {
 				return err
 			}
 
+			tableRule, _ := r.cfg.GetTableRule(t)
+
+			// parquet 字段类型依据 Oracle 字段数据类型，仅获取字段数据类型，无需排序规则
+			// 字段值转换规则转换结果为字符类型，以字符输出
+			var columnsINFO []map[string]string
+			if strings.EqualFold(r.cfg.CSVConfig.FileFormat, common.CSVFileFormatParquet) {
+				tableColumnsINFO, err := r.oracle.GetOracleSchemaTableColumn(r.cfg.OracleConfig.SchemaName, t, false)
+				if err != nil {
+					return err
+				}
+				for _, rowCol := range tableColumnsINFO {
+					if _, ok := tableRule.GetColumnRule(rowCol["COLUMN_NAME"]); !ok {
+						columnsINFO = append(columnsINFO, rowCol)
+					}
+				}
+			}
+
+			// 表级别数据文件清单
+			var manifest *manifestWriter
+			if len(fullMetas) > 0 {
+				manifest, err = newManifestWriter(r.ctx, r.storage, r.genManifestFileName(t, fullMetas[0].TableNameT), &Manifest{
+					SchemaNameS: fullMetas[0].SchemaNameS,
+					TableNameS:  fullMetas[0].TableNameS,
+					SchemaNameT: fullMetas[0].SchemaNameT,
+					TableNameT:  fullMetas[0].TableNameT,
+					FileFormat:  r.cfg.CSVConfig.FileFormat,
+					Compress:    r.cfg.CSVConfig.Compress,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
 			g1 := &errgroup.Group{}
 			g1.SetLimit(r.cfg.CSVConfig.SQLThreads)
 
 			for _, fullSyncMeta := range fullMetas {
+				if signal.IsStopping(r.ctx) {
+					break
+				}
 				m := fullSyncMeta
 				g1.Go(func() error {
-					querySQL := common.StringsBuilder(
-						`SELECT `, m.ColumnInfoS, ` FROM `, m.SchemaNameS, `.`, m.TableNameS, ` WHERE `, m.RowidInfoS)
+					if signal.IsStopping(r.ctx) {
+						return signal.ErrGracefulStop
+					}
+					// chunk 级别日志关联字段
+					chunkCtx := logger.WithFields(tableCtx, logger.ChunkID(m.ID))
+					querySQL := r.genTableChunkSQL(m, tableRule.Where)
+					chunkTime := time.Now()
 
 					// 抽取 Oracle 数据
 					var (
@@ -350,15 +439,52 @@ func (r *O2M) csvPartSyncTable(csvPartTables []string) error {
 						return fmt.Errorf("get oracle schema table [%v] rows.Columns failed: %v", m.String(), err)
 					}
 
+					// 断点续传已完成分片
+					var finishedParts []ManifestFile
+					if m.CSVParts != "" {
+						if err = json.Unmarshal([]byte(m.CSVParts), &finishedParts); err != nil {
+							return fmt.Errorf("get oracle schema table [%v] csv parts unmarshal failed: %v", m.String(), err)
+						}
+					}
+
 					// 数据输出
-					err = NewWriter(m.SchemaNameS,
-						m.TableNameS,
-						oracleDBCharacterSet, querySQL, m.CSVFile, columnFields,
-						r.cfg.CSVConfig, rowsResult).WriteFile()
+					files, err := NewWriter(chunkCtx, r.storage, m.SchemaNameS,
+						m.TableNameS, m.SchemaNameT, m.TableNameT,
+						oracleDBCharacterSet, querySQL, m.CSVFile, columnFields, columnsINFO,
+						r.cfg.CSVConfig, rowsResult).WithResume(finishedParts, func(parts []ManifestFile) error {
+						partsJSON, err := json.Marshal(parts)
+						if err != nil {
+							return err
+						}
+						return meta.NewFullSyncMetaModel(r.metaDB).UpdateFullSyncMetaCSVParts(r.ctx, &meta.FullSyncMeta{
+							DBTypeS:     common.TaskDBOracle,
+							DBTypeT:     common.TaskDBMySQL,
+							SchemaNameS: m.SchemaNameS,
+							TableNameS:  m.TableNameS,
+							RowidInfoS:  m.RowidInfoS,
+							Mode:        common.CSVO2MMode,
+							CSVParts:    string(partsJSON),
+						})
+					}).WriteFile()
 					if err != nil {
 						return err
 					}
 
+					// 清单记录先于元数据清理，避免 chunk 完成但清单缺失
+					var rows, bytes int64
+					for i := range files {
+						files[i].ChunkFile = path.Base(m.CSVFile)
+						rows += files[i].Rows
+						bytes += files[i].Bytes
+					}
+					r.progress.AddRows(t, rows, bytes)
+					metrics.ObserveChunk(common.CSVO2MMode, m.SchemaNameS, metrics.StageWrite, time.Since(chunkTime))
+					metrics.AddRows(common.CSVO2MMode, m.SchemaNameS, m.TableNameS, metrics.DirectionRead, rows, bytes)
+					metrics.AddRows(common.CSVO2MMode, m.SchemaNameS, m.TableNameS, metrics.DirectionWrite, rows, bytes)
+					if err = manifest.Record(path.Base(m.CSVFile), files); err != nil {
+						return err
+					}
+
 					// 清理记录
 					err = meta.NewFullSyncMetaModel(r.metaDB).DeleteFullSyncMetaBySchemaTableRowid(
 						r.ctx, &meta.FullSyncMeta{
@@ -380,6 +506,15 @@ func (r *O2M) csvPartSyncTable(csvPartTables []string) error {
 			if err := g1.Wait(); err != nil {
 				return err
 			}
+			if signal.IsStopping(r.ctx) {
+				return signal.ErrGracefulStop
+			}
+
+			if manifest != nil {
+				if err = manifest.Finish(); err != nil {
+					return err
+				}
+			}
 
 			// 更新表级别记录
 			err = meta.NewWaitSyncMetaModel(r.metaDB).ModifyWaitSyncMetaColumnFullSplitTimesZero(r.ctx, &meta.WaitSyncMeta{
@@ -394,20 +529,20 @@ func (r *O2M) csvPartSyncTable(csvPartTables []string) error {
 				return err
 			}
 
-			zap.L().Info("source schema table csv data finished",
-				zap.String("schema", r.cfg.OracleConfig.SchemaName),
-				zap.String("table", t),
+			logger.L(tableCtx).Info("source schema table csv data finished",
 				zap.String("cost", time.Now().Sub(startTime).String()))
 			return nil
 		})
 	}
 
 	if err := g.Wait(); err != nil {
-		return fmt.Errorf("sync oracle table rows by checkpoint failed: %v", err)
+		return fmt.Errorf("sync oracle table rows by checkpoint failed: %w", err)
+	}
+	if signal.IsStopping(r.ctx) {
+		return signal.ErrGracefulStop
 	}
 
-	zap.L().Info("source schema csv data sync finished",
-		zap.String("schema", r.cfg.OracleConfig.SchemaName),
+	logger.L(r.ctx).Info("source schema csv data sync finished",
 		zap.Int("table counts", len(csvPartTables)),
 		zap.String("cost", time.Now().Sub(startTime).String()))
 	return nil
@@ -428,7 +563,7 @@ func (r *O2M) csvWaitSyncTable(csvWaitTables []string, tableNameRule map[string]
 func (r *O2M) initWaitSyncTableRowID(csvWaitTables []string, tableNameRule map[string]string, oracleCollation bool) error {
 	startTask := time.Now()
 	// 全量同步前，获取 SCN 以及初始化元数据表
-	globalSCN, err := r.oracle.GetOracleCurrentSnapshotSCN()
+	globalSCN, err := r.oracle.GetOracleGlobalSCN(r.ctx, r.metaDB, r.cfg.OracleConfig.SchemaName, common.CSVO2MMode, r.cfg.CSVConfig.ConsistentSnapshot)
 	if err != nil {
 		return err
 	}
@@ -441,9 +576,15 @@ func (r *O2M) initWaitSyncTableRowID(csvWaitTables []string, tableNameRule map[s
 	g.SetLimit(r.cfg.CSVConfig.TaskThreads)
 
 	for idx, table := range csvWaitTables {
+		if signal.IsStopping(r.ctx) {
+			break
+		}
 		t := table
 		workerID := idx
 		g.Go(func() error {
+			if signal.IsStopping(r.ctx) {
+				return signal.ErrGracefulStop
+			}
 			startTime := time.Now()
 
 			// 库名、表名规则
@@ -458,6 +599,11 @@ func (r *O2M) initWaitSyncTableRowID(csvWaitTables []string, tableNameRule map[s
 				return fmt.Errorf("csv config paramter output-dir can't be null, please configure")
 			}
 
+			// 表重新初始化，清理历史数据文件清单
+			if err := r.storage.DeleteFile(r.ctx, r.genManifestFileName(t, targetTableName)); err != nil {
+				return err
+			}
+
 			sourceColumnInfo, err := r.adjustTableSelectColumn(t, oracleCollation)
 			if err != nil {
 				return err
@@ -478,9 +624,8 @@ func (r *O2M) initWaitSyncTableRowID(csvWaitTables []string, tableNameRule map[s
 			}
 			// 统计信息数据行数 0，直接全表扫
 			if tableRowsByStatistics == 0 {
-				zap.L().Warn("get oracle table rows",
-					zap.String("schema", r.cfg.OracleConfig.SchemaName),
-					zap.String("table", t),
+				logger.L(r.ctx).Warn("get oracle table rows",
+					logger.TableS(t),
 					zap.String("column", sourceColumnInfo),
 					zap.String("where", "1 = 1"),
 					zap.Int("statistics rows", tableRowsByStatistics))
@@ -497,10 +642,7 @@ func (r *O2M) initWaitSyncTableRowID(csvWaitTables []string, tableNameRule map[s
 					RowidInfoS:  "1 = 1",
 					Mode:        common.CSVO2MMode,
 					IsPartition: isPartition,
-					CSVFile: filepath.Join(r.cfg.CSVConfig.OutputDir,
-						common.StringUPPER(r.cfg.OracleConfig.SchemaName), common.StringUPPER(t),
-						common.StringsBuilder(common.StringUPPER(r.cfg.MySQLConfig.SchemaName),
-							`.`, common.StringUPPER(targetTableName), `.0.csv`)),
+					CSVFile:     r.genDataFileName(t, targetTableName, 0),
 				}, &meta.WaitSyncMeta{
 					DBTypeS:        common.TaskDBOracle,
 					DBTypeT:        common.TaskDBMySQL,
@@ -517,9 +659,8 @@ func (r *O2M) initWaitSyncTableRowID(csvWaitTables []string, tableNameRule map[s
 				return nil
 			}
 
-			zap.L().Info("get oracle table statistics rows",
-				zap.String("schema", common.StringUPPER(r.cfg.OracleConfig.SchemaName)),
-				zap.String("table", common.StringUPPER(t)),
+			logger.L(r.ctx).Info("get oracle table statistics rows",
+				logger.TableS(common.StringUPPER(t)),
 				zap.Int("rows", tableRowsByStatistics))
 
 			taskName := common.StringsBuilder(common.StringUPPER(r.cfg.OracleConfig.SchemaName), `_`, common.StringUPPER(t), `_`, `TASK`, strconv.Itoa(workerID))
@@ -527,6 +668,17 @@ func (r *O2M) initWaitSyncTableRowID(csvWaitTables []string, tableNameRule map[s
 			if err = r.oracle.StartOracleChunkCreateTask(taskName); err != nil {
 				return err
 			}
+			// 异常或者提前退出时释放 Oracle DBMS_PARALLEL_EXECUTE 任务，正常结束已显式释放
+			taskClosed := false
+			defer func() {
+				if !taskClosed {
+					if errClose := r.oracle.CloseOracleChunkTask(taskName); errClose != nil {
+						logger.L(r.ctx).Warn("close oracle chunk task failed",
+							zap.String("task", taskName),
+							zap.Error(errClose))
+					}
+				}
+			}()
 
 			if err = r.oracle.StartOracleCreateChunkByRowID(taskName, common.StringUPPER(r.cfg.OracleConfig.SchemaName), common.StringUPPER(t), strconv.Itoa(r.cfg.CSVConfig.Rows)); err != nil {
 				return err
@@ -539,9 +691,8 @@ func (r *O2M) initWaitSyncTableRowID(csvWaitTables []string, tableNameRule map[s
 
 			// 判断数据是否存在
 			if len(chunkRes) == 0 {
-				zap.L().Warn("get oracle table rowids rows",
-					zap.String("schema", common.StringUPPER(r.cfg.OracleConfig.SchemaName)),
-					zap.String("table", common.StringUPPER(t)),
+				logger.L(r.ctx).Warn("get oracle table rowids rows",
+					logger.TableS(common.StringUPPER(t)),
 					zap.String("column", sourceColumnInfo),
 					zap.String("where", "1 = 1"),
 					zap.Int("rowids rows", len(chunkRes)))
@@ -558,10 +709,7 @@ func (r *O2M) initWaitSyncTableRowID(csvWaitTables []string, tableNameRule map[s
 					RowidInfoS:  "1 = 1",
 					Mode:        common.CSVO2MMode,
 					IsPartition: isPartition,
-					CSVFile: filepath.Join(r.cfg.CSVConfig.OutputDir,
-						common.StringUPPER(r.cfg.OracleConfig.SchemaName), common.StringUPPER(t),
-						common.StringsBuilder(common.StringUPPER(r.cfg.MySQLConfig.SchemaName),
-							`.`, common.StringUPPER(targetTableName), `.0.csv`)),
+					CSVFile:     r.genDataFileName(t, targetTableName, 0),
 				}, &meta.WaitSyncMeta{
 					DBTypeS:        common.TaskDBOracle,
 					DBTypeT:        common.TaskDBMySQL,
@@ -581,11 +729,7 @@ func (r *O2M) initWaitSyncTableRowID(csvWaitTables []string, tableNameRule map[s
 
 			var fullMetas []meta.FullSyncMeta
 			for i, res := range chunkRes {
-				var csvFile string
-				csvFile = filepath.Join(r.cfg.CSVConfig.OutputDir,
-					common.StringUPPER(r.cfg.OracleConfig.SchemaName), common.StringUPPER(t),
-					common.StringsBuilder(common.StringUPPER(r.cfg.MySQLConfig.SchemaName), `.`,
-						common.StringUPPER(targetTableName), `.`, strconv.Itoa(i), `.csv`))
+				csvFile := r.genDataFileName(t, targetTableName, i)
 
 				fullMetas = append(fullMetas, meta.FullSyncMeta{
 					DBTypeS:     common.TaskDBOracle,
@@ -627,11 +771,11 @@ func (r *O2M) initWaitSyncTableRowID(csvWaitTables []string, tableNameRule map[s
 			if err = r.oracle.CloseOracleChunkTask(taskName); err != nil {
 				return err
 			}
+			taskClosed = true
 
 			endTime := time.Now()
-			zap.L().Info("source table init wait_sync_meta and full_sync_meta finished",
-				zap.String("schema", r.cfg.OracleConfig.SchemaName),
-				zap.String("table", t),
+			logger.L(r.ctx).Info("source table init wait_sync_meta and full_sync_meta finished",
+				logger.TableS(t),
 				zap.String("cost", endTime.Sub(startTime).String()))
 			return nil
 		})
@@ -640,13 +784,36 @@ func (r *O2M) initWaitSyncTableRowID(csvWaitTables []string, tableNameRule map[s
 	if err = g.Wait(); err != nil {
 		return err
 	}
+	if signal.IsStopping(r.ctx) {
+		return signal.ErrGracefulStop
+	}
 
-	zap.L().Info("source schema init wait_sync_meta and full_sync_meta finished",
-		zap.String("schema", r.cfg.OracleConfig.SchemaName),
+	logger.L(r.ctx).Info("source schema init wait_sync_meta and full_sync_meta finished",
 		zap.String("cost", time.Now().Sub(startTask).String()))
 	return nil
 }
 
+func (r *O2M) getTableNameRule() (map[string]string, error) {
+	// 获取表名自定义规则
+	tableNameRules, err := meta.NewTableNameRuleModel(r.metaDB).DetailTableNameRule(r.ctx, &meta.TableNameRule{
+		DBTypeS:     common.TaskDBOracle,
+		DBTypeT:     common.TaskDBMySQL,
+		SchemaNameS: r.cfg.OracleConfig.SchemaName,
+		SchemaNameT: r.cfg.MySQLConfig.SchemaName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	tableNameRuleMap := make(map[string]string)
+
+	if len(tableNameRules) > 0 {
+		for _, tr := range tableNameRules {
+			tableNameRuleMap[common.StringUPPER(tr.TableNameS)] = common.StringUPPER(tr.TableNameT)
+		}
+	}
+	return tableNameRuleMap, nil
+}
+
 func (r *O2M) adjustTableSelectColumn(sourceTable string, oracleCollation bool) (string, error) {
 	// Date/Timestamp 字段类型格式化
 	// Interval Year/Day 数据字符 TO_CHAR 格式化
@@ -655,9 +822,31 @@ func (r *O2M) adjustTableSelectColumn(sourceTable string, oracleCollation bool)
 		return "", err
 	}
 
+	// 表级别数据迁移规则，字段子集以及字段值转换
+	tableRule, _ := r.cfg.GetTableRule(sourceTable)
+	columnsINFO, err = oracle.FilterOracleTableColumnByRule(sourceTable, columnsINFO, tableRule)
+	if err != nil {
+		return "", err
+	}
+
+	// 自定义日期、时间戳格式（Oracle TO_CHAR 格式），parquet 依据默认格式解析时间，配置校验拒绝自定义格式
+	var dateFormat, timestampFormat string
+	if !strings.EqualFold(r.cfg.CSVConfig.FileFormat, common.CSVFileFormatParquet) {
+		dateFormat = common.SpecialLettersUsingOracle([]byte(r.cfg.CSVConfig.DateFormat))
+		timestampFormat = common.SpecialLettersUsingOracle([]byte(r.cfg.CSVConfig.TimestampFormat))
+	}
+
 	var columnNames []string
 
 	for _, rowCol := range columnsINFO {
+		if colRule, ok := tableRule.GetColumnRule(rowCol["COLUMN_NAME"]); ok {
+			ruleExpr, err := oracle.GenOracleColumnRuleExpr(rowCol["COLUMN_NAME"], colRule)
+			if err != nil {
+				return "", err
+			}
+			columnNames = append(columnNames, common.StringsBuilder(ruleExpr, " AS ", rowCol["COLUMN_NAME"]))
+			continue
+		}
 		switch strings.ToUpper(rowCol["DATA_TYPE"]) {
 		// 数字
 		case "NUMBER":
@@ -672,11 +861,17 @@ func (r *O2M) adjustTableSelectColumn(sourceTable string, oracleCollation bool)
 			columnNames = append(columnNames, rowCol["COLUMN_NAME"])
 		// 时间
 		case "DATE":
-			columnNames = append(columnNames, common.StringsBuilder("TO_CHAR(", rowCol["COLUMN_NAME"], ",'yyyy-MM-dd HH24:mi:ss') AS ", rowCol["COLUMN_NAME"]))
+			if dateFormat != "" {
+				columnNames = append(columnNames, common.StringsBuilder("TO_CHAR(", rowCol["COLUMN_NAME"], ",'", dateFormat, "') AS ", rowCol["COLUMN_NAME"]))
+			} else {
+				columnNames = append(columnNames, common.StringsBuilder("TO_CHAR(", rowCol["COLUMN_NAME"], ",'yyyy-MM-dd HH24:mi:ss') AS ", rowCol["COLUMN_NAME"]))
+			}
 		// 默认其他类型
 		default:
 			if strings.Contains(rowCol["DATA_TYPE"], "INTERVAL") {
 				columnNames = append(columnNames, common.StringsBuilder("TO_CHAR(", rowCol["COLUMN_NAME"], ") AS ", rowCol["COLUMN_NAME"]))
+			} else if strings.Contains(rowCol["DATA_TYPE"], "TIMESTAMP") && timestampFormat != "" {
+				columnNames = append(columnNames, common.StringsBuilder("TO_CHAR(", rowCol["COLUMN_NAME"], ",'", timestampFormat, "') AS ", rowCol["COLUMN_NAME"]))
 			} else if strings.Contains(rowCol["DATA_TYPE"], "TIMESTAMP") {
 				dataScale, err := strconv.Atoi(rowCol["DATA_SCALE"])
 				if err != nil {
@@ -700,3 +895,35 @@ func (r *O2M) adjustTableSelectColumn(sourceTable string, oracleCollation bool)
 
 	return strings.Join(columnNames, ","), nil
 }
+
+// 数据文件名 {target_schema}.{target_table}.{serial}.{format}，路径相对 output-dir
+// 默认目录 {source_schema}/{source_table}
+// Lightning 目录格式输出目录平铺，序号补零，同 Dumpling
+func (r *O2M) genDataFileName(sourceTable, targetTable string, serial int) string {
+	var ext string
+	switch common.StringUPPER(r.cfg.CSVConfig.FileFormat) {
+	case common.CSVFileFormatSQL:
+		ext = "sql"
+	case common.CSVFileFormatParquet:
+		ext = "parquet"
+	default:
+		ext = "csv"
+	}
+
+	if r.cfg.CSVConfig.LightningLayout {
+		return common.StringsBuilder(common.StringUPPER(r.cfg.MySQLConfig.SchemaName), `.`, common.StringUPPER(targetTable), `.`,
+			fmt.Sprintf("%0*d", common.LightningFileSerialWidth, serial), `.`, ext)
+	}
+	return path.Join(common.StringUPPER(r.cfg.OracleConfig.SchemaName), common.StringUPPER(sourceTable),
+		common.StringsBuilder(common.StringUPPER(r.cfg.MySQLConfig.SchemaName), `.`,
+			common.StringUPPER(targetTable), `.`, strconv.Itoa(serial), `.`, ext))
+}
+
+// 表级别数据文件清单 {target_schema}.{target_table}.manifest.json，同数据文件目录
+func (r *O2M) genManifestFileName(sourceTable, targetTable string) string {
+	fileName := common.StringsBuilder(common.StringUPPER(r.cfg.MySQLConfig.SchemaName), `.`, common.StringUPPER(targetTable), `.manifest.json`)
+	if r.cfg.CSVConfig.LightningLayout {
+		return fileName
+	}
+	return path.Join(common.StringUPPER(r.cfg.OracleConfig.SchemaName), common.StringUPPER(sourceTable), fileName)
+}
@@ -0,0 +1,537 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/thinkeridea/go-extend/exstrings"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/module/csv"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQL 文件单条 INSERT 语句行数
+const sqlInsertBatchRows = 200
+
+// Oracle DATE/TIMESTAMP 查询 TO_CHAR 输出格式
+const oracleTimestampLayout = "2006-01-02 15:04:05.999999999"
+
+// 文件编码器，file-format 决定输出 csv/sql/parquet
+// Encode 字段值 raw 为 nil 表示 NULL，raw 在下次 Scan 会被复用，需要缓存的编码器自行拷贝
+type Encoder interface {
+	Encode(raws [][]byte) error
+	Close() error
+}
+
+func (f *File) NewEncoder(w io.Writer, columnTypes, databaseTypes []string) (Encoder, error) {
+	switch common.StringUPPER(f.FileFormat) {
+	case "", common.CSVFileFormatCSV:
+		return newCSVEncoder(f, w, columnTypes, databaseTypes)
+	case common.CSVFileFormatSQL:
+		return newSQLEncoder(f, w, columnTypes, databaseTypes)
+	case common.CSVFileFormatParquet:
+		return newParquetEncoder(f, w)
+	default:
+		return nil, fmt.Errorf("csv config file-format [%s] isn't support, only support [csv sql parquet]", f.FileFormat)
+	}
+}
+
+/*
+CSV
+*/
+type csvEncoder struct {
+	file          *File
+	writer        *bufio.Writer
+	columnTypes   []string
+	databaseTypes []string
+	quote         string
+	results       []string
+}
+
+func newCSVEncoder(f *File, w io.Writer, columnTypes, databaseTypes []string) (*csvEncoder, error) {
+	e := &csvEncoder{
+		file:          f,
+		writer:        bufio.NewWriter(w),
+		columnTypes:   columnTypes,
+		databaseTypes: databaseTypes,
+		quote:         f.Delimiter,
+	}
+	// 未配置字符串引用定界符，字段需引用时使用双引号
+	if e.quote == "" {
+		e.quote = `"`
+	}
+	if f.Header {
+		var headers []string
+		for _, c := range f.SourceColumns {
+			headers = append(headers, e.quoteField(c, false))
+		}
+		if _, err := e.writer.WriteString(common.StringsBuilder(exstrings.Join(headers, f.Separator), f.Terminator)); err != nil {
+			return nil, fmt.Errorf("failed to write headers: %v", err)
+		}
+	}
+	return e, nil
+}
+
+func (e *csvEncoder) Encode(raws [][]byte) error {
+	e.results = e.results[0:0]
+
+	for i, raw := range raws {
+		// 注意 Oracle/Mysql NULL VS 空字符串区别
+		// Oracle 空字符串与 NULL 归于一类，统一 NULL 处理 （is null 可以查询 NULL 以及空字符串值，空字符串查询无法查询到空字符串值）
+		// Mysql 空字符串与 NULL 非一类，NULL 是 NULL，空字符串是空字符串（is null 只查询 NULL 值，空字符串查询只查询到空字符串值）
+		// 按照 Oracle 特性来，转换同步统一转换成 NULL 即可，但需要注意业务逻辑中空字符串得写入，需要变更
+		// NULL 输出 null-value 且不加引号，与 null-value 相同的字符串值加引号区分
+		if raw == nil || string(raw) == "" {
+			e.results = append(e.results, e.file.NullValue)
+			continue
+		}
+
+		num, isNumber, err := genNumberValue(raw, e.columnTypes[i])
+		if err != nil {
+			return err
+		}
+		if isNumber {
+			e.results = append(e.results, num)
+			continue
+		}
+
+		var bs string
+		switch common.StringUPPER(e.databaseTypes[i]) {
+		case "BLOB", "RAW", "LONG RAW":
+			// 二进制字段不做字符集转换
+			switch common.StringUPPER(e.file.BinaryFormat) {
+			case common.CSVBinaryFormatHex:
+				bs = strings.ToUpper(hex.EncodeToString(raw))
+			case common.CSVBinaryFormatBase64:
+				bs = base64.StdEncoding.EncodeToString(raw)
+			default:
+				bs = string(raw)
+			}
+		default:
+			by, err := e.file.genCharsetValue(raw)
+			if err != nil {
+				return err
+			}
+			bs = string(by)
+		}
+
+		// 配置字符串引用定界符，字符串统一加引号，否则仅需要引用的字段加引号
+		e.results = append(e.results, e.quoteField(bs, e.file.Delimiter != ""))
+	}
+
+	// 写入文件
+	if _, err := e.writer.WriteString(common.StringsBuilder(exstrings.Join(e.results, e.file.Separator), e.file.Terminator)); err != nil {
+		return fmt.Errorf("failed to write data row to csv %w", err)
+	}
+	return nil
+}
+
+// RFC 4180 字段引用
+//   - 字段包含分隔符、引用定界符、回车换行、行尾定界符或与 null-value 相同，需加引号
+//   - 字段内引用定界符双写转义
+//   - escape-backslash 开启，反斜杠以及控制字符使用反斜杠转义（同 LOAD DATA / Lightning backslash-escape）
+func (e *csvEncoder) quoteField(s string, force bool) string {
+	if e.file.EscapeBackslash {
+		s = csvBackslashReplacer.Replace(s)
+	}
+	if !force && s != e.file.NullValue &&
+		!strings.Contains(s, e.file.Separator) &&
+		!strings.Contains(s, e.quote) &&
+		!strings.ContainsAny(s, "\r\n") &&
+		!strings.Contains(s, e.file.Terminator) {
+		return s
+	}
+	return common.StringsBuilder(e.quote, strings.ReplaceAll(s, e.quote, e.quote+e.quote), e.quote)
+}
+
+var csvBackslashReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	"\x00", `\0`,
+	"\r", `\r`,
+	"\n", `\n`,
+	"\x1a", `\Z`,
+)
+
+func (e *csvEncoder) Close() error {
+	if err := e.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush data row to csv %w", err)
+	}
+	return nil
+}
+
+/*
+SQL INSERT
+*/
+type sqlEncoder struct {
+	file          *File
+	writer        *bufio.Writer
+	columnTypes   []string
+	databaseTypes []string
+	insertPrefix  string
+	batchRows     int
+	results       []string
+}
+
+func newSQLEncoder(f *File, w io.Writer, columnTypes, databaseTypes []string) (*sqlEncoder, error) {
+	var columns []string
+	for _, c := range f.SourceColumns {
+		columns = append(columns, common.StringsBuilder("`", c, "`"))
+	}
+
+	e := &sqlEncoder{
+		file:          f,
+		writer:        bufio.NewWriter(w),
+		columnTypes:   columnTypes,
+		databaseTypes: databaseTypes,
+		insertPrefix: fmt.Sprintf("INSERT INTO `%s`.`%s` (%s) VALUES\n",
+			f.TargetSchema, f.TargetTable, strings.Join(columns, ",")),
+	}
+
+	charset := "utf8mb4"
+	if strings.EqualFold(f.Charset, common.GBKCharacterSetCSV) {
+		charset = "gbk"
+	}
+	if _, err := e.writer.WriteString(fmt.Sprintf("/*!40101 SET NAMES %s*/;\n", charset)); err != nil {
+		return nil, fmt.Errorf("failed to write sql header: %v", err)
+	}
+	return e, nil
+}
+
+func (e *sqlEncoder) Encode(raws [][]byte) error {
+	e.results = e.results[0:0]
+
+	for i, raw := range raws {
+		// Oracle 空字符串与 NULL 归于一类，统一 NULL 处理
+		if raw == nil || string(raw) == "" {
+			e.results = append(e.results, "NULL")
+			continue
+		}
+
+		num, isNumber, err := genNumberValue(raw, e.columnTypes[i])
+		if err != nil {
+			return err
+		}
+		if isNumber {
+			e.results = append(e.results, num)
+			continue
+		}
+
+		switch common.StringUPPER(e.databaseTypes[i]) {
+		case "BLOB", "RAW", "LONG RAW":
+			e.results = append(e.results, common.StringsBuilder("X'", hex.EncodeToString(raw), "'"))
+		default:
+			by, err := e.file.genCharsetValue(raw)
+			if err != nil {
+				return err
+			}
+			e.results = append(e.results, common.StringsBuilder("'", common.SpecialLettersUsingMySQL(by), "'"))
+		}
+	}
+
+	var prefix string
+	if e.batchRows == 0 {
+		prefix = e.insertPrefix
+	} else {
+		prefix = ",\n"
+	}
+	if _, err := e.writer.WriteString(common.StringsBuilder(prefix, "(", exstrings.Join(e.results, ","), ")")); err != nil {
+		return fmt.Errorf("failed to write data row to sql %w", err)
+	}
+
+	e.batchRows++
+	if e.batchRows == sqlInsertBatchRows {
+		if _, err := e.writer.WriteString(";\n"); err != nil {
+			return fmt.Errorf("failed to write data row to sql %w", err)
+		}
+		e.batchRows = 0
+	}
+	return nil
+}
+
+func (e *sqlEncoder) Close() error {
+	if e.batchRows > 0 {
+		if _, err := e.writer.WriteString(";\n"); err != nil {
+			return fmt.Errorf("failed to write data row to sql %w", err)
+		}
+	}
+	if err := e.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush data row to sql %w", err)
+	}
+	return nil
+}
+
+/*
+Parquet
+*/
+type parquetEncoder struct {
+	file    *File
+	writer  *bufio.Writer
+	columns []csv.ParquetColumn
+	parquet *csv.ParquetWriter
+	values  []interface{}
+}
+
+func newParquetEncoder(f *File, w io.Writer) (*parquetEncoder, error) {
+	columnsINFO := make(map[string]map[string]string)
+	for _, rowCol := range f.SourceColumnsINFO {
+		columnsINFO[common.StringUPPER(rowCol["COLUMN_NAME"])] = rowCol
+	}
+
+	var columns []csv.ParquetColumn
+	for _, c := range f.SourceColumns {
+		column, err := genParquetColumn(c, columnsINFO[common.StringUPPER(c)])
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+
+	e := &parquetEncoder{
+		file:    f,
+		writer:  bufio.NewWriter(w),
+		columns: columns,
+		values:  make([]interface{}, len(columns)),
+	}
+	pw, err := csv.NewParquetWriter(e.writer, columns, 0, genParquetGroupSize(f.MaxFileSize))
+	if err != nil {
+		return nil, err
+	}
+	e.parquet = pw
+	return e, nil
+}
+
+// 限制文件大小时 row group 上限为文件大小 1/parquetFileGroups，分片文件以 row group 为边界切换，
+// 超出 max-file-size 不超过单个 row group
+const parquetFileGroups = 8
+
+// maxFileSize 单位 MB，小于等于 0 使用默认 row group 大小
+func genParquetGroupSize(maxFileSize int) int64 {
+	if maxFileSize <= 0 {
+		return 0
+	}
+	return int64(maxFileSize) * 1024 * 1024 / parquetFileGroups
+}
+
+func (e *parquetEncoder) Encode(raws [][]byte) error {
+	for i, raw := range raws {
+		// Oracle 空字符串与 NULL 归于一类，统一 NULL 处理
+		if raw == nil || string(raw) == "" {
+			e.values[i] = nil
+			continue
+		}
+		val, err := genParquetValue(e.columns[i], raw)
+		if err != nil {
+			return fmt.Errorf("oracle schema [%s] table [%s] column [%s] parquet value failed: %v",
+				e.file.SourceSchema, e.file.SourceTable, e.columns[i].Name, err)
+		}
+		e.values[i] = val
+	}
+	return e.parquet.Write(e.values)
+}
+
+func (e *parquetEncoder) Close() error {
+	if err := e.parquet.Close(); err != nil {
+		return fmt.Errorf("failed to close parquet %w", err)
+	}
+	if err := e.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush data row to parquet %w", err)
+	}
+	return nil
+}
+
+// Oracle 字段类型映射 Parquet 类型
+// NUMBER(p,0) p<=18 -> INT64，NUMBER(p,s) -> DECIMAL，NUMBER 未指定精度 -> UTF8 保证精度
+// DATE/TIMESTAMP -> TIMESTAMP_MICROS，二进制 -> BYTE_ARRAY，其余 -> UTF8
+func genParquetColumn(columnName string, columnINFO map[string]string) (csv.ParquetColumn, error) {
+	column := csv.ParquetColumn{
+		Name:          columnName,
+		Type:          csv.ParquetTypeByteArray,
+		ConvertedType: csv.ParquetConvertedUTF8,
+	}
+	if columnINFO == nil {
+		return column, nil
+	}
+
+	dataType := common.StringUPPER(columnINFO["DATA_TYPE"])
+	switch dataType {
+	case "NUMBER", "DECIMAL", "DEC", "NUMERIC", "INTEGER", "INT", "SMALLINT":
+		dataPrecision, err := strconv.Atoi(columnINFO["DATA_PRECISION"])
+		if err != nil {
+			return column, fmt.Errorf("column [%s] data_precision [%s] strconv.Atoi failed: %v", columnName, columnINFO["DATA_PRECISION"], err)
+		}
+		dataScale, err := strconv.Atoi(columnINFO["DATA_SCALE"])
+		if err != nil {
+			return column, fmt.Errorf("column [%s] data_scale [%s] strconv.Atoi failed: %v", columnName, columnINFO["DATA_SCALE"], err)
+		}
+		switch {
+		// number / number(*) 查询输出 number(38,127)
+		case dataPrecision == 38 && dataScale == 127:
+			return column, nil
+		case dataScale == 0 && dataPrecision <= 18:
+			column.Type = csv.ParquetTypeInt64
+			column.ConvertedType = csv.ParquetConvertedNone
+		case dataScale >= 0 && dataScale <= dataPrecision:
+			column.ConvertedType = csv.ParquetConvertedDecimal
+			column.Precision = int32(dataPrecision)
+			column.Scale = int32(dataScale)
+		}
+	case "BINARY_FLOAT":
+		column.Type = csv.ParquetTypeFloat
+		column.ConvertedType = csv.ParquetConvertedNone
+	case "BINARY_DOUBLE", "FLOAT", "DOUBLE PRECISION", "REAL":
+		column.Type = csv.ParquetTypeDouble
+		column.ConvertedType = csv.ParquetConvertedNone
+	case "DATE":
+		column.Type = csv.ParquetTypeInt64
+		column.ConvertedType = csv.ParquetConvertedTimestampMicros
+	case "BLOB", "RAW", "LONG RAW":
+		column.ConvertedType = csv.ParquetConvertedNone
+	default:
+		if strings.Contains(dataType, "TIMESTAMP") {
+			column.Type = csv.ParquetTypeInt64
+			column.ConvertedType = csv.ParquetConvertedTimestampMicros
+		}
+	}
+	return column, nil
+}
+
+func genParquetValue(column csv.ParquetColumn, raw []byte) (interface{}, error) {
+	switch column.Type {
+	case csv.ParquetTypeInt64:
+		if column.ConvertedType == csv.ParquetConvertedTimestampMicros {
+			// TO_CHAR 输出不含时区，按 UTC 墙上时间写入
+			t, err := time.ParseInLocation(oracleTimestampLayout, string(raw), time.UTC)
+			if err != nil {
+				return nil, err
+			}
+			return t.UnixMicro(), nil
+		}
+		return strconv.ParseInt(string(raw), 10, 64)
+	case csv.ParquetTypeFloat:
+		v, err := strconv.ParseFloat(string(raw), 32)
+		if err != nil {
+			return nil, err
+		}
+		return float32(v), nil
+	case csv.ParquetTypeDouble:
+		return strconv.ParseFloat(string(raw), 64)
+	default:
+		if column.ConvertedType == csv.ParquetConvertedDecimal {
+			d, err := decimal.NewFromString(string(raw))
+			if err != nil {
+				return nil, err
+			}
+			return genDecimalBytes(d.Shift(column.Scale).BigInt()), nil
+		}
+		// 字段值缓存至 row group 写入，需拷贝
+		return append([]byte(nil), raw...), nil
+	}
+}
+
+// DECIMAL 非标度值，大端序二进制补码
+func genDecimalBytes(unscaled *big.Int) []byte {
+	if unscaled.Sign() >= 0 {
+		bs := unscaled.Bytes()
+		if len(bs) == 0 || bs[0]&0x80 != 0 {
+			bs = append([]byte{0}, bs...)
+		}
+		return bs
+	}
+	// 负数补码：2^(8n) + v，n 为满足符号位的最小字节数
+	n := (unscaled.BitLen() + 8) / 8
+	twos := new(big.Int).Lsh(big.NewInt(1), uint(n*8))
+	twos.Add(twos, unscaled)
+	bs := twos.Bytes()
+	for len(bs) < n {
+		bs = append([]byte{0xFF}, bs...)
+	}
+	return bs
+}
+
+// 数值类型格式化，非数值类型返回 false
+func genNumberValue(raw []byte, columnType string) (string, bool, error) {
+	switch columnType {
+	case "int64":
+		r, err := common.StrconvIntBitSize(string(raw), 64)
+		if err != nil {
+			return "", true, err
+		}
+		return fmt.Sprintf("%v", r), true, nil
+	case "uint64":
+		r, err := common.StrconvUintBitSize(string(raw), 64)
+		if err != nil {
+			return "", true, err
+		}
+		return fmt.Sprintf("%v", r), true, nil
+	case "float32":
+		r, err := common.StrconvFloatBitSize(string(raw), 32)
+		if err != nil {
+			return "", true, err
+		}
+		return fmt.Sprintf("%v", r), true, nil
+	case "float64":
+		r, err := common.StrconvFloatBitSize(string(raw), 64)
+		if err != nil {
+			return "", true, err
+		}
+		return fmt.Sprintf("%v", r), true, nil
+	case "rune":
+		r, err := common.StrconvRune(string(raw))
+		if err != nil {
+			return "", true, err
+		}
+		return fmt.Sprintf("%v", r), true, nil
+	case "godror.Number":
+		r, err := decimal.NewFromString(string(raw))
+		if err != nil {
+			return "", true, err
+		}
+		if r.IsInteger() {
+			si, err := common.StrconvIntBitSize(string(raw), 64)
+			if err != nil {
+				return "", true, err
+			}
+			return fmt.Sprintf("%v", si), true, nil
+		}
+		rf, err := common.StrconvFloatBitSize(string(raw), 64)
+		if err != nil {
+			return "", true, err
+		}
+		return fmt.Sprintf("%v", rf), true, nil
+	default:
+		return "", false, nil
+	}
+}
+
+// 字符集转换
+func (f *File) genCharsetValue(raw []byte) ([]byte, error) {
+	if strings.ToUpper(f.Charset) == common.GBKCharacterSetCSV {
+		gbkBytes, err := common.Utf8ToGbk(raw)
+		if err != nil {
+			return nil, err
+		}
+		return gbkBytes, nil
+	}
+	return raw, nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wentaojin/transferdb/storage"
+	"os"
+	"sort"
+	"sync"
+)
+
+const manifestChecksumAlgorithm = "sha256"
+
+// 表级别数据文件清单，记录每个数据文件行数、字节数以及校验和，供导入工具以及审计核对完整性
+// 每个 chunk 完成即更新清单，表全部 chunk 完成后 finished 为 true
+type Manifest struct {
+	SchemaNameS string         `json:"schema_name_s"`
+	TableNameS  string         `json:"table_name_s"`
+	SchemaNameT string         `json:"schema_name_t"`
+	TableNameT  string         `json:"table_name_t"`
+	FileFormat  string         `json:"file_format"`
+	Compress    string         `json:"compress"`
+	Checksum    string         `json:"checksum"`
+	Finished    bool           `json:"finished"`
+	TotalFiles  int            `json:"total_files"`
+	TotalRows   int64          `json:"total_rows"`
+	TotalBytes  int64          `json:"total_bytes"`
+	Files       []ManifestFile `json:"files"`
+}
+
+// 数据文件名同清单文件目录的相对路径，字节数以及校验和基于落盘（压缩后）文件
+type ManifestFile struct {
+	ChunkFile string `json:"chunk_file"`
+	FileName  string `json:"file_name"`
+	Rows      int64  `json:"rows"`
+	Bytes     int64  `json:"bytes"`
+	Checksum  string `json:"checksum"`
+}
+
+type manifestWriter struct {
+	mu       sync.Mutex
+	ctx      context.Context
+	storage  storage.ExternalStorage
+	fileName string
+	manifest *Manifest
+}
+
+// 清单文件存在则加载（断点续传），已完成 chunk 的记录保留
+func newManifestWriter(ctx context.Context, extStorage storage.ExternalStorage, fileName string, manifest *Manifest) (*manifestWriter, error) {
+	data, err := extStorage.ReadFile(ctx, fileName)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		var m Manifest
+		if err = json.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("csv manifest file [%s] unmarshal failed: %v", fileName, err)
+		}
+		manifest.Files = m.Files
+	}
+	manifest.Checksum = manifestChecksumAlgorithm
+	manifest.Finished = false
+	return &manifestWriter{
+		ctx:      ctx,
+		storage:  extStorage,
+		fileName: fileName,
+		manifest: manifest,
+	}, nil
+}
+
+// 记录 chunk 数据文件，同一 chunk 重新导出则覆盖原记录
+func (mw *manifestWriter) Record(chunkFile string, files []ManifestFile) error {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	var newFiles []ManifestFile
+	for _, file := range mw.manifest.Files {
+		if file.ChunkFile != chunkFile {
+			newFiles = append(newFiles, file)
+		}
+	}
+	newFiles = append(newFiles, files...)
+	sort.Slice(newFiles, func(i, j int) bool {
+		return newFiles[i].FileName < newFiles[j].FileName
+	})
+	mw.manifest.Files = newFiles
+
+	return mw.flush()
+}
+
+func (mw *manifestWriter) Finish() error {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	mw.manifest.Finished = true
+	return mw.flush()
+}
+
+// 清单文件整体写入，避免写入中断
+func (mw *manifestWriter) flush() error {
+	mw.manifest.TotalFiles = len(mw.manifest.Files)
+	mw.manifest.TotalRows = 0
+	mw.manifest.TotalBytes = 0
+	for _, file := range mw.manifest.Files {
+		mw.manifest.TotalRows += file.Rows
+		mw.manifest.TotalBytes += file.Bytes
+	}
+
+	data, err := json.MarshalIndent(mw.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return mw.storage.WriteFile(mw.ctx, mw.fileName, data)
+}
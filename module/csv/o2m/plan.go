@@ -0,0 +1,181 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/csv"
+	"github.com/wentaojin/transferdb/module/plan"
+	"os"
+	"strings"
+)
+
+// dry-run 导出计划
+// 与实际运行执行相同的预检查，仅只读查询，不写入元数据库、不创建输出目录以及数据文件、不创建 Oracle chunk 切分任务
+func (r *O2M) NewPlan() (*plan.Plan, error) {
+	pl := plan.NewPlan(common.CSVO2MMode, r.cfg.OracleConfig.SchemaName, r.cfg.MySQLConfig.SchemaName)
+
+	oraDBVersion, exporters, err := r.preCheck()
+	if err != nil {
+		return nil, err
+	}
+	pl.AddCheck("oracle db version", oraDBVersion)
+	pl.AddCheck("filter config table", fmt.Sprintf("%d tables", len(exporters)))
+	pl.AddCheck("error_log_detail", fmt.Sprintf("mode [%s] no failed records", common.CSVO2MMode))
+	oracleCollation := false
+	if common.VersionOrdinal(oraDBVersion) >= common.VersionOrdinal(common.OracleTableColumnCollationDBVersion) {
+		oracleCollation = true
+	}
+
+	outputDir, err := r.checkOutputDir()
+	if err != nil {
+		return nil, err
+	}
+	pl.AddCheck("output dir", outputDir)
+
+	oracleDBCharacterSet, err := r.oracle.GetOracleDBCharacterSet()
+	if err != nil {
+		return nil, err
+	}
+	charset, err := genCSVCharset(r.cfg.CSVConfig.Charset, oracleDBCharacterSet)
+	if err != nil {
+		return nil, err
+	}
+	pl.AddCheck("csv character set", fmt.Sprintf("oracle [%s] -> csv [%s]", oracleDBCharacterSet, charset))
+
+	if _, err = csv.CompressFileSuffix(r.cfg.CSVConfig.Compress); err != nil {
+		return nil, err
+	}
+	pl.AddCheck("file format", fmt.Sprintf("format [%s] compress [%s] lightning layout [%v]",
+		r.cfg.CSVConfig.FileFormat, r.cfg.CSVConfig.Compress, r.cfg.CSVConfig.LightningLayout))
+
+	tableNameRule, err := r.getTableNameRule()
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.cfg.CSVConfig.EnableCheckpoint {
+		pl.AddAction(fmt.Sprintf("enable-checkpoint is false, delete schema [%s] mode [%s] full_sync_meta and wait_sync_meta records",
+			common.StringUPPER(r.cfg.OracleConfig.SchemaName), common.CSVO2MMode))
+	}
+
+	var globalSCN uint64
+	for _, t := range exporters {
+		waitSyncMetas, err := meta.NewWaitSyncMetaModel(r.metaDB).DetailWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
+			DBTypeS:     common.TaskDBOracle,
+			DBTypeT:     common.TaskDBMySQL,
+			SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
+			TableNameS:  t,
+			Mode:        common.CSVO2MMode,
+		})
+		if err != nil {
+			return nil, err
+		}
+		targetTableName := common.StringUPPER(t)
+		if val, ok := tableNameRule[common.StringUPPER(t)]; ok {
+			targetTableName = val
+		}
+		tbl := &plan.Table{
+			TableNameS:     common.StringUPPER(t),
+			TableNameT:     targetTableName,
+			State:          plan.TableState(waitSyncMetas, r.cfg.CSVConfig.EnableCheckpoint),
+			StatisticsRows: -1,
+			ChunkSize:      -1,
+		}
+		tableRule, _ := r.cfg.GetTableRule(t)
+
+		switch tbl.State {
+		case plan.TableStateFinished:
+		case plan.TableStateResume:
+			fullMetas, err := meta.NewFullSyncMetaModel(r.metaDB).DetailFullSyncMeta(r.ctx, &meta.FullSyncMeta{
+				DBTypeS:     common.TaskDBOracle,
+				DBTypeT:     common.TaskDBMySQL,
+				SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
+				TableNameS:  common.StringUPPER(t),
+				Mode:        common.CSVO2MMode,
+			})
+			if err != nil {
+				return nil, err
+			}
+			if len(fullMetas) == 0 {
+				return nil, fmt.Errorf("table [%s] checkpoint isn't consistent, please reruning [enable-checkpoint = fase]", t)
+			}
+			tbl.Chunks = len(fullMetas)
+			tbl.SampleChunk = fullMetas[0].RowidInfoS
+			tbl.SampleSourceSQL = r.genTableChunkSQL(fullMetas[0], tableRule.Where)
+			tbl.SampleTargetSQL = fullMetas[0].CSVFile
+		default:
+			sourceColumnInfo, err := r.adjustTableSelectColumn(t, oracleCollation)
+			if err != nil {
+				return nil, err
+			}
+			tableRows, err := r.oracle.GetOracleTableRowsByStatistics(r.cfg.OracleConfig.SchemaName, t)
+			if err != nil {
+				return nil, err
+			}
+			tbl.StatisticsRows, tbl.ChunkSize = tableRows, r.cfg.CSVConfig.Rows
+
+			// 同实际切分，统计信息 0 全表扫
+			rowidInfo := "1 = 1"
+			if tableRows == 0 {
+				tbl.Chunks = 1
+			} else {
+				tbl.Chunks = plan.EstimateChunks(tableRows, r.cfg.CSVConfig.Rows)
+				rowidInfo = "ROWID BETWEEN '<start_rowid>' AND '<end_rowid>'"
+			}
+			if globalSCN == 0 {
+				if globalSCN, err = r.oracle.GetOracleGlobalSCN(r.ctx, r.metaDB, r.cfg.OracleConfig.SchemaName, common.CSVO2MMode, r.cfg.CSVConfig.ConsistentSnapshot); err != nil {
+					return nil, err
+				}
+			}
+			tbl.SampleChunk = rowidInfo
+			tbl.SampleSourceSQL = r.genTableChunkSQL(meta.FullSyncMeta{
+				SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
+				TableNameS:  common.StringUPPER(t),
+				GlobalScnS:  globalSCN,
+				ColumnInfoS: sourceColumnInfo,
+				RowidInfoS:  rowidInfo,
+			}, tableRule.Where)
+			tbl.SampleTargetSQL = r.genDataFileName(t, targetTableName, 0)
+		}
+		pl.AddTable(tbl)
+	}
+	return pl, nil
+}
+
+// 输出目录检查，本地目录不存在时实际运行自动创建，对象存储 dry-run 不访问
+func (r *O2M) checkOutputDir() (string, error) {
+	outputDir := r.cfg.CSVConfig.OutputDir
+	if outputDir == "" {
+		return outputDir, fmt.Errorf("csv config paramter output-dir can't be null, please configure")
+	}
+	if strings.Contains(outputDir, "://") && !strings.HasPrefix(strings.ToLower(outputDir), "file://") && !strings.HasPrefix(strings.ToLower(outputDir), "local://") {
+		return common.StringsBuilder(outputDir, " (object storage, not accessed)"), nil
+	}
+	localDir := outputDir
+	if idx := strings.Index(outputDir, "://"); idx >= 0 {
+		localDir = outputDir[idx+3:]
+	}
+	if _, err := os.Stat(localDir); err != nil {
+		if os.IsNotExist(err) {
+			return common.StringsBuilder(outputDir, " (not exist, will be created)"), nil
+		}
+		return outputDir, err
+	}
+	return outputDir, nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/logger"
+	"github.com/wentaojin/transferdb/module/reverse"
+	reverseO2M "github.com/wentaojin/transferdb/module/reverse/o2m"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"time"
+)
+
+// Lightning 目录格式库、表结构文件，建表语句基于 reverse 转换规则
+// {target_schema}-schema-create.sql
+// {target_schema}.{target_table}-schema.sql
+func (r *O2M) genLightningSchemaFile(exporters []string, tableNameRule map[string]string) error {
+	startTime := time.Now()
+
+	dialect, err := reverse.NewDialect(r.cfg.MySQLConfig.DBType)
+	if err != nil {
+		return err
+	}
+	nlsComp, err := r.oracle.GetOracleDBCharacterNLSCompCollation()
+	if err != nil {
+		return err
+	}
+	nlsSort, err := r.oracle.GetOracleDBCharacterNLSSortCollation()
+	if err != nil {
+		return err
+	}
+
+	targetSchema := common.StringUPPER(r.cfg.MySQLConfig.SchemaName)
+
+	createSchema, err := reverseO2M.GenCreateSchemaDDL(r.oracle, dialect,
+		common.StringUPPER(r.cfg.OracleConfig.SchemaName), targetSchema, nlsComp)
+	if err != nil {
+		return err
+	}
+	if err = r.storage.WriteFile(r.ctx, common.StringsBuilder(targetSchema, `-schema-create.sql`), []byte(createSchema+"\n")); err != nil {
+		return err
+	}
+
+	tableOptionRuleMap, err := reverseO2M.GenTableOptionRuleMap(r.ctx, r.metaDB, r.cfg.MySQLConfig.DBType, r.cfg.OracleConfig.SchemaName)
+	if err != nil {
+		return err
+	}
+
+	// 表结构仅依据 Oracle 元数据以及转换规则生成，无需连接目标端
+	tables, err := reverseO2M.GenReverseTableTask(r.ctx, r.cfg, nil, r.oracle, dialect, tableNameRule, tableOptionRuleMap, exporters, nlsSort, nlsComp)
+	if err != nil {
+		return err
+	}
+
+	g := &errgroup.Group{}
+	g.SetLimit(r.cfg.CSVConfig.TaskThreads)
+
+	for _, table := range tables {
+		t := table
+		g.Go(func() error {
+			rule, err := reverseO2M.IReader(r.ctx, r.metaDB, t, t)
+			if err != nil {
+				return fmt.Errorf("csv lightning schema file table [%s] reader failed: %v", t.SourceTableName, err)
+			}
+			ddl, err := reverseO2M.IReverse(t, rule)
+			if err != nil {
+				return fmt.Errorf("csv lightning schema file table [%s] reverse failed: %v", t.SourceTableName, err)
+			}
+
+			// 外键、检查约束以及不兼容项不输出，需参考 reverse 模式人工处理
+			if len(ddl.ForeignKeyDDL) > 0 || len(ddl.CheckKeyDDL) > 0 || len(ddl.CompatibleDDL) > 0 {
+				logger.L(r.ctx).Warn("csv lightning schema file skip foreign key, check key and compatible sql",
+					logger.SchemaS(t.SourceSchemaName),
+					logger.TableS(t.SourceTableName),
+					zap.String("suggest", "if necessary, please running reverse mode and manually process"))
+			}
+
+			return r.storage.WriteFile(r.ctx,
+				common.StringsBuilder(ddl.TargetSchemaName, `.`, ddl.TargetTableName, `-schema.sql`), []byte(ddl.GenTableDDL()))
+		})
+	}
+
+	if err = g.Wait(); err != nil {
+		return err
+	}
+
+	logger.L(r.ctx).Info("csv lightning schema file finished",
+		zap.Int("table counts", len(tables)),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
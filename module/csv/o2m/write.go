@@ -16,68 +16,113 @@ limitations under the License.
 package o2m
 
 import (
-	"bufio"
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"github.com/shopspring/decimal"
-	"github.com/thinkeridea/go-extend/exstrings"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/logger"
+	"github.com/wentaojin/transferdb/module/csv"
+	"github.com/wentaojin/transferdb/storage"
 	"go.uber.org/zap"
+	"hash"
 	"io"
-	"os"
-	"path/filepath"
+	"path"
+	"strconv"
 	"strings"
 )
 
 type File struct {
-	SourceSchema     string   `json:"source_schema"`
-	SourceCharset    string   `json:"source_charset"`
-	SourceTable      string   `json:"source_table"`
-	SourceColumns    []string `json:"source_columns"`
-	QuerySQL         string   `json:"query_sql"`
-	FileName         string   `json:"file_name"`
-	config.CSVConfig `json:"-"`
-	Rows             *sql.Rows `json:"-"`
+	SourceSchema      string              `json:"source_schema"`
+	SourceCharset     string              `json:"source_charset"`
+	SourceTable       string              `json:"source_table"`
+	SourceColumns     []string            `json:"source_columns"`
+	SourceColumnsINFO []map[string]string `json:"-"`
+	TargetSchema      string              `json:"target_schema"`
+	TargetTable       string              `json:"target_table"`
+	QuerySQL          string              `json:"query_sql"`
+	FileName          string              `json:"file_name"`
+	config.CSVConfig  `json:"-"`
+	Rows              *sql.Rows               `json:"-"`
+	Ctx               context.Context         `json:"-"`
+	Storage           storage.ExternalStorage `json:"-"`
+	// 断点续传已完成分片文件以及分片完成回调
+	FinishedParts []ManifestFile                   `json:"-"`
+	RecordParts   func(parts []ManifestFile) error `json:"-"`
 }
 
-func NewWriter(sourceSchema, sourceTable, sourceCharSet, querySQL, fileName string, sourceColumns []string, csvConfig config.CSVConfig, rows *sql.Rows) *File {
+func NewWriter(ctx context.Context, extStorage storage.ExternalStorage, sourceSchema, sourceTable, targetSchema, targetTable, sourceCharSet, querySQL, fileName string, sourceColumns []string, sourceColumnsINFO []map[string]string, csvConfig config.CSVConfig, rows *sql.Rows) *File {
 	return &File{
-		SourceSchema:  sourceSchema,
-		SourceTable:   sourceTable,
-		SourceCharset: sourceCharSet,
-		SourceColumns: sourceColumns,
-		QuerySQL:      querySQL,
-		FileName:      fileName,
-		CSVConfig:     csvConfig,
-		Rows:          rows,
+		SourceSchema:      sourceSchema,
+		SourceTable:       sourceTable,
+		TargetSchema:      targetSchema,
+		TargetTable:       targetTable,
+		SourceCharset:     sourceCharSet,
+		SourceColumns:     sourceColumns,
+		SourceColumnsINFO: sourceColumnsINFO,
+		QuerySQL:          querySQL,
+		FileName:          fileName,
+		CSVConfig:         csvConfig,
+		Rows:              rows,
+		Ctx:               ctx,
+		Storage:           extStorage,
 	}
 }
-func (f *File) WriteFile() error {
-	if err := f.adjustCSVConfig(); err != nil {
-		return err
+
+// 断点续传：已完成分片文件保留并跳过对应行数，仅续写后续分片
+//   - 分片完成即回调 RecordParts 记录，chunk 中断后依据记录续传
+//   - 跳过行数要求重新查询行顺序一致，仅限制文件大小且一致性快照读（ORDER BY ROWID）时生效，否则整个 chunk 重新导出
+func (f *File) WithResume(finishedParts []ManifestFile, recordParts func(parts []ManifestFile) error) *File {
+	if f.MaxFileSize > 0 && f.ConsistentSnapshot {
+		f.FinishedParts = finishedParts
+		f.RecordParts = recordParts
 	}
+	return f
+}
 
-	// 文件目录判断
-	if err := common.PathExist(
-		filepath.Join(
-			f.CSVConfig.OutputDir,
-			strings.ToUpper(f.SourceSchema),
-			strings.ToUpper(f.SourceTable))); err != nil {
-		return err
+// 数据写入分片文件，返回各分片文件清单记录
+func (f *File) WriteFile() ([]ManifestFile, error) {
+	if err := f.adjustCSVConfig(); err != nil {
+		return nil, err
 	}
 
-	fileW, err := os.OpenFile(f.FileName, os.O_WRONLY|os.O_CREATE|os.O_APPEND|os.O_TRUNC, 0666)
+	finished, err := f.checkFinishedParts()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer fileW.Close()
 
-	if err = f.write(fileW); err != nil {
-		return err
+	// 清理 chunk 未完成分片文件（断点续传中断残留）
+	if err = f.removePartFiles(finished); err != nil {
+		return nil, err
 	}
-	return nil
+
+	return f.write(finished)
+}
+
+// 已完成分片需按序号连续且文件存在，缺失分片及其后分片重新导出
+func (f *File) checkFinishedParts() ([]ManifestFile, error) {
+	var finished []ManifestFile
+	for i, part := range f.FinishedParts {
+		fileName, err := f.genPartFileName(i + 1)
+		if err != nil {
+			return nil, err
+		}
+		if path.Base(fileName) != part.FileName {
+			break
+		}
+		exist, err := f.Storage.FileExists(f.Ctx, fileName)
+		if err != nil {
+			return nil, err
+		}
+		if !exist {
+			break
+		}
+		finished = append(finished, part)
+	}
+	return finished, nil
 }
 
 func (f *File) adjustCSVConfig() error {
@@ -87,50 +132,60 @@ func (f *File) adjustCSVConfig() error {
 	if f.Terminator == "" {
 		f.Terminator = "\r\n"
 	}
-	if f.Charset == "" {
-		if val, ok := common.OracleDBCSVCharacterSetMap[strings.ToUpper(f.SourceCharset)]; ok {
-			f.Charset = val
-		} else {
-			return fmt.Errorf("oracle db csv characterset [%v] isn't support", f.SourceCharset)
-		}
-	}
-	isSupport := false
-	if f.Charset != "" {
-		switch strings.ToUpper(f.Charset) {
-		case common.UTF8CharacterSetCSV:
-			isSupport = true
-		case common.GBKCharacterSetCSV:
-			isSupport = true
-		default:
-			isSupport = false
-		}
+	charset, err := genCSVCharset(f.Charset, f.SourceCharset)
+	if err != nil {
+		return err
 	}
-	if !isSupport {
-		return fmt.Errorf("target db character is not support: [%s]", f.Charset)
+	f.Charset = charset
+
+	switch common.StringUPPER(f.BinaryFormat) {
+	case "", common.CSVBinaryFormatRaw, common.CSVBinaryFormatHex, common.CSVBinaryFormatBase64:
+	default:
+		return fmt.Errorf("csv config binary-format [%s] isn't support, only support [raw hex base64]", f.BinaryFormat)
 	}
 	return nil
 }
 
-func (f *File) write(w io.Writer) error {
-	writer := bufio.NewWriter(w)
-	if f.Header {
-		if _, err := writer.WriteString(common.StringsBuilder(exstrings.Join(f.SourceColumns, f.Separator), f.Terminator)); err != nil {
-			return fmt.Errorf("failed to write headers: %v", err)
+// 数据文件字符集，未配置 charset 依据源端字符集
+func genCSVCharset(charset, sourceCharset string) (string, error) {
+	if charset == "" {
+		if val, ok := common.OracleDBCSVCharacterSetMap[strings.ToUpper(sourceCharset)]; ok {
+			charset = val
+		} else {
+			return charset, fmt.Errorf("oracle db csv characterset [%v] isn't support", sourceCharset)
 		}
 	}
+	switch strings.ToUpper(charset) {
+	case common.UTF8CharacterSetCSV, common.GBKCharacterSetCSV:
+		return charset, nil
+	default:
+		return charset, fmt.Errorf("target db character is not support: [%s]", charset)
+	}
+}
 
+func (f *File) write(finished []ManifestFile) ([]ManifestFile, error) {
 	// 统计行数
 	var rowCount int
 
-	var columnTypes []string
+	// 已完成分片行数
+	var skipRows int64
+	for _, part := range finished {
+		skipRows += part.Rows
+	}
+
+	var (
+		columnTypes   []string
+		databaseTypes []string
+	)
 	colTypes, err := f.Rows.ColumnTypes()
 	if err != nil {
-		return fmt.Errorf("failed to csv get rows columnTypes: %v", err)
+		return nil, fmt.Errorf("failed to csv get rows columnTypes: %v", err)
 	}
 
 	for _, ct := range colTypes {
 		// 数据库字段类型 DatabaseTypeName() 映射 go 类型 ScanType()
 		columnTypes = append(columnTypes, ct.ScanType().String())
+		databaseTypes = append(databaseTypes, ct.DatabaseTypeName())
 	}
 
 	// 数据 SCAN
@@ -141,137 +196,259 @@ func (f *File) write(w io.Writer) error {
 		dest[i] = &rawResult[i]
 	}
 
+	var (
+		parts   []ManifestFile
+		part    *partFile
+		partNum int
+	)
+	parts = append(parts, finished...)
+	partNum = len(finished)
+
+	// 数据为空同样输出文件
+	if len(finished) == 0 {
+		partNum = partNum + 1
+		part, err = f.newPartFile(partNum, columnTypes, databaseTypes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	defer func() {
+		if part != nil {
+			part.abort()
+		}
+	}()
+
 	// 表行数读取
 	for f.Rows.Next() {
 		rowCount = rowCount + 1
 
-		var results []string
-
 		err = f.Rows.Scan(dest...)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		for i, raw := range rawResult {
-			// 注意 Oracle/Mysql NULL VS 空字符串区别
-			// Oracle 空字符串与 NULL 归于一类，统一 NULL 处理 （is null 可以查询 NULL 以及空字符串值，空字符串查询无法查询到空字符串值）
-			// Mysql 空字符串与 NULL 非一类，NULL 是 NULL，空字符串是空字符串（is null 只查询 NULL 值，空字符串查询只查询到空字符串值）
-			// 按照 Oracle 特性来，转换同步统一转换成 NULL 即可，但需要注意业务逻辑中空字符串得写入，需要变更
-			// Oracle/Mysql 对于 'NULL' 统一字符 NULL 处理，查询出来转成 NULL,所以需要判断处理
-			if raw == nil {
-				results = append(results, "NULL")
-			} else if string(raw) == "" {
-				results = append(results, "NULL")
-			} else {
-				switch columnTypes[i] {
-				case "int64":
-					r, err := common.StrconvIntBitSize(string(raw), 64)
-					if err != nil {
-						return err
-					}
-					results = append(results, fmt.Sprintf("%v", r))
-				case "uint64":
-					r, err := common.StrconvUintBitSize(string(raw), 64)
-					if err != nil {
-						return err
-					}
-					results = append(results, fmt.Sprintf("%v", r))
-				case "float32":
-					r, err := common.StrconvFloatBitSize(string(raw), 32)
-					if err != nil {
-						return err
-					}
-					results = append(results, fmt.Sprintf("%v", r))
-				case "float64":
-					r, err := common.StrconvFloatBitSize(string(raw), 64)
-					if err != nil {
-						return err
-					}
-					results = append(results, fmt.Sprintf("%v", r))
-				case "rune":
-					r, err := common.StrconvRune(string(raw))
-					if err != nil {
-						return err
-					}
-					results = append(results, fmt.Sprintf("%v", r))
-				case "godror.Number":
-					r, err := decimal.NewFromString(string(raw))
-					if err != nil {
-						return err
-					}
-					if r.IsInteger() {
-						si, err := common.StrconvIntBitSize(string(raw), 64)
-						if err != nil {
-							return err
-						}
-						results = append(results, fmt.Sprintf("%v", si))
-					} else {
-						rf, err := common.StrconvFloatBitSize(string(raw), 64)
-						if err != nil {
-							return err
-						}
-						results = append(results, fmt.Sprintf("%v", rf))
-					}
-				default:
-					var (
-						by []byte
-						bs string
-					)
-					// 处理字符集、特殊字符转义、字符串引用定界符
-					if strings.ToUpper(f.Charset) == common.GBKCharacterSetCSV {
-						gbkBytes, err := common.Utf8ToGbk(raw)
-						if err != nil {
-							return err
-						}
-						by = gbkBytes
-					} else {
-						by = raw
-					}
-
-					if f.EscapeBackslash {
-						bs = common.SpecialLettersUsingMySQL(by)
-					} else {
-						bs = string(by)
-					}
-
-					if f.Delimiter == "" {
-						results = append(results, bs)
-					} else {
-						results = append(results, common.StringsBuilder(f.Delimiter, bs, f.Delimiter))
-					}
-				}
+		// 已完成分片行跳过
+		if int64(rowCount) <= skipRows {
+			continue
+		}
+
+		if part == nil {
+			partNum = partNum + 1
+			part, err = f.newPartFile(partNum, columnTypes, databaseTypes)
+			if err != nil {
+				return nil, err
 			}
 		}
 
 		// 写入文件
-		if _, err = writer.WriteString(common.StringsBuilder(exstrings.Join(results, f.Separator), f.Terminator)); err != nil {
-			return fmt.Errorf("failed to write data row to csv %w", err)
+		if err = part.Encode(rawResult); err != nil {
+			return nil, err
+		}
+
+		// 文件大小达到上限，切换分片文件（以行为边界，大小以落盘字节计，压缩、编码缓存可能略超上限）
+		if f.MaxFileSize > 0 && part.Size() >= int64(f.MaxFileSize)*1024*1024 {
+			mf, err := part.Close()
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, mf)
+			part = nil
+
+			if f.RecordParts != nil {
+				if err = f.RecordParts(parts); err != nil {
+					return nil, err
+				}
+			}
 		}
 	}
 
-	if err := f.Rows.Err(); err != nil {
-		return err
+	if err = f.Rows.Err(); err != nil {
+		return nil, err
 	}
 
-	if err := writer.Flush(); err != nil {
-		return fmt.Errorf("failed to flush data row to csv %w", err)
+	if int64(rowCount) < skipRows {
+		return nil, fmt.Errorf("csv file [%s] resume failed: finished part files rows [%d] more than query rows [%d], please set enable-checkpoint = false and rerun", f.FileName, skipRows, rowCount)
+	}
+
+	if part != nil {
+		mf, err := part.Close()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, mf)
+		part = nil
 	}
 
 	// Close Rows
-	if err := f.Rows.Close(); err != nil {
-		return err
+	if err = f.Rows.Close(); err != nil {
+		return nil, err
 	}
 
-	zap.L().Info("oracle schema table rowid data rows",
-		zap.String("schema", f.SourceSchema),
-		zap.String("table", f.SourceTable),
+	logger.L(f.Ctx).Info("oracle schema table rowid data rows",
 		zap.Int("rows", rowCount),
+		zap.Int64("skip rows", skipRows),
+		zap.Int("files", len(parts)),
 		zap.String("query sql", f.QuerySQL),
 		zap.String("detail", f.String()))
 
+	return parts, nil
+}
+
+// 分片文件名
+//   - 未限制文件大小：{file_name}{compress_suffix}
+//   - 限制文件大小：{schema}.{table}.{serial}.{part}.{format}{compress_suffix}
+//   - Lightning 目录格式：分片序号拼接至 serial，{schema}.{table}.{serial}{part:4}.{format}{compress_suffix}
+func (f *File) genPartFileName(part int) (string, error) {
+	suffix, err := csv.CompressFileSuffix(f.Compress)
+	if err != nil {
+		return "", err
+	}
+	if f.MaxFileSize <= 0 {
+		return common.StringsBuilder(f.FileName, suffix), nil
+	}
+	ext := path.Ext(f.FileName)
+	prefix := strings.TrimSuffix(f.FileName, ext)
+	if f.LightningLayout {
+		return common.StringsBuilder(prefix, fmt.Sprintf("%04d", part), ext, suffix), nil
+	}
+	return common.StringsBuilder(prefix, `.`, strconv.Itoa(part), ext, suffix), nil
+}
+
+func (f *File) removePartFiles(finished []ManifestFile) error {
+	suffix, err := csv.CompressFileSuffix(f.Compress)
+	if err != nil {
+		return err
+	}
+	ext := path.Ext(f.FileName)
+	prefix := strings.TrimSuffix(f.FileName, ext)
+
+	var pattern string
+	if f.MaxFileSize <= 0 {
+		pattern = common.StringsBuilder(f.FileName, suffix)
+	} else if f.LightningLayout {
+		pattern = common.StringsBuilder(prefix, `[0-9][0-9][0-9][0-9]*`, ext, suffix)
+	} else {
+		pattern = common.StringsBuilder(prefix, `.[0-9]*`, ext, suffix)
+	}
+	var files []string
+	err = f.Storage.WalkDir(f.Ctx, path.Dir(f.FileName), func(name string, size int64) error {
+		match, err := path.Match(pattern, name)
+		if err != nil {
+			return err
+		}
+		if match && !isFinishedPart(finished, name) {
+			files = append(files, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err = f.Storage.DeleteFile(f.Ctx, file); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+func isFinishedPart(finished []ManifestFile, name string) bool {
+	for _, part := range finished {
+		if part.FileName == path.Base(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// 分片文件写入链路 encoder -> compressor -> counter(字节数、校验和) -> storage file
+type partFile struct {
+	fileName   string
+	file       storage.FileWriter
+	counter    *countWriter
+	compressor io.WriteCloser
+	encoder    Encoder
+	rows       int64
+}
+
+func (f *File) newPartFile(part int, columnTypes, databaseTypes []string) (*partFile, error) {
+	fileName, err := f.genPartFileName(part)
+	if err != nil {
+		return nil, err
+	}
+	fileW, err := f.Storage.Create(f.Ctx, fileName)
+	if err != nil {
+		return nil, err
+	}
+	counter := &countWriter{w: fileW, hash: sha256.New()}
+	compressor, err := csv.NewCompressWriter(counter, f.Compress)
+	if err != nil {
+		fileW.Abort()
+		return nil, err
+	}
+	encoder, err := f.NewEncoder(compressor, columnTypes, databaseTypes)
+	if err != nil {
+		fileW.Abort()
+		return nil, err
+	}
+	return &partFile{
+		fileName:   fileName,
+		file:       fileW,
+		counter:    counter,
+		compressor: compressor,
+		encoder:    encoder,
+	}, nil
+}
+
+func (p *partFile) Encode(raws [][]byte) error {
+	p.rows = p.rows + 1
+	return p.encoder.Encode(raws)
+}
+
+func (p *partFile) Size() int64 {
+	return p.counter.bytes
+}
+
+func (p *partFile) Close() (ManifestFile, error) {
+	if err := p.encoder.Close(); err != nil {
+		return ManifestFile{}, err
+	}
+	if err := p.compressor.Close(); err != nil {
+		return ManifestFile{}, err
+	}
+	if err := p.file.Close(); err != nil {
+		return ManifestFile{}, err
+	}
+	return ManifestFile{
+		FileName: path.Base(p.fileName),
+		Rows:     p.rows,
+		Bytes:    p.counter.bytes,
+		Checksum: hex.EncodeToString(p.counter.hash.Sum(nil)),
+	}, nil
+}
+
+// 写入失败丢弃文件（对象存储取消 multipart upload）
+func (p *partFile) abort() {
+	if err := p.file.Abort(); err != nil {
+		zap.L().Warn("csv file abort failed",
+			zap.String("file", p.fileName),
+			zap.Error(err))
+	}
+}
+
+type countWriter struct {
+	w     io.Writer
+	hash  hash.Hash
+	bytes int64
+}
+
+func (c *countWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.bytes += int64(n)
+	c.hash.Write(b[:n])
+	return n, err
+}
+
 func (f *File) String() string {
 	jsonStr, _ := json.Marshal(f)
 	return string(jsonStr)
@@ -0,0 +1,249 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package csv
+
+import (
+	"fmt"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+	"io"
+	"strings"
+)
+
+/*
+Parquet 文件写入，基于 xitongsys/parquet-go，字段统一 OPTIONAL、SNAPPY 压缩，字段顺序与写入字段一致
+*/
+
+// Parquet 物理类型
+const (
+	ParquetTypeBoolean   int32 = 0
+	ParquetTypeInt32     int32 = 1
+	ParquetTypeInt64     int32 = 2
+	ParquetTypeFloat     int32 = 4
+	ParquetTypeDouble    int32 = 5
+	ParquetTypeByteArray int32 = 6
+)
+
+// Parquet 逻辑类型（ConvertedType），-1 表示无
+const (
+	ParquetConvertedNone            int32 = -1
+	ParquetConvertedUTF8            int32 = 0
+	ParquetConvertedDecimal         int32 = 5
+	ParquetConvertedDate            int32 = 6
+	ParquetConvertedTimestampMicros int32 = 10
+)
+
+const (
+	parquetCreatedBy        = "transferdb"
+	parquetDefaultGroupRows = 65536
+	parquetDefaultGroupSize = 128 * 1024 * 1024
+	parquetMarshalThreads   = 1
+)
+
+type ParquetColumn struct {
+	Name          string
+	Type          int32
+	ConvertedType int32
+	Precision     int32
+	Scale         int32
+}
+
+type ParquetWriter struct {
+	writer    *writer.CSVWriter
+	columns   []ParquetColumn
+	groupRows int
+	groupSize int64
+	bufRows   int
+	bufSize   int64
+}
+
+// groupRows 单个 row group 行数，groupSize 单个 row group 缓存字节数（未压缩字段值估算），小于等于 0 使用默认值
+// 行数或者字节数任一达到上限即写出 row group，文件大小只在 row group 写出后增长
+func NewParquetWriter(w io.Writer, columns []ParquetColumn, groupRows int, groupSize int64) (*ParquetWriter, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("parquet writer columns can't be null")
+	}
+	if groupRows <= 0 {
+		groupRows = parquetDefaultGroupRows
+	}
+	if groupSize <= 0 {
+		groupSize = parquetDefaultGroupSize
+	}
+
+	var metadata []string
+	for _, column := range columns {
+		md, err := genParquetMetadata(column)
+		if err != nil {
+			return nil, err
+		}
+		metadata = append(metadata, md)
+	}
+
+	pw, err := writer.NewCSVWriterFromWriter(metadata, w, parquetMarshalThreads)
+	if err != nil {
+		return nil, fmt.Errorf("parquet writer create failed: %v", err)
+	}
+	createdBy := parquetCreatedBy
+	pw.Footer.CreatedBy = &createdBy
+
+	return &ParquetWriter{
+		writer:    pw,
+		columns:   columns,
+		groupRows: groupRows,
+		groupSize: groupSize,
+	}, nil
+}
+
+// 字段值 nil 表示 NULL，其余值类型需与字段物理类型一致
+// BOOLEAN -> bool，INT32 -> int32，INT64 -> int64，FLOAT -> float32，DOUBLE -> float64，BYTE_ARRAY -> []byte/string
+// DECIMAL -> 非标度值大端序二进制补码 []byte
+func (p *ParquetWriter) Write(row []interface{}) error {
+	if len(row) != len(p.columns) {
+		return fmt.Errorf("parquet writer row values [%d] isn't equal columns [%d]", len(row), len(p.columns))
+	}
+	// parquet-go 写入缓存字段值，需独立 row
+	values := make([]interface{}, len(row))
+	for i, val := range row {
+		if val == nil {
+			continue
+		}
+		v, err := p.genValue(i, val)
+		if err != nil {
+			return err
+		}
+		values[i] = v
+		p.bufSize += genValueSize(v)
+	}
+	if err := p.writer.Write(values); err != nil {
+		return err
+	}
+	p.bufRows++
+
+	if p.bufRows >= p.groupRows || p.bufSize >= p.groupSize {
+		p.bufRows = 0
+		p.bufSize = 0
+		return p.writer.Flush(true)
+	}
+	return nil
+}
+
+func (p *ParquetWriter) Close() error {
+	return p.writer.WriteStop()
+}
+
+func (p *ParquetWriter) genValue(idx int, val interface{}) (interface{}, error) {
+	column := p.columns[idx]
+
+	switch column.Type {
+	case ParquetTypeBoolean:
+		if _, ok := val.(bool); !ok {
+			return nil, fmt.Errorf("parquet column [%s] value [%v] isn't bool", column.Name, val)
+		}
+	case ParquetTypeInt32:
+		if _, ok := val.(int32); !ok {
+			return nil, fmt.Errorf("parquet column [%s] value [%v] isn't int32", column.Name, val)
+		}
+	case ParquetTypeInt64:
+		if _, ok := val.(int64); !ok {
+			return nil, fmt.Errorf("parquet column [%s] value [%v] isn't int64", column.Name, val)
+		}
+	case ParquetTypeFloat:
+		if _, ok := val.(float32); !ok {
+			return nil, fmt.Errorf("parquet column [%s] value [%v] isn't float32", column.Name, val)
+		}
+	case ParquetTypeDouble:
+		if _, ok := val.(float64); !ok {
+			return nil, fmt.Errorf("parquet column [%s] value [%v] isn't float64", column.Name, val)
+		}
+	case ParquetTypeByteArray:
+		// parquet-go BYTE_ARRAY 值类型 string
+		switch v := val.(type) {
+		case []byte:
+			return string(v), nil
+		case string:
+			return v, nil
+		default:
+			return nil, fmt.Errorf("parquet column [%s] value [%v] isn't byte array", column.Name, val)
+		}
+	default:
+		return nil, fmt.Errorf("parquet column [%s] type [%d] isn't support", column.Name, column.Type)
+	}
+	return val, nil
+}
+
+// 字段值缓存字节数估算，定长类型按物理类型长度
+func genValueSize(val interface{}) int64 {
+	switch v := val.(type) {
+	case bool:
+		return 1
+	case int32, float32:
+		return 4
+	case string:
+		return int64(len(v))
+	default:
+		return 8
+	}
+}
+
+// parquet-go 字段元数据，name=ID, type=INT64, repetitiontype=OPTIONAL
+func genParquetMetadata(column ParquetColumn) (string, error) {
+	// 元数据以逗号、等号分隔，字段名不允许包含
+	if column.Name == "" || strings.ContainsAny(column.Name, ",=") {
+		return "", fmt.Errorf("parquet column name [%s] isn't support", column.Name)
+	}
+
+	var physicalType string
+	switch column.Type {
+	case ParquetTypeBoolean:
+		physicalType = parquet.Type_BOOLEAN.String()
+	case ParquetTypeInt32:
+		physicalType = parquet.Type_INT32.String()
+	case ParquetTypeInt64:
+		physicalType = parquet.Type_INT64.String()
+	case ParquetTypeFloat:
+		physicalType = parquet.Type_FLOAT.String()
+	case ParquetTypeDouble:
+		physicalType = parquet.Type_DOUBLE.String()
+	case ParquetTypeByteArray:
+		physicalType = parquet.Type_BYTE_ARRAY.String()
+	default:
+		return "", fmt.Errorf("parquet column [%s] type [%d] isn't support", column.Name, column.Type)
+	}
+
+	metadata := []string{
+		fmt.Sprintf("name=%s", column.Name),
+		fmt.Sprintf("type=%s", physicalType),
+		fmt.Sprintf("repetitiontype=%s", parquet.FieldRepetitionType_OPTIONAL.String()),
+	}
+	switch column.ConvertedType {
+	case ParquetConvertedNone:
+	case ParquetConvertedUTF8:
+		metadata = append(metadata, fmt.Sprintf("convertedtype=%s", parquet.ConvertedType_UTF8.String()))
+	case ParquetConvertedDecimal:
+		if column.Precision <= 0 || column.Scale < 0 || column.Scale > column.Precision {
+			return "", fmt.Errorf("parquet column [%s] decimal precision [%d] scale [%d] isn't valid", column.Name, column.Precision, column.Scale)
+		}
+		metadata = append(metadata, fmt.Sprintf("convertedtype=%s", parquet.ConvertedType_DECIMAL.String()),
+			fmt.Sprintf("precision=%d", column.Precision), fmt.Sprintf("scale=%d", column.Scale))
+	case ParquetConvertedDate:
+		metadata = append(metadata, fmt.Sprintf("convertedtype=%s", parquet.ConvertedType_DATE.String()))
+	case ParquetConvertedTimestampMicros:
+		metadata = append(metadata, fmt.Sprintf("convertedtype=%s", parquet.ConvertedType_TIMESTAMP_MICROS.String()))
+	default:
+		return "", fmt.Errorf("parquet column [%s] converted type [%d] isn't support", column.Name, column.ConvertedType)
+	}
+	return strings.Join(metadata, ", "), nil
+}
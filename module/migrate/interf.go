@@ -16,7 +16,7 @@ limitations under the License.
 package migrate
 
 type Extractor interface {
-	GetTableRows() ([]string, []string, error)
+	GetTableRows() ([]string, [][]string, error)
 }
 
 type Translator interface {
@@ -0,0 +1,201 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"github.com/wentaojin/transferdb/common"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// 根据统计信息行数以及平均行长度规划 chunk 行数
+//   - 按 chunk 目标数据量（MB）/ 平均行长度计算行数
+//   - 大表 chunk 数不少于 sql-threads，保证表内并发
+//   - 不小于 insert-batch-size 且按 insert-batch-size 向上取整
+//
+// 无平均行长度统计信息返回 chunk-size
+func PlanChunkSize(tableRows, avgRowLen, chunkSize, chunkTargetSize, sqlThreads, batchSize int) int {
+	if avgRowLen <= 0 {
+		return chunkSize
+	}
+	if chunkTargetSize <= 0 {
+		chunkTargetSize = common.FullDefaultChunkTargetSize
+	}
+	rows := chunkTargetSize * 1024 * 1024 / avgRowLen
+
+	if tableRows > 0 && sqlThreads > 0 {
+		perThreadRows := (tableRows + sqlThreads - 1) / sqlThreads
+		if rows > perThreadRows {
+			rows = perThreadRows
+		}
+	}
+
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	if rows < batchSize {
+		rows = batchSize
+	}
+	if rows%batchSize != 0 {
+		rows = (rows/batchSize + 1) * batchSize
+	}
+	return rows
+}
+
+// 判断目标端繁忙、锁冲突以及 backoff 类错误
+func IsBusyError(err error) bool {
+	e, ok := lookupRetryableError(err)
+	return ok && e.Busy
+}
+
+// 自适应并发控制器，并发数在 [min, max] 区间内运行期动态调整
+//   - 每观测窗口统计单位耗时（耗时 / 处理量），窗口样本数不小于当前并发数
+//   - 窗口内出现繁忙错误，并发减半
+//   - 平均单位耗时超过基线 2 倍，并发减少 1/4
+//   - 平均单位耗时不超过基线 1.25 倍，并发增加 1/8（至少 1）
+//
+// nil 控制器表示未开启自适应并发，Acquire/Release 不做限制
+type ConcurrencyController struct {
+	name string
+	min  int
+	max  int
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	limit    int
+	active   int
+	samples  int
+	unitCost time.Duration
+	busyErrs int
+	baseline time.Duration
+}
+
+func NewConcurrencyController(name string, min, max int) *ConcurrencyController {
+	if min <= 0 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	limit := (max + 1) / 2
+	if limit < min {
+		limit = min
+	}
+	c := &ConcurrencyController{
+		name:  name,
+		min:   min,
+		max:   max,
+		limit: limit,
+	}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// 获取执行许可，当前执行数达到并发数则等待
+func (c *ConcurrencyController) Acquire() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	for c.active >= c.limit {
+		c.cond.Wait()
+	}
+	c.active++
+	c.mu.Unlock()
+}
+
+// 释放执行许可并反馈执行耗时、处理量以及执行错误
+func (c *ConcurrencyController) Release(cost time.Duration, units int, err error) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.active--
+	if units <= 0 {
+		units = 1
+	}
+	c.samples++
+	c.unitCost += cost / time.Duration(units)
+	if IsBusyError(err) {
+		c.busyErrs++
+	}
+
+	window := c.limit
+	if window < common.FullAdaptiveMinWindowSamples {
+		window = common.FullAdaptiveMinWindowSamples
+	}
+	if c.samples >= window {
+		c.adjust()
+	}
+	c.cond.Broadcast()
+}
+
+func (c *ConcurrencyController) Limit() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.limit
+}
+
+func (c *ConcurrencyController) adjust() {
+	avgCost := c.unitCost / time.Duration(c.samples)
+	oldLimit := c.limit
+
+	switch {
+	case c.busyErrs > 0:
+		c.limit = c.limit / 2
+	case c.baseline > 0 && avgCost > c.baseline*2:
+		c.limit = c.limit * 3 / 4
+	case c.baseline == 0 || avgCost*4 <= c.baseline*5:
+		step := c.limit / 8
+		if step < 1 {
+			step = 1
+		}
+		c.limit = c.limit + step
+	}
+	if c.limit < c.min {
+		c.limit = c.min
+	}
+	if c.limit > c.max {
+		c.limit = c.max
+	}
+
+	// 基线取观测最小单位耗时，并缓慢跟随负载变化
+	if c.baseline == 0 || avgCost < c.baseline {
+		c.baseline = avgCost
+	} else {
+		c.baseline = c.baseline + (avgCost-c.baseline)/20
+	}
+
+	if c.limit != oldLimit {
+		zap.L().Info("adaptive concurrency adjust",
+			zap.String("controller", c.name),
+			zap.Int("from", oldLimit),
+			zap.Int("to", c.limit),
+			zap.Int("busy errors", c.busyErrs),
+			zap.String("avg unit cost", avgCost.String()),
+			zap.String("baseline", c.baseline.String()))
+	}
+
+	c.samples = 0
+	c.unitCost = 0
+	c.busyErrs = 0
+}
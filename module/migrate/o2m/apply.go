@@ -24,9 +24,12 @@ import (
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/logger"
+	"github.com/wentaojin/transferdb/metrics"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	"sync"
+	"time"
 )
 
 type IncrTask struct {
@@ -60,6 +63,7 @@ func applyOracleIncrRecord(metaDB *meta.Meta, mysqlDB *mysql.MySQL, cfg *config.
 		sourceTable := tableName
 		g.Go(func() error {
 			if len(rowsResult) > 0 {
+				metrics.AddRows(common.AllO2MMode, cfg.OracleConfig.SchemaName, sourceTable, metrics.DirectionRead, int64(len(rowsResult)), 0)
 				var (
 					done        = make(chan bool)
 					taskQueue   = make(chan IncrTask, cfg.AllConfig.WorkerQueue)
@@ -73,8 +77,8 @@ func applyOracleIncrRecord(metaDB *meta.Meta, mysqlDB *mysql.MySQL, cfg *config.
 					defer func() {
 						if err := recover(); err != nil {
 							zap.L().Fatal("translatorAndApplyOracleIncrementRecord",
-								zap.String("oracle schema", cfg.OracleConfig.SchemaName),
-								zap.String("oracle table", sourceTable),
+								logger.SchemaS(cfg.OracleConfig.SchemaName),
+								logger.TableS(sourceTable),
 								zap.Error(fmt.Errorf("%v", err)))
 						}
 					}()
@@ -89,15 +93,15 @@ func applyOracleIncrRecord(metaDB *meta.Meta, mysqlDB *mysql.MySQL, cfg *config.
 				}(mysqlDB, cfg.OracleConfig.SchemaName, sourceTable, rowsResult, taskQueue)
 
 				// 必须在任务分配和获取结果后创建工作池
-				go createWorkerPool(cfg.AllConfig.WorkerThreads, taskQueue, resultQueue)
+				go createWorkerPool(cfg.OracleConfig.SchemaName, sourceTable, cfg.AllConfig.WorkerThreads, taskQueue, resultQueue)
 				// 等待执行完成
 				<-done
 
 				return nil
 			}
 			zap.L().Warn("increment table log file logminer null data, transferdb will continue to capture",
-				zap.String("oracle schema", cfg.OracleConfig.SchemaName),
-				zap.String("oracle table", sourceTable),
+				logger.SchemaS(cfg.OracleConfig.SchemaName),
+				logger.TableS(sourceTable),
 				zap.String("status", "success"))
 			return nil
 		})
@@ -121,20 +125,29 @@ func (p *IncrTask) IncrApply() error {
 		}
 		for _, sql := range p.MySQLRedo {
 			if _, err = txn.ExecContext(p.Ctx, sql); err != nil {
+				metrics.IncApplyError(common.AllO2MMode, p.SourceSchema, err)
 				return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql [%v] transaction doing falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
 			}
 		}
 		if err = txn.Commit(); err != nil {
+			metrics.IncApplyError(common.AllO2MMode, p.SourceSchema, err)
 			return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql [%v] transaction commit falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
 		}
 	} else {
 		for _, sql := range p.MySQLRedo {
 			_, err := p.MySQL.MySQLDB.ExecContext(p.Ctx, sql)
 			if err != nil {
+				metrics.IncApplyError(common.AllO2MMode, p.SourceSchema, err)
 				return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql [%v] exec falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
 			}
 		}
 	}
+	var bytes int
+	for _, sql := range p.MySQLRedo {
+		bytes += len(sql)
+	}
+	metrics.AddRows(common.AllO2MMode, p.SourceSchema, p.SourceTable, metrics.DirectionWrite, 1, int64(bytes))
+
 	// 数据写入完毕，更新元数据 checkpoint 表
 	// 如果同步中断，数据同步使用会以 global_scn_s 为准，也就是会进行重复消费
 	if p.Operation == common.MigrateOperationDropTable {
@@ -146,10 +159,13 @@ func (p *IncrTask) IncrApply() error {
 		}, &meta.WaitSyncMeta{
 			SchemaNameS: p.SourceSchema,
 			TableNameS:  p.SourceTable,
-			Mode:        common.AllO2MMode,
+			Mode:        common.FullO2MMode,
 		})
 		if err != nil {
-			zap.L().Error("update table increment scn record failed",
+			logger.L(p.Ctx).Error("update table increment scn record failed",
+				logger.SchemaS(p.SourceSchema),
+				logger.TableS(p.SourceTable),
+				logger.SCN(p.SourceTableSCN),
 				zap.String("task", p.String()),
 				zap.Error(err))
 			return err
@@ -164,7 +180,10 @@ func (p *IncrTask) IncrApply() error {
 			TableScnS:   p.SourceTableSCN,
 		})
 		if err != nil {
-			zap.L().Error("update table increment scn record failed",
+			logger.L(p.Ctx).Error("update table increment scn record failed",
+				logger.SchemaS(p.SourceSchema),
+				logger.TableS(p.SourceTable),
+				logger.SCN(p.SourceTableSCN),
 				zap.String("task", p.String()),
 				zap.Error(err))
 			return err
@@ -177,20 +196,38 @@ func (p *IncrTask) IncrApply() error {
 func (p *IncrTask) String() string {
 	b, err := json.Marshal(&p)
 	if err != nil {
-		zap.L().Error("marshal task to string",
+		logger.L(p.Ctx).Error("marshal task to string",
 			zap.String("string", string(b)),
 			zap.Error(err))
 	}
 	return string(b)
 }
 
-func createWorkerPool(numOfWorkers int, jobQueue chan IncrTask, resultQueue chan IncrResult) {
+func createWorkerPool(schemaName, tableName string, numOfWorkers int, jobQueue chan IncrTask, resultQueue chan IncrResult) {
 	var wg sync.WaitGroup
 	for i := 0; i < numOfWorkers; i++ {
 		wg.Add(1)
 		go worker(&wg, jobQueue, resultQueue)
 	}
+
+	// 定期采集工作池队列深度
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			metrics.SetIncrWorkerQueueDepth(schemaName, tableName, len(jobQueue))
+			select {
+			case <-done:
+				metrics.SetIncrWorkerQueueDepth(schemaName, tableName, 0)
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
 	wg.Wait()
+	close(done)
 	close(resultQueue)
 }
 
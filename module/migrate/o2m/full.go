@@ -23,6 +23,10 @@ import (
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/logger"
+	"github.com/wentaojin/transferdb/metrics"
+	"github.com/wentaojin/transferdb/progress"
+	"github.com/wentaojin/transferdb/signal"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	"strconv"
@@ -36,6 +40,11 @@ type Migrate struct {
 	oracle *oracle.Oracle
 	mysql  *mysql.MySQL
 	metaDB *meta.Meta
+	// 自适应并发控制器，未开启 adaptive-concurrency 为 nil
+	readController *ConcurrencyController
+	applyPolicy    ApplyPolicy
+	// 全量迁移进度
+	progress *progress.Tracker
 }
 
 func NewO2MFuller(ctx context.Context, cfg *config.Config, oracle *oracle.Oracle, mysql *mysql.MySQL, metaDB *meta.Meta) *Migrate {
@@ -50,38 +59,34 @@ func NewO2MFuller(ctx context.Context, cfg *config.Config, oracle *oracle.Oracle
 
 func (r *Migrate) NewFuller() error {
 	startTime := time.Now()
-	zap.L().Info("source schema full table data sync start",
-		zap.String("schema", r.cfg.OracleConfig.SchemaName))
+	logger.L(r.ctx).Info("source schema full table data sync start")
 
-	// 判断上游 Oracle 数据库版本
-	// 需要 oracle 11g 及以上
-	oracleDBVersion, err := r.oracle.GetOracleDBVersion()
+	oracleDBVersion, exporters, err := r.preCheck(common.FullO2MMode)
 	if err != nil {
 		return err
 	}
-	if common.VersionOrdinal(oracleDBVersion) < common.VersionOrdinal(common.RequireOracleDBVersion) {
-		return fmt.Errorf("oracle db version [%v] is less than 11g, can't be using transferdb tools", oracleDBVersion)
-	}
 	oracleCollation := false
 	if common.VersionOrdinal(oracleDBVersion) >= common.VersionOrdinal(common.OracleTableColumnCollationDBVersion) {
 		oracleCollation = true
 	}
 
-	// 获取配置文件待同步表列表
-	exporters, err := filterCFGTable(r.cfg, r.oracle)
+	loadData, err := r.checkApplyMode()
 	if err != nil {
 		return err
 	}
-
-	// 判断 error_log_detail 是否存在错误记录，是否可进行 CSV
-	errTotals, err := meta.NewErrorLogDetailModel(r.metaDB).CountsErrorLogBySchema(r.ctx, &meta.ErrorLogDetail{
-		DBTypeS:     common.TaskDBOracle,
-		DBTypeT:     common.TaskDBMySQL,
-		SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
-		RunMode:     common.FullO2MMode,
-	})
-	if errTotals > 0 || err != nil {
-		return fmt.Errorf("full schema [%s] table mode [%s] task failed: %v, table [error_log_detail] exist failed error, please clear and rerunning", strings.ToUpper(r.cfg.OracleConfig.SchemaName), common.CompareO2MMode, err)
+	// 写入策略，可重试错误重试以及错误行跳过
+	r.applyPolicy = ApplyPolicy{
+		RetryTimes:    r.cfg.FullConfig.ApplyRetryTimes,
+		RetryInterval: time.Duration(r.cfg.FullConfig.ApplyRetryInterval) * time.Millisecond,
+		SkipBadRows:   r.cfg.FullConfig.SkipBadRows,
+		LoadData:      loadData,
+	}
+	// 自适应并发，table-threads、sql-threads 以及 apply-threads 作为并发上限
+	if r.cfg.FullConfig.AdaptiveConcurrency {
+		r.readController = NewConcurrencyController("oracle read", 1,
+			r.cfg.FullConfig.TableThreads*r.cfg.FullConfig.SQLThreads)
+		r.applyPolicy.Controller = NewConcurrencyController("mysql apply", 1,
+			r.cfg.FullConfig.TableThreads*r.cfg.FullConfig.SQLThreads*r.cfg.FullConfig.ApplyThreads)
 	}
 
 	// 判断并记录待同步表列表
@@ -120,6 +125,11 @@ func (r *Migrate) NewFuller() error {
 	//  - 若想断点恢复，设置 enable-checkpoint true,首次一旦运行则 batch 数不能调整，
 	//  - 若不想断点恢复或者重新调整 batch 数，设置 enable-checkpoint false,清理元数据表 [wait_sync_meta],重新运行全量任务
 	if !r.cfg.FullConfig.EnableCheckpoint {
+		// 获取自定义库表名规则，清理目标表数据
+		tableNameRule, err := r.getTableNameRule()
+		if err != nil {
+			return err
+		}
 		err = meta.NewFullSyncMetaModel(r.metaDB).DeleteFullSyncMetaBySchemaSyncMode(
 			r.ctx, &meta.FullSyncMeta{
 				DBTypeS:     common.TaskDBOracle,
@@ -142,7 +152,7 @@ func (r *Migrate) NewFuller() error {
 				return err
 			}
 			// 清理已有表数据
-			if err := r.mysql.TruncateMySQLTable(r.cfg.MySQLConfig.SchemaName, tableName); err != nil {
+			if err := r.mysql.TruncateMySQLTable(r.cfg.MySQLConfig.SchemaName, genTargetTableName(tableNameRule, tableName)); err != nil {
 				return err
 			}
 			// 判断并记录待同步表列表
@@ -221,8 +231,7 @@ func (r *Migrate) NewFuller() error {
 
 	if len(waitSyncTableMetas) == 0 && len(partSyncTableMetas) == 0 {
 		endTime := time.Now()
-		zap.L().Info("source schema full table data finished",
-			zap.String("schema", r.cfg.OracleConfig.SchemaName),
+		logger.L(r.ctx).Info("source schema full table data finished",
 			zap.String("cost", endTime.Sub(startTime).String()))
 		return nil
 	}
@@ -246,13 +255,24 @@ func (r *Migrate) NewFuller() error {
 
 	if len(panicTblFullSlice) != 0 {
 		endTime := time.Now()
-		zap.L().Error("source schema full table data sync error",
-			zap.String("schema", r.cfg.OracleConfig.SchemaName),
+		logger.L(r.ctx).Error("source schema full table data sync error",
 			zap.String("cost", endTime.Sub(startTime).String()),
 			zap.Strings("panic tables", panicTblFullSlice))
 		return fmt.Errorf("checkpoint isn't consistent, please reruning [enable-checkpoint = fase]")
 	}
 
+	// 断点续传复用已记录的一致性快照 SCN，校验仍在 undo 保留期内
+	if r.cfg.FullConfig.ConsistentSnapshot && len(partSyncTables) > 0 {
+		if _, err = r.oracle.GetOracleGlobalSCN(r.ctx, r.metaDB, r.cfg.OracleConfig.SchemaName, common.FullO2MMode, true); err != nil {
+			return err
+		}
+	}
+
+	// 进度跟踪，定期输出进度汇总
+	r.progress = progress.NewTracker(r.ctx, r.metaDB, common.TaskDBOracle, common.TaskDBMySQL, r.cfg.OracleConfig.SchemaName, common.FullO2MMode)
+	stopProgress := r.progress.Run(time.Duration(r.cfg.AppConfig.ProgressInterval) * time.Second)
+	defer stopProgress()
+
 	// 数据迁移
 	// 优先存在断点的表
 	// partSyncTables -> waitSyncTables
@@ -269,22 +289,99 @@ func (r *Migrate) NewFuller() error {
 		}
 	}
 
+	// 跳过的错误行提示
+	if r.cfg.FullConfig.SkipBadRows {
+		errRows, err := meta.NewFullSyncErrorRowModel(r.metaDB).CountsFullSyncErrorRowBySchema(r.ctx, &meta.FullSyncErrorRow{
+			DBTypeS:     common.TaskDBOracle,
+			DBTypeT:     common.TaskDBMySQL,
+			SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
+			Mode:        common.FullO2MMode,
+		})
+		if err != nil {
+			return err
+		}
+		if errRows > 0 {
+			logger.L(r.ctx).Warn("source schema full table data exist skipped bad rows, please check meta table [full_sync_error_row]",
+				zap.Int64("bad rows", errRows))
+		}
+	}
+
 	endTime := time.Now()
-	zap.L().Info("all full table data sync finished",
-		zap.String("schema", r.cfg.OracleConfig.SchemaName),
+	logger.L(r.ctx).Info("all full table data sync finished",
 		zap.String("cost", endTime.Sub(startTime).String()))
 	return nil
 }
 
+// 运行前检查，上游 Oracle 数据库版本、配置文件待同步表列表以及 error_log_detail 错误记录
+func (r *Migrate) preCheck(runMode string) (string, []string, error) {
+	// 判断上游 Oracle 数据库版本
+	// 需要 oracle 11g 及以上
+	oracleDBVersion, err := r.oracle.GetOracleDBVersion()
+	if err != nil {
+		return oracleDBVersion, nil, err
+	}
+	if common.VersionOrdinal(oracleDBVersion) < common.VersionOrdinal(common.RequireOracleDBVersion) {
+		return oracleDBVersion, nil, fmt.Errorf("oracle db version [%v] is less than 11g, can't be using transferdb tools", oracleDBVersion)
+	}
+
+	// 获取配置文件待同步表列表
+	exporters, err := filterCFGTable(r.cfg, r.oracle)
+	if err != nil {
+		return oracleDBVersion, exporters, err
+	}
+
+	// 判断 error_log_detail 是否存在错误记录，是否可进行迁移
+	errTotals, err := meta.NewErrorLogDetailModel(r.metaDB).CountsErrorLogBySchema(r.ctx, &meta.ErrorLogDetail{
+		DBTypeS:     common.TaskDBOracle,
+		DBTypeT:     common.TaskDBMySQL,
+		SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
+		RunMode:     runMode,
+	})
+	if errTotals > 0 || err != nil {
+		return oracleDBVersion, exporters, fmt.Errorf("schema [%s] table mode [%s] task failed: %v, table [error_log_detail] exist failed error, please clear and rerunning", strings.ToUpper(r.cfg.OracleConfig.SchemaName), runMode, err)
+	}
+	return oracleDBVersion, exporters, nil
+}
+
+// 校验写入方式，返回是否 LOAD DATA 写入
+// LOAD DATA 写入行数不一致依赖事务回滚后回退 INSERT/REPLACE，目标端 LOAD DATA 非事务写入（TiDB v7.0.0 之前）时回退 insert 写入，避免重复写入
+func (r *Migrate) checkApplyMode() (bool, error) {
+	switch strings.ToLower(r.cfg.FullConfig.ApplyMode) {
+	case "", common.FullApplyModeInsert:
+		return false, nil
+	case common.FullApplyModeLoadData:
+		mysqlDBVersion, err := r.mysql.GetMySQLDBVersion()
+		if err != nil {
+			return false, err
+		}
+		if !mysql.IsLoadDataTransactional(mysqlDBVersion) {
+			logger.L(r.ctx).Warn("target db load data isn't transactional, fallback apply-mode insert",
+				zap.String("version", mysqlDBVersion),
+				zap.String("require tidb version", common.TiDBLoadDataTransactionVersion))
+			return false, nil
+		}
+		return true, nil
+	default:
+		return false, fmt.Errorf("full apply-mode [%s] isn't support, only support [%s/%s]", r.cfg.FullConfig.ApplyMode, common.FullApplyModeInsert, common.FullApplyModeLoadData)
+	}
+}
+
 func (r *Migrate) fullPartSyncTable(fullPartTables []string) error {
 	taskTime := time.Now()
 
 	g := &errgroup.Group{}
 	g.SetLimit(r.cfg.FullConfig.TableThreads)
 
+	// 收到优雅停止信号后不再调度新的表以及 chunk，进行中的 chunk 写入完成并清理 chunk 断点后退出
 	for _, table := range fullPartTables {
+		if signal.IsStopping(r.ctx) {
+			break
+		}
 		t := table
 		g.Go(func() error {
+			if signal.IsStopping(r.ctx) {
+				return signal.ErrGracefulStop
+			}
 			startTime := time.Now()
 			fullMetas, err := meta.NewFullSyncMetaModel(r.metaDB).DetailFullSyncMeta(r.ctx, &meta.FullSyncMeta{
 				DBTypeS:     common.TaskDBOracle,
@@ -297,25 +394,49 @@ func (r *Migrate) fullPartSyncTable(fullPartTables []string) error {
 				return err
 			}
 
+			tableRule, _ := r.cfg.GetTableRule(t)
+			// 表级别日志关联字段
+			tableCtx := logger.WithFields(r.ctx, logger.TableS(t))
+
 			g1 := &errgroup.Group{}
 			g1.SetLimit(r.cfg.FullConfig.SQLThreads)
 			for _, fullMeta := range fullMetas {
+				if signal.IsStopping(r.ctx) {
+					break
+				}
 				m := fullMeta
 				g1.Go(func() error {
-					// 数据写入
+					if signal.IsStopping(r.ctx) {
+						return signal.ErrGracefulStop
+					}
+					// chunk 级别日志关联字段
+					chunkCtx := logger.WithFields(tableCtx, logger.ChunkID(m.ID))
+					// 数据抽取，开启自适应并发时受读并发控制器限制并反馈抽取耗时
+					r.readController.Acquire()
+					readTime := time.Now()
 					columnFields, batchResults, err := IExtractor(
-						NewTable(r.ctx, m, r.oracle, r.cfg.AppConfig.InsertBatchSize))
+						NewTable(chunkCtx, m, r.oracle, r.cfg.AppConfig.InsertBatchSize, r.cfg.FullConfig.ConsistentSnapshot, tableRule.Where))
+					r.readController.Release(time.Since(readTime), len(batchResults), err)
 					if err != nil {
 						return err
 					}
-					err = ITranslator(NewChunk(r.ctx, m, r.oracle, r.mysql, r.metaDB, columnFields, batchResults, r.cfg.FullConfig.ApplyThreads, r.cfg.AppConfig.InsertBatchSize, true))
+					metrics.ObserveChunk(common.FullO2MMode, m.SchemaNameS, metrics.StageExtract, time.Since(readTime))
+					rows, bytes := CountBatchRows(batchResults)
+					metrics.AddRows(common.FullO2MMode, m.SchemaNameS, m.TableNameS, metrics.DirectionRead, rows, bytes)
+
+					// 数据写入
+					applyTime := time.Now()
+					err = ITranslator(NewChunk(chunkCtx, m, r.oracle, r.mysql, r.metaDB, columnFields, batchResults, r.cfg.FullConfig.ApplyThreads, r.cfg.AppConfig.InsertBatchSize, true, r.applyPolicy))
 					if err != nil {
 						return err
 					}
-					err = IApplier(NewChunk(r.ctx, m, r.oracle, r.mysql, r.metaDB, columnFields, batchResults, r.cfg.FullConfig.ApplyThreads, r.cfg.AppConfig.InsertBatchSize, true))
+					err = IApplier(NewChunk(chunkCtx, m, r.oracle, r.mysql, r.metaDB, columnFields, batchResults, r.cfg.FullConfig.ApplyThreads, r.cfg.AppConfig.InsertBatchSize, true, r.applyPolicy))
 					if err != nil {
 						return err
 					}
+					metrics.ObserveChunk(common.FullO2MMode, m.SchemaNameS, metrics.StageApply, time.Since(applyTime))
+					metrics.AddRows(common.FullO2MMode, m.SchemaNameS, m.TableNameS, metrics.DirectionWrite, rows, bytes)
+					r.progress.AddRows(t, rows, bytes)
 					return nil
 				})
 			}
@@ -323,6 +444,9 @@ func (r *Migrate) fullPartSyncTable(fullPartTables []string) error {
 			if err = g1.Wait(); err != nil {
 				return err
 			}
+			if signal.IsStopping(r.ctx) {
+				return signal.ErrGracefulStop
+			}
 
 			// 更新表级别记录
 			err = meta.NewWaitSyncMetaModel(r.metaDB).ModifyWaitSyncMetaColumnFullSplitTimesZero(r.ctx, &meta.WaitSyncMeta{
@@ -337,9 +461,7 @@ func (r *Migrate) fullPartSyncTable(fullPartTables []string) error {
 				return err
 			}
 
-			zap.L().Info("source schema table data finished",
-				zap.String("schema", r.cfg.OracleConfig.SchemaName),
-				zap.String("table", t),
+			logger.L(tableCtx).Info("source schema table data finished",
 				zap.String("cost", time.Now().Sub(startTime).String()))
 			return nil
 		})
@@ -348,9 +470,11 @@ func (r *Migrate) fullPartSyncTable(fullPartTables []string) error {
 	if err := g.Wait(); err != nil {
 		return err
 	}
+	if signal.IsStopping(r.ctx) {
+		return signal.ErrGracefulStop
+	}
 
-	zap.L().Info("source schema all table data loader finished",
-		zap.String("schema", r.cfg.OracleConfig.SchemaName),
+	logger.L(r.ctx).Info("source schema all table data loader finished",
 		zap.Int("table totals", len(fullPartTables)),
 		zap.String("cost", time.Now().Sub(taskTime).String()))
 	return nil
@@ -378,7 +502,7 @@ func (r *Migrate) initWaitSyncTableRowID(csvWaitTables []string, oracleCollation
 	}
 
 	// 全量同步前，获取 SCN 以及初始化元数据表
-	globalSCN, err := r.oracle.GetOracleCurrentSnapshotSCN()
+	globalSCN, err := r.oracle.GetOracleGlobalSCN(r.ctx, r.metaDB, r.cfg.OracleConfig.SchemaName, common.FullO2MMode, r.cfg.FullConfig.ConsistentSnapshot)
 	if err != nil {
 		return err
 	}
@@ -387,23 +511,29 @@ func (r *Migrate) initWaitSyncTableRowID(csvWaitTables []string, oracleCollation
 		return err
 	}
 
+	// 未开启 adaptive-chunk 沿用 [csv] task-threads 以及 rows 切分
 	g := &errgroup.Group{}
-	g.SetLimit(r.cfg.CSVConfig.TaskThreads)
+	if r.cfg.FullConfig.AdaptiveChunk {
+		g.SetLimit(r.cfg.FullConfig.TaskThreads)
+	} else {
+		g.SetLimit(r.cfg.CSVConfig.TaskThreads)
+	}
 
 	for idx, table := range csvWaitTables {
+		if signal.IsStopping(r.ctx) {
+			break
+		}
 		t := table
 		workerID := idx
 		g.Go(func() error {
+			if signal.IsStopping(r.ctx) {
+				return signal.ErrGracefulStop
+			}
 			startTime := time.Now()
 			// 库名、表名规则
-			var targetTableName string
-			if val, ok := tableNameRule[common.StringUPPER(t)]; ok {
-				targetTableName = val
-			} else {
-				targetTableName = common.StringUPPER(t)
-			}
+			targetTableName := genTargetTableName(tableNameRule, t)
 
-			sourceColumnInfo, err := r.adjustTableSelectColumn(t, oracleCollation)
+			sourceColumnInfo, _, err := r.adjustTableSelectColumn(t, oracleCollation)
 			if err != nil {
 				return err
 			}
@@ -421,14 +551,18 @@ func (r *Migrate) initWaitSyncTableRowID(csvWaitTables []string, oracleCollation
 			if err != nil {
 				return err
 			}
-			// 统计信息数据行数 0，直接全表扫
-			if tableRowsByStatistics == 0 {
-				zap.L().Warn("get oracle table rows",
-					zap.String("schema", r.cfg.OracleConfig.SchemaName),
-					zap.String("table", t),
+			chunkSize, err := r.planChunkSize(t, tableRowsByStatistics)
+			if err != nil {
+				return err
+			}
+			// 统计信息数据行数 0 或者自适应 chunk 规划数据行数不超过单 chunk，直接全表扫
+			if tableRowsByStatistics == 0 || (r.cfg.FullConfig.AdaptiveChunk && tableRowsByStatistics <= chunkSize) {
+				logger.L(r.ctx).Warn("get oracle table rows",
+					logger.TableS(t),
 					zap.String("column", sourceColumnInfo),
 					zap.String("where", "1 = 1"),
-					zap.Int("statistics rows", tableRowsByStatistics))
+					zap.Int("statistics rows", tableRowsByStatistics),
+					zap.Int("chunk size", chunkSize))
 
 				err = meta.NewCommonModel(r.metaDB).CreateFullSyncMetaAndUpdateWaitSyncMeta(r.ctx, &meta.FullSyncMeta{
 					DBTypeS:     common.TaskDBOracle,
@@ -458,9 +592,8 @@ func (r *Migrate) initWaitSyncTableRowID(csvWaitTables []string, oracleCollation
 				return nil
 			}
 
-			zap.L().Info("get oracle table statistics rows",
-				zap.String("schema", common.StringUPPER(r.cfg.OracleConfig.SchemaName)),
-				zap.String("table", common.StringUPPER(t)),
+			logger.L(r.ctx).Info("get oracle table statistics rows",
+				logger.TableS(common.StringUPPER(t)),
 				zap.Int("rows", tableRowsByStatistics))
 
 			taskName := common.StringsBuilder(common.StringUPPER(r.cfg.OracleConfig.SchemaName), `_`, common.StringUPPER(t), `_`, `TASK`, strconv.Itoa(workerID))
@@ -468,8 +601,19 @@ func (r *Migrate) initWaitSyncTableRowID(csvWaitTables []string, oracleCollation
 			if err = r.oracle.StartOracleChunkCreateTask(taskName); err != nil {
 				return err
 			}
+			// 异常或者提前退出时释放 Oracle DBMS_PARALLEL_EXECUTE 任务，正常结束已显式释放
+			taskClosed := false
+			defer func() {
+				if !taskClosed {
+					if errClose := r.oracle.CloseOracleChunkTask(taskName); errClose != nil {
+						logger.L(r.ctx).Warn("close oracle chunk task failed",
+							zap.String("task", taskName),
+							zap.Error(errClose))
+					}
+				}
+			}()
 
-			if err = r.oracle.StartOracleCreateChunkByRowID(taskName, common.StringUPPER(r.cfg.OracleConfig.SchemaName), common.StringUPPER(t), strconv.Itoa(r.cfg.CSVConfig.Rows)); err != nil {
+			if err = r.oracle.StartOracleCreateChunkByRowID(taskName, common.StringUPPER(r.cfg.OracleConfig.SchemaName), common.StringUPPER(t), strconv.Itoa(chunkSize)); err != nil {
 				return err
 			}
 
@@ -480,9 +624,8 @@ func (r *Migrate) initWaitSyncTableRowID(csvWaitTables []string, oracleCollation
 
 			// 判断数据是否存在
 			if len(chunkRes) == 0 {
-				zap.L().Warn("get oracle table rowids rows",
-					zap.String("schema", common.StringUPPER(r.cfg.OracleConfig.SchemaName)),
-					zap.String("table", common.StringUPPER(t)),
+				logger.L(r.ctx).Warn("get oracle table rowids rows",
+					logger.TableS(common.StringUPPER(t)),
 					zap.String("column", sourceColumnInfo),
 					zap.String("where", "1 = 1"),
 					zap.Int("rowids rows", len(chunkRes)))
@@ -557,11 +700,11 @@ func (r *Migrate) initWaitSyncTableRowID(csvWaitTables []string, oracleCollation
 			if err = r.oracle.CloseOracleChunkTask(taskName); err != nil {
 				return err
 			}
+			taskClosed = true
 
 			endTime := time.Now()
-			zap.L().Info("source table init wait_sync_meta and full_sync_meta finished",
-				zap.String("schema", r.cfg.OracleConfig.SchemaName),
-				zap.String("table", t),
+			logger.L(r.ctx).Info("source table init wait_sync_meta and full_sync_meta finished",
+				logger.TableS(t),
 				zap.String("cost", endTime.Sub(startTime).String()))
 			return nil
 		})
@@ -570,13 +713,33 @@ func (r *Migrate) initWaitSyncTableRowID(csvWaitTables []string, oracleCollation
 	if err = g.Wait(); err != nil {
 		return err
 	}
+	if signal.IsStopping(r.ctx) {
+		return signal.ErrGracefulStop
+	}
 
-	zap.L().Info("source schema init wait_sync_meta and full_sync_meta finished",
-		zap.String("schema", r.cfg.OracleConfig.SchemaName),
+	logger.L(r.ctx).Info("source schema init wait_sync_meta and full_sync_meta finished",
 		zap.String("cost", time.Now().Sub(startTask).String()))
 	return nil
 }
 
+// 开启 adaptive-chunk 时根据统计信息行数以及平均行长度规划 chunk 行数，否则以 [csv] rows 为准
+func (r *Migrate) planChunkSize(tableName string, tableRows int) (int, error) {
+	if !r.cfg.FullConfig.AdaptiveChunk {
+		return r.cfg.CSVConfig.Rows, nil
+	}
+	avgRowLen, err := r.oracle.GetOracleTableAvgRowLengthByStatistics(r.cfg.OracleConfig.SchemaName, tableName)
+	if err != nil {
+		return 0, err
+	}
+	chunkSize := PlanChunkSize(tableRows, avgRowLen, r.cfg.FullConfig.ChunkSize, r.cfg.FullConfig.ChunkTargetSize, r.cfg.FullConfig.SQLThreads, r.cfg.AppConfig.InsertBatchSize)
+	logger.L(r.ctx).Info("adaptive plan table chunk size",
+		logger.TableS(tableName),
+		zap.Int("statistics rows", tableRows),
+		zap.Int("avg row length", avgRowLen),
+		zap.Int("chunk size", chunkSize))
+	return chunkSize, nil
+}
+
 func (r *Migrate) getTableNameRule() (map[string]string, error) {
 	// 获取表名自定义规则
 	tableNameRules, err := meta.NewTableNameRuleModel(r.metaDB).DetailTableNameRule(r.ctx, &meta.TableNameRule{
@@ -598,17 +761,42 @@ func (r *Migrate) getTableNameRule() (map[string]string, error) {
 	return tableNameRuleMap, nil
 }
 
-func (r *Migrate) adjustTableSelectColumn(sourceTable string, oracleCollation bool) (string, error) {
+// 库名、表名规则，无规则目标表名同源端表名
+func genTargetTableName(tableNameRule map[string]string, sourceTable string) string {
+	if val, ok := tableNameRule[common.StringUPPER(sourceTable)]; ok {
+		return val
+	}
+	return common.StringUPPER(sourceTable)
+}
+
+// 返回源端查询字段以及对应的目标端写入字段名
+func (r *Migrate) adjustTableSelectColumn(sourceTable string, oracleCollation bool) (string, []string, error) {
 	// Date/Timestamp 字段类型格式化
 	// Interval Year/Day 数据字符 TO_CHAR 格式化
 	columnsINFO, err := r.oracle.GetOracleSchemaTableColumn(r.cfg.OracleConfig.SchemaName, sourceTable, oracleCollation)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	var columnNames []string
+	// 表级别数据迁移规则，字段子集以及字段值转换
+	tableRule, _ := r.cfg.GetTableRule(sourceTable)
+	columnsINFO, err = oracle.FilterOracleTableColumnByRule(sourceTable, columnsINFO, tableRule)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var columnNames, columnFields []string
 
 	for _, rowCol := range columnsINFO {
+		columnFields = append(columnFields, rowCol["COLUMN_NAME"])
+		if colRule, ok := tableRule.GetColumnRule(rowCol["COLUMN_NAME"]); ok {
+			ruleExpr, err := oracle.GenOracleColumnRuleExpr(rowCol["COLUMN_NAME"], colRule)
+			if err != nil {
+				return "", nil, err
+			}
+			columnNames = append(columnNames, common.StringsBuilder(ruleExpr, " AS ", rowCol["COLUMN_NAME"]))
+			continue
+		}
 		switch strings.ToUpper(rowCol["DATA_TYPE"]) {
 		// 数字
 		case "NUMBER":
@@ -631,7 +819,7 @@ func (r *Migrate) adjustTableSelectColumn(sourceTable string, oracleCollation bo
 			} else if strings.Contains(rowCol["DATA_TYPE"], "TIMESTAMP") {
 				dataScale, err := strconv.Atoi(rowCol["DATA_SCALE"])
 				if err != nil {
-					return "", fmt.Errorf("aujust oracle timestamp datatype scale [%s] strconv.Atoi failed: %v", rowCol["DATA_SCALE"], err)
+					return "", nil, fmt.Errorf("aujust oracle timestamp datatype scale [%s] strconv.Atoi failed: %v", rowCol["DATA_SCALE"], err)
 				}
 				if dataScale == 0 {
 					columnNames = append(columnNames, common.StringsBuilder("TO_CHAR(", rowCol["COLUMN_NAME"], ",'yyyy-mm-dd hh24:mi:ss') AS ", rowCol["COLUMN_NAME"]))
@@ -649,5 +837,5 @@ func (r *Migrate) adjustTableSelectColumn(sourceTable string, oracleCollation bo
 
 	}
 
-	return strings.Join(columnNames, ","), nil
+	return strings.Join(columnNames, ","), columnFields, nil
 }
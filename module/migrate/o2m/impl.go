@@ -19,7 +19,7 @@ import (
 	"github.com/wentaojin/transferdb/module/migrate"
 )
 
-func IExtractor(e migrate.Extractor) ([]string, []string, error) {
+func IExtractor(e migrate.Extractor) ([]string, [][]string, error) {
 	columnFields, batchResults, err := e.GetTableRows()
 	if err != nil {
 		return columnFields, batchResults, err
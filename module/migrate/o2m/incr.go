@@ -23,6 +23,9 @@ import (
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/logger"
+	"github.com/wentaojin/transferdb/metrics"
+	"github.com/wentaojin/transferdb/signal"
 	"go.uber.org/zap"
 	"strconv"
 	"strings"
@@ -40,34 +43,12 @@ func NewO2MIncr(ctx context.Context, cfg *config.Config, oracle *oracle.Oracle,
 }
 
 func (r *Migrate) NewIncr() error {
-	zap.L().Info("oracle to mysql increment sync table data start", zap.String("schema", r.cfg.OracleConfig.SchemaName))
+	logger.L(r.ctx).Info("oracle to mysql increment sync table data start")
 
-	// 判断上游 Oracle 数据库版本
-	// 需要 oracle 11g 及以上
-	oraDBVersion, err := r.oracle.GetOracleDBVersion()
+	_, exporters, err := r.preCheck(common.AllO2MMode)
 	if err != nil {
 		return err
 	}
-	if common.VersionOrdinal(oraDBVersion) < common.VersionOrdinal(common.RequireOracleDBVersion) {
-		return fmt.Errorf("oracle db version [%v] is less than 11g, can't be using transferdb tools", oraDBVersion)
-	}
-
-	// 获取配置文件待同步表列表
-	exporters, err := filterCFGTable(r.cfg, r.oracle)
-	if err != nil {
-		return err
-	}
-
-	// 判断 error_log_detail 是否存在错误记录，是否可进行 ALL
-	errTotals, err := meta.NewErrorLogDetailModel(r.metaDB).CountsErrorLogBySchema(r.ctx, &meta.ErrorLogDetail{
-		DBTypeS:     common.TaskDBOracle,
-		DBTypeT:     common.TaskDBMySQL,
-		SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
-		RunMode:     common.AllO2MMode,
-	})
-	if errTotals > 0 || err != nil {
-		return fmt.Errorf("incr schema [%s] table mode [%s] task failed: %v, table [error_log_detail] exist failed error, please clear and rerunning", strings.ToUpper(r.cfg.OracleConfig.SchemaName), common.CompareO2MMode, err)
-	}
 
 	// 全量数据导出导入，初始化全量元数据表以及导入完成初始化增量元数据表
 	var (
@@ -104,7 +85,7 @@ func (r *Migrate) NewIncr() error {
 					DBTypeT:     common.TaskDBMySQL,
 					SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
 					TableNameS:  common.StringUPPER(t),
-					Mode:        common.AllO2MMode,
+					Mode:        common.FullO2MMode,
 				})
 				if err != nil {
 					return err
@@ -118,12 +99,7 @@ func (r *Migrate) NewIncr() error {
 				return fmt.Errorf("table list %s can't incremently sync, because table increment sync meta record is exist and full meta sync isn't finished", panicTables)
 			}
 			// 增量数据同步
-			for range time.Tick(300 * time.Millisecond) {
-				if err := r.syncTableIncrRecord(); err != nil {
-					return err
-				}
-			}
-			return nil
+			return r.syncTableIncrLoop()
 		}
 
 		// 配置文件获取的表列表不等于 increment_sync_meta 表列表数，不能直接增量同步，需要手工调整
@@ -139,12 +115,13 @@ func (r *Migrate) NewIncr() error {
 		}
 
 		// 全量任务结束，写入增量源数据表起始 SCN 号
+		// 开启 consistent-snapshot 时，所有表全量基于同一全局 SCN，增量从该 SCN 开始，全量与增量无缝衔接
 		//根据配置文件生成同步表元数据 [incr_sync_meta]
 		tableMetas, err := meta.NewWaitSyncMetaModel(r.metaDB).DetailWaitSyncMetaBySchema(r.ctx, &meta.WaitSyncMeta{
 			DBTypeS:     common.TaskDBOracle,
 			DBTypeT:     common.TaskDBMySQL,
 			SchemaNameS: r.cfg.OracleConfig.SchemaName,
-			Mode:        common.AllO2MMode})
+			Mode:        common.FullO2MMode})
 		if err != nil {
 			return err
 		}
@@ -159,12 +136,7 @@ func (r *Migrate) NewIncr() error {
 		if len(tableMetas) > 0 {
 			for _, table := range tableMetas {
 				// 库名、表名规则
-				var targetTableName string
-				if val, ok := tableNameRule[common.StringUPPER(table.TableNameS)]; ok {
-					targetTableName = val
-				} else {
-					targetTableName = common.StringUPPER(table.TableNameS)
-				}
+				targetTableName := genTargetTableName(tableNameRule, table.TableNameS)
 
 				incrSyncMetas = append(incrSyncMetas, meta.IncrSyncMeta{
 					DBTypeS:     common.TaskDBOracle,
@@ -179,16 +151,33 @@ func (r *Migrate) NewIncr() error {
 				})
 			}
 		}
+		if len(incrSyncMetas) > 0 {
+			if err = meta.NewIncrSyncMetaModel(r.metaDB).BatchCreateIncrSyncMeta(r.ctx, incrSyncMetas, r.cfg.AppConfig.InsertBatchSize); err != nil {
+				return err
+			}
+		}
 
 		// 增量数据同步
-		for range time.Tick(300 * time.Millisecond) {
-			if err = r.syncTableIncrRecord(); err != nil {
+		return r.syncTableIncrLoop()
+	}
+	return fmt.Errorf("increment sync taskflow condition isn't match, can't sync")
+}
+
+// 增量数据同步循环，收到优雅停止信号后当前日志文件数据应用以及 incr_sync_meta 更新完成后退出
+func (r *Migrate) syncTableIncrLoop() error {
+	ticker := time.NewTicker(300 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-signal.Stopping(r.ctx):
+			logger.L(r.ctx).Warn("oracle to mysql increment sync table data graceful stopped")
+			return signal.ErrGracefulStop
+		case <-ticker.C:
+			if err := r.syncTableIncrRecord(); err != nil {
 				return err
 			}
 		}
-		return nil
 	}
-	return fmt.Errorf("increment sync taskflow condition isn't match, can't sync")
 }
 
 func (r *Migrate) syncTableIncrRecord() error {
@@ -203,11 +192,14 @@ func (r *Migrate) syncTableIncrRecord() error {
 	if err != nil {
 		return err
 	}
-	zap.L().Info("increment table log file get",
+	logger.L(r.ctx).Info("increment table log file get",
 		zap.String("logfile", fmt.Sprintf("%v", logFiles)))
 
-	// 遍历所有日志文件
+	// 遍历所有日志文件，日志文件级别应用并更新 incr_sync_meta，收到优雅停止信号后不再处理新的日志文件
 	for _, log := range logFiles {
+		if signal.IsStopping(r.ctx) {
+			return signal.ErrGracefulStop
+		}
 		// 获取日志文件起始 SCN
 		logFileStartSCN, err := common.StrconvUintBitSize(log["FIRST_CHANGE"], 64)
 		if err != nil {
@@ -220,10 +212,9 @@ func (r *Migrate) syncTableIncrRecord() error {
 			return fmt.Errorf("get oracle log file end scn %s utils.StrconvUintBitSize failed: %v", log["NEXT_CHANGE"], err)
 		}
 
-		zap.L().Info("increment table log file logminer",
+		logger.L(r.ctx).Info("increment table log file logminer",
 			zap.String("logfile", log["LOG_FILE"]),
-			zap.Uint64("logfile start scn", logFileStartSCN),
-			zap.Uint64("logminer start scn", logFileStartSCN),
+			logger.SCN(logFileStartSCN),
 			zap.Uint64("logfile end scn", logFileEndSCN))
 
 		// 获取增量元数据表内所需同步表信息
@@ -264,10 +255,12 @@ func (r *Migrate) syncTableIncrRecord() error {
 		}
 
 		if err = r.oracle.StartOracleLogminerStoredProcedure(log["FIRST_CHANGE"]); err != nil {
+			r.endOracleLogminer()
 			return err
 		}
 
 		// 捕获数据
+		queryTime := time.Now()
 		rowsResult, err := getOracleIncrRecord(r.ctx, r.oracle,
 			common.StringUPPER(r.cfg.OracleConfig.SchemaName),
 			common.StringUPPER(r.cfg.MySQLConfig.SchemaName),
@@ -276,11 +269,14 @@ func (r *Migrate) syncTableIncrRecord() error {
 			strconv.FormatUint(minSourceTableSCN, 10),
 			r.cfg.AllConfig.LogminerQueryTimeout)
 		if err != nil {
+			r.endOracleLogminer()
 			return err
 		}
-		zap.L().Info("increment table log extractor", zap.String("logfile", log["LOG_FILE"]),
+		metrics.ObserveLogminerQuery(r.cfg.OracleConfig.SchemaName, time.Since(queryTime))
+		logger.L(r.ctx).Info("increment table log extractor",
+			zap.String("logfile", log["LOG_FILE"]),
 			zap.Uint64("logfile start scn", logFileStartSCN),
-			zap.Uint64("source table last scn", minSourceTableSCN),
+			logger.SCN(minSourceTableSCN),
 			zap.Int("row counts", len(rowsResult)))
 
 		// logminer 关闭
@@ -299,6 +295,10 @@ func (r *Migrate) syncTableIncrRecord() error {
 		if err != nil {
 			return err
 		}
+		// 增量同步延迟，当前重做日志最大 SCN 与表已应用 SCN 差值
+		for _, tbl := range incrSyncMetas {
+			metrics.SetIncrLag(tbl.SchemaNameS, tbl.TableNameS, currentRedoLogMaxSCN, tbl.TableScnS)
+		}
 
 		// 按表级别筛选数据
 		var (
@@ -320,7 +320,7 @@ func (r *Migrate) syncTableIncrRecord() error {
 					if err != nil {
 						return err
 					}
-					zap.L().Warn("oracle current redo log reset flag", zap.Int("MigrateCurrentResetFlag", common.MigrateCurrentResetFlag))
+					logger.L(r.ctx).Warn("oracle current redo log reset flag", zap.Int("MigrateCurrentResetFlag", common.MigrateCurrentResetFlag))
 					common.MigrateCurrentResetFlag = 1
 				} else {
 					logminerContentMap, err = filterOracleIncrRecord(
@@ -369,7 +369,7 @@ func (r *Migrate) syncTableIncrRecord() error {
 
 					continue
 				}
-				zap.L().Warn("increment table log file logminer data that needn't to be consumed by current redo, transferdb will continue to capture")
+				logger.L(r.ctx).Warn("increment table log file logminer data that needn't to be consumed by current redo, transferdb will continue to capture")
 				continue
 			}
 			logminerContentMap, err = filterOracleIncrRecord(
@@ -399,7 +399,7 @@ func (r *Migrate) syncTableIncrRecord() error {
 				}
 				continue
 			}
-			zap.L().Warn("increment table log file logminer data that needn't to be consumed by logfile, transferdb will continue to capture")
+			logger.L(r.ctx).Warn("increment table log file logminer data that needn't to be consumed by logfile, transferdb will continue to capture")
 			continue
 		}
 
@@ -443,7 +443,7 @@ func (r *Migrate) syncTableIncrRecord() error {
 				return err
 			}
 		}
-		zap.L().Warn("increment table log file logminer null data, transferdb will continue to capture")
+		logger.L(r.ctx).Warn("increment table log file logminer null data, transferdb will continue to capture")
 		continue
 	}
 	return nil
@@ -491,3 +491,11 @@ func (r *Migrate) getTableIncrRecordLogfile() ([]map[string]string, error) {
 	}
 	return logFiles, nil
 }
+
+// 异常退出时关闭 logminer 会话，避免 Oracle 侧 logminer 会话残留
+func (r *Migrate) endOracleLogminer() {
+	if err := r.oracle.EndOracleLogminerStoredProcedure(); err != nil {
+		logger.L(r.ctx).Warn("end oracle logminer failed",
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,326 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/plan"
+	"strings"
+)
+
+// dry-run 全量以及全量 + 增量迁移计划
+// 与实际运行执行相同的预检查，仅只读查询，不写入元数据库、不清理目标表、不创建 Oracle chunk 切分任务
+type Planner struct {
+	*Migrate
+	mode string
+}
+
+func NewO2MFullPlanner(ctx context.Context, cfg *config.Config, oracle *oracle.Oracle, mysql *mysql.MySQL, metaDB *meta.Meta) *Planner {
+	return &Planner{
+		Migrate: NewO2MFuller(ctx, cfg, oracle, mysql, metaDB),
+		mode:    common.FullO2MMode,
+	}
+}
+
+func NewO2MIncrPlanner(ctx context.Context, cfg *config.Config, oracle *oracle.Oracle, mysql *mysql.MySQL, metaDB *meta.Meta) *Planner {
+	return &Planner{
+		Migrate: NewO2MIncr(ctx, cfg, oracle, mysql, metaDB),
+		mode:    common.AllO2MMode,
+	}
+}
+
+func (p *Planner) NewPlan() (*plan.Plan, error) {
+	r := p.Migrate
+	pl := plan.NewPlan(p.mode, r.cfg.OracleConfig.SchemaName, r.cfg.MySQLConfig.SchemaName)
+
+	oracleDBVersion, exporters, err := r.preCheck(p.mode)
+	if err != nil {
+		return nil, err
+	}
+	pl.AddCheck("oracle db version", oracleDBVersion)
+	pl.AddCheck("filter config table", fmt.Sprintf("%d tables", len(exporters)))
+	pl.AddCheck("error_log_detail", fmt.Sprintf("mode [%s] no failed records", p.mode))
+	oracleCollation := false
+	if common.VersionOrdinal(oracleDBVersion) >= common.VersionOrdinal(common.OracleTableColumnCollationDBVersion) {
+		oracleCollation = true
+	}
+
+	if r.applyPolicy.LoadData, err = r.checkApplyMode(); err != nil {
+		return nil, err
+	}
+	if r.applyPolicy.LoadData {
+		pl.AddCheck("full apply mode", common.FullApplyModeLoadData)
+	} else {
+		pl.AddCheck("full apply mode", common.FullApplyModeInsert)
+	}
+
+	oracleDBCharacterSet, err := r.oracle.GetOracleDBCharacterSet()
+	if err != nil {
+		return nil, err
+	}
+	if err = checkOracleDBCharacterSet(oracleDBCharacterSet); err != nil {
+		return nil, err
+	}
+	pl.AddCheck("oracle db character set", oracleDBCharacterSet)
+
+	mysqlDBVersion, err := r.mysql.GetMySQLDBVersion()
+	if err != nil {
+		return nil, err
+	}
+	pl.AddCheck("mysql db version", mysqlDBVersion)
+
+	tableNameRule, err := r.getTableNameRule()
+	if err != nil {
+		return nil, err
+	}
+	if err = r.checkTargetTable(exporters, tableNameRule); err != nil {
+		return nil, err
+	}
+	pl.AddCheck("target table exist", fmt.Sprintf("%d tables", len(exporters)))
+
+	if p.mode == common.AllO2MMode {
+		isIncr, err := r.planIncr(pl, exporters, tableNameRule)
+		if err != nil {
+			return nil, err
+		}
+		if isIncr {
+			return pl, nil
+		}
+		// 全量阶段同实际运行，检查全量 error_log_detail 错误记录
+		if _, _, err = r.preCheck(common.FullO2MMode); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = r.planFull(pl, exporters, tableNameRule, oracleCollation); err != nil {
+		return nil, err
+	}
+	return pl, nil
+}
+
+// 依据 incr_sync_meta 判断是否直接增量同步，判断逻辑同 NewIncr
+func (r *Migrate) planIncr(pl *plan.Plan, exporters []string, tableNameRule map[string]string) (bool, error) {
+	var (
+		existTableList, isNotExistTableList []string
+	)
+	for _, tbl := range exporters {
+		counts, err := meta.NewIncrSyncMetaModel(r.metaDB).CountsIncrSyncMetaBySchemaTable(r.ctx, &meta.IncrSyncMeta{
+			DBTypeS:     common.TaskDBOracle,
+			DBTypeT:     common.TaskDBMySQL,
+			SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
+			TableNameS:  tbl,
+		})
+		if err != nil {
+			return false, err
+		}
+		if counts == 1 {
+			existTableList = append(existTableList, tbl)
+		} else {
+			isNotExistTableList = append(isNotExistTableList, tbl)
+		}
+	}
+
+	if len(existTableList) > 0 {
+		if len(existTableList) != len(exporters) {
+			return false, fmt.Errorf("there is a migration table record for increment_sync_meta, but the configuration table list is not equal to the number of increment_sync_meta table lists, and it cannot be directly incrementally synchronized, please manually adjust to a list of meta-database tables [%v]", existTableList)
+		}
+		var panicTables []string
+		for _, t := range exporters {
+			waitSyncMetas, err := meta.NewWaitSyncMetaModel(r.metaDB).DetailWaitSyncMetaBySchemaTableSCN(r.ctx, &meta.WaitSyncMeta{
+				DBTypeS:     common.TaskDBOracle,
+				DBTypeT:     common.TaskDBMySQL,
+				SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
+				TableNameS:  common.StringUPPER(t),
+				Mode:        common.FullO2MMode,
+			})
+			if err != nil {
+				return false, err
+			}
+			if len(waitSyncMetas) == 0 {
+				panicTables = append(panicTables, t)
+			}
+		}
+		if len(panicTables) != 0 {
+			return false, fmt.Errorf("table list %s can't incremently sync, because table increment sync meta record is exist and full meta sync isn't finished", panicTables)
+		}
+
+		pl.AddCheck("incr_sync_meta", fmt.Sprintf("%d tables exist, increment sync directly", len(existTableList)))
+		for _, t := range exporters {
+			pl.AddTable(&plan.Table{
+				TableNameS:     common.StringUPPER(t),
+				TableNameT:     genTargetTableName(tableNameRule, t),
+				State:          plan.TableStateIncr,
+				StatisticsRows: -1,
+				ChunkSize:      -1,
+			})
+		}
+		return true, nil
+	}
+
+	if len(isNotExistTableList) != len(exporters) {
+		return false, fmt.Errorf("increment sync taskflow condition isn't match, can't sync")
+	}
+	pl.AddCheck("incr_sync_meta", "no records, full sync first")
+	pl.AddAction("after full sync finished, create incr_sync_meta records and increment sync from the full global scn")
+	return false, nil
+}
+
+// 全量迁移计划，表状态依据 wait_sync_meta，chunk 数依据统计信息预估
+func (r *Migrate) planFull(pl *plan.Plan, exporters []string, tableNameRule map[string]string, oracleCollation bool) error {
+	if !r.cfg.FullConfig.EnableCheckpoint {
+		pl.AddAction(fmt.Sprintf("enable-checkpoint is false, delete schema [%s] mode [%s] full_sync_meta and wait_sync_meta records",
+			common.StringUPPER(r.cfg.OracleConfig.SchemaName), common.FullO2MMode))
+	}
+
+	var globalSCN uint64
+	for _, t := range exporters {
+		waitSyncMetas, err := meta.NewWaitSyncMetaModel(r.metaDB).DetailWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
+			DBTypeS:     common.TaskDBOracle,
+			DBTypeT:     common.TaskDBMySQL,
+			SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
+			TableNameS:  t,
+			Mode:        common.FullO2MMode,
+		})
+		if err != nil {
+			return err
+		}
+		tbl := &plan.Table{
+			TableNameS:     common.StringUPPER(t),
+			TableNameT:     genTargetTableName(tableNameRule, t),
+			State:          plan.TableState(waitSyncMetas, r.cfg.FullConfig.EnableCheckpoint),
+			Truncate:       !r.cfg.FullConfig.EnableCheckpoint,
+			StatisticsRows: -1,
+			ChunkSize:      -1,
+		}
+
+		switch tbl.State {
+		case plan.TableStateFinished:
+		case plan.TableStateResume:
+			fullMetas, err := meta.NewFullSyncMetaModel(r.metaDB).DetailFullSyncMeta(r.ctx, &meta.FullSyncMeta{
+				DBTypeS:     common.TaskDBOracle,
+				DBTypeT:     common.TaskDBMySQL,
+				SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
+				TableNameS:  common.StringUPPER(t),
+				Mode:        common.FullO2MMode,
+			})
+			if err != nil {
+				return err
+			}
+			if len(fullMetas) == 0 {
+				return fmt.Errorf("table [%s] checkpoint isn't consistent, please reruning [enable-checkpoint = fase]", t)
+			}
+			_, columnFields, err := r.adjustTableSelectColumn(t, oracleCollation)
+			if err != nil {
+				return err
+			}
+			tbl.Chunks = len(fullMetas)
+			r.planSampleChunk(tbl, fullMetas[0], columnFields)
+		default:
+			sourceColumnInfo, columnFields, err := r.adjustTableSelectColumn(t, oracleCollation)
+			if err != nil {
+				return err
+			}
+			tableRows, err := r.oracle.GetOracleTableRowsByStatistics(r.cfg.OracleConfig.SchemaName, t)
+			if err != nil {
+				return err
+			}
+			chunkSize, err := r.planChunkSize(t, tableRows)
+			if err != nil {
+				return err
+			}
+			tbl.StatisticsRows, tbl.ChunkSize = tableRows, chunkSize
+
+			// 同实际切分，统计信息 0 或者自适应 chunk 不超过单 chunk 全表扫
+			rowidInfo := "1 = 1"
+			if tableRows == 0 || (r.cfg.FullConfig.AdaptiveChunk && tableRows <= chunkSize) {
+				tbl.Chunks = 1
+			} else {
+				tbl.Chunks = plan.EstimateChunks(tableRows, chunkSize)
+				rowidInfo = "ROWID BETWEEN '<start_rowid>' AND '<end_rowid>'"
+			}
+			if globalSCN == 0 {
+				if globalSCN, err = r.oracle.GetOracleGlobalSCN(r.ctx, r.metaDB, r.cfg.OracleConfig.SchemaName, common.FullO2MMode, r.cfg.FullConfig.ConsistentSnapshot); err != nil {
+					return err
+				}
+			}
+			r.planSampleChunk(tbl, meta.FullSyncMeta{
+				SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
+				TableNameS:  common.StringUPPER(t),
+				SchemaNameT: common.StringUPPER(r.cfg.MySQLConfig.SchemaName),
+				TableNameT:  tbl.TableNameT,
+				GlobalScnS:  globalSCN,
+				ColumnInfoS: sourceColumnInfo,
+				RowidInfoS:  rowidInfo,
+			}, columnFields)
+		}
+		pl.AddTable(tbl)
+	}
+	return nil
+}
+
+// 样例 chunk 抽取以及写入语句，写入同实际运行 safe-mode
+func (r *Migrate) planSampleChunk(tbl *plan.Table, syncMeta meta.FullSyncMeta, columnFields []string) {
+	tableRule, _ := r.cfg.GetTableRule(syncMeta.TableNameS)
+	tbl.SampleChunk = syncMeta.RowidInfoS
+	tbl.SampleSourceSQL = GenOracleTableChunkSQL(syncMeta, r.cfg.FullConfig.ConsistentSnapshot, tableRule.Where)
+	if r.applyPolicy.LoadData {
+		tbl.SampleTargetSQL = mysql.GenMySQLLoadDataSQL(syncMeta.SchemaNameT, syncMeta.TableNameT, columnFields, true,
+			mysql.ParseMySQLCharset(r.cfg.MySQLConfig.ConnectParams), "transferdb_<id>")
+	} else {
+		tbl.SampleTargetSQL = GenMySQLTablePrepareStmt(syncMeta.SchemaNameT, syncMeta.TableNameT, columnFields, 1, true)
+	}
+}
+
+// 下游目标表需已存在
+func (r *Migrate) checkTargetTable(exporters []string, tableNameRule map[string]string) error {
+	var (
+		targetTables, tables []string
+	)
+	for _, t := range exporters {
+		targetTable := genTargetTableName(tableNameRule, t)
+		targetTables = append(targetTables, targetTable)
+		tables = append(tables, common.StringsBuilder("'", targetTable, "'"))
+	}
+	mysqlTables, err := r.mysql.GetMySQLTableName(r.cfg.MySQLConfig.SchemaName, strings.Join(tables, ","))
+	if err != nil {
+		return err
+	}
+	for i, t := range mysqlTables {
+		mysqlTables[i] = common.StringUPPER(t)
+	}
+	diffItems := common.FilterDifferenceStringItems(targetTables, mysqlTables)
+	if len(diffItems) != 0 {
+		return fmt.Errorf("table [%v] target db isn't exists, please create table", diffItems)
+	}
+	return nil
+}
+
+func checkOracleDBCharacterSet(oracleDBCharacterSet string) error {
+	charset := strings.Split(oracleDBCharacterSet, ".")
+	if len(charset) != 2 {
+		return fmt.Errorf("oracle db character set [%v] isn't support", oracleDBCharacterSet)
+	}
+	if _, ok := common.OracleDBCharacterSetMap[charset[1]]; !ok {
+		return fmt.Errorf("oracle db character set [%v] isn't support", oracleDBCharacterSet)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"database/sql/driver"
+	"errors"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/wentaojin/transferdb/common"
+	"io"
+	"net"
+	"syscall"
+	"time"
+)
+
+// 判断目标端可重试错误，死锁、写冲突、繁忙以及连接中断
+func IsRetryableError(err error) bool {
+	_, ok := lookupRetryableError(err)
+	return ok
+}
+
+// 依据 MySQL 错误码匹配可重试错误，非 MySQL 错误依据驱动连接错误判断
+func lookupRetryableError(err error) (common.MigrateRetryableError, bool) {
+	if err == nil {
+		return common.MigrateRetryableError{}, false
+	}
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		for _, e := range common.MigrateRetryableErrors {
+			if e.Number == mysqlErr.Number {
+				return e, true
+			}
+		}
+		return common.MigrateRetryableError{}, false
+	}
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysqldriver.ErrInvalidConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &netErr) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return common.MigrateRetryableError{}, true
+	}
+	return common.MigrateRetryableError{}, false
+}
+
+// 指数退避间隔，第 retry 次（从 0 开始）重试间隔为 interval * 2^retry，不超过最大间隔
+func RetryBackoffInterval(interval time.Duration, retry int) time.Duration {
+	if interval <= 0 {
+		interval = time.Duration(common.FullDefaultApplyRetryInterval) * time.Millisecond
+	}
+	maxInterval := time.Duration(common.FullMaxApplyRetryInterval) * time.Millisecond
+	for i := 0; i < retry && interval < maxInterval; i++ {
+		interval = interval * 2
+	}
+	if interval > maxInterval {
+		interval = maxInterval
+	}
+	return interval
+}
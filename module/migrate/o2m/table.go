@@ -17,35 +17,44 @@ package o2m
 
 import (
 	"context"
+	"errors"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/logger"
+	"github.com/wentaojin/transferdb/metrics"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type Table struct {
-	Ctx       context.Context
-	SyncMeta  meta.FullSyncMeta
-	Oracle    *oracle.Oracle
-	BatchSize int
+	Ctx            context.Context
+	SyncMeta       meta.FullSyncMeta
+	Oracle         *oracle.Oracle
+	BatchSize      int
+	ConsistentRead bool
+	Where          string
 }
 
 func NewTable(ctx context.Context, syncMeta meta.FullSyncMeta,
-	oracle *oracle.Oracle, batchSize int) *Table {
+	oracle *oracle.Oracle, batchSize int, consistentRead bool, where string) *Table {
 	return &Table{
-		Ctx:       ctx,
-		SyncMeta:  syncMeta,
-		Oracle:    oracle,
-		BatchSize: batchSize,
+		Ctx:            ctx,
+		SyncMeta:       syncMeta,
+		Oracle:         oracle,
+		BatchSize:      batchSize,
+		ConsistentRead: consistentRead,
+		Where:          where,
 	}
 }
 
-func (t *Table) GetTableRows() ([]string, []string, error) {
+func (t *Table) GetTableRows() ([]string, [][]string, error) {
 	startTime := time.Now()
-	querySQL := common.StringsBuilder(`SELECT `, t.SyncMeta.ColumnInfoS, ` FROM `, t.SyncMeta.SchemaNameS, `.`, t.SyncMeta.TableNameS, ` WHERE `, t.SyncMeta.RowidInfoS)
+	querySQL := GenOracleTableChunkSQL(t.SyncMeta, t.ConsistentRead, t.Where)
 
 	columnFields, rowResults, err := t.Oracle.GetOracleTableRowsData(querySQL, t.BatchSize)
 	if err != nil {
@@ -53,15 +62,24 @@ func (t *Table) GetTableRows() ([]string, []string, error) {
 	}
 
 	endTime := time.Now()
-	zap.L().Info("source schema table rowid data extractor finished",
-		zap.String("schema", t.SyncMeta.SchemaNameS),
-		zap.String("table", t.SyncMeta.TableNameS),
+	logger.L(t.Ctx).Info("source schema table rowid data extractor finished",
 		zap.String("rowid", t.SyncMeta.RowidInfoS),
 		zap.String("sql", querySQL),
 		zap.String("cost", endTime.Sub(startTime).String()))
 	return columnFields, rowResults, nil
 }
 
+// chunk 数据抽取语句
+func GenOracleTableChunkSQL(syncMeta meta.FullSyncMeta, consistentRead bool, where string) string {
+	querySQL := common.StringsBuilder(`SELECT `, syncMeta.ColumnInfoS, ` FROM `, syncMeta.SchemaNameS, `.`, syncMeta.TableNameS)
+	// 一致性快照读，所有 chunk 基于同一全局 SCN
+	if consistentRead {
+		querySQL = common.StringsBuilder(querySQL, ` AS OF SCN `, strconv.FormatUint(syncMeta.GlobalScnS, 10))
+	}
+	// 表级别数据过滤条件
+	return common.StringsBuilder(querySQL, ` WHERE `, oracle.GenOracleTableRuleWhere(syncMeta.RowidInfoS, where))
+}
+
 type Chunk struct {
 	Ctx           context.Context
 	SyncMeta      meta.FullSyncMeta
@@ -72,12 +90,26 @@ type Chunk struct {
 	Oracle        *oracle.Oracle
 	MetaDB        *meta.Meta
 	SourceColumns []string
-	BatchResults  []string
+	BatchResults  [][]string
+	ApplyPolicy   ApplyPolicy
+}
+
+// 写入策略
+type ApplyPolicy struct {
+	// 自适应并发控制器，nil 表示未开启
+	Controller *ConcurrencyController
+	// 可重试错误重试次数以及退避初始间隔，间隔按次数指数增长
+	RetryTimes    int
+	RetryInterval time.Duration
+	// 持续失败的 batch 二分定位错误行，记录至 full_sync_error_row 并跳过
+	SkipBadRows bool
+	// LOAD DATA LOCAL INFILE 写入，否则 INSERT/REPLACE 语句写入
+	LoadData bool
 }
 
 func NewChunk(ctx context.Context, syncMeta meta.FullSyncMeta,
 	oracle *oracle.Oracle, mysql *mysql.MySQL, metaDB *meta.Meta,
-	sourceColumns, batchResults []string, applyThreads, batchSize int, safeMode bool) *Chunk {
+	sourceColumns []string, batchResults [][]string, applyThreads, batchSize int, safeMode bool, applyPolicy ApplyPolicy) *Chunk {
 	return &Chunk{
 		Ctx:           ctx,
 		SyncMeta:      syncMeta,
@@ -89,6 +121,7 @@ func NewChunk(ctx context.Context, syncMeta meta.FullSyncMeta,
 		MetaDB:        metaDB,
 		SourceColumns: sourceColumns,
 		BatchResults:  batchResults,
+		ApplyPolicy:   applyPolicy,
 	}
 }
 
@@ -101,15 +134,13 @@ func (t *Chunk) TranslateTableRows() error {
 
 func (t *Chunk) ApplyTableRows() error {
 	startTime := time.Now()
-	zap.L().Info("target schema table rowid data applier start",
-		zap.String("schema", t.SyncMeta.SchemaNameT),
-		zap.String("table", t.SyncMeta.TableNameT),
+	logger.L(t.Ctx).Info("target schema table rowid data applier start",
+		logger.SchemaT(t.SyncMeta.SchemaNameT),
+		logger.TableT(t.SyncMeta.TableNameT),
 		zap.String("rowid", t.SyncMeta.RowidInfoS))
 
 	if len(t.BatchResults) == 0 {
-		zap.L().Warn("oracle schema table rowid data return null rows, skip",
-			zap.String("schema", t.SyncMeta.SchemaNameS),
-			zap.String("table", t.SyncMeta.TableNameS),
+		logger.L(t.Ctx).Warn("oracle schema table rowid data return null rows, skip",
 			zap.String("info", common.StringsBuilder(`SELECT `, t.SyncMeta.ColumnInfoS, ` FROM `, t.SyncMeta.SchemaNameS, `.`, t.SyncMeta.TableNameS, ` WHERE `, t.SyncMeta.RowidInfoS)))
 
 		// 清理 full_sync_meta 记录
@@ -127,20 +158,41 @@ func (t *Chunk) ApplyTableRows() error {
 		return nil
 	}
 
+	prefixSQL := GenMySQLInsertSQLStmtPrefix(
+		t.SyncMeta.SchemaNameT,
+		t.SyncMeta.TableNameT,
+		t.SourceColumns,
+		t.SafeMode)
+
+	// chunk 重跑时清理上次记录的错误行，避免重复记录
+	if t.ApplyPolicy.SkipBadRows {
+		err := meta.NewFullSyncErrorRowModel(t.MetaDB).DeleteFullSyncErrorRowByChunk(t.Ctx, &meta.FullSyncErrorRow{
+			DBTypeS:     common.TaskDBOracle,
+			DBTypeT:     common.TaskDBMySQL,
+			SchemaNameS: t.SyncMeta.SchemaNameS,
+			TableNameS:  t.SyncMeta.TableNameS,
+			RowidInfoS:  t.SyncMeta.RowidInfoS,
+			Mode:        common.FullO2MMode,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	g := &errgroup.Group{}
 	g.SetLimit(t.ApplyThreads)
 	for _, result := range t.BatchResults {
-		valArgs := result
+		rows := result
 		g.Go(func() error {
-			err := t.MySQL.WriteMySQLTable(common.StringsBuilder(GenMySQLInsertSQLStmtPrefix(
-				t.SyncMeta.SchemaNameT,
-				t.SyncMeta.TableNameT,
-				t.SourceColumns,
-				t.SafeMode), valArgs))
-			if err != nil {
+			err := t.writeTableRows(prefixSQL, rows)
+			if err == nil {
+				return nil
+			}
+			// 可重试错误重试耗尽，说明目标端异常，直接报错
+			if !t.ApplyPolicy.SkipBadRows || IsRetryableError(err) {
 				return err
 			}
-			return nil
+			return t.bisectTableRows(prefixSQL, rows, err)
 		})
 	}
 	if err := g.Wait(); err != nil {
@@ -161,11 +213,105 @@ func (t *Chunk) ApplyTableRows() error {
 	}
 
 	endTime := time.Now()
-	zap.L().Info("target schema table rowid data applier finished",
-		zap.String("schema", t.SyncMeta.SchemaNameT),
-		zap.String("table", t.SyncMeta.TableNameT),
+	logger.L(t.Ctx).Info("target schema table rowid data applier finished",
+		logger.SchemaT(t.SyncMeta.SchemaNameT),
+		logger.TableT(t.SyncMeta.TableNameT),
 		zap.String("rowid", t.SyncMeta.RowidInfoS),
 		zap.String("cost", endTime.Sub(startTime).String()))
 
 	return nil
 }
+
+// 写入 batch 数据，可重试错误按退避间隔重试，开启自适应并发时写入受控制器并发限制并反馈写入耗时
+// LOAD DATA 写入行数不一致（冲突、截断等被降级为 warning）时已回滚，回退 INSERT/REPLACE 写入，由语句报错进入重试以及二分定位流程
+func (t *Chunk) writeTableRows(prefixSQL string, rows []string) error {
+	var (
+		err       error
+		loadLines string
+	)
+	valArgs := strings.Join(rows, ",")
+	loadData := t.ApplyPolicy.LoadData
+	if loadData {
+		loadLines = GenMySQLLoadDataRows(rows)
+	}
+	for i := 0; ; i++ {
+		t.ApplyPolicy.Controller.Acquire()
+		startTime := time.Now()
+		if loadData {
+			err = t.MySQL.LoadMySQLTable(t.SyncMeta.SchemaNameT, t.SyncMeta.TableNameT, t.SourceColumns, t.SafeMode,
+				len(rows), strings.NewReader(loadLines))
+			if errors.Is(err, mysql.ErrLoadDataRowsMismatch) {
+				logger.L(t.Ctx).Warn("target schema table rowid data load mismatch, fallback insert",
+					logger.SchemaT(t.SyncMeta.SchemaNameT),
+					logger.TableT(t.SyncMeta.TableNameT),
+					zap.String("rowid", t.SyncMeta.RowidInfoS),
+					zap.String("error", common.TruncateString(err.Error(), 512)))
+				loadData = false
+				err = t.MySQL.WriteMySQLTable(common.StringsBuilder(prefixSQL, valArgs))
+			}
+		} else {
+			err = t.MySQL.WriteMySQLTable(common.StringsBuilder(prefixSQL, valArgs))
+		}
+		t.ApplyPolicy.Controller.Release(time.Since(startTime), len(rows), err)
+		metrics.IncApplyError(common.FullO2MMode, t.SyncMeta.SchemaNameS, err)
+		if err == nil || !IsRetryableError(err) || i >= t.ApplyPolicy.RetryTimes {
+			return err
+		}
+
+		interval := RetryBackoffInterval(t.ApplyPolicy.RetryInterval, i)
+		logger.L(t.Ctx).Warn("target schema table rowid data applier failed, retry",
+			logger.SchemaT(t.SyncMeta.SchemaNameT),
+			logger.TableT(t.SyncMeta.TableNameT),
+			zap.String("rowid", t.SyncMeta.RowidInfoS),
+			zap.Int("retry", i+1),
+			zap.String("backoff", interval.String()),
+			zap.String("error", common.TruncateString(err.Error(), 512)))
+
+		select {
+		case <-t.Ctx.Done():
+			return err
+		case <-time.After(interval):
+		}
+	}
+}
+
+// 二分定位写入失败数据行，错误行记录至 full_sync_error_row，其余数据行正常写入
+func (t *Chunk) bisectTableRows(prefixSQL string, rows []string, rowsErr error) error {
+	if len(rows) == 0 {
+		return rowsErr
+	}
+	if len(rows) == 1 {
+		logger.L(t.Ctx).Warn("target schema table rowid data applier skip bad row",
+			logger.SchemaT(t.SyncMeta.SchemaNameT),
+			logger.TableT(t.SyncMeta.TableNameT),
+			zap.String("rowid", t.SyncMeta.RowidInfoS),
+			zap.String("error", common.TruncateString(rowsErr.Error(), 512)))
+		return meta.NewFullSyncErrorRowModel(t.MetaDB).CreateFullSyncErrorRow(t.Ctx, &meta.FullSyncErrorRow{
+			DBTypeS:     common.TaskDBOracle,
+			DBTypeT:     common.TaskDBMySQL,
+			SchemaNameS: t.SyncMeta.SchemaNameS,
+			TableNameS:  t.SyncMeta.TableNameS,
+			SchemaNameT: t.SyncMeta.SchemaNameT,
+			TableNameT:  t.SyncMeta.TableNameT,
+			RowidInfoS:  t.SyncMeta.RowidInfoS,
+			Mode:        common.FullO2MMode,
+			RowInfo:     rows[0],
+			ErrorDetail: rowsErr.Error(),
+		})
+	}
+
+	mid := len(rows) / 2
+	for _, part := range [][]string{rows[:mid], rows[mid:]} {
+		err := t.writeTableRows(prefixSQL, part)
+		if err == nil {
+			continue
+		}
+		if IsRetryableError(err) {
+			return err
+		}
+		if err = t.bisectTableRows(prefixSQL, part, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/logger"
 	"go.uber.org/zap"
 	"math"
 	"strings"
@@ -91,8 +92,8 @@ func translateTableRecord(
 	}
 	endTime := time.Now()
 	zap.L().Info("single full table rowid data translator",
-		zap.String("schema", targetSchemaName),
-		zap.String("table", targetTableName),
+		logger.SchemaT(targetSchemaName),
+		logger.TableT(targetTableName),
 		zap.String("rowid sql", rowidSQL),
 		zap.Int("rowid rows", rowCounts),
 		zap.Int("insert batch size", insertBatchSize),
@@ -126,6 +127,22 @@ func GenMySQLInsertSQLStmtPrefix(targetSchemaName, targetTableName string, colum
 	return prefixSQL
 }
 
+// batch 数据行转换 LOAD DATA 数据行
+// 数据行形如 (v1,'v2')、(v3,NULL)，转换为 v1,'v2'\nv3,NULL\n，字符值转义与 LOAD DATA ESCAPED BY '\\' 一致
+func GenMySQLLoadDataRows(rows []string) string {
+	size := 0
+	for _, row := range rows {
+		size += len(row)
+	}
+	var b strings.Builder
+	b.Grow(size)
+	for _, row := range rows {
+		b.WriteString(row[1 : len(row)-1])
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
 // SQL Prepare 语句
 func GenMySQLPrepareBindVarStmt(columns, bindVarBatch int) string {
 	var (
@@ -149,8 +166,8 @@ func translateAndAddOracleIncrRecord(metaDB *meta.Meta, mysql *mysql.MySQL, sour
 
 	startTime := time.Now()
 	zap.L().Info("oracle table increment log apply start",
-		zap.String("oracle schema", sourceSchema),
-		zap.String("oracle table", sourceTable),
+		logger.SchemaS(sourceSchema),
+		logger.TableS(sourceTable),
 		zap.Time("start time", startTime))
 
 	for _, rows := range logminers {
@@ -209,8 +226,8 @@ func translateAndAddOracleIncrRecord(metaDB *meta.Meta, mysql *mysql.MySQL, sour
 
 	endTime := time.Now()
 	zap.L().Info("oracle table increment log apply finished",
-		zap.String("oracle schema", sourceSchema),
-		zap.String("oracle table", sourceTable),
+		logger.SchemaS(sourceSchema),
+		logger.TableS(sourceTable),
 		zap.String("status", "success"),
 		zap.Time("start time", startTime),
 		zap.Time("end time", endTime),
@@ -328,3 +345,20 @@ func translateOracleToMySQLSQL(oracleSQLRedo, oracleSQLUndo, targetSchema, targe
 	}
 	return sqls, operationType, nil
 }
+
+// 统计 batch 数据行数以及字节数，字节数按 VALUES 拼接后长度计算
+func CountBatchRows(batchResults [][]string) (int64, int64) {
+	var rows, bytes int64
+	for _, b := range batchResults {
+		if len(b) == 0 {
+			continue
+		}
+		rows += int64(len(b))
+		// 行间逗号分隔符
+		bytes += int64(len(b) - 1)
+		for _, r := range b {
+			bytes += int64(len(r))
+		}
+	}
+	return rows, bytes
+}
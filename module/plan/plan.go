@@ -0,0 +1,200 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package plan
+
+import (
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"strconv"
+	"strings"
+)
+
+// 表运行状态
+const (
+	// 无断点记录或者未开启断点续传，重新初始化
+	TableStateInit = "INIT"
+	// 已记录待同步，未进行 chunk 切分
+	TableStateWait = "WAIT"
+	// 存在未完成 chunk，断点续传
+	TableStateResume = "RESUME"
+	// 已完成，跳过
+	TableStateFinished = "FINISHED"
+	// 全量已完成，直接增量同步
+	TableStateIncr = "INCR"
+)
+
+// dry-run 运行计划，仅包含预检查结果以及预计执行动作，生成过程不产生任何写入
+type Plan struct {
+	Mode        string
+	SchemaNameS string
+	SchemaNameT string
+	Checks      []Check
+	// 运行前元数据清理等全局动作
+	Actions []string
+	Tables  []*Table
+}
+
+type Check struct {
+	Name   string
+	Result string
+}
+
+type Table struct {
+	TableNameS string
+	TableNameT string
+	State      string
+	// 运行前清理目标表数据
+	Truncate       bool
+	StatisticsRows int
+	ChunkSize      int
+	// 待切分表为依据统计信息预估 chunk 数，断点续传表为剩余 chunk 数
+	Chunks int
+	// 样例 chunk 范围以及生成的源端、目标端 SQL
+	SampleChunk     string
+	SampleSourceSQL string
+	SampleTargetSQL string
+}
+
+type Planner interface {
+	NewPlan() (*Plan, error)
+}
+
+// dry-run 生成并输出运行计划
+func IPlan(p Planner) error {
+	plan, err := p.NewPlan()
+	if err != nil {
+		return err
+	}
+	fmt.Print(plan.String())
+	return nil
+}
+
+func NewPlan(mode, schemaNameS, schemaNameT string) *Plan {
+	return &Plan{
+		Mode:        mode,
+		SchemaNameS: common.StringUPPER(schemaNameS),
+		SchemaNameT: common.StringUPPER(schemaNameT),
+	}
+}
+
+func (p *Plan) AddCheck(name, result string) {
+	p.Checks = append(p.Checks, Check{Name: name, Result: result})
+}
+
+func (p *Plan) AddAction(action string) {
+	p.Actions = append(p.Actions, action)
+}
+
+func (p *Plan) AddTable(t *Table) {
+	p.Tables = append(p.Tables, t)
+}
+
+// 依据 wait_sync_meta 记录判断表运行状态，与实际运行断点续传逻辑一致
+//   - 未开启断点续传或者无记录：INIT
+//   - full_split_times = -1：WAIT
+//   - full_split_times > 0：RESUME
+//   - full_split_times = 0：FINISHED
+func TableState(waitSyncMetas []meta.WaitSyncMeta, enableCheckpoint bool) string {
+	if !enableCheckpoint || len(waitSyncMetas) == 0 {
+		return TableStateInit
+	}
+	switch {
+	case waitSyncMetas[0].FullSplitTimes < 0:
+		return TableStateWait
+	case waitSyncMetas[0].FullSplitTimes > 0:
+		return TableStateResume
+	default:
+		return TableStateFinished
+	}
+}
+
+// 依据统计信息行数预估 chunk 数，统计信息为 0 全表扫单 chunk
+func EstimateChunks(tableRows, chunkSize int) int {
+	if tableRows <= 0 || chunkSize <= 0 {
+		return 1
+	}
+	return (tableRows + chunkSize - 1) / chunkSize
+}
+
+func (p *Plan) String() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("transferdb dry-run plan, mode [%s] schema [%s] -> [%s], nothing has been changed\n", p.Mode, p.SchemaNameS, p.SchemaNameT))
+
+	if len(p.Checks) > 0 {
+		t := table.NewWriter()
+		t.SetStyle(table.StyleLight)
+		t.AppendHeader(table.Row{"#", "PRE CHECK", "RESULT"})
+		for i, c := range p.Checks {
+			t.AppendRow(table.Row{i + 1, c.Name, c.Result})
+		}
+		b.WriteString(t.Render() + "\n")
+	}
+
+	if len(p.Actions) > 0 {
+		b.WriteString("actions before running:\n")
+		for _, a := range p.Actions {
+			b.WriteString(common.StringsBuilder("  - ", a, "\n"))
+		}
+	}
+
+	var (
+		runs, truncates, chunks int
+	)
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.AppendHeader(table.Row{"#", "SOURCE TABLE", "TARGET TABLE", "STATE", "TRUNCATE", "STATISTICS ROWS", "CHUNK SIZE", "CHUNKS"})
+	for i, tbl := range p.Tables {
+		if tbl.State != TableStateFinished {
+			runs++
+			chunks += tbl.Chunks
+		}
+		truncate := "NO"
+		if tbl.Truncate {
+			truncates++
+			truncate = "YES"
+		}
+		t.AppendRow(table.Row{i + 1,
+			common.StringsBuilder(p.SchemaNameS, ".", tbl.TableNameS),
+			common.StringsBuilder(p.SchemaNameT, ".", tbl.TableNameT),
+			tbl.State, truncate, planInt(tbl.StatisticsRows), planInt(tbl.ChunkSize), tbl.Chunks})
+	}
+	t.AppendFooter(table.Row{"", "TOTAL", len(p.Tables), fmt.Sprintf("RUN %d", runs), truncates, "", "", chunks})
+	b.WriteString(t.Render() + "\n")
+
+	for _, tbl := range p.Tables {
+		if tbl.SampleSourceSQL == "" && tbl.SampleTargetSQL == "" {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("sample chunk [%s.%s] [%s]:\n", p.SchemaNameS, tbl.TableNameS, tbl.SampleChunk))
+		if tbl.SampleSourceSQL != "" {
+			b.WriteString(common.StringsBuilder("  source: ", tbl.SampleSourceSQL, "\n"))
+		}
+		if tbl.SampleTargetSQL != "" {
+			b.WriteString(common.StringsBuilder("  target: ", tbl.SampleTargetSQL, "\n"))
+		}
+	}
+	return b.String()
+}
+
+// 未知数值 -1 输出 -
+func planInt(v int) string {
+	if v < 0 {
+		return "-"
+	}
+	return strconv.Itoa(v)
+}
@@ -0,0 +1,386 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reverse
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"regexp"
+	"strings"
+)
+
+// Dialect 目标端 DDL 渲染方言【标识符、数据类型、表选项、注释、约束以及索引语法】
+// 源端固定 Oracle，目标端支持 MySQL/TiDB/PostgreSQL；MySQL/TiDB -> MySQL/TiDB 同构转换不支持
+type Dialect interface {
+	// 目标端规则数据库类型，MySQL/TiDB 共用 MYSQL 内置以及自定义规则
+	DBTypeT() string
+	QuoteIdentifier(identifier string) string
+
+	// 数据类型，modifiers 长度或者精度修饰
+	GenColumnDatatype(datatypeName string, modifiers ...int) string
+	GenNumberDatatype(dataPrecision, dataScale int) string
+	GenColumnDefinition(columnName, columnType, columnCollation, nullable, dataDefault, comment string) string
+
+	// 表选项【存储引擎、字符集、排序规则】
+	GenTableOption(tableCollation string) string
+
+	// 注释，内联注释随建表语句输出，否则独立语句输出
+	IsInlineComment() bool
+	GenTableComment(schemaName, tableName, comment string) string
+	GenColumnComment(schemaName, tableName, columnName, comment string) string
+
+	// 约束
+	IsSupportForeignKey() bool
+	IsSupportCheckKey(dbVersion string) bool
+	GenPrimaryKey(columns []string) string
+	GenUniqueKey(constraintName string, columns []string) string
+	GenForeignKey(constraintName string, columns []string, rSchemaName, rTableName string, rColumns []string, deleteRule string) string
+	GenCheckKey(constraintName, searchCondition string) string
+
+	// 索引，内联索引随建表语句输出，否则独立 CREATE INDEX 语句输出
+	IsInlineIndex() bool
+	GenUniqueIndex(schemaName, tableName, indexName string, columns []string) string
+	GenNormalIndex(schemaName, tableName, indexName string, columns []string) string
+
+	GenCreateSchema(schemaName, schemaCollation string) string
+}
+
+func NewDialect(dbType string) (Dialect, error) {
+	switch common.StringUPPER(dbType) {
+	case common.TaskDBMySQL:
+		return &MySQLDialect{}, nil
+	case common.TaskDBTiDB:
+		return &TiDBDialect{}, nil
+	case common.TaskDBPostgres:
+		return &PostgresDialect{}, nil
+	default:
+		return nil, fmt.Errorf("target db type [%s] dialect isn't support", dbType)
+	}
+}
+
+// MySQLDialect MySQL 目标端
+type MySQLDialect struct{}
+
+func (d *MySQLDialect) DBTypeT() string {
+	return common.TaskDBMySQL
+}
+
+func (d *MySQLDialect) QuoteIdentifier(identifier string) string {
+	return fmt.Sprintf("`%s`", identifier)
+}
+
+func (d *MySQLDialect) GenColumnDatatype(datatypeName string, modifiers ...int) string {
+	return genColumnDatatype(datatypeName, modifiers...)
+}
+
+// number(*) -> number(38,127)
+// number  -> number(38,127)
+// number(*,x) ->  number(38,x)
+// decimal(x,y) -> y max 30
+func (d *MySQLDialect) GenNumberDatatype(dataPrecision, dataScale int) string {
+	switch {
+	case dataScale > 0:
+		switch {
+		case dataPrecision == 38 && dataScale > 30:
+			return fmt.Sprintf("DECIMAL(%d,%d)", 65, 30)
+		case dataPrecision == 38 && dataScale <= 30:
+			return fmt.Sprintf("DECIMAL(%d,%d)", 65, dataScale)
+		case dataScale <= 30:
+			return fmt.Sprintf("DECIMAL(%d,%d)", dataPrecision, dataScale)
+		default:
+			return fmt.Sprintf("DECIMAL(%d,%d)", dataPrecision, 30)
+		}
+	default:
+		switch {
+		case dataPrecision >= 1 && dataPrecision < 3:
+			return "TINYINT"
+		case dataPrecision >= 3 && dataPrecision < 5:
+			return "SMALLINT"
+		case dataPrecision >= 5 && dataPrecision < 9:
+			return "INT"
+		case dataPrecision >= 9 && dataPrecision < 19:
+			return "BIGINT"
+		case dataPrecision >= 19 && dataPrecision <= 38:
+			return fmt.Sprintf("DECIMAL(%d)", dataPrecision)
+		default:
+			return fmt.Sprintf("DECIMAL(%d,%d)", 65, dataScale)
+		}
+	}
+}
+
+func (d *MySQLDialect) GenColumnDefinition(columnName, columnType, columnCollation, nullable, dataDefault, comment string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s %s", d.QuoteIdentifier(columnName), columnType))
+	if columnCollation != "" {
+		b.WriteString(fmt.Sprintf(" COLLATE %s", columnCollation))
+	}
+	// NULL 默认，无需显示指定
+	if nullable != "NULL" {
+		b.WriteString(fmt.Sprintf(" %s", nullable))
+	}
+	if dataDefault != "" {
+		b.WriteString(fmt.Sprintf(" DEFAULT %s", dataDefault))
+	}
+	if comment != "" {
+		b.WriteString(fmt.Sprintf(" COMMENT '%s'", common.SpecialLettersUsingMySQL([]byte(comment))))
+	}
+	return b.String()
+}
+
+func (d *MySQLDialect) GenTableOption(tableCollation string) string {
+	return fmt.Sprintf("ENGINE=InnoDB DEFAULT CHARSET=%s COLLATE=%s",
+		strings.ToLower(common.MySQLCharacterSet), tableCollation)
+}
+
+func (d *MySQLDialect) IsInlineComment() bool {
+	return true
+}
+
+func (d *MySQLDialect) GenTableComment(schemaName, tableName, comment string) string {
+	return fmt.Sprintf("COMMENT='%s'", comment)
+}
+
+func (d *MySQLDialect) GenColumnComment(schemaName, tableName, columnName, comment string) string {
+	// 字段注释内联于字段定义
+	return ""
+}
+
+func (d *MySQLDialect) IsSupportForeignKey() bool {
+	return true
+}
+
+func (d *MySQLDialect) IsSupportCheckKey(dbVersion string) bool {
+	return common.VersionOrdinal(dbVersion) > common.VersionOrdinal(common.MySQLCheckConsVersion)
+}
+
+func (d *MySQLDialect) GenPrimaryKey(columns []string) string {
+	return fmt.Sprintf("PRIMARY KEY (%s)", quoteIdentifiers(d, columns))
+}
+
+func (d *MySQLDialect) GenUniqueKey(constraintName string, columns []string) string {
+	return fmt.Sprintf("UNIQUE KEY %s (%s)", d.QuoteIdentifier(constraintName), quoteIdentifiers(d, columns))
+}
+
+func (d *MySQLDialect) GenForeignKey(constraintName string, columns []string, rSchemaName, rTableName string, rColumns []string, deleteRule string) string {
+	return genForeignKey(d, constraintName, columns, rSchemaName, rTableName, rColumns, deleteRule)
+}
+
+func (d *MySQLDialect) GenCheckKey(constraintName, searchCondition string) string {
+	return fmt.Sprintf("CONSTRAINT %s CHECK (%s)", d.QuoteIdentifier(constraintName), searchCondition)
+}
+
+func (d *MySQLDialect) IsInlineIndex() bool {
+	return true
+}
+
+func (d *MySQLDialect) GenUniqueIndex(schemaName, tableName, indexName string, columns []string) string {
+	return fmt.Sprintf("UNIQUE INDEX %s (%s)", d.QuoteIdentifier(indexName), quoteIdentifiers(d, columns))
+}
+
+func (d *MySQLDialect) GenNormalIndex(schemaName, tableName, indexName string, columns []string) string {
+	return fmt.Sprintf("KEY %s (%s)", d.QuoteIdentifier(indexName), quoteIdentifiers(d, columns))
+}
+
+func (d *MySQLDialect) GenCreateSchema(schemaName, schemaCollation string) string {
+	return fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s DEFAULT CHARACTER SET %s COLLATE %s;",
+		schemaName, strings.ToLower(common.MySQLCharacterSet), schemaCollation)
+}
+
+// TiDBDialect 语法同 MySQL，外键、检查约束不生效，输出兼容性文件
+type TiDBDialect struct {
+	MySQLDialect
+}
+
+func (d *TiDBDialect) IsSupportForeignKey() bool {
+	return false
+}
+
+func (d *TiDBDialect) IsSupportCheckKey(dbVersion string) bool {
+	return false
+}
+
+// PostgresDialect 标识符统一小写并使用双引号，与 PostgreSQL 未引用标识符折叠规则一致
+type PostgresDialect struct{}
+
+// 允许长度或者精度修饰的数据类型
+var postgresModifierDatatypes = map[string]struct{}{
+	"CHAR":              {},
+	"CHARACTER":         {},
+	"VARCHAR":           {},
+	"CHARACTER VARYING": {},
+	"NUMERIC":           {},
+	"DECIMAL":           {},
+	"TIMESTAMP":         {},
+	"TIMESTAMPTZ":       {},
+	"TIME":              {},
+	"TIMETZ":            {},
+	"BIT":               {},
+	"VARBIT":            {},
+}
+
+// 检查约束条件内 Oracle 双引号标识符
+var postgresQuotedIdentifierReg = regexp.MustCompile(`"([^"]+)"`)
+
+func (d *PostgresDialect) DBTypeT() string {
+	return common.TaskDBPostgres
+}
+
+func (d *PostgresDialect) QuoteIdentifier(identifier string) string {
+	return fmt.Sprintf(`"%s"`, strings.ReplaceAll(strings.ToLower(identifier), `"`, `""`))
+}
+
+func (d *PostgresDialect) GenColumnDatatype(datatypeName string, modifiers ...int) string {
+	if _, ok := postgresModifierDatatypes[common.StringUPPER(datatypeName)]; !ok {
+		return datatypeName
+	}
+	return genColumnDatatype(datatypeName, modifiers...)
+}
+
+// number(38,127) 等 scale 大于 precision 使用不限精度 NUMERIC
+func (d *PostgresDialect) GenNumberDatatype(dataPrecision, dataScale int) string {
+	switch {
+	case dataScale > 0:
+		if dataScale > dataPrecision {
+			return "NUMERIC"
+		}
+		return fmt.Sprintf("NUMERIC(%d,%d)", dataPrecision, dataScale)
+	default:
+		switch {
+		case dataPrecision >= 1 && dataPrecision < 5:
+			return "SMALLINT"
+		case dataPrecision >= 5 && dataPrecision < 10:
+			return "INTEGER"
+		case dataPrecision >= 10 && dataPrecision < 19:
+			return "BIGINT"
+		case dataPrecision >= 19 && dataPrecision <= 38:
+			return fmt.Sprintf("NUMERIC(%d)", dataPrecision)
+		default:
+			return "NUMERIC"
+		}
+	}
+}
+
+// PostgreSQL 排序规则基于 locale，不沿用 Oracle collation 映射；字段注释独立 COMMENT ON 语句输出
+func (d *PostgresDialect) GenColumnDefinition(columnName, columnType, columnCollation, nullable, dataDefault, comment string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s %s", d.QuoteIdentifier(columnName), columnType))
+	if dataDefault != "" {
+		b.WriteString(fmt.Sprintf(" DEFAULT %s", dataDefault))
+	}
+	if nullable != "NULL" {
+		b.WriteString(fmt.Sprintf(" %s", nullable))
+	}
+	return b.String()
+}
+
+func (d *PostgresDialect) GenTableOption(tableCollation string) string {
+	return ""
+}
+
+func (d *PostgresDialect) IsInlineComment() bool {
+	return false
+}
+
+func (d *PostgresDialect) GenTableComment(schemaName, tableName, comment string) string {
+	return fmt.Sprintf("COMMENT ON TABLE %s.%s IS '%s';",
+		d.QuoteIdentifier(schemaName), d.QuoteIdentifier(tableName), strings.ReplaceAll(comment, "'", "''"))
+}
+
+func (d *PostgresDialect) GenColumnComment(schemaName, tableName, columnName, comment string) string {
+	return fmt.Sprintf("COMMENT ON COLUMN %s.%s.%s IS '%s';",
+		d.QuoteIdentifier(schemaName), d.QuoteIdentifier(tableName), d.QuoteIdentifier(columnName), strings.ReplaceAll(comment, "'", "''"))
+}
+
+func (d *PostgresDialect) IsSupportForeignKey() bool {
+	return true
+}
+
+func (d *PostgresDialect) IsSupportCheckKey(dbVersion string) bool {
+	return true
+}
+
+func (d *PostgresDialect) GenPrimaryKey(columns []string) string {
+	return fmt.Sprintf("PRIMARY KEY (%s)", quoteIdentifiers(d, columns))
+}
+
+func (d *PostgresDialect) GenUniqueKey(constraintName string, columns []string) string {
+	return fmt.Sprintf("CONSTRAINT %s UNIQUE (%s)", d.QuoteIdentifier(constraintName), quoteIdentifiers(d, columns))
+}
+
+func (d *PostgresDialect) GenForeignKey(constraintName string, columns []string, rSchemaName, rTableName string, rColumns []string, deleteRule string) string {
+	return genForeignKey(d, constraintName, columns, rSchemaName, rTableName, rColumns, deleteRule)
+}
+
+// Oracle 检查约束条件双引号标识符大写，转换小写与字段定义保持一致
+func (d *PostgresDialect) GenCheckKey(constraintName, searchCondition string) string {
+	searchCondition = postgresQuotedIdentifierReg.ReplaceAllStringFunc(searchCondition, strings.ToLower)
+	return fmt.Sprintf("CONSTRAINT %s CHECK (%s)", d.QuoteIdentifier(constraintName), searchCondition)
+}
+
+func (d *PostgresDialect) IsInlineIndex() bool {
+	return false
+}
+
+func (d *PostgresDialect) GenUniqueIndex(schemaName, tableName, indexName string, columns []string) string {
+	return fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s.%s (%s);", d.QuoteIdentifier(indexName),
+		d.QuoteIdentifier(schemaName), d.QuoteIdentifier(tableName), quoteIdentifiers(d, columns))
+}
+
+func (d *PostgresDialect) GenNormalIndex(schemaName, tableName, indexName string, columns []string) string {
+	return fmt.Sprintf("CREATE INDEX %s ON %s.%s (%s);", d.QuoteIdentifier(indexName),
+		d.QuoteIdentifier(schemaName), d.QuoteIdentifier(tableName), quoteIdentifiers(d, columns))
+}
+
+func (d *PostgresDialect) GenCreateSchema(schemaName, schemaCollation string) string {
+	return fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;", d.QuoteIdentifier(schemaName))
+}
+
+func genColumnDatatype(datatypeName string, modifiers ...int) string {
+	if len(modifiers) == 0 {
+		return datatypeName
+	}
+	var mods []string
+	for _, m := range modifiers {
+		mods = append(mods, fmt.Sprintf("%d", m))
+	}
+	return fmt.Sprintf("%s(%s)", datatypeName, strings.Join(mods, ","))
+}
+
+func quoteIdentifiers(d Dialect, identifiers []string) string {
+	var quoted []string
+	for _, i := range identifiers {
+		quoted = append(quoted, d.QuoteIdentifier(i))
+	}
+	return strings.Join(quoted, ",")
+}
+
+// 外键删除规则：NO ACTION / CASCADE / SET NULL
+func genForeignKey(d Dialect, constraintName string, columns []string, rSchemaName, rTableName string, rColumns []string, deleteRule string) string {
+	fk := fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s.%s (%s)",
+		d.QuoteIdentifier(constraintName),
+		quoteIdentifiers(d, columns),
+		d.QuoteIdentifier(rSchemaName),
+		d.QuoteIdentifier(rTableName),
+		quoteIdentifiers(d, rColumns))
+	switch common.StringUPPER(deleteRule) {
+	case "CASCADE":
+		return fmt.Sprintf("%s ON DELETE CASCADE", fk)
+	case "SET NULL":
+		return fmt.Sprintf("%s ON DELETE SET NULL", fk)
+	default:
+		return fk
+	}
+}
@@ -39,6 +39,8 @@ type Generator interface {
 	GenTableComment() (tableComment string, err error)
 	GenTableColumn() (columnMetas []string, err error)
 	GenTableColumnComment() (columnComments []string, err error)
+	GenTableSequence() (sequenceDDL []string, err error)
+	GenTableTrigger() (triggerDDL []string, err error)
 	GenCreateTableDDL() (reverseDDL string, checkKeyDDL []string, foreignKeyDDL []string, compatibleDDL []string, err error)
 
 	ChangeSchemaName() string
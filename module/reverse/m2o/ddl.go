@@ -36,6 +36,8 @@ type DDL struct {
 	CheckKeyDDL      []string `json:"check_key_ddl"`
 	ForeignKeyDDL    []string `json:"foreign_key_ddl"`
 	CompatibleDDL    []string `json:"compatible_ddl"`
+	SequenceDDL      []string `json:"sequence_ddl"`
+	TriggerDDL       []string `json:"trigger_ddl"`
 }
 
 func (d *DDL) Writer(f *reverse.File) error {
@@ -85,6 +87,15 @@ func (d *DDL) Writer(f *reverse.File) error {
 		sqlRev.WriteString(strings.Join(d.TableIndexDDL, "\n") + "\n")
 	}
 
+	// sequence 先于 trigger 创建
+	if len(d.SequenceDDL) > 0 {
+		sqlRev.WriteString(strings.Join(d.SequenceDDL, "\n") + "\n")
+	}
+
+	if len(d.TriggerDDL) > 0 {
+		sqlRev.WriteString(strings.Join(d.TriggerDDL, "\n") + "\n")
+	}
+
 	if len(d.CompatibleDDL) > 0 {
 		sqlComp.WriteString(strings.Join(d.CompatibleDDL, "\n") + "\n")
 	}
@@ -0,0 +1,185 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package m2o
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"hash/crc32"
+	"regexp"
+	"strings"
+)
+
+// 字符集引导符，例如 _utf8mb4'xxx'
+var charsetIntroducerReg = regexp.MustCompile(`(^|[^A-Za-z0-9_$])_[A-Za-z0-9]+'`)
+
+// MySQL 字段 EXTRA 特性判断
+// 生成列 EXTRA: VIRTUAL GENERATED / STORED GENERATED，MySQL 8.0 默认值表达式 DEFAULT_GENERATED 无 GENERATION_EXPRESSION
+func isMySQLColumnAutoIncrement(rowCol map[string]string) bool {
+	return strings.Contains(strings.ToLower(rowCol["EXTRA"]), "auto_increment")
+}
+
+func isMySQLColumnGenerated(rowCol map[string]string) bool {
+	return strings.Contains(strings.ToLower(rowCol["EXTRA"]), "generated") && rowCol["GENERATION_EXPRESSION"] != ""
+}
+
+func isMySQLColumnStoredGenerated(rowCol map[string]string) bool {
+	return strings.Contains(strings.ToLower(rowCol["EXTRA"]), "stored generated")
+}
+
+func isMySQLColumnOnUpdate(rowCol map[string]string) bool {
+	return strings.Contains(strings.ToLower(rowCol["EXTRA"]), "on update current_timestamp")
+}
+
+// Oracle 12c 及以上版本支持 IDENTITY 字段
+func isOracleSupportIdentityColumn(oracleDBVersion string) bool {
+	return common.VersionOrdinal(oracleDBVersion) >= common.VersionOrdinal(common.OracleIdentityColumnDBVersion)
+}
+
+// 解析 ENUM/SET COLUMN_TYPE 值列表，例如 enum('a','b') -> [a b]，单引号转义还原
+func parseMySQLColumnTypeValues(columnType string) []string {
+	var values []string
+
+	leftIdx := strings.Index(columnType, "(")
+	rightIdx := strings.LastIndex(columnType, ")")
+	if leftIdx < 0 || rightIdx <= leftIdx {
+		return values
+	}
+	elems := columnType[leftIdx+1 : rightIdx]
+
+	var (
+		builder strings.Builder
+		inQuote bool
+	)
+	for i := 0; i < len(elems); i++ {
+		c := elems[i]
+		switch {
+		case c == '\'' && !inQuote:
+			inQuote = true
+		case c == '\'' && inQuote:
+			// '' 转义单引号
+			if i+1 < len(elems) && elems[i+1] == '\'' {
+				builder.WriteByte(c)
+				i++
+				continue
+			}
+			inQuote = false
+			values = append(values, builder.String())
+			builder.Reset()
+		case c == '\\' && inQuote && i+1 < len(elems):
+			builder.WriteByte(elems[i+1])
+			i++
+		case inQuote:
+			builder.WriteByte(c)
+		}
+	}
+	return values
+}
+
+// 生成 ENUM/SET 字段 CHECK 约束条件
+// ENUM -> COL IN ('a','b')
+// SET  -> REGEXP_LIKE(COL, '^(a|b)(,(a|b))*$')
+func genMySQLColumnCheckCondition(columnName, dataType, columnType string) string {
+	values := parseMySQLColumnTypeValues(columnType)
+	if len(values) == 0 {
+		return ""
+	}
+
+	switch common.StringUPPER(dataType) {
+	case common.BuildInMySQLDatatypeEnum:
+		var literals []string
+		for _, v := range values {
+			literals = append(literals, fmt.Sprintf("'%s'", strings.ReplaceAll(v, "'", "''")))
+		}
+		return fmt.Sprintf("%s IN (%s)", columnName, strings.Join(literals, ","))
+	case common.BuildInMySQLDatatypeSet:
+		var patterns []string
+		for _, v := range values {
+			patterns = append(patterns, strings.ReplaceAll(regexp.QuoteMeta(v), "'", "''"))
+		}
+		elem := fmt.Sprintf("(%s)", strings.Join(patterns, "|"))
+		return fmt.Sprintf("REGEXP_LIKE(%s, '^%s(,%s)*$')", columnName, elem, elem)
+	default:
+		return ""
+	}
+}
+
+// 生成列表达式去除反引号以及字符集引导符，函数差异需人工确认
+func genOracleVirtualColumnExpr(generationExpr string) string {
+	expr := strings.ReplaceAll(generationExpr, "`", "")
+	return charsetIntroducerReg.ReplaceAllString(expr, "$1'")
+}
+
+// 生成 Oracle 对象名，超出版本长度限制使用 CRC32 缩短
+func genOracleObjectName(oracleDBVersion, prefix string, names ...string) string {
+	limit := common.OracleObjectNameLengthLimit
+	if common.VersionOrdinal(oracleDBVersion) >= common.VersionOrdinal(common.OracleTableColumnCollationDBVersion) {
+		limit = common.OracleObjectNameLengthLimitV2
+	}
+	objectName := common.StringUPPER(strings.Join(append([]string{prefix}, names...), "_"))
+	if len(objectName) <= limit {
+		return objectName
+	}
+	return common.StringUPPER(fmt.Sprintf("%s_%08X", prefix, crc32.ChecksumIEEE([]byte(strings.Join(names, ".")))))
+}
+
+// 字段特性转换说明，用于兼容性输出
+func genMySQLColumnFeatures(rowCol map[string]string, oracleDBVersion string) []map[string]string {
+	var features []map[string]string
+
+	dataType := common.StringUPPER(rowCol["DATA_TYPE"])
+	if dataType == common.BuildInMySQLDatatypeEnum || dataType == common.BuildInMySQLDatatypeSet {
+		features = append(features, map[string]string{
+			"ColumnName": rowCol["COLUMN_NAME"],
+			"ColumnType": rowCol["COLUMN_TYPE"],
+			"Feature":    dataType,
+			"Suggest":    "VARCHAR2 + CHECK CONSTRAINT",
+		})
+	}
+	if isMySQLColumnAutoIncrement(rowCol) {
+		suggest := "SEQUENCE + BEFORE INSERT TRIGGER"
+		if isOracleSupportIdentityColumn(oracleDBVersion) {
+			suggest = "IDENTITY COLUMN"
+		}
+		features = append(features, map[string]string{
+			"ColumnName": rowCol["COLUMN_NAME"],
+			"ColumnType": rowCol["COLUMN_TYPE"],
+			"Feature":    common.MySQLColumnFeatureAutoIncrement,
+			"Suggest":    suggest,
+		})
+	}
+	if isMySQLColumnGenerated(rowCol) {
+		suggest := "VIRTUAL COLUMN, Manual Check Expression"
+		if isMySQLColumnStoredGenerated(rowCol) {
+			suggest = "VIRTUAL COLUMN (STORED Isn't Support), Manual Check Expression"
+		}
+		features = append(features, map[string]string{
+			"ColumnName": rowCol["COLUMN_NAME"],
+			"ColumnType": rowCol["COLUMN_TYPE"],
+			"Feature":    common.MySQLColumnFeatureGenerated,
+			"Suggest":    suggest,
+		})
+	}
+	if isMySQLColumnOnUpdate(rowCol) {
+		features = append(features, map[string]string{
+			"ColumnName": rowCol["COLUMN_NAME"],
+			"ColumnType": rowCol["COLUMN_TYPE"],
+			"Feature":    common.MySQLColumnFeatureOnUpdate,
+			"Suggest":    "BEFORE UPDATE TRIGGER",
+		})
+	}
+	return features
+}
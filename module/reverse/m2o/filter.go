@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/logger"
 	"go.uber.org/zap"
 	"time"
 )
@@ -48,7 +49,7 @@ func filterCFGTable(cfg *config.Config, mysql *mysql.MySQL) ([]string, []string,
 
 	endTime := time.Now()
 	zap.L().Info("get mysql to oracle all tables",
-		zap.String("schema", cfg.MySQLConfig.SchemaName),
+		logger.SchemaS(cfg.MySQLConfig.SchemaName),
 		//zap.Strings("exporter tables list", allTables),
 		zap.Int("all table counts", len(normalTables)+len(viewTables)),
 		zap.String("cost", endTime.Sub(startTime).String()))
@@ -65,24 +65,26 @@ func IReader(ctx context.Context, mysql *mysql.MySQL, oracle *oracle.Oracle, met
 	}
 
 	return &Rule{
-		Ctx:               ctx,
-		SourceSchema:      t.SourceSchemaName,
-		SourceTableName:   t.SourceTableName,
-		TargetSchema:      t.TargetSchemaName,
-		TargetTableName:   t.TargetTableName,
-		PrimaryKeyINFO:    primaryKey,
-		UniqueKeyINFO:     uniqueKey,
-		ForeignKeyINFO:    foreignKey,
-		CheckKeyINFO:      checkKey,
-		UniqueIndexINFO:   uniqueIndex,
-		NormalIndexINFO:   normalIndex,
-		TableCommentINFO:  tableComment,
-		TableColumnINFO:   columnMeta,
-		ColumnCommentINFO: columnComment,
-		IsPartition:       t.IsPartition,
-		Oracle:            oracle,
-		MySQL:             mysql,
-		MetaDB:            metaDB,
+		Ctx:                ctx,
+		SourceSchema:       t.SourceSchemaName,
+		SourceTableName:    t.SourceTableName,
+		TargetSchema:       t.TargetSchemaName,
+		TargetTableName:    t.TargetTableName,
+		PrimaryKeyINFO:     primaryKey,
+		UniqueKeyINFO:      uniqueKey,
+		ForeignKeyINFO:     foreignKey,
+		CheckKeyINFO:       checkKey,
+		UniqueIndexINFO:    uniqueIndex,
+		NormalIndexINFO:    normalIndex,
+		TableCommentINFO:   tableComment,
+		TableColumnINFO:    columnMeta,
+		ColumnCommentINFO:  columnComment,
+		IsPartition:        t.IsPartition,
+		OracleDBVersion:    t.OracleDBVersion,
+		OracleExtendedMode: t.OracleExtendedMode,
+		Oracle:             oracle,
+		MySQL:              mysql,
+		MetaDB:             metaDB,
 	}, nil
 }
 
@@ -107,6 +109,16 @@ func IReverse(t *Table, s reverse.Generator) (*DDL, error) {
 	}
 	compatibleDDL = append(compatibleDDL, normalIndexCompSQL...)
 
+	sequenceDDL, err := s.GenTableSequence()
+	if err != nil {
+		return nil, fmt.Errorf("mysql db reverse table auto_increment sequence failed: %v", err)
+	}
+
+	triggerDDL, err := s.GenTableTrigger()
+	if err != nil {
+		return nil, fmt.Errorf("mysql db reverse table auto_increment or on update trigger failed: %v", err)
+	}
+
 	return &DDL{
 		SourceSchemaName: t.SourceSchemaName,
 		SourceTableName:  t.SourceTableName,
@@ -120,6 +132,8 @@ func IReverse(t *Table, s reverse.Generator) (*DDL, error) {
 		ForeignKeyDDL:    foreignKeyDDL,
 		CompatibleDDL:    compatibleDDL,
 		TableIndexDDL:    normalIndexDDL,
+		SequenceDDL:      sequenceDDL,
+		TriggerDDL:       triggerDDL,
 		IsPartition:      t.IsPartition,
 	}, nil
 }
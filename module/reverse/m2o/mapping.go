@@ -319,18 +319,26 @@ func MySQLTableColumnMapRule(sourceSchema, sourceTable string, column Column, bu
 			return originColumnType, buildInColumnType, fmt.Errorf("mysql table column type [%s] map oracle column type rule isn't exist, please checkin", common.BuildInMySQLDatatypeLongBlob)
 		}
 	case common.BuildInMySQLDatatypeEnum:
-		// originColumnType = "ENUM"
-		// buildInColumnType = fmt.Sprintf("VARCHAR2(%d CHAR)", dataLength)
-		// return originColumnType, buildInColumnType, nil
-		// columnMeta = reverse.GenTableColumnMeta(utils.ReverseModeM2O, columnName, modifyColumnType, dataNullable, comments, rule.DataDefault, columnCollation, defaultValueMapSlice)
-		return originColumnType, buildInColumnType, fmt.Errorf("oracle isn't support data type ENUM, please manual check")
+		// ENUM 转换 VARCHAR2，枚举值范围由 CHECK 约束保证
+		// DATA_LENGTH 为枚举值最大字符长度
+		originColumnType = common.BuildInMySQLDatatypeEnum
+		if val, ok := buildinDatatypeMap[common.BuildInMySQLDatatypeEnum]; ok {
+			buildInColumnType = fmt.Sprintf("%s(%d CHAR)", common.StringUPPER(val), dataLength)
+			return originColumnType, buildInColumnType, nil
+		} else {
+			return originColumnType, buildInColumnType, fmt.Errorf("mysql table column type [%s] map oracle column type rule isn't exist, please checkin", common.BuildInMySQLDatatypeEnum)
+		}
 
 	case common.BuildInMySQLDatatypeSet:
-		// originColumnType = "SET"
-		// buildInColumnType = fmt.Sprintf("VARCHAR2(%d CHAR)", dataLength)
-		// return originColumnType, buildInColumnType, nil
-		// columnMeta = reverse.GenTableColumnMeta(utils.ReverseModeM2O, columnName, modifyColumnType, dataNullable, comments, rule.DataDefault, columnCollation, defaultValueMapSlice)
-		return originColumnType, buildInColumnType, fmt.Errorf("oracle isn't support data type SET, please manual check")
+		// SET 转换 VARCHAR2，集合值范围由 CHECK 约束保证
+		// DATA_LENGTH 为全部集合值逗号拼接字符长度
+		originColumnType = common.BuildInMySQLDatatypeSet
+		if val, ok := buildinDatatypeMap[common.BuildInMySQLDatatypeSet]; ok {
+			buildInColumnType = fmt.Sprintf("%s(%d CHAR)", common.StringUPPER(val), dataLength)
+			return originColumnType, buildInColumnType, nil
+		} else {
+			return originColumnType, buildInColumnType, fmt.Errorf("mysql table column type [%s] map oracle column type rule isn't exist, please checkin", common.BuildInMySQLDatatypeSet)
+		}
 
 	default:
 		return originColumnType, buildInColumnType, fmt.Errorf("mysql schema [%s] table [%s] reverser column meta info [%s] failed", sourceSchema, sourceTable, common.StringUPPER(column.DataType))
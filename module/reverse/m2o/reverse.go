@@ -23,7 +23,9 @@ import (
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/logger"
 	"github.com/wentaojin/transferdb/module/reverse"
+	"github.com/wentaojin/transferdb/signal"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	"os"
@@ -51,8 +53,7 @@ func NewM2OReverse(ctx context.Context, cfg *config.Config, mysql *mysql.MySQL,
 
 func (r *Reverse) NewReverse() error {
 	startTime := time.Now()
-	zap.L().Info("reverse table mysql to oracle start",
-		zap.String("schema", r.cfg.MySQLConfig.SchemaName))
+	logger.L(r.ctx).Info("reverse table mysql to oracle start")
 
 	// 获取配置文件待同步表列表
 	exporters, viewTables, err := filterCFGTable(r.cfg, r.mysql)
@@ -61,8 +62,7 @@ func (r *Reverse) NewReverse() error {
 	}
 
 	if (len(exporters) + len(viewTables)) == 0 {
-		zap.L().Warn("there are no table objects in the mysql schema",
-			zap.String("schema", r.cfg.MySQLConfig.SchemaName))
+		logger.L(r.ctx).Warn("there are no table objects in the mysql schema")
 		return nil
 	}
 
@@ -83,6 +83,12 @@ func (r *Reverse) NewReverse() error {
 		return fmt.Errorf("get oracle db version falied: %v", err)
 	}
 
+	// 源端 MySQL 版本，低版本不支持生成列
+	mysqlDBVersion, err := r.mysql.GetMySQLDBVersion()
+	if err != nil {
+		return fmt.Errorf("get mysql db version falied: %v", err)
+	}
+
 	// Oracle 12.2 版本及以上，column collation extended 模式检查
 	isExtended := false
 
@@ -93,7 +99,7 @@ func (r *Reverse) NewReverse() error {
 		}
 	}
 
-	reverseTaskTables, errCompatibility, tableCharSetMap, tableCollationMap, err := PreCheckCompatibility(r.cfg, r.mysql, exporters, oracleDBVersion, isExtended)
+	reverseTaskTables, errCompatibility, featureCompatibility, tableCharSetMap, tableCollationMap, err := PreCheckCompatibility(r.cfg, r.mysql, exporters, oracleDBVersion, mysqlDBVersion, isExtended)
 	if err != nil {
 		return err
 	}
@@ -116,7 +122,7 @@ func (r *Reverse) NewReverse() error {
 		}
 	}
 
-	tables, err := GenReverseTableTask(r.cfg, r.mysql, r.oracle, tableNameRuleMap, reverseTaskTables, oracleDBVersion, isExtended, tableCharSetMap, tableCollationMap)
+	tables, err := GenReverseTableTask(r.cfg, r.mysql, r.oracle, tableNameRuleMap, reverseTaskTables, oracleDBVersion, mysqlDBVersion, isExtended, tableCharSetMap, tableCollationMap)
 	if err != nil {
 		return err
 	}
@@ -143,7 +149,7 @@ func (r *Reverse) NewReverse() error {
 	}
 
 	// 表类型不兼容项输出
-	err = GenCompatibilityTable(f, common.StringUPPER(r.cfg.MySQLConfig.SchemaName), errCompatibility, viewTables)
+	err = GenCompatibilityTable(f, common.StringUPPER(r.cfg.MySQLConfig.SchemaName), errCompatibility, featureCompatibility, viewTables)
 	if err != nil {
 		return err
 	}
@@ -152,7 +158,11 @@ func (r *Reverse) NewReverse() error {
 	g := &errgroup.Group{}
 	g.SetLimit(r.cfg.AppConfig.Threads)
 
+	// 收到优雅停止信号后不再调度新的表，进行中的表完成并写入文件后退出
 	for _, table := range tables {
+		if signal.IsStopping(r.ctx) {
+			break
+		}
 		t := table
 		g.Go(func() error {
 			rule, err := IReader(r.ctx, r.mysql, r.oracle, r.metaDB, t, t)
@@ -167,9 +177,8 @@ func (r *Reverse) NewReverse() error {
 					InfoDetail:  t.String(),
 					ErrorDetail: err.Error(),
 				}); err != nil {
-					zap.L().Error("reverse table mysql to oracle failed",
-						zap.String("schema", t.SourceSchemaName),
-						zap.String("table", t.SourceTableName),
+					logger.L(r.ctx).Error("reverse table mysql to oracle failed",
+						logger.TableS(t.SourceTableName),
 						zap.Error(
 							fmt.Errorf("reader table task failed, detail see [error_log_detail], please rerunning")))
 
@@ -189,9 +198,8 @@ func (r *Reverse) NewReverse() error {
 					InfoDetail:  t.String(),
 					ErrorDetail: err.Error(),
 				}); err != nil {
-					zap.L().Error("reverse table mysql to oracle failed",
-						zap.String("schema", t.SourceSchemaName),
-						zap.String("table", t.SourceTableName),
+					logger.L(r.ctx).Error("reverse table mysql to oracle failed",
+						logger.TableS(t.SourceTableName),
 						zap.Error(
 							fmt.Errorf("reverse table task failed, detail see [error_log_detail], please rerunning")))
 
@@ -211,9 +219,8 @@ func (r *Reverse) NewReverse() error {
 					InfoDetail:  t.String(),
 					ErrorDetail: err.Error(),
 				}); err != nil {
-					zap.L().Error("reverse table mysql to oracle failed",
-						zap.String("schema", t.SourceSchemaName),
-						zap.String("table", t.SourceTableName),
+					logger.L(r.ctx).Error("reverse table mysql to oracle failed",
+						logger.TableS(t.SourceTableName),
 						zap.Error(
 							fmt.Errorf("writer table task failed, detail see [error_log_detail], please rerunning")))
 
@@ -233,6 +240,9 @@ func (r *Reverse) NewReverse() error {
 	if err != nil {
 		return err
 	}
+	if signal.IsStopping(r.ctx) {
+		return signal.ErrGracefulStop
+	}
 
 	errTotals, err = meta.NewErrorLogDetailModel(r.metaDB).CountsErrorLogBySchema(r.ctx, &meta.ErrorLogDetail{
 		DBTypeS:     common.TaskDBMySQL,
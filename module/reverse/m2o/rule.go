@@ -23,27 +23,30 @@ import (
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/logger"
 	"go.uber.org/zap"
 	"regexp"
 	"strings"
 )
 
 type Rule struct {
-	Ctx               context.Context     `json:"-"`
-	SourceSchema      string              `json:"source_schema"`
-	SourceTableName   string              `json:"source_table_name"`
-	TargetSchema      string              `json:"target_schema"`
-	TargetTableName   string              `json:"target_table_name"`
-	PrimaryKeyINFO    []map[string]string `json:"primary_key_info"`
-	UniqueKeyINFO     []map[string]string `json:"unique_key_info"`
-	ForeignKeyINFO    []map[string]string `json:"foreign_key_info"`
-	CheckKeyINFO      []map[string]string `json:"check_key_info"`
-	UniqueIndexINFO   []map[string]string `json:"unique_index_info"`
-	NormalIndexINFO   []map[string]string `json:"normal_index_info"`
-	TableCommentINFO  []map[string]string `json:"table_comment_info"`
-	TableColumnINFO   []map[string]string `json:"table_column_info"`
-	ColumnCommentINFO []map[string]string `json:"column_comment_info"`
-	IsPartition       bool                `json:"is_partition"`
+	Ctx                context.Context     `json:"-"`
+	SourceSchema       string              `json:"source_schema"`
+	SourceTableName    string              `json:"source_table_name"`
+	TargetSchema       string              `json:"target_schema"`
+	TargetTableName    string              `json:"target_table_name"`
+	PrimaryKeyINFO     []map[string]string `json:"primary_key_info"`
+	UniqueKeyINFO      []map[string]string `json:"unique_key_info"`
+	ForeignKeyINFO     []map[string]string `json:"foreign_key_info"`
+	CheckKeyINFO       []map[string]string `json:"check_key_info"`
+	UniqueIndexINFO    []map[string]string `json:"unique_index_info"`
+	NormalIndexINFO    []map[string]string `json:"normal_index_info"`
+	TableCommentINFO   []map[string]string `json:"table_comment_info"`
+	TableColumnINFO    []map[string]string `json:"table_column_info"`
+	ColumnCommentINFO  []map[string]string `json:"column_comment_info"`
+	IsPartition        bool                `json:"is_partition"`
+	OracleDBVersion    string              `json:"oracle_db_version"`
+	OracleExtendedMode bool                `json:"oracle_extended_mode"`
 
 	MySQL  *mysql.MySQL   `json:"-"`
 	Oracle *oracle.Oracle `json:"-"`
@@ -105,10 +108,10 @@ func (r *Rule) GenCreateTableDDL() (reverseDDL string, checkKeyDDL []string, for
 
 	if len(checkKeyMetas) > 0 {
 		for _, ck := range checkKeyMetas {
-			ckSQL := fmt.Sprintf("ALTER TABLE `%s`.`%s` ADD %s;", targetSchema, targetTable, ck)
-			zap.L().Info("reverse",
-				zap.String("schema", targetSchema),
-				zap.String("table", targetTable),
+			ckSQL := fmt.Sprintf("ALTER TABLE %s.%s ADD %s;", targetSchema, targetTable, ck)
+			logger.L(r.Ctx).Info("reverse",
+				logger.SchemaT(targetSchema),
+				logger.TableT(targetTable),
 				zap.String("ck sql", ckSQL))
 
 			checkKeyDDL = append(checkKeyDDL, ckSQL)
@@ -123,15 +126,15 @@ func (r *Rule) GenCreateTableDDL() (reverseDDL string, checkKeyDDL []string, for
 	if len(foreignKeyDDL) > 0 {
 		for _, fk := range foreignKeyMetas {
 			addFkSQL := fmt.Sprintf("ALTER TABLE `%s`.`%s` ADD %s;", targetSchema, targetTable, fk)
-			zap.L().Info("reverse",
-				zap.String("schema", targetSchema),
-				zap.String("table", targetTable),
+			logger.L(r.Ctx).Info("reverse",
+				logger.SchemaT(targetSchema),
+				logger.TableT(targetTable),
 				zap.String("fk sql", addFkSQL))
 			foreignKeyDDL = append(foreignKeyDDL, addFkSQL)
 		}
 	}
 
-	zap.L().Info("reverse oracle table struct", zap.String("table", r.String()))
+	logger.L(r.Ctx).Info("reverse oracle table struct", logger.TableS(r.SourceTableName), zap.String("rule", r.String()))
 
 	return reverseDDL, checkKeyDDL, foreignKeyDDL, compatibleDDL, nil
 }
@@ -277,9 +280,94 @@ func (r *Rule) GenTableCheckKey() (checkKeyMetas []string, err error) {
 			checkKeyMetas = append(checkKeyMetas, ck)
 		}
 	}
+
+	// ENUM/SET -> VARCHAR2 + CHECK 约束
+	// 值区分大小写，不做大写转换
+	for _, rowCol := range r.TableColumnINFO {
+		condition := genMySQLColumnCheckCondition(rowCol["COLUMN_NAME"], rowCol["DATA_TYPE"], rowCol["COLUMN_TYPE"])
+		if condition == "" {
+			continue
+		}
+		ck := fmt.Sprintf("CONSTRAINT %s CHECK (%s)",
+			genOracleObjectName(r.OracleDBVersion, "CK", r.ChangeTableName(), rowCol["COLUMN_NAME"]),
+			condition)
+		checkKeyMetas = append(checkKeyMetas, ck)
+	}
 	return checkKeyMetas, nil
 }
 
+// AUTO_INCREMENT -> Oracle 12c 以下版本 SEQUENCE
+func (r *Rule) GenTableSequence() (sequenceDDL []string, err error) {
+	if isOracleSupportIdentityColumn(r.OracleDBVersion) {
+		return sequenceDDL, nil
+	}
+	targetSchema, targetTable := r.GenTablePrefix()
+	for _, rowCol := range r.TableColumnINFO {
+		if !isMySQLColumnAutoIncrement(rowCol) {
+			continue
+		}
+		sequenceDDL = append(sequenceDDL, fmt.Sprintf("CREATE SEQUENCE %s.%s START WITH %s INCREMENT BY 1;",
+			targetSchema,
+			genOracleObjectName(r.OracleDBVersion, "SEQ", targetTable, rowCol["COLUMN_NAME"]),
+			r.genTableAutoIncrement()))
+	}
+	return sequenceDDL, nil
+}
+
+// AUTO_INCREMENT -> Oracle 12c 以下版本 SEQUENCE + BEFORE INSERT TRIGGER
+// ON UPDATE CURRENT_TIMESTAMP -> BEFORE UPDATE TRIGGER，Oracle 不存在对应字段属性，全版本使用触发器
+func (r *Rule) GenTableTrigger() (triggerDDL []string, err error) {
+	targetSchema, targetTable := r.GenTablePrefix()
+
+	var onUpdateSets []string
+	for _, rowCol := range r.TableColumnINFO {
+		if isMySQLColumnAutoIncrement(rowCol) && !isOracleSupportIdentityColumn(r.OracleDBVersion) {
+			triggerDDL = append(triggerDDL, fmt.Sprintf(`CREATE OR REPLACE TRIGGER %s.%s
+BEFORE INSERT ON %s.%s
+FOR EACH ROW
+WHEN (NEW.%s IS NULL)
+BEGIN
+	SELECT %s.%s.NEXTVAL INTO :NEW.%s FROM DUAL;
+END;
+/`,
+				targetSchema, genOracleObjectName(r.OracleDBVersion, "TRG_AI", targetTable, rowCol["COLUMN_NAME"]),
+				targetSchema, targetTable,
+				rowCol["COLUMN_NAME"],
+				targetSchema, genOracleObjectName(r.OracleDBVersion, "SEQ", targetTable, rowCol["COLUMN_NAME"]), rowCol["COLUMN_NAME"]))
+		}
+		if isMySQLColumnOnUpdate(rowCol) {
+			// 显式更新字段时保持 MySQL 语义，不覆盖
+			currentTime := "SYSDATE"
+			if strings.EqualFold(rowCol["DATA_TYPE"], common.BuildInMySQLDatatypeTimestamp) {
+				currentTime = "SYSTIMESTAMP"
+			}
+			onUpdateSets = append(onUpdateSets, fmt.Sprintf("\tIF NOT UPDATING('%s') THEN\n\t\t:NEW.%s := %s;\n\tEND IF;",
+				rowCol["COLUMN_NAME"], rowCol["COLUMN_NAME"], currentTime))
+		}
+	}
+
+	if len(onUpdateSets) > 0 {
+		triggerDDL = append(triggerDDL, fmt.Sprintf(`CREATE OR REPLACE TRIGGER %s.%s
+BEFORE UPDATE ON %s.%s
+FOR EACH ROW
+BEGIN
+%s
+END;
+/`,
+			targetSchema, genOracleObjectName(r.OracleDBVersion, "TRG_OU", targetTable),
+			targetSchema, targetTable,
+			strings.Join(onUpdateSets, "\n")))
+	}
+	return triggerDDL, nil
+}
+
+func (r *Rule) genTableAutoIncrement() string {
+	if len(r.TableCommentINFO) > 0 && r.TableCommentINFO[0]["AUTO_INCREMENT"] != "" {
+		return r.TableCommentINFO[0]["AUTO_INCREMENT"]
+	}
+	return "1"
+}
+
 func (r *Rule) GenTableUniqueIndex() (uniqueIndexMetas []string, compatibilityIndexSQL []string, err error) {
 	// MySQL Unique Index = Unique Constraint
 	return
@@ -323,14 +411,8 @@ func (r *Rule) GenTableColumn() (columnMetas []string, err error) {
 		return columnMetas, err
 	}
 
-	oracleExtendedMode, err := r.Oracle.GetOracleExtendedMode()
-	if err != nil {
-		return columnMetas, err
-	}
-	oracleDBVersion, err := r.Oracle.GetOracleDBVersion()
-	if err != nil {
-		return columnMetas, err
-	}
+	oracleExtendedMode := r.OracleExtendedMode
+	oracleDBVersion := r.OracleDBVersion
 
 	for _, rowCol := range r.TableColumnINFO {
 		var (
@@ -427,6 +509,23 @@ func (r *Rule) GenTableColumn() (columnMetas []string, err error) {
 			return columnMetas, err
 		}
 
+		// 生成列 -> 虚拟列，Oracle 虚拟列不支持 DEFAULT 以及 COLLATE
+		if isMySQLColumnGenerated(rowCol) {
+			if strings.EqualFold(nullable, "NULL") {
+				columnMetas = append(columnMetas, fmt.Sprintf("%s %s GENERATED ALWAYS AS (%s) VIRTUAL", columnName, columnType, genOracleVirtualColumnExpr(rowCol["GENERATION_EXPRESSION"])))
+			} else {
+				columnMetas = append(columnMetas, fmt.Sprintf("%s %s GENERATED ALWAYS AS (%s) VIRTUAL %s", columnName, columnType, genOracleVirtualColumnExpr(rowCol["GENERATION_EXPRESSION"]), nullable))
+			}
+			continue
+		}
+
+		// AUTO_INCREMENT -> Oracle 12c 及以上 IDENTITY 字段，BY DEFAULT 允许数据迁移显式写入
+		// 以下版本由 SEQUENCE + TRIGGER 生成，字段定义不变
+		if isMySQLColumnAutoIncrement(rowCol) && isOracleSupportIdentityColumn(oracleDBVersion) {
+			columnMetas = append(columnMetas, fmt.Sprintf("%s %s GENERATED BY DEFAULT ON NULL AS IDENTITY (START WITH %s INCREMENT BY 1)", columnName, columnType, r.genTableAutoIncrement()))
+			continue
+		}
+
 		if strings.EqualFold(nullable, "NULL") {
 			// M2O
 			switch {
@@ -25,9 +25,11 @@ import (
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/logger"
 	"github.com/wentaojin/transferdb/module/reverse"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
+	"sort"
 	"strings"
 	"time"
 )
@@ -36,6 +38,7 @@ type Table struct {
 	Ctx                     context.Context `json:"-"`
 	MySQLDBType             string          `json:"mysqldb_type"`
 	OracleDBVersion         string          `json:"oracle_db_version"`
+	MySQLDBVersion          string          `json:"mysql_db_version"`
 	OracleExtendedMode      bool            `json:"oracle_extended_mode"`
 	SourceSchemaName        string          `json:"source_schema_name"`
 	TargetSchemaName        string          `json:"target_schema_name"`
@@ -49,23 +52,28 @@ type Table struct {
 	MySQL                   *mysql.MySQL    `json:"-"`
 }
 
-func PreCheckCompatibility(cfg *config.Config, mysql *mysql.MySQL, exporters []string, oracleDBVersion string, isExtended bool) ([]string, map[string][]map[string]string, map[string]string, map[string]string, error) {
+// PreCheckCompatibility 字符集、排序规则不兼容表过滤，字段特性（ENUM/SET、AUTO_INCREMENT、生成列、ON UPDATE）不过滤，仅输出转换说明
+func PreCheckCompatibility(cfg *config.Config, mysql *mysql.MySQL, exporters []string, oracleDBVersion, mysqlDBVersion string, isExtended bool) ([]string, map[string][]map[string]string, map[string][]map[string]string, map[string]string, map[string]string, error) {
 	// MySQL CharacterSet And Collation 过滤检查
 	tableCharSetMap := make(map[string]string)
 	tableCollationMap := make(map[string]string)
 
 	errCompatibility := make(map[string][]map[string]string)
+	featureCompatibility := make(map[string][]map[string]string)
 
 	var (
-		errCompINFO       []map[string]string
 		reverseTaskTables []string
 	)
 
 	for _, t := range exporters {
+		var (
+			errCompINFO     []map[string]string
+			featureCompINFO []map[string]string
+		)
 		// 检查表级别字符集以及排序规则
 		characterSet, collation, err := mysql.GetMySQLTableCharacterSetAndCollation(cfg.MySQLConfig.SchemaName, t)
 		if err != nil {
-			return []string{}, errCompatibility, tableCharSetMap, tableCollationMap, fmt.Errorf("get mysql table characterSet and collation falied: %v", err)
+			return []string{}, errCompatibility, featureCompatibility, tableCharSetMap, tableCollationMap, fmt.Errorf("get mysql table characterSet and collation falied: %v", err)
 		}
 		_, okTableCharacterSet := common.MySQLDBCharacterSetMap[common.StringUPPER(characterSet)]
 		_, okTableCollation := common.MySQLDBCollationMap[strings.ToLower(collation)]
@@ -82,9 +90,9 @@ func PreCheckCompatibility(cfg *config.Config, mysql *mysql.MySQL, exporters []s
 		// 检查表字段级别字符集以及排序规则
 		// 如果表级别字符集与字段级别字符集不一样，oracle 不支持
 		// 如果 Oracle 版本
-		columnsMap, err := mysql.GetMySQLTableColumn(cfg.MySQLConfig.SchemaName, t)
+		columnsMap, err := mysql.GetMySQLTableColumn(cfg.MySQLConfig.SchemaName, t, mysqlDBVersion)
 		if err != nil {
-			return []string{}, errCompatibility, tableCharSetMap, tableCollationMap, fmt.Errorf("get mysql table column characterSet and collation falied: %v", err)
+			return []string{}, errCompatibility, featureCompatibility, tableCharSetMap, tableCollationMap, fmt.Errorf("get mysql table column characterSet and collation falied: %v", err)
 		}
 
 		// 12.2 以下版本没有字段级别 collation，使用 oracledb 实例级别 collation
@@ -94,8 +102,7 @@ func PreCheckCompatibility(cfg *config.Config, mysql *mysql.MySQL, exporters []s
 				// 检查字段级别排序规则
 				_, ok := common.MySQLDBCollationMap[strings.ToLower(rowCol["COLLATION_NAME"])]
 
-				if common.IsContainString(common.OracleIsNotSupportDataType, rowCol["DATA_TYPE"]) ||
-					(!strings.EqualFold(rowCol["CHARACTER_SET_NAME"], "UNKNOWN") && !strings.EqualFold(rowCol["CHARACTER_SET_NAME"], characterSet)) ||
+				if (!strings.EqualFold(rowCol["CHARACTER_SET_NAME"], "UNKNOWN") && !strings.EqualFold(rowCol["CHARACTER_SET_NAME"], characterSet)) ||
 					(!ok && !strings.EqualFold(rowCol["COLLATION_NAME"], "UNKNOWN")) ||
 					(ok && !strings.EqualFold(rowCol["COLLATION_NAME"], "utf8mb4_bin")) ||
 					(ok && !strings.EqualFold(collation, "utf8mb4_bin")) ||
@@ -117,8 +124,7 @@ func PreCheckCompatibility(cfg *config.Config, mysql *mysql.MySQL, exporters []s
 				// 检查字段级别排序规则
 				_, ok := common.MySQLDBCollationMap[strings.ToLower(rowCol["COLLATION_NAME"])]
 
-				if common.IsContainString(common.OracleIsNotSupportDataType, rowCol["DATA_TYPE"]) ||
-					(!strings.EqualFold(rowCol["CHARACTER_SET_NAME"], "UNKNOWN") && !strings.EqualFold(rowCol["CHARACTER_SET_NAME"], characterSet)) ||
+				if (!strings.EqualFold(rowCol["CHARACTER_SET_NAME"], "UNKNOWN") && !strings.EqualFold(rowCol["CHARACTER_SET_NAME"], characterSet)) ||
 					(!ok && !strings.EqualFold(rowCol["COLLATION_NAME"], "UNKNOWN")) ||
 					(!isExtended && !strings.EqualFold(rowCol["COLLATION_NAME"], collation)) ||
 					(!isExtended && !strings.EqualFold(rowCol["CHARACTER_SET_NAME"], characterSet)) {
@@ -137,6 +143,14 @@ func PreCheckCompatibility(cfg *config.Config, mysql *mysql.MySQL, exporters []s
 			errCompatibility[common.StringUPPER(t)] = errCompINFO
 		}
 
+		// 字段特性转换说明，ENUM/SET、AUTO_INCREMENT 转换方式由 oracle 版本决定
+		for _, rowCol := range columnsMap {
+			featureCompINFO = append(featureCompINFO, genMySQLColumnFeatures(rowCol, oracleDBVersion)...)
+		}
+		if len(featureCompINFO) > 0 {
+			featureCompatibility[common.StringUPPER(t)] = featureCompINFO
+		}
+
 		_, okErrCharSet := errCompatibility[common.StringUPPER(t)]
 		// 筛选过滤不兼容表
 		// Skip 当前循环，继续
@@ -147,10 +161,10 @@ func PreCheckCompatibility(cfg *config.Config, mysql *mysql.MySQL, exporters []s
 		tableCollationMap[common.StringUPPER(t)] = collation
 		reverseTaskTables = append(reverseTaskTables, common.StringUPPER(t))
 	}
-	return reverseTaskTables, errCompatibility, tableCharSetMap, tableCollationMap, nil
+	return reverseTaskTables, errCompatibility, featureCompatibility, tableCharSetMap, tableCollationMap, nil
 }
 
-func GenReverseTableTask(cfg *config.Config, mysql *mysql.MySQL, oracle *oracle.Oracle, tableNameRule map[string]string, exporters []string, oracleDBVersion string, isExtended bool, tableCharSetMap map[string]string, tableCollationMap map[string]string) ([]*Table, error) {
+func GenReverseTableTask(cfg *config.Config, mysql *mysql.MySQL, oracle *oracle.Oracle, tableNameRule map[string]string, exporters []string, oracleDBVersion, mysqlDBVersion string, isExtended bool, tableCharSetMap map[string]string, tableCollationMap map[string]string) ([]*Table, error) {
 	var (
 		tables []*Table
 	)
@@ -160,7 +174,7 @@ func GenReverseTableTask(cfg *config.Config, mysql *mysql.MySQL, oracle *oracle.
 	defer func() {
 		endTime := time.Now()
 		zap.L().Info("gen oracle table list finished",
-			zap.String("schema", sourceSchema),
+			logger.SchemaS(sourceSchema),
 			zap.Int("table totals", len(exporters)),
 			zap.Int("table gens", len(tables)),
 			zap.String("cost", endTime.Sub(beginTime).String()))
@@ -193,6 +207,7 @@ func GenReverseTableTask(cfg *config.Config, mysql *mysql.MySQL, oracle *oracle.
 				tbl := &Table{
 					MySQLDBType:             cfg.MySQLConfig.DBType,
 					OracleDBVersion:         oracleDBVersion,
+					MySQLDBVersion:          mysqlDBVersion,
 					OracleExtendedMode:      isExtended,
 					SourceSchemaName:        common.StringUPPER(sourceSchema),
 					SourceTableName:         common.StringUPPER(ts),
@@ -229,7 +244,7 @@ func GenReverseTableTask(cfg *config.Config, mysql *mysql.MySQL, oracle *oracle.
 
 	endTime := time.Now()
 	zap.L().Info("gen mysql slice table finished",
-		zap.String("schema", sourceSchema),
+		logger.SchemaS(sourceSchema),
 		zap.Int("table totals", len(exporters)),
 		zap.Int("table gens", len(tables)),
 		zap.String("cost", endTime.Sub(startTime).String()))
@@ -270,7 +285,7 @@ func (t *Table) GetTableComment() ([]map[string]string, error) {
 }
 
 func (t *Table) GetTableColumnMeta() ([]map[string]string, error) {
-	return t.MySQL.GetMySQLTableColumn(t.SourceSchemaName, t.SourceTableName)
+	return t.MySQL.GetMySQLTableColumn(t.SourceSchemaName, t.SourceTableName, t.MySQLDBVersion)
 }
 
 func (t *Table) GetTableColumnComment() ([]map[string]string, error) {
@@ -306,13 +321,13 @@ func GenCreateSchema(f *reverse.File, sourceSchema, targetSchema string) error {
 	}
 	endTime := time.Now()
 	zap.L().Info("output mysql to oracle schema create sql",
-		zap.String("schema", sourceSchema),
+		logger.SchemaS(sourceSchema),
 		zap.String("cost", endTime.Sub(startTime).String()))
 
 	return nil
 }
 
-func GenCompatibilityTable(f *reverse.File, sourceSchema string, errCompatibility map[string][]map[string]string, viewTables []string) error {
+func GenCompatibilityTable(f *reverse.File, sourceSchema string, errCompatibility map[string][]map[string]string, featureCompatibility map[string][]map[string]string, viewTables []string) error {
 	startTime := time.Now()
 	// 兼容提示
 	if len(errCompatibility) > 0 {
@@ -346,6 +361,42 @@ func GenCompatibilityTable(f *reverse.File, sourceSchema string, errCompatibilit
 		}
 	}
 
+	// 字段特性转换提示
+	if len(featureCompatibility) > 0 {
+		var (
+			sqlComp    strings.Builder
+			tableNames []string
+		)
+		for tableName := range featureCompatibility {
+			tableNames = append(tableNames, tableName)
+		}
+		sort.Strings(tableNames)
+
+		sqlComp.WriteString("/*\n")
+		sqlComp.WriteString(" mysql table column feature oracle isn't the same, reverse has been converted, please check\n")
+		sqlComp.WriteString(" - mysql enum/set convert varchar2 and check constraint\n")
+		sqlComp.WriteString(" - mysql auto_increment convert identity column (oracle 12c and above) or sequence and trigger\n")
+		sqlComp.WriteString(" - mysql generated column convert virtual column, expression need manual check\n")
+		sqlComp.WriteString(" - mysql on update current_timestamp convert before update trigger\n")
+
+		t := table.NewWriter()
+		t.SetStyle(table.StyleLight)
+		t.AppendHeader(table.Row{"SCHEMA", "TABLE NAME", "COLUMN NAME", "COLUMN TYPE", "FEATURE", "SUGGEST"})
+		for _, tableName := range tableNames {
+			for _, featINFO := range featureCompatibility[tableName] {
+				t.AppendRows([]table.Row{
+					{sourceSchema, tableName, featINFO["ColumnName"], featINFO["ColumnType"], featINFO["Feature"], featINFO["Suggest"]},
+				})
+			}
+		}
+		sqlComp.WriteString(t.Render() + "\n")
+		sqlComp.WriteString("*/\n")
+
+		if _, err := f.CWriteString(sqlComp.String()); err != nil {
+			return err
+		}
+	}
+
 	if len(viewTables) > 0 {
 		var sqlComp strings.Builder
 
@@ -370,7 +421,7 @@ func GenCompatibilityTable(f *reverse.File, sourceSchema string, errCompatibilit
 
 	endTime := time.Now()
 	zap.L().Info("output mysql to oracle compatibility tips",
-		zap.String("schema", sourceSchema),
+		logger.SchemaS(sourceSchema),
 		zap.String("cost", endTime.Sub(startTime).String()))
 
 	return nil
@@ -21,6 +21,7 @@ import (
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/oracle"
 	"github.com/wentaojin/transferdb/filter"
+	"github.com/wentaojin/transferdb/logger"
 	"go.uber.org/zap"
 	"time"
 )
@@ -89,7 +90,7 @@ func filterCFGTable(cfg *config.Config, oracle *oracle.Oracle) ([]string, error)
 
 	endTime := time.Now()
 	zap.L().Info("get oracle to mysql all tables",
-		zap.String("schema", cfg.OracleConfig.SchemaName),
+		logger.SchemaS(cfg.OracleConfig.SchemaName),
 		zap.Strings("exporter tables list", exporterTableSlice),
 		zap.Int("include table counts", len(exporterTableSlice)),
 		zap.Int("exclude table counts", len(excludeTables)),
@@ -17,7 +17,6 @@ package o2m
 
 import (
 	"context"
-	"fmt"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/module/reverse"
 	"strings"
@@ -79,6 +78,8 @@ func IReader(ctx context.Context, metaDB *meta.Meta, t *Table, rd reverse.Reader
 		TableColumnINFO:   columnMeta,
 		ColumnCommentINFO: columnComment,
 		OracleCollation:   t.OracleCollation,
+		TableOptionRule:   t.TableOptionRule,
+		Dialect:           t.Dialect,
 		MetaDB:            metaDB,
 	}, nil
 }
@@ -100,7 +101,7 @@ func IReverse(t *Table, s reverse.Generator) (*DDL, error) {
 	var primaryColumns []string
 	if len(primaryKeyMap) > 0 {
 		for _, col := range strings.Split(primaryKeyMap[0]["COLUMN_LIST"], ",") {
-			primaryColumns = append(primaryColumns, fmt.Sprintf("`%s`", col))
+			primaryColumns = append(primaryColumns, t.Dialect.QuoteIdentifier(col))
 		}
 	}
 
@@ -109,11 +110,39 @@ func IReverse(t *Table, s reverse.Generator) (*DDL, error) {
 		return nil, err
 	}
 
-	tableSuffix, err := t.GenTableSuffix(primaryColumns, t.IsSingleIntegerPK(primaryColumns, columnMetas))
+	tableSuffix, err := t.GenTableSuffix(primaryColumns, t.IsSingleIntegerPK(primaryColumns, columnMetas), t.IsSingleBigintPK(primaryColumns, columnMetas))
 	if err != nil {
 		return nil, err
 	}
 
+	// 非内联索引、注释目标端独立语句输出
+	var indexDDL, commentDDL []string
+	if !t.Dialect.IsInlineIndex() {
+		uniqueIndexDDL, uniqueIndexCompSQL, err := s.GenTableUniqueIndex()
+		if err != nil {
+			return nil, err
+		}
+		normalIndexDDL, normalIndexCompSQL, err := s.GenTableNormalIndex()
+		if err != nil {
+			return nil, err
+		}
+		indexDDL = append(indexDDL, uniqueIndexDDL...)
+		indexDDL = append(indexDDL, normalIndexDDL...)
+		compatibleDDL = append(compatibleDDL, uniqueIndexCompSQL...)
+		compatibleDDL = append(compatibleDDL, normalIndexCompSQL...)
+	}
+	if !t.Dialect.IsInlineComment() {
+		if tableComment != "" {
+			commentDDL = append(commentDDL, tableComment)
+			tableComment = ""
+		}
+		columnComments, err := s.GenTableColumnComment()
+		if err != nil {
+			return nil, err
+		}
+		commentDDL = append(commentDDL, columnComments...)
+	}
+
 	return &DDL{
 		SourceSchemaName: t.SourceSchemaName,
 		SourceTableName:  t.SourceTableName,
@@ -128,6 +157,9 @@ func IReverse(t *Table, s reverse.Generator) (*DDL, error) {
 		CheckKeyDDL:      checkKeyDDL,
 		ForeignKeyDDL:    foreignKeyDDL,
 		CompatibleDDL:    compatibleDDL,
+		IndexDDL:         indexDDL,
+		CommentDDL:       commentDDL,
+		Dialect:          t.Dialect,
 	}, nil
 }
 
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/reverse"
 	"strconv"
 	"strings"
 )
@@ -44,7 +45,7 @@ type ColumnInfo struct {
 	Comment           string
 }
 
-func OracleTableColumnMapRule(sourceSchema, sourceTable string, column Column, buildinDatatypes []meta.BuildinDatatypeRule) (string, string, error) {
+func OracleTableColumnMapRule(sourceSchema, sourceTable string, column Column, buildinDatatypes []meta.BuildinDatatypeRule, dialect reverse.Dialect) (string, string, error) {
 	var (
 		// oracle 表原始字段类型
 		originColumnType string
@@ -82,261 +83,188 @@ func OracleTableColumnMapRule(sourceSchema, sourceTable string, column Column, b
 	switch common.StringUPPER(column.DataType) {
 	case common.BuildInOracleDatatypeNumber:
 		if _, ok := buildinDatatypeMap[common.BuildInOracleDatatypeNumber]; ok {
-			switch {
-			case dataScale > 0:
-				switch {
-				// oracle 真实数据类型 number(*) -> number(38,127)
-				// number  -> number(38,127)
-				// number(*,x) ->  number(38,x)
-				// decimal(x,y) -> y max 30
-				case dataPrecision == 38 && dataScale > 30:
-					originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
-					if _, ok = numberDatatypeMap["DECIMAL"]; ok {
-						buildInColumnType = fmt.Sprintf("DECIMAL(%d,%d)", 65, 30)
-					} else {
-						return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin mapping data type [DECIMAL]", originColumnType)
-					}
-				case dataPrecision == 38 && dataScale <= 30:
-					originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
-					if _, ok = numberDatatypeMap["DECIMAL"]; ok {
-						buildInColumnType = fmt.Sprintf("DECIMAL(%d,%d)", 65, dataScale)
-					} else {
-						return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin mapping data type [DECIMAL]", originColumnType)
-					}
-				default:
-					if dataScale <= 30 {
-						originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
-						if _, ok = numberDatatypeMap["DECIMAL"]; ok {
-							buildInColumnType = fmt.Sprintf("DECIMAL(%d,%d)", dataPrecision, dataScale)
-						} else {
-							return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin mapping data type [DECIMAL]", originColumnType)
-						}
-					} else {
-						originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
-						if _, ok = numberDatatypeMap["DECIMAL"]; ok {
-							buildInColumnType = fmt.Sprintf("DECIMAL(%d,%d)", dataPrecision, 30)
-						} else {
-							return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin mapping data type [DECIMAL]", originColumnType)
-						}
-					}
-				}
-			case dataScale == 0:
-				switch {
-				case dataPrecision >= 1 && dataPrecision < 3:
-					originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
-					if _, ok = numberDatatypeMap["TINYINT"]; ok {
-						buildInColumnType = "TINYINT"
-					} else {
-						return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin mapping data type [TINYINT]", originColumnType)
-					}
-				case dataPrecision >= 3 && dataPrecision < 5:
-					originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
-					if _, ok = numberDatatypeMap["SMALLINT"]; ok {
-						buildInColumnType = "SMALLINT"
-					} else {
-						return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin mapping data type [SMALLINT]", originColumnType)
-					}
-				case dataPrecision >= 5 && dataPrecision < 9:
-					originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
-					if _, ok = numberDatatypeMap["INT"]; ok {
-						buildInColumnType = "INT"
-					} else {
-						return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin mapping data type [INT]", originColumnType)
-					}
-				case dataPrecision >= 9 && dataPrecision < 19:
-					originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
-					if _, ok = numberDatatypeMap["BIGINT"]; ok {
-						buildInColumnType = "BIGINT"
-					} else {
-						return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin mapping data type [BIGINT]", originColumnType)
-					}
-				case dataPrecision >= 19 && dataPrecision <= 38:
-					originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
-					if _, ok = numberDatatypeMap["DECIMAL"]; ok {
-						buildInColumnType = fmt.Sprintf("DECIMAL(%d)", dataPrecision)
-					} else {
-						return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin mapping data type [DECIMAL]", originColumnType)
-					}
-				default:
-					originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
-					if _, ok = numberDatatypeMap["DECIMAL"]; ok {
-						buildInColumnType = fmt.Sprintf("DECIMAL(%d,%d)", 65, dataScale)
-					} else {
-						return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin mapping data type [DECIMAL]", originColumnType)
-					}
+			// oracle 真实数据类型 number(*) -> number(38,127)
+			// number  -> number(38,127)
+			// number(*,x) ->  number(38,x)
+			// 目标端精度范围差异由 dialect 处理
+			if dataScale >= 0 {
+				originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumber, dataPrecision, dataScale)
+				buildInColumnType = dialect.GenNumberDatatype(dataPrecision, dataScale)
+				if _, ok = numberDatatypeMap[strings.Split(buildInColumnType, "(")[0]]; !ok {
+					return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin mapping data type [%s]", originColumnType, strings.ToLower(dialect.DBTypeT()), strings.Split(buildInColumnType, "(")[0])
 				}
 			}
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeNumber)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeNumber, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeBfile:
 		originColumnType = common.BuildInOracleDatatypeBfile
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeBfile]; ok {
-			buildInColumnType = fmt.Sprintf("%s(255)", common.StringUPPER(val))
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val), 255)
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeBfile)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeBfile, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeChar:
 		originColumnType = fmt.Sprintf("%s(%d)", common.BuildInOracleDatatypeChar, dataLength)
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeChar]; ok {
-			buildInColumnType = fmt.Sprintf("%s(%d)", common.StringUPPER(val), dataLength)
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val), dataLength)
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeChar)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeChar, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeCharacter:
 		originColumnType = fmt.Sprintf("%s(%d)", common.BuildInOracleDatatypeCharacter, dataLength)
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeCharacter]; ok {
-			buildInColumnType = fmt.Sprintf("%s(%d)", common.StringUPPER(val), dataLength)
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val), dataLength)
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeCharacter)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeCharacter, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeClob:
 		originColumnType = common.BuildInOracleDatatypeClob
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeClob]; ok {
-			buildInColumnType = common.StringUPPER(val)
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val))
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeClob)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeClob, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeBlob:
 		originColumnType = common.BuildInOracleDatatypeBlob
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeBlob]; ok {
-			buildInColumnType = common.StringUPPER(val)
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val))
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeBlob)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeBlob, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeDate:
 		originColumnType = common.BuildInOracleDatatypeDate
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeDate]; ok {
-			buildInColumnType = common.StringUPPER(val)
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val))
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeDate)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeDate, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeDecimal:
 		originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeDecimal, dataPrecision, dataScale)
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeDecimal]; ok {
-			buildInColumnType = fmt.Sprintf("%s(%d,%d)", common.StringUPPER(val), dataPrecision, dataScale)
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val), dataPrecision, dataScale)
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeDecimal)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeDecimal, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeDec:
 		originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeDecimal, dataPrecision, dataScale)
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeDec]; ok {
-			buildInColumnType = fmt.Sprintf("%s(%d,%d)", common.StringUPPER(val), dataPrecision, dataScale)
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val), dataPrecision, dataScale)
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeDec)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeDec, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeDoublePrecision:
 		originColumnType = common.BuildInOracleDatatypeDoublePrecision
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeDoublePrecision]; ok {
-			buildInColumnType = fmt.Sprintf("%s", common.StringUPPER(val))
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val))
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeDoublePrecision)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeDoublePrecision, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeFloat:
 		originColumnType = common.BuildInOracleDatatypeFloat
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeFloat]; ok {
-			buildInColumnType = fmt.Sprintf("%s", common.StringUPPER(val))
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val))
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeFloat)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeFloat, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeInteger:
 		originColumnType = common.BuildInOracleDatatypeInteger
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeInteger]; ok {
-			buildInColumnType = fmt.Sprintf("%s", common.StringUPPER(val))
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val))
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeInteger)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeInteger, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeInt:
 		originColumnType = common.BuildInOracleDatatypeInteger
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeInt]; ok {
-			buildInColumnType = fmt.Sprintf("%s", common.StringUPPER(val))
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val))
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeInt)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeInt, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeLong:
 		originColumnType = common.BuildInOracleDatatypeLong
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeLong]; ok {
-			buildInColumnType = fmt.Sprintf("%s", common.StringUPPER(val))
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val))
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeLong)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeLong, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeLongRAW:
 		originColumnType = common.BuildInOracleDatatypeLongRAW
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeLongRAW]; ok {
-			buildInColumnType = fmt.Sprintf("%s", common.StringUPPER(val))
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val))
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeLongRAW)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeLongRAW, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeBinaryFloat:
 		originColumnType = common.BuildInOracleDatatypeBinaryFloat
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeBinaryFloat]; ok {
-			buildInColumnType = fmt.Sprintf("%s", common.StringUPPER(val))
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val))
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeBinaryFloat)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeBinaryFloat, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeBinaryDouble:
 		originColumnType = common.BuildInOracleDatatypeBinaryDouble
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeBinaryDouble]; ok {
-			buildInColumnType = fmt.Sprintf("%s", common.StringUPPER(val))
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val))
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeBinaryDouble)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeBinaryDouble, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeNchar:
 		originColumnType = fmt.Sprintf("%s(%d)", common.BuildInOracleDatatypeNchar, dataLength)
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeNchar]; ok {
-			buildInColumnType = fmt.Sprintf("%s(%d)", common.StringUPPER(val), dataLength)
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val), dataLength)
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeNchar)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeNchar, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeNcharVarying:
 		originColumnType = fmt.Sprintf("%s(%d)", common.BuildInOracleDatatypeNcharVarying, dataLength)
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeNcharVarying]; ok {
-			buildInColumnType = fmt.Sprintf("%s(%d)", common.StringUPPER(val), dataLength)
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val), dataLength)
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeNcharVarying)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeNcharVarying, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeNclob:
 		originColumnType = common.BuildInOracleDatatypeNclob
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeNclob]; ok {
-			buildInColumnType = fmt.Sprintf("%s", common.StringUPPER(val))
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val))
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeNclob)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeNclob, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeNumeric:
 		originColumnType = fmt.Sprintf("%s(%d,%d)", common.BuildInOracleDatatypeNumeric, dataPrecision, dataScale)
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeNumeric]; ok {
-			buildInColumnType = fmt.Sprintf("%s(%d,%d)", common.StringUPPER(val), dataPrecision, dataScale)
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val), dataPrecision, dataScale)
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeNumeric)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeNumeric, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeNvarchar2:
 		originColumnType = fmt.Sprintf("%s(%d)", common.BuildInOracleDatatypeNvarchar2, dataLength)
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeNvarchar2]; ok {
-			buildInColumnType = fmt.Sprintf("%s(%d)", common.StringUPPER(val), dataLength)
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val), dataLength)
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeNvarchar2)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeNvarchar2, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeRaw:
 		originColumnType = fmt.Sprintf("%s(%d)", common.BuildInOracleDatatypeRaw, dataLength)
@@ -347,99 +275,99 @@ func OracleTableColumnMapRule(sourceSchema, sourceTable string, column Column, b
 		//	buildInColumnType = fmt.Sprintf("VARBINARY(%d)", dataLength)
 		//}
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeRaw]; ok {
-			buildInColumnType = fmt.Sprintf("%s(%d)", common.StringUPPER(val), dataLength)
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val), dataLength)
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeRaw)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeRaw, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeReal:
 		originColumnType = common.BuildInOracleDatatypeReal
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeReal]; ok {
-			buildInColumnType = fmt.Sprintf("%s", common.StringUPPER(val))
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val))
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeReal)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeReal, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeRowid:
 		originColumnType = common.BuildInOracleDatatypeRowid
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeRowid]; ok {
-			buildInColumnType = fmt.Sprintf("%s(10)", common.StringUPPER(val))
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val), 10)
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeRowid)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeRowid, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeSmallint:
 		originColumnType = common.BuildInOracleDatatypeSmallint
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeSmallint]; ok {
-			buildInColumnType = fmt.Sprintf("%s", common.StringUPPER(val))
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val))
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeSmallint)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeSmallint, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeUrowid:
 		originColumnType = common.BuildInOracleDatatypeUrowid
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeUrowid]; ok {
-			buildInColumnType = fmt.Sprintf("%s(%d)", common.StringUPPER(val), dataLength)
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val), dataLength)
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeUrowid)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeUrowid, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeVarchar2:
 		originColumnType = fmt.Sprintf("%s(%d)", common.BuildInOracleDatatypeVarchar2, dataLength)
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeVarchar2]; ok {
-			buildInColumnType = fmt.Sprintf("%s(%d)", common.StringUPPER(val), dataLength)
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val), dataLength)
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeVarchar2)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeVarchar2, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeVarchar:
 		originColumnType = fmt.Sprintf("%s(%d)", common.BuildInOracleDatatypeVarchar, dataLength)
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeVarchar]; ok {
-			buildInColumnType = fmt.Sprintf("%s(%d)", common.StringUPPER(val), dataLength)
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val), dataLength)
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeVarchar)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeVarchar, strings.ToLower(dialect.DBTypeT()))
 		}
 	case common.BuildInOracleDatatypeXmltype:
 		originColumnType = common.BuildInOracleDatatypeXmltype
 		if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeXmltype]; ok {
-			buildInColumnType = fmt.Sprintf("%s", common.StringUPPER(val))
+			buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val))
 			return originColumnType, buildInColumnType, nil
 		} else {
-			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.BuildInOracleDatatypeXmltype)
+			return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.BuildInOracleDatatypeXmltype, strings.ToLower(dialect.DBTypeT()))
 		}
 	default:
 		if strings.Contains(column.DataType, "INTERVAL YEAR") {
 			originColumnType = column.DataType
 			if val, ok := buildinDatatypeMap[common.StringUPPER(originColumnType)]; ok {
-				buildInColumnType = fmt.Sprintf("%s(30)", common.StringUPPER(val))
+				buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val), 30)
 				return originColumnType, buildInColumnType, nil
 			} else {
-				return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.StringUPPER(originColumnType))
+				return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.StringUPPER(originColumnType), strings.ToLower(dialect.DBTypeT()))
 			}
 		} else if strings.Contains(column.DataType, "INTERVAL DAY") {
 			originColumnType = column.DataType
 			if val, ok := buildinDatatypeMap[common.BuildInOracleDatatypeIntervalDay]; ok {
-				buildInColumnType = fmt.Sprintf("%s(30)", common.StringUPPER(val))
+				buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val), 30)
 				return originColumnType, buildInColumnType, nil
 			} else {
-				return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.StringUPPER(originColumnType))
+				return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.StringUPPER(originColumnType), strings.ToLower(dialect.DBTypeT()))
 			}
 		} else if strings.Contains(column.DataType, "TIMESTAMP") {
 			originColumnType = column.DataType
 			if dataScale <= 6 {
 				if val, ok := buildinDatatypeMap[common.StringUPPER(originColumnType)]; ok {
-					buildInColumnType = fmt.Sprintf("%s(%d)", common.StringUPPER(val), dataScale)
+					buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val), dataScale)
 					return originColumnType, buildInColumnType, nil
 				} else {
-					return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.StringUPPER(originColumnType))
+					return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.StringUPPER(originColumnType), strings.ToLower(dialect.DBTypeT()))
 				}
 			} else {
 				if val, ok := buildinDatatypeMap[common.StringUPPER(originColumnType)]; ok {
-					buildInColumnType = fmt.Sprintf("%s(%d)", common.StringUPPER(val), 6)
+					buildInColumnType = dialect.GenColumnDatatype(common.StringUPPER(val), 6)
 					return originColumnType, buildInColumnType, nil
 				} else {
-					return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map mysql column type rule isn't exist, please checkin", common.StringUPPER(originColumnType))
+					return originColumnType, buildInColumnType, fmt.Errorf("oracle table column type [%s] map %s column type rule isn't exist, please checkin", common.StringUPPER(originColumnType), strings.ToLower(dialect.DBTypeT()))
 				}
 			}
 		} else {
@@ -23,7 +23,9 @@ import (
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/logger"
 	"github.com/wentaojin/transferdb/module/reverse"
+	"github.com/wentaojin/transferdb/signal"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	"os"
@@ -33,27 +35,51 @@ import (
 )
 
 type Reverse struct {
-	ctx    context.Context
-	cfg    *config.Config
-	mysql  *mysql.MySQL
-	oracle *oracle.Oracle
-	metaDB *meta.Meta
+	ctx          context.Context
+	cfg          *config.Config
+	mysql        *mysql.MySQL
+	oracle       *oracle.Oracle
+	metaDB       *meta.Meta
+	targetDBType string
+	targetSchema string
+	runMode      string
 }
 
 func NewO2MReverse(ctx context.Context, cfg *config.Config, mysql *mysql.MySQL, oracle *oracle.Oracle, metaDB *meta.Meta) *Reverse {
 	return &Reverse{
-		ctx:    ctx,
-		cfg:    cfg,
-		mysql:  mysql,
-		oracle: oracle,
-		metaDB: metaDB,
+		ctx:          ctx,
+		cfg:          cfg,
+		mysql:        mysql,
+		oracle:       oracle,
+		metaDB:       metaDB,
+		targetDBType: cfg.MySQLConfig.DBType,
+		targetSchema: cfg.MySQLConfig.SchemaName,
+		runMode:      common.ReverseO2MMode,
+	}
+}
+
+// O2P 仅生成 PostgreSQL DDL 文件，无需连接目标端
+func NewO2PReverse(ctx context.Context, cfg *config.Config, oracle *oracle.Oracle, metaDB *meta.Meta) *Reverse {
+	return &Reverse{
+		ctx:          ctx,
+		cfg:          cfg,
+		oracle:       oracle,
+		metaDB:       metaDB,
+		targetDBType: common.TaskDBPostgres,
+		targetSchema: cfg.PostgresConfig.SchemaName,
+		runMode:      common.ReverseO2PMode,
 	}
 }
 
 func (r *Reverse) NewReverse() error {
 	startTime := time.Now()
-	zap.L().Info("reverse table r.oracle to mysql start",
-		zap.String("schema", r.cfg.OracleConfig.SchemaName))
+	logger.L(r.ctx).Info("reverse table r.oracle to mysql start",
+		zap.String("target db type", r.targetDBType))
+
+	dialect, err := reverse.NewDialect(r.targetDBType)
+	if err != nil {
+		return err
+	}
 
 	// 获取配置文件待同步表列表
 	exporters, err := filterCFGTable(r.cfg, r.oracle)
@@ -62,20 +88,19 @@ func (r *Reverse) NewReverse() error {
 	}
 
 	if len(exporters) == 0 {
-		zap.L().Warn("there are no table objects in the r.oracle schema",
-			zap.String("schema", r.cfg.OracleConfig.SchemaName))
+		logger.L(r.ctx).Warn("there are no table objects in the r.oracle schema")
 		return nil
 	}
 
 	// 判断 error_log_detail 是否存在错误记录，是否可进行 reverse
 	errTotals, err := meta.NewErrorLogDetailModel(r.metaDB).CountsErrorLogBySchema(r.ctx, &meta.ErrorLogDetail{
 		DBTypeS:     common.TaskDBOracle,
-		DBTypeT:     common.TaskDBMySQL,
+		DBTypeT:     dialect.DBTypeT(),
 		SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
-		RunMode:     common.ReverseO2MMode,
+		RunMode:     r.runMode,
 	})
 	if errTotals > 0 || err != nil {
-		return fmt.Errorf("reverse schema [%s] table mode [%s] task failed: %v, table [error_log_detail] exist failed error, please clear and rerunning", r.cfg.OracleConfig.SchemaName, common.ReverseO2MMode, err)
+		return fmt.Errorf("reverse schema [%s] table mode [%s] task failed: %v, table [error_log_detail] exist failed error, please clear and rerunning", r.cfg.OracleConfig.SchemaName, r.runMode, err)
 	}
 
 	// 获取 r.oracle 数据库字符排序规则
@@ -120,28 +145,24 @@ func (r *Reverse) NewReverse() error {
 	}
 
 	if len(partitionTables) != 0 {
-		zap.L().Warn("partition tables",
-			zap.String("schema", r.cfg.OracleConfig.SchemaName),
+		logger.L(r.ctx).Warn("partition tables",
 			zap.String("partition table list", fmt.Sprintf("%v", partitionTables)),
 			zap.String("suggest", "if necessary, please manually convert and process the tables in the above list"))
 	}
 	if len(temporaryTables) != 0 {
-		zap.L().Warn("temporary tables",
-			zap.String("schema", r.cfg.OracleConfig.SchemaName),
+		logger.L(r.ctx).Warn("temporary tables",
 			zap.String("temporary table list", fmt.Sprintf("%v", temporaryTables)),
 			zap.String("suggest", "if necessary, please manually process the tables in the above list"))
 	}
 	if len(clusteredTables) != 0 {
-		zap.L().Warn("clustered tables",
-			zap.String("schema", r.cfg.OracleConfig.SchemaName),
+		logger.L(r.ctx).Warn("clustered tables",
 			zap.String("clustered table list", fmt.Sprintf("%v", clusteredTables)),
 			zap.String("suggest", "if necessary, please manually process the tables in the above list"))
 	}
 
 	var exporterTables []string
 	if len(materializedView) != 0 {
-		zap.L().Warn("materialized views",
-			zap.String("schema", r.cfg.OracleConfig.SchemaName),
+		logger.L(r.ctx).Warn("materialized views",
 			zap.String("materialized view list", fmt.Sprintf("%v", materializedView)),
 			zap.String("suggest", "if necessary, please manually process the tables in the above list"))
 
@@ -154,9 +175,9 @@ func (r *Reverse) NewReverse() error {
 	// 获取表名自定义规则
 	tableNameRules, err := meta.NewTableNameRuleModel(r.metaDB).DetailTableNameRule(r.ctx, &meta.TableNameRule{
 		DBTypeS:     common.TaskDBOracle,
-		DBTypeT:     common.TaskDBMySQL,
+		DBTypeT:     dialect.DBTypeT(),
 		SchemaNameS: r.cfg.OracleConfig.SchemaName,
-		SchemaNameT: r.cfg.MySQLConfig.SchemaName,
+		SchemaNameT: r.targetSchema,
 	})
 	if err != nil {
 		return err
@@ -169,8 +190,14 @@ func (r *Reverse) NewReverse() error {
 		}
 	}
 
+	// 获取表级别选项规则，Only 适用于 TiDB
+	tableOptionRuleMap, err := GenTableOptionRuleMap(r.ctx, r.metaDB, r.targetDBType, r.cfg.OracleConfig.SchemaName)
+	if err != nil {
+		return err
+	}
+
 	// 获取 reverse 表任务列表
-	tables, err := GenReverseTableTask(r.ctx, r.cfg, r.mysql, r.oracle, tableNameRuleMap, exporterTables, nlsSort, nlsComp)
+	tables, err := GenReverseTableTask(r.ctx, r.cfg, r.mysql, r.oracle, dialect, tableNameRuleMap, tableOptionRuleMap, exporterTables, nlsSort, nlsComp)
 	if err != nil {
 		return err
 	}
@@ -190,8 +217,8 @@ func (r *Reverse) NewReverse() error {
 	}
 
 	// schema create
-	err = GenCreateSchema(f,
-		common.StringUPPER(r.cfg.OracleConfig.SchemaName), common.StringUPPER(r.cfg.MySQLConfig.SchemaName), nlsComp)
+	err = GenCreateSchema(f, dialect,
+		common.StringUPPER(r.cfg.OracleConfig.SchemaName), common.StringUPPER(r.targetSchema), nlsComp)
 	if err != nil {
 		return err
 	}
@@ -206,24 +233,27 @@ func (r *Reverse) NewReverse() error {
 	g := &errgroup.Group{}
 	g.SetLimit(r.cfg.AppConfig.Threads)
 
+	// 收到优雅停止信号后不再调度新的表，进行中的表完成并写入文件后退出
 	for _, table := range tables {
+		if signal.IsStopping(r.ctx) {
+			break
+		}
 		t := table
 		g.Go(func() error {
 			rule, err := IReader(r.ctx, r.metaDB, t, t)
 			if err != nil {
 				if err = meta.NewErrorLogDetailModel(r.metaDB).CreateErrorLog(r.ctx, &meta.ErrorLogDetail{
 					DBTypeS:     common.TaskDBOracle,
-					DBTypeT:     common.TaskDBMySQL,
+					DBTypeT:     dialect.DBTypeT(),
 					SchemaNameS: t.SourceSchemaName,
 					TableNameS:  t.SourceTableName,
-					RunMode:     common.ReverseO2MMode,
+					RunMode:     r.runMode,
 					RunStatus:   "Failed",
 					InfoDetail:  t.String(),
 					ErrorDetail: err.Error(),
 				}); err != nil {
-					zap.L().Error("reverse table r.oracle to mysql failed",
-						zap.String("schema", t.SourceSchemaName),
-						zap.String("table", t.SourceTableName),
+					logger.L(r.ctx).Error("reverse table r.oracle to mysql failed",
+						logger.TableS(t.SourceTableName),
 						zap.Error(
 							fmt.Errorf("reader table task failed, detail see [error_log_detail], please rerunning")))
 
@@ -235,17 +265,16 @@ func (r *Reverse) NewReverse() error {
 			if err != nil {
 				if err = meta.NewErrorLogDetailModel(r.metaDB).CreateErrorLog(r.ctx, &meta.ErrorLogDetail{
 					DBTypeS:     common.TaskDBOracle,
-					DBTypeT:     common.TaskDBMySQL,
+					DBTypeT:     dialect.DBTypeT(),
 					SchemaNameS: t.SourceSchemaName,
 					TableNameS:  t.SourceTableName,
-					RunMode:     common.ReverseO2MMode,
+					RunMode:     r.runMode,
 					RunStatus:   "Failed",
 					InfoDetail:  t.String(),
 					ErrorDetail: err.Error(),
 				}); err != nil {
-					zap.L().Error("reverse table r.oracle to mysql failed",
-						zap.String("schema", t.SourceSchemaName),
-						zap.String("table", t.SourceTableName),
+					logger.L(r.ctx).Error("reverse table r.oracle to mysql failed",
+						logger.TableS(t.SourceTableName),
 						zap.Error(
 							fmt.Errorf("reverse table task failed, detail see [error_log_detail], please rerunning")))
 
@@ -258,17 +287,16 @@ func (r *Reverse) NewReverse() error {
 			if err != nil {
 				if err = meta.NewErrorLogDetailModel(r.metaDB).CreateErrorLog(r.ctx, &meta.ErrorLogDetail{
 					DBTypeS:     common.TaskDBOracle,
-					DBTypeT:     common.TaskDBMySQL,
+					DBTypeT:     dialect.DBTypeT(),
 					SchemaNameS: t.SourceSchemaName,
 					TableNameS:  t.SourceTableName,
-					RunMode:     common.ReverseO2MMode,
+					RunMode:     r.runMode,
 					RunStatus:   "Failed",
 					InfoDetail:  t.String(),
 					ErrorDetail: err.Error(),
 				}); err != nil {
-					zap.L().Error("reverse table r.oracle to mysql failed",
-						zap.String("schema", t.SourceSchemaName),
-						zap.String("table", t.SourceTableName),
+					logger.L(r.ctx).Error("reverse table r.oracle to mysql failed",
+						logger.TableS(t.SourceTableName),
 						zap.Error(
 							fmt.Errorf("writer table task failed, detail see [error_log_detail], please rerunning")))
 
@@ -289,12 +317,15 @@ func (r *Reverse) NewReverse() error {
 	if err != nil {
 		return err
 	}
+	if signal.IsStopping(r.ctx) {
+		return signal.ErrGracefulStop
+	}
 
 	errTotals, err = meta.NewErrorLogDetailModel(r.metaDB).CountsErrorLogBySchema(r.ctx, &meta.ErrorLogDetail{
 		DBTypeS:     common.TaskDBOracle,
-		DBTypeT:     common.TaskDBMySQL,
+		DBTypeT:     dialect.DBTypeT(),
 		SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
-		RunMode:     common.ReverseO2MMode,
+		RunMode:     r.runMode,
 	})
 	if err != nil {
 		return err
@@ -323,3 +354,24 @@ func (r *Reverse) NewReverse() error {
 	}
 	return nil
 }
+
+// 表级别选项规则，Only 适用于 TiDB
+func GenTableOptionRuleMap(ctx context.Context, metaDB *meta.Meta, targetDBType, sourceSchema string) (map[string]meta.TableOptionRule, error) {
+	tableOptionRuleMap := make(map[string]meta.TableOptionRule)
+
+	if !strings.EqualFold(targetDBType, common.TaskDBTiDB) {
+		return tableOptionRuleMap, nil
+	}
+	tableOptionRules, err := meta.NewTableOptionRuleModel(metaDB).DetailTableOptionRule(ctx, &meta.TableOptionRule{
+		DBTypeS:     common.TaskDBOracle,
+		DBTypeT:     common.TaskDBTiDB,
+		SchemaNameS: sourceSchema,
+	})
+	if err != nil {
+		return tableOptionRuleMap, err
+	}
+	for _, tr := range tableOptionRules {
+		tableOptionRuleMap[common.StringUPPER(tr.TableNameS)] = tr
+	}
+	return tableOptionRuleMap, nil
+}
@@ -20,6 +20,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/logger"
+	"github.com/wentaojin/transferdb/module/reverse"
 	"go.uber.org/zap"
 	"regexp"
 	"strings"
@@ -44,7 +46,12 @@ type Rule struct {
 	ColumnCommentINFO []map[string]string `json:"column_comment_info"`
 	OracleCollation   bool                `json:"oracle_collation"`
 
-	MetaDB *meta.Meta `json:"-"`
+	TableOptionRule *meta.TableOptionRule `json:"table_option_rule"` // 可为空，Only TiDB
+	Dialect         reverse.Dialect       `json:"-"`
+	MetaDB          *meta.Meta            `json:"-"`
+
+	// TiDB 数据打散方式，首次判定后复用，避免重复查询字段类型转换规则
+	tidbShardMode string
 }
 
 func (r *Rule) GenCreateTableDDL() (reverseDDL string, checkKeyDDL []string, foreignKeyDDL []string, compatibleDDL []string, err error) {
@@ -62,22 +69,16 @@ func (r *Rule) GenCreateTableDDL() (reverseDDL string, checkKeyDDL []string, for
 	compatibleDDL = compDDL
 
 	// table create sql -> target
-	reverseDDL = fmt.Sprintf("CREATE TABLE `%s`.`%s` (\n%s,\n%s\n)",
-		targetSchema,
-		targetTable,
-		strings.Join(tableColumnMetas, ",\n"),
-		strings.Join(tableKeyMetas, ",\n"))
-
 	if len(tableKeyMetas) > 0 {
-		reverseDDL = fmt.Sprintf("CREATE TABLE `%s`.`%s` (\n%s,\n%s\n)",
-			targetSchema,
-			targetTable,
+		reverseDDL = fmt.Sprintf("CREATE TABLE %s.%s (\n%s,\n%s\n)",
+			r.Dialect.QuoteIdentifier(targetSchema),
+			r.Dialect.QuoteIdentifier(targetTable),
 			strings.Join(tableColumnMetas, ",\n"),
 			strings.Join(tableKeyMetas, ",\n"))
 	} else {
-		reverseDDL = fmt.Sprintf("CREATE TABLE `%s`.`%s` (\n%s\n)",
-			targetSchema,
-			targetTable,
+		reverseDDL = fmt.Sprintf("CREATE TABLE %s.%s (\n%s\n)",
+			r.Dialect.QuoteIdentifier(targetSchema),
+			r.Dialect.QuoteIdentifier(targetTable),
 			strings.Join(tableColumnMetas, ",\n"))
 	}
 
@@ -89,10 +90,10 @@ func (r *Rule) GenCreateTableDDL() (reverseDDL string, checkKeyDDL []string, for
 
 	if len(checkKeyMetas) > 0 {
 		for _, ck := range checkKeyMetas {
-			ckSQL := fmt.Sprintf("ALTER TABLE `%s`.`%s` ADD %s;", targetSchema, targetTable, ck)
-			zap.L().Info("reverse oracle table check key",
-				zap.String("schema", targetSchema),
-				zap.String("table", targetTable),
+			ckSQL := fmt.Sprintf("ALTER TABLE %s.%s ADD %s;", r.Dialect.QuoteIdentifier(targetSchema), r.Dialect.QuoteIdentifier(targetTable), ck)
+			logger.L(r.Ctx).Info("reverse oracle table check key",
+				logger.SchemaT(targetSchema),
+				logger.TableT(targetTable),
 				zap.String("ck sql", ckSQL))
 
 			checkKeyDDL = append(checkKeyDDL, ckSQL)
@@ -106,17 +107,17 @@ func (r *Rule) GenCreateTableDDL() (reverseDDL string, checkKeyDDL []string, for
 
 	if len(foreignKeyMetas) > 0 {
 		for _, fk := range foreignKeyMetas {
-			addFkSQL := fmt.Sprintf("ALTER TABLE `%s`.`%s` ADD %s;", targetSchema, targetTable, fk)
-			zap.L().Info("reverse oracle table foreign key",
-				zap.String("schema", targetSchema),
-				zap.String("table", targetTable),
+			addFkSQL := fmt.Sprintf("ALTER TABLE %s.%s ADD %s;", r.Dialect.QuoteIdentifier(targetSchema), r.Dialect.QuoteIdentifier(targetTable), fk)
+			logger.L(r.Ctx).Info("reverse oracle table foreign key",
+				logger.SchemaT(targetSchema),
+				logger.TableT(targetTable),
 				zap.String("fk sql", addFkSQL))
 
 			foreignKeyDDL = append(foreignKeyDDL, addFkSQL)
 		}
 	}
 
-	zap.L().Info("reverse oracle table struct", zap.String("table", r.String()))
+	logger.L(r.Ctx).Info("reverse oracle table struct", logger.TableS(r.SourceTableName), zap.String("rule", r.String()))
 
 	return reverseDDL, checkKeyDDL, foreignKeyDDL, compatibleDDL, nil
 }
@@ -128,21 +129,26 @@ func (r *Rule) GenTableKeyMeta() (tableKeyMetas []string, compatibilityIndexSQL
 		return tableKeyMetas, compatibilityIndexSQL, fmt.Errorf("table json [%v], oracle db reverse table unique constraint failed: %v", r.String(), err)
 	}
 
-	normalIndexMetas, normalIndexCompSQL, err := r.GenTableNormalIndex()
-	if err != nil {
-		return tableKeyMetas, compatibilityIndexSQL, fmt.Errorf("table json [%v], oracle db reverse table key non-unique index failed: %v", r.String(), err)
-	}
+	// 非内联索引目标端【PostgreSQL】索引独立 CREATE INDEX 语句输出，见 IReverse
+	var normalIndexMetas, uniqueIndexMetas []string
+	if r.Dialect.IsInlineIndex() {
+		normalMetas, normalIndexCompSQL, err := r.GenTableNormalIndex()
+		if err != nil {
+			return tableKeyMetas, compatibilityIndexSQL, fmt.Errorf("table json [%v], oracle db reverse table key non-unique index failed: %v", r.String(), err)
+		}
 
-	uniqueIndexMetas, uniqueIndexCompSQL, err := r.GenTableUniqueIndex()
-	if err != nil {
-		return tableKeyMetas, compatibilityIndexSQL, fmt.Errorf("table json [%v], oracle db reverse table key unique index failed: %v", r.String(), err)
-	}
+		uniqueMetas, uniqueIndexCompSQL, err := r.GenTableUniqueIndex()
+		if err != nil {
+			return tableKeyMetas, compatibilityIndexSQL, fmt.Errorf("table json [%v], oracle db reverse table key unique index failed: %v", r.String(), err)
+		}
 
-	if len(normalIndexCompSQL) > 0 {
-		compatibilityIndexSQL = append(compatibilityIndexSQL, normalIndexCompSQL...)
-	}
-	if len(uniqueIndexCompSQL) > 0 {
-		compatibilityIndexSQL = append(compatibilityIndexSQL, uniqueIndexCompSQL...)
+		normalIndexMetas, uniqueIndexMetas = normalMetas, uniqueMetas
+		if len(normalIndexCompSQL) > 0 {
+			compatibilityIndexSQL = append(compatibilityIndexSQL, normalIndexCompSQL...)
+		}
+		if len(uniqueIndexCompSQL) > 0 {
+			compatibilityIndexSQL = append(compatibilityIndexSQL, uniqueIndexCompSQL...)
+		}
 	}
 
 	// 主键
@@ -182,11 +188,19 @@ func (r *Rule) GenTablePrimaryKey() (primaryKeyMetas []string, err error) {
 		return primaryKeyMetas, fmt.Errorf("oracle schema [%s] table [%s] primary key exist multiple values: [%v]", r.SourceSchema, r.SourceTableName, r.PrimaryKeyINFO)
 	}
 	if len(r.PrimaryKeyINFO) > 0 {
-		var primaryColumns []string
-		for _, col := range strings.Split(r.PrimaryKeyINFO[0]["COLUMN_LIST"], ",") {
-			primaryColumns = append(primaryColumns, fmt.Sprintf("`%s`", col))
+		pk := r.Dialect.GenPrimaryKey(strings.Split(strings.ToUpper(r.PrimaryKeyINFO[0]["COLUMN_LIST"]), ","))
+
+		// TiDB 表级别选项规则，显式指定聚簇索引，不依赖 tidb_enable_clustered_index
+		shardMode, err := r.GenTiDBShardMode()
+		if err != nil {
+			return primaryKeyMetas, err
+		}
+		switch shardMode {
+		case common.TiDBShardModeAutoRandom:
+			pk = fmt.Sprintf("%s %s", pk, common.TiDBClusteredIndexComment)
+		case common.TiDBShardModeShardRowIDBits:
+			pk = fmt.Sprintf("%s %s", pk, common.TiDBNonClusteredIndexComment)
 		}
-		pk := fmt.Sprintf("PRIMARY KEY (%s)", strings.ToUpper(strings.Join(primaryColumns, ",")))
 		primaryKeyMetas = append(primaryKeyMetas, pk)
 	}
 
@@ -196,12 +210,8 @@ func (r *Rule) GenTablePrimaryKey() (primaryKeyMetas []string, err error) {
 func (r *Rule) GenTableUniqueKey() (uniqueKeyMetas []string, err error) {
 	if len(r.UniqueKeyINFO) > 0 {
 		for _, rowUKCol := range r.UniqueKeyINFO {
-			var ukArr []string
-			for _, col := range strings.Split(rowUKCol["COLUMN_LIST"], ",") {
-				ukArr = append(ukArr, fmt.Sprintf("`%s`", col))
-			}
-			uk := fmt.Sprintf("UNIQUE KEY `%s` (%s)",
-				strings.ToUpper(rowUKCol["CONSTRAINT_NAME"]), strings.ToUpper(strings.Join(ukArr, ",")))
+			uk := r.Dialect.GenUniqueKey(strings.ToUpper(rowUKCol["CONSTRAINT_NAME"]),
+				strings.Split(strings.ToUpper(rowUKCol["COLUMN_LIST"]), ","))
 
 			uniqueKeyMetas = append(uniqueKeyMetas, uk)
 		}
@@ -212,31 +222,14 @@ func (r *Rule) GenTableUniqueKey() (uniqueKeyMetas []string, err error) {
 func (r *Rule) GenTableForeignKey() (foreignKeyMetas []string, err error) {
 	if len(r.ForeignKeyINFO) > 0 {
 		for _, rowFKCol := range r.ForeignKeyINFO {
-			if rowFKCol["DELETE_RULE"] == "" || rowFKCol["DELETE_RULE"] == "NO ACTION" {
-				fk := fmt.Sprintf("CONSTRAINT `%s` FOREIGN KEY (%s) REFERENCES `%s`.`%s` (%s)",
-					strings.ToUpper(rowFKCol["CONSTRAINT_NAME"]),
-					strings.ToUpper(rowFKCol["COLUMN_LIST"]),
+			switch rowFKCol["DELETE_RULE"] {
+			case "", "NO ACTION", "CASCADE", "SET NULL":
+				fk := r.Dialect.GenForeignKey(strings.ToUpper(rowFKCol["CONSTRAINT_NAME"]),
+					strings.Split(strings.ToUpper(rowFKCol["COLUMN_LIST"]), ","),
 					strings.ToUpper(rowFKCol["R_OWNER"]),
 					strings.ToUpper(rowFKCol["RTABLE_NAME"]),
-					strings.ToUpper(rowFKCol["RCOLUMN_LIST"]))
-				foreignKeyMetas = append(foreignKeyMetas, fk)
-			}
-			if rowFKCol["DELETE_RULE"] == "CASCADE" {
-				fk := fmt.Sprintf("CONSTRAINT `%s` FOREIGN KEY (%s) REFERENCES `%s`.`%s`(%s) ON DELETE CASCADE",
-					strings.ToUpper(rowFKCol["CONSTRAINT_NAME"]),
-					strings.ToUpper(rowFKCol["COLUMN_LIST"]),
-					strings.ToUpper(rowFKCol["R_OWNER"]),
-					strings.ToUpper(rowFKCol["RTABLE_NAME"]),
-					strings.ToUpper(rowFKCol["RCOLUMN_LIST"]))
-				foreignKeyMetas = append(foreignKeyMetas, fk)
-			}
-			if rowFKCol["DELETE_RULE"] == "SET NULL" {
-				fk := fmt.Sprintf("CONSTRAINT `%s` FOREIGN KEY(%s) REFERENCES `%s`.`%s`(%s) ON DELETE SET NULL",
-					strings.ToUpper(rowFKCol["CONSTRAINT_NAME"]),
-					strings.ToUpper(rowFKCol["COLUMN_LIST"]),
-					strings.ToUpper(rowFKCol["R_OWNER"]),
-					strings.ToUpper(rowFKCol["RTABLE_NAME"]),
-					strings.ToUpper(rowFKCol["RCOLUMN_LIST"]))
+					strings.Split(strings.ToUpper(rowFKCol["RCOLUMN_LIST"]), ","),
+					rowFKCol["DELETE_RULE"])
 				foreignKeyMetas = append(foreignKeyMetas, fk)
 			}
 		}
@@ -270,7 +263,7 @@ func (r *Rule) GenTableCheckKey() (checkKeyMetas []string, err error) {
 
 			if !reg.MatchString(s) {
 				if !matchRex.MatchString(s) {
-					checkKeyMetas = append(checkKeyMetas, fmt.Sprintf("CONSTRAINT `%s` CHECK (%s)",
+					checkKeyMetas = append(checkKeyMetas, r.Dialect.GenCheckKey(
 						strings.ToUpper(rowCKCol["CONSTRAINT_NAME"]),
 						rowCKCol["SEARCH_CONDITION"]))
 				}
@@ -316,7 +309,7 @@ func (r *Rule) GenTableCheckKey() (checkKeyMetas []string, err error) {
 					d = d[:len(d)-1]
 				}
 
-				checkKeyMetas = append(checkKeyMetas, fmt.Sprintf("CONSTRAINT `%s` CHECK (%s)",
+				checkKeyMetas = append(checkKeyMetas, r.Dialect.GenCheckKey(
 					strings.ToUpper(rowCKCol["CONSTRAINT_NAME"]),
 					strings.Join(d, " ")))
 			}
@@ -332,18 +325,13 @@ func (r *Rule) GenTableUniqueIndex() (uniqueIndexMetas []string, compatibilityIn
 			if idxMeta["TABLE_NAME"] != "" && strings.ToUpper(idxMeta["UNIQUENESS"]) == "UNIQUE" {
 				switch idxMeta["INDEX_TYPE"] {
 				case "NORMAL":
-					var uniqueIndex []string
-					for _, col := range strings.Split(idxMeta["COLUMN_LIST"], ",") {
-						uniqueIndex = append(uniqueIndex, fmt.Sprintf("`%s`", col))
-					}
-
-					uniqueIDX := fmt.Sprintf("UNIQUE INDEX `%s` (%s)", strings.ToUpper(idxMeta["INDEX_NAME"]), strings.Join(uniqueIndex, ","))
+					uniqueIDX := r.Dialect.GenUniqueIndex(r.ChangeSchemaName(), r.ChangeTableName(),
+						strings.ToUpper(idxMeta["INDEX_NAME"]), strings.Split(idxMeta["COLUMN_LIST"], ","))
 
 					uniqueIndexMetas = append(uniqueIndexMetas, uniqueIDX)
 
-					zap.L().Info("reverse unique index",
-						zap.String("schema", r.SourceSchema),
-						zap.String("table", idxMeta["TABLE_NAME"]),
+					logger.L(r.Ctx).Info("reverse unique index",
+						logger.TableS(idxMeta["TABLE_NAME"]),
 						zap.String("index name", idxMeta["INDEX_NAME"]),
 						zap.String("index type", idxMeta["INDEX_TYPE"]),
 						zap.String("index column list", idxMeta["COLUMN_LIST"]),
@@ -352,15 +340,15 @@ func (r *Rule) GenTableUniqueIndex() (uniqueIndexMetas []string, compatibilityIn
 					continue
 
 				case "FUNCTION-BASED NORMAL":
-					sql := fmt.Sprintf("CREATE UNIQUE INDEX `%s` ON `%s`.`%s` (%s);",
-						strings.ToUpper(idxMeta["INDEX_NAME"]), r.TargetSchema, r.TargetTableName,
+					sql := fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s.%s (%s);",
+						r.Dialect.QuoteIdentifier(strings.ToUpper(idxMeta["INDEX_NAME"])),
+						r.Dialect.QuoteIdentifier(r.TargetSchema), r.Dialect.QuoteIdentifier(r.TargetTableName),
 						idxMeta["COLUMN_LIST"])
 
 					compatibilityIndexSQL = append(compatibilityIndexSQL, sql)
 
-					zap.L().Warn("reverse unique key",
-						zap.String("schema", r.SourceSchema),
-						zap.String("table", idxMeta["TABLE_NAME"]),
+					logger.L(r.Ctx).Warn("reverse unique key",
+						logger.TableS(idxMeta["TABLE_NAME"]),
 						zap.String("index name", idxMeta["INDEX_NAME"]),
 						zap.String("index type", idxMeta["INDEX_TYPE"]),
 						zap.String("index column list", idxMeta["COLUMN_LIST"]),
@@ -370,9 +358,8 @@ func (r *Rule) GenTableUniqueIndex() (uniqueIndexMetas []string, compatibilityIn
 					continue
 
 				default:
-					zap.L().Error("reverse unique index",
-						zap.String("schema", r.SourceSchema),
-						zap.String("table", idxMeta["TABLE_NAME"]),
+					logger.L(r.Ctx).Error("reverse unique index",
+						logger.TableS(idxMeta["TABLE_NAME"]),
 						zap.String("index name", idxMeta["INDEX_NAME"]),
 						zap.String("index type", idxMeta["INDEX_TYPE"]),
 						zap.String("index column list", idxMeta["COLUMN_LIST"]),
@@ -381,9 +368,8 @@ func (r *Rule) GenTableUniqueIndex() (uniqueIndexMetas []string, compatibilityIn
 					return uniqueIndexMetas, compatibilityIndexSQL, fmt.Errorf("[UNIQUE] oracle schema [%s] table [%s] reverse normal index panic, error: %v", r.SourceSchema, r.SourceTableName, idxMeta)
 				}
 			}
-			zap.L().Error("reverse unique key",
-				zap.String("schema", r.SourceSchema),
-				zap.String("table", idxMeta["TABLE_NAME"]),
+			logger.L(r.Ctx).Error("reverse unique key",
+				logger.TableS(idxMeta["TABLE_NAME"]),
 				zap.String("index name", idxMeta["INDEX_NAME"]),
 				zap.String("index type", idxMeta["INDEX_TYPE"]),
 				zap.String("index column list", idxMeta["COLUMN_LIST"]))
@@ -402,18 +388,13 @@ func (r *Rule) GenTableNormalIndex() (normalIndexMetas []string, compatibilityIn
 			if idxMeta["TABLE_NAME"] != "" && strings.ToUpper(idxMeta["UNIQUENESS"]) == "NONUNIQUE" {
 				switch idxMeta["INDEX_TYPE"] {
 				case "NORMAL":
-					var normalIndex []string
-					for _, col := range strings.Split(idxMeta["COLUMN_LIST"], ",") {
-						normalIndex = append(normalIndex, fmt.Sprintf("`%s`", col))
-					}
-
-					keyIndex := fmt.Sprintf("KEY `%s` (%s)", strings.ToUpper(idxMeta["INDEX_NAME"]), strings.Join(normalIndex, ","))
+					keyIndex := r.Dialect.GenNormalIndex(r.ChangeSchemaName(), r.ChangeTableName(),
+						strings.ToUpper(idxMeta["INDEX_NAME"]), strings.Split(idxMeta["COLUMN_LIST"], ","))
 
 					normalIndexMetas = append(normalIndexMetas, keyIndex)
 
-					zap.L().Info("reverse normal index",
-						zap.String("schema", r.SourceSchema),
-						zap.String("table", idxMeta["TABLE_NAME"]),
+					logger.L(r.Ctx).Info("reverse normal index",
+						logger.TableS(idxMeta["TABLE_NAME"]),
 						zap.String("index name", idxMeta["INDEX_NAME"]),
 						zap.String("index type", idxMeta["INDEX_TYPE"]),
 						zap.String("index column list", idxMeta["COLUMN_LIST"]),
@@ -428,9 +409,8 @@ func (r *Rule) GenTableNormalIndex() (normalIndexMetas []string, compatibilityIn
 
 					compatibilityIndexSQL = append(compatibilityIndexSQL, sql)
 
-					zap.L().Warn("reverse normal index",
-						zap.String("schema", r.SourceSchema),
-						zap.String("table", idxMeta["TABLE_NAME"]),
+					logger.L(r.Ctx).Warn("reverse normal index",
+						logger.TableS(idxMeta["TABLE_NAME"]),
 						zap.String("index name", idxMeta["INDEX_NAME"]),
 						zap.String("index type", idxMeta["INDEX_TYPE"]),
 						zap.String("index column list", idxMeta["COLUMN_LIST"]),
@@ -445,9 +425,8 @@ func (r *Rule) GenTableNormalIndex() (normalIndexMetas []string, compatibilityIn
 
 					compatibilityIndexSQL = append(compatibilityIndexSQL, sql)
 
-					zap.L().Warn("reverse normal index",
-						zap.String("schema", r.SourceSchema),
-						zap.String("table", idxMeta["TABLE_NAME"]),
+					logger.L(r.Ctx).Warn("reverse normal index",
+						logger.TableS(idxMeta["TABLE_NAME"]),
 						zap.String("index name", idxMeta["INDEX_NAME"]),
 						zap.String("index type", idxMeta["INDEX_TYPE"]),
 						zap.String("index column list", idxMeta["COLUMN_LIST"]),
@@ -462,9 +441,8 @@ func (r *Rule) GenTableNormalIndex() (normalIndexMetas []string, compatibilityIn
 
 					compatibilityIndexSQL = append(compatibilityIndexSQL, sql)
 
-					zap.L().Warn("reverse normal index",
-						zap.String("schema", r.SourceSchema),
-						zap.String("table", idxMeta["TABLE_NAME"]),
+					logger.L(r.Ctx).Warn("reverse normal index",
+						logger.TableS(idxMeta["TABLE_NAME"]),
 						zap.String("index name", idxMeta["INDEX_NAME"]),
 						zap.String("index type", idxMeta["INDEX_TYPE"]),
 						zap.String("index column list", idxMeta["COLUMN_LIST"]),
@@ -482,9 +460,8 @@ func (r *Rule) GenTableNormalIndex() (normalIndexMetas []string, compatibilityIn
 
 					compatibilityIndexSQL = append(compatibilityIndexSQL, sql)
 
-					zap.L().Warn("reverse normal index",
-						zap.String("schema", r.SourceSchema),
-						zap.String("table", idxMeta["TABLE_NAME"]),
+					logger.L(r.Ctx).Warn("reverse normal index",
+						logger.TableS(idxMeta["TABLE_NAME"]),
 						zap.String("index name", idxMeta["INDEX_NAME"]),
 						zap.String("index type", idxMeta["INDEX_TYPE"]),
 						zap.String("index column list", idxMeta["COLUMN_LIST"]),
@@ -496,9 +473,8 @@ func (r *Rule) GenTableNormalIndex() (normalIndexMetas []string, compatibilityIn
 					continue
 
 				default:
-					zap.L().Error("reverse normal index",
-						zap.String("schema", r.SourceSchema),
-						zap.String("table", idxMeta["TABLE_NAME"]),
+					logger.L(r.Ctx).Error("reverse normal index",
+						logger.TableS(idxMeta["TABLE_NAME"]),
 						zap.String("index name", idxMeta["INDEX_NAME"]),
 						zap.String("index type", idxMeta["INDEX_TYPE"]),
 						zap.String("index column list", idxMeta["COLUMN_LIST"]),
@@ -511,9 +487,8 @@ func (r *Rule) GenTableNormalIndex() (normalIndexMetas []string, compatibilityIn
 				}
 			}
 
-			zap.L().Error("reverse normal index",
-				zap.String("schema", r.SourceSchema),
-				zap.String("table", idxMeta["TABLE_NAME"]),
+			logger.L(r.Ctx).Error("reverse normal index",
+				logger.TableS(idxMeta["TABLE_NAME"]),
 				zap.String("index name", idxMeta["INDEX_NAME"]),
 				zap.String("index type", idxMeta["INDEX_TYPE"]),
 				zap.String("index column list", idxMeta["COLUMN_LIST"]),
@@ -529,17 +504,22 @@ func (r *Rule) GenTableNormalIndex() (normalIndexMetas []string, compatibilityIn
 
 func (r *Rule) GenTableComment() (tableComment string, err error) {
 	if len(r.TableColumnINFO) > 0 && r.TableCommentINFO[0]["COMMENTS"] != "" {
-		tableComment = fmt.Sprintf("COMMENT='%s'", r.TableCommentINFO[0]["COMMENTS"])
+		targetSchema, targetTable := r.GenTablePrefix()
+		tableComment = r.Dialect.GenTableComment(targetSchema, targetTable, r.TableCommentINFO[0]["COMMENTS"])
 	}
 	return tableComment, err
 }
 
 func (r *Rule) GenTableColumn() (columnMetas []string, err error) {
+	shardMode, err := r.GenTiDBShardMode()
+	if err != nil {
+		return columnMetas, err
+	}
+
 	for _, rowCol := range r.TableColumnINFO {
 		var (
 			columnCollation string
 			nullable        string
-			comment         string
 			dataDefault     string
 		)
 		if r.OracleCollation {
@@ -579,12 +559,6 @@ func (r *Rule) GenTableColumn() (columnMetas []string, err error) {
 			nullable = "NOT NULL"
 		}
 
-		if !strings.EqualFold(rowCol["COMMENTS"], "") {
-			comment = "'" + common.SpecialLettersUsingMySQL([]byte(rowCol["COMMENTS"])) + "'"
-		} else {
-			comment = rowCol["COMMENTS"]
-		}
-
 		if !strings.EqualFold(rowCol["DATA_DEFAULT"], "") {
 			dataDefault, err = r.ChangeTableColumnDefaultValue(rowCol["DATA_DEFAULT"])
 			if err != nil {
@@ -594,56 +568,113 @@ func (r *Rule) GenTableColumn() (columnMetas []string, err error) {
 			dataDefault = rowCol["DATA_DEFAULT"]
 		}
 
-		if nullable == "NULL" {
-			switch {
-			case columnCollation != "" && comment != "" && dataDefault != "":
-				columnMetas = append(columnMetas, fmt.Sprintf("`%s` %s COLLATE %s DEFAULT %s COMMENT %s", rowCol["COLUMN_NAME"], columnType, columnCollation, dataDefault, comment))
-			case columnCollation != "" && comment == "" && dataDefault != "":
-				columnMetas = append(columnMetas, fmt.Sprintf("`%s` %s COLLATE %s DEFAULT %s", rowCol["COLUMN_NAME"], columnType, columnCollation, dataDefault))
-			case columnCollation != "" && comment == "" && dataDefault == "":
-				columnMetas = append(columnMetas, fmt.Sprintf("`%s` %s COLLATE %s", rowCol["COLUMN_NAME"], columnType, columnCollation))
-			case columnCollation != "" && comment != "" && dataDefault == "":
-				columnMetas = append(columnMetas, fmt.Sprintf("`%s` %s COLLATE %s COMMENT %s", rowCol["COLUMN_NAME"], columnType, columnCollation, comment))
-			case columnCollation == "" && comment != "" && dataDefault != "":
-				columnMetas = append(columnMetas, fmt.Sprintf("`%s` %s DEFAULT %s COMMENT %s", rowCol["COLUMN_NAME"], columnType, dataDefault, comment))
-			case columnCollation == "" && comment == "" && dataDefault != "":
-				columnMetas = append(columnMetas, fmt.Sprintf("`%s` %s DEFAULT %s", rowCol["COLUMN_NAME"], columnType, dataDefault))
-			case columnCollation == "" && comment == "" && dataDefault == "":
-				columnMetas = append(columnMetas, fmt.Sprintf("`%s` %s", rowCol["COLUMN_NAME"], columnType))
-			case columnCollation == "" && comment != "" && dataDefault == "":
-				columnMetas = append(columnMetas, fmt.Sprintf("`%s` %s COMMENT %s", rowCol["COLUMN_NAME"], columnType, comment))
-			default:
-				return columnMetas, fmt.Errorf("error on gen oracle schema table column meta with nullable, rule: %v", r.String())
-			}
-		} else {
-			switch {
-			case columnCollation != "" && comment != "" && dataDefault != "":
-				columnMetas = append(columnMetas, fmt.Sprintf("`%s` %s COLLATE %s %s DEFAULT %s COMMENT %s",
-					rowCol["COLUMN_NAME"], columnType, columnCollation, nullable, dataDefault, comment))
-			case columnCollation != "" && comment != "" && dataDefault == "":
-				columnMetas = append(columnMetas, fmt.Sprintf("`%s` %s COLLATE %s %s COMMENT %s", rowCol["COLUMN_NAME"], columnType, columnCollation, nullable, comment))
-			case columnCollation != "" && comment == "" && dataDefault != "":
-				columnMetas = append(columnMetas, fmt.Sprintf("`%s` %s COLLATE %s %s DEFAULT %s", rowCol["COLUMN_NAME"], columnType, columnCollation, nullable, dataDefault))
-			case columnCollation != "" && comment == "" && dataDefault == "":
-				columnMetas = append(columnMetas, fmt.Sprintf("`%s` %s COLLATE %s %s", rowCol["COLUMN_NAME"], columnType, columnCollation, nullable))
-			case columnCollation == "" && comment != "" && dataDefault != "":
-				columnMetas = append(columnMetas, fmt.Sprintf("`%s` %s %s DEFAULT %s COMMENT %s", rowCol["COLUMN_NAME"], columnType, nullable, dataDefault, comment))
-			case columnCollation == "" && comment != "" && dataDefault == "":
-				columnMetas = append(columnMetas, fmt.Sprintf("`%s` %s %s COMMENT %s", rowCol["COLUMN_NAME"], columnType, nullable, comment))
-			case columnCollation == "" && comment == "" && dataDefault != "":
-				columnMetas = append(columnMetas, fmt.Sprintf("`%s` %s %s DEFAULT %s", rowCol["COLUMN_NAME"], columnType, nullable, dataDefault))
-			case columnCollation == "" && comment == "" && dataDefault == "":
-				columnMetas = append(columnMetas, fmt.Sprintf("`%s` %s %s", rowCol["COLUMN_NAME"], columnType, nullable))
-			default:
-				return columnMetas, fmt.Errorf("error on gen oracle schema table column meta without nullable, rule: %v", r.String())
+		// TiDB AUTO_RANDOM 主键字段，AUTO_RANDOM 仅支持 BIGINT 且不支持默认值
+		if shardMode == common.TiDBShardModeAutoRandom &&
+			strings.EqualFold(rowCol["COLUMN_NAME"], strings.Split(r.PrimaryKeyINFO[0]["COLUMN_LIST"], ",")[0]) {
+			autoRandomBits := r.TableOptionRule.AutoRandomBits
+			if autoRandomBits <= 0 {
+				autoRandomBits = common.TiDBDefaultAutoRandomBits
 			}
+			logger.L(r.Ctx).Warn("reverse oracle table column auto_random",
+				logger.TableS(r.SourceTableName),
+				zap.String("column", rowCol["COLUMN_NAME"]),
+				zap.String("column type", columnType),
+				zap.String("suggest", "column type would be changed to bigint, data migration need set allow_auto_random_explicit_insert = on"))
+
+			columnType = fmt.Sprintf("BIGINT AUTO_RANDOM(%d)", autoRandomBits)
+			dataDefault = ""
 		}
+
+		columnMetas = append(columnMetas, r.Dialect.GenColumnDefinition(rowCol["COLUMN_NAME"], columnType, columnCollation, nullable, dataDefault, rowCol["COMMENTS"]))
 	}
 
 	return columnMetas, nil
 }
 
+// TiDB 表级别选项规则数据打散方式，依据 Map 规则转换后的单列整型主键判定
+// AUTO_RANDOM 仅支持 BIGINT，非 BIGINT 整型主键跳过 AUTO_RANDOM 改写
+func (r *Rule) GenTiDBShardMode() (string, error) {
+	if r.TableOptionRule == nil {
+		return common.TiDBShardModeNone, nil
+	}
+	if r.tidbShardMode != "" {
+		return r.tidbShardMode, nil
+	}
+
+	var (
+		primaryColumns  []string
+		primaryType     string
+		singleIntegerPK bool
+		singleBigintPK  bool
+	)
+	if len(r.PrimaryKeyINFO) > 0 {
+		primaryColumns = strings.Split(r.PrimaryKeyINFO[0]["COLUMN_LIST"], ",")
+	}
+	if len(primaryColumns) == 1 {
+		for _, rowCol := range r.TableColumnINFO {
+			if !strings.EqualFold(rowCol["COLUMN_NAME"], primaryColumns[0]) {
+				continue
+			}
+			columnType, err := r.ChangeTableColumnType(r.SourceSchema, r.SourceTableName, rowCol["COLUMN_NAME"], Column{
+				DataType: rowCol["DATA_TYPE"],
+				ColumnInfo: ColumnInfo{
+					DataLength:    rowCol["DATA_LENGTH"],
+					DataPrecision: rowCol["DATA_PRECISION"],
+					DataScale:     rowCol["DATA_SCALE"],
+					NULLABLE:      rowCol["NULLABLE"],
+					DataDefault:   rowCol["DATA_DEFAULT"],
+					Comment:       rowCol["COMMENTS"],
+				},
+			})
+			if err != nil {
+				return "", err
+			}
+			primaryType = columnType
+			singleIntegerPK = isIntegerColumnType(columnType)
+			singleBigintPK = isAutoRandomColumnType(columnType)
+		}
+	}
+
+	shardMode, err := genTiDBShardMode(r.TableOptionRule, len(primaryColumns), singleIntegerPK, singleBigintPK)
+	if err != nil {
+		return shardMode, fmt.Errorf("reverse oracle table [%s.%s] option rule failed: %v", r.SourceSchema, r.SourceTableName, err)
+	}
+
+	optionShardMode := common.StringUPPER(r.TableOptionRule.ShardMode)
+	if singleIntegerPK && !singleBigintPK &&
+		(optionShardMode == common.TiDBShardModeAuto || optionShardMode == common.TiDBShardModeAutoRandom) {
+		logger.L(r.Ctx).Warn("reverse oracle table column auto_random skip",
+			logger.TableS(r.SourceTableName),
+			zap.String("column", primaryColumns[0]),
+			zap.String("column type", primaryType),
+			zap.String("shard mode", shardMode),
+			zap.String("suggest", "auto_random only support bigint primary key, please set column_datatype_rule change column type to bigint"))
+	}
+
+	r.tidbShardMode = shardMode
+	return shardMode, nil
+}
+
 func (r *Rule) GenTableColumnComment() (columnComments []string, err error) {
+	// 字段注释内联于字段定义【MySQL/TiDB】，否则独立注释语句输出【PostgreSQL】
+	if r.Dialect.IsInlineComment() {
+		return
+	}
+	targetSchema, targetTable := r.GenTablePrefix()
+	for _, rowCol := range r.TableColumnINFO {
+		if !strings.EqualFold(rowCol["COMMENTS"], "") {
+			columnComments = append(columnComments, r.Dialect.GenColumnComment(targetSchema, targetTable, rowCol["COLUMN_NAME"], rowCol["COMMENTS"]))
+		}
+	}
+	return columnComments, nil
+}
+
+func (r *Rule) GenTableSequence() (sequenceDDL []string, err error) {
+	// O2M Skip
+	return
+}
+
+func (r *Rule) GenTableTrigger() (triggerDDL []string, err error) {
 	// O2M Skip
 	return
 }
@@ -676,19 +707,19 @@ func (r *Rule) ChangeTableColumnType(sourceSchema, sourceTable, sourceColumn str
 	// 获取内置映射规则
 	buildinDatatypeNames, err := meta.NewBuildinDatatypeRuleModel(r.MetaDB).BatchQueryBuildinDatatype(r.Ctx, &meta.BuildinDatatypeRule{
 		DBTypeS: common.TaskDBOracle,
-		DBTypeT: common.TaskDBMySQL,
+		DBTypeT: r.Dialect.DBTypeT(),
 	})
 	if err != nil {
 		return columnType, err
 	}
-	originColumnType, buildInColumnType, err := OracleTableColumnMapRule(sourceSchema, sourceTable, column.(Column), buildinDatatypeNames)
+	originColumnType, buildInColumnType, err := OracleTableColumnMapRule(sourceSchema, sourceTable, column.(Column), buildinDatatypeNames, r.Dialect)
 	if err != nil {
 		return columnType, err
 	}
 	// 获取自定义映射规则
 	columnDataTypeMapSlice, err := meta.NewColumnDatatypeRuleModel(r.MetaDB).DetailColumnRule(r.Ctx, &meta.ColumnDatatypeRule{
 		DBTypeS:     common.TaskDBOracle,
-		DBTypeT:     common.TaskDBMySQL,
+		DBTypeT:     r.Dialect.DBTypeT(),
 		SchemaNameS: r.SourceSchema,
 		TableNameS:  r.SourceTableName,
 		ColumnNameS: sourceColumn,
@@ -699,7 +730,7 @@ func (r *Rule) ChangeTableColumnType(sourceSchema, sourceTable, sourceColumn str
 
 	tableDataTypeMapSlice, err := meta.NewTableDatatypeRuleModel(r.MetaDB).DetailTableRule(r.Ctx, &meta.TableDatatypeRule{
 		DBTypeS:     common.TaskDBOracle,
-		DBTypeT:     common.TaskDBMySQL,
+		DBTypeT:     r.Dialect.DBTypeT(),
 		SchemaNameS: r.SourceSchema,
 		TableNameS:  r.SourceTableName,
 	})
@@ -709,7 +740,7 @@ func (r *Rule) ChangeTableColumnType(sourceSchema, sourceTable, sourceColumn str
 
 	schemaDataTypeMapSlice, err := meta.NewSchemaDatatypeRuleModel(r.MetaDB).DetailSchemaRule(r.Ctx, &meta.SchemaDatatypeRule{
 		DBTypeS:     common.TaskDBOracle,
-		DBTypeT:     common.TaskDBMySQL,
+		DBTypeT:     r.Dialect.DBTypeT(),
 		SchemaNameS: r.SourceSchema,
 	})
 	if err != nil {
@@ -746,7 +777,7 @@ func (r *Rule) ChangeTableColumnDefaultValue(dataDefault string) (string, error)
 	var defaultVal string
 	defaultValueMapSlice, err := meta.NewBuildinColumnDefaultvalModel(r.MetaDB).DetailColumnDefaultVal(r.Ctx, &meta.BuildinColumnDefaultval{
 		DBTypeS: common.TaskDBOracle,
-		DBTypeT: common.TaskDBMySQL,
+		DBTypeT: r.Dialect.DBTypeT(),
 	})
 	if err != nil {
 		return defaultVal, err
@@ -23,8 +23,10 @@ import (
 	"github.com/valyala/fastjson"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/logger"
 	"github.com/wentaojin/transferdb/module/reverse"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
@@ -50,16 +52,18 @@ type Table struct {
 	Overwrite             bool            `json:"overwrite"`
 	Oracle                *oracle.Oracle  `json:"-"`
 	MySQL                 *mysql.MySQL    `json:"-"`
+	Dialect               reverse.Dialect `json:"-"`
+
+	TableOptionRule *meta.TableOptionRule `json:"table_option_rule"` // 可为空，Only TiDB
 }
 
-func GenReverseTableTask(ctx context.Context, cfg *config.Config, mysql *mysql.MySQL, oracle *oracle.Oracle, tableNameRule map[string]string, exporters []string, nlsSort, nlsComp string) ([]*Table, error) {
+func GenReverseTableTask(ctx context.Context, cfg *config.Config, mysql *mysql.MySQL, oracle *oracle.Oracle, dialect reverse.Dialect, tableNameRule map[string]string, tableOptionRule map[string]meta.TableOptionRule, exporters []string, nlsSort, nlsComp string) ([]*Table, error) {
 	var tables []*Table
 
 	beginTime := time.Now()
 	defer func() {
 		endTime := time.Now()
-		zap.L().Info("gen oracle table list finished",
-			zap.String("schema", cfg.OracleConfig.SchemaName),
+		logger.L(ctx).Info("gen oracle table list finished",
 			zap.Int("table totals", len(exporters)),
 			zap.Int("table gens", len(tables)),
 			zap.String("cost", endTime.Sub(beginTime).String()))
@@ -88,8 +92,7 @@ func GenReverseTableTask(ctx context.Context, cfg *config.Config, mysql *mysql.M
 	}
 
 	endTime := time.Now()
-	zap.L().Info("get oracle db character and version finished",
-		zap.String("schema", cfg.OracleConfig.SchemaName),
+	logger.L(ctx).Info("get oracle db character and version finished",
 		zap.String("db version", oraDBVersion),
 		zap.String("db character", characterSet),
 		zap.Int("table totals", len(exporters)),
@@ -112,8 +115,7 @@ func GenReverseTableTask(ctx context.Context, cfg *config.Config, mysql *mysql.M
 			return tables, err
 		}
 		endTime = time.Now()
-		zap.L().Info("get oracle schema and table collation finished",
-			zap.String("schema", cfg.OracleConfig.SchemaName),
+		logger.L(ctx).Info("get oracle schema and table collation finished",
 			zap.String("db version", oraDBVersion),
 			zap.String("db character", characterSet),
 			zap.Int("table totals", len(exporters)),
@@ -127,30 +129,53 @@ func GenReverseTableTask(ctx context.Context, cfg *config.Config, mysql *mysql.M
 		return tables, err
 	}
 	endTime = time.Now()
-	zap.L().Info("get oracle table type finished",
-		zap.String("schema", cfg.OracleConfig.SchemaName),
+	logger.L(ctx).Info("get oracle table type finished",
 		zap.String("db version", oraDBVersion),
 		zap.String("db character", characterSet),
 		zap.Int("table totals", len(exporters)),
 		zap.Bool("table collation", oraCollation),
 		zap.String("cost", endTime.Sub(startTime).String()))
 
-	// 获取 MySQL 版本
-	mysqlVersion, err := mysql.GetMySQLDBVersion()
-	if err != nil {
-		return nil, err
-	}
-
-	var dbVersion string
+	var (
+		dbVersion         string
+		targetSchema      string
+		targetDBType      string
+		targetTableOption string
+		overwrite         bool
+	)
 
-	if common.StringUPPER(common.StringUPPER(cfg.MySQLConfig.DBType)) == common.TaskDBTiDB {
-		dbVersion = mysqlVersion
+	// PostgreSQL 目标端仅生成 DDL 文件，无需连接目标端
+	if dialect.DBTypeT() == common.TaskDBPostgres {
+		targetSchema = cfg.PostgresConfig.SchemaName
+		targetDBType = common.TaskDBPostgres
+	} else if mysql == nil {
+		// 未连接目标端【CSV Lightning 表结构文件】，仅依据 Oracle 元数据生成
+		// 目标端版本未知，table-option 依赖目标端聚簇索引配置不生效，表级别选项规则仍生效
+		logger.L(ctx).Warn("reverse oracle table without target db connection",
+			zap.String("target db type", cfg.MySQLConfig.DBType),
+			zap.String("table-option", "table-option would be disabled"))
+		targetSchema = cfg.MySQLConfig.SchemaName
+		targetDBType = cfg.MySQLConfig.DBType
 	} else {
-		if strings.Contains(mysqlVersion, common.MySQLVersionDelimiter) {
-			dbVersion = strings.Split(mysqlVersion, common.MySQLVersionDelimiter)[0]
-		} else {
+		// 获取 MySQL 版本
+		mysqlVersion, err := mysql.GetMySQLDBVersion()
+		if err != nil {
+			return nil, err
+		}
+
+		if common.StringUPPER(common.StringUPPER(cfg.MySQLConfig.DBType)) == common.TaskDBTiDB {
 			dbVersion = mysqlVersion
+		} else {
+			if strings.Contains(mysqlVersion, common.MySQLVersionDelimiter) {
+				dbVersion = strings.Split(mysqlVersion, common.MySQLVersionDelimiter)[0]
+			} else {
+				dbVersion = mysqlVersion
+			}
 		}
+		targetSchema = cfg.MySQLConfig.SchemaName
+		targetDBType = cfg.MySQLConfig.DBType
+		targetTableOption = cfg.MySQLConfig.TableOption
+		overwrite = cfg.MySQLConfig.Overwrite
 	}
 
 	startTime = time.Now()
@@ -174,18 +199,24 @@ func GenReverseTableTask(ctx context.Context, cfg *config.Config, mysql *mysql.M
 				tbl := &Table{
 					Ctx:               ctx,
 					SourceSchemaName:  common.StringUPPER(cfg.OracleConfig.SchemaName),
-					TargetSchemaName:  common.StringUPPER(cfg.MySQLConfig.SchemaName),
+					TargetSchemaName:  common.StringUPPER(targetSchema),
 					SourceTableName:   common.StringUPPER(t),
-					TargetDBType:      common.StringUPPER(cfg.MySQLConfig.DBType),
+					TargetDBType:      common.StringUPPER(targetDBType),
 					TargetDBVersion:   dbVersion,
 					TargetTableName:   targetTableName,
-					TargetTableOption: common.StringUPPER(cfg.MySQLConfig.TableOption),
+					TargetTableOption: common.StringUPPER(targetTableOption),
 					SourceTableType:   tablesMap[t],
 					SourceDBNLSSort:   nlsSort,
 					SourceDBNLSComp:   nlsComp,
-					Overwrite:         cfg.MySQLConfig.Overwrite,
+					Overwrite:         overwrite,
 					Oracle:            oracle,
 					MySQL:             mysql,
+					Dialect:           dialect,
+				}
+				// 表级别选项规则
+				if val, ok := tableOptionRule[common.StringUPPER(t)]; ok {
+					optionRule := val
+					tbl.TableOptionRule = &optionRule
 				}
 				tbl.OracleCollation = oraCollation
 				if oraCollation {
@@ -216,8 +247,7 @@ func GenReverseTableTask(ctx context.Context, cfg *config.Config, mysql *mysql.M
 	}
 
 	endTime = time.Now()
-	zap.L().Info("gen oracle slice table finished",
-		zap.String("schema", cfg.OracleConfig.SchemaName),
+	logger.L(ctx).Info("gen oracle slice table finished",
 		zap.Int("table totals", len(exporters)),
 		zap.Int("table gens", len(tables)),
 		zap.String("cost", endTime.Sub(startTime).String()))
@@ -226,7 +256,7 @@ func GenReverseTableTask(ctx context.Context, cfg *config.Config, mysql *mysql.M
 }
 
 // O2M special
-func (t *Table) GenTableSuffix(primaryColumns []string, singleIntegerPK bool) (tableSuffix string, err error) {
+func (t *Table) GenTableSuffix(primaryColumns []string, singleIntegerPK, singleBigintPK bool) (tableSuffix string, err error) {
 	var (
 		tableCollation string
 	)
@@ -259,14 +289,29 @@ func (t *Table) GenTableSuffix(primaryColumns []string, singleIntegerPK bool) (t
 			return tableSuffix, fmt.Errorf("oracle db nls_comp [%v] nls_sort [%v] isn't support", t.SourceDBNLSComp, t.SourceDBNLSSort)
 		}
 	}
-	// table-option 表后缀可选项
-	if strings.EqualFold(t.TargetDBType, common.TaskDBMySQL) || t.TargetTableOption == "" {
-		zap.L().Warn("reverse oracle table suffix",
-			zap.String("table", t.String()),
+	// TiDB 表级别选项规则，存在规则 table-option 不生效
+	if t.TableOptionRule != nil {
+		tableSuffix, err = t.GenTableOptionRuleSuffix(tableCollation, primaryColumns, singleIntegerPK, singleBigintPK)
+		if err != nil {
+			return tableSuffix, err
+		}
+		logger.L(t.Ctx).Info("reverse oracle table suffix",
+			logger.TableS(t.SourceTableName),
+			zap.String("table info", t.String()),
+			zap.String("table option rule", "enabled"),
+			zap.String("create table suffix", tableSuffix))
+
+		return tableSuffix, nil
+	}
+
+	// table-option 表后缀可选项，PostgreSQL 无表选项
+	if !strings.EqualFold(t.TargetDBType, common.TaskDBTiDB) || t.TargetTableOption == "" {
+		logger.L(t.Ctx).Warn("reverse oracle table suffix",
+			logger.TableS(t.SourceTableName),
+			zap.String("table info", t.String()),
 			zap.String("table-option", "table-option is null, would be disabled"))
 		// table suffix
-		tableSuffix = fmt.Sprintf("ENGINE=InnoDB DEFAULT CHARSET=%s COLLATE=%s",
-			strings.ToLower(common.MySQLCharacterSet), tableCollation)
+		tableSuffix = t.Dialect.GenTableOption(tableCollation)
 
 	} else {
 		// TiDB
@@ -276,26 +321,25 @@ func (t *Table) GenTableSuffix(primaryColumns []string, singleIntegerPK bool) (t
 		}
 		switch common.StringUPPER(clusteredIdxVal) {
 		case common.TiDBClusteredIndexOFFValue:
-			zap.L().Warn("reverse oracle table suffix",
-				zap.String("table", t.String()),
+			logger.L(t.Ctx).Warn("reverse oracle table suffix",
+				logger.TableS(t.SourceTableName),
+				zap.String("table info", t.String()),
 				zap.String("tidb_enable_clustered_index", common.TiDBClusteredIndexOFFValue),
 				zap.String("table-option", "tidb_enable_clustered_index is off, would be enabled"))
 
 			if t.TargetTableOption != "" {
-				tableSuffix = fmt.Sprintf("ENGINE=InnoDB DEFAULT CHARSET=%s COLLATE=%s %s",
-					strings.ToLower(common.MySQLCharacterSet), tableCollation, common.StringUPPER(t.TargetTableOption))
+				tableSuffix = fmt.Sprintf("%s %s", t.Dialect.GenTableOption(tableCollation), common.StringUPPER(t.TargetTableOption))
 			} else {
-				tableSuffix = fmt.Sprintf("ENGINE=InnoDB DEFAULT CHARSET=%s COLLATE=%s",
-					strings.ToLower(common.MySQLCharacterSet), tableCollation)
+				tableSuffix = t.Dialect.GenTableOption(tableCollation)
 			}
 		case common.TiDBClusteredIndexONValue:
-			zap.L().Warn("reverse oracle table suffix",
-				zap.String("table", t.String()),
+			logger.L(t.Ctx).Warn("reverse oracle table suffix",
+				logger.TableS(t.SourceTableName),
+				zap.String("table info", t.String()),
 				zap.String("tidb_enable_clustered_index", common.TiDBClusteredIndexONValue),
 				zap.String("table-option", "tidb_enable_clustered_index is on, would be disabled"))
 
-			tableSuffix = fmt.Sprintf("ENGINE=InnoDB DEFAULT CHARSET=%s COLLATE=%s",
-				strings.ToLower(common.MySQLCharacterSet), tableCollation)
+			tableSuffix = t.Dialect.GenTableOption(tableCollation)
 
 		default:
 			// tidb_enable_clustered_index = int_only / tidb_enable_clustered_index 不存在值，等于空
@@ -304,14 +348,14 @@ func (t *Table) GenTableSuffix(primaryColumns []string, singleIntegerPK bool) (t
 				return tableSuffix, err
 			}
 			if !fastjson.Exists([]byte(pkVal), "alter-primary-key") {
-				zap.L().Warn("reverse oracle table suffix",
-					zap.String("table", t.String()),
+				logger.L(t.Ctx).Warn("reverse oracle table suffix",
+					logger.TableS(t.SourceTableName),
+					zap.String("table info", t.String()),
 					zap.String("tidb_enable_clustered_index", common.StringUPPER(clusteredIdxVal)),
 					zap.String("alter-primary-key", "not exist"),
 					zap.String("table-option", "alter-primary-key isn't exits, would be disable"))
 
-				tableSuffix = fmt.Sprintf("ENGINE=InnoDB DEFAULT CHARSET=%s COLLATE=%s",
-					strings.ToLower(common.MySQLCharacterSet), tableCollation)
+				tableSuffix = t.Dialect.GenTableOption(tableCollation)
 
 			} else {
 				var p fastjson.Parser
@@ -326,14 +370,14 @@ func (t *Table) GenTableSuffix(primaryColumns []string, singleIntegerPK bool) (t
 				// 整型主键 table-option 不生效
 				// 单列主键是整型
 				if !isAlterPK && len(primaryColumns) == 1 && singleIntegerPK {
-					zap.L().Warn("reverse oracle table suffix",
-						zap.String("table", t.String()),
+					logger.L(t.Ctx).Warn("reverse oracle table suffix",
+						logger.TableS(t.SourceTableName),
+						zap.String("table info", t.String()),
 						zap.String("tidb_enable_clustered_index", common.StringUPPER(clusteredIdxVal)),
 						zap.Bool("alter-primary-key", isAlterPK),
 						zap.String("table-option", "integer primary key, would be disable"))
 
-					tableSuffix = fmt.Sprintf("ENGINE=InnoDB DEFAULT CHARSET=%s COLLATE=%s",
-						strings.ToLower(common.MySQLCharacterSet), tableCollation)
+					tableSuffix = t.Dialect.GenTableOption(tableCollation)
 
 				} else {
 					// table-option 生效
@@ -341,22 +385,22 @@ func (t *Table) GenTableSuffix(primaryColumns []string, singleIntegerPK bool) (t
 					// alter-primary-key = false && 联合主键 len(pkINFO)>1
 					// alter-primary-key = false && 非整型主键
 					if isAlterPK || (!isAlterPK && len(primaryColumns) > 1) || (!isAlterPK && !singleIntegerPK) {
-						zap.L().Warn("reverse oracle table suffix",
-							zap.String("table", t.String()),
+						logger.L(t.Ctx).Warn("reverse oracle table suffix",
+							logger.TableS(t.SourceTableName),
+							zap.String("table info", t.String()),
 							zap.String("tidb_enable_clustered_index", common.StringUPPER(clusteredIdxVal)),
 							zap.Bool("alter-primary-key", isAlterPK),
 							zap.String("table-option", "enabled"))
 
 						if t.TargetTableOption != "" {
-							tableSuffix = fmt.Sprintf("ENGINE=InnoDB DEFAULT CHARSET=%s COLLATE=%s %s",
-								strings.ToLower(common.MySQLCharacterSet), tableCollation, common.StringUPPER(t.TargetTableOption))
+							tableSuffix = fmt.Sprintf("%s %s", t.Dialect.GenTableOption(tableCollation), common.StringUPPER(t.TargetTableOption))
 						} else {
-							tableSuffix = fmt.Sprintf("ENGINE=InnoDB DEFAULT CHARSET=%s COLLATE=%s",
-								strings.ToLower(common.MySQLCharacterSet), tableCollation)
+							tableSuffix = t.Dialect.GenTableOption(tableCollation)
 						}
 					} else {
-						zap.L().Error("reverse oracle table suffix",
-							zap.String("table", t.String()),
+						logger.L(t.Ctx).Error("reverse oracle table suffix",
+							logger.TableS(t.SourceTableName),
+							zap.String("table info", t.String()),
 							zap.String("tidb_enable_clustered_index", common.StringUPPER(clusteredIdxVal)),
 							zap.Bool("alter-primary-key", isAlterPK),
 							zap.String("table-option", "disabled"),
@@ -367,36 +411,144 @@ func (t *Table) GenTableSuffix(primaryColumns []string, singleIntegerPK bool) (t
 			}
 		}
 	}
-	zap.L().Info("reverse oracle table suffix",
-		zap.String("table", t.String()),
+	logger.L(t.Ctx).Info("reverse oracle table suffix",
+		logger.TableS(t.SourceTableName),
+		zap.String("table info", t.String()),
 		zap.String("create table suffix", tableSuffix))
 
 	return tableSuffix, nil
 }
 
+// TiDB 表级别选项规则后缀
+// SHARD_ROW_ID_BITS、PRE_SPLIT_REGIONS、PLACEMENT POLICY、TTL
+func (t *Table) GenTableOptionRuleSuffix(tableCollation string, primaryColumns []string, singleIntegerPK, singleBigintPK bool) (string, error) {
+	shardMode, err := genTiDBShardMode(t.TableOptionRule, len(primaryColumns), singleIntegerPK, singleBigintPK)
+	if err != nil {
+		return "", fmt.Errorf("reverse oracle table [%s.%s] option rule failed: %v", t.SourceSchemaName, t.SourceTableName, err)
+	}
+
+	tableOptions := []string{t.Dialect.GenTableOption(tableCollation)}
+
+	switch shardMode {
+	case common.TiDBShardModeShardRowIDBits:
+		shardRowIDBits := t.TableOptionRule.ShardRowIDBits
+		if shardRowIDBits <= 0 {
+			shardRowIDBits = common.TiDBDefaultShardRowIDBits
+		}
+		if t.TableOptionRule.PreSplitRegions > shardRowIDBits {
+			return "", fmt.Errorf("reverse oracle table [%s.%s] option rule pre_split_regions [%d] greater than shard_row_id_bits [%d]",
+				t.SourceSchemaName, t.SourceTableName, t.TableOptionRule.PreSplitRegions, shardRowIDBits)
+		}
+		tableOptions = append(tableOptions, fmt.Sprintf("SHARD_ROW_ID_BITS = %d", shardRowIDBits))
+		if t.TableOptionRule.PreSplitRegions > 0 {
+			tableOptions = append(tableOptions, fmt.Sprintf("PRE_SPLIT_REGIONS = %d", t.TableOptionRule.PreSplitRegions))
+		}
+	case common.TiDBShardModeAutoRandom:
+		// AUTO_RANDOM 字段属性由 Rule 生成，表选项仅 PRE_SPLIT_REGIONS
+		autoRandomBits := t.TableOptionRule.AutoRandomBits
+		if autoRandomBits <= 0 {
+			autoRandomBits = common.TiDBDefaultAutoRandomBits
+		}
+		if t.TableOptionRule.PreSplitRegions > autoRandomBits {
+			return "", fmt.Errorf("reverse oracle table [%s.%s] option rule pre_split_regions [%d] greater than auto_random_bits [%d]",
+				t.SourceSchemaName, t.SourceTableName, t.TableOptionRule.PreSplitRegions, autoRandomBits)
+		}
+		if t.TableOptionRule.PreSplitRegions > 0 {
+			tableOptions = append(tableOptions, fmt.Sprintf("PRE_SPLIT_REGIONS = %d", t.TableOptionRule.PreSplitRegions))
+		}
+	}
+
+	if !strings.EqualFold(t.TableOptionRule.PlacementPolicy, "") {
+		tableOptions = append(tableOptions, fmt.Sprintf("PLACEMENT POLICY = %s", t.Dialect.QuoteIdentifier(t.TableOptionRule.PlacementPolicy)))
+	}
+	if !strings.EqualFold(t.TableOptionRule.TTLExpr, "") {
+		tableOptions = append(tableOptions, fmt.Sprintf("TTL = %s TTL_ENABLE = 'ON'", t.TableOptionRule.TTLExpr))
+	}
+
+	return strings.Join(tableOptions, " "), nil
+}
+
 // 判断 Oracle 主键是否是整型主键
 func (t *Table) IsSingleIntegerPK(primaryColumns []string, columnMetas []string) bool {
-	singleIntegerPK := false
+	return isSinglePKColumnType(primaryColumns, columnMetas, isIntegerColumnType)
+}
+
+// 判断 Oracle 主键是否是 BIGINT 主键【AUTO_RANDOM】
+func (t *Table) IsSingleBigintPK(primaryColumns []string, columnMetas []string) bool {
+	return isSinglePKColumnType(primaryColumns, columnMetas, isAutoRandomColumnType)
+}
+
+func isSinglePKColumnType(primaryColumns []string, columnMetas []string, isColumnType func(columnType string) bool) bool {
+	singlePK := false
 
-	// 单列主键且整型主键
+	// 单列主键
 	if len(primaryColumns) == 1 {
 		// 单列主键数据类型获取判断
 		for _, columnMeta := range columnMetas {
 			columnName := strings.Fields(columnMeta)[0]
 			columnType := strings.Fields(columnMeta)[1]
 
-			if strings.EqualFold(primaryColumns[0], columnName) {
-				// Map 规则转换后的字段对应数据类型
-				// columnMeta 视角 columnName columnType ....
-				for _, integerType := range common.TiDBIntegerPrimaryKeyList {
-					if find := strings.Contains(common.StringUPPER(columnType), common.StringUPPER(integerType)); find {
-						singleIntegerPK = true
-					}
-				}
+			// Map 规则转换后的字段对应数据类型
+			// columnMeta 视角 columnName columnType ....
+			if strings.EqualFold(primaryColumns[0], columnName) && isColumnType(columnType) {
+				singlePK = true
 			}
 		}
 	}
-	return singleIntegerPK
+	return singlePK
+}
+
+// Map 规则转换后的字段数据类型是否整型
+func isIntegerColumnType(columnType string) bool {
+	for _, integerType := range common.TiDBIntegerPrimaryKeyList {
+		if strings.Contains(common.StringUPPER(columnType), common.StringUPPER(integerType)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Map 规则转换后的字段数据类型是否 BIGINT，TiDB AUTO_RANDOM 仅支持 BIGINT
+// BIGINT、BIGINT(20)、BIGINT UNSIGNED
+func isAutoRandomColumnType(columnType string) bool {
+	columnType = strings.TrimSpace(common.StringUPPER(columnType))
+	if !strings.HasPrefix(columnType, "BIGINT") {
+		return false
+	}
+	suffix := strings.TrimPrefix(columnType, "BIGINT")
+	return suffix == "" || strings.HasPrefix(suffix, "(") || strings.HasPrefix(suffix, " ")
+}
+
+// TiDB 表数据打散方式
+// AUTO: 单列 BIGINT 主键 AUTO_RANDOM，其余 SHARD_ROW_ID_BITS
+// AUTO_RANDOM: 仅支持单列整型主键，非 BIGINT 整型主键不打散
+func genTiDBShardMode(optionRule *meta.TableOptionRule, primaryColumnCounts int, singleIntegerPK, singleBigintPK bool) (string, error) {
+	if optionRule == nil {
+		return common.TiDBShardModeNone, nil
+	}
+	isAutoRandomPK := primaryColumnCounts == 1 && singleBigintPK
+
+	switch common.StringUPPER(optionRule.ShardMode) {
+	case "", common.TiDBShardModeNone:
+		return common.TiDBShardModeNone, nil
+	case common.TiDBShardModeAuto:
+		if isAutoRandomPK {
+			return common.TiDBShardModeAutoRandom, nil
+		}
+		return common.TiDBShardModeShardRowIDBits, nil
+	case common.TiDBShardModeAutoRandom:
+		if primaryColumnCounts != 1 || !singleIntegerPK {
+			return "", fmt.Errorf("shard mode [%s] only support single integer primary key", optionRule.ShardMode)
+		}
+		if !isAutoRandomPK {
+			return common.TiDBShardModeNone, nil
+		}
+		return common.TiDBShardModeAutoRandom, nil
+	case common.TiDBShardModeShardRowIDBits:
+		return common.TiDBShardModeShardRowIDBits, nil
+	default:
+		return "", fmt.Errorf("shard mode [%s] isn't support, only support [AUTO AUTO_RANDOM SHARD_ROW_ID_BITS NONE]", optionRule.ShardMode)
+	}
 }
 
 func (t *Table) GetTablePrimaryKey() ([]map[string]string, error) {
@@ -445,63 +597,69 @@ func (t *Table) String() string {
 	return string(jsonStr)
 }
 
-func GenCreateSchema(f *reverse.File, sourceSchema, targetSchema, nlsComp string) error {
+func GenCreateSchema(f *reverse.File, dialect reverse.Dialect, sourceSchema, targetSchema, nlsComp string) error {
 	startTime := time.Now()
-	var (
-		sqlRev          strings.Builder
-		schemaCollation string
-	)
+	var sqlRev strings.Builder
 
-	oraDBVersion, err := f.Oracle.GetOracleDBVersion()
+	createSchema, err := GenCreateSchemaDDL(f.Oracle, dialect, sourceSchema, targetSchema, nlsComp)
 	if err != nil {
 		return err
 	}
 
-	oraCollation := false
-	if common.VersionOrdinal(oraDBVersion) >= common.VersionOrdinal(common.OracleTableColumnCollationDBVersion) {
-		oraCollation = true
-	}
-	if oraCollation {
-		schemaCollation, err = f.Oracle.GetOracleSchemaCollation(sourceSchema)
-		if err != nil {
-			return err
-		}
-	}
-
 	sqlRev.WriteString("/*\n")
-	sqlRev.WriteString(" oracle schema reverse mysql database\n")
+	sqlRev.WriteString(fmt.Sprintf(" oracle schema reverse %s database\n", strings.ToLower(dialect.DBTypeT())))
 	t := table.NewWriter()
 	t.SetStyle(table.StyleLight)
-	t.AppendHeader(table.Row{"#", "ORACLE", "MYSQL", "SUGGEST"})
+	t.AppendHeader(table.Row{"#", "ORACLE", dialect.DBTypeT(), "SUGGEST"})
 	t.AppendRows([]table.Row{
 		{"Schema", sourceSchema, targetSchema, "Create Schema"},
 	})
 	sqlRev.WriteString(t.Render() + "\n")
 	sqlRev.WriteString("*/\n")
-
-	if oraCollation {
-		if _, ok := common.OracleCollationMap[common.StringUPPER(schemaCollation)]; !ok {
-			return fmt.Errorf("oracle schema collation [%s] isn't support", schemaCollation)
-		}
-		sqlRev.WriteString(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s DEFAULT CHARACTER SET %s COLLATE %s;\n\n", common.StringUPPER(targetSchema), strings.ToLower(common.MySQLCharacterSet), common.OracleCollationMap[common.StringUPPER(schemaCollation)]))
-	} else {
-		if _, ok := common.OracleCollationMap[common.StringUPPER(nlsComp)]; !ok {
-			return fmt.Errorf("oracle db nls_comp collation [%s] isn't support", nlsComp)
-		}
-		sqlRev.WriteString(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s DEFAULT CHARACTER SET %s COLLATE %s;\n\n", common.StringUPPER(targetSchema), strings.ToLower(common.MySQLCharacterSet), common.OracleCollationMap[common.StringUPPER(nlsComp)]))
-	}
+	sqlRev.WriteString(createSchema + "\n\n")
 
 	if _, err = f.RWriteString(sqlRev.String()); err != nil {
 		return err
 	}
 	endTime := time.Now()
-	zap.L().Info("output oracle to mysql schema create sql",
-		zap.String("schema", sourceSchema),
+	zap.L().Info("output oracle schema create sql",
+		zap.String("target db type", dialect.DBTypeT()),
+		logger.SchemaS(sourceSchema),
 		zap.String("cost", endTime.Sub(startTime).String()))
 
 	return nil
 }
 
+// 目标端库创建语句，排序规则 schema collation 优先，其次 db nls_comp
+func GenCreateSchemaDDL(oracle *oracle.Oracle, dialect reverse.Dialect, sourceSchema, targetSchema, nlsComp string) (string, error) {
+	var schemaCollation string
+
+	oraDBVersion, err := oracle.GetOracleDBVersion()
+	if err != nil {
+		return "", err
+	}
+
+	oraCollation := false
+	if common.VersionOrdinal(oraDBVersion) >= common.VersionOrdinal(common.OracleTableColumnCollationDBVersion) {
+		oraCollation = true
+	}
+	if oraCollation {
+		schemaCollation, err = oracle.GetOracleSchemaCollation(sourceSchema)
+		if err != nil {
+			return "", err
+		}
+		if _, ok := common.OracleCollationMap[common.StringUPPER(schemaCollation)]; !ok {
+			return "", fmt.Errorf("oracle schema collation [%s] isn't support", schemaCollation)
+		}
+		return dialect.GenCreateSchema(common.StringUPPER(targetSchema), common.OracleCollationMap[common.StringUPPER(schemaCollation)]), nil
+	}
+
+	if _, ok := common.OracleCollationMap[common.StringUPPER(nlsComp)]; !ok {
+		return "", fmt.Errorf("oracle db nls_comp collation [%s] isn't support", nlsComp)
+	}
+	return dialect.GenCreateSchema(common.StringUPPER(targetSchema), common.OracleCollationMap[common.StringUPPER(nlsComp)]), nil
+}
+
 func GenCompatibilityTable(f *reverse.File, sourceSchema string, partitionTables, temporaryTables, clusteredTables []string, materializedViews []string) error {
 	startTime := time.Now()
 	// 兼容提示
@@ -566,7 +724,7 @@ func GenCompatibilityTable(f *reverse.File, sourceSchema string, partitionTables
 	}
 	endTime := time.Now()
 	zap.L().Info("output oracle to mysql compatibility tips",
-		zap.String("schema", sourceSchema),
+		logger.SchemaS(sourceSchema),
 		zap.String("cost", endTime.Sub(startTime).String()))
 
 	return nil
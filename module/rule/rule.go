@@ -0,0 +1,195 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package rule
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/wentaojin/transferdb/common"
+	"gopkg.in/yaml.v2"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 声明式规则文件，对应元数据库自定义规则表
+//   - schema-datatype-rule：库级别数据类型转换规则 [schema_datatype_rule]
+//   - table-datatype-rule：表级别数据类型转换规则 [table_datatype_rule]
+//   - column-datatype-rule：字段级别数据类型转换规则 [column_datatype_rule]
+//   - table-name-rule：上下游表名映射规则 [table_name_rule]
+//   - column-defaultval-rule：字段默认值转换规则 [buildin_column_defaultval]
+//
+// 单个规则文件对应一组上下游数据库类型 db-type-s/db-type-t
+type File struct {
+	DBTypeS           string             `toml:"db-type-s" yaml:"db-type-s" json:"db-type-s"`
+	DBTypeT           string             `toml:"db-type-t" yaml:"db-type-t" json:"db-type-t"`
+	SchemaRules       []SchemaRule       `toml:"schema-datatype-rule,omitempty" yaml:"schema-datatype-rule,omitempty" json:"schema-datatype-rule"`
+	TableRules        []TableRule        `toml:"table-datatype-rule,omitempty" yaml:"table-datatype-rule,omitempty" json:"table-datatype-rule"`
+	ColumnRules       []ColumnRule       `toml:"column-datatype-rule,omitempty" yaml:"column-datatype-rule,omitempty" json:"column-datatype-rule"`
+	TableNameRules    []TableNameRule    `toml:"table-name-rule,omitempty" yaml:"table-name-rule,omitempty" json:"table-name-rule"`
+	DefaultValueRules []DefaultValueRule `toml:"column-defaultval-rule,omitempty" yaml:"column-defaultval-rule,omitempty" json:"column-defaultval-rule"`
+}
+
+type SchemaRule struct {
+	SchemaNameS string `toml:"schema-name-s" yaml:"schema-name-s" json:"schema-name-s"`
+	ColumnTypeS string `toml:"column-type-s" yaml:"column-type-s" json:"column-type-s"`
+	ColumnTypeT string `toml:"column-type-t" yaml:"column-type-t" json:"column-type-t"`
+}
+
+type TableRule struct {
+	SchemaNameS string `toml:"schema-name-s" yaml:"schema-name-s" json:"schema-name-s"`
+	TableNameS  string `toml:"table-name-s" yaml:"table-name-s" json:"table-name-s"`
+	ColumnTypeS string `toml:"column-type-s" yaml:"column-type-s" json:"column-type-s"`
+	ColumnTypeT string `toml:"column-type-t" yaml:"column-type-t" json:"column-type-t"`
+}
+
+type ColumnRule struct {
+	SchemaNameS string `toml:"schema-name-s" yaml:"schema-name-s" json:"schema-name-s"`
+	TableNameS  string `toml:"table-name-s" yaml:"table-name-s" json:"table-name-s"`
+	ColumnNameS string `toml:"column-name-s" yaml:"column-name-s" json:"column-name-s"`
+	ColumnTypeS string `toml:"column-type-s" yaml:"column-type-s" json:"column-type-s"`
+	ColumnTypeT string `toml:"column-type-t" yaml:"column-type-t" json:"column-type-t"`
+}
+
+type TableNameRule struct {
+	SchemaNameS string `toml:"schema-name-s" yaml:"schema-name-s" json:"schema-name-s"`
+	TableNameS  string `toml:"table-name-s" yaml:"table-name-s" json:"table-name-s"`
+	SchemaNameT string `toml:"schema-name-t" yaml:"schema-name-t" json:"schema-name-t"`
+	TableNameT  string `toml:"table-name-t" yaml:"table-name-t" json:"table-name-t"`
+}
+
+type DefaultValueRule struct {
+	DefaultValueS string `toml:"default-value-s" yaml:"default-value-s" json:"default-value-s"`
+	DefaultValueT string `toml:"default-value-t" yaml:"default-value-t" json:"default-value-t"`
+}
+
+// 根据文件后缀判断规则文件格式，.yaml/.yml 为 YAML，其余为 TOML
+func FileFormat(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		return common.RuleFileFormatYAML
+	default:
+		return common.RuleFileFormatTOML
+	}
+}
+
+// LoadFile 加载规则文件，未知配置项视为错误，避免拼写错误的规则被静默忽略
+func LoadFile(file string) (*File, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read rule file [%s] failed: %v", file, err)
+	}
+
+	f := &File{}
+	switch FileFormat(file) {
+	case common.RuleFileFormatYAML:
+		if err = yaml.UnmarshalStrict(content, f); err != nil {
+			return nil, fmt.Errorf("decode yaml rule file [%s] failed: %v", file, err)
+		}
+	default:
+		md, err := toml.Decode(string(content), f)
+		if err != nil {
+			return nil, fmt.Errorf("decode toml rule file [%s] failed: %v", file, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			var keys []string
+			for _, k := range undecoded {
+				keys = append(keys, k.String())
+			}
+			return nil, fmt.Errorf("decode toml rule file [%s] failed: unknown keys [%s]", file, strings.Join(keys, ","))
+		}
+	}
+	f.Normalize()
+	return f, nil
+}
+
+// WriteFile 规则写入文件，文件格式根据文件后缀判断
+func (f *File) WriteFile(file string) error {
+	var content []byte
+	switch FileFormat(file) {
+	case common.RuleFileFormatYAML:
+		out, err := yaml.Marshal(f)
+		if err != nil {
+			return fmt.Errorf("encode yaml rule file [%s] failed: %v", file, err)
+		}
+		content = out
+	default:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(f); err != nil {
+			return fmt.Errorf("encode toml rule file [%s] failed: %v", file, err)
+		}
+		content = buf.Bytes()
+	}
+	if dir := filepath.Dir(file); dir != "" {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return fmt.Errorf("create rule file [%s] dir failed: %v", file, err)
+		}
+	}
+	if err := os.WriteFile(file, content, 0644); err != nil {
+		return fmt.Errorf("write rule file [%s] failed: %v", file, err)
+	}
+	return nil
+}
+
+// Normalize 数据库类型、对象名以及数据类型统一大写，与元数据库规则大小写不敏感匹配保持一致
+// 默认值可能包含区分大小写的字符串常量，仅去除首尾空格
+func (f *File) Normalize() {
+	f.DBTypeS = common.StringUPPER(strings.TrimSpace(f.DBTypeS))
+	f.DBTypeT = common.StringUPPER(strings.TrimSpace(f.DBTypeT))
+	for i := range f.SchemaRules {
+		r := &f.SchemaRules[i]
+		r.SchemaNameS = normalizeName(r.SchemaNameS)
+		r.ColumnTypeS = normalizeName(r.ColumnTypeS)
+		r.ColumnTypeT = normalizeName(r.ColumnTypeT)
+	}
+	for i := range f.TableRules {
+		r := &f.TableRules[i]
+		r.SchemaNameS = normalizeName(r.SchemaNameS)
+		r.TableNameS = normalizeName(r.TableNameS)
+		r.ColumnTypeS = normalizeName(r.ColumnTypeS)
+		r.ColumnTypeT = normalizeName(r.ColumnTypeT)
+	}
+	for i := range f.ColumnRules {
+		r := &f.ColumnRules[i]
+		r.SchemaNameS = normalizeName(r.SchemaNameS)
+		r.TableNameS = normalizeName(r.TableNameS)
+		r.ColumnNameS = normalizeName(r.ColumnNameS)
+		r.ColumnTypeS = normalizeName(r.ColumnTypeS)
+		r.ColumnTypeT = normalizeName(r.ColumnTypeT)
+	}
+	for i := range f.TableNameRules {
+		r := &f.TableNameRules[i]
+		r.SchemaNameS = normalizeName(r.SchemaNameS)
+		r.TableNameS = normalizeName(r.TableNameS)
+		r.SchemaNameT = normalizeName(r.SchemaNameT)
+		r.TableNameT = normalizeName(r.TableNameT)
+	}
+	for i := range f.DefaultValueRules {
+		r := &f.DefaultValueRules[i]
+		r.DefaultValueS = strings.TrimSpace(r.DefaultValueS)
+		r.DefaultValueT = strings.TrimSpace(r.DefaultValueT)
+	}
+}
+
+func (f *File) String() string {
+	return fmt.Sprintf("db-type-s [%s] db-type-t [%s] schema-datatype-rule [%d] table-datatype-rule [%d] column-datatype-rule [%d] table-name-rule [%d] column-defaultval-rule [%d]",
+		f.DBTypeS, f.DBTypeT, len(f.SchemaRules), len(f.TableRules), len(f.ColumnRules), len(f.TableNameRules), len(f.DefaultValueRules))
+}
+
+func normalizeName(s string) string {
+	return common.StringUPPER(strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,176 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package rule
+
+import (
+	"context"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"go.uber.org/zap"
+)
+
+// Sync 规则文件同步至元数据库，单事务内执行
+// 规则以元数据库唯一键判断是否存在，存在则更新目标值，不存在则写入，重复同步结果一致
+// 元数据库存在而规则文件未声明的规则保留不删除
+func Sync(ctx context.Context, metaDB *meta.Meta, f *File) error {
+	return metaDB.Transaction(ctx, func(txnCtx context.Context) error {
+		var schemaRules []meta.SchemaDatatypeRule
+		for _, r := range f.SchemaRules {
+			schemaRules = append(schemaRules, meta.SchemaDatatypeRule{
+				DBTypeS:     f.DBTypeS,
+				DBTypeT:     f.DBTypeT,
+				SchemaNameS: r.SchemaNameS,
+				ColumnTypeS: r.ColumnTypeS,
+				ColumnTypeT: r.ColumnTypeT,
+			})
+		}
+		if err := meta.NewSchemaDatatypeRuleModel(metaDB).UpsertSchemaRule(txnCtx, schemaRules, common.RuleSyncBatchSize); err != nil {
+			return err
+		}
+
+		var tableRules []meta.TableDatatypeRule
+		for _, r := range f.TableRules {
+			tableRules = append(tableRules, meta.TableDatatypeRule{
+				DBTypeS:     f.DBTypeS,
+				DBTypeT:     f.DBTypeT,
+				SchemaNameS: r.SchemaNameS,
+				TableNameS:  r.TableNameS,
+				ColumnTypeS: r.ColumnTypeS,
+				ColumnTypeT: r.ColumnTypeT,
+			})
+		}
+		if err := meta.NewTableDatatypeRuleModel(metaDB).UpsertTableRule(txnCtx, tableRules, common.RuleSyncBatchSize); err != nil {
+			return err
+		}
+
+		var columnRules []meta.ColumnDatatypeRule
+		for _, r := range f.ColumnRules {
+			columnRules = append(columnRules, meta.ColumnDatatypeRule{
+				DBTypeS:     f.DBTypeS,
+				DBTypeT:     f.DBTypeT,
+				SchemaNameS: r.SchemaNameS,
+				TableNameS:  r.TableNameS,
+				ColumnNameS: r.ColumnNameS,
+				ColumnTypeS: r.ColumnTypeS,
+				ColumnTypeT: r.ColumnTypeT,
+			})
+		}
+		if err := meta.NewColumnDatatypeRuleModel(metaDB).UpsertColumnRule(txnCtx, columnRules, common.RuleSyncBatchSize); err != nil {
+			return err
+		}
+
+		var tableNameRules []meta.TableNameRule
+		for _, r := range f.TableNameRules {
+			tableNameRules = append(tableNameRules, meta.TableNameRule{
+				DBTypeS:     f.DBTypeS,
+				DBTypeT:     f.DBTypeT,
+				SchemaNameS: r.SchemaNameS,
+				TableNameS:  r.TableNameS,
+				SchemaNameT: r.SchemaNameT,
+				TableNameT:  r.TableNameT,
+			})
+		}
+		if err := meta.NewTableNameRuleModel(metaDB).UpsertTableNameRule(txnCtx, tableNameRules, common.RuleSyncBatchSize); err != nil {
+			return err
+		}
+
+		var defaultValueRules []meta.BuildinColumnDefaultval
+		for _, r := range f.DefaultValueRules {
+			defaultValueRules = append(defaultValueRules, meta.BuildinColumnDefaultval{
+				DBTypeS:       f.DBTypeS,
+				DBTypeT:       f.DBTypeT,
+				DefaultValueS: r.DefaultValueS,
+				DefaultValueT: r.DefaultValueT,
+			})
+		}
+		return meta.NewBuildinColumnDefaultvalModel(metaDB).UpsertColumnDefaultVal(txnCtx, defaultValueRules, common.RuleSyncBatchSize)
+	})
+}
+
+// Export 导出元数据库指定上下游数据库类型的当前规则，字段默认值规则包含内置规则
+func Export(ctx context.Context, metaDB *meta.Meta, dbTypeS, dbTypeT string) (*File, error) {
+	f := &File{
+		DBTypeS: common.StringUPPER(dbTypeS),
+		DBTypeT: common.StringUPPER(dbTypeT),
+	}
+
+	schemaRules, err := meta.NewSchemaDatatypeRuleModel(metaDB).QuerySchemaRuleByDBType(ctx, &meta.SchemaDatatypeRule{DBTypeS: f.DBTypeS, DBTypeT: f.DBTypeT})
+	if err != nil {
+		return f, err
+	}
+	for _, r := range schemaRules {
+		f.SchemaRules = append(f.SchemaRules, SchemaRule{
+			SchemaNameS: r.SchemaNameS,
+			ColumnTypeS: r.ColumnTypeS,
+			ColumnTypeT: r.ColumnTypeT,
+		})
+	}
+
+	tableRules, err := meta.NewTableDatatypeRuleModel(metaDB).QueryTableRuleByDBType(ctx, &meta.TableDatatypeRule{DBTypeS: f.DBTypeS, DBTypeT: f.DBTypeT})
+	if err != nil {
+		return f, err
+	}
+	for _, r := range tableRules {
+		f.TableRules = append(f.TableRules, TableRule{
+			SchemaNameS: r.SchemaNameS,
+			TableNameS:  r.TableNameS,
+			ColumnTypeS: r.ColumnTypeS,
+			ColumnTypeT: r.ColumnTypeT,
+		})
+	}
+
+	columnRules, err := meta.NewColumnDatatypeRuleModel(metaDB).QueryColumnRuleByDBType(ctx, &meta.ColumnDatatypeRule{DBTypeS: f.DBTypeS, DBTypeT: f.DBTypeT})
+	if err != nil {
+		return f, err
+	}
+	for _, r := range columnRules {
+		f.ColumnRules = append(f.ColumnRules, ColumnRule{
+			SchemaNameS: r.SchemaNameS,
+			TableNameS:  r.TableNameS,
+			ColumnNameS: r.ColumnNameS,
+			ColumnTypeS: r.ColumnTypeS,
+			ColumnTypeT: r.ColumnTypeT,
+		})
+	}
+
+	tableNameRules, err := meta.NewTableNameRuleModel(metaDB).QueryTableNameRuleByDBType(ctx, &meta.TableNameRule{DBTypeS: f.DBTypeS, DBTypeT: f.DBTypeT})
+	if err != nil {
+		return f, err
+	}
+	for _, r := range tableNameRules {
+		f.TableNameRules = append(f.TableNameRules, TableNameRule{
+			SchemaNameS: r.SchemaNameS,
+			TableNameS:  r.TableNameS,
+			SchemaNameT: r.SchemaNameT,
+			TableNameT:  r.TableNameT,
+		})
+	}
+
+	defaultValueRules, err := meta.NewBuildinColumnDefaultvalModel(metaDB).QueryColumnDefaultValByDBType(ctx, &meta.BuildinColumnDefaultval{DBTypeS: f.DBTypeS, DBTypeT: f.DBTypeT})
+	if err != nil {
+		return f, err
+	}
+	for _, r := range defaultValueRules {
+		f.DefaultValueRules = append(f.DefaultValueRules, DefaultValueRule{
+			DefaultValueS: r.DefaultValueS,
+			DefaultValueT: r.DefaultValueT,
+		})
+	}
+
+	f.Normalize()
+	zap.L().Info("export meta rules", zap.String("rules", f.String()))
+	return f, nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package rule
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/errors"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// IRule 声明式规则文件 validate/sync/export，validate 不访问元数据库，metaDB 可为 nil
+func IRule(ctx context.Context, cfg *config.Config, metaDB *meta.Meta) error {
+	startTime := time.Now()
+	action := strings.ToLower(strings.TrimSpace(cfg.RuleAction))
+	if cfg.RuleFile == "" {
+		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_RULE, fmt.Errorf("flag [rule-file] can not null"))
+	}
+	zap.L().Info("rule file task start", zap.String("action", action), zap.String("file", cfg.RuleFile))
+
+	switch action {
+	case common.RuleActionValidate, common.RuleActionSync:
+		f, err := LoadFile(cfg.RuleFile)
+		if err != nil {
+			return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_RULE, err)
+		}
+		lister, err := newTableLister(ctx, cfg, f.DBTypeS)
+		if err != nil {
+			return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_RULE, err)
+		}
+		if err = Validate(f, lister); err != nil {
+			return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_RULE, err)
+		}
+		zap.L().Info("rule file validate success", zap.String("file", cfg.RuleFile), zap.String("rules", f.String()))
+		if action == common.RuleActionValidate {
+			break
+		}
+		if err = metaDB.MigrateTables(); err != nil {
+			return err
+		}
+		if err = Sync(ctx, metaDB, f); err != nil {
+			return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_RULE, err)
+		}
+		zap.L().Info("rule file sync meta success", zap.String("file", cfg.RuleFile))
+	case common.RuleActionExport:
+		dbTypeS, dbTypeT := cfg.RuleDBTypeS, cfg.RuleDBTypeT
+		if dbTypeS == "" {
+			dbTypeS = common.TaskDBOracle
+		}
+		if dbTypeT == "" {
+			dbTypeT = cfg.MySQLConfig.DBType
+		}
+		f, err := Export(ctx, metaDB, dbTypeS, dbTypeT)
+		if err != nil {
+			return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_RULE, err)
+		}
+		if err = f.WriteFile(cfg.RuleFile); err != nil {
+			return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_RULE, err)
+		}
+	default:
+		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_RULE, fmt.Errorf("rules subcommand [%s] isn't support, please configure [%s/%s/%s]",
+			cfg.RuleAction, common.RuleActionValidate, common.RuleActionSync, common.RuleActionExport))
+	}
+
+	zap.L().Info("rule file task finished", zap.String("action", action), zap.String("cost", time.Since(startTime).String()))
+	return nil
+}
+
+// 连接源端数据库获取表名，源端连接未配置跳过规则引用表是否存在校验
+func newTableLister(ctx context.Context, cfg *config.Config, dbTypeS string) (TableLister, error) {
+	switch dbTypeS {
+	case common.TaskDBOracle:
+		if cfg.OracleConfig.Host == "" {
+			zap.L().Warn("oracle config host is empty, skip rule table exist check")
+			return nil, nil
+		}
+		oracleDB, err := oracle.NewOracleDBEngine(ctx, cfg.OracleConfig)
+		if err != nil {
+			return nil, err
+		}
+		return oracleDB.GetOracleSchemaTable, nil
+	case common.TaskDBMySQL, common.TaskDBTiDB:
+		if cfg.MySQLConfig.Host == "" {
+			zap.L().Warn("mysql config host is empty, skip rule table exist check")
+			return nil, nil
+		}
+		mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
+		if err != nil {
+			return nil, err
+		}
+		return mysqlDB.GetMySQLTable, nil
+	default:
+		// 数据库类型不支持，由 Validate 统一报错
+		return nil, nil
+	}
+}
@@ -0,0 +1,258 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package rule
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TableLister 获取源端 schema 下表名，用于校验规则引用表是否存在
+type TableLister func(schemaName string) ([]string, error)
+
+// 规则文件支持的上下游数据库类型
+var supportDBTypePairs = map[string][]string{
+	common.TaskDBOracle: {common.TaskDBMySQL, common.TaskDBTiDB, common.TaskDBPostgres},
+	common.TaskDBMySQL:  {common.TaskDBOracle},
+	common.TaskDBTiDB:   {common.TaskDBOracle},
+}
+
+// PostgreSQL 常用数据类型名，补充内置 O2P 映射规则未覆盖的类型
+var postgresDatatypeNames = []string{
+	"SMALLINT", "INTEGER", "INT", "BIGINT", "NUMERIC", "DECIMAL", "REAL", "DOUBLE PRECISION", "SERIAL", "BIGSERIAL",
+	"CHAR", "CHARACTER", "VARCHAR", "CHARACTER VARYING", "TEXT", "BYTEA", "BOOLEAN",
+	"DATE", "TIME", "TIMESTAMP", "TIMESTAMPTZ", "TIMESTAMP WITH TIME ZONE", "INTERVAL", "JSON", "JSONB", "UUID", "XML",
+}
+
+var (
+	datatypeParamRegex = regexp.MustCompile(`\([^)]*\)`)
+	// MySQL/TiDB 数据类型修饰属性
+	datatypeAttrRegex = regexp.MustCompile(`\s+(UNSIGNED|ZEROFILL|SIGNED)\b`)
+)
+
+// Validate 校验规则文件，返回全部校验问题
+//   - 上下游数据库类型以及规则数据类型是否支持
+//   - 必填项是否为空
+//   - 同一规则对象是否重复或者冲突（同一源端对象映射不同目标）
+//   - 规则引用源端 schema、表是否存在，lister 为 nil 跳过
+func Validate(f *File, lister TableLister) error {
+	var problems []string
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	targets, ok := supportDBTypePairs[f.DBTypeS]
+	if !ok || !common.IsContainString(targets, f.DBTypeT) {
+		addProblem("db-type-s [%s] db-type-t [%s] isn't support, support [ORACLE -> MYSQL/TIDB/POSTGRESQL] or [MYSQL/TIDB -> ORACLE]", f.DBTypeS, f.DBTypeT)
+		return validateError(problems)
+	}
+	sourceTypes := datatypeNames(f.DBTypeS, true)
+	targetTypes := datatypeNames(f.DBTypeT, false)
+
+	checkDatatype := func(rule string, idx int, columnTypeS, columnTypeT string) {
+		if columnTypeS == "" || columnTypeT == "" {
+			addProblem("%s #%d column-type-s and column-type-t can't be empty", rule, idx+1)
+			return
+		}
+		if !isDatatypeName(sourceTypes, columnTypeS) {
+			addProblem("%s #%d column-type-s [%s] is unknown %s datatype", rule, idx+1, columnTypeS, f.DBTypeS)
+		}
+		if !isDatatypeName(targetTypes, columnTypeT) {
+			addProblem("%s #%d column-type-t [%s] is unknown %s datatype", rule, idx+1, columnTypeT, f.DBTypeT)
+		}
+	}
+	// key -> 目标值以及首次出现位置
+	type seen struct {
+		value string
+		idx   int
+	}
+	checkDuplicate := func(rule string, idx int, keys map[string]seen, key, value string) {
+		if s, exist := keys[key]; exist {
+			if s.value == value {
+				addProblem("%s #%d [%s] is duplicated with #%d", rule, idx+1, key, s.idx+1)
+			} else {
+				addProblem("%s #%d [%s] is conflicting with #%d, target [%s] and [%s]", rule, idx+1, key, s.idx+1, value, s.value)
+			}
+			return
+		}
+		keys[key] = seen{value: value, idx: idx}
+	}
+
+	// 规则引用源端表
+	refTables := make(map[string][]string)
+
+	schemaKeys := make(map[string]seen)
+	for i, r := range f.SchemaRules {
+		if r.SchemaNameS == "" {
+			addProblem("schema-datatype-rule #%d schema-name-s can't be empty", i+1)
+			continue
+		}
+		checkDatatype("schema-datatype-rule", i, r.ColumnTypeS, r.ColumnTypeT)
+		checkDuplicate("schema-datatype-rule", i, schemaKeys, common.StringsBuilder(r.SchemaNameS, ".", r.ColumnTypeS), r.ColumnTypeT)
+		refTables[r.SchemaNameS] = append(refTables[r.SchemaNameS], "")
+	}
+
+	tableKeys := make(map[string]seen)
+	for i, r := range f.TableRules {
+		if r.SchemaNameS == "" || r.TableNameS == "" {
+			addProblem("table-datatype-rule #%d schema-name-s and table-name-s can't be empty", i+1)
+			continue
+		}
+		checkDatatype("table-datatype-rule", i, r.ColumnTypeS, r.ColumnTypeT)
+		checkDuplicate("table-datatype-rule", i, tableKeys, common.StringsBuilder(r.SchemaNameS, ".", r.TableNameS, ".", r.ColumnTypeS), r.ColumnTypeT)
+		refTables[r.SchemaNameS] = append(refTables[r.SchemaNameS], r.TableNameS)
+	}
+
+	columnKeys := make(map[string]seen)
+	for i, r := range f.ColumnRules {
+		if r.SchemaNameS == "" || r.TableNameS == "" || r.ColumnNameS == "" {
+			addProblem("column-datatype-rule #%d schema-name-s, table-name-s and column-name-s can't be empty", i+1)
+			continue
+		}
+		checkDatatype("column-datatype-rule", i, r.ColumnTypeS, r.ColumnTypeT)
+		checkDuplicate("column-datatype-rule", i, columnKeys, common.StringsBuilder(r.SchemaNameS, ".", r.TableNameS, ".", r.ColumnNameS),
+			common.StringsBuilder(r.ColumnTypeS, " -> ", r.ColumnTypeT))
+		refTables[r.SchemaNameS] = append(refTables[r.SchemaNameS], r.TableNameS)
+	}
+
+	tableNameKeys := make(map[string]seen)
+	tableNameTargets := make(map[string]seen)
+	for i, r := range f.TableNameRules {
+		if r.SchemaNameS == "" || r.TableNameS == "" || r.SchemaNameT == "" || r.TableNameT == "" {
+			addProblem("table-name-rule #%d schema-name-s, table-name-s, schema-name-t and table-name-t can't be empty", i+1)
+			continue
+		}
+		sourceTable := common.StringsBuilder(r.SchemaNameS, ".", r.TableNameS)
+		targetTable := common.StringsBuilder(r.SchemaNameT, ".", r.TableNameT)
+		checkDuplicate("table-name-rule", i, tableNameKeys, sourceTable, targetTable)
+		// 不同源端表映射同一目标表
+		if s, exist := tableNameTargets[targetTable]; exist && s.value != sourceTable {
+			addProblem("table-name-rule #%d target table [%s] is conflicting with #%d, source [%s] and [%s]", i+1, targetTable, s.idx+1, sourceTable, s.value)
+		} else if !exist {
+			tableNameTargets[targetTable] = seen{value: sourceTable, idx: i}
+		}
+		refTables[r.SchemaNameS] = append(refTables[r.SchemaNameS], r.TableNameS)
+	}
+
+	defaultKeys := make(map[string]seen)
+	for i, r := range f.DefaultValueRules {
+		if r.DefaultValueS == "" || r.DefaultValueT == "" {
+			addProblem("column-defaultval-rule #%d default-value-s and default-value-t can't be empty", i+1)
+			continue
+		}
+		// 默认值规则大小写不敏感匹配
+		checkDuplicate("column-defaultval-rule", i, defaultKeys, common.StringUPPER(r.DefaultValueS), r.DefaultValueT)
+	}
+
+	if lister != nil {
+		var schemas []string
+		for s := range refTables {
+			schemas = append(schemas, s)
+		}
+		sort.Strings(schemas)
+		for _, s := range schemas {
+			tables, err := lister(s)
+			if err != nil {
+				addProblem("get %s schema [%s] tables failed: %v", f.DBTypeS, s, err)
+				continue
+			}
+			if len(tables) == 0 {
+				addProblem("%s schema [%s] isn't exist or has no tables", f.DBTypeS, s)
+				continue
+			}
+			exists := make(map[string]struct{}, len(tables))
+			for _, t := range tables {
+				exists[common.StringUPPER(t)] = struct{}{}
+			}
+			reported := make(map[string]struct{})
+			for _, t := range refTables[s] {
+				if t == "" {
+					continue
+				}
+				if _, ok := exists[t]; ok {
+					continue
+				}
+				if _, ok := reported[t]; !ok {
+					addProblem("%s table [%s.%s] isn't exist", f.DBTypeS, s, t)
+					reported[t] = struct{}{}
+				}
+			}
+		}
+	}
+
+	return validateError(problems)
+}
+
+func validateError(problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("rule file validate failed, %d problems:\n  - %s", len(problems), strings.Join(problems, "\n  - "))
+}
+
+// 数据库数据类型名，统一去除精度、长度等参数
+func datatypeNames(dbType string, source bool) map[string]struct{} {
+	names := make(map[string]struct{})
+	add := func(name string) {
+		names[datatypeBaseName(name)] = struct{}{}
+	}
+	switch dbType {
+	case common.TaskDBOracle:
+		for k := range common.BuildInOracleO2MDatatypeNameMap {
+			add(k)
+		}
+		// INTERVAL DAY(p) TO SECOND(s) 组合过多，内置规则代码内匹配处理
+		add("INTERVAL DAY TO SECOND")
+	case common.TaskDBMySQL, common.TaskDBTiDB:
+		for k := range common.BuildInMySQLM2ODatatypeNameMap {
+			add(k)
+		}
+		if !source {
+			// 内置 O2M 映射规则目标类型，例如 NVARCHAR、LONG BLOB
+			for _, v := range common.BuildInOracleO2MDatatypeNameMap {
+				for _, t := range strings.Split(v, "/") {
+					add(t)
+				}
+			}
+			add("JSON")
+		}
+	case common.TaskDBPostgres:
+		for _, v := range common.BuildInOracleO2PDatatypeNameMap {
+			for _, t := range strings.Split(v, "/") {
+				add(t)
+			}
+		}
+		for _, t := range postgresDatatypeNames {
+			add(t)
+		}
+	}
+	return names
+}
+
+func isDatatypeName(names map[string]struct{}, columnType string) bool {
+	_, ok := names[datatypeBaseName(columnType)]
+	return ok
+}
+
+// 数据类型名去除参数以及修饰属性，例如 NUMBER(10,2) -> NUMBER、TIMESTAMP(6) WITH TIME ZONE -> TIMESTAMP WITH TIME ZONE
+func datatypeBaseName(columnType string) string {
+	name := datatypeParamRegex.ReplaceAllString(common.StringUPPER(columnType), " ")
+	name = datatypeAttrRegex.ReplaceAllString(name, " ")
+	return strings.Join(strings.Fields(name), " ")
+}
@@ -0,0 +1,81 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package task
+
+import (
+	"context"
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"strings"
+)
+
+// 查看 error_log_detail 错误记录，tables 为空返回 schema 全部记录
+func ListErrors(ctx context.Context, metaDB *meta.Meta, schemaNameS, runMode string, tables []string) ([]meta.ErrorLogDetail, error) {
+	if len(tables) == 0 {
+		tables = []string{""}
+	}
+	var errLogs []meta.ErrorLogDetail
+	for _, t := range tables {
+		logs, err := meta.NewErrorLogDetailModel(metaDB).DetailErrorLogBySchemaMode(ctx, &meta.ErrorLogDetail{
+			SchemaNameS: schemaNameS,
+			TableNameS:  t,
+			RunMode:     runMode,
+		})
+		if err != nil {
+			return errLogs, err
+		}
+		errLogs = append(errLogs, logs...)
+	}
+	return errLogs, nil
+}
+
+// 清理 error_log_detail 错误记录，tables 为空清理 schema 全部记录，返回清理记录数
+func ClearErrors(ctx context.Context, metaDB *meta.Meta, schemaNameS, runMode string, tables []string) (int64, error) {
+	if len(tables) == 0 {
+		tables = []string{""}
+	}
+	var totals int64
+	for _, t := range tables {
+		counts, err := meta.NewErrorLogDetailModel(metaDB).DeleteErrorLogBySchemaMode(ctx, &meta.ErrorLogDetail{
+			SchemaNameS: schemaNameS,
+			TableNameS:  t,
+			RunMode:     runMode,
+		})
+		if err != nil {
+			return totals, err
+		}
+		totals += counts
+	}
+	return totals, nil
+}
+
+func ErrorsString(schemaNameS, runMode string, errLogs []meta.ErrorLogDetail) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("transferdb task errors, schema [%s] mode [%s] records [%d]\n", schemaNameS, runMode, len(errLogs)))
+	if len(errLogs) == 0 {
+		return b.String()
+	}
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.AppendHeader(table.Row{"#", "ID", "SOURCE TABLE", "RUN STATUS", "INFO DETAIL", "ERROR DETAIL"})
+	for i, e := range errLogs {
+		t.AppendRow(table.Row{i + 1, e.ID, common.StringsBuilder(e.SchemaNameS, ".", e.TableNameS), e.RunStatus, e.InfoDetail, e.ErrorDetail})
+	}
+	b.WriteString(t.Render() + "\n")
+	return b.String()
+}
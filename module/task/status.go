@@ -0,0 +1,216 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package task
+
+import (
+	"context"
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/plan"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 表级别任务状态
+type TableStatus struct {
+	TableNameS    string `json:"table_name_s"`
+	State         string `json:"state"`
+	FullGlobalSCN uint64 `json:"full_global_scn"` // 全量全局 SCN
+	Chunks        int    `json:"chunks"`          // chunk 总数，-1 未知
+	Remains       int    `json:"remains"`         // 未完成 chunk 数
+	IncrGlobalSCN uint64 `json:"incr_global_scn"` // 增量全局 SCN
+	IncrTableSCN  uint64 `json:"incr_table_scn"`  // 增量表同步 SCN
+	Errors        int64  `json:"errors"`
+}
+
+// 存在断点记录
+func (s *TableStatus) Checkpoint() bool {
+	return s.State != plan.TableStateInit
+}
+
+// 依据元数据库 wait_sync_meta、full_sync_meta/data_compare_meta、incr_sync_meta 以及 error_log_detail 汇总表级别任务状态
+// all 模式全量阶段断点记录模式为 FullO2M，tables 为空返回存在记录的全部表
+func Status(ctx context.Context, metaDB *meta.Meta, schemaNameS, runMode string, tables []string) ([]*TableStatus, error) {
+	syncMode := runMode
+	if runMode == common.AllO2MMode {
+		syncMode = common.FullO2MMode
+	}
+	statuses := make(map[string]*TableStatus)
+	getStatus := func(tableName string) *TableStatus {
+		tableName = common.StringUPPER(tableName)
+		if s, ok := statuses[tableName]; ok {
+			return s
+		}
+		s := &TableStatus{TableNameS: tableName, State: plan.TableStateInit, Chunks: -1}
+		statuses[tableName] = s
+		return s
+	}
+	for _, t := range tables {
+		getStatus(t)
+	}
+
+	waitSyncMetas, err := meta.NewWaitSyncMetaModel(metaDB).DetailWaitSyncMeta(ctx, &meta.WaitSyncMeta{
+		DBTypeS:     common.TaskDBOracle,
+		DBTypeT:     common.TaskDBMySQL,
+		SchemaNameS: schemaNameS,
+		Mode:        syncMode,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range waitSyncMetas {
+		s := getStatus(w.TableNameS)
+		s.State = plan.TableState([]meta.WaitSyncMeta{w}, true)
+		s.FullGlobalSCN = w.FullGlobalSCN
+		if w.FullSplitTimes > 0 {
+			s.Chunks = w.FullSplitTimes
+		}
+	}
+
+	var remains map[string]int
+	if runMode == common.CompareO2MMode {
+		remains, err = meta.NewDataCompareMetaModel(metaDB).CountsDataCompareMetaGroupByTableNameS(ctx, &meta.DataCompareMeta{
+			DBTypeS:     common.TaskDBOracle,
+			DBTypeT:     common.TaskDBMySQL,
+			SchemaNameS: schemaNameS,
+		})
+	} else {
+		remains, err = meta.NewFullSyncMetaModel(metaDB).CountsFullSyncMetaGroupByTableNameS(ctx, &meta.FullSyncMeta{
+			DBTypeS:     common.TaskDBOracle,
+			DBTypeT:     common.TaskDBMySQL,
+			SchemaNameS: schemaNameS,
+			Mode:        syncMode,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	for t, c := range remains {
+		getStatus(t).Remains = c
+	}
+
+	if runMode == common.AllO2MMode {
+		incrSyncMetas, err := meta.NewIncrSyncMetaModel(metaDB).DetailIncrSyncMetaBySchema(ctx, &meta.IncrSyncMeta{
+			DBTypeS:     common.TaskDBOracle,
+			DBTypeT:     common.TaskDBMySQL,
+			SchemaNameS: schemaNameS,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range incrSyncMetas {
+			s := getStatus(m.TableNameS)
+			s.State = plan.TableStateIncr
+			s.IncrGlobalSCN = m.GlobalScnS
+			s.IncrTableSCN = m.TableScnS
+		}
+	}
+
+	errLogs, err := meta.NewErrorLogDetailModel(metaDB).DetailErrorLogBySchemaMode(ctx, &meta.ErrorLogDetail{
+		SchemaNameS: schemaNameS,
+		RunMode:     runMode,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range errLogs {
+		getStatus(e.TableNameS).Errors++
+	}
+
+	var results []*TableStatus
+	for t, s := range statuses {
+		if len(tables) > 0 && !common.IsContainString(tables, t) {
+			continue
+		}
+		results = append(results, s)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].TableNameS < results[j].TableNameS
+	})
+	return results, nil
+}
+
+// 重置表级别断点记录，tables 为空重置存在断点记录的全部表，返回重置表
+func Reset(ctx context.Context, metaDB *meta.Meta, schemaNameS, runMode string, tables []string) ([]string, error) {
+	statuses, err := Status(ctx, metaDB, schemaNameS, runMode, tables)
+	if err != nil {
+		return nil, err
+	}
+	var resets []string
+	for _, s := range statuses {
+		if len(tables) == 0 && !s.Checkpoint() && s.Remains == 0 {
+			continue
+		}
+		if err = meta.NewCommonModel(metaDB).DeleteTableSyncMetaByMode(ctx, &meta.WaitSyncMeta{
+			DBTypeS:     common.TaskDBOracle,
+			DBTypeT:     common.TaskDBMySQL,
+			SchemaNameS: schemaNameS,
+			TableNameS:  s.TableNameS,
+			Mode:        runMode,
+		}); err != nil {
+			return resets, fmt.Errorf("reset table [%s.%s] mode [%s] checkpoint failed: %v", schemaNameS, s.TableNameS, runMode, err)
+		}
+		resets = append(resets, s.TableNameS)
+	}
+	return resets, nil
+}
+
+func StatusString(schemaNameS, runMode string, statuses []*TableStatus) string {
+	var (
+		b                       strings.Builder
+		checkpoints, remains    int
+		errTotals               int64
+		incrGlobalSCN, tableSCN uint64
+	)
+	b.WriteString(fmt.Sprintf("transferdb task status, schema [%s] mode [%s]\n", schemaNameS, runMode))
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.AppendHeader(table.Row{"#", "SOURCE TABLE", "STATE", "FULL GLOBAL SCN", "CHUNKS", "REMAINING CHUNKS", "INCR GLOBAL SCN", "INCR TABLE SCN", "ERRORS"})
+	for i, s := range statuses {
+		if s.Checkpoint() {
+			checkpoints++
+		}
+		remains += s.Remains
+		errTotals += s.Errors
+		if s.State == plan.TableStateIncr && (tableSCN == 0 || s.IncrTableSCN < tableSCN) {
+			incrGlobalSCN, tableSCN = s.IncrGlobalSCN, s.IncrTableSCN
+		}
+		t.AppendRow(table.Row{i + 1, common.StringsBuilder(schemaNameS, ".", s.TableNameS), s.State,
+			statusSCN(s.FullGlobalSCN), statusInt(s.Chunks), s.Remains, statusSCN(s.IncrGlobalSCN), statusSCN(s.IncrTableSCN), s.Errors})
+	}
+	t.AppendFooter(table.Row{"", "TOTAL", fmt.Sprintf("CHECKPOINT %d", checkpoints), "", "", remains, statusSCN(incrGlobalSCN), statusSCN(tableSCN), errTotals})
+	b.WriteString(t.Render() + "\n")
+	return b.String()
+}
+
+// SCN 0 未记录输出 -
+func statusSCN(scn uint64) string {
+	if scn == 0 {
+		return "-"
+	}
+	return strconv.FormatUint(scn, 10)
+}
+
+// 未知数值 -1 输出 -
+func statusInt(v int) string {
+	if v < 0 {
+		return "-"
+	}
+	return strconv.Itoa(v)
+}
@@ -0,0 +1,191 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package task
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/errors"
+	"github.com/wentaojin/transferdb/logger"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// 运行模式 --mode 对应元数据库记录模式
+var runModes = map[string]string{
+	"reverseo2m": common.ReverseO2MMode,
+	"reversem2o": common.ReverseM2OMode,
+	"reverseo2p": common.ReverseO2PMode,
+	"check":      common.CheckO2MMode,
+	"checko2p":   common.CheckO2PMode,
+	"compare":    common.CompareO2MMode,
+	"csv":        common.CSVO2MMode,
+	"full":       common.FullO2MMode,
+	"all":        common.AllO2MMode,
+}
+
+// 存在断点记录的运行模式，支持 status/reset/resume
+var checkpointModes = []string{"full", "csv", "all", "compare"}
+
+// ITask 任务管理子命令 status/reset/errors list/errors clear
+// resume 需运行对应模式，由 server 校验断点后运行
+func ITask(ctx context.Context, cfg *config.Config, metaDB *meta.Meta) error {
+	startTime := time.Now()
+	mode, runMode, err := GetRunMode(cfg)
+	if err != nil {
+		return err
+	}
+	schemaNameS := GetSchemaNameS(cfg, runMode)
+	tables := cfg.GetTaskTables()
+	logger.L(ctx).Info("task subcommand start",
+		zap.String("action", cfg.TaskAction),
+		logger.Mode(runMode),
+		logger.SchemaS(schemaNameS),
+		zap.Strings("tables", tables))
+
+	switch cfg.TaskAction {
+	case common.TaskActionStatus:
+		if err = checkCheckpointMode(cfg.TaskAction, mode); err != nil {
+			return err
+		}
+		statuses, err := Status(ctx, metaDB, schemaNameS, runMode, tables)
+		if err != nil {
+			return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_TASK, err)
+		}
+		fmt.Print(StatusString(schemaNameS, runMode, statuses))
+	case common.TaskActionReset:
+		if err = checkCheckpointMode(cfg.TaskAction, mode); err != nil {
+			return err
+		}
+		if len(tables) == 0 && !cfg.TaskAllTables {
+			return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_TASK,
+				fmt.Errorf("task reset flag [table] is null, please configure flag [table] or flag [all-tables] to reset all tables of schema [%s]", schemaNameS))
+		}
+		resets, err := Reset(ctx, metaDB, schemaNameS, runMode, tables)
+		if err != nil {
+			return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_TASK, err)
+		}
+		fmt.Printf("task reset schema [%s] mode [%s] tables %v checkpoint success, error_log_detail records are kept, please run [task errors clear] if needed\n",
+			schemaNameS, runMode, resets)
+	case common.TaskActionErrorsList:
+		errLogs, err := ListErrors(ctx, metaDB, schemaNameS, runMode, tables)
+		if err != nil {
+			return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_TASK, err)
+		}
+		fmt.Print(ErrorsString(schemaNameS, runMode, errLogs))
+	case common.TaskActionErrorsClear:
+		counts, err := ClearErrors(ctx, metaDB, schemaNameS, runMode, tables)
+		if err != nil {
+			return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_TASK, err)
+		}
+		fmt.Printf("task errors clear schema [%s] mode [%s] %d records success\n", schemaNameS, runMode, counts)
+	default:
+		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_TASK, fmt.Errorf("task subcommand [%s] isn't support, please configure [%s/%s/%s/%s/%s]",
+			cfg.TaskAction, common.TaskActionStatus, common.TaskActionReset, common.TaskActionErrorsList, common.TaskActionErrorsClear, common.TaskActionResume))
+	}
+
+	zap.L().Info("task subcommand finished",
+		zap.String("action", cfg.TaskAction),
+		zap.String("mode", runMode),
+		zap.String("cost", time.Since(startTime).String()))
+	return nil
+}
+
+// Resume 校验断点记录以及错误记录，开启对应模式断点续传
+// 校验通过后由调用方按 --mode 运行
+func Resume(ctx context.Context, cfg *config.Config, metaDB *meta.Meta) error {
+	mode, runMode, err := GetRunMode(cfg)
+	if err != nil {
+		return err
+	}
+	if err = checkCheckpointMode(cfg.TaskAction, mode); err != nil {
+		return err
+	}
+	schemaNameS := GetSchemaNameS(cfg, runMode)
+
+	statuses, err := Status(ctx, metaDB, schemaNameS, runMode, nil)
+	if err != nil {
+		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_TASK, err)
+	}
+	var (
+		checkpoints int
+		errTotals   int64
+	)
+	for _, s := range statuses {
+		if s.Checkpoint() {
+			checkpoints++
+		}
+		errTotals += s.Errors
+	}
+	if checkpoints == 0 {
+		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_TASK,
+			fmt.Errorf("schema [%s] mode [%s] hasn't checkpoint record, please run mode [%s] directly", schemaNameS, runMode, mode))
+	}
+	if errTotals > 0 {
+		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_TASK,
+			fmt.Errorf("schema [%s] mode [%s] has [%d] error_log_detail records, please run [task errors list] to check and [task errors clear] before resuming", schemaNameS, runMode, errTotals))
+	}
+
+	switch mode {
+	case "full", "all":
+		cfg.FullConfig.EnableCheckpoint = true
+	case "csv":
+		cfg.CSVConfig.EnableCheckpoint = true
+	case "compare":
+		cfg.DiffConfig.EnableCheckpoint = true
+	}
+	logger.L(ctx).Info("task resume check success",
+		logger.Mode(runMode),
+		logger.SchemaS(schemaNameS),
+		zap.Int("checkpoint tables", checkpoints))
+	return nil
+}
+
+// 获取 --mode 以及对应元数据库记录模式
+func GetRunMode(cfg *config.Config) (string, string, error) {
+	mode := strings.ToLower(strings.TrimSpace(cfg.Mode))
+	runMode, ok := runModes[mode]
+	if !ok {
+		return mode, runMode, errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_TASK,
+			fmt.Errorf("task subcommand flag [mode] [%s] isn't support, please configure [reverseO2M reverseM2O reverseO2P check checkO2P compare csv full all]", cfg.Mode))
+	}
+	return mode, runMode, nil
+}
+
+// 获取运行模式源端 schema，reverseM2O 源端为 MySQL
+func GetSchemaNameS(cfg *config.Config, runMode string) string {
+	if runMode == common.ReverseM2OMode {
+		return common.StringUPPER(cfg.MySQLConfig.SchemaName)
+	}
+	return common.StringUPPER(cfg.OracleConfig.SchemaName)
+}
+
+// 运行模式是否存在断点记录
+func IsCheckpointMode(mode string) bool {
+	return common.IsContainString(checkpointModes, strings.ToLower(strings.TrimSpace(mode)))
+}
+
+func checkCheckpointMode(action, mode string) error {
+	if !IsCheckpointMode(mode) {
+		return errors.NewMSError(errors.TRANSFERDB, errors.DOMAIN_TASK,
+			fmt.Errorf("task subcommand [%s] only support mode [full/csv/all/compare], current mode [%s]", action, mode))
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package progress
+
+import (
+	"encoding/json"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/metrics"
+	"net/http"
+	"sync"
+)
+
+var (
+	trackerMu sync.Mutex
+	trackers  []*Tracker
+)
+
+func register(t *Tracker) {
+	trackerMu.Lock()
+	defer trackerMu.Unlock()
+	trackers = append(trackers, t)
+}
+
+func unregister(t *Tracker) {
+	trackerMu.Lock()
+	defer trackerMu.Unlock()
+	for i, r := range trackers {
+		if r == t {
+			trackers = append(trackers[:i], trackers[i+1:]...)
+			return
+		}
+	}
+}
+
+// 当前运行任务进度快照
+func Snapshots() []Progress {
+	trackerMu.Lock()
+	running := append([]*Tracker{}, trackers...)
+	trackerMu.Unlock()
+
+	progresses := make([]Progress, 0, len(running))
+	for _, t := range running {
+		progresses = append(progresses, t.Snapshot())
+	}
+	return progresses
+}
+
+// JSON 进度输出，GET /progress
+func JSONHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(Snapshots()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+type metric struct {
+	desc  *prometheus.Desc
+	typ   prometheus.ValueType
+	value func(p Progress, t *TableProgress) float64
+}
+
+func newMetric(name, help string, typ prometheus.ValueType, labels []string, value func(p Progress, t *TableProgress) float64) metric {
+	return metric{desc: prometheus.NewDesc(name, help, labels, nil), typ: typ, value: value}
+}
+
+// 行数以及字节数累计值见 metrics transferdb_rows_total/transferdb_bytes_total，进度指标仅输出完成数、速率以及预计剩余时间
+var (
+	overallLabels  = []string{"mode", "schema"}
+	tableLabels    = []string{"mode", "schema", "table"}
+	overallMetrics = []metric{
+		newMetric("transferdb_progress_tables", "Number of tables to migrate.", prometheus.GaugeValue, overallLabels, func(p Progress, _ *TableProgress) float64 { return float64(p.TablesTotal) }),
+		newMetric("transferdb_progress_tables_done", "Number of tables migrated.", prometheus.GaugeValue, overallLabels, func(p Progress, _ *TableProgress) float64 { return float64(p.TablesDone) }),
+		newMetric("transferdb_progress_chunks", "Number of chunks to migrate.", prometheus.GaugeValue, overallLabels, func(p Progress, _ *TableProgress) float64 { return float64(p.ChunksTotal) }),
+		newMetric("transferdb_progress_chunks_done", "Number of chunks migrated.", prometheus.GaugeValue, overallLabels, func(p Progress, _ *TableProgress) float64 { return float64(p.ChunksDone) }),
+		newMetric("transferdb_progress_rows_per_second", "Average rows migrated per second.", prometheus.GaugeValue, overallLabels, func(p Progress, _ *TableProgress) float64 { return p.RowsPerSec }),
+		newMetric("transferdb_progress_bytes_per_second", "Average bytes migrated per second.", prometheus.GaugeValue, overallLabels, func(p Progress, _ *TableProgress) float64 { return p.BytesPerSec }),
+		newMetric("transferdb_progress_eta_seconds", "Estimated seconds to finish, 0 means unknown or finished.", prometheus.GaugeValue, overallLabels, func(p Progress, _ *TableProgress) float64 { return p.ETASeconds }),
+	}
+	tableMetrics = []metric{
+		newMetric("transferdb_progress_table_chunks", "Number of table chunks to migrate.", prometheus.GaugeValue, tableLabels, func(_ Progress, t *TableProgress) float64 { return float64(t.ChunksTotal) }),
+		newMetric("transferdb_progress_table_chunks_done", "Number of table chunks migrated.", prometheus.GaugeValue, tableLabels, func(_ Progress, t *TableProgress) float64 { return float64(t.ChunksDone) }),
+		newMetric("transferdb_progress_table_rows_per_second", "Average table rows migrated per second.", prometheus.GaugeValue, tableLabels, func(_ Progress, t *TableProgress) float64 { return t.RowsPerSec }),
+		newMetric("transferdb_progress_table_eta_seconds", "Estimated seconds to finish the table, 0 means unknown or finished.", prometheus.GaugeValue, tableLabels, func(_ Progress, t *TableProgress) float64 { return t.ETASeconds }),
+	}
+)
+
+// 进度指标注册至 metrics 共享 registry，随 /metrics 输出
+func init() {
+	metrics.MustRegister(collector{})
+}
+
+// 运行中任务进度 Prometheus collector，采集时读取进度快照
+type collector struct{}
+
+func (collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range overallMetrics {
+		ch <- m.desc
+	}
+	for _, m := range tableMetrics {
+		ch <- m.desc
+	}
+}
+
+// 相同 mode 以及 schema 的多个运行中任务只输出首个，避免重复标签导致采集失败
+func (collector) Collect(ch chan<- prometheus.Metric) {
+	seen := make(map[string]struct{})
+	for _, p := range Snapshots() {
+		key := common.StringsBuilder(p.Mode, "/", p.SchemaName)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		for _, m := range overallMetrics {
+			ch <- prometheus.MustNewConstMetric(m.desc, m.typ, m.value(p, nil), p.Mode, p.SchemaName)
+		}
+		for i := range p.Tables {
+			t := &p.Tables[i]
+			for _, m := range tableMetrics {
+				ch <- prometheus.MustNewConstMetric(m.desc, m.typ, m.value(p, t), p.Mode, p.SchemaName, t.TableName)
+			}
+		}
+	}
+}
@@ -0,0 +1,414 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package progress
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/logger"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 表同步状态
+const (
+	TableStatusWaiting  = "waiting"
+	TableStatusRunning  = "running"
+	TableStatusFinished = "finished"
+)
+
+// 表级别进度
+type TableProgress struct {
+	TableName   string    `json:"table_name"`
+	Status      string    `json:"status"`
+	ChunksTotal int       `json:"chunks_total"`
+	ChunksDone  int       `json:"chunks_done"`
+	Rows        int64     `json:"rows"`
+	Bytes       int64     `json:"bytes"`
+	RowsPerSec  float64   `json:"rows_per_sec"`
+	BytesPerSec float64   `json:"bytes_per_sec"`
+	ETASeconds  float64   `json:"eta_seconds"`
+	Elapsed     string    `json:"elapsed"`
+	StartTime   time.Time `json:"start_time"`
+	elapsed     time.Duration
+}
+
+// 任务整体进度
+type Progress struct {
+	Mode          string          `json:"mode"`
+	SchemaName    string          `json:"schema_name"`
+	StartTime     time.Time       `json:"start_time"`
+	Elapsed       string          `json:"elapsed"`
+	TablesTotal   int             `json:"tables_total"`
+	TablesDone    int             `json:"tables_done"`
+	ChunksTotal   int             `json:"chunks_total"`
+	ChunksDone    int             `json:"chunks_done"`
+	Rows          int64           `json:"rows"`
+	Bytes         int64           `json:"bytes"`
+	RowsPerSec    float64         `json:"rows_per_sec"`
+	BytesPerSec   float64         `json:"bytes_per_sec"`
+	ETASeconds    float64         `json:"eta_seconds"`
+	SlowestTables []TableProgress `json:"slowest_tables"`
+	Tables        []TableProgress `json:"tables"`
+}
+
+type tableState struct {
+	status      string
+	chunksTotal int
+	chunksDone  int
+	// 本次运行开始时已完成 chunk 数，用于计算本次运行 chunk 完成速率
+	initDone  int
+	rows      int64
+	bytes     int64
+	startTime time.Time
+	endTime   time.Time
+}
+
+// 全量数据迁移（full/csv）进度跟踪
+//   - chunk 总数来源于 wait_sync_meta full_split_times，未完成 chunk 数来源于 full_sync_meta 记录数
+//   - 数据行数以及字节数由数据写入完成后上报
+//
+// nil 跟踪器不做任何记录
+type Tracker struct {
+	ctx         context.Context
+	metaDB      *meta.Meta
+	dbTypeS     string
+	dbTypeT     string
+	schemaNameS string
+	mode        string
+	startTime   time.Time
+
+	mu     sync.Mutex
+	tables map[string]*tableState
+}
+
+func NewTracker(ctx context.Context, metaDB *meta.Meta, dbTypeS, dbTypeT, schemaNameS, mode string) *Tracker {
+	return &Tracker{
+		ctx:         ctx,
+		metaDB:      metaDB,
+		dbTypeS:     dbTypeS,
+		dbTypeT:     dbTypeT,
+		schemaNameS: common.StringUPPER(schemaNameS),
+		mode:        mode,
+		startTime:   time.Now(),
+		tables:      make(map[string]*tableState),
+	}
+}
+
+// 上报表数据写入行数以及字节数
+func (t *Tracker) AddRows(tableName string, rows, bytes int64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.table(tableName)
+	if s.startTime.IsZero() {
+		s.startTime = time.Now()
+	}
+	if s.status == TableStatusWaiting {
+		s.status = TableStatusRunning
+	}
+	s.rows += rows
+	s.bytes += bytes
+}
+
+// 依据元数据刷新表 chunk 进度
+func (t *Tracker) Refresh() error {
+	if t == nil {
+		return nil
+	}
+	waitSyncMetas, err := meta.NewWaitSyncMetaModel(t.metaDB).DetailWaitSyncMeta(t.ctx, &meta.WaitSyncMeta{
+		DBTypeS:     t.dbTypeS,
+		DBTypeT:     t.dbTypeT,
+		SchemaNameS: t.schemaNameS,
+		Mode:        t.mode,
+	})
+	if err != nil {
+		return err
+	}
+	remainChunks, err := meta.NewFullSyncMetaModel(t.metaDB).CountsFullSyncMetaGroupByTableNameS(t.ctx, &meta.FullSyncMeta{
+		DBTypeS:     t.dbTypeS,
+		DBTypeT:     t.dbTypeT,
+		SchemaNameS: t.schemaNameS,
+		Mode:        t.mode,
+	})
+	if err != nil {
+		return err
+	}
+	t.UpdateChunks(waitSyncMetas, remainChunks)
+	return nil
+}
+
+// 更新表 chunk 进度
+//   - full_split_times -1 表示未初始化 chunk，0 表示同步完成，其余表示 chunk 总数
+//   - 同步完成 full_split_times 置 0，chunk 总数保留最近观测值
+func (t *Tracker) UpdateChunks(waitSyncMetas []meta.WaitSyncMeta, remainChunks map[string]int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	for _, w := range waitSyncMetas {
+		tableName := common.StringUPPER(w.TableNameS)
+		_, exist := t.tables[tableName]
+		s := t.table(tableName)
+
+		switch {
+		case w.FullSplitTimes < 0:
+			s.status = TableStatusWaiting
+		case w.FullSplitTimes == 0:
+			if s.status != TableStatusFinished {
+				s.endTime = now
+			}
+			s.status = TableStatusFinished
+			s.chunksDone = s.chunksTotal
+		default:
+			if w.FullSplitTimes > s.chunksTotal {
+				s.chunksTotal = w.FullSplitTimes
+			}
+			s.chunksDone = s.chunksTotal - remainChunks[tableName]
+			if s.chunksDone < 0 {
+				s.chunksDone = 0
+			}
+			if !exist {
+				s.initDone = s.chunksDone
+			}
+			if s.chunksDone > s.initDone || s.rows > 0 {
+				s.status = TableStatusRunning
+				if s.startTime.IsZero() {
+					s.startTime = now
+				}
+			}
+		}
+		if !exist && s.status == TableStatusFinished {
+			s.initDone = s.chunksDone
+		}
+	}
+}
+
+func (t *Tracker) table(tableName string) *tableState {
+	tableName = common.StringUPPER(tableName)
+	s, ok := t.tables[tableName]
+	if !ok {
+		s = &tableState{status: TableStatusWaiting}
+		t.tables[tableName] = s
+	}
+	return s
+}
+
+// 进度快照
+//   - 表速率以表开始写入至完成（未完成至当前）计算
+//   - ETA 以本次运行 chunk 完成速率估算，未初始化 chunk 的表不计入
+//   - 最慢表以 ETA、耗时降序取前 common.ProgressSlowestTables 张
+func (t *Tracker) Snapshot() Progress {
+	if t == nil {
+		return Progress{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(t.startTime)
+	p := Progress{
+		Mode:        t.mode,
+		SchemaName:  t.schemaNameS,
+		StartTime:   t.startTime,
+		Elapsed:     elapsed.Round(time.Second).String(),
+		TablesTotal: len(t.tables),
+	}
+
+	var runDone, remain int
+	for tableName, s := range t.tables {
+		tp := TableProgress{
+			TableName:   tableName,
+			Status:      s.status,
+			ChunksTotal: s.chunksTotal,
+			ChunksDone:  s.chunksDone,
+			Rows:        s.rows,
+			Bytes:       s.bytes,
+			StartTime:   s.startTime,
+		}
+		if !s.startTime.IsZero() {
+			end := now
+			if s.status == TableStatusFinished && !s.endTime.IsZero() {
+				end = s.endTime
+			}
+			tp.elapsed = end.Sub(s.startTime)
+			tp.Elapsed = tp.elapsed.Round(time.Second).String()
+			if seconds := tp.elapsed.Seconds(); seconds > 0 {
+				tp.RowsPerSec = float64(s.rows) / seconds
+				tp.BytesPerSec = float64(s.bytes) / seconds
+			}
+			if done := s.chunksDone - s.initDone; s.status == TableStatusRunning && done > 0 {
+				tp.ETASeconds = tp.elapsed.Seconds() / float64(done) * float64(s.chunksTotal-s.chunksDone)
+			}
+		}
+
+		if s.status == TableStatusFinished {
+			p.TablesDone++
+		}
+		p.ChunksTotal += s.chunksTotal
+		p.ChunksDone += s.chunksDone
+		p.Rows += s.rows
+		p.Bytes += s.bytes
+		runDone += s.chunksDone - s.initDone
+		remain += s.chunksTotal - s.chunksDone
+		p.Tables = append(p.Tables, tp)
+	}
+
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		p.RowsPerSec = float64(p.Rows) / seconds
+		p.BytesPerSec = float64(p.Bytes) / seconds
+		if runDone > 0 {
+			p.ETASeconds = seconds / float64(runDone) * float64(remain)
+		}
+	}
+
+	sort.Slice(p.Tables, func(i, j int) bool {
+		return p.Tables[i].TableName < p.Tables[j].TableName
+	})
+
+	var started []TableProgress
+	for _, tp := range p.Tables {
+		if !tp.StartTime.IsZero() {
+			started = append(started, tp)
+		}
+	}
+	sort.SliceStable(started, func(i, j int) bool {
+		if started[i].ETASeconds != started[j].ETASeconds {
+			return started[i].ETASeconds > started[j].ETASeconds
+		}
+		return started[i].elapsed > started[j].elapsed
+	})
+	if len(started) > common.ProgressSlowestTables {
+		started = started[:common.ProgressSlowestTables]
+	}
+	p.SlowestTables = started
+	return p
+}
+
+// 进度汇总文本
+func (p Progress) String() string {
+	return fmt.Sprintf("[%s] schema [%s] tables %d/%d, chunks %d/%d (%.1f%%), rows %d (%.0f rows/s), bytes %s (%s/s), elapsed %s, eta %s, slowest tables [%s]",
+		p.Mode, p.SchemaName, p.TablesDone, p.TablesTotal, p.ChunksDone, p.ChunksTotal, percent(p.ChunksDone, p.ChunksTotal),
+		p.Rows, p.RowsPerSec, formatBytes(float64(p.Bytes)), formatBytes(p.BytesPerSec), p.Elapsed,
+		formatETA(p.ETASeconds, p.ChunksDone, p.ChunksTotal), strings.Join(p.slowestTables(), ","))
+}
+
+// 最慢表以及 chunk 完成数，形如 T1(3/10)
+func (p Progress) slowestTables() []string {
+	slowest := make([]string, 0, len(p.SlowestTables))
+	for _, tp := range p.SlowestTables {
+		slowest = append(slowest, fmt.Sprintf("%s(%d/%d)", tp.TableName, tp.ChunksDone, tp.ChunksTotal))
+	}
+	return slowest
+}
+
+// 定期刷新进度并输出控制台汇总，返回停止函数，停止时输出最终汇总
+func (t *Tracker) Run(interval time.Duration) func() {
+	if t == nil {
+		return func() {}
+	}
+	register(t)
+	if interval <= 0 {
+		interval = time.Duration(common.ProgressDefaultInterval) * time.Second
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.ctx.Done():
+				return
+			case <-ticker.C:
+				t.report()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+			t.report()
+			unregister(t)
+		})
+	}
+}
+
+func (t *Tracker) report() {
+	if err := t.Refresh(); err != nil {
+		logger.L(t.ctx).Warn("refresh progress failed",
+			zap.Error(err))
+		return
+	}
+	p := t.Snapshot()
+	logger.L(t.ctx).Info("progress summary",
+		zap.Int("tables total", p.TablesTotal),
+		zap.Int("tables done", p.TablesDone),
+		zap.Int("chunks total", p.ChunksTotal),
+		zap.Int("chunks done", p.ChunksDone),
+		zap.Float64("chunks percent", percent(p.ChunksDone, p.ChunksTotal)),
+		zap.Int64("rows", p.Rows),
+		zap.Int64("bytes", p.Bytes),
+		zap.Float64("rows per second", p.RowsPerSec),
+		zap.Float64("bytes per second", p.BytesPerSec),
+		zap.Float64("eta seconds", p.ETASeconds),
+		zap.String("eta", formatETA(p.ETASeconds, p.ChunksDone, p.ChunksTotal)),
+		zap.String("elapsed", p.Elapsed),
+		zap.Strings("slowest tables", p.slowestTables()))
+}
+
+func percent(done, total int) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(done) * 100 / float64(total)
+}
+
+func formatBytes(bytes float64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	i := 0
+	for bytes >= 1024 && i < len(units)-1 {
+		bytes /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.1f%s", bytes, units[i])
+}
+
+func formatETA(seconds float64, done, total int) string {
+	if total > 0 && done >= total {
+		return "0s"
+	}
+	if seconds <= 0 {
+		return "unknown"
+	}
+	return (time.Duration(seconds) * time.Second).Round(time.Second).String()
+}
@@ -18,31 +18,59 @@ package server
 import (
 	"context"
 	"fmt"
+	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/module/assess"
 	assessO2M "github.com/wentaojin/transferdb/module/assess/o2m"
 	"github.com/wentaojin/transferdb/module/check"
 	"github.com/wentaojin/transferdb/module/check/o2m"
+	checkO2P "github.com/wentaojin/transferdb/module/check/o2p"
 	"github.com/wentaojin/transferdb/module/compare"
 	compareO2M "github.com/wentaojin/transferdb/module/compare/o2m"
 	"github.com/wentaojin/transferdb/module/csv"
 	csvO2M "github.com/wentaojin/transferdb/module/csv/o2m"
 	"github.com/wentaojin/transferdb/module/migrate"
 	migrateO2M "github.com/wentaojin/transferdb/module/migrate/o2m"
+	"github.com/wentaojin/transferdb/module/plan"
 	"github.com/wentaojin/transferdb/module/prepare"
 	"github.com/wentaojin/transferdb/module/reverse"
 	reverseM2O "github.com/wentaojin/transferdb/module/reverse/m2o"
 	reverseO2M "github.com/wentaojin/transferdb/module/reverse/o2m"
+	"github.com/wentaojin/transferdb/module/rule"
+	"github.com/wentaojin/transferdb/module/task"
 
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/database/postgres"
+	"github.com/wentaojin/transferdb/logger"
+	"github.com/wentaojin/transferdb/metrics"
 	"strings"
+	"time"
 )
 
 // 程序运行
-func Run(ctx context.Context, cfg *config.Config) error {
-	switch strings.ToLower(strings.TrimSpace(cfg.Mode)) {
+func Run(ctx context.Context, cfg *config.Config) (err error) {
+	mode := strings.ToLower(strings.TrimSpace(cfg.Mode))
+	if cfg.DryRun && !common.IsContainString([]string{"full", "csv", "all", "compare"}, mode) {
+		return fmt.Errorf("flag [dry-run] only support mode [full/csv/all/compare], current mode [%s]", cfg.Mode)
+	}
+	// 配置校验，避免非法配置运行中失败或者死循环
+	if err = cfg.Validate(); err != nil {
+		return err
+	}
+	// 任务运行结果以及耗时指标，dry-run 除外
+	if _, runMode, errMode := task.GetRunMode(cfg); errMode == nil {
+		// 日志关联字段，任务各模块日志携带 mode 以及 schema_s
+		ctx = logger.WithFields(ctx, logger.Mode(runMode), logger.SchemaS(task.GetSchemaNameS(cfg, runMode)))
+		if !cfg.DryRun {
+			startTime := time.Now()
+			defer func() {
+				metrics.ObserveTask(runMode, task.GetSchemaNameS(cfg, runMode), time.Since(startTime), err)
+			}()
+		}
+	}
+	switch mode {
 	case "prepare":
 		// 表结构转换 - only prepare 阶段
 		err := prepare.TPrepare(ctx, cfg)
@@ -51,14 +79,16 @@ func Run(ctx context.Context, cfg *config.Config) error {
 		}
 	case "assess":
 		// 收集评估改造成本
-		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, cfg.AppConfig.SlowlogThreshold)
+		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
 		if err != nil {
 			return err
 		}
+		defer metaDB.Close()
 		oracleDB, err := oracle.NewOracleDBEngine(ctx, cfg.OracleConfig)
 		if err != nil {
 			return err
 		}
+		defer oracleDB.Close()
 		err = assess.TAssess(assessO2M.NewAssess(ctx, cfg, metaDB, oracleDB))
 		if err != nil {
 			return err
@@ -66,39 +96,63 @@ func Run(ctx context.Context, cfg *config.Config) error {
 	case "reverseo2m":
 		// 表结构转换 - reverse 阶段
 		// O2M
-		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, cfg.AppConfig.SlowlogThreshold)
+		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
 		if err != nil {
 			return err
 		}
+		defer metaDB.Close()
 		oracleDB, err := oracle.NewOracleDBEngine(ctx, cfg.OracleConfig)
 		if err != nil {
 			return err
 		}
+		defer oracleDB.Close()
 		mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
 		if err != nil {
 			return err
 		}
+		defer mysqlDB.Close()
 
 		err = reverse.IReverse(reverseO2M.NewO2MReverse(
 			ctx, cfg, mysqlDB, oracleDB, metaDB))
 		if err != nil {
 			return err
 		}
+	case "reverseo2p":
+		// 表结构转换 - reverse 阶段
+		// O2P，仅生成 PostgreSQL DDL 文件
+		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+		if err != nil {
+			return err
+		}
+		defer metaDB.Close()
+		oracleDB, err := oracle.NewOracleDBEngine(ctx, cfg.OracleConfig)
+		if err != nil {
+			return err
+		}
+		defer oracleDB.Close()
+
+		err = reverse.IReverse(reverseO2M.NewO2PReverse(ctx, cfg, oracleDB, metaDB))
+		if err != nil {
+			return err
+		}
 	case "reversem2o":
 		// 表结构转换 - reverse 阶段
 		// M2O
-		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, cfg.AppConfig.SlowlogThreshold)
+		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
 		if err != nil {
 			return err
 		}
+		defer metaDB.Close()
 		oracleDB, err := oracle.NewOracleDBEngine(ctx, cfg.OracleConfig)
 		if err != nil {
 			return err
 		}
+		defer oracleDB.Close()
 		mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
 		if err != nil {
 			return err
 		}
+		defer mysqlDB.Close()
 
 		err = reverse.IReverse(reverseM2O.NewM2OReverse(ctx, cfg,
 			mysqlDB, oracleDB, metaDB))
@@ -107,99 +161,202 @@ func Run(ctx context.Context, cfg *config.Config) error {
 		}
 	case "check":
 		// 表结构校验 - 上下游
-		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, cfg.AppConfig.SlowlogThreshold)
+		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
 		if err != nil {
 			return err
 		}
+		defer metaDB.Close()
 		oracleDB, err := oracle.NewOracleDBEngine(ctx, cfg.OracleConfig)
 		if err != nil {
 			return err
 		}
+		defer oracleDB.Close()
 		mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
 		if err != nil {
 			return err
 		}
+		defer mysqlDB.Close()
 
 		err = check.ICheck(o2m.NewO2MCheck(ctx, cfg,
 			oracleDB, mysqlDB, metaDB))
 		if err != nil {
 			return err
 		}
+	case "checko2p":
+		// 表结构校验 - O2P 上下游
+		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+		if err != nil {
+			return err
+		}
+		defer metaDB.Close()
+		oracleDB, err := oracle.NewOracleDBEngine(ctx, cfg.OracleConfig)
+		if err != nil {
+			return err
+		}
+		defer oracleDB.Close()
+		postgresDB, err := postgres.NewPostgresDBEngine(ctx, cfg.PostgresConfig)
+		if err != nil {
+			return err
+		}
+		defer postgresDB.Close()
+
+		err = check.ICheck(checkO2P.NewO2PCheck(ctx, cfg, oracleDB, postgresDB, metaDB))
+		if err != nil {
+			return err
+		}
 	case "compare":
 		// 数据校验 - 以上游为准
-		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, cfg.AppConfig.SlowlogThreshold)
+		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, dryRunMetaConfig(cfg), cfg.AppConfig.SlowlogThreshold)
 		if err != nil {
 			return err
 		}
+		defer metaDB.Close()
 		oracleDB, err := oracle.NewOracleDBEngine(ctx, cfg.OracleConfig)
 		if err != nil {
 			return err
 		}
+		defer oracleDB.Close()
 		mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
 		if err != nil {
 			return err
 		}
+		defer mysqlDB.Close()
+		if cfg.DryRun {
+			return plan.IPlan(compareO2M.NewO2MCompare(ctx, cfg, oracleDB, mysqlDB, metaDB))
+		}
 		err = compare.ICompare(compareO2M.NewO2MCompare(ctx, cfg, oracleDB, mysqlDB, metaDB))
 		if err != nil {
 			return err
 		}
 	case "csv":
 		// csv 全量数据导出
-		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, cfg.AppConfig.SlowlogThreshold)
+		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, dryRunMetaConfig(cfg), cfg.AppConfig.SlowlogThreshold)
 		if err != nil {
 			return err
 		}
+		defer metaDB.Close()
 		oracleDB, err := oracle.NewOracleDBEngine(ctx, cfg.OracleConfig)
 		if err != nil {
 			return err
 		}
-		mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
-		if err != nil {
-			return err
+		defer oracleDB.Close()
+		// csv 导出不依赖目标端，meta db-type 为 sqlite 时无需连接 MySQL
+		if cfg.DryRun {
+			return plan.IPlan(csvO2M.NewO2MCSVer(ctx, cfg, oracleDB, metaDB))
 		}
 		err = csv.ICSVer(csvO2M.NewO2MCSVer(ctx, cfg,
-			oracleDB, mysqlDB, metaDB))
+			oracleDB, metaDB))
 		if err != nil {
 			return err
 		}
 	case "full":
-		// 全量数据 ETL 非一致性（基于某个时间点，而是直接基于现有 SCN）抽取，离线环境提供与原库一致性
-		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, cfg.AppConfig.SlowlogThreshold)
+		// 全量数据 ETL 默认非一致性（基于某个时间点，而是直接基于现有 SCN）抽取，离线环境提供与原库一致性
+		// 配置 consistent-snapshot 基于全局 SCN 一致性快照抽取
+		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, dryRunMetaConfig(cfg), cfg.AppConfig.SlowlogThreshold)
 		if err != nil {
 			return err
 		}
+		defer metaDB.Close()
 		oracleDB, err := oracle.NewOracleDBEngine(ctx, cfg.OracleConfig)
 		if err != nil {
 			return err
 		}
+		defer oracleDB.Close()
 		mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
 		if err != nil {
 			return err
 		}
+		defer mysqlDB.Close()
+		if cfg.DryRun {
+			return plan.IPlan(migrateO2M.NewO2MFullPlanner(ctx, cfg, oracleDB, mysqlDB, metaDB))
+		}
 		err = migrate.IMigrateFull(migrateO2M.NewO2MFuller(ctx, cfg, oracleDB, mysqlDB, metaDB))
 		if err != nil {
 			return err
 		}
 	case "all":
 		// 全量 + 增量数据同步阶段 - logminer
-		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, cfg.AppConfig.SlowlogThreshold)
+		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, dryRunMetaConfig(cfg), cfg.AppConfig.SlowlogThreshold)
 		if err != nil {
 			return err
 		}
+		defer metaDB.Close()
 		oracleDB, err := oracle.NewOracleDBEngine(ctx, cfg.OracleConfig)
 		if err != nil {
 			return err
 		}
+		defer oracleDB.Close()
 		mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
 		if err != nil {
 			return err
 		}
+		defer mysqlDB.Close()
+		if cfg.DryRun {
+			return plan.IPlan(migrateO2M.NewO2MIncrPlanner(ctx, cfg, oracleDB, mysqlDB, metaDB))
+		}
 		err = migrate.IMigrateIncr(migrateO2M.NewO2MIncr(ctx, cfg, oracleDB, mysqlDB, metaDB))
 		if err != nil {
 			return err
 		}
+	case "server":
+		// 常驻服务模式，HTTP API 提交、查看以及取消任务
+		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+		if err != nil {
+			return err
+		}
+		defer metaDB.Close()
+		err = NewService(ctx, cfg, metaDB, Run).Serve()
+		if err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("flag [mode] can not null or value configure error")
 	}
 	return nil
 }
+
+// dry-run 只读打开元数据库，不创建元数据库 schema 以及 sqlite 文件
+func dryRunMetaConfig(cfg *config.Config) config.MetaConfig {
+	metaCfg := cfg.MetaConfig
+	metaCfg.ReadOnly = cfg.DryRun
+	return metaCfg
+}
+
+// 任务管理子命令 transferdb task <action>
+// 声明式规则文件子命令 transferdb rules <action>
+// validate 仅校验规则文件以及源端表，不打开元数据库；sync/export 读写元数据库
+func RunRules(ctx context.Context, cfg *config.Config) error {
+	if cfg.RuleAction == common.RuleActionValidate {
+		return rule.IRule(ctx, cfg, nil)
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return err
+	}
+	defer metaDB.Close()
+	return rule.IRule(ctx, cfg, metaDB)
+}
+
+func RunTask(ctx context.Context, cfg *config.Config) error {
+	metaCfg := cfg.MetaConfig
+	// status/errors list 只读打开元数据库
+	if cfg.TaskAction == common.TaskActionStatus || cfg.TaskAction == common.TaskActionErrorsList {
+		metaCfg.ReadOnly = true
+	}
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MySQLConfig, metaCfg, cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return err
+	}
+	defer metaDB.Close()
+	if cfg.TaskAction != common.TaskActionResume {
+		return task.ITask(ctx, cfg, metaDB)
+	}
+	// 断点校验通过后开启断点续传运行
+	if err = task.Resume(ctx, cfg, metaDB); err != nil {
+		return err
+	}
+	return Run(ctx, cfg)
+}
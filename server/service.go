@@ -0,0 +1,570 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/logger"
+	"github.com/wentaojin/transferdb/metrics"
+	"github.com/wentaojin/transferdb/module/task"
+	"github.com/wentaojin/transferdb/progress"
+	"github.com/wentaojin/transferdb/signal"
+	"go.uber.org/zap"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// server 模式 HTTP API
+//   - POST   /api/v1/tasks              提交任务
+//   - GET    /api/v1/tasks              任务列表
+//   - GET    /api/v1/tasks/{id}         任务详情，包括元数据库表级别状态、错误数以及运行进度
+//   - POST   /api/v1/tasks/{id}/cancel  优雅取消任务，DELETE /api/v1/tasks/{id} 等同
+//
+// 任务 API 需鉴权，配置 token 时校验请求头 Authorization: Bearer {token}，配置 ssl-ca 时要求 mTLS 客户端证书
+const serviceTaskPath = "/api/v1/tasks"
+
+// 任务运行函数，默认 Run
+type Runner func(ctx context.Context, cfg *config.Config) error
+
+// server 模式支持提交的任务模式
+var serviceModes = []string{"assess", "reverseo2m", "reverseo2p", "reversem2o", "check", "checko2p", "full", "csv", "compare", "all"}
+
+// 任务定义，config 为基于服务启动配置文件的 JSON 覆盖项，字段同配置文件 JSON 输出，[meta] 以服务启动配置为准
+type TaskRequest struct {
+	Mode   string          `json:"mode"`
+	DryRun bool            `json:"dry-run"`
+	Config json.RawMessage `json:"config"`
+}
+
+type ServiceTask struct {
+	ID          string     `json:"id"`
+	Mode        string     `json:"mode"`
+	DryRun      bool       `json:"dry-run"`
+	SchemaNameS string     `json:"schema_name_s"`
+	State       string     `json:"state"`
+	Error       string     `json:"error,omitempty"`
+	CreateTime  time.Time  `json:"create_time"`
+	StartTime   *time.Time `json:"start_time,omitempty"`
+	EndTime     *time.Time `json:"end_time,omitempty"`
+
+	runMode string
+	cfg     *config.Config
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stop    context.CancelFunc
+}
+
+type ServiceTaskDetail struct {
+	ServiceTask
+	Errors   int                 `json:"errors"`
+	Tables   []*task.TableStatus `json:"tables,omitempty"`
+	Progress []progress.Progress `json:"progress,omitempty"`
+}
+
+type Service struct {
+	ctx    context.Context
+	cfg    *config.Config
+	metaDB *meta.Meta
+	runner Runner
+	sem    chan struct{}
+
+	wg    sync.WaitGroup
+	mu    sync.Mutex
+	seq   int
+	tasks map[string]*ServiceTask
+	ids   []string
+}
+
+type serviceError struct {
+	code int
+	err  error
+}
+
+func (e *serviceError) Error() string {
+	return e.err.Error()
+}
+
+func NewService(ctx context.Context, cfg *config.Config, metaDB *meta.Meta, runner Runner) *Service {
+	concurrency := cfg.ServerConfig.Concurrency
+	if concurrency <= 0 {
+		concurrency = common.ServerDefaultConcurrency
+	}
+	return &Service{
+		ctx:    ctx,
+		cfg:    cfg,
+		metaDB: metaDB,
+		runner: runner,
+		sem:    make(chan struct{}, concurrency),
+		tasks:  make(map[string]*ServiceTask),
+	}
+}
+
+// 常驻运行 HTTP API 服务，收到优雅停止信号或者 ctx 结束后优雅取消全部任务、关闭服务并等待运行中任务退出
+func (s *Service) Serve() error {
+	addr := s.cfg.ServerConfig.Addr
+	if addr == "" {
+		addr = common.ServerDefaultAddr
+	}
+	if s.cfg.ServerConfig.Token == "" && s.cfg.ServerConfig.SSLCA == "" {
+		return fmt.Errorf("server mode [server] token or ssl-ca (mTLS) must be configured, http api requires authentication")
+	}
+	tlsCfg, err := newServiceTLSConfig(s.cfg.ServerConfig)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Addr: addr, Handler: s.Handler(), TLSConfig: tlsCfg}
+	go func() {
+		select {
+		case <-signal.Stopping(s.ctx):
+		case <-s.ctx.Done():
+		}
+		s.CancelAll()
+		_ = srv.Shutdown(context.Background())
+	}()
+
+	zap.L().Info("server mode http api start", zap.String("addr", addr), zap.Int("concurrency", cap(s.sem)), zap.Bool("tls", tlsCfg != nil))
+	if tlsCfg != nil {
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("server mode listen and serve [%s] failed: %v", addr, err)
+	}
+	s.wg.Wait()
+	zap.L().Info("server mode http api stopped", zap.String("addr", addr))
+	return nil
+}
+
+func (s *Service) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(serviceTaskPath, s.authorize(http.HandlerFunc(s.handleTasks)))
+	mux.Handle(serviceTaskPath+"/", s.authorize(http.HandlerFunc(s.handleTask)))
+	mux.Handle("/metrics", metrics.Handler())
+	return mux
+}
+
+// 任务 API 鉴权，未配置 token 时要求已校验的 mTLS 客户端证书，均未配置拒绝全部请求
+func (s *Service) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorized := false
+		if token := s.cfg.ServerConfig.Token; token != "" {
+			auth := r.Header.Get("Authorization")
+			authorized = strings.HasPrefix(auth, "Bearer ") &&
+				subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) == 1
+		} else if s.cfg.ServerConfig.SSLCA != "" {
+			authorized = r.TLS != nil && len(r.TLS.VerifiedChains) > 0
+		}
+		if !authorized {
+			writeServiceError(w, &serviceError{code: http.StatusUnauthorized, err: fmt.Errorf("task api unauthorized")})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HTTPS 服务配置，未配置 ssl-cert 返回 nil；配置 ssl-ca 时校验客户端证书（mTLS）
+func newServiceTLSConfig(serverCfg config.ServerConfig) (*tls.Config, error) {
+	if serverCfg.SSLCert == "" && serverCfg.SSLKey == "" {
+		if serverCfg.SSLCA != "" {
+			return nil, fmt.Errorf("server ssl-ca (mTLS) requires ssl-cert and ssl-key")
+		}
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(serverCfg.SSLCert, serverCfg.SSLKey)
+	if err != nil {
+		return nil, fmt.Errorf("load server ssl-cert [%s] ssl-key [%s] failed: %v", serverCfg.SSLCert, serverCfg.SSLKey, err)
+	}
+	tlsCfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if serverCfg.SSLCA != "" {
+		caPEM, err := os.ReadFile(serverCfg.SSLCA)
+		if err != nil {
+			return nil, fmt.Errorf("read server ssl-ca [%s] failed: %v", serverCfg.SSLCA, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("server ssl-ca [%s] isn't valid PEM certificate", serverCfg.SSLCA)
+		}
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsCfg, nil
+}
+
+// 提交任务，同一源端 schema 同一模式（full 与 all 全量阶段共用断点）仅允许单个未结束任务
+func (s *Service) Submit(req TaskRequest) (ServiceTask, error) {
+	mode := strings.ToLower(strings.TrimSpace(req.Mode))
+	if !common.IsContainString(serviceModes, mode) {
+		return ServiceTask{}, &serviceError{code: http.StatusBadRequest,
+			err: fmt.Errorf("task mode [%s] isn't support, please configure [assess reverseO2M reverseO2P reverseM2O check checkO2P full csv compare all]", req.Mode)}
+	}
+	taskCfg, err := s.newTaskConfig(req)
+	if err != nil {
+		return ServiceTask{}, &serviceError{code: http.StatusBadRequest, err: err}
+	}
+	_, runMode, err := task.GetRunMode(taskCfg)
+	if err != nil {
+		return ServiceTask{}, &serviceError{code: http.StatusBadRequest, err: err}
+	}
+	schemaNameS := task.GetSchemaNameS(taskCfg, runMode)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.tasks {
+		if t.finished() || t.SchemaNameS != schemaNameS || serviceConflictMode(t.runMode) != serviceConflictMode(runMode) {
+			continue
+		}
+		return ServiceTask{}, &serviceError{code: http.StatusConflict,
+			err: fmt.Errorf("task [%s] schema [%s] mode [%s] is %s, please wait or cancel it", t.ID, t.SchemaNameS, t.Mode, strings.ToLower(t.State))}
+	}
+
+	s.seq++
+	ctx, cancel := context.WithCancel(logger.WithFields(s.ctx, logger.TaskID(strconv.Itoa(s.seq))))
+	ctx, stop := signal.WithGracefulStop(ctx)
+	t := &ServiceTask{
+		ID:          strconv.Itoa(s.seq),
+		Mode:        mode,
+		DryRun:      req.DryRun,
+		SchemaNameS: schemaNameS,
+		State:       common.ServerTaskStateQueued,
+		CreateTime:  time.Now(),
+		runMode:     runMode,
+		cfg:         taskCfg,
+		ctx:         ctx,
+		cancel:      cancel,
+		stop:        stop,
+	}
+	s.tasks[t.ID] = t
+	s.ids = append(s.ids, t.ID)
+	logger.L(t.ctx).Info("server mode task submit", logger.Mode(t.runMode), logger.SchemaS(t.SchemaNameS))
+
+	s.wg.Add(1)
+	go s.run(t)
+	return *t, nil
+}
+
+func (s *Service) List() []ServiceTask {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tasks := make([]ServiceTask, 0, len(s.ids))
+	for _, id := range s.ids {
+		tasks = append(tasks, *s.tasks[id])
+	}
+	return tasks
+}
+
+// 任务详情，表级别状态以及错误数来源于元数据库，运行进度来源于 full/csv 进度跟踪
+func (s *Service) Detail(id string) (*ServiceTaskDetail, error) {
+	s.mu.Lock()
+	t, ok := s.tasks[id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, &serviceError{code: http.StatusNotFound, err: fmt.Errorf("task [%s] isn't exist", id)}
+	}
+	detail := &ServiceTaskDetail{ServiceTask: *t}
+	s.mu.Unlock()
+
+	errLogs, err := task.ListErrors(s.ctx, s.metaDB, detail.SchemaNameS, detail.runMode, nil)
+	if err != nil {
+		return nil, err
+	}
+	detail.Errors = len(errLogs)
+	if task.IsCheckpointMode(detail.Mode) {
+		detail.Tables, err = task.Status(s.ctx, s.metaDB, detail.SchemaNameS, detail.runMode, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range progress.Snapshots() {
+		if p.Mode == detail.runMode && strings.EqualFold(p.SchemaName, detail.SchemaNameS) {
+			detail.Progress = append(detail.Progress, p)
+		}
+	}
+	return detail, nil
+}
+
+// 取消任务，排队任务直接结束，运行任务优雅停止：不再调度新的 chunk，进行中的 chunk 完成并持久化断点后结束
+func (s *Service) Cancel(id string) (ServiceTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tasks[id]
+	if !ok {
+		return ServiceTask{}, &serviceError{code: http.StatusNotFound, err: fmt.Errorf("task [%s] isn't exist", id)}
+	}
+	if t.finished() {
+		return ServiceTask{}, &serviceError{code: http.StatusConflict, err: fmt.Errorf("task [%s] is already %s", id, strings.ToLower(t.State))}
+	}
+	t.stop()
+	logger.L(t.ctx).Warn("server mode task cancel", logger.Mode(t.runMode), logger.SchemaS(t.SchemaNameS), zap.String("state", t.State))
+	return *t, nil
+}
+
+func (s *Service) CancelAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.tasks {
+		if !t.finished() {
+			t.stop()
+		}
+	}
+}
+
+func (s *Service) run(t *ServiceTask) {
+	defer s.wg.Done()
+	select {
+	case s.sem <- struct{}{}:
+	case <-signal.Stopping(t.ctx):
+		s.finish(t, signal.ErrGracefulStop)
+		return
+	case <-t.ctx.Done():
+		s.finish(t, t.ctx.Err())
+		return
+	}
+	defer func() {
+		<-s.sem
+	}()
+	if signal.IsStopping(t.ctx) {
+		s.finish(t, signal.ErrGracefulStop)
+		return
+	}
+	if err := t.ctx.Err(); err != nil {
+		s.finish(t, err)
+		return
+	}
+
+	s.mu.Lock()
+	startTime := time.Now()
+	t.State = common.ServerTaskStateRunning
+	t.StartTime = &startTime
+	s.mu.Unlock()
+	logger.L(t.ctx).Info("server mode task start", logger.Mode(t.runMode), logger.SchemaS(t.SchemaNameS))
+
+	s.finish(t, s.runTask(t))
+}
+
+func (s *Service) runTask(t *ServiceTask) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panic: %v", r)
+		}
+	}()
+	return s.runner(t.ctx, t.cfg)
+}
+
+func (s *Service) finish(t *ServiceTask, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	endTime := time.Now()
+	t.EndTime = &endTime
+	switch {
+	case signal.IsStopping(t.ctx) || t.ctx.Err() != nil:
+		t.State = common.ServerTaskStateCanceled
+		if err != nil && err != t.ctx.Err() && !errors.Is(err, signal.ErrGracefulStop) {
+			t.Error = err.Error()
+		}
+	case err != nil:
+		t.State = common.ServerTaskStateFailed
+		t.Error = err.Error()
+	default:
+		t.State = common.ServerTaskStateSuccess
+	}
+	t.stop()
+	t.cancel()
+	logger.L(t.ctx).Info("server mode task finished", logger.Mode(t.runMode), logger.SchemaS(t.SchemaNameS),
+		zap.String("state", t.State), zap.String("error", t.Error))
+
+	// 清理最早结束任务记录
+	var finished int
+	for _, id := range s.ids {
+		if s.tasks[id].finished() {
+			finished++
+		}
+	}
+	for i := 0; finished > common.ServerTaskHistory && i < len(s.ids); {
+		if !s.tasks[s.ids[i]].finished() {
+			i++
+			continue
+		}
+		delete(s.tasks, s.ids[i])
+		s.ids = append(s.ids[:i], s.ids[i+1:]...)
+		finished--
+	}
+}
+
+// 基于服务启动配置生成任务配置，JSON 覆盖项不允许未知字段
+func (s *Service) newTaskConfig(req TaskRequest) (*config.Config, error) {
+	taskCfg := &config.Config{}
+	cfgJSON, err := json.Marshal(s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(cfgJSON, taskCfg); err != nil {
+		return nil, err
+	}
+	if len(req.Config) > 0 {
+		dec := json.NewDecoder(strings.NewReader(string(req.Config)))
+		dec.DisallowUnknownFields()
+		if err = dec.Decode(taskCfg); err != nil {
+			return nil, fmt.Errorf("task config decode failed: %v", err)
+		}
+		// 仅请求覆盖项，用于判断请求是否自带凭据
+		reqCfg := &config.Config{}
+		if err = json.Unmarshal(req.Config, reqCfg); err != nil {
+			return nil, fmt.Errorf("task config decode failed: %v", err)
+		}
+		if err = checkTaskConfigOverride(s.cfg, taskCfg, reqCfg); err != nil {
+			return nil, err
+		}
+		// 密码环境变量以及密码文件仅允许服务启动配置指定，避免 API 读取服务端任意环境变量以及文件
+		if taskCfg.OracleConfig.PasswordEnv != s.cfg.OracleConfig.PasswordEnv || taskCfg.OracleConfig.PasswordFile != s.cfg.OracleConfig.PasswordFile ||
+			taskCfg.MySQLConfig.PasswordEnv != s.cfg.MySQLConfig.PasswordEnv || taskCfg.MySQLConfig.PasswordFile != s.cfg.MySQLConfig.PasswordFile {
+			return nil, fmt.Errorf("task config [password-env/password-file] isn't allowed to override")
+		}
+		if taskCfg.AppConfig.EncryptionKeyFile != s.cfg.AppConfig.EncryptionKeyFile {
+			return nil, fmt.Errorf("task config [encryption-key-file] isn't allowed to override")
+		}
+		if err = taskCfg.ResolveSecrets(); err != nil {
+			return nil, err
+		}
+	}
+	taskCfg.MetaConfig = s.cfg.MetaConfig
+	taskCfg.ServerConfig = s.cfg.ServerConfig
+	taskCfg.Mode = req.Mode
+	taskCfg.DryRun = req.DryRun
+	taskCfg.TaskAction = ""
+	return taskCfg, nil
+}
+
+// 服务启动配置凭据为已解析明文，任务覆盖连接地址或者输出位置时需请求自带凭据，避免借用服务端凭据访问任意地址
+//   - [oracle]/[mysql]/[postgres] host、port 覆盖需同时提供对应 username 以及 password
+//   - [csv] s3、output-dir 覆盖需同时提供 s3 access-key 以及 secret-key，output-dir 仅允许 s3://
+func checkTaskConfigOverride(base, taskCfg, reqCfg *config.Config) error {
+	for _, c := range []struct {
+		section            string
+		host, baseHost     string
+		port, basePort     int
+		username, password string
+	}{
+		{"oracle", taskCfg.OracleConfig.Host, base.OracleConfig.Host, taskCfg.OracleConfig.Port, base.OracleConfig.Port, reqCfg.OracleConfig.Username, reqCfg.OracleConfig.Password},
+		{"mysql", taskCfg.MySQLConfig.Host, base.MySQLConfig.Host, taskCfg.MySQLConfig.Port, base.MySQLConfig.Port, reqCfg.MySQLConfig.Username, reqCfg.MySQLConfig.Password},
+		{"postgres", taskCfg.PostgresConfig.Host, base.PostgresConfig.Host, taskCfg.PostgresConfig.Port, base.PostgresConfig.Port, reqCfg.PostgresConfig.Username, reqCfg.PostgresConfig.Password},
+	} {
+		if (c.host != c.baseHost || c.port != c.basePort) && (c.username == "" || c.password == "") {
+			return fmt.Errorf("task config [%s] host/port isn't allowed to override without [%s] username and password", c.section, c.section)
+		}
+	}
+	if taskCfg.CSVConfig.S3 == base.CSVConfig.S3 && taskCfg.CSVConfig.OutputDir == base.CSVConfig.OutputDir {
+		return nil
+	}
+	if reqCfg.CSVConfig.S3.AccessKey == "" || reqCfg.CSVConfig.S3.SecretKey == "" {
+		return fmt.Errorf("task config [csv] s3/output-dir isn't allowed to override without [csv.s3] access-key and secret-key")
+	}
+	if taskCfg.CSVConfig.OutputDir != base.CSVConfig.OutputDir && !strings.HasPrefix(strings.ToLower(taskCfg.CSVConfig.OutputDir), "s3://") {
+		return fmt.Errorf("task config [csv] output-dir override only support s3://{bucket}/{prefix}")
+	}
+	return nil
+}
+
+func (s *Service) handleTasks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeServiceJSON(w, http.StatusOK, s.List())
+	case http.MethodPost:
+		var req TaskRequest
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeServiceError(w, &serviceError{code: http.StatusBadRequest, err: fmt.Errorf("task request decode failed: %v", err)})
+			return
+		}
+		t, err := s.Submit(req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		writeServiceJSON(w, http.StatusCreated, t)
+	default:
+		writeServiceError(w, &serviceError{code: http.StatusMethodNotAllowed, err: fmt.Errorf("method [%s] isn't allowed", r.Method)})
+	}
+}
+
+func (s *Service) handleTask(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, serviceTaskPath+"/"), "/")
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		detail, err := s.Detail(id)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		writeServiceJSON(w, http.StatusOK, detail)
+	case (action == "" && r.Method == http.MethodDelete) || (action == "cancel" && r.Method == http.MethodPost):
+		t, err := s.Cancel(id)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		writeServiceJSON(w, http.StatusAccepted, t)
+	case action == "" || action == "cancel":
+		writeServiceError(w, &serviceError{code: http.StatusMethodNotAllowed, err: fmt.Errorf("method [%s] isn't allowed", r.Method)})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (t *ServiceTask) finished() bool {
+	return t.State != common.ServerTaskStateQueued && t.State != common.ServerTaskStateRunning
+}
+
+// full 与 all 全量阶段共用 FullO2M 断点记录
+func serviceConflictMode(runMode string) string {
+	if runMode == common.AllO2MMode {
+		return common.FullO2MMode
+	}
+	return runMode
+}
+
+func writeServiceJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	if e, ok := err.(*serviceError); ok {
+		code = e.code
+	}
+	writeServiceJSON(w, code, map[string]string{"error": err.Error()})
+}
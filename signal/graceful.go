@@ -0,0 +1,57 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package signal
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrGracefulStop 收到退出信号或者任务取消后，已停止调度新的 chunk 并等待进行中的 chunk 完成，断点已持久化
+var ErrGracefulStop = errors.New("transferdb graceful stopped, in-flight chunks drained and checkpoint persisted, please rerun with enable-checkpoint to resume")
+
+type gracefulStopKey struct{}
+
+// WithGracefulStop 返回携带优雅停止信号的 context 以及停止函数
+// 停止信号与 context 取消相互独立：调用停止函数后 context 本身仍然有效，进行中的 SQL 执行以及元数据库断点写入不受影响，
+// 各模块仅需通过 IsStopping / Stopping 判断是否继续调度新的表或 chunk
+// 父 context 已携带停止信号时，父级停止同样传递至子级
+func WithGracefulStop(ctx context.Context) (context.Context, context.CancelFunc) {
+	parent, ok := ctx.Value(gracefulStopKey{}).(context.Context)
+	if !ok {
+		parent = context.Background()
+	}
+	stopCtx, stop := context.WithCancel(parent)
+	return context.WithValue(ctx, gracefulStopKey{}, stopCtx), stop
+}
+
+// Stopping 返回优雅停止信号 channel，未携带停止信号的 context 返回 nil channel（永不关闭）
+func Stopping(ctx context.Context) <-chan struct{} {
+	if stopCtx, ok := ctx.Value(gracefulStopKey{}).(context.Context); ok {
+		return stopCtx.Done()
+	}
+	return nil
+}
+
+// IsStopping 判断是否已收到优雅停止信号
+func IsStopping(ctx context.Context) bool {
+	select {
+	case <-Stopping(ctx):
+		return true
+	default:
+		return false
+	}
+}
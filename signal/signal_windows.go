@@ -35,9 +35,14 @@ func SetupSignalHandler(shutdownFunc func()) {
 		syscall.SIGTERM,
 		syscall.SIGQUIT)
 
+	// 首次信号执行 shutdownFunc 优雅退出，再次收到信号强制退出
 	go func() {
 		sig := <-closeSignalChan
-		zap.L().Info("got signal to exit", zap.Stringer("signal", sig))
+		zap.L().Info("got signal to exit, graceful stop, send the signal again to force quit", zap.Stringer("signal", sig))
 		shutdownFunc()
+
+		sig = <-closeSignalChan
+		zap.L().Warn("got signal again, force quit", zap.Stringer("signal", sig))
+		os.Exit(1)
 	}()
 }
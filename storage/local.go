@@ -0,0 +1,134 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/wentaojin/transferdb/common"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+type LocalStorage struct {
+	base string
+}
+
+func NewLocalStorage(base string) (*LocalStorage, error) {
+	if err := common.PathExist(base); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{base: base}, nil
+}
+
+// 兼容历史断点记录的绝对路径文件名
+func (l *LocalStorage) path(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(l.base, filepath.FromSlash(name))
+}
+
+func (l *LocalStorage) Create(ctx context.Context, name string) (FileWriter, error) {
+	fileName := l.path(name)
+	if err := common.PathExist(filepath.Dir(fileName)); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return nil, err
+	}
+	return &localWriter{File: file}, nil
+}
+
+// 临时文件写入后 rename
+func (l *LocalStorage) WriteFile(ctx context.Context, name string, data []byte) error {
+	fileName := l.path(name)
+	if err := common.PathExist(filepath.Dir(fileName)); err != nil {
+		return err
+	}
+	tmpFile := fileName + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0666); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, fileName)
+}
+
+func (l *LocalStorage) ReadFile(ctx context.Context, name string) ([]byte, error) {
+	return os.ReadFile(l.path(name))
+}
+
+func (l *LocalStorage) FileExists(ctx context.Context, name string) (bool, error) {
+	_, err := os.Stat(l.path(name))
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+func (l *LocalStorage) DeleteFile(ctx context.Context, name string) error {
+	if err := os.Remove(l.path(name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (l *LocalStorage) WalkDir(ctx context.Context, dir string, fn func(name string, size int64) error) error {
+	root := l.path(dir)
+	err := filepath.WalkDir(root, func(fileName string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		// 返回名称形式同入参
+		name := fileName
+		if !filepath.IsAbs(dir) {
+			if name, err = filepath.Rel(l.base, fileName); err != nil {
+				return err
+			}
+		}
+		return fn(filepath.ToSlash(name), info.Size())
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (l *LocalStorage) URI() string {
+	return common.StringsBuilder("file://", l.base)
+}
+
+type localWriter struct {
+	*os.File
+}
+
+func (w *localWriter) Abort() error {
+	if err := w.File.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+		return err
+	}
+	return os.Remove(w.File.Name())
+}
@@ -0,0 +1,280 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+/*
+S3 兼容对象存储（AWS S3、MinIO 等），基于 minio-go 客户端
+  - 文件小于 part-size 单次 PutObject，否则 multipart upload
+  - 网络错误、5xx 以及 429 由客户端按指数退避重试
+  - Create 时清理同名对象未完成的 multipart upload（断点续传中断残留）
+*/
+
+const (
+	s3DefaultRegion     = "us-east-1"
+	s3DefaultPartSizeMB = 8
+	s3MinPartSizeMB     = 5
+	s3DefaultMaxRetries = 3
+)
+
+var errS3WriterAborted = errors.New("s3 writer aborted")
+
+type S3Storage struct {
+	bucket   string
+	prefix   string
+	partSize int
+	client   *minio.Client
+}
+
+func NewS3Storage(ctx context.Context, bucket, prefix string, cfg config.S3Config) (*S3Storage, error) {
+	if cfg.Region == "" {
+		cfg.Region = s3DefaultRegion
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = common.StringsBuilder("https://s3.", cfg.Region, ".amazonaws.com")
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("s3 endpoint [%s] parse failed: %v", cfg.Endpoint, err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("s3 endpoint [%s] isn't valid, example: http://127.0.0.1:9000", cfg.Endpoint)
+	}
+
+	partSize := cfg.PartSize
+	if partSize == 0 {
+		partSize = s3DefaultPartSizeMB
+	}
+	if partSize < s3MinPartSizeMB {
+		return nil, fmt.Errorf("s3 part-size [%dMB] can't be less than %dMB", partSize, s3MinPartSizeMB)
+	}
+	maxRetries := cfg.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = s3DefaultMaxRetries
+	}
+	// minio-go 重试次数为包级变量（含首次请求），同一进程所有 S3 存储共用
+	minio.MaxRetry = maxRetries + 1
+
+	bucketLookup := minio.BucketLookupDNS
+	if cfg.ForcePathStyle {
+		bucketLookup = minio.BucketLookupPath
+	}
+	// access-key 为空匿名访问
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure:       strings.EqualFold(endpoint.Scheme, "https"),
+		Region:       cfg.Region,
+		BucketLookup: bucketLookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("s3 endpoint [%s] client create failed: %v", cfg.Endpoint, err)
+	}
+
+	return &S3Storage{
+		bucket:   bucket,
+		prefix:   prefix,
+		partSize: partSize * 1024 * 1024,
+		client:   client,
+	}, nil
+}
+
+func (s *S3Storage) key(name string) string {
+	return strings.TrimPrefix(path.Join(s.prefix, name), "/")
+}
+
+func (s *S3Storage) Create(ctx context.Context, name string) (FileWriter, error) {
+	key := s.key(name)
+	if err := s.client.RemoveIncompleteUpload(ctx, s.bucket, key); err != nil {
+		return nil, s.convertError(err, key)
+	}
+	return &s3Writer{
+		ctx: ctx,
+		s:   s,
+		key: key,
+	}, nil
+}
+
+func (s *S3Storage) WriteFile(ctx context.Context, name string, data []byte) error {
+	return s.putObject(ctx, s.key(name), data)
+}
+
+func (s *S3Storage) ReadFile(ctx context.Context, name string) ([]byte, error) {
+	key := s.key(name)
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, s.convertError(err, key)
+	}
+	defer obj.Close()
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		return nil, s.convertError(err, key)
+	}
+	return data, nil
+}
+
+func (s *S3Storage) FileExists(ctx context.Context, name string) (bool, error) {
+	key := s.key(name)
+	if _, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{}); err != nil {
+		err = s.convertError(err, key)
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *S3Storage) DeleteFile(ctx context.Context, name string) error {
+	key := s.key(name)
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		err = s.convertError(err, key)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *S3Storage) WalkDir(ctx context.Context, dir string, fn func(name string, size int64) error) error {
+	prefix := s.key(dir)
+	if prefix != "" {
+		prefix = prefix + "/"
+	}
+	// 返回名称去除存储前缀
+	trimPrefix := s.prefix
+	if trimPrefix != "" {
+		trimPrefix = trimPrefix + "/"
+	}
+
+	// 提前返回时取消列举
+	listCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for obj := range s.client.ListObjects(listCtx, s.bucket, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}) {
+		if obj.Err != nil {
+			return s.convertError(obj.Err, prefix)
+		}
+		if err := fn(strings.TrimPrefix(obj.Key, trimPrefix), obj.Size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *S3Storage) URI() string {
+	return common.StringsBuilder("s3://", s.bucket, "/", s.prefix)
+}
+
+func (s *S3Storage) putObject(ctx context.Context, key string, data []byte) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		PartSize: uint64(s.partSize),
+	})
+	if err != nil {
+		return s.convertError(err, key)
+	}
+	return nil
+}
+
+// 对象不存在转换为 os.ErrNotExist
+func (s *S3Storage) convertError(err error, key string) error {
+	resp := minio.ToErrorResponse(err)
+	switch {
+	case resp.Code == "NoSuchKey" || resp.StatusCode == http.StatusNotFound && resp.Code != "NoSuchBucket":
+		return fmt.Errorf("s3 object [%s/%s] %w", s.bucket, key, os.ErrNotExist)
+	case resp.Code != "":
+		return fmt.Errorf("s3 object [%s/%s] request failed, status [%d] code [%s]: %s", s.bucket, key, resp.StatusCode, resp.Code, resp.Message)
+	default:
+		return fmt.Errorf("s3 object [%s/%s] request failed: %v", s.bucket, key, err)
+	}
+}
+
+// 缓存达到 part-size 转为流式 multipart upload，Close 时不足一个 part 的文件单次上传
+// multipart upload 由后台协程读取管道上传，Abort 关闭管道后客户端取消 multipart upload
+type s3Writer struct {
+	ctx  context.Context
+	s    *S3Storage
+	key  string
+	buf  bytes.Buffer
+	pw   *io.PipeWriter
+	done chan error
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	if w.pw != nil {
+		return w.pw.Write(p)
+	}
+	w.buf.Write(p)
+	if w.buf.Len() >= w.s.partSize {
+		w.startMultipartUpload()
+	}
+	return len(p), nil
+}
+
+func (w *s3Writer) startMultipartUpload() {
+	pr, pw := io.Pipe()
+	w.pw = pw
+	w.done = make(chan error, 1)
+	go func() {
+		_, err := w.s.client.PutObject(w.ctx, w.s.bucket, w.key, io.MultiReader(&w.buf, pr), -1, minio.PutObjectOptions{
+			PartSize: uint64(w.s.partSize),
+		})
+		// 上传失败解除写入阻塞
+		pr.CloseWithError(err)
+		w.done <- err
+	}()
+}
+
+func (w *s3Writer) Close() error {
+	if w.pw == nil {
+		return w.s.putObject(w.ctx, w.key, w.buf.Bytes())
+	}
+	w.pw.Close()
+	if err := <-w.done; err != nil {
+		return w.s.convertError(err, w.key)
+	}
+	return nil
+}
+
+func (w *s3Writer) Abort() error {
+	if w.pw == nil {
+		w.buf.Reset()
+		return nil
+	}
+	w.pw.CloseWithError(errS3WriterAborted)
+	if err := <-w.done; err != nil && !errors.Is(err, errS3WriterAborted) {
+		return w.s.convertError(err, w.key)
+	}
+	return nil
+}
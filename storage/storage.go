@@ -0,0 +1,73 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/config"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// 外部存储，文件名为相对存储根目录的路径，以 / 分隔
+//   - 本地文件系统：output-dir 为本地目录或 file:// 路径
+//   - S3 兼容对象存储：output-dir 为 s3://{bucket}/{prefix}
+//
+// 文件不存在时 ReadFile 返回错误可通过 errors.Is(err, os.ErrNotExist) 判断，DeleteFile 忽略不存在文件
+type ExternalStorage interface {
+	// 流式写入文件，Close 完成写入，写入失败需调用 Abort 丢弃
+	Create(ctx context.Context, name string) (FileWriter, error)
+	// 整体写入文件，写入中断不会留下不完整文件
+	WriteFile(ctx context.Context, name string, data []byte) error
+	ReadFile(ctx context.Context, name string) ([]byte, error)
+	FileExists(ctx context.Context, name string) (bool, error)
+	DeleteFile(ctx context.Context, name string) error
+	// 递归遍历目录下文件
+	WalkDir(ctx context.Context, dir string, fn func(name string, size int64) error) error
+	URI() string
+}
+
+type FileWriter interface {
+	io.WriteCloser
+	Abort() error
+}
+
+func NewStorage(ctx context.Context, outputDir string, s3Cfg config.S3Config) (ExternalStorage, error) {
+	if outputDir == "" {
+		return nil, fmt.Errorf("storage output dir can't be null, please configure")
+	}
+	if !strings.Contains(outputDir, "://") {
+		return NewLocalStorage(outputDir)
+	}
+
+	u, err := url.Parse(outputDir)
+	if err != nil {
+		return nil, fmt.Errorf("storage output dir [%s] parse failed: %v", outputDir, err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "file", "local":
+		return NewLocalStorage(u.Path)
+	case "s3":
+		if u.Host == "" {
+			return nil, fmt.Errorf("storage output dir [%s] bucket can't be null", outputDir)
+		}
+		return NewS3Storage(ctx, u.Host, strings.Trim(u.Path, "/"), s3Cfg)
+	default:
+		return nil, fmt.Errorf("storage output dir [%s] scheme [%s] isn't support, only support local path, file:// and s3://", outputDir, u.Scheme)
+	}
+}
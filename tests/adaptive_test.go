@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"fmt"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/wentaojin/transferdb/module/migrate/o2m"
+	"testing"
+	"time"
+)
+
+func TestPlanChunkSize(t *testing.T) {
+	cases := []struct {
+		name                                                           string
+		tableRows, avgRowLen, chunkSize, targetSize, sqlThreads, batch int
+		expect                                                         int
+	}{
+		// 无统计信息，使用 chunk-size
+		{"no statistics", 1000000, 0, 100000, 64, 32, 100, 100000},
+		// 小表，不小于 insert-batch-size
+		{"small table", 100, 200, 100000, 64, 32, 100, 100},
+		// 64MB / 100 bytes = 671088 行，向上取整 insert-batch-size
+		{"target size", 10000000000, 100, 100000, 64, 32, 100, 671100},
+		// 表内并发，chunk 数不少于 sql-threads
+		{"sql threads", 1000000, 100, 100000, 64, 32, 100, 31300},
+		// 宽表
+		{"wide rows", 10000000000, 1024 * 1024, 100000, 64, 32, 100, 100},
+	}
+	for _, c := range cases {
+		got := o2m.PlanChunkSize(c.tableRows, c.avgRowLen, c.chunkSize, c.targetSize, c.sqlThreads, c.batch)
+		if got != c.expect {
+			t.Fatalf("case [%s] plan chunk size %d, expect %d", c.name, got, c.expect)
+		}
+	}
+}
+
+func TestIsBusyError(t *testing.T) {
+	if !o2m.IsBusyError(fmt.Errorf("source schema table sql [insert] write failed: %w", &mysqldriver.MySQLError{Number: 9003, Message: "TiKV server is busy"})) {
+		t.Fatal("tikv server busy should be busy error")
+	}
+	if !o2m.IsBusyError(&mysqldriver.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded; try restarting transaction"}) {
+		t.Fatal("lock wait timeout should be busy error")
+	}
+	if o2m.IsBusyError(&mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry '1' for key 'PRIMARY'"}) || o2m.IsBusyError(nil) {
+		t.Fatal("duplicate entry shouldn't be busy error")
+	}
+	// 可重试但非繁忙类错误
+	if o2m.IsBusyError(&mysqldriver.MySQLError{Number: 8028, Message: "Information schema is changed"}) || o2m.IsBusyError(mysqldriver.ErrInvalidConn) {
+		t.Fatal("information schema changed and invalid connection shouldn't be busy error")
+	}
+}
+
+func TestConcurrencyController(t *testing.T) {
+	c := o2m.NewConcurrencyController("test", 1, 16)
+	if c.Limit() != 8 {
+		t.Fatalf("initial limit %d, expect 8", c.Limit())
+	}
+
+	// 稳定耗时，并发增加直至上限
+	for i := 0; i < 1000 && c.Limit() < 16; i++ {
+		c.Acquire()
+		c.Release(10*time.Millisecond, 1, nil)
+	}
+	if c.Limit() != 16 {
+		t.Fatalf("stable latency limit %d, expect 16", c.Limit())
+	}
+
+	// 繁忙错误，并发减半
+	busyErr := &mysqldriver.MySQLError{Number: 9003, Message: "TiKV server is busy"}
+	for i := 0; i < 16; i++ {
+		c.Acquire()
+		c.Release(10*time.Millisecond, 1, busyErr)
+	}
+	if c.Limit() != 8 {
+		t.Fatalf("busy error limit %d, expect 8", c.Limit())
+	}
+
+	// 耗时明显上升，并发减少
+	for i := 0; i < 8; i++ {
+		c.Acquire()
+		c.Release(100*time.Millisecond, 1, nil)
+	}
+	if c.Limit() != 6 {
+		t.Fatalf("latency increase limit %d, expect 6", c.Limit())
+	}
+
+	// 并发限制生效
+	for i := 0; i < c.Limit(); i++ {
+		c.Acquire()
+	}
+	acquired := make(chan struct{})
+	go func() {
+		c.Acquire()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquire should block when active reach limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+	c.Release(10*time.Millisecond, 1, nil)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("acquire should be unblocked after release")
+	}
+
+	// nil 控制器不限制
+	var n *o2m.ConcurrencyController
+	n.Acquire()
+	n.Release(time.Second, 1, busyErr)
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/migrate/o2m"
+	"testing"
+	"time"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	for _, e := range []error{
+		fmt.Errorf("source schema table sql [insert] write failed: %w", &mysqldriver.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock; try restarting transaction"}),
+		&mysqldriver.MySQLError{Number: 9007, Message: "Write conflict, txnStartTS=1, conflictStartTS=2"},
+		fmt.Errorf("source schema table sql [insert] write failed: %w", mysqldriver.ErrInvalidConn),
+		driver.ErrBadConn,
+	} {
+		if !o2m.IsRetryableError(e) {
+			t.Fatalf("error [%v] should be retryable", e)
+		}
+	}
+	for _, e := range []error{
+		&mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry '1' for key 'PRIMARY'"},
+		&mysqldriver.MySQLError{Number: 1406, Message: "Data too long for column 'NAME' at row 1"},
+		// 错误码判断，不依据错误信息文本
+		errors.New("Error 1213: Deadlock found when trying to get lock"),
+		&mysqldriver.MySQLError{Number: 1105, Message: "server is busy"},
+	} {
+		if o2m.IsRetryableError(e) {
+			t.Fatalf("error [%v] shouldn't be retryable", e)
+		}
+	}
+}
+
+func TestRetryBackoffInterval(t *testing.T) {
+	if got := o2m.RetryBackoffInterval(0, 0); got != 500*time.Millisecond {
+		t.Fatalf("default backoff %v, expect 500ms", got)
+	}
+	if got := o2m.RetryBackoffInterval(time.Second, 3); got != 8*time.Second {
+		t.Fatalf("backoff %v, expect 8s", got)
+	}
+	if got := o2m.RetryBackoffInterval(time.Second, 10); got != 30*time.Second {
+		t.Fatalf("backoff %v, expect max 30s", got)
+	}
+}
+
+func TestApplyTableRowsSkipBadRows(t *testing.T) {
+	ctx := context.Background()
+	server := newMySQLStandin(t)
+	db := newStandinMySQL(t, server)
+	metaDB := newSQLiteMeta(t)
+
+	syncMeta := meta.FullSyncMeta{
+		SchemaNameS: "MARVIN",
+		TableNameS:  "T1",
+		SchemaNameT: "MARVIN",
+		TableNameT:  "T1",
+		RowidInfoS:  "ROWID BETWEEN 'A' AND 'B'",
+	}
+	columns := []string{"`ID`", "`NAME`"}
+	policy := o2m.ApplyPolicy{RetryTimes: 0, SkipBadRows: true}
+
+	// 错误行二分定位记录，其余数据行正常写入
+	chunk := o2m.NewChunk(ctx, syncMeta, nil, db, metaDB, columns,
+		[][]string{{"(1,'a')", "(2,,)", "(3,'c')"}}, 1, 3, true, policy)
+	if err := chunk.ApplyTableRows(); err != nil {
+		t.Fatal(err)
+	}
+	if server.Rows() != 2 {
+		t.Fatalf("standin rows %d, expect 2", server.Rows())
+	}
+	errorRows, err := meta.NewFullSyncErrorRowModel(metaDB).DetailFullSyncErrorRow(ctx, &meta.FullSyncErrorRow{
+		DBTypeS:     common.TaskDBOracle,
+		DBTypeT:     common.TaskDBMySQL,
+		SchemaNameS: "MARVIN",
+		Mode:        common.FullO2MMode,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errorRows) != 1 || errorRows[0].RowInfo != "(2,,)" {
+		t.Fatalf("unexpected error rows: %+v", errorRows)
+	}
+
+	// chunk 重跑不重复记录错误行
+	if err = chunk.ApplyTableRows(); err != nil {
+		t.Fatal(err)
+	}
+	errorRows, err = meta.NewFullSyncErrorRowModel(metaDB).DetailFullSyncErrorRow(ctx, &meta.FullSyncErrorRow{
+		DBTypeS:     common.TaskDBOracle,
+		DBTypeT:     common.TaskDBMySQL,
+		SchemaNameS: "MARVIN",
+		Mode:        common.FullO2MMode,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errorRows) != 1 {
+		t.Fatalf("rerun chunk error rows %d, expect 1", len(errorRows))
+	}
+}
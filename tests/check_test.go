@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"context"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/module/check"
+	checkO2M "github.com/wentaojin/transferdb/module/check/o2m"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckFixFile(t *testing.T) {
+	dir := t.TempDir()
+	checkFile, fixFile := filepath.Join(dir, "check_marvin.sql"), filepath.Join(dir, "fix_structure_marvin.sql")
+
+	// 无结构差异不生成修复文件，并清理历史遗留修复文件
+	if err := os.WriteFile(fixFile, []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := check.NewWriter(checkFile, fixFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(fixFile); !os.IsNotExist(err) {
+		t.Fatalf("fix file exist without differences: %v", err)
+	}
+	if f.HasFixSQL() {
+		t.Fatal("fix file has sql without differences")
+	}
+
+	f, err = check.NewWriter(checkFile, fixFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.FWriteString(check.FixStageKey, "ALTER TABLE `M`.`T2` ADD PRIMARY KEY (`ID`);")
+	// 同一表语句保持生成顺序，先删除后新增
+	f.FWriteString(check.FixStageDrop, "ALTER TABLE `M`.`T2` DROP INDEX `IDX_B`;", "ALTER TABLE `M`.`T2` DROP INDEX `IDX_A`;")
+	f.FWriteString(check.FixStageDrop, "ALTER TABLE `M`.`T1` DROP PRIMARY KEY;")
+	f.FWriteString(check.FixStageIndex)
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(fixFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "-- drop conflicting primary key and indexes\n" +
+		"ALTER TABLE `M`.`T1` DROP PRIMARY KEY;\n" +
+		"ALTER TABLE `M`.`T2` DROP INDEX `IDX_B`;\n" +
+		"ALTER TABLE `M`.`T2` DROP INDEX `IDX_A`;\n\n" +
+		"-- add primary key and unique key\n" +
+		"ALTER TABLE `M`.`T2` ADD PRIMARY KEY (`ID`);\n\n"
+	if string(content) != want {
+		t.Fatalf("fix file content:\n%s\nwant:\n%s", content, want)
+	}
+	if !f.HasFixSQL() || strings.Contains(string(content), "add indexes") {
+		t.Fatal("fix file stage output unexpected")
+	}
+}
+
+func newCheckIndex(name, uniqueness, indexType, column string) checkO2M.Index {
+	return checkO2M.Index{
+		IndexInfo: checkO2M.IndexInfo{Uniqueness: uniqueness, IndexColumn: column},
+		IndexName: name,
+		IndexType: indexType,
+	}
+}
+
+func TestCheckIndex(t *testing.T) {
+	oracleTable := &checkO2M.Table{SchemaName: "MARVIN", TableName: "T1", Indexes: []checkO2M.Index{
+		newCheckIndex("IDX_NAME", "NONUNIQUE", "NORMAL", "NAME,AGE"),
+		newCheckIndex("IDX_UPPER", "NONUNIQUE", "FUNCTION-BASED NORMAL", `UPPER("NAME")`),
+		newCheckIndex("IDX_SUBSTR", "UNIQUE", "FUNCTION-BASED NORMAL", `SUBSTR("NAME",1,3),AGE`),
+		newCheckIndex("IDX_CHANGED", "NONUNIQUE", "NORMAL", "AGE"),
+		newCheckIndex("IDX_MISSING", "NONUNIQUE", "NORMAL", "ADDR"),
+	}}
+	mysqlTable := &checkO2M.Table{SchemaName: "MARVIN", TableName: "T1", Indexes: []checkO2M.Index{
+		newCheckIndex("IDX_NAME", "NONUNIQUE", "BTREE", "NAME,AGE"),
+		// MySQL 函数索引表达式
+		newCheckIndex("IDX_UPPER", "NONUNIQUE", "BTREE", "(UPPER(`NAME`))"),
+		newCheckIndex("IDX_SUBSTR", "UNIQUE", "BTREE", "(SUBSTR(`NAME`, 1, 3)),AGE"),
+		newCheckIndex("IDX_CHANGED", "NONUNIQUE", "BTREE", "NAME"),
+	}}
+
+	c := checkO2M.NewChecker(context.Background(), oracleTable, mysqlTable, "8.0.30", "MYSQL", nil)
+	diffs, err := c.CheckIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 定义一致的同名索引（包括函数索引）不输出修复 SQL
+	var objects []string
+	for _, d := range diffs {
+		objects = append(objects, d.Object)
+	}
+	if strings.Join(objects, ",") != "IDX_CHANGED,IDX_MISSING" {
+		t.Fatalf("check index differences = %v", objects)
+	}
+	if len(c.FixSQL[check.FixStageDrop]) != 1 || !strings.Contains(c.FixSQL[check.FixStageDrop][0], "DROP INDEX IDX_CHANGED") {
+		t.Fatalf("check index drop sql = %v", c.FixSQL[check.FixStageDrop])
+	}
+	if len(c.FixSQL[check.FixStageIndex]) != 2 {
+		t.Fatalf("check index create sql = %v", c.FixSQL[check.FixStageIndex])
+	}
+}
+
+func TestCheckReport(t *testing.T) {
+	diffs := []check.Difference{
+		{TableName: "T2", Object: "C1", Attribute: check.AttributeColumn, Severity: check.SeverityError,
+			SourceValue: "NUMBER(10)", TargetValue: "INT", ExpectedValue: "BIGINT", Suggest: "Modify Column",
+			FixSQL: []string{"ALTER TABLE `M`.`T2` MODIFY COLUMN `C1` BIGINT;"}},
+		{TableName: "T1", Object: "T1", Attribute: check.AttributeComment, Severity: check.SeverityInfo,
+			SourceValue: "a", TargetValue: "b", ExpectedValue: "a", Suggest: "Modify Comment"},
+		{TableName: "T1", Object: "IDX_A", Attribute: check.AttributeIndex, Severity: check.SeverityWarn,
+			SourceValue: "NONUNIQUE NORMAL (A)", ExpectedValue: "NONUNIQUE (A)", Suggest: "Create Table Index",
+			FixSQL: []string{"CREATE INDEX IDX_A ON M.T1 (A);"}},
+	}
+
+	report := check.NewReport("MARVIN", "STEVEN", 3, diffs)
+	if report.SchemaNameS != "MARVIN" || report.SchemaNameT != "STEVEN" || report.TableTotals != 3 || report.DiffTables != 2 {
+		t.Fatalf("report summary = %+v", report.ReportSummary)
+	}
+	if report.Errors != 1 || report.Warns != 1 || report.Infos != 1 {
+		t.Fatalf("report severity counts = %+v", report.ReportSummary)
+	}
+	// 按表名以及属性排序
+	var order []string
+	for _, d := range report.Differences {
+		order = append(order, d.TableName+"."+d.Object)
+	}
+	if strings.Join(order, ",") != "T1.T1,T1.IDX_A,T2.C1" {
+		t.Fatalf("report differences order = %v", order)
+	}
+
+	text := check.GenTextReport(report.Differences, common.CheckTextFormatTable)
+	for _, s := range []string{
+		"oracle and mysql table comment different",
+		"oracle and mysql table index different",
+		"oracle and mysql table column different",
+		"NUMBER(10)",
+		"*/\nCREATE INDEX IDX_A ON M.T1 (A);\n\n",
+		"ALTER TABLE `M`.`T2` MODIFY COLUMN `C1` BIGINT;",
+	} {
+		if !strings.Contains(text, s) {
+			t.Fatalf("text report not contains [%s]:\n%s", s, text)
+		}
+	}
+	// 按差异属性首次出现顺序分段输出
+	if strings.Index(text, "comment different") > strings.Index(text, "index different") {
+		t.Fatalf("text report attribute order unexpected:\n%s", text)
+	}
+	if check.GenTextReport(nil, common.CheckTextFormatTable) != "" || check.GenTextReport(nil, "") != "" {
+		t.Fatal("empty differences text report isn't empty")
+	}
+}
+
+func TestCheckReportSection(t *testing.T) {
+	diffs := []check.Difference{
+		{TableName: "T1", Object: "T1", Attribute: check.AttributeComment, Section: check.SectionComment,
+			SourceValue: "a", TargetValue: "b", Suggest: "Create Table Comment",
+			FixSQL: []string{"ALTER TABLE M.T1 COMMENT 'a';"}},
+		{TableName: "T1", Object: "C1", Attribute: check.AttributeColumn, Section: check.SectionColumn,
+			SourceValue: "NUMBER(10)", TargetValue: "INT", ExpectedValue: "BIGINT", Suggest: "Modify MySQL Table Column",
+			FixSQL: []string{"ALTER TABLE M.T1 MODIFY COLUMN `C1` BIGINT;"}},
+		{TableName: "T1", Object: "IDX_A", Attribute: check.AttributeIndex, Section: check.SectionIndex,
+			FixSQL: []string{"CREATE INDEX IDX_A ON M.T1 (A);"}},
+		{TableName: "T1", Object: "IDX_B", Attribute: check.AttributeIndex, Section: check.SectionIndex,
+			FixSQL: []string{"CREATE INDEX IDX_B ON M.T1 (B);"}},
+		{TableName: "T1", Object: "C2", Attribute: check.AttributeColumn, Section: check.SectionColumnAdd,
+			SourceValue: "VARCHAR2(10)", Suggest: "Add MySQL Table Column",
+			FixSQL: []string{"ALTER TABLE M.T1 ADD COLUMN C2 VARCHAR(10);"}},
+	}
+
+	// 默认 SECTION 格式，与原 check 文件格式一致
+	text := check.GenTextReport(diffs, "")
+	for _, s := range []string{
+		"/*\n oracle and mysql table comment\n",
+		"*/\nALTER TABLE M.T1 COMMENT 'a';\n",
+		" mysql column character set and collation add [mysql column isn't exist], generate add sql\n",
+		"*/\nALTER TABLE M.T1 ADD COLUMN C2 VARCHAR(10);\n\n",
+		" oracle and mysql table indexes\n",
+		"Oracle And Mysql Different",
+		"*/\nCREATE INDEX IDX_A ON M.T1 (A);\nCREATE INDEX IDX_B ON M.T1 (B);\n",
+		" oracle table columns info is different from mysql\n",
+		"`C1`",
+		"-- oracle table columns info is different from mysql, generate fixed sql\nALTER TABLE M.T1 MODIFY COLUMN `C1` BIGINT;\n\n",
+	} {
+		if !strings.Contains(text, s) {
+			t.Fatalf("section text report not contains [%s]:\n%s", s, text)
+		}
+	}
+	if strings.Contains(text, "SEVERITY") {
+		t.Fatalf("section text report contains table format header:\n%s", text)
+	}
+	// 分段按原 check 文件顺序输出：注释、新增字段、索引、字段
+	comment := strings.Index(text, "table comment")
+	add := strings.Index(text, "generate add sql")
+	index := strings.Index(text, "table indexes")
+	column := strings.Index(text, "columns info is different")
+	if !(comment < add && add < index && index < column) {
+		t.Fatalf("section text report order unexpected:\n%s", text)
+	}
+	// 索引分段每表仅输出一行说明
+	if strings.Count(text, "Oracle And Mysql Different") != 1 {
+		t.Fatalf("section text report index rows unexpected:\n%s", text)
+	}
+}
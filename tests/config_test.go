@@ -0,0 +1,227 @@
+package tests
+
+import (
+	"bytes"
+	"github.com/wentaojin/transferdb/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestConfig(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestConfigOverrides(t *testing.T) {
+	file := writeTestConfig(t, `
+[full]
+chunk-size = 100
+task-threads = 2
+
+[csv.s3]
+region = "us-east-1"
+
+[oracle]
+include-table = ["T1"]
+`)
+	if got := config.ConfigEnvName("csv.s3.secret-key"); got != "TRANSFERDB_CSV_S3_SECRET_KEY" {
+		t.Fatalf("config env name = %s", got)
+	}
+	t.Setenv("TRANSFERDB_FULL_CHUNK_SIZE", "200")
+	t.Setenv("TRANSFERDB_FULL_TASK_THREADS", "4")
+	t.Setenv("TRANSFERDB_CSV_S3_REGION", "cn-north-1")
+	t.Setenv("TRANSFERDB_ORACLE_INCLUDE_TABLE", "T2, T3")
+
+	// 优先级 --set > 环境变量 > 配置文件
+	cfg := config.NewConfig()
+	if err := cfg.Parse([]string{"--config", file, "--set", "full.chunk-size=300", "--set", "full.consistent-snapshot=true"}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.FullConfig.ChunkSize != 300 || cfg.FullConfig.TaskThreads != 4 || !cfg.FullConfig.ConsistentSnapshot {
+		t.Fatalf("full config = %+v", cfg.FullConfig)
+	}
+	if cfg.CSVConfig.S3.Region != "cn-north-1" {
+		t.Fatalf("csv s3 region = %s", cfg.CSVConfig.S3.Region)
+	}
+	if strings.Join(cfg.OracleConfig.IncludeTable, ",") != "T2,T3" {
+		t.Fatalf("oracle include table = %v", cfg.OracleConfig.IncludeTable)
+	}
+	// 环境变量覆盖配置项记录，与 --set 同时覆盖同样记录
+	envKeys := strings.Join(cfg.EnvKeys, ",")
+	for _, key := range []string{"full.chunk-size", "full.task-threads", "csv.s3.region", "oracle.include-table"} {
+		if !strings.Contains(envKeys, key) {
+			t.Fatalf("config env keys = %v, missing [%s]", cfg.EnvKeys, key)
+		}
+	}
+
+	for _, set := range []string{"full.chunk-size=abc", "full.not-exist=1", "full", "table-rule=T1"} {
+		cfg = config.NewConfig()
+		if err := cfg.Parse([]string{"--config", file, "--set", set}); err == nil {
+			t.Fatalf("flag set [%s] should be failed", set)
+		}
+	}
+
+	// 数组表配置项不支持覆盖，明确报错而非忽略
+	cfg = config.NewConfig()
+	err := cfg.Parse([]string{"--config", file, "--set", "table-rule.source-table=T1"})
+	if err == nil || !strings.Contains(err.Error(), "isn't support override") {
+		t.Fatalf("flag set table-rule.source-table error = %v", err)
+	}
+	t.Setenv("TRANSFERDB_TABLE_RULE", "T1")
+	cfg = config.NewConfig()
+	err = cfg.Parse([]string{"--config", file})
+	if err == nil || !strings.Contains(err.Error(), "TRANSFERDB_TABLE_RULE") {
+		t.Fatalf("config environment variable table-rule error = %v", err)
+	}
+}
+
+func TestConfigUnknownKeys(t *testing.T) {
+	file := writeTestConfig(t, `
+[full]
+chunk-size = 100
+chunk-szie = 200
+
+[unknown]
+key = 1
+`)
+	cfg := config.NewConfig()
+	if err := cfg.Parse([]string{"--config", file}); err != nil {
+		t.Fatal(err)
+	}
+	keys := strings.Join(cfg.UnknownKeys, ",")
+	if !strings.Contains(keys, "full.chunk-szie") || !strings.Contains(keys, "unknown.key") || strings.Contains(keys, "full.chunk-size") {
+		t.Fatalf("unknown keys = %v", cfg.UnknownKeys)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := config.NewConfig()
+	if err := cfg.Parse([]string{"--config", "../example/config.toml"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("example config validate failed: %v", err)
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "output")
+	if err := os.WriteFile(outputFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Mode = "csv"
+	cfg.AppConfig.InsertBatchSize = 0
+	cfg.CSVConfig.Rows = 0
+	cfg.CSVConfig.FileFormat = "xlsx"
+	cfg.CSVConfig.OutputDir = filepath.Join(outputFile, "sub")
+	cfg.OracleConfig.SchemaName = ""
+	cfg.OracleConfig.Port = 70000
+	cfg.MySQLConfig.DBType = "postgres"
+	cfg.LogConfig.LogLevel = "trace"
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("invalid config validate should be failed")
+	}
+	for _, msg := range []string{"[app] insert-batch-size", "[csv] rows", "[csv] file-format", "[csv] output-dir",
+		"[oracle] schema-name", "[oracle] port", "[mysql] db-type", "[log] log-level"} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Fatalf("validate error [%v] not contains [%s]", err, msg)
+		}
+	}
+	// server 模式 HTTP API 需鉴权
+	serverCfg := config.NewConfig()
+	if err = serverCfg.Parse([]string{"--config", "../example/config.toml"}); err != nil {
+		t.Fatal(err)
+	}
+	serverCfg.Mode = "server"
+	if err = serverCfg.Validate(); err == nil || !strings.Contains(err.Error(), "[server] token or ssl-ca") {
+		t.Fatalf("server mode should require token or ssl-ca, error: %v", err)
+	}
+	serverCfg.ServerConfig.Token = "token"
+	if err = serverCfg.Validate(); err != nil {
+		t.Fatalf("server mode with token validate failed: %v", err)
+	}
+
+	// all 模式增量同步不支持表级别数据迁移规则
+	allCfg := config.NewConfig()
+	if err = allCfg.Parse([]string{"--config", "../example/config.toml"}); err != nil {
+		t.Fatal(err)
+	}
+	allCfg.Mode = "all"
+	allCfg.TableRules = []config.TableRule{{SourceTable: "T1", Where: "ID > 1"}}
+	if err = allCfg.Validate(); err == nil || !strings.Contains(err.Error(), "[table-rule]") {
+		t.Fatalf("all mode should reject table-rule, error: %v", err)
+	}
+	allCfg.Mode = "full"
+	if err = allCfg.Validate(); err != nil {
+		t.Fatalf("full mode table-rule validate failed: %v", err)
+	}
+
+	// full section 不属于 csv 模式校验范围
+	cfg.FullConfig.ChunkSize = 0
+	if strings.Contains(cfg.Validate().Error(), "[full]") {
+		t.Fatal("csv mode validate full section")
+	}
+
+	// csv 模式元数据库为 sqlite 时无需目标端连接配置，仍需 schema-name
+	cfg = config.NewConfig()
+	if err = cfg.Parse([]string{"--config", "../example/config.toml"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Mode = "csv"
+	cfg.MetaConfig.DBType = "sqlite"
+	cfg.MySQLConfig.Username, cfg.MySQLConfig.Host, cfg.MySQLConfig.Port = "", "", 0
+	if err = cfg.Validate(); err != nil {
+		t.Fatalf("csv mode with sqlite meta shouldn't require mysql connection: %v", err)
+	}
+	cfg.MySQLConfig.SchemaName = ""
+	if err = cfg.Validate(); err == nil || !strings.Contains(err.Error(), "[mysql] schema-name") {
+		t.Fatalf("csv mode should require mysql schema-name, error: %v", err)
+	}
+
+	// parquet 不支持自定义日期、时间戳格式
+	cfg.MySQLConfig.SchemaName = "marvin"
+	cfg.CSVConfig.FileFormat = "parquet"
+	if err = cfg.Validate(); err != nil {
+		t.Fatalf("csv mode parquet validate failed: %v", err)
+	}
+	cfg.CSVConfig.DateFormat, cfg.CSVConfig.TimestampFormat = "yyyy/mm/dd", "yyyy/mm/dd hh24:mi:ss.ff6"
+	err = cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "[csv] date-format") || !strings.Contains(err.Error(), "[csv] timestamp-format") {
+		t.Fatalf("csv mode parquet should reject date-format and timestamp-format, error: %v", err)
+	}
+}
+
+func TestConfigCheck(t *testing.T) {
+	file := writeTestConfig(t, `
+[oracle]
+password = "oracle-secret"
+
+[mysql]
+password = "mysql-secret"
+pasword = "typo"
+`)
+	t.Setenv("TRANSFERDB_MYSQL_PORT", "4000")
+	cfg := config.NewConfig()
+	if err := cfg.ParseConfig([]string{"check", "--config", file, "--set", "mysql.host=127.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := config.Check(cfg, &out); err == nil {
+		t.Fatal("config check should be failed")
+	}
+	if strings.Contains(out.String(), "oracle-secret") || strings.Contains(out.String(), "mysql-secret") {
+		t.Fatalf("config check output isn't redacted: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "mysql.pasword") || !strings.Contains(out.String(), `"host": "127.0.0.1"`) ||
+		!strings.Contains(out.String(), "config item [mysql.port] is overridden by environment variable [TRANSFERDB_MYSQL_PORT]") {
+		t.Fatalf("config check output = %s", out.String())
+	}
+
+	if err := config.NewConfig().ParseConfig([]string{"lint"}); err == nil {
+		t.Fatal("config subcommand lint should be failed")
+	}
+}
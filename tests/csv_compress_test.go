@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/wentaojin/transferdb/module/csv"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestCSVCompressRoundTrip(t *testing.T) {
+	// 重复文本、随机字节以及跨多个 block 的数据
+	random := make([]byte, 300*1024)
+	rand.New(rand.NewSource(1)).Read(random)
+	payloads := map[string][]byte{
+		"empty":  nil,
+		"small":  []byte("1,marvin,2023-01-02\r\n"),
+		"repeat": []byte(strings.Repeat("1,\"a,b\",2023-01-02 03:04:05\r\n", 20000)),
+		"random": random,
+	}
+
+	decoders := map[string]func(r io.Reader) (io.Reader, error){
+		csv.CompressGzip: func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		},
+		csv.CompressZstd: func(r io.Reader) (io.Reader, error) {
+			return zstd.NewReader(r)
+		},
+		// golang/snappy framing 格式解码，与 TiDB Lightning 解码一致
+		csv.CompressSnappy: func(r io.Reader) (io.Reader, error) {
+			return snappy.NewReader(r), nil
+		},
+	}
+
+	for compress, decoder := range decoders {
+		for name, payload := range payloads {
+			var buf bytes.Buffer
+			w, err := csv.NewCompressWriter(&buf, strings.ToLower(compress))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 分段写入，模拟流式输出
+			for i := 0; i < len(payload); i += 4096 {
+				end := i + 4096
+				if end > len(payload) {
+					end = len(payload)
+				}
+				if _, err = w.Write(payload[i:end]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err = w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if name == "repeat" && buf.Len() >= len(payload)/10 {
+				t.Fatalf("compress [%s] payload [%s] size [%d] isn't compressed, origin size [%d]", compress, name, buf.Len(), len(payload))
+			}
+
+			r, err := decoder(&buf)
+			if err != nil {
+				t.Fatalf("compress [%s] payload [%s] decoder failed: %v", compress, name, err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("compress [%s] payload [%s] decode failed: %v", compress, name, err)
+			}
+			if !bytes.Equal(got, payload) {
+				t.Fatalf("compress [%s] payload [%s] round trip size [%d], expect [%d]", compress, name, len(got), len(payload))
+			}
+		}
+	}
+
+	suffix, err := csv.CompressFileSuffix("snappy")
+	if err != nil || suffix != ".snappy" {
+		t.Fatalf("compress snappy file suffix [%s], error: %v", suffix, err)
+	}
+	if _, err = csv.NewCompressWriter(&bytes.Buffer{}, "lz4"); err == nil {
+		t.Fatal("compress lz4 should be failed")
+	}
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/csv"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/module/csv/o2m"
+	"reflect"
+	"testing"
+)
+
+func encodeCSV(t *testing.T, cfg config.CSVConfig, columnTypes, databaseTypes []string, rows [][][]byte) string {
+	var buf bytes.Buffer
+	f := o2m.NewWriter(nil, nil, "MARVIN", "T1", "MARVIN", "T1", "AL32UTF8", "", "MARVIN.T1.0.csv",
+		[]string{"ID", "NAME", "DATA"}, nil, cfg, nil)
+	e, err := f.NewEncoder(&buf, columnTypes, databaseTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err = e.Encode(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestCSVEncoderRFC4180(t *testing.T) {
+	cfg := config.CSVConfig{
+		Header:     true,
+		Separator:  ",",
+		Terminator: "\r\n",
+		Charset:    "UTF8",
+		NullValue:  `\N`,
+	}
+	columnTypes := []string{"int64", "string", "string"}
+	databaseTypes := []string{"NUMBER", "VARCHAR2", "VARCHAR2"}
+	rows := [][][]byte{
+		{[]byte("1"), []byte("a,b"), []byte(`say "hi"`)},
+		{[]byte("2"), []byte("line1\r\nline2"), nil},
+		{[]byte("3"), []byte(`\N`), []byte("plain")},
+	}
+
+	out := encodeCSV(t, cfg, columnTypes, databaseTypes, rows)
+	expect := "ID,NAME,DATA\r\n" +
+		"1,\"a,b\",\"say \"\"hi\"\"\"\r\n" +
+		"2,\"line1\r\nline2\",\\N\r\n" +
+		"3,\"\\N\",plain\r\n"
+	if out != expect {
+		t.Fatalf("unexpected csv:\n%q\nexpect:\n%q", out, expect)
+	}
+
+	records, err := csv.NewReader(bytes.NewBufferString(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("csv isn't rfc 4180 compliant: %v", err)
+	}
+	if !reflect.DeepEqual(records[1], []string{"1", "a,b", `say "hi"`}) ||
+		!reflect.DeepEqual(records[2], []string{"2", "line1\nline2", `\N`}) {
+		t.Fatalf("unexpected records: %q", records)
+	}
+}
+
+func TestCSVEncoderDelimiterAndNullValue(t *testing.T) {
+	cfg := config.CSVConfig{
+		Separator:  "|#|",
+		Terminator: "|+|\r\n",
+		Delimiter:  `'`,
+		Charset:    "UTF8",
+		NullValue:  "",
+	}
+	columnTypes := []string{"int64", "string", "string"}
+	databaseTypes := []string{"NUMBER", "VARCHAR2", "VARCHAR2"}
+	rows := [][][]byte{
+		{[]byte("1"), []byte("it's"), nil},
+		{[]byte("2"), []byte("NULL"), []byte("")},
+	}
+
+	out := encodeCSV(t, cfg, columnTypes, databaseTypes, rows)
+	expect := "1|#|'it''s'|#||+|\r\n" +
+		"2|#|'NULL'|#||+|\r\n"
+	if out != expect {
+		t.Fatalf("unexpected csv:\n%q\nexpect:\n%q", out, expect)
+	}
+}
+
+func TestCSVEncoderEscapeBackslashAndBinary(t *testing.T) {
+	columnTypes := []string{"int64", "string", "[]uint8"}
+	databaseTypes := []string{"NUMBER", "VARCHAR2", "BLOB"}
+	rows := [][][]byte{
+		{[]byte("1"), []byte("c:\\dir\n"), {0x00, 0xff, '"'}},
+	}
+
+	hexOut := encodeCSV(t, config.CSVConfig{
+		Separator:       ",",
+		Terminator:      "\n",
+		Delimiter:       `"`,
+		EscapeBackslash: true,
+		Charset:         "UTF8",
+		NullValue:       "NULL",
+		BinaryFormat:    "hex",
+	}, columnTypes, databaseTypes, rows)
+	if hexOut != "1,\"c:\\\\dir\\n\",\"00FF22\"\n" {
+		t.Fatalf("unexpected hex csv: %q", hexOut)
+	}
+
+	base64Out := encodeCSV(t, config.CSVConfig{
+		Separator:    ",",
+		Terminator:   "\n",
+		Charset:      "UTF8",
+		NullValue:    "NULL",
+		BinaryFormat: "base64",
+	}, columnTypes, databaseTypes, rows)
+	if base64Out != "1,\"c:\\dir\n\",AP8i\n" {
+		t.Fatalf("unexpected base64 csv: %q", base64Out)
+	}
+}
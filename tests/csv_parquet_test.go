@@ -0,0 +1,232 @@
+package tests
+
+import (
+	"bytes"
+	"github.com/shopspring/decimal"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/module/csv"
+	"github.com/wentaojin/transferdb/module/csv/o2m"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// parquet 文件按字段读取，返回字段值，NULL 为 nil
+func readParquetColumns(t *testing.T, data []byte) (*reader.ParquetReader, [][]interface{}) {
+	t.Helper()
+	pf, err := buffer.NewBufferFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, err := reader.NewParquetColumnReader(pf, 1)
+	if err != nil {
+		t.Fatalf("parquet file can't be open by parquet reader: %v", err)
+	}
+	numRows := pr.GetNumRows()
+	var columns [][]interface{}
+	for i := 0; i < len(pr.Footer.Schema)-1; i++ {
+		values, _, dls, err := pr.ReadColumnByIndex(int64(i), numRows)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int64(len(dls)) != numRows {
+			t.Fatalf("parquet column [%d] values [%d], expect [%d]", i, len(dls), numRows)
+		}
+		columns = append(columns, values)
+	}
+	return pr, columns
+}
+
+func TestCSVParquetEncoderReadBack(t *testing.T) {
+	columns := []string{"ID", "AMOUNT", "NAME", "CREATED_AT", "RATE", "PAYLOAD", "BIG"}
+	columnsINFO := []map[string]string{
+		{"COLUMN_NAME": "ID", "DATA_TYPE": "NUMBER", "DATA_PRECISION": "10", "DATA_SCALE": "0"},
+		{"COLUMN_NAME": "AMOUNT", "DATA_TYPE": "NUMBER", "DATA_PRECISION": "12", "DATA_SCALE": "2"},
+		{"COLUMN_NAME": "NAME", "DATA_TYPE": "VARCHAR2", "DATA_PRECISION": "", "DATA_SCALE": ""},
+		{"COLUMN_NAME": "CREATED_AT", "DATA_TYPE": "DATE", "DATA_PRECISION": "", "DATA_SCALE": ""},
+		{"COLUMN_NAME": "RATE", "DATA_TYPE": "BINARY_DOUBLE", "DATA_PRECISION": "", "DATA_SCALE": ""},
+		{"COLUMN_NAME": "PAYLOAD", "DATA_TYPE": "RAW", "DATA_PRECISION": "", "DATA_SCALE": ""},
+		{"COLUMN_NAME": "BIG", "DATA_TYPE": "NUMBER", "DATA_PRECISION": "38", "DATA_SCALE": "0"},
+	}
+	rows := [][][]byte{
+		{[]byte("1"), []byte("-1234.5"), []byte("marvin"), []byte("2023-01-02 03:04:05"), []byte("0.5"), []byte{0x00, 0xFF}, []byte("12345678901234567890123456789012345678")},
+		{[]byte("2"), nil, []byte(""), nil, nil, nil, []byte("-99999999999999999999999999999999999999")},
+		{[]byte("3"), []byte("0.01"), []byte("中文"), []byte("1999-12-31 23:59:59.123456"), []byte("-2.25"), []byte("abc"), []byte("0")},
+	}
+
+	var buf bytes.Buffer
+	f := o2m.NewWriter(nil, nil, "MARVIN", "T1", "MARVIN", "T1", "AL32UTF8", "", "MARVIN.T1.0.parquet",
+		columns, columnsINFO, config.CSVConfig{FileFormat: common.CSVFileFormatParquet}, nil)
+	e, err := f.NewEncoder(&buf, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err = e.Encode(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	pr, values := readParquetColumns(t, buf.Bytes())
+	if pr.GetNumRows() != int64(len(rows)) {
+		t.Fatalf("parquet rows [%d], expect [%d]", pr.GetNumRows(), len(rows))
+	}
+
+	// 字段保持 Oracle 字段顺序、OPTIONAL 以及逻辑类型
+	expectTypes := []parquet.Type{parquet.Type_INT64, parquet.Type_BYTE_ARRAY, parquet.Type_BYTE_ARRAY, parquet.Type_INT64,
+		parquet.Type_DOUBLE, parquet.Type_BYTE_ARRAY, parquet.Type_BYTE_ARRAY}
+	expectConverted := []string{"<nil>", "DECIMAL", "UTF8", "TIMESTAMP_MICROS", "<nil>", "<nil>", "DECIMAL"}
+	for i, se := range pr.Footer.Schema[1:] {
+		if se.Name != columns[i] {
+			t.Fatalf("parquet column [%d] name [%s], expect [%s]", i, se.Name, columns[i])
+		}
+		if se.GetRepetitionType() != parquet.FieldRepetitionType_OPTIONAL {
+			t.Fatalf("parquet column [%s] should be optional", se.Name)
+		}
+		converted := "<nil>"
+		if se.ConvertedType != nil {
+			converted = se.ConvertedType.String()
+		}
+		if se.GetType() != expectTypes[i] || converted != expectConverted[i] {
+			t.Fatalf("parquet column [%s] type [%s %s], expect [%s %s]", se.Name, se.GetType(), converted, expectTypes[i], expectConverted[i])
+		}
+	}
+	if pr.Footer.Schema[2].GetPrecision() != 12 || pr.Footer.Schema[2].GetScale() != 2 {
+		t.Fatalf("parquet column AMOUNT decimal(%d,%d), expect decimal(12,2)", pr.Footer.Schema[2].GetPrecision(), pr.Footer.Schema[2].GetScale())
+	}
+
+	for j := range columns {
+		for i := range rows {
+			raw, v := rows[i][j], values[j][i]
+			if len(raw) == 0 {
+				if v != nil {
+					t.Fatalf("row [%d] column [%s] value [%v] should be null", i, columns[j], v)
+				}
+				continue
+			}
+			var got, expect string
+			switch columns[j] {
+			case "ID":
+				got, expect = decimal.NewFromInt(v.(int64)).String(), string(raw)
+			case "AMOUNT", "BIG":
+				scale := pr.Footer.Schema[j+1].GetScale()
+				got = decimal.NewFromBigInt(decodeTwosComplement([]byte(v.(string))), -scale).String()
+				expect = decimal.RequireFromString(string(raw)).String()
+			case "CREATED_AT":
+				got = time.UnixMicro(v.(int64)).UTC().Format("2006-01-02 15:04:05.999999")
+				expect = string(raw)
+			case "RATE":
+				got, expect = decimal.NewFromFloat(v.(float64)).String(), string(raw)
+			default:
+				got, expect = v.(string), string(raw)
+			}
+			if got != expect {
+				t.Fatalf("row [%d] column [%s] value [%s], expect [%s]", i, columns[j], got, expect)
+			}
+		}
+	}
+}
+
+func TestCSVParquetWriterRowGroup(t *testing.T) {
+	var buf bytes.Buffer
+	pw, err := csv.NewParquetWriter(&buf, []csv.ParquetColumn{
+		{Name: "B", Type: csv.ParquetTypeBoolean, ConvertedType: csv.ParquetConvertedNone},
+		{Name: "A", Type: csv.ParquetTypeInt32, ConvertedType: csv.ParquetConvertedNone},
+		{Name: "F", Type: csv.ParquetTypeFloat, ConvertedType: csv.ParquetConvertedNone},
+	}, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		var b interface{} = i%2 == 0
+		if i == 3 {
+			b = nil
+		}
+		if err = pw.Write([]interface{}{b, int32(i), float32(i) / 2}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = pw.Write([]interface{}{true, int64(1), float32(0)}); err == nil {
+		t.Fatal("parquet int32 column write int64 value should be failed")
+	}
+	if err = pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	pr, values := readParquetColumns(t, buf.Bytes())
+	if len(pr.Footer.RowGroups) != 3 || pr.GetNumRows() != 5 {
+		t.Fatalf("parquet row groups [%d] rows [%d], expect 3 row groups 5 rows", len(pr.Footer.RowGroups), pr.GetNumRows())
+	}
+	if pr.Footer.Schema[1].Name != "B" || pr.Footer.Schema[2].Name != "A" {
+		t.Fatalf("parquet column order [%s %s] isn't write order", pr.Footer.Schema[1].Name, pr.Footer.Schema[2].Name)
+	}
+	for i := 0; i < 5; i++ {
+		if i == 3 {
+			if values[0][i] != nil {
+				t.Fatalf("row [%d] boolean value should be null", i)
+			}
+		} else if values[0][i].(bool) != (i%2 == 0) {
+			t.Fatalf("row [%d] boolean value [%v]", i, values[0][i])
+		}
+		if values[1][i].(int32) != int32(i) || values[2][i].(float32) != float32(i)/2 {
+			t.Fatalf("row [%d] values [%v %v]", i, values[1][i], values[2][i])
+		}
+	}
+
+	for _, column := range []csv.ParquetColumn{
+		{Name: "D", Type: csv.ParquetTypeByteArray, ConvertedType: csv.ParquetConvertedDecimal, Precision: 0},
+		{Name: "A,B", Type: csv.ParquetTypeInt64, ConvertedType: csv.ParquetConvertedNone},
+	} {
+		if _, err = csv.NewParquetWriter(&buf, []csv.ParquetColumn{column}, 0, 0); err == nil {
+			t.Fatalf("parquet column [%v] should be failed", column)
+		}
+	}
+}
+
+func TestCSVParquetWriterRowGroupSize(t *testing.T) {
+	var buf bytes.Buffer
+	// 行数上限不触发，按缓存字节数写出 row group
+	pw, err := csv.NewParquetWriter(&buf, []csv.ParquetColumn{
+		{Name: "ID", Type: csv.ParquetTypeInt64, ConvertedType: csv.ParquetConvertedNone},
+		{Name: "NAME", Type: csv.ParquetTypeByteArray, ConvertedType: csv.ParquetConvertedUTF8},
+	}, 1000, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := bytes.Repeat([]byte("a"), 120)
+	for i := 0; i < 40; i++ {
+		if err = pw.Write([]interface{}{int64(i), name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 每行 128 字节，1024 字节 8 行一个 row group
+	pr, values := readParquetColumns(t, buf.Bytes())
+	if len(pr.Footer.RowGroups) != 5 || pr.GetNumRows() != 40 {
+		t.Fatalf("parquet row groups [%d] rows [%d], expect 5 row groups 40 rows", len(pr.Footer.RowGroups), pr.GetNumRows())
+	}
+	for i := 0; i < 40; i++ {
+		if values[0][i].(int64) != int64(i) || values[1][i].(string) != string(name) {
+			t.Fatalf("row [%d] values [%v %v]", i, values[0][i], values[1][i])
+		}
+	}
+}
+
+// DECIMAL 非标度值，大端序二进制补码解码
+func decodeTwosComplement(bs []byte) *big.Int {
+	v := new(big.Int).SetBytes(bs)
+	if len(bs) > 0 && bs[0]&0x80 != 0 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(len(bs)*8)))
+	}
+	return v
+}
@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/module/csv/o2m"
+	"github.com/wentaojin/transferdb/storage"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 内存数据源：ID NUMBER、NAME VARCHAR2，行数以 DSN 指定
+type csvRowsDriver struct{}
+
+func (csvRowsDriver) Open(dsn string) (driver.Conn, error) {
+	rows, err := strconv.Atoi(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &csvRowsConn{rows: rows}, nil
+}
+
+type csvRowsConn struct {
+	rows int
+}
+
+func (c *csvRowsConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+func (c *csvRowsConn) Close() error              { return nil }
+func (c *csvRowsConn) Begin() (driver.Tx, error) { return nil, errors.New("not implemented") }
+func (c *csvRowsConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &csvRows{total: c.rows}, nil
+}
+
+type csvRows struct {
+	total int
+	cur   int
+}
+
+func (r *csvRows) Columns() []string { return []string{"ID", "NAME"} }
+func (r *csvRows) Close() error      { return nil }
+func (r *csvRows) Next(dest []driver.Value) error {
+	if r.cur >= r.total {
+		return io.EOF
+	}
+	r.cur++
+	dest[0] = []byte(strconv.Itoa(r.cur))
+	dest[1] = []byte(strings.Repeat("x", 1000))
+	return nil
+}
+func (r *csvRows) ColumnTypeScanType(index int) reflect.Type {
+	if index == 0 {
+		return reflect.TypeOf(int64(0))
+	}
+	return reflect.TypeOf("")
+}
+func (r *csvRows) ColumnTypeDatabaseTypeName(index int) string {
+	if index == 0 {
+		return "NUMBER"
+	}
+	return "VARCHAR2"
+}
+
+func init() {
+	sql.Register("csvrows", csvRowsDriver{})
+}
+
+func newCSVWriteRows(t *testing.T, rows int) *sql.Rows {
+	db, err := sql.Open("csvrows", strconv.Itoa(rows))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	result, err := db.Query(`SELECT ID, NAME FROM MARVIN.CSV_RESUME`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestCSVWriteResumeFinishedParts(t *testing.T) {
+	ctx := context.Background()
+	// 3000 行约 3MB，max-file-size 1MB 预期 3 个分片
+	s, err := storage.NewStorage(ctx, t.TempDir(), config.S3Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.CSVConfig{
+		Separator:          ",",
+		Terminator:         "\n",
+		Charset:            "UTF8",
+		MaxFileSize:        1,
+		ConsistentSnapshot: true,
+	}
+	newWriter := func() *o2m.File {
+		return o2m.NewWriter(ctx, s, "MARVIN", "CSV_RESUME", "MARVIN", "CSV_RESUME", "AL32UTF8", "",
+			"MARVIN/MARVIN.CSV_RESUME.1.csv", []string{"ID", "NAME"}, nil, cfg, newCSVWriteRows(t, 3000))
+	}
+
+	// 第 2 个分片完成后中断
+	errInterrupt := errors.New("interrupt")
+	var recorded []o2m.ManifestFile
+	_, err = newWriter().WithResume(nil, func(parts []o2m.ManifestFile) error {
+		recorded = append([]o2m.ManifestFile{}, parts...)
+		if len(parts) == 2 {
+			return errInterrupt
+		}
+		return nil
+	}).WriteFile()
+	if !errors.Is(err, errInterrupt) || len(recorded) != 2 {
+		t.Fatalf("expect interrupt after 2 parts, got %v, parts %v", err, recorded)
+	}
+	part1, err := s.ReadFile(ctx, "MARVIN/MARVIN.CSV_RESUME.1.1.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 模拟残留未完成分片
+	if err = s.WriteFile(ctx, "MARVIN/MARVIN.CSV_RESUME.1.3.csv", []byte("partial")); err != nil {
+		t.Fatal(err)
+	}
+
+	// 续传保留已完成分片，仅续写剩余行
+	files, err := newWriter().WithResume(recorded, nil).WriteFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 || files[0] != recorded[0] || files[1] != recorded[1] {
+		t.Fatalf("unexpected resume files: %v", files)
+	}
+	if data, _ := s.ReadFile(ctx, "MARVIN/MARVIN.CSV_RESUME.1.1.csv"); string(data) != string(part1) {
+		t.Fatal("finished part file should not be rewritten")
+	}
+	var totalRows int64
+	var content strings.Builder
+	for _, f := range files {
+		totalRows += f.Rows
+		data, err := s.ReadFile(ctx, "MARVIN/"+f.FileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content.Write(data)
+	}
+	if totalRows != 3000 || strings.Count(content.String(), "\n") != 3000 || !strings.HasPrefix(content.String(), "1,") {
+		t.Fatalf("unexpected resume rows [%d] lines [%d]", totalRows, strings.Count(content.String(), "\n"))
+	}
+	lines := strings.Split(strings.TrimSuffix(content.String(), "\n"), "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, strconv.Itoa(i+1)+",") {
+			t.Fatalf("line %d unexpected: %.20s", i+1, line)
+		}
+	}
+
+	// 未开启一致性快照读不跳过，整个 chunk 重新导出
+	cfg.ConsistentSnapshot = false
+	files, err = newWriter().WithResume(recorded, nil).WriteFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 || files[0].Rows+files[1].Rows+files[2].Rows != 3000 {
+		t.Fatalf("unexpected rewrite files: %v", files)
+	}
+}
@@ -0,0 +1,210 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/module/migrate/o2m"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newStandinMySQL(t testing.TB, standin *mysqlStandin) *mysql.MySQL {
+	db, err := sql.Open("mysql", standin.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+	return &mysql.MySQL{Ctx: context.Background(), MySQLDB: db}
+}
+
+func genBatchRows(rows int) []string {
+	var values []string
+	for i := 0; i < rows; i++ {
+		values = append(values, common.StringsBuilder("(", strconv.Itoa(i), ",'",
+			common.SpecialLettersUsingMySQL([]byte(fmt.Sprintf("name-%d, it's a \"row\"", i))), "',NULL,",
+			strconv.FormatFloat(float64(i)*1.5, 'f', -1, 64), ")"))
+	}
+	return values
+}
+
+func TestGenMySQLLoadDataRows(t *testing.T) {
+	batch := []string{"(1,'it\\'s\\,ok',NULL)", "(2,'a\\)\\,\\(b','NULL')"}
+	expect := "1,'it\\'s\\,ok',NULL\n2,'a\\)\\,\\(b','NULL'\n"
+	if got := o2m.GenMySQLLoadDataRows(batch); got != expect {
+		t.Fatalf("load data rows %q, expect %q", got, expect)
+	}
+	fields := splitLoadDataFields("1,'it\\'s\\,ok',NULL")
+	if !reflect.DeepEqual(fields, []string{"1", "it's,ok", "NULL"}) {
+		t.Fatalf("load data fields %q", fields)
+	}
+}
+
+func TestIsLoadDataTransactional(t *testing.T) {
+	for version, expect := range map[string]bool{
+		"8.0.32":                 true,
+		"5.7.25-log":             true,
+		"5.7.25-TiDB-v6.5.3":     false,
+		"5.7.25-TiDB-v7.0.0":     true,
+		"8.0.11-TiDB-v7.5.1-dev": true,
+		"5.7.25-TiDB-None":       false,
+	} {
+		if got := mysql.IsLoadDataTransactional(version); got != expect {
+			t.Fatalf("version [%s] load data transactional %v, expect %v", version, got, expect)
+		}
+	}
+}
+
+func TestLoadMySQLTableStandin(t *testing.T) {
+	server := newMySQLStandin(t)
+	db := newStandinMySQL(t, server)
+
+	columns := []string{"`ID`", "`NAME`", "`REMARK`", "`AMOUNT`"}
+	if err := db.LoadMySQLTable("MARVIN", "T1", columns, true, 100, strings.NewReader(o2m.GenMySQLLoadDataRows(genBatchRows(100)))); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.LoadMySQLTable("MARVIN", "T2", columns, false, 10, strings.NewReader(o2m.GenMySQLLoadDataRows(genBatchRows(10)))); err != nil {
+		t.Fatal(err)
+	}
+	// REPLACE 覆盖已存在数据行
+	if err := db.LoadMySQLTable("MARVIN", "T1", columns, true, 10, strings.NewReader(o2m.GenMySQLLoadDataRows(genBatchRows(10)))); err != nil {
+		t.Fatal(err)
+	}
+	if server.Rows() != 120 {
+		t.Fatalf("standin load rows %d, expect 120", server.Rows())
+	}
+	if !strings.Contains(server.loadStmts[0], "' REPLACE INTO TABLE MARVIN.T1 CHARACTER SET UTF8MB4 ") ||
+		!strings.Contains(server.loadStmts[1], "' IGNORE INTO TABLE MARVIN.T2 ") ||
+		!strings.HasSuffix(server.loadStmts[0], "(`ID`,`NAME`,`REMARK`,`AMOUNT`)") {
+		t.Fatalf("unexpected load data statements: %q", server.loadStmts)
+	}
+	fields := splitLoadDataFields(server.loadRows[1])
+	if !reflect.DeepEqual(fields, []string{"1", `name-1, it's a "row"`, "NULL", "1.5"}) {
+		t.Fatalf("unexpected load data fields: %q", fields)
+	}
+}
+
+func TestLoadMySQLTableRowsMismatch(t *testing.T) {
+	server := newMySQLStandin(t)
+	db := newStandinMySQL(t, server)
+	db.Charset = mysql.ParseMySQLCharset("charset=gbk,utf8mb4&parseTime=True")
+
+	columns := []string{"`ID`", "`NAME`"}
+	if err := db.LoadMySQLTable("MARVIN", "T1", columns, false, 2, strings.NewReader("1,'a'\n2,'b'\n")); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(server.loadStmts[0], " CHARACTER SET gbk ") {
+		t.Fatalf("load data charset should from connect-params: %s", server.loadStmts[0])
+	}
+
+	// IGNORE 冲突数据行以及多余字段截断仅为 warning，需报错并回滚整个 batch
+	for _, data := range []string{"2,'b'\n3,'c'\n", "3,'c'\n4,'d','x'\n"} {
+		err := db.LoadMySQLTable("MARVIN", "T1", columns, false, 2, strings.NewReader(data))
+		if !errors.Is(err, mysql.ErrLoadDataRowsMismatch) {
+			t.Fatalf("load data [%q] should return rows mismatch, got: %v", data, err)
+		}
+	}
+	if server.Rows() != 2 || len(server.loadStmts) != 1 {
+		t.Fatalf("mismatch load data should rollback, standin rows %d", server.Rows())
+	}
+}
+
+func TestApplyTableRowsLoadDataFallback(t *testing.T) {
+	ctx := context.Background()
+	server := newMySQLStandin(t)
+	db := newStandinMySQL(t, server)
+	metaDB := newSQLiteMeta(t)
+
+	// 源端 schema 区别于 metrics 用例，避免 apply error 指标计数冲突
+	syncMeta := meta.FullSyncMeta{
+		SchemaNameS: "STEVEN",
+		TableNameS:  "T1",
+		SchemaNameT: "MARVIN",
+		TableNameT:  "T1",
+		RowidInfoS:  "ROWID BETWEEN 'A' AND 'B'",
+	}
+	columns := []string{"`ID`", "`NAME`"}
+	policy := o2m.ApplyPolicy{SkipBadRows: true, LoadData: true}
+
+	// LOAD DATA IGNORE 冲突数据行被静默跳过，回退 INSERT 二分定位记录冲突数据行
+	chunk := o2m.NewChunk(ctx, syncMeta, nil, db, metaDB, columns,
+		[][]string{{"(1,'a')", "(1,'b')", "(2,'c')"}}, 1, 3, false, policy)
+	if err := chunk.ApplyTableRows(); err != nil {
+		t.Fatal(err)
+	}
+	if server.Rows() != 2 {
+		t.Fatalf("standin rows %d, expect 2", server.Rows())
+	}
+	errorRows, err := meta.NewFullSyncErrorRowModel(metaDB).DetailFullSyncErrorRow(ctx, &meta.FullSyncErrorRow{
+		DBTypeS:     common.TaskDBOracle,
+		DBTypeT:     common.TaskDBMySQL,
+		SchemaNameS: "STEVEN",
+		Mode:        common.FullO2MMode,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errorRows) != 1 || errorRows[0].RowInfo != "(1,'b')" {
+		t.Fatalf("unexpected error rows: %+v", errorRows)
+	}
+
+	// 未开启跳过错误行，回退 INSERT 报错
+	policy.SkipBadRows = false
+	chunk = o2m.NewChunk(ctx, syncMeta, nil, db, metaDB, columns,
+		[][]string{{"(3,'d')", "(4,'e','x')"}}, 1, 2, false, policy)
+	if err = chunk.ApplyTableRows(); err == nil || !strings.Contains(err.Error(), "1136") {
+		t.Fatalf("truncated row should fail, got: %v", err)
+	}
+	if server.Rows() != 2 {
+		t.Fatalf("standin rows %d, expect 2", server.Rows())
+	}
+}
+
+// go test ./tests/ -run none -bench ApplyTableRows -benchmem
+func benchmarkApplyTableRows(b *testing.B, loadData bool) {
+	server := newMySQLStandin(b)
+	db := newStandinMySQL(b, server)
+
+	const (
+		batchSize = 500
+		batches   = 20
+	)
+	columns := []string{"`ID`", "`NAME`", "`REMARK`", "`AMOUNT`"}
+	batch := genBatchRows(batchSize)
+	prefixSQL := o2m.GenMySQLInsertSQLStmtPrefix("MARVIN", "T1", columns, true)
+
+	b.ResetTimer()
+	startTime := time.Now()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < batches; j++ {
+			var err error
+			if loadData {
+				err = db.LoadMySQLTable("MARVIN", "T1", columns, true, batchSize, strings.NewReader(o2m.GenMySQLLoadDataRows(batch)))
+			} else {
+				err = db.WriteMySQLTable(common.StringsBuilder(prefixSQL, strings.Join(batch, ",")))
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(batchSize*batches*b.N)/time.Since(startTime).Seconds(), "rows/s")
+}
+
+func BenchmarkApplyTableRowsInsert(b *testing.B) {
+	benchmarkApplyTableRows(b, false)
+}
+
+func BenchmarkApplyTableRowsLoadData(b *testing.B) {
+	benchmarkApplyTableRows(b, true)
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/wentaojin/transferdb/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"testing"
+)
+
+func TestLoggerFields(t *testing.T) {
+	if fields := logger.Fields(context.Background()); len(fields) != 0 {
+		t.Fatalf("background context fields = %v, want empty", fields)
+	}
+
+	taskCtx := logger.WithFields(context.Background(), logger.TaskID("1"), logger.Mode("FullO2M"), logger.SchemaS("MARVIN"))
+	tableCtx := logger.WithFields(taskCtx, logger.TableS("T1"))
+	chunkCtx := logger.WithFields(tableCtx, logger.ChunkID(7))
+
+	if got := len(logger.Fields(taskCtx)); got != 3 {
+		t.Fatalf("task context fields = %d, want 3", got)
+	}
+	if got := len(logger.Fields(tableCtx)); got != 4 {
+		t.Fatalf("table context fields = %d, want 4", got)
+	}
+
+	// 子 context 追加字段不影响父 context
+	otherCtx := logger.WithFields(tableCtx, logger.ChunkID(8))
+	if got := len(logger.Fields(tableCtx)); got != 4 {
+		t.Fatalf("table context fields after child = %d, want 4", got)
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range logger.Fields(chunkCtx) {
+		f.AddTo(enc)
+	}
+	want := map[string]interface{}{
+		logger.FieldTaskID:  "1",
+		logger.FieldMode:    "FullO2M",
+		logger.FieldSchemaS: "MARVIN",
+		logger.FieldTableS:  "T1",
+		logger.FieldChunkID: uint64(7),
+	}
+	for k, v := range want {
+		if enc.Fields[k] != v {
+			t.Errorf("field [%s] = %v, want %v", k, enc.Fields[k], v)
+		}
+	}
+
+	enc = zapcore.NewMapObjectEncoder()
+	for _, f := range logger.Fields(otherCtx) {
+		f.AddTo(enc)
+	}
+	if enc.Fields[logger.FieldChunkID] != uint64(8) {
+		t.Errorf("sibling chunk_id = %v, want 8", enc.Fields[logger.FieldChunkID])
+	}
+}
+
+func TestLoggerJSONEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	core := zapcore.NewCore(logger.GetEncoder(logger.LogFormatJSON), zapcore.AddSync(&buf), zapcore.InfoLevel)
+	restore := zap.ReplaceGlobals(zap.New(core))
+	defer restore()
+
+	ctx := logger.WithFields(context.Background(), logger.Mode("CsvO2M"), logger.SchemaS("MARVIN"))
+	ctx = logger.WithFields(ctx, logger.TableS("T1"), logger.ChunkID(3))
+	logger.L(ctx).Info("chunk finished", logger.SCN(1024))
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log output isn't json: %v, output: %s", err, buf.String())
+	}
+	want := map[string]interface{}{
+		"level":             "INFO",
+		"msg":               "chunk finished",
+		logger.FieldMode:    "CsvO2M",
+		logger.FieldSchemaS: "MARVIN",
+		logger.FieldTableS:  "T1",
+		logger.FieldChunkID: float64(3),
+		logger.FieldSCN:     float64(1024),
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("json field [%s] = %v, want %v", k, entry[k], v)
+		}
+	}
+	if _, ok := entry["ts"]; !ok {
+		t.Errorf("json output missing ts field: %s", buf.String())
+	}
+}
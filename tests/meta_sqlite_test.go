@@ -0,0 +1,207 @@
+package tests
+
+import (
+	"context"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"path/filepath"
+	"testing"
+)
+
+func newSQLiteMeta(t *testing.T) *meta.Meta {
+	t.Helper()
+	metaDB, err := meta.NewMetaDBEngine(context.Background(), config.MySQLConfig{}, config.MetaConfig{
+		DBType:     common.MetaDBTypeSQLite,
+		SQLiteFile: filepath.Join(t.TempDir(), "meta", "transferdb.db"),
+	}, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = metaDB.MigrateTables(); err != nil {
+		t.Fatal(err)
+	}
+	if err = metaDB.InitDefaultValue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := metaDB.GormDB.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return metaDB
+}
+
+func TestSQLiteMetaMigrate(t *testing.T) {
+	ctx := context.Background()
+	metaDB := newSQLiteMeta(t)
+
+	// 重复初始化幂等
+	if err := metaDB.MigrateTables(); err != nil {
+		t.Fatal(err)
+	}
+	if err := metaDB.InitDefaultValue(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	defaultVals, err := meta.NewBuildinColumnDefaultvalModel(metaDB).DetailColumnDefaultVal(ctx, &meta.BuildinColumnDefaultval{
+		DBTypeS: common.TaskDBOracle,
+		DBTypeT: common.TaskDBMySQL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(defaultVals) != 2 {
+		t.Fatalf("buildin column default value records %d, expect 2", len(defaultVals))
+	}
+
+	if _, err = meta.NewMetaDBEngine(ctx, config.MySQLConfig{}, config.MetaConfig{DBType: "redis"}, 300); err == nil {
+		t.Fatal("unsupported meta db-type should failed")
+	}
+}
+
+func TestSQLiteMetaSyncMeta(t *testing.T) {
+	ctx := context.Background()
+	metaDB := newSQLiteMeta(t)
+
+	waitSyncMeta := &meta.WaitSyncMeta{
+		DBTypeS:        common.TaskDBOracle,
+		DBTypeT:        common.TaskDBMySQL,
+		SchemaNameS:    "MARVIN",
+		TableNameS:     "T1",
+		Mode:           common.FullO2MMode,
+		FullGlobalSCN:  0,
+		FullSplitTimes: -1,
+	}
+	if err := meta.NewWaitSyncMetaModel(metaDB).CreateWaitSyncMeta(ctx, waitSyncMeta); err != nil {
+		t.Fatal(err)
+	}
+
+	var fullSyncMetas []meta.FullSyncMeta
+	for _, rowid := range []string{"ROWID BETWEEN 'A' AND 'B'", "ROWID BETWEEN 'B' AND 'C'", "ROWID BETWEEN 'C' AND 'D'"} {
+		fullSyncMetas = append(fullSyncMetas, meta.FullSyncMeta{
+			DBTypeS:     common.TaskDBOracle,
+			DBTypeT:     common.TaskDBMySQL,
+			SchemaNameS: "MARVIN",
+			TableNameS:  "T1",
+			SchemaNameT: "MARVIN",
+			TableNameT:  "T1",
+			GlobalScnS:  100,
+			ColumnInfoS: "ID,NAME",
+			RowidInfoS:  rowid,
+			Mode:        common.FullO2MMode,
+		})
+	}
+	if err := meta.NewFullSyncMetaModel(metaDB).BatchCreateFullSyncMeta(ctx, fullSyncMetas, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// 小写条件与 MySQL 默认排序规则一致，大小写不敏感
+	detailS := &meta.FullSyncMeta{DBTypeS: common.TaskDBOracle, DBTypeT: common.TaskDBMySQL, SchemaNameS: "marvin", Mode: common.FullO2MMode}
+	counts, err := meta.NewFullSyncMetaModel(metaDB).CountsFullSyncMetaGroupByTableNameS(ctx, detailS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts["T1"] != 3 {
+		t.Fatalf("full sync meta counts %v, expect T1 3", counts)
+	}
+
+	if err = meta.NewFullSyncMetaModel(metaDB).DeleteFullSyncMetaBySchemaTableRowid(ctx, &fullSyncMetas[0]); err != nil {
+		t.Fatal(err)
+	}
+	tables, err := meta.NewFullSyncMetaModel(metaDB).DistinctFullSyncMetaByTableNameS(ctx, detailS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 1 || tables[0] != "T1" {
+		t.Fatalf("distinct full sync meta tables %v", tables)
+	}
+	chunks, err := meta.NewFullSyncMetaModel(metaDB).DetailFullSyncMeta(ctx, &meta.FullSyncMeta{SchemaNameS: "MARVIN", TableNameS: "T1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 || chunks[0].CreatedAt == "" {
+		t.Fatalf("detail full sync meta %v", chunks)
+	}
+
+	waitSyncMeta.FullGlobalSCN = 100
+	waitSyncMeta.FullSplitTimes = 3
+	if err = meta.NewWaitSyncMetaModel(metaDB).UpdateWaitSyncMeta(ctx, waitSyncMeta); err != nil {
+		t.Fatal(err)
+	}
+	waits, err := meta.NewWaitSyncMetaModel(metaDB).BatchQueryWaitSyncMeta(ctx, waitSyncMeta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(waits) != 1 || waits[0].FullSplitTimes != 3 {
+		t.Fatalf("batch query wait sync meta %v", waits)
+	}
+
+	// 事务回滚后连接释放，后续访问不阻塞
+	if err = meta.NewCommonModel(metaDB).CreateFullSyncMetaAndUpdateWaitSyncMeta(ctx, &fullSyncMetas[1], waitSyncMeta); err == nil {
+		t.Fatal("duplicate full sync meta should failed")
+	}
+	fullSyncMetas[0].ID = 0
+	if err = meta.NewCommonModel(metaDB).CreateFullSyncMetaAndUpdateWaitSyncMeta(ctx, &fullSyncMetas[0], waitSyncMeta); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = meta.NewWaitSyncMetaModel(metaDB).DeleteWaitSyncMeta(ctx, waitSyncMeta); err != nil {
+		t.Fatal(err)
+	}
+	waits, err = meta.NewWaitSyncMetaModel(metaDB).DetailWaitSyncMeta(ctx, &meta.WaitSyncMeta{SchemaNameS: "MARVIN"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(waits) != 0 {
+		t.Fatalf("wait sync meta %v should deleted", waits)
+	}
+}
+
+func TestSQLiteMetaCompareAndErrorRow(t *testing.T) {
+	ctx := context.Background()
+	metaDB := newSQLiteMeta(t)
+
+	compareMetas := []meta.DataCompareMeta{
+		{DBTypeS: common.TaskDBOracle, DBTypeT: common.TaskDBMySQL, SchemaNameS: "MARVIN", TableNameS: "T1", SchemaNameT: "MARVIN", TableNameT: "T1", WhereRange: "1 = 1"},
+		{DBTypeS: common.TaskDBOracle, DBTypeT: common.TaskDBMySQL, SchemaNameS: "MARVIN", TableNameS: "T2", SchemaNameT: "MARVIN", TableNameT: "T2", WhereRange: "1 = 1"},
+	}
+	if err := meta.NewDataCompareMetaModel(metaDB).BatchCreateDataCompareMeta(ctx, compareMetas, 10); err != nil {
+		t.Fatal(err)
+	}
+	tables, err := meta.NewDataCompareMetaModel(metaDB).DistinctDataCompareMetaTableNameS(ctx, &compareMetas[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 {
+		t.Fatalf("distinct data compare meta tables %v", tables)
+	}
+	if err = meta.NewDataCompareMetaModel(metaDB).TruncateDataCompareMeta(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if tables, err = meta.NewDataCompareMetaModel(metaDB).DistinctDataCompareMetaTableNameS(ctx, &compareMetas[0]); err != nil || len(tables) != 0 {
+		t.Fatalf("data compare meta %v should truncated: %v", tables, err)
+	}
+
+	errorRow := &meta.FullSyncErrorRow{
+		DBTypeS:     common.TaskDBOracle,
+		DBTypeT:     common.TaskDBMySQL,
+		SchemaNameS: "MARVIN",
+		TableNameS:  "T1",
+		SchemaNameT: "MARVIN",
+		TableNameT:  "T1",
+		Mode:        common.FullO2MMode,
+		RowInfo:     "(1,'a')",
+		ErrorDetail: "Error 1062: Duplicate entry",
+	}
+	if err = meta.NewFullSyncErrorRowModel(metaDB).CreateFullSyncErrorRow(ctx, errorRow); err != nil {
+		t.Fatal(err)
+	}
+	counts, err := meta.NewFullSyncErrorRowModel(metaDB).CountsFullSyncErrorRowBySchema(ctx, errorRow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts != 1 {
+		t.Fatalf("full sync error row counts %d, expect 1", counts)
+	}
+}
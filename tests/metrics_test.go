@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/metrics"
+	"github.com/wentaojin/transferdb/signal"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMySQLErrorCode(t *testing.T) {
+	cases := []struct {
+		err  error
+		code string
+	}{
+		{nil, ""},
+		{fmt.Errorf("Error 1062: Duplicate entry '1' for key 'PRIMARY'"), "1062"},
+		{fmt.Errorf("Error 1213 (40001): Deadlock found when trying to get lock"), "1213"},
+		{fmt.Errorf("single increment table [T1] exec falied: %v", fmt.Errorf("Error 9007: Write conflict")), "9007"},
+		{fmt.Errorf("invalid connection"), "unknown"},
+	}
+	for _, c := range cases {
+		if code := metrics.MySQLErrorCode(c.err); code != c.code {
+			t.Fatalf("error [%v] code [%s], want [%s]", c.err, code, c.code)
+		}
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	metrics.AddRows(common.FullO2MMode, "marvin", "t_metrics", metrics.DirectionRead, 100, 2048)
+	metrics.AddRows(common.FullO2MMode, "marvin", "t_metrics", metrics.DirectionWrite, 100, 2048)
+	metrics.ObserveChunk(common.FullO2MMode, "marvin", metrics.StageApply, 200*time.Millisecond)
+	metrics.IncApplyError(common.FullO2MMode, "marvin", fmt.Errorf("Error 1062: Duplicate entry"))
+	metrics.IncApplyError(common.FullO2MMode, "marvin", nil)
+	metrics.ObserveLogminerQuery("marvin", time.Second)
+	metrics.SetIncrLag("marvin", "t_metrics", 1000, 400)
+	metrics.SetIncrLag("marvin", "t_metrics_ahead", 1000, 1200)
+	metrics.SetIncrWorkerQueueDepth("marvin", "t_metrics", 7)
+	metrics.AddCompareMismatch("marvin", "t_metrics", 3, 1)
+	metrics.ObserveTask(common.CSVO2MMode, "marvin", time.Minute, signal.ErrGracefulStop)
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("metrics status [%d]", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`transferdb_rows_total{direction="read",mode="FullO2M",schema="MARVIN",table="T_METRICS"} 100`,
+		`transferdb_bytes_total{direction="write",mode="FullO2M",schema="MARVIN",table="T_METRICS"} 2048`,
+		`transferdb_chunk_duration_seconds_count{mode="FullO2M",schema="MARVIN",stage="apply"} 1`,
+		`transferdb_apply_errors_total{code="1062",mode="FullO2M",schema="MARVIN"} 1`,
+		`transferdb_logminer_query_duration_seconds_count{schema="MARVIN"} 1`,
+		`transferdb_incr_lag_scn{schema="MARVIN",table="T_METRICS"} 600`,
+		`transferdb_incr_lag_scn{schema="MARVIN",table="T_METRICS_AHEAD"} 0`,
+		`transferdb_incr_worker_queue_depth{schema="MARVIN",table="T_METRICS"} 7`,
+		`transferdb_compare_mismatch_chunks_total{schema="MARVIN",table="T_METRICS"} 1`,
+		`transferdb_compare_mismatch_rows_total{kind="source_more",schema="MARVIN",table="T_METRICS"} 3`,
+		`transferdb_task_runs_total{mode="CsvO2M",schema="MARVIN",status="canceled"} 1`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("metrics output missing [%s]", want)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	_ "github.com/pingcap/tidb/types/parser_driver"
+	"github.com/wentaojin/transferdb/common"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// MySQL 协议替身服务，支持握手、COM_PING、COM_QUERY INSERT/REPLACE、LOAD DATA LOCAL INFILE、事务以及 SHOW WARNINGS
+//   - INSERT/REPLACE 语句使用 TiDB parser 解析，模拟服务端 SQL 解析开销
+//   - LOAD DATA 数据按行、字段拆分
+//   - 首个字段视为主键：INSERT 冲突报错，REPLACE 覆盖，LOAD DATA IGNORE 冲突以及字段数不一致同 LOCAL 模式降级为 warning
+type mysqlStandin struct {
+	ln net.Listener
+
+	mu        sync.Mutex
+	rows      int
+	keys      map[string]bool
+	loadRows  []string
+	loadStmts []string
+}
+
+// 单条语句写入，事务内暂存至 COMMIT
+type standinWrite struct {
+	rows      int
+	keys      []string
+	loadStmt  string
+	loadLines []string
+}
+
+func newMySQLStandin(t testing.TB) *mysqlStandin {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &mysqlStandin{ln: ln, keys: make(map[string]bool)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() {
+		ln.Close()
+	})
+	return s
+}
+
+func (s *mysqlStandin) DSN() string {
+	return fmt.Sprintf("root:@tcp(%s)/marvin", s.ln.Addr().String())
+}
+
+func (s *mysqlStandin) Rows() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rows
+}
+
+type standinConn struct {
+	r   *bufio.Reader
+	w   net.Conn
+	seq byte
+
+	inTxn    bool
+	pending  []standinWrite
+	txnKeys  map[string]bool
+	warnings []standinWarning
+}
+
+type standinWarning struct {
+	code    uint16
+	message string
+}
+
+func (c *standinConn) readPacket() ([]byte, error) {
+	var payload []byte
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(c.r, header[:]); err != nil {
+			return nil, err
+		}
+		size := int(uint32(header[0]) | uint32(header[1])<<8 | uint32(header[2])<<16)
+		c.seq = header[3] + 1
+		data := make([]byte, size)
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			return nil, err
+		}
+		payload = append(payload, data...)
+		if size < 0xffffff {
+			return payload, nil
+		}
+	}
+}
+
+func (c *standinConn) writePacket(payload []byte) error {
+	header := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), c.seq}
+	c.seq++
+	_, err := c.w.Write(append(header, payload...))
+	return err
+}
+
+func (c *standinConn) writeOK(affectedRows int) error {
+	payload := []byte{0x00}
+	payload = appendLengthEncodedInt(payload, uint64(affectedRows))
+	payload = append(payload, 0x00, 0x02, 0x00, 0x00, 0x00)
+	return c.writePacket(payload)
+}
+
+func (c *standinConn) writeError(code uint16, msg string) error {
+	payload := []byte{0xff, byte(code), byte(code >> 8), '#'}
+	payload = append(payload, []byte("HY000")...)
+	payload = append(payload, []byte(msg)...)
+	return c.writePacket(payload)
+}
+
+func appendLengthEncodedString(b []byte, v string) []byte {
+	return append(appendLengthEncodedInt(b, uint64(len(v))), v...)
+}
+
+// SHOW WARNINGS 结果集（text protocol，未开启 CLIENT_DEPRECATE_EOF）
+func (c *standinConn) writeWarnings() error {
+	columns := []string{"Level", "Code", "Message"}
+	if err := c.writePacket(appendLengthEncodedInt(nil, uint64(len(columns)))); err != nil {
+		return err
+	}
+	for _, name := range columns {
+		var def []byte
+		for _, v := range []string{"def", "", "", "", name, name} {
+			def = appendLengthEncodedString(def, v)
+		}
+		def = append(def, 0x0c, 33, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfd, 0x00, 0x00, 0x00, 0x00, 0x00)
+		if err := c.writePacket(def); err != nil {
+			return err
+		}
+	}
+	eof := []byte{0xfe, 0x00, 0x00, 0x02, 0x00}
+	if err := c.writePacket(eof); err != nil {
+		return err
+	}
+	for _, w := range c.warnings {
+		row := appendLengthEncodedString(nil, "Warning")
+		row = appendLengthEncodedString(row, strconv.Itoa(int(w.code)))
+		row = appendLengthEncodedString(row, w.message)
+		if err := c.writePacket(row); err != nil {
+			return err
+		}
+	}
+	return c.writePacket(eof)
+}
+
+func appendLengthEncodedInt(b []byte, n uint64) []byte {
+	switch {
+	case n < 251:
+		return append(b, byte(n))
+	case n < 1<<16:
+		return append(b, 0xfc, byte(n), byte(n>>8))
+	case n < 1<<24:
+		return append(b, 0xfd, byte(n), byte(n>>8), byte(n>>16))
+	default:
+		b = append(b, 0xfe)
+		return binary.LittleEndian.AppendUint64(b, n)
+	}
+}
+
+func (s *mysqlStandin) serve(conn net.Conn) {
+	defer conn.Close()
+	c := &standinConn{r: bufio.NewReaderSize(conn, 64*1024), w: conn}
+
+	// Handshake V10
+	var capabilities uint32 = 0x00000001 | 0x00000004 | 0x00000008 | 0x00000080 | 0x00000200 | 0x00002000 | 0x00008000 | 0x00020000 | 0x00080000
+	handshake := []byte{0x0a}
+	handshake = append(handshake, []byte("5.7.25-transferdb-standin")...)
+	handshake = append(handshake, 0x00, 0x01, 0x00, 0x00, 0x00)
+	handshake = append(handshake, []byte("abcdefgh")...)
+	handshake = append(handshake, 0x00, byte(capabilities), byte(capabilities>>8), 45, 0x02, 0x00,
+		byte(capabilities>>16), byte(capabilities>>24), 21)
+	handshake = append(handshake, make([]byte, 10)...)
+	handshake = append(handshake, []byte("ijklmnopqrst")...)
+	handshake = append(handshake, 0x00)
+	handshake = append(handshake, []byte("mysql_native_password")...)
+	handshake = append(handshake, 0x00)
+	if err := c.writePacket(handshake); err != nil {
+		return
+	}
+	if _, err := c.readPacket(); err != nil {
+		return
+	}
+	if err := c.writeOK(0); err != nil {
+		return
+	}
+
+	p := parser.New()
+	for {
+		packet, err := c.readPacket()
+		if err != nil || len(packet) == 0 {
+			return
+		}
+		switch packet[0] {
+		case 0x01:
+			return
+		case 0x0e:
+			err = c.writeOK(0)
+		case 0x03:
+			err = s.handleQuery(c, p, string(packet[1:]))
+		default:
+			err = c.writeError(1047, "unknown command")
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *mysqlStandin) handleQuery(c *standinConn, p *parser.Parser, query string) error {
+	switch strings.ToUpper(strings.TrimSpace(query)) {
+	case "START TRANSACTION", "BEGIN":
+		c.inTxn, c.pending, c.txnKeys = true, nil, make(map[string]bool)
+		return c.writeOK(0)
+	case "COMMIT":
+		for _, w := range c.pending {
+			s.commit(w)
+		}
+		c.inTxn, c.pending, c.txnKeys = false, nil, nil
+		return c.writeOK(0)
+	case "ROLLBACK":
+		c.inTxn, c.pending, c.txnKeys = false, nil, nil
+		return c.writeOK(0)
+	case "SHOW WARNINGS":
+		return c.writeWarnings()
+	}
+	c.warnings = nil
+
+	if strings.HasPrefix(query, "LOAD DATA LOCAL INFILE") {
+		fileName := query[strings.Index(query, "'")+1:]
+		fileName = fileName[:strings.Index(fileName, "'")]
+		if err := c.writePacket(append([]byte{0xfb}, []byte(fileName)...)); err != nil {
+			return err
+		}
+		var data bytes.Buffer
+		for {
+			packet, err := c.readPacket()
+			if err != nil {
+				return err
+			}
+			if len(packet) == 0 {
+				break
+			}
+			data.Write(packet)
+		}
+
+		table := query[strings.Index(query, " INTO TABLE ")+len(" INTO TABLE "):]
+		table = table[:strings.Index(table, " ")]
+		columns := strings.Count(query[strings.LastIndex(query, "("):], ",") + 1
+		replace := strings.Contains(query, "' REPLACE INTO TABLE ")
+
+		var (
+			w        standinWrite
+			affected int
+		)
+		stmtKeys := make(map[string]bool)
+		for i, line := range strings.Split(strings.TrimSuffix(data.String(), "\n"), "\n") {
+			if line == "" {
+				continue
+			}
+			fields := splitLoadDataFields(line)
+			if len(fields) > columns {
+				c.warnings = append(c.warnings, standinWarning{1262, fmt.Sprintf("Row %d was truncated; it contained more data than there were input columns", i+1)})
+			} else if len(fields) < columns {
+				c.warnings = append(c.warnings, standinWarning{1261, fmt.Sprintf("Row %d doesn't contain data for all columns", i+1)})
+			}
+			key := common.StringsBuilder(table, "/", fields[0])
+			if s.exists(c, key) || stmtKeys[key] {
+				if !replace {
+					c.warnings = append(c.warnings, standinWarning{1062, fmt.Sprintf("Duplicate entry '%s' for key 'PRIMARY'", fields[0])})
+					continue
+				}
+				affected += 2
+			} else {
+				affected++
+				stmtKeys[key] = true
+				w.keys = append(w.keys, key)
+			}
+			w.rows++
+			w.loadLines = append(w.loadLines, line)
+		}
+		w.loadStmt = query
+		s.write(c, w)
+		return c.writeOK(affected)
+	}
+
+	stmt, err := p.ParseOneStmt(query, "", "")
+	if err != nil {
+		return c.writeError(1064, err.Error())
+	}
+	insert, ok := stmt.(*ast.InsertStmt)
+	if !ok {
+		return c.writeOK(0)
+	}
+	tableName := insert.Table.TableRefs.Left.(*ast.TableSource).Source.(*ast.TableName)
+	table := common.StringsBuilder(tableName.Schema.O, ".", tableName.Name.O)
+
+	var (
+		w        standinWrite
+		affected int
+	)
+	stmtKeys := make(map[string]bool)
+	for i, list := range insert.Lists {
+		if len(insert.Columns) > 0 && len(list) != len(insert.Columns) {
+			return c.writeError(1136, fmt.Sprintf("Column count doesn't match value count at row %d", i+1))
+		}
+		w.rows++
+		value, ok := list[0].(ast.ValueExpr)
+		if !ok {
+			affected++
+			continue
+		}
+		key := common.StringsBuilder(table, "/", fmt.Sprint(value.GetValue()))
+		if s.exists(c, key) || stmtKeys[key] {
+			if !insert.IsReplace {
+				return c.writeError(1062, fmt.Sprintf("Duplicate entry '%v' for key 'PRIMARY'", value.GetValue()))
+			}
+			affected += 2
+			continue
+		}
+		affected++
+		stmtKeys[key] = true
+		w.keys = append(w.keys, key)
+	}
+	s.write(c, w)
+	return c.writeOK(affected)
+}
+
+func (s *mysqlStandin) exists(c *standinConn, key string) bool {
+	if c.txnKeys[key] {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys[key]
+}
+
+func (s *mysqlStandin) write(c *standinConn, w standinWrite) {
+	if !c.inTxn {
+		s.commit(w)
+		return
+	}
+	c.pending = append(c.pending, w)
+	for _, k := range w.keys {
+		c.txnKeys[k] = true
+	}
+}
+
+func (s *mysqlStandin) commit(w standinWrite) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rows += w.rows
+	for _, k := range w.keys {
+		s.keys[k] = true
+	}
+	if w.loadStmt != "" {
+		s.loadStmts = append(s.loadStmts, w.loadStmt)
+		s.loadRows = append(s.loadRows, w.loadLines...)
+	}
+}
+
+// 按 ENCLOSED BY '\” ESCAPED BY '\\' 拆分字段
+func splitLoadDataFields(line string) []string {
+	var (
+		fields  []string
+		field   strings.Builder
+		inQuote bool
+		escaped bool
+	)
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case escaped:
+			field.WriteByte(ch)
+			escaped = false
+		case ch == '\\':
+			escaped = true
+		case ch == '\'':
+			inQuote = !inQuote
+		case ch == ',' && !inQuote:
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteByte(ch)
+		}
+	}
+	return append(fields, field.String())
+}
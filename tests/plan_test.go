@@ -0,0 +1,190 @@
+package tests
+
+import (
+	"context"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/module/migrate/o2m"
+	"github.com/wentaojin/transferdb/module/plan"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanTableState(t *testing.T) {
+	cases := []struct {
+		metas      []meta.WaitSyncMeta
+		checkpoint bool
+		want       string
+	}{
+		{nil, true, plan.TableStateInit},
+		{[]meta.WaitSyncMeta{{FullSplitTimes: 3}}, false, plan.TableStateInit},
+		{[]meta.WaitSyncMeta{{FullSplitTimes: -1}}, true, plan.TableStateWait},
+		{[]meta.WaitSyncMeta{{FullSplitTimes: 3}}, true, plan.TableStateResume},
+		{[]meta.WaitSyncMeta{{FullSplitTimes: 0}}, true, plan.TableStateFinished},
+	}
+	for i, c := range cases {
+		if got := plan.TableState(c.metas, c.checkpoint); got != c.want {
+			t.Fatalf("case %d: table state [%s], want [%s]", i, got, c.want)
+		}
+	}
+}
+
+func TestPlanEstimateChunks(t *testing.T) {
+	cases := [][3]int{
+		{0, 100, 1},
+		{100, 0, 1},
+		{100, 100, 1},
+		{101, 100, 2},
+		{1000000, 10000, 100},
+	}
+	for _, c := range cases {
+		if got := plan.EstimateChunks(c[0], c[1]); got != c[2] {
+			t.Fatalf("estimate chunks rows [%d] chunk size [%d]: got [%d], want [%d]", c[0], c[1], got, c[2])
+		}
+	}
+}
+
+func TestPlanString(t *testing.T) {
+	p := plan.NewPlan(common.FullO2MMode, "MARVIN", "STEVEN")
+	p.AddCheck("oracle db version", "19.3.0.0.0")
+	p.AddAction("truncate target table [STEVEN.T1]")
+	p.AddTable(&plan.Table{
+		TableNameS:      "T1",
+		TableNameT:      "T1_NEW",
+		State:           plan.TableStateInit,
+		Truncate:        true,
+		StatisticsRows:  2500,
+		ChunkSize:       1000,
+		Chunks:          3,
+		SampleChunk:     "ROWID BETWEEN '<start_rowid>' AND '<end_rowid>'",
+		SampleSourceSQL: "SELECT ID FROM MARVIN.T1 WHERE ROWID BETWEEN '<start_rowid>' AND '<end_rowid>'",
+		SampleTargetSQL: "REPLACE INTO STEVEN.T1_NEW (ID) VALUES (?)",
+	})
+	p.AddTable(&plan.Table{
+		TableNameS:     "T2",
+		TableNameT:     "T2",
+		State:          plan.TableStateFinished,
+		StatisticsRows: -1,
+		ChunkSize:      -1,
+		Chunks:         5,
+	})
+
+	out := p.String()
+	for _, want := range []string{
+		"mode [FullO2M] schema [MARVIN] -> [STEVEN], nothing has been changed",
+		"19.3.0.0.0",
+		"  - truncate target table [STEVEN.T1]",
+		"MARVIN.T1",
+		"STEVEN.T1_NEW",
+		"RUN 1",
+		"sample chunk [MARVIN.T1] [ROWID BETWEEN '<start_rowid>' AND '<end_rowid>']:",
+		"  source: SELECT ID FROM MARVIN.T1",
+		"  target: REPLACE INTO STEVEN.T1_NEW",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("plan output missing [%s]:\n%s", want, out)
+		}
+	}
+	// 已完成表不计入 chunk 总数，且不输出样例
+	if strings.Contains(out, "sample chunk [MARVIN.T2]") {
+		t.Fatalf("finished table should not print sample chunk:\n%s", out)
+	}
+}
+
+func TestPlanSQLiteMetaReadOnly(t *testing.T) {
+	ctx := context.Background()
+	dbFile := filepath.Join(t.TempDir(), "meta", "transferdb.db")
+
+	// 只读打开不存在的元数据库文件报错，且不创建文件
+	if _, err := meta.NewMetaDBEngine(ctx, config.MySQLConfig{}, config.MetaConfig{
+		DBType:     common.MetaDBTypeSQLite,
+		SQLiteFile: dbFile,
+		ReadOnly:   true,
+	}, 300); err == nil {
+		t.Fatal("read only sqlite meta should fail when file not exist")
+	}
+
+	metaDB, err := meta.NewMetaDBEngine(ctx, config.MySQLConfig{}, config.MetaConfig{
+		DBType:     common.MetaDBTypeSQLite,
+		SQLiteFile: dbFile,
+	}, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = metaDB.MigrateTables(); err != nil {
+		t.Fatal(err)
+	}
+	if err = meta.NewWaitSyncMetaModel(metaDB).CreateWaitSyncMeta(ctx, &meta.WaitSyncMeta{
+		DBTypeS:        common.TaskDBOracle,
+		DBTypeT:        common.TaskDBMySQL,
+		SchemaNameS:    "MARVIN",
+		TableNameS:     "T1",
+		Mode:           common.FullO2MMode,
+		FullSplitTimes: 2,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if sqlDB, err := metaDB.GormDB.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+
+	roDB, err := meta.NewMetaDBEngine(ctx, config.MySQLConfig{}, config.MetaConfig{
+		DBType:     common.MetaDBTypeSQLite,
+		SQLiteFile: dbFile,
+		ReadOnly:   true,
+	}, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := roDB.GormDB.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+
+	waitSyncMetas, err := meta.NewWaitSyncMetaModel(roDB).DetailWaitSyncMeta(ctx, &meta.WaitSyncMeta{
+		DBTypeS:     common.TaskDBOracle,
+		DBTypeT:     common.TaskDBMySQL,
+		SchemaNameS: "MARVIN",
+		TableNameS:  "T1",
+		Mode:        common.FullO2MMode,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := plan.TableState(waitSyncMetas, true); got != plan.TableStateResume {
+		t.Fatalf("table state [%s], want [%s]", got, plan.TableStateResume)
+	}
+
+	if err = meta.NewWaitSyncMetaModel(roDB).DeleteWaitSyncMeta(ctx, &meta.WaitSyncMeta{
+		DBTypeS:     common.TaskDBOracle,
+		DBTypeT:     common.TaskDBMySQL,
+		SchemaNameS: "MARVIN",
+		TableNameS:  "T1",
+		Mode:        common.FullO2MMode,
+	}); err == nil {
+		t.Fatal("read only sqlite meta should reject write")
+	}
+}
+
+func TestPlanSampleChunkSQL(t *testing.T) {
+	sourceSQL := o2m.GenOracleTableChunkSQL(meta.FullSyncMeta{
+		SchemaNameS: "MARVIN",
+		TableNameS:  "T1",
+		ColumnInfoS: "ID,NAME",
+		GlobalScnS:  1024,
+		RowidInfoS:  "1 = 1",
+	}, true, "ID > 10")
+	if want := "SELECT ID,NAME FROM MARVIN.T1 AS OF SCN 1024 WHERE (1 = 1) AND (ID > 10)"; sourceSQL != want {
+		t.Fatalf("source chunk sql [%s], want [%s]", sourceSQL, want)
+	}
+
+	targetSQL := mysql.GenMySQLLoadDataSQL("STEVEN", "T1", []string{"ID", "NAME"}, true, "utf8mb4", "transferdb_0")
+	if !strings.HasPrefix(targetSQL, "LOAD DATA LOCAL INFILE 'Reader::transferdb_0' REPLACE INTO TABLE STEVEN.T1") ||
+		!strings.HasSuffix(targetSQL, "(ID,NAME)") {
+		t.Fatalf("target load data sql [%s]", targetSQL)
+	}
+}
@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/metrics"
+	"github.com/wentaojin/transferdb/module/migrate/o2m"
+	"github.com/wentaojin/transferdb/progress"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCountBatchRows(t *testing.T) {
+	batch := genBatchRows(100)
+	rows, size := o2m.CountBatchRows([][]string{batch, batch, genBatchRows(7)})
+	if rows != 207 {
+		t.Fatalf("count batch rows %d, expect 207", rows)
+	}
+	if size != int64(2*len(strings.Join(batch, ","))+len(strings.Join(genBatchRows(7), ","))) {
+		t.Fatalf("count batch bytes %d", size)
+	}
+	if rows, size = o2m.CountBatchRows(nil); rows != 0 || size != 0 {
+		t.Fatalf("count empty batch rows %d bytes %d", rows, size)
+	}
+}
+
+func TestProgressTracker(t *testing.T) {
+	tracker := progress.NewTracker(context.Background(), nil, common.TaskDBOracle, common.TaskDBMySQL, "marvin", common.FullO2MMode)
+
+	waitSyncMetas := []meta.WaitSyncMeta{
+		{TableNameS: "T1", FullSplitTimes: 10},
+		{TableNameS: "T2", FullSplitTimes: -1},
+		{TableNameS: "T3", FullSplitTimes: 0},
+		{TableNameS: "T4", FullSplitTimes: 4},
+	}
+	// 断点续传，T1 已完成 2 个 chunk
+	tracker.UpdateChunks(waitSyncMetas, map[string]int{"T1": 8, "T4": 4})
+
+	time.Sleep(20 * time.Millisecond)
+	tracker.AddRows("t1", 1000, 64000)
+	tracker.AddRows("T4", 500, 1000)
+	tracker.UpdateChunks(waitSyncMetas, map[string]int{"T1": 4, "T4": 3})
+
+	p := tracker.Snapshot()
+	if p.TablesTotal != 4 || p.TablesDone != 1 || p.ChunksTotal != 14 || p.ChunksDone != 7 {
+		t.Fatalf("unexpected progress: %s", p)
+	}
+	if p.Rows != 1500 || p.Bytes != 65000 || p.RowsPerSec <= 0 || p.ETASeconds <= 0 {
+		t.Fatalf("unexpected progress throughput: %s", p)
+	}
+	if len(p.SlowestTables) != 2 {
+		t.Fatalf("unexpected slowest tables: %v", p.SlowestTables)
+	}
+
+	status := make(map[string]string)
+	for _, tp := range p.Tables {
+		status[tp.TableName] = tp.Status
+	}
+	if status["T1"] != progress.TableStatusRunning || status["T2"] != progress.TableStatusWaiting ||
+		status["T3"] != progress.TableStatusFinished || status["T4"] != progress.TableStatusRunning {
+		t.Fatalf("unexpected table status: %v", status)
+	}
+
+	// T1 完成，chunk 总数保留
+	waitSyncMetas[0].FullSplitTimes = 0
+	tracker.UpdateChunks(waitSyncMetas, map[string]int{"T4": 3})
+	p = tracker.Snapshot()
+	if p.TablesDone != 2 || p.ChunksDone != 11 {
+		t.Fatalf("unexpected progress after table finished: %s", p)
+	}
+	if !strings.Contains(p.String(), "tables 2/4, chunks 11/14") {
+		t.Fatalf("unexpected progress summary: %s", p)
+	}
+
+	// nil 跟踪器不做记录
+	var n *progress.Tracker
+	n.AddRows("T1", 1, 1)
+	n.UpdateChunks(waitSyncMetas, nil)
+	n.Run(time.Second)()
+}
+
+func TestProgressMetrics(t *testing.T) {
+	ctx := context.Background()
+	metaDB := newSQLiteMeta(t)
+	if err := meta.NewWaitSyncMetaModel(metaDB).CreateWaitSyncMeta(ctx, &meta.WaitSyncMeta{
+		DBTypeS:        common.TaskDBOracle,
+		DBTypeT:        common.TaskDBMySQL,
+		SchemaNameS:    "STEVEN",
+		TableNameS:     "T1",
+		Mode:           common.CSVO2MMode,
+		FullSplitTimes: 4,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 相同 mode 以及 schema 的重复任务不影响指标采集
+	var stops []func()
+	for i := 0; i < 2; i++ {
+		tracker := progress.NewTracker(ctx, metaDB, common.TaskDBOracle, common.TaskDBMySQL, "steven", common.CSVO2MMode)
+		if err := tracker.Refresh(); err != nil {
+			t.Fatal(err)
+		}
+		tracker.AddRows("T1", 500, 4096)
+		stops = append(stops, tracker.Run(time.Hour))
+	}
+	defer func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}()
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("metrics status [%d], body: %s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`transferdb_progress_chunks{mode="` + common.CSVO2MMode + `",schema="STEVEN"} 4`,
+		`transferdb_progress_table_chunks{mode="` + common.CSVO2MMode + `",schema="STEVEN",table="T1"}`,
+		`# TYPE transferdb_progress_table_rows_per_second gauge`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("metrics output missing [%s]:\n%s", want, body)
+		}
+	}
+	// 行数以及字节数累计值复用 transferdb_rows_total/transferdb_bytes_total
+	for _, dup := range []string{"transferdb_progress_rows_total", "transferdb_progress_table_bytes_total"} {
+		if strings.Contains(body, dup) {
+			t.Fatalf("metrics output shouldn't contain [%s]", dup)
+		}
+	}
+}
+
+func TestProgressJSONHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	progress.JSONHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/progress", nil))
+	var progresses []progress.Progress
+	if err := json.Unmarshal(rec.Body.Bytes(), &progresses); err != nil {
+		t.Fatalf("unmarshal progress json failed: %v, body: %s", err, rec.Body.String())
+	}
+	if len(progresses) != 0 {
+		t.Fatalf("unexpected running progresses: %v", progresses)
+	}
+}
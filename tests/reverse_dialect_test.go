@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/reverse"
+	reverseO2M "github.com/wentaojin/transferdb/module/reverse/o2m"
+	"testing"
+)
+
+func TestReverseNewDialect(t *testing.T) {
+	for dbType, want := range map[string]string{
+		"mysql":      common.TaskDBMySQL,
+		"TiDB":       common.TaskDBMySQL,
+		"postgresql": common.TaskDBPostgres,
+	} {
+		d, err := reverse.NewDialect(dbType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.DBTypeT() != want {
+			t.Fatalf("dialect [%s] db type [%s], want [%s]", dbType, d.DBTypeT(), want)
+		}
+	}
+	if _, err := reverse.NewDialect("sqlserver"); err == nil {
+		t.Fatal("unsupported dialect without error")
+	}
+}
+
+func TestReverseMySQLDialect(t *testing.T) {
+	d := &reverse.MySQLDialect{}
+	cases := [][2]string{
+		{d.QuoteIdentifier("T1"), "`T1`"},
+		{d.GenNumberDatatype(2, 0), "TINYINT"},
+		{d.GenNumberDatatype(4, 0), "SMALLINT"},
+		{d.GenNumberDatatype(8, 0), "INT"},
+		{d.GenNumberDatatype(18, 0), "BIGINT"},
+		{d.GenNumberDatatype(20, 0), "DECIMAL(20)"},
+		{d.GenNumberDatatype(38, 127), "DECIMAL(65,30)"},
+		{d.GenNumberDatatype(10, 2), "DECIMAL(10,2)"},
+		{d.GenColumnDatatype("VARCHAR", 10), "VARCHAR(10)"},
+		{d.GenTableOption("utf8mb4_bin"), "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin"},
+		{d.GenTableComment("M", "T1", "c"), "COMMENT='c'"},
+		{d.GenColumnComment("M", "T1", "ID", "c"), ""},
+		{d.GenPrimaryKey([]string{"ID", "NAME"}), "PRIMARY KEY (`ID`,`NAME`)"},
+		{d.GenUniqueKey("UK1", []string{"NAME"}), "UNIQUE KEY `UK1` (`NAME`)"},
+		{d.GenCheckKey("CK1", `"ID" > 0`), "CONSTRAINT `CK1` CHECK (\"ID\" > 0)"},
+		{d.GenUniqueIndex("M", "T1", "UI1", []string{"A"}), "UNIQUE INDEX `UI1` (`A`)"},
+		{d.GenNormalIndex("M", "T1", "I1", []string{"A", "B"}), "KEY `I1` (`A`,`B`)"},
+		{d.GenCreateSchema("M", "utf8mb4_bin"), "CREATE DATABASE IF NOT EXISTS M DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_bin;"},
+		{d.GenColumnDefinition("ID", "INT", "", "NOT NULL", "0", "it's"), "`ID` INT NOT NULL DEFAULT 0 COMMENT 'it\\'s'"},
+		{d.GenColumnDefinition("NAME", "VARCHAR(10)", "utf8mb4_bin", "NULL", "", ""), "`NAME` VARCHAR(10) COLLATE utf8mb4_bin"},
+		{d.GenForeignKey("FK1", []string{"PID"}, "M", "P", []string{"ID"}, "CASCADE"), "CONSTRAINT `FK1` FOREIGN KEY (`PID`) REFERENCES `M`.`P` (`ID`) ON DELETE CASCADE"},
+		{d.GenForeignKey("FK2", []string{"PID"}, "M", "P", []string{"ID"}, "SET NULL"), "CONSTRAINT `FK2` FOREIGN KEY (`PID`) REFERENCES `M`.`P` (`ID`) ON DELETE SET NULL"},
+		{d.GenForeignKey("FK3", []string{"PID"}, "M", "P", []string{"ID"}, "NO ACTION"), "CONSTRAINT `FK3` FOREIGN KEY (`PID`) REFERENCES `M`.`P` (`ID`)"},
+	}
+	for _, c := range cases {
+		if c[0] != c[1] {
+			t.Fatalf("mysql dialect got [%s], want [%s]", c[0], c[1])
+		}
+	}
+	if !d.IsInlineComment() || !d.IsInlineIndex() || !d.IsSupportForeignKey() {
+		t.Fatal("mysql dialect inline comment/index and foreign key should be supported")
+	}
+	if d.IsSupportCheckKey("8.0.15") || !d.IsSupportCheckKey("8.0.16") {
+		t.Fatal("mysql dialect check key support version isn't 8.0.16")
+	}
+
+	tidb := &reverse.TiDBDialect{}
+	if tidb.IsSupportForeignKey() || tidb.IsSupportCheckKey("8.0.16") {
+		t.Fatal("tidb dialect foreign key and check key should be unsupported")
+	}
+	if tidb.GenPrimaryKey([]string{"ID"}) != "PRIMARY KEY (`ID`)" {
+		t.Fatal("tidb dialect primary key should be same as mysql")
+	}
+}
+
+func TestReversePostgresDialect(t *testing.T) {
+	d := &reverse.PostgresDialect{}
+	cases := [][2]string{
+		{d.QuoteIdentifier(`T"1`), `"t""1"`},
+		{d.GenNumberDatatype(4, 0), "SMALLINT"},
+		{d.GenNumberDatatype(9, 0), "INTEGER"},
+		{d.GenNumberDatatype(18, 0), "BIGINT"},
+		{d.GenNumberDatatype(38, 0), "NUMERIC(38)"},
+		{d.GenNumberDatatype(38, 127), "NUMERIC"},
+		{d.GenNumberDatatype(10, 2), "NUMERIC(10,2)"},
+		{d.GenColumnDatatype("VARCHAR", 10), "VARCHAR(10)"},
+		{d.GenColumnDatatype("BYTEA", 2000), "BYTEA"},
+		{d.GenColumnDatatype("TIMESTAMP", 3), "TIMESTAMP(3)"},
+		{d.GenTableOption("utf8mb4_bin"), ""},
+		{d.GenTableComment("M", "T1", "it's"), `COMMENT ON TABLE "m"."t1" IS 'it''s';`},
+		{d.GenColumnComment("M", "T1", "ID", "id"), `COMMENT ON COLUMN "m"."t1"."id" IS 'id';`},
+		{d.GenPrimaryKey([]string{"ID", "NAME"}), `PRIMARY KEY ("id","name")`},
+		{d.GenUniqueKey("UK1", []string{"NAME"}), `CONSTRAINT "uk1" UNIQUE ("name")`},
+		{d.GenCheckKey("CK1", `"ID" > 0 AND "NAME" IS NOT NULL`), `CONSTRAINT "ck1" CHECK ("id" > 0 AND "name" IS NOT NULL)`},
+		{d.GenUniqueIndex("M", "T1", "UI1", []string{"A"}), `CREATE UNIQUE INDEX "ui1" ON "m"."t1" ("a");`},
+		{d.GenNormalIndex("M", "T1", "I1", []string{"A", "B"}), `CREATE INDEX "i1" ON "m"."t1" ("a","b");`},
+		{d.GenCreateSchema("M", "utf8mb4_bin"), `CREATE SCHEMA IF NOT EXISTS "m";`},
+		// 排序规则以及注释不随字段定义输出
+		{d.GenColumnDefinition("ID", "INTEGER", "utf8mb4_bin", "NOT NULL", "0", "c"), `"id" INTEGER DEFAULT 0 NOT NULL`},
+		{d.GenColumnDefinition("NAME", "VARCHAR(10)", "", "NULL", "", ""), `"name" VARCHAR(10)`},
+		{d.GenForeignKey("FK1", []string{"PID"}, "M", "P", []string{"ID"}, "cascade"), `CONSTRAINT "fk1" FOREIGN KEY ("pid") REFERENCES "m"."p" ("id") ON DELETE CASCADE`},
+	}
+	for _, c := range cases {
+		if c[0] != c[1] {
+			t.Fatalf("postgres dialect got [%s], want [%s]", c[0], c[1])
+		}
+	}
+	if d.IsInlineComment() || d.IsInlineIndex() {
+		t.Fatal("postgres dialect comment and index should be separate statements")
+	}
+	if !d.IsSupportForeignKey() || !d.IsSupportCheckKey("") {
+		t.Fatal("postgres dialect foreign key and check key should be supported")
+	}
+}
+
+func TestReverseO2PColumnMapRule(t *testing.T) {
+	var buildinDatatypes []meta.BuildinDatatypeRule
+	for k, v := range common.BuildInOracleO2PDatatypeNameMap {
+		buildinDatatypes = append(buildinDatatypes, meta.BuildinDatatypeRule{
+			DBTypeS:       common.TaskDBOracle,
+			DBTypeT:       common.TaskDBPostgres,
+			DatatypeNameS: k,
+			DatatypeNameT: v,
+		})
+	}
+	dialect := &reverse.PostgresDialect{}
+
+	cases := []struct {
+		column     reverseO2M.Column
+		originType string
+		targetType string
+	}{
+		{newReverseColumn("NUMBER", "22", "9", "0"), "NUMBER(9,0)", "INTEGER"},
+		{newReverseColumn("NUMBER", "22", "38", "127"), "NUMBER(38,127)", "NUMERIC"},
+		{newReverseColumn("NUMBER", "22", "12", "4"), "NUMBER(12,4)", "NUMERIC(12,4)"},
+		{newReverseColumn("VARCHAR2", "100", "0", "0"), "VARCHAR2(100)", "VARCHAR(100)"},
+		{newReverseColumn("CHAR", "10", "0", "0"), "CHAR(10)", "CHAR(10)"},
+		{newReverseColumn("DATE", "7", "0", "0"), "DATE", "TIMESTAMP(0)"},
+		{newReverseColumn("CLOB", "4000", "0", "0"), "CLOB", "TEXT"},
+		{newReverseColumn("BLOB", "4000", "0", "0"), "BLOB", "BYTEA"},
+		{newReverseColumn("RAW", "16", "0", "0"), "RAW(16)", "BYTEA"},
+		{newReverseColumn("TIMESTAMP(3)", "11", "0", "3"), "TIMESTAMP(3)", "TIMESTAMP(3)"},
+		{newReverseColumn("TIMESTAMP(9)", "11", "0", "9"), "TIMESTAMP(9)", "TIMESTAMP(6)"},
+		{newReverseColumn("XMLTYPE", "2000", "0", "0"), "XMLTYPE", "XML"},
+	}
+	for _, c := range cases {
+		originType, targetType, err := reverseO2M.OracleTableColumnMapRule("MARVIN", "T1", c.column, buildinDatatypes, dialect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if originType != c.originType || targetType != c.targetType {
+			t.Fatalf("oracle column [%s] map [%s %s], want [%s %s]", c.column.DataType, originType, targetType, c.originType, c.targetType)
+		}
+	}
+
+	// 内置规则不存在报错
+	if _, _, err := reverseO2M.OracleTableColumnMapRule("MARVIN", "T1", newReverseColumn("NUMBER", "22", "9", "0"), nil, dialect); err == nil {
+		t.Fatal("oracle number column without buildin rule should be failed")
+	}
+}
+
+func newReverseColumn(dataType, dataLength, dataPrecision, dataScale string) reverseO2M.Column {
+	return reverseO2M.Column{
+		DataType: dataType,
+		ColumnInfo: reverseO2M.ColumnInfo{
+			DataLength:    dataLength,
+			DataPrecision: dataPrecision,
+			DataScale:     dataScale,
+			NULLABLE:      "Y",
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"github.com/wentaojin/transferdb/module/reverse/m2o"
+	"strings"
+	"testing"
+)
+
+func TestReverseM2OEnumSetCheckKey(t *testing.T) {
+	r := &m2o.Rule{
+		SourceSchema:    "marvin",
+		SourceTableName: "T1",
+		TargetSchema:    "STEVEN",
+		OracleDBVersion: "19.3",
+		TableColumnINFO: []map[string]string{
+			{"COLUMN_NAME": "STATUS", "DATA_TYPE": "enum", "COLUMN_TYPE": `enum('new','it''s','a\\b','a,b')`},
+			{"COLUMN_NAME": "TAGS", "DATA_TYPE": "set", "COLUMN_TYPE": "set('x.y','Z')"},
+			{"COLUMN_NAME": "EMPTY", "DATA_TYPE": "enum", "COLUMN_TYPE": "enum"},
+			{"COLUMN_NAME": "ID", "DATA_TYPE": "int", "COLUMN_TYPE": "int(11)"},
+		},
+	}
+	checkKeys, err := r.GenTableCheckKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`CONSTRAINT CK_T1_STATUS CHECK (STATUS IN ('new','it''s','a\b','a,b'))`,
+		`CONSTRAINT CK_T1_TAGS CHECK (REGEXP_LIKE(TAGS, '^(x\.y|Z)(,(x\.y|Z))*$'))`,
+	}
+	if strings.Join(checkKeys, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("check keys:\n%s\nwant:\n%s", strings.Join(checkKeys, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestReverseM2OObjectName(t *testing.T) {
+	longTable := strings.Repeat("T", 40)
+	column := map[string]string{"COLUMN_NAME": "ID", "DATA_TYPE": "int", "COLUMN_TYPE": "int(11)", "EXTRA": "auto_increment"}
+
+	// 12.2 以下版本对象名长度限制 30，超出使用 CRC32 缩短
+	r := &m2o.Rule{SourceSchema: "MARVIN", SourceTableName: "t1", OracleDBVersion: "11.2.0.4",
+		TableColumnINFO: []map[string]string{column}}
+	seqs, err := r.GenTableSequence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seqs) != 1 || seqs[0] != "CREATE SEQUENCE MARVIN.SEQ_T1_ID START WITH 1 INCREMENT BY 1;" {
+		t.Fatalf("sequence ddl = %v", seqs)
+	}
+
+	r.SourceTableName = longTable
+	seqs, err = r.GenTableSequence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := strings.Fields(seqs[0])[2]
+	if !strings.HasPrefix(name, "MARVIN.SEQ_") || len(strings.TrimPrefix(name, "MARVIN.")) != len("SEQ_")+8 {
+		t.Fatalf("shorten sequence name = %s", name)
+	}
+	triggers, err := r.GenTableTrigger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 序列名以及触发器引用序列名一致
+	if len(triggers) != 1 || !strings.Contains(triggers[0], name+".NEXTVAL") {
+		t.Fatalf("trigger ddl = %v", triggers)
+	}
+
+	// 12.2 及以上版本对象名长度限制 128，不缩短，IDENTITY 字段不生成序列
+	r.OracleDBVersion = "19.3"
+	r.TableColumnINFO = []map[string]string{{"COLUMN_NAME": "STATUS", "DATA_TYPE": "enum", "COLUMN_TYPE": "enum('a')"}}
+	checkKeys, err := r.GenTableCheckKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checkKeys) != 1 || !strings.HasPrefix(checkKeys[0], "CONSTRAINT CK_"+longTable+"_STATUS CHECK") {
+		t.Fatalf("check keys = %v", checkKeys)
+	}
+	if seqs, err = r.GenTableSequence(); err != nil || len(seqs) != 0 {
+		t.Fatalf("identity column sequence ddl = %v, %v", seqs, err)
+	}
+}
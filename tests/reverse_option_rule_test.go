@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"context"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/reverse"
+	reverseO2M "github.com/wentaojin/transferdb/module/reverse/o2m"
+	"strings"
+	"testing"
+)
+
+func newReverseOptionRule(t *testing.T, shardMode string, pkColumns string, pkPrecision string) *reverseO2M.Rule {
+	t.Helper()
+	return &reverseO2M.Rule{
+		Ctx:             context.Background(),
+		SourceSchema:    "MARVIN",
+		SourceTableName: "T_ORDER",
+		TargetSchema:    "MARVIN",
+		TargetTableName: "T_ORDER",
+		PrimaryKeyINFO:  []map[string]string{{"COLUMN_LIST": pkColumns}},
+		TableColumnINFO: []map[string]string{
+			{"COLUMN_NAME": "ID", "DATA_TYPE": "NUMBER", "DATA_LENGTH": "22", "DATA_PRECISION": pkPrecision, "DATA_SCALE": "0", "NULLABLE": "N", "DATA_DEFAULT": ""},
+			{"COLUMN_NAME": "NAME", "DATA_TYPE": "VARCHAR2", "DATA_LENGTH": "20", "DATA_PRECISION": "0", "DATA_SCALE": "0", "NULLABLE": "Y", "DATA_DEFAULT": ""},
+		},
+		TableOptionRule: &meta.TableOptionRule{ShardMode: shardMode},
+		Dialect:         &reverse.TiDBDialect{},
+		MetaDB:          newSQLiteMeta(t),
+	}
+}
+
+func TestReverseTiDBShardMode(t *testing.T) {
+	cases := []struct {
+		shardMode   string
+		pkColumns   string
+		pkPrecision string
+		wantMode    string
+		wantColumn  string
+		wantPK      string
+	}{
+		{common.TiDBShardModeAuto, "ID", "18", common.TiDBShardModeAutoRandom, "`ID` BIGINT AUTO_RANDOM(5) NOT NULL", common.TiDBClusteredIndexComment},
+		{common.TiDBShardModeAutoRandom, "ID", "18", common.TiDBShardModeAutoRandom, "`ID` BIGINT AUTO_RANDOM(5) NOT NULL", common.TiDBClusteredIndexComment},
+		// 非 BIGINT 整型主键跳过 AUTO_RANDOM 改写
+		{common.TiDBShardModeAuto, "ID", "8", common.TiDBShardModeShardRowIDBits, "`ID` INT NOT NULL", common.TiDBNonClusteredIndexComment},
+		{common.TiDBShardModeAutoRandom, "ID", "8", common.TiDBShardModeNone, "`ID` INT NOT NULL", ""},
+		{common.TiDBShardModeAutoRandom, "ID", "2", common.TiDBShardModeNone, "`ID` TINYINT NOT NULL", ""},
+		{common.TiDBShardModeAuto, "ID", "20", common.TiDBShardModeShardRowIDBits, "`ID` DECIMAL(20) NOT NULL", common.TiDBNonClusteredIndexComment},
+		{common.TiDBShardModeAuto, "ID,NAME", "18", common.TiDBShardModeShardRowIDBits, "`ID` BIGINT NOT NULL", common.TiDBNonClusteredIndexComment},
+	}
+	for _, c := range cases {
+		r := newReverseOptionRule(t, c.shardMode, c.pkColumns, c.pkPrecision)
+		shardMode, err := r.GenTiDBShardMode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if shardMode != c.wantMode {
+			t.Fatalf("shard mode [%s] pk [%s] precision [%s] got [%s], want [%s]", c.shardMode, c.pkColumns, c.pkPrecision, shardMode, c.wantMode)
+		}
+		columnMetas, err := r.GenTableColumn()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if columnMetas[0] != c.wantColumn {
+			t.Fatalf("shard mode [%s] precision [%s] column got [%s], want [%s]", c.shardMode, c.pkPrecision, columnMetas[0], c.wantColumn)
+		}
+		pk, err := r.GenTablePrimaryKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.wantPK != "" && !strings.HasSuffix(pk[0], c.wantPK) || c.wantPK == "" && strings.Contains(pk[0], "clustered_index") {
+			t.Fatalf("shard mode [%s] precision [%s] primary key got [%s], want [%s]", c.shardMode, c.pkPrecision, pk[0], c.wantPK)
+		}
+
+		// 表选项与字段改写判定一致
+		table := &reverseO2M.Table{
+			SourceSchemaName: "MARVIN",
+			SourceTableName:  "T_ORDER",
+			TableOptionRule:  r.TableOptionRule,
+			Dialect:          r.Dialect,
+		}
+		var primaryColumns []string
+		for _, col := range strings.Split(c.pkColumns, ",") {
+			primaryColumns = append(primaryColumns, r.Dialect.QuoteIdentifier(col))
+		}
+		suffix, err := table.GenTableOptionRuleSuffix("utf8mb4_bin", primaryColumns,
+			table.IsSingleIntegerPK(primaryColumns, columnMetas), table.IsSingleBigintPK(primaryColumns, columnMetas))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(suffix, "SHARD_ROW_ID_BITS") != (c.wantMode == common.TiDBShardModeShardRowIDBits) {
+			t.Fatalf("shard mode [%s] precision [%s] table suffix [%s], want mode [%s]", c.shardMode, c.pkPrecision, suffix, c.wantMode)
+		}
+	}
+
+	// AUTO_RANDOM 联合主键不支持
+	r := newReverseOptionRule(t, common.TiDBShardModeAutoRandom, "ID,NAME", "18")
+	if _, err := r.GenTiDBShardMode(); err == nil {
+		t.Fatal("shard mode AUTO_RANDOM with composite primary key should be failed")
+	}
+
+	// 打散方式首次判定后复用，不再查询字段类型转换规则
+	r = newReverseOptionRule(t, common.TiDBShardModeAuto, "ID", "18")
+	if _, err := r.GenTiDBShardMode(); err != nil {
+		t.Fatal(err)
+	}
+	r.MetaDB = nil
+	shardMode, err := r.GenTiDBShardMode()
+	if err != nil || shardMode != common.TiDBShardModeAutoRandom {
+		t.Fatalf("cached shard mode [%s], error: %v", shardMode, err)
+	}
+}
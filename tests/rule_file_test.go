@@ -0,0 +1,247 @@
+package tests
+
+import (
+	"context"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/rule"
+	"github.com/wentaojin/transferdb/server"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const ruleFileYAML = `db-type-s: oracle
+db-type-t: mysql
+schema-datatype-rule:
+  - schema-name-s: marvin
+    column-type-s: number(10,2)
+    column-type-t: decimal(12,2)
+column-datatype-rule:
+  - schema-name-s: marvin
+    table-name-s: t1
+    column-name-s: id
+    column-type-s: number(20)
+    column-type-t: bigint unsigned
+table-name-rule:
+  - schema-name-s: marvin
+    table-name-s: t1
+    schema-name-t: steven
+    table-name-t: t1_new
+column-defaultval-rule:
+  - default-value-s: systimestamp
+    default-value-t: CURRENT_TIMESTAMP(6)
+`
+
+func marvinTableLister(schemaName string) ([]string, error) {
+	if strings.EqualFold(schemaName, "marvin") {
+		return []string{"T1", "T_ORDER"}, nil
+	}
+	return nil, nil
+}
+
+func TestRuleFileLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	f, err := rule.LoadFile("../example/rules.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.DBTypeS != common.TaskDBOracle || f.DBTypeT != common.TaskDBTiDB || len(f.ColumnRules) != 1 || f.ColumnRules[0].ColumnTypeT != "BIGINT UNSIGNED" {
+		t.Fatalf("load toml rule file: %s", f)
+	}
+	if err = rule.Validate(f, marvinTableLister); err != nil {
+		t.Fatal(err)
+	}
+
+	yamlFile := filepath.Join(dir, "rules.yaml")
+	if err = os.WriteFile(yamlFile, []byte(ruleFileYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err = rule.LoadFile(yamlFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.SchemaRules) != 1 || f.SchemaRules[0].SchemaNameS != "MARVIN" || f.DefaultValueRules[0].DefaultValueT != "CURRENT_TIMESTAMP(6)" {
+		t.Fatalf("load yaml rule file: %s", f)
+	}
+	if err = rule.Validate(f, marvinTableLister); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未知配置项报错
+	unknownFile := filepath.Join(dir, "unknown.toml")
+	if err = os.WriteFile(unknownFile, []byte("db-type-s = \"oracle\"\ndb-type-t = \"mysql\"\n[[schema-datatype-rule]]\nschema-name = \"marvin\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = rule.LoadFile(unknownFile); err == nil || !strings.Contains(err.Error(), "schema-name") {
+		t.Fatalf("unknown toml key should failed: %v", err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "unknown.yml"), []byte("db-type-s: oracle\nschema-rule: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = rule.LoadFile(filepath.Join(dir, "unknown.yml")); err == nil {
+		t.Fatal("unknown yaml key should failed")
+	}
+}
+
+func TestRuleFileValidate(t *testing.T) {
+	f := &rule.File{
+		DBTypeS: "oracle",
+		DBTypeT: "mysql",
+		SchemaRules: []rule.SchemaRule{
+			{SchemaNameS: "marvin", ColumnTypeS: "number(10,2)", ColumnTypeT: "decimal(10,2)"},
+			{SchemaNameS: "MARVIN", ColumnTypeS: "NUMBER(10,2)", ColumnTypeT: "DECIMAL(10,2)"},
+			{SchemaNameS: "marvin", ColumnTypeS: "varchar3(10)", ColumnTypeT: "varchar(10)"},
+		},
+		TableRules: []rule.TableRule{
+			{SchemaNameS: "marvin", TableNameS: "t1", ColumnTypeS: "timestamp(6) with time zone", ColumnTypeT: "datetime(6)"},
+			{SchemaNameS: "marvin", TableNameS: "t_missing", ColumnTypeS: "date", ColumnTypeT: "datetim"},
+		},
+		ColumnRules: []rule.ColumnRule{
+			{SchemaNameS: "marvin", TableNameS: "t1", ColumnNameS: "id", ColumnTypeS: "number(20)", ColumnTypeT: "bigint unsigned"},
+			{SchemaNameS: "marvin", TableNameS: "t1", ColumnNameS: "id", ColumnTypeS: "number(20)", ColumnTypeT: "decimal(20)"},
+			{SchemaNameS: "marvin", TableNameS: "t1", ColumnNameS: "", ColumnTypeS: "number", ColumnTypeT: "int"},
+		},
+		TableNameRules: []rule.TableNameRule{
+			{SchemaNameS: "marvin", TableNameS: "t1", SchemaNameT: "steven", TableNameT: "orders"},
+			{SchemaNameS: "marvin", TableNameS: "t_order", SchemaNameT: "steven", TableNameT: "ORDERS"},
+		},
+		DefaultValueRules: []rule.DefaultValueRule{
+			{DefaultValueS: "sysdate", DefaultValueT: "NOW()"},
+			{DefaultValueS: "SYSDATE", DefaultValueT: "CURRENT_TIMESTAMP"},
+		},
+	}
+	f.Normalize()
+
+	err := rule.Validate(f, marvinTableLister)
+	if err == nil {
+		t.Fatal("rule file validate should failed")
+	}
+	for _, problem := range []string{
+		"schema-datatype-rule #2 [MARVIN.NUMBER(10,2)] is duplicated with #1",
+		"schema-datatype-rule #3 column-type-s [VARCHAR3(10)] is unknown ORACLE datatype",
+		"table-datatype-rule #2 column-type-t [DATETIM] is unknown MYSQL datatype",
+		"column-datatype-rule #2 [MARVIN.T1.ID] is conflicting with #1",
+		"column-datatype-rule #3 schema-name-s, table-name-s and column-name-s can't be empty",
+		"table-name-rule #2 target table [STEVEN.ORDERS] is conflicting with #1",
+		"column-defaultval-rule #2 [SYSDATE] is conflicting with #1",
+		"ORACLE table [MARVIN.T_MISSING] isn't exist",
+		"8 problems",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Fatalf("validate error missing [%s]:\n%v", problem, err)
+		}
+	}
+
+	// 不校验源端表是否存在
+	f.TableRules = f.TableRules[:1]
+	f.SchemaRules = f.SchemaRules[:1]
+	f.ColumnRules = f.ColumnRules[:1]
+	f.TableNameRules = f.TableNameRules[:1]
+	f.DefaultValueRules = f.DefaultValueRules[:1]
+	if err = rule.Validate(f, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	f.DBTypeT = common.TaskDBOracle
+	if err = rule.Validate(f, nil); err == nil || !strings.Contains(err.Error(), "isn't support") {
+		t.Fatalf("unsupported db type should failed: %v", err)
+	}
+}
+
+func TestRuleFileSyncAndExport(t *testing.T) {
+	ctx := context.Background()
+	metaDB := newSQLiteMeta(t)
+
+	f, err := rule.LoadFile("../example/rules.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 重复同步幂等
+	for i := 0; i < 2; i++ {
+		if err = rule.Sync(ctx, metaDB, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	columnRules, err := meta.NewColumnDatatypeRuleModel(metaDB).DetailColumnRule(ctx, &meta.ColumnDatatypeRule{
+		DBTypeS:     common.TaskDBOracle,
+		DBTypeT:     common.TaskDBTiDB,
+		SchemaNameS: "marvin",
+		TableNameS:  "t_order",
+		ColumnNameS: "order_id",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(columnRules) != 1 || columnRules[0].ColumnTypeT != "BIGINT UNSIGNED" {
+		t.Fatalf("sync column datatype rule %v", columnRules)
+	}
+
+	// 规则变更同步更新目标值
+	f.ColumnRules[0].ColumnTypeT = "DECIMAL(20)"
+	if err = rule.Sync(ctx, metaDB, f); err != nil {
+		t.Fatal(err)
+	}
+
+	exported, err := rule.Export(ctx, metaDB, "oracle", "tidb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exported.SchemaRules) != 1 || len(exported.TableRules) != 1 || len(exported.ColumnRules) != 1 || len(exported.TableNameRules) != 1 {
+		t.Fatalf("export rules: %s", exported)
+	}
+	if exported.ColumnRules[0].ColumnTypeT != "DECIMAL(20)" || exported.TableNameRules[0].TableNameT != "ORDERS" {
+		t.Fatalf("export rules: %v %v", exported.ColumnRules, exported.TableNameRules)
+	}
+	if len(exported.DefaultValueRules) != 1 || exported.DefaultValueRules[0].DefaultValueS != "systimestamp" {
+		t.Fatalf("export default value rules: %v", exported.DefaultValueRules)
+	}
+
+	// 导出文件可重新加载，与元数据库规则一致
+	for _, name := range []string{"export.toml", "export.yaml"} {
+		file := filepath.Join(t.TempDir(), name)
+		if err = exported.WriteFile(file); err != nil {
+			t.Fatal(err)
+		}
+		reloaded, err := rule.LoadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reloaded.String() != exported.String() || reloaded.ColumnRules[0] != exported.ColumnRules[0] {
+			t.Fatalf("reload export file [%s]: %s", name, reloaded)
+		}
+		if err = rule.Validate(reloaded, marvinTableLister); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRunRulesValidateWithoutMeta(t *testing.T) {
+	cfg := config.NewConfig()
+	if err := cfg.ParseRules([]string{"check", "--config", "../example/config.toml"}); err == nil || !strings.Contains(err.Error(), "isn't support") {
+		t.Fatalf("rules subcommand [check] should be rejected, error: %v", err)
+	}
+	cfg = config.NewConfig()
+	if err := cfg.ParseRules([]string{"validate", "--config", "../example/config.toml", "--rule-file", "../example/rules.toml"}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.RuleAction != common.RuleActionValidate {
+		t.Fatalf("rules action [%s], expect [%s]", cfg.RuleAction, common.RuleActionValidate)
+	}
+
+	// validate 不打开元数据库，不创建 sqlite 文件
+	metaFile := filepath.Join(t.TempDir(), "meta", "transferdb.db")
+	cfg.MetaConfig.DBType = common.MetaDBTypeSQLite
+	cfg.MetaConfig.SQLiteFile = metaFile
+	cfg.OracleConfig.Host = ""
+	if err := server.RunRules(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(metaFile); !os.IsNotExist(err) {
+		t.Fatalf("rules validate shouldn't open meta db, stat error: %v", err)
+	}
+}
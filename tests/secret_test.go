@@ -0,0 +1,192 @@
+package tests
+
+import (
+	"bytes"
+	driver "github.com/go-sql-driver/mysql"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretEncryptDecrypt(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "transferdb.key")
+	if err := config.GenerateSecretKey(keyFile); err != nil {
+		t.Fatal(err)
+	}
+	// 密钥文件已存在不覆盖
+	if err := config.GenerateSecretKey(keyFile); err == nil {
+		t.Fatal("generate secret key overwrite existing key file")
+	}
+	key, err := config.ReadSecretKey(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := config.EncryptSecret("p@ss:w/rd", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.IsEncryptedSecret(secret) || strings.Contains(secret, "p@ss") {
+		t.Fatalf("encrypted secret = %s", secret)
+	}
+	plain, err := config.DecryptSecret(secret, key)
+	if err != nil || plain != "p@ss:w/rd" {
+		t.Fatalf("decrypt secret = %s, %v", plain, err)
+	}
+
+	otherFile := filepath.Join(t.TempDir(), "other.key")
+	if err = config.GenerateSecretKey(otherFile); err != nil {
+		t.Fatal(err)
+	}
+	otherKey, _ := config.ReadSecretKey(otherFile)
+	if _, err = config.DecryptSecret(secret, otherKey); err == nil {
+		t.Fatal("decrypt secret with wrong key succeeded")
+	}
+
+	var out bytes.Buffer
+	if err = config.RunSecret([]string{"encrypt", "--key-file", keyFile}, strings.NewReader("tiger\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if plain, err = config.DecryptSecret(strings.TrimSpace(out.String()), key); err != nil || plain != "tiger" {
+		t.Fatalf("secret subcommand encrypt output decrypt = %s, %v", plain, err)
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "transferdb.key")
+	if err := config.GenerateSecretKey(keyFile); err != nil {
+		t.Fatal(err)
+	}
+	key, _ := config.ReadSecretKey(keyFile)
+	walletSecret, _ := config.EncryptSecret("wallet", key)
+	s3Secret, _ := config.EncryptSecret("s3secret", key)
+	mysqlSecret, _ := config.EncryptSecret("mysqlpass", key)
+
+	passwordFile := filepath.Join(dir, "oracle.pass")
+	if err := os.WriteFile(passwordFile, []byte("oraclepass\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TRANSFERDB_TEST_MYSQL_PASSWORD", mysqlSecret)
+
+	cfg := &config.Config{}
+	cfg.AppConfig.EncryptionKeyFile = keyFile
+	cfg.OracleConfig.Password = "ignored"
+	cfg.OracleConfig.PasswordFile = passwordFile
+	cfg.OracleConfig.WalletPassword = walletSecret
+	cfg.MySQLConfig.PasswordEnv = "TRANSFERDB_TEST_MYSQL_PASSWORD"
+	cfg.MySQLConfig.PasswordFile = passwordFile
+	cfg.CSVConfig.S3.SecretKey = s3Secret
+
+	// 重复解析结果一致
+	for i := 0; i < 2; i++ {
+		if err := cfg.ResolveSecrets(); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.OracleConfig.Password != "oraclepass" || cfg.OracleConfig.WalletPassword != "wallet" ||
+			cfg.MySQLConfig.Password != "mysqlpass" || cfg.CSVConfig.S3.SecretKey != "s3secret" {
+			t.Fatalf("resolve secrets = %s/%s/%s/%s", cfg.OracleConfig.Password, cfg.OracleConfig.WalletPassword,
+				cfg.MySQLConfig.Password, cfg.CSVConfig.S3.SecretKey)
+		}
+	}
+
+	// 配置输出脱敏，原配置不变
+	out := cfg.String()
+	for _, secret := range []string{"oraclepass", "wallet\"", "mysqlpass", "s3secret"} {
+		if strings.Contains(out, secret) {
+			t.Fatalf("config string output contains secret [%s]: %s", secret, out)
+		}
+	}
+	if !strings.Contains(out, config.RedactedSecret) || cfg.MySQLConfig.Password != "mysqlpass" {
+		t.Fatalf("config string output isn't redacted or config modified: %s", out)
+	}
+
+	// 连接参数内凭据脱敏，其余参数保持原样
+	cfg.MySQLConfig.ConnectParams = "charset=utf8mb4&password=cppass"
+	cfg.PostgresConfig.ConnectParams = "connect_timeout=10&sslpassword=pgpass"
+	cfg.OracleConfig.ConnectParams = "poolMinSessions=50&walletPassword=orapass"
+	redacted := cfg.Redacted()
+	if redacted.MySQLConfig.ConnectParams != "charset=utf8mb4&password="+config.RedactedSecret ||
+		redacted.PostgresConfig.ConnectParams != "connect_timeout=10&sslpassword="+config.RedactedSecret ||
+		redacted.OracleConfig.ConnectParams != "poolMinSessions=50&walletPassword="+config.RedactedSecret {
+		t.Fatalf("connect-params isn't redacted: %s / %s / %s", redacted.MySQLConfig.ConnectParams,
+			redacted.PostgresConfig.ConnectParams, redacted.OracleConfig.ConnectParams)
+	}
+	if out = cfg.String(); strings.Contains(out, "cppass") || cfg.MySQLConfig.ConnectParams != "charset=utf8mb4&password=cppass" {
+		t.Fatalf("config string output contains connect-params secret or config modified: %s", out)
+	}
+
+	missing := &config.Config{}
+	missing.MySQLConfig.PasswordEnv = "TRANSFERDB_TEST_NOT_EXIST_PASSWORD"
+	if err := missing.ResolveSecrets(); err == nil {
+		t.Fatal("resolve secrets with missing environment variable succeeded")
+	}
+	noKey := &config.Config{}
+	noKey.OracleConfig.Password = walletSecret
+	if err := noKey.ResolveSecrets(); err == nil {
+		t.Fatal("resolve encrypted secret without encryption key file succeeded")
+	}
+}
+
+func TestOracleURLOptions(t *testing.T) {
+	if options := oracle.NewOracleURLOptions(config.OracleConfig{}); options != nil {
+		t.Fatalf("oracle url options = %v, want nil", options)
+	}
+	options := oracle.NewOracleURLOptions(config.OracleConfig{
+		WalletDir:      "/opt/wallet",
+		WalletPassword: "wallet",
+		SSL:            true,
+		SSLSkipVerify:  true,
+	})
+	want := map[string]string{"WALLET": "/opt/wallet", "WALLET PASSWORD": "wallet", "SSL": "true", "SSL VERIFY": "false"}
+	for k, v := range want {
+		if options[k] != v {
+			t.Errorf("oracle url option [%s] = %s, want %s", k, options[k], v)
+		}
+	}
+}
+
+func TestMySQLDSNTLS(t *testing.T) {
+	mysqlCfg := config.MySQLConfig{Username: "root", Password: "p", Host: "127.0.0.1", Port: 4000}
+	dsn, err := mysql.NewMySQLDSN(mysqlCfg, "db", "charset=utf8mb4")
+	if err != nil || dsn != "root:p@tcp(127.0.0.1:4000)/db?charset=utf8mb4" {
+		t.Fatalf("mysql dsn = %s, %v", dsn, err)
+	}
+
+	mysqlCfg.SSLSkipVerify = true
+	dsn, err = mysql.NewMySQLDSN(mysqlCfg, "db", "charset=utf8mb4")
+	if err != nil || !strings.Contains(dsn, "tls=transferdb-") || !strings.Contains(dsn, "charset=utf8mb4") {
+		t.Fatalf("mysql tls dsn = %s, %v", dsn, err)
+	}
+	mysqlCfg.SSLSkipVerify = false
+
+	// 密码特殊字符以及 IPv6 地址由驱动格式化，解析结果与配置一致
+	mysqlCfg.Password = "p@ss:w/rd?&"
+	mysqlCfg.Host = "::1"
+	dsn, err = mysql.NewMySQLDSN(mysqlCfg, "db", "charset=utf8mb4&parseTime=True")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := driver.ParseDSN(dsn)
+	if err != nil || parsed.Passwd != mysqlCfg.Password || parsed.Addr != "[::1]:4000" || parsed.DBName != "db" ||
+		!parsed.ParseTime || parsed.Params["charset"] != "utf8mb4" {
+		t.Fatalf("mysql dsn = %s parsed = %+v, %v", dsn, parsed, err)
+	}
+	if _, err = mysql.NewMySQLDSN(mysqlCfg, "db", "parseTime=maybe"); err == nil {
+		t.Fatal("mysql dsn with invalid connect-params succeeded")
+	}
+
+	mysqlCfg.SSLCA = filepath.Join(t.TempDir(), "not-exist-ca.pem")
+	if _, err = mysql.NewMySQLDSN(mysqlCfg, "db", ""); err == nil {
+		t.Fatal("mysql dsn with missing ssl-ca succeeded")
+	}
+	mysqlCfg.SSLCA = ""
+	mysqlCfg.SSLCert = "client.pem"
+	if _, err = mysql.NewMySQLDSN(mysqlCfg, "db", ""); err == nil {
+		t.Fatal("mysql dsn with ssl-cert but without ssl-key succeeded")
+	}
+}
@@ -0,0 +1,222 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/server"
+	"github.com/wentaojin/transferdb/signal"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const serviceTestToken = "transferdb-test-token"
+
+func serviceRequest(t *testing.T, method, url string, body interface{}, wantCode int, out interface{}) {
+	t.Helper()
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, url, &reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+serviceTestToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != wantCode {
+		var e map[string]string
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		t.Fatalf("%s %s status [%d], want [%d], error [%s]", method, url, resp.StatusCode, wantCode, e["error"])
+	}
+	if out != nil {
+		if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func waitServiceTask(t *testing.T, url, state string) server.ServiceTaskDetail {
+	t.Helper()
+	var detail server.ServiceTaskDetail
+	for i := 0; i < 200; i++ {
+		serviceRequest(t, http.MethodGet, url, nil, http.StatusOK, &detail)
+		if detail.State == state {
+			return detail
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("task [%s] state [%s], want [%s]", detail.ID, detail.State, state)
+	return detail
+}
+
+func TestServiceTask(t *testing.T) {
+	metaDB := newTaskMeta(t)
+	cfg := config.NewConfig()
+	cfg.OracleConfig.SchemaName = "marvin"
+	cfg.ServerConfig.Concurrency = 1
+	cfg.ServerConfig.Token = serviceTestToken
+
+	release := make(chan struct{})
+	runs := make(chan *config.Config, 10)
+	runner := func(ctx context.Context, c *config.Config) error {
+		runs <- c
+		switch c.Mode {
+		case "check":
+			return fmt.Errorf("check failed")
+		case "csv":
+			<-signal.Stopping(ctx)
+			return signal.ErrGracefulStop
+		}
+		select {
+		case <-release:
+			return nil
+		case <-signal.Stopping(ctx):
+			return signal.ErrGracefulStop
+		}
+	}
+	srv := httptest.NewServer(server.NewService(context.Background(), cfg, metaDB, runner).Handler())
+	defer srv.Close()
+	api := srv.URL + "/api/v1/tasks"
+
+	// 未携带或者错误 token 拒绝访问
+	for _, auth := range []string{"", "Bearer wrong-token", serviceTestToken} {
+		req, err := http.NewRequest(http.MethodGet, api, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("authorization [%s] status [%d], want [%d]", auth, resp.StatusCode, http.StatusUnauthorized)
+		}
+	}
+
+	// 覆盖连接地址以及输出位置需请求自带凭据
+	serviceRequest(t, http.MethodPost, api, map[string]interface{}{"mode": "full", "config": map[string]interface{}{"mysql": map[string]interface{}{"host": "10.0.0.1"}}}, http.StatusBadRequest, nil)
+	serviceRequest(t, http.MethodPost, api, map[string]interface{}{"mode": "full", "config": map[string]interface{}{"oracle": map[string]interface{}{"port": 1522, "username": "marvin"}}}, http.StatusBadRequest, nil)
+	serviceRequest(t, http.MethodPost, api, map[string]interface{}{"mode": "csv", "config": map[string]interface{}{"csv": map[string]interface{}{"output-dir": "/tmp/dump"}}}, http.StatusBadRequest, nil)
+	serviceRequest(t, http.MethodPost, api, map[string]interface{}{"mode": "csv", "config": map[string]interface{}{"csv": map[string]interface{}{"output-dir": "/tmp/dump",
+		"s3": map[string]interface{}{"access-key": "ak", "secret-key": "sk"}}}}, http.StatusBadRequest, nil)
+
+	// 非法模式以及未知配置项
+	serviceRequest(t, http.MethodPost, api, map[string]interface{}{"mode": "prepare"}, http.StatusBadRequest, nil)
+	serviceRequest(t, http.MethodPost, api, map[string]interface{}{"mode": "full", "config": map[string]interface{}{"unknown": 1}}, http.StatusBadRequest, nil)
+	// 密码文件不允许任务配置覆盖
+	serviceRequest(t, http.MethodPost, api, map[string]interface{}{"mode": "full", "config": map[string]interface{}{"mysql": map[string]interface{}{"password-file": "/etc/passwd"}}}, http.StatusBadRequest, nil)
+
+	var full server.ServiceTask
+	serviceRequest(t, http.MethodPost, api, map[string]interface{}{
+		"mode":   "full",
+		"config": map[string]interface{}{"full": map[string]interface{}{"chunk-size": 500}},
+	}, http.StatusCreated, &full)
+	runCfg := <-runs
+	if runCfg.FullConfig.ChunkSize != 500 || runCfg.Mode != "full" || runCfg.OracleConfig.SchemaName != "marvin" {
+		t.Fatalf("task config unexpected: chunk-size [%d] mode [%s] schema [%s]", runCfg.FullConfig.ChunkSize, runCfg.Mode, runCfg.OracleConfig.SchemaName)
+	}
+
+	// full 与 all 共用断点，同 schema 不允许同时运行
+	serviceRequest(t, http.MethodPost, api, map[string]interface{}{"mode": "all"}, http.StatusConflict, nil)
+
+	// 并发 1，后续任务排队
+	var check server.ServiceTask
+	serviceRequest(t, http.MethodPost, api, map[string]interface{}{"mode": "check"}, http.StatusCreated, &check)
+	detail := waitServiceTask(t, api+"/"+check.ID, common.ServerTaskStateQueued)
+	if detail.Errors != 1 {
+		t.Fatalf("check task errors [%d], want 1", detail.Errors)
+	}
+
+	detail = waitServiceTask(t, api+"/"+full.ID, common.ServerTaskStateRunning)
+	if len(detail.Tables) != 3 || detail.Errors != 2 {
+		t.Fatalf("full task tables [%d] errors [%d], want 3/2", len(detail.Tables), detail.Errors)
+	}
+	close(release)
+	waitServiceTask(t, api+"/"+full.ID, common.ServerTaskStateSuccess)
+	detail = waitServiceTask(t, api+"/"+check.ID, common.ServerTaskStateFailed)
+	if detail.Error != "check failed" {
+		t.Fatalf("check task error [%s]", detail.Error)
+	}
+
+	// 取消运行中任务
+	var csv server.ServiceTask
+	serviceRequest(t, http.MethodPost, api, map[string]interface{}{"mode": "csv"}, http.StatusCreated, &csv)
+	waitServiceTask(t, api+"/"+csv.ID, common.ServerTaskStateRunning)
+	serviceRequest(t, http.MethodPost, api+"/"+csv.ID+"/cancel", nil, http.StatusAccepted, nil)
+	waitServiceTask(t, api+"/"+csv.ID, common.ServerTaskStateCanceled)
+	serviceRequest(t, http.MethodDelete, api+"/"+csv.ID, nil, http.StatusConflict, nil)
+	serviceRequest(t, http.MethodGet, api+"/404", nil, http.StatusNotFound, nil)
+
+	var tasks []server.ServiceTask
+	serviceRequest(t, http.MethodGet, api, nil, http.StatusOK, &tasks)
+	if len(tasks) != 3 {
+		t.Fatalf("task list [%d], want 3", len(tasks))
+	}
+}
+
+func TestServiceGracefulStop(t *testing.T) {
+	metaDB := newTaskMeta(t)
+	cfg := config.NewConfig()
+	cfg.OracleConfig.SchemaName = "marvin"
+	cfg.ServerConfig.Addr = "127.0.0.1:0"
+	cfg.ServerConfig.Token = serviceTestToken
+
+	started := make(chan struct{})
+	drained := false
+	runner := func(ctx context.Context, c *config.Config) error {
+		close(started)
+		<-signal.Stopping(ctx)
+		// 模拟进行中的 chunk 完成，context 仍然有效
+		time.Sleep(100 * time.Millisecond)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		drained = true
+		return signal.ErrGracefulStop
+	}
+	ctx, stop := signal.WithGracefulStop(context.Background())
+	s := server.NewService(ctx, cfg, metaDB, runner)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Serve()
+	}()
+
+	task, err := s.Submit(server.TaskRequest{Mode: "full"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	stop()
+
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve doesn't return after graceful stop")
+	}
+	if !drained {
+		t.Fatal("serve returned before in-flight task drained")
+	}
+	tasks := s.List()
+	if len(tasks) != 1 || tasks[0].ID != task.ID || tasks[0].State != common.ServerTaskStateCanceled || tasks[0].Error != "" {
+		t.Fatalf("task list unexpected: %+v", tasks)
+	}
+}
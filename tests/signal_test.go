@@ -0,0 +1,43 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	msErrors "github.com/wentaojin/transferdb/errors"
+	"github.com/wentaojin/transferdb/signal"
+	"testing"
+)
+
+func TestGracefulStop(t *testing.T) {
+	if signal.IsStopping(context.Background()) {
+		t.Fatal("background context is stopping")
+	}
+	if signal.Stopping(context.Background()) != nil {
+		t.Fatal("background context stopping channel isn't nil")
+	}
+
+	parent, stopParent := signal.WithGracefulStop(context.Background())
+	child, stopChild := signal.WithGracefulStop(parent)
+	defer stopChild()
+
+	// 子级停止不影响父级
+	other, stopOther := signal.WithGracefulStop(parent)
+	stopOther()
+	if !signal.IsStopping(other) || signal.IsStopping(parent) || signal.IsStopping(child) {
+		t.Fatal("child graceful stop propagates to parent or sibling")
+	}
+
+	// 父级停止传递至子级，context 本身不取消
+	stopParent()
+	if !signal.IsStopping(parent) || !signal.IsStopping(child) {
+		t.Fatal("parent graceful stop doesn't propagate to child")
+	}
+	if parent.Err() != nil || child.Err() != nil {
+		t.Fatal("graceful stop cancels context")
+	}
+
+	err := msErrors.NewMSError(msErrors.TRANSFERDB, msErrors.DOMAIN_TASK, signal.ErrGracefulStop)
+	if !errors.Is(err, signal.ErrGracefulStop) {
+		t.Fatal("wrapped graceful stop error isn't matched")
+	}
+}
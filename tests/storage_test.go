@@ -0,0 +1,601 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/storage"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// MinIO 替身：进程内 S3 兼容服务，仅支持 path-style 以及导出所需接口
+type fakeS3 struct {
+	mu        sync.Mutex
+	bucket    string
+	accessKey string
+	secretKey string
+	region    string
+	objects   map[string][]byte
+	uploads   map[string]*fakeUpload
+	uploadSeq int
+	// 注入前 N 个请求失败（503），验证重试
+	failNext int
+	requests map[string]int
+}
+
+type fakeUpload struct {
+	key   string
+	parts map[int][]byte
+}
+
+func newFakeS3(bucket, accessKey, secretKey, region string) *fakeS3 {
+	return &fakeS3{
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		region:    region,
+		objects:   make(map[string][]byte),
+		uploads:   make(map[string]*fakeUpload),
+		requests:  make(map[string]int),
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	query := r.URL.Query()
+
+	if f.failNext > 0 {
+		f.failNext--
+		f.writeError(w, http.StatusServiceUnavailable, "SlowDown", "please reduce your request rate")
+		return
+	}
+
+	// 明文 HTTP 上传使用 aws-chunked 流式签名负载，其余请求校验负载哈希
+	switch contentSha256 := r.Header.Get("X-Amz-Content-Sha256"); {
+	case contentSha256 == "STREAMING-AWS4-HMAC-SHA256-PAYLOAD":
+		decoded, err := decodeAWSChunked(body)
+		if err != nil {
+			f.writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+			return
+		}
+		body = decoded
+	case contentSha256 == "UNSIGNED-PAYLOAD":
+	default:
+		payloadHash := sha256.Sum256(body)
+		if contentSha256 != hex.EncodeToString(payloadHash[:]) {
+			f.writeError(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch", "payload hash mismatch")
+			return
+		}
+	}
+	if err := verifySigV4(r, f.accessKey, f.secretKey, f.region); err != nil {
+		f.requests["SignatureDoesNotMatch"]++
+		f.writeError(w, http.StatusForbidden, "SignatureDoesNotMatch", err.Error())
+		return
+	}
+
+	bucketKey := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	if bucketKey[0] != f.bucket {
+		f.writeError(w, http.StatusNotFound, "NoSuchBucket", bucketKey[0])
+		return
+	}
+	var key string
+	if len(bucketKey) == 2 {
+		key = bucketKey[1]
+	}
+
+	switch {
+	case r.Method == http.MethodGet && key == "" && query.Get("list-type") == "2":
+		f.requests["ListObjectsV2"]++
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, query.Get("prefix")) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		var b strings.Builder
+		b.WriteString("<ListBucketResult><IsTruncated>false</IsTruncated>")
+		for _, k := range keys {
+			fmt.Fprintf(&b, "<Contents><Key>%s</Key><Size>%d</Size><LastModified>%s</LastModified></Contents>", k, len(f.objects[k]), fakeS3LastModified.Format(time.RFC3339))
+		}
+		b.WriteString("</ListBucketResult>")
+		w.Write([]byte(b.String()))
+	case r.Method == http.MethodGet && key == "" && query.Has("uploads"):
+		f.requests["ListMultipartUploads"]++
+		var b strings.Builder
+		b.WriteString("<ListMultipartUploadsResult><IsTruncated>false</IsTruncated>")
+		for id, u := range f.uploads {
+			if strings.HasPrefix(u.key, query.Get("prefix")) {
+				fmt.Fprintf(&b, "<Upload><Key>%s</Key><UploadId>%s</UploadId><Initiated>%s</Initiated></Upload>", u.key, id, fakeS3LastModified.Format(time.RFC3339))
+			}
+		}
+		b.WriteString("</ListMultipartUploadsResult>")
+		w.Write([]byte(b.String()))
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.requests["CreateMultipartUpload"]++
+		f.uploadSeq++
+		id := "upload-" + strconv.Itoa(f.uploadSeq)
+		f.uploads[id] = &fakeUpload{key: key, parts: make(map[int][]byte)}
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>", key, id)
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		f.requests["UploadPart"]++
+		u, ok := f.uploads[query.Get("uploadId")]
+		if !ok {
+			f.writeError(w, http.StatusNotFound, "NoSuchUpload", query.Get("uploadId"))
+			return
+		}
+		partNumber, _ := strconv.Atoi(query.Get("partNumber"))
+		u.parts[partNumber] = body
+		sum := sha256.Sum256(body)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		f.requests["CompleteMultipartUpload"]++
+		u, ok := f.uploads[query.Get("uploadId")]
+		if !ok {
+			f.writeError(w, http.StatusNotFound, "NoSuchUpload", query.Get("uploadId"))
+			return
+		}
+		var complete struct {
+			Parts []struct {
+				PartNumber int    `xml:"PartNumber"`
+				ETag       string `xml:"ETag"`
+			} `xml:"Part"`
+		}
+		if err := xml.Unmarshal(body, &complete); err != nil {
+			f.writeError(w, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+		var data []byte
+		for i, p := range complete.Parts {
+			part, ok := u.parts[p.PartNumber]
+			sum := sha256.Sum256(part)
+			if !ok || p.PartNumber != i+1 || strings.Trim(p.ETag, `"`) != hex.EncodeToString(sum[:16]) {
+				// 同 S3，状态码 200 响应体为 Error
+				w.Write([]byte("<Error><Code>InvalidPart</Code><Message>part not found</Message></Error>"))
+				return
+			}
+			data = append(data, part...)
+		}
+		f.objects[u.key] = data
+		delete(f.uploads, query.Get("uploadId"))
+		fmt.Fprintf(w, "<CompleteMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key></CompleteMultipartUploadResult>", f.bucket, u.key)
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		f.requests["AbortMultipartUpload"]++
+		delete(f.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		f.requests["PutObject"]++
+		f.objects[key] = body
+		sum := sha256.Sum256(body)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		data, ok := f.objects[key]
+		if !ok {
+			f.writeError(w, http.StatusNotFound, "NoSuchKey", key)
+			return
+		}
+		sum := sha256.Sum256(data)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+		w.Header().Set("Last-Modified", fakeS3LastModified.Format(http.TimeFormat))
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.writeError(w, http.StatusNotImplemented, "NotImplemented", r.Method)
+	}
+}
+
+var fakeS3LastModified = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// aws-chunked 负载：{hex-size};chunk-signature={signature}\r\n{data}\r\n，以长度 0 的 chunk 结束
+func decodeAWSChunked(body []byte) ([]byte, error) {
+	var data []byte
+	for {
+		header, rest, ok := bytes.Cut(body, []byte("\r\n"))
+		if !ok {
+			return nil, fmt.Errorf("aws-chunked header not found")
+		}
+		sizeHex, _, _ := bytes.Cut(header, []byte(";"))
+		size, err := strconv.ParseInt(string(sizeHex), 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("aws-chunked size [%s] isn't valid", sizeHex)
+		}
+		if size == 0 {
+			return data, nil
+		}
+		if int64(len(rest)) < size+2 {
+			return nil, fmt.Errorf("aws-chunked data is incomplete")
+		}
+		data = append(data, rest[:size]...)
+		body = rest[size+2:]
+	}
+}
+
+// 服务端独立校验 Signature V4，依据实际收到的请求行以及 SignedHeaders 重建 canonical request
+func verifySigV4(r *http.Request, accessKey, secretKey, region string) error {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 ") {
+		return fmt.Errorf("authorization [%s] algorithm isn't AWS4-HMAC-SHA256", auth)
+	}
+	fields := make(map[string]string)
+	for _, kv := range strings.Split(strings.TrimPrefix(auth, "AWS4-HMAC-SHA256 "), ",") {
+		pair := strings.SplitN(strings.TrimSpace(kv), "=", 2)
+		if len(pair) != 2 {
+			return fmt.Errorf("authorization [%s] isn't valid", auth)
+		}
+		fields[pair[0]] = pair[1]
+	}
+	credential := strings.Split(fields["Credential"], "/")
+	if len(credential) != 5 || credential[0] != accessKey || credential[2] != region || credential[3] != "s3" || credential[4] != "aws4_request" {
+		return fmt.Errorf("credential [%s] isn't valid", fields["Credential"])
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	if _, err := time.Parse("20060102T150405Z", amzDate); err != nil || !strings.HasPrefix(amzDate, credential[1]) {
+		return fmt.Errorf("x-amz-date [%s] isn't match credential date [%s]", amzDate, credential[1])
+	}
+
+	signedHeaders := strings.Split(fields["SignedHeaders"], ";")
+	if !sort.StringsAreSorted(signedHeaders) {
+		return fmt.Errorf("signed headers [%s] isn't sorted", fields["SignedHeaders"])
+	}
+	var canonicalHeaders strings.Builder
+	for _, h := range signedHeaders {
+		var v string
+		if h == "host" {
+			v = r.Host
+		} else {
+			vs, ok := r.Header[http.CanonicalHeaderKey(h)]
+			if !ok {
+				return fmt.Errorf("signed header [%s] not found", h)
+			}
+			v = strings.Join(strings.Fields(strings.Join(vs, ",")), " ")
+		}
+		canonicalHeaders.WriteString(h + ":" + v + "\n")
+	}
+	for _, h := range []string{"host", "x-amz-content-sha256", "x-amz-date"} {
+		if !strings.Contains(";"+fields["SignedHeaders"]+";", ";"+h+";") {
+			return fmt.Errorf("header [%s] isn't signed", h)
+		}
+	}
+
+	// 请求行原始路径以及查询参数，查询参数解码后按 RFC 3986 重新编码排序
+	rawPath, rawQuery, _ := strings.Cut(r.RequestURI, "?")
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return err
+	}
+	var pairs []string
+	for k, vs := range values {
+		for _, v := range vs {
+			pairs = append(pairs, sigV4Escape(k)+"="+sigV4Escape(v))
+		}
+	}
+	sort.Strings(pairs)
+	canonicalPath := sigV4Escape(mustUnescapePath(rawPath))
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		strings.ReplaceAll(canonicalPath, "%2F", "/"),
+		strings.Join(pairs, "&"),
+		canonicalHeaders.String(),
+		fields["SignedHeaders"],
+		r.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, strings.Join(credential[1:], "/"), hex.EncodeToString(canonicalHash[:])}, "\n")
+
+	key := []byte("AWS4" + secretKey)
+	for _, v := range []string{credential[1], region, "s3", "aws4_request"} {
+		key = sigV4HMAC(key, v)
+	}
+	expect := hex.EncodeToString(sigV4HMAC(key, stringToSign))
+	if !hmac.Equal([]byte(expect), []byte(fields["Signature"])) {
+		return fmt.Errorf("signature [%s] isn't match, canonical request:\n%s", fields["Signature"], canonicalRequest)
+	}
+	return nil
+}
+
+func sigV4HMAC(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sigV4Escape(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if strings.IndexByte("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_.~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func mustUnescapePath(p string) string {
+	v, err := url.PathUnescape(p)
+	if err != nil {
+		return p
+	}
+	return v
+}
+
+func (f *fakeS3) writeError(w http.ResponseWriter, status int, code, message string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, message)
+}
+
+func newFakeS3Storage(t *testing.T, partSize int) (*fakeS3, storage.ExternalStorage) {
+	fake := newFakeS3("transferdb", "minioadmin", "minioadmin", "us-east-1")
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	s, err := storage.NewStorage(context.Background(), "s3://transferdb/export/marvin", config.S3Config{
+		Endpoint:       server.URL,
+		AccessKey:      "minioadmin",
+		SecretKey:      "minioadmin",
+		ForcePathStyle: true,
+		PartSize:       partSize,
+		MaxRetries:     3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fake, s
+}
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.NewStorage(ctx, t.TempDir(), config.S3Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := s.Create(ctx, "MARVIN/T1/MARVIN.T1.0.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write([]byte("1,a\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.WriteFile(ctx, "MARVIN/T1/MARVIN.T1.manifest.json", []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+
+	aborted, err := s.Create(ctx, "MARVIN/T1/MARVIN.T1.1.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	aborted.Write([]byte("partial"))
+	if err = aborted.Abort(); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	err = s.WalkDir(ctx, "MARVIN", func(name string, size int64) error {
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "MARVIN/T1/MARVIN.T1.0.csv,MARVIN/T1/MARVIN.T1.manifest.json" {
+		t.Fatalf("unexpected walk result: %v", names)
+	}
+
+	data, err := s.ReadFile(ctx, "MARVIN/T1/MARVIN.T1.0.csv")
+	if err != nil || string(data) != "1,a\r\n" {
+		t.Fatalf("unexpected read result: %q, %v", data, err)
+	}
+	if err = s.DeleteFile(ctx, "MARVIN/T1/MARVIN.T1.0.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.ReadFile(ctx, "MARVIN/T1/MARVIN.T1.0.csv"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expect not exist error, got: %v", err)
+	}
+	if err = s.WalkDir(ctx, "NOT_EXIST", func(name string, size int64) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestS3StoragePutObject(t *testing.T) {
+	ctx := context.Background()
+	fake, s := newFakeS3Storage(t, 5)
+
+	w, err := s.Create(ctx, "MARVIN/T1/MARVIN.T1.0.csv.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("1,a\r\n"))
+	w.Write([]byte("2,b\r\n"))
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.WriteFile(ctx, "MARVIN/T1/MARVIN.T1.manifest.json", []byte(`{"finished":true}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(fake.objects["export/marvin/MARVIN/T1/MARVIN.T1.0.csv.gz"]) != "1,a\r\n2,b\r\n" {
+		t.Fatalf("unexpected object: %v", fake.objects)
+	}
+	if fake.requests["PutObject"] != 2 || fake.requests["CreateMultipartUpload"] != 0 {
+		t.Fatalf("small file expect single put object, requests: %v", fake.requests)
+	}
+
+	exist, err := s.FileExists(ctx, "MARVIN/T1/MARVIN.T1.manifest.json")
+	if err != nil || !exist {
+		t.Fatalf("expect manifest exist: %v", err)
+	}
+	data, err := s.ReadFile(ctx, "MARVIN/T1/MARVIN.T1.manifest.json")
+	if err != nil || string(data) != `{"finished":true}` {
+		t.Fatalf("unexpected read result: %q, %v", data, err)
+	}
+
+	var names []string
+	err = s.WalkDir(ctx, "MARVIN/T1", func(name string, size int64) error {
+		names = append(names, fmt.Sprintf("%s:%d", name, size))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "MARVIN/T1/MARVIN.T1.0.csv.gz:10,MARVIN/T1/MARVIN.T1.manifest.json:17" {
+		t.Fatalf("unexpected walk result: %v", names)
+	}
+
+	if err = s.DeleteFile(ctx, "MARVIN/T1/MARVIN.T1.manifest.json"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.ReadFile(ctx, "MARVIN/T1/MARVIN.T1.manifest.json"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expect not exist error, got: %v", err)
+	}
+}
+
+func TestS3StorageMultipartUpload(t *testing.T) {
+	ctx := context.Background()
+	fake, s := newFakeS3Storage(t, 5)
+
+	// 12MB，part-size 5MB，预期 3 个 part
+	data := bytes.Repeat([]byte("0123456789abcdef"), 12*1024*1024/16)
+	w, err := s.Create(ctx, "MARVIN.T1.000000001.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(data); i += 100000 {
+		end := i + 100000
+		if end > len(data) {
+			end = len(data)
+		}
+		if _, err = w.Write(data[i:end]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(fake.objects["export/marvin/MARVIN.T1.000000001.csv"], data) {
+		t.Fatal("multipart object content mismatch")
+	}
+	if fake.requests["UploadPart"] != 3 || fake.requests["CompleteMultipartUpload"] != 1 || len(fake.uploads) != 0 {
+		t.Fatalf("unexpected requests: %v", fake.requests)
+	}
+}
+
+func TestS3StorageRetry(t *testing.T) {
+	ctx := context.Background()
+	fake, s := newFakeS3Storage(t, 5)
+
+	fake.failNext = 2
+	if err := s.WriteFile(ctx, "MARVIN-schema-create.sql", []byte("CREATE DATABASE IF NOT EXISTS `MARVIN`;")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.objects["export/marvin/MARVIN-schema-create.sql"]; !ok {
+		t.Fatal("object not found after retry")
+	}
+
+	// 超过重试次数返回错误
+	fake.failNext = 10
+	if err := s.WriteFile(ctx, "MARVIN-schema-create.sql", []byte("x")); err == nil {
+		t.Fatal("expect error after max retries")
+	}
+}
+
+func TestS3StorageResumeAbortIncompleteUpload(t *testing.T) {
+	ctx := context.Background()
+	fake, s := newFakeS3Storage(t, 5)
+
+	// 模拟导出中断：进程退出残留已上传部分 part 的 multipart upload
+	fake.uploads["upload-interrupted"] = &fakeUpload{
+		key:   "export/marvin/MARVIN/T1/MARVIN.T1.3.csv",
+		parts: map[int][]byte{1: bytes.Repeat([]byte("x"), 5*1024*1024)},
+	}
+
+	// 断点续传，依据 full_sync_meta csv_file 重新导出同名文件，清理残留 upload
+	w, err := s.Create(ctx, "MARVIN/T1/MARVIN.T1.3.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.uploads) != 0 || fake.requests["AbortMultipartUpload"] != 1 {
+		t.Fatalf("expect incomplete upload aborted, requests: %v", fake.requests)
+	}
+	w.Write([]byte("1,a\r\n"))
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if string(fake.objects["export/marvin/MARVIN/T1/MARVIN.T1.3.csv"]) != "1,a\r\n" {
+		t.Fatal("unexpected resumed object")
+	}
+
+	// 写入失败 Abort 丢弃 multipart upload
+	w, err = s.Create(ctx, "MARVIN/T1/MARVIN.T1.4.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(bytes.Repeat([]byte("y"), 6*1024*1024))
+	if err = w.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.uploads) != 0 || fake.requests["AbortMultipartUpload"] != 2 {
+		t.Fatal("expect upload aborted")
+	}
+	if _, ok := fake.objects["export/marvin/MARVIN/T1/MARVIN.T1.4.csv"]; ok {
+		t.Fatal("aborted object should not exist")
+	}
+}
+
+func TestS3StorageSignatureMismatch(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeS3("transferdb", "minioadmin", "minioadmin", "us-east-1")
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	s, err := storage.NewStorage(ctx, "s3://transferdb/backup", config.S3Config{
+		Endpoint:       server.URL,
+		AccessKey:      "minioadmin",
+		SecretKey:      "wrong-secret",
+		ForcePathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.WriteFile(ctx, "a.csv", []byte("1,2\n")); err == nil {
+		t.Fatal("write with wrong secret should fail")
+	}
+	if fake.requests["SignatureDoesNotMatch"] == 0 {
+		t.Fatal("fake s3 should reject signature mismatch")
+	}
+
+	// 对象名含需转义字符时签名仍需通过服务端校验
+	_, store := newFakeS3Storage(t, 5)
+	if err = store.WriteFile(ctx, "MARVIN/T$1/a b+c=d.csv", []byte("1,2\n")); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"github.com/BurntSushi/toml"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/oracle"
+	compareO2M "github.com/wentaojin/transferdb/module/compare/o2m"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const tableRuleConfig = `
+[[table-rule]]
+source-table = "marvin"
+column-fields = ["id", "name", "phone", "status"]
+where = "create_time >= DATE '2022-01-01'"
+
+[[table-rule.column-rule]]
+column-name = "phone"
+transform = "hash"
+
+[[table-rule.column-rule]]
+column-name = "name"
+transform = "redact"
+value = "it's"
+
+[[table-rule.column-rule]]
+column-name = "status"
+transform = "remap"
+mapping = { "A" = "1", "B" = "2" }
+`
+
+func TestTableRuleConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	if _, err := toml.Decode(tableRuleConfig, cfg); err != nil {
+		t.Fatal(err)
+	}
+	rule, ok := cfg.GetTableRule("MARVIN")
+	if !ok {
+		t.Fatal("table rule [MARVIN] should be found")
+	}
+	if _, ok = cfg.GetTableRule("MARVIN2"); ok {
+		t.Fatal("table rule [MARVIN2] shouldn't be found")
+	}
+	colRule, ok := rule.GetColumnRule("STATUS")
+	if !ok || !reflect.DeepEqual(colRule.Mapping, map[string]string{"A": "1", "B": "2"}) {
+		t.Fatalf("unexpected column rule: %v", colRule)
+	}
+
+	columnsINFO := []map[string]string{
+		{"COLUMN_NAME": "ID", "DATA_TYPE": "NUMBER"},
+		{"COLUMN_NAME": "NAME", "DATA_TYPE": "VARCHAR2"},
+		{"COLUMN_NAME": "PHONE", "DATA_TYPE": "VARCHAR2"},
+		{"COLUMN_NAME": "REMARK", "DATA_TYPE": "CLOB"},
+		{"COLUMN_NAME": "STATUS", "DATA_TYPE": "CHAR"},
+	}
+	filterColumns, err := oracle.FilterOracleTableColumnByRule("MARVIN", columnsINFO, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var columnNames []string
+	for _, c := range filterColumns {
+		columnNames = append(columnNames, c["COLUMN_NAME"])
+	}
+	if !reflect.DeepEqual(columnNames, []string{"ID", "NAME", "PHONE", "STATUS"}) {
+		t.Fatalf("unexpected filter columns: %v", columnNames)
+	}
+
+	// 规则字段不存在
+	rule.ColumnFields = append(rule.ColumnFields, "not_exist")
+	if _, err = oracle.FilterOracleTableColumnByRule("MARVIN", columnsINFO, rule); err == nil {
+		t.Fatal("rule column not exist should be failed")
+	}
+
+	// 未配置字段子集迁移全部字段
+	filterColumns, err = oracle.FilterOracleTableColumnByRule("MARVIN", columnsINFO, config.TableRule{})
+	if err != nil || len(filterColumns) != len(columnsINFO) {
+		t.Fatalf("unexpected filter columns: %v, error: %v", filterColumns, err)
+	}
+
+	// STANDARD_HASH 不支持 LOB 字段
+	_, err = oracle.FilterOracleTableColumnByRule("MARVIN", columnsINFO, config.TableRule{
+		ColumnRules: []config.ColumnRule{{ColumnName: "remark", Transform: "HASH"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "CLOB") {
+		t.Fatalf("hash transform on clob column should be failed, error: %v", err)
+	}
+	if _, err = oracle.FilterOracleTableColumnByRule("MARVIN", columnsINFO, config.TableRule{
+		ColumnRules: []config.ColumnRule{{ColumnName: "remark", Transform: "redact"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTableRuleWhereTarget(t *testing.T) {
+	cfg := config.NewConfig()
+	if _, err := toml.Decode(tableRuleConfig+`
+[[table-rule]]
+source-table = "steven"
+where = "create_time >= DATE '2022-01-01'"
+where-target = "create_time >= '2022-01-01'"
+`, cfg); err != nil {
+		t.Fatal(err)
+	}
+	rule, _ := cfg.GetTableRule("marvin")
+	if rule.GetWhereTarget() != rule.Where {
+		t.Fatalf("where-target should default to where, got [%s]", rule.GetWhereTarget())
+	}
+	rule, _ = cfg.GetTableRule("steven")
+	if rule.GetWhereTarget() != "create_time >= '2022-01-01'" {
+		t.Fatalf("unexpected where-target [%s]", rule.GetWhereTarget())
+	}
+
+	// compare 源端以及目标端分别使用各自过滤条件，未记录目标端范围沿用源端范围
+	compareMeta := meta.DataCompareMeta{
+		SchemaNameS: "MARVIN",
+		TableNameS:  "STEVEN",
+		ColumnInfoS: "ID",
+		SchemaNameT: "MARVIN",
+		TableNameT:  "STEVEN",
+		ColumnInfoT: "ID",
+		WhereRange:  oracle.GenOracleTableRuleWhere("1 = 1", rule.Where),
+		WhereRangeT: oracle.GenOracleTableRuleWhere("1 = 1", rule.GetWhereTarget()),
+	}
+	oracleQuery, mysqlQuery := compareO2M.NewReport(compareMeta, nil, nil, false).GenDBQuery()
+	if oracleQuery != "SELECT ID FROM MARVIN.STEVEN WHERE (1 = 1) AND (create_time >= DATE '2022-01-01')" ||
+		mysqlQuery != "SELECT ID FROM MARVIN.STEVEN WHERE (1 = 1) AND (create_time >= '2022-01-01')" {
+		t.Fatalf("unexpected compare query [%s] [%s]", oracleQuery, mysqlQuery)
+	}
+	compareMeta.WhereRangeT = ""
+	if _, mysqlQuery = compareO2M.NewReport(compareMeta, nil, nil, false).GenDBQuery(); !strings.HasSuffix(mysqlQuery, "DATE '2022-01-01')") {
+		t.Fatalf("unexpected compare target query [%s]", mysqlQuery)
+	}
+}
+
+func TestGenOracleColumnRuleExpr(t *testing.T) {
+	cases := []struct {
+		rule   config.ColumnRule
+		expect string
+	}{
+		{config.ColumnRule{Transform: "hash"}, "NVL2(COL,LOWER(RAWTOHEX(STANDARD_HASH(COL,'SHA256'))),NULL)"},
+		{config.ColumnRule{Transform: "HASH", Value: "md5"}, "NVL2(COL,LOWER(RAWTOHEX(STANDARD_HASH(COL,'MD5'))),NULL)"},
+		{config.ColumnRule{Transform: "redact"}, "NVL2(COL,'******',NULL)"},
+		{config.ColumnRule{Transform: "redact", Value: "it's"}, "NVL2(COL,'it''s',NULL)"},
+		{config.ColumnRule{Transform: "trim"}, "RTRIM(COL)"},
+		{config.ColumnRule{Transform: "remap", Mapping: map[string]string{"B": "2", "A": "1"}},
+			"CASE TO_CHAR(COL) WHEN 'A' THEN '1' WHEN 'B' THEN '2' ELSE TO_CHAR(COL) END"},
+		{config.ColumnRule{Transform: "remap", Value: "0", Mapping: map[string]string{"A": "1"}},
+			"CASE TO_CHAR(COL) WHEN 'A' THEN '1' ELSE '0' END"},
+	}
+	for _, c := range cases {
+		got, err := oracle.GenOracleColumnRuleExpr("COL", c.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.expect {
+			t.Fatalf("column rule %v expr %q, expect %q", c.rule, got, c.expect)
+		}
+	}
+
+	for _, rule := range []config.ColumnRule{
+		{Transform: "hash", Value: "crc32"},
+		{Transform: "remap"},
+		{Transform: "upper"},
+	} {
+		if _, err := oracle.GenOracleColumnRuleExpr("COL", rule); err == nil {
+			t.Fatalf("column rule %v should be failed", rule)
+		}
+	}
+}
+
+func TestGenOracleTableRuleWhere(t *testing.T) {
+	if got := oracle.GenOracleTableRuleWhere("1 = 1", ""); got != "1 = 1" {
+		t.Fatalf("rule where %q, expect 1 = 1", got)
+	}
+	if got := oracle.GenOracleTableRuleWhere("ID < 10", "STATUS = 'A' OR STATUS = 'B'"); got != "(ID < 10) AND (STATUS = 'A' OR STATUS = 'B')" {
+		t.Fatalf("unexpected rule where %q", got)
+	}
+}
@@ -0,0 +1,247 @@
+package tests
+
+import (
+	"context"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/plan"
+	"github.com/wentaojin/transferdb/module/task"
+	"strings"
+	"testing"
+)
+
+func newTaskMeta(t *testing.T) *meta.Meta {
+	t.Helper()
+	ctx := context.Background()
+	metaDB := newSQLiteMeta(t)
+
+	for table, splitTimes := range map[string]int{"T1": 2, "T2": 0, "T3": -1} {
+		if err := meta.NewWaitSyncMetaModel(metaDB).CreateWaitSyncMeta(ctx, &meta.WaitSyncMeta{
+			DBTypeS:        common.TaskDBOracle,
+			DBTypeT:        common.TaskDBMySQL,
+			SchemaNameS:    "MARVIN",
+			TableNameS:     table,
+			Mode:           common.FullO2MMode,
+			FullGlobalSCN:  1024,
+			FullSplitTimes: splitTimes,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var fullSyncMetas []meta.FullSyncMeta
+	for _, rowid := range []string{"ROWID BETWEEN 'A' AND 'B'", "ROWID BETWEEN 'C' AND 'D'"} {
+		fullSyncMetas = append(fullSyncMetas, meta.FullSyncMeta{
+			DBTypeS:     common.TaskDBOracle,
+			DBTypeT:     common.TaskDBMySQL,
+			SchemaNameS: "MARVIN",
+			TableNameS:  "T1",
+			SchemaNameT: "STEVEN",
+			TableNameT:  "T1",
+			GlobalScnS:  1024,
+			ColumnInfoS: "ID",
+			RowidInfoS:  rowid,
+			Mode:        common.FullO2MMode,
+		})
+	}
+	if err := meta.NewFullSyncMetaModel(metaDB).BatchCreateFullSyncMeta(ctx, fullSyncMetas, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := meta.NewIncrSyncMetaModel(metaDB).BatchCreateIncrSyncMeta(ctx, []meta.IncrSyncMeta{{
+		DBTypeS:     common.TaskDBOracle,
+		DBTypeT:     common.TaskDBMySQL,
+		SchemaNameS: "MARVIN",
+		TableNameS:  "T2",
+		SchemaNameT: "STEVEN",
+		TableNameT:  "T2",
+		GlobalScnS:  1024,
+		TableScnS:   2048,
+	}}, 10); err != nil {
+		t.Fatal(err)
+	}
+	for _, mode := range []string{common.FullO2MMode, common.FullO2MMode, common.CheckO2MMode} {
+		if err := meta.NewErrorLogDetailModel(metaDB).CreateErrorLog(ctx, &meta.ErrorLogDetail{
+			DBTypeS:     common.TaskDBOracle,
+			DBTypeT:     common.TaskDBMySQL,
+			SchemaNameS: "MARVIN",
+			TableNameS:  "T3",
+			RunMode:     mode,
+			RunStatus:   "Failed",
+			InfoDetail:  "chunk failed",
+			ErrorDetail: "duplicate entry",
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return metaDB
+}
+
+func TestTaskStatus(t *testing.T) {
+	ctx := context.Background()
+	metaDB := newTaskMeta(t)
+
+	statuses, err := task.Status(ctx, metaDB, "MARVIN", common.FullO2MMode, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 3 {
+		t.Fatalf("full mode status tables [%d], want 3", len(statuses))
+	}
+	want := map[string]struct {
+		state   string
+		remains int
+		errors  int64
+	}{
+		"T1": {plan.TableStateResume, 2, 0},
+		"T2": {plan.TableStateFinished, 0, 0},
+		"T3": {plan.TableStateWait, 0, 2},
+	}
+	for _, s := range statuses {
+		w := want[s.TableNameS]
+		if s.State != w.state || s.Remains != w.remains || s.Errors != w.errors {
+			t.Fatalf("table [%s] status [%s/%d/%d], want [%s/%d/%d]", s.TableNameS, s.State, s.Remains, s.Errors, w.state, w.remains, w.errors)
+		}
+	}
+
+	// all 模式全量阶段断点复用 FullO2M，增量记录表状态 INCR
+	statuses, err = task.Status(ctx, metaDB, "MARVIN", common.AllO2MMode, []string{"T2", "T9"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || statuses[0].State != plan.TableStateIncr || statuses[0].IncrTableSCN != 2048 ||
+		statuses[1].State != plan.TableStateInit {
+		t.Fatalf("all mode status unexpected: %+v %+v", statuses[0], statuses[1])
+	}
+
+	out := task.StatusString("MARVIN", common.AllO2MMode, statuses)
+	for _, s := range []string{"schema [MARVIN] mode [AllO2M]", "MARVIN.T2", "INCR", "2048", "CHECKPOINT 1"} {
+		if !strings.Contains(out, s) {
+			t.Fatalf("status output missing [%s]:\n%s", s, out)
+		}
+	}
+}
+
+func TestTaskReset(t *testing.T) {
+	ctx := context.Background()
+	metaDB := newTaskMeta(t)
+
+	resets, err := task.Reset(ctx, metaDB, "MARVIN", common.FullO2MMode, []string{"T1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resets) != 1 || resets[0] != "T1" {
+		t.Fatalf("reset tables %v, want [T1]", resets)
+	}
+	statuses, err := task.Status(ctx, metaDB, "MARVIN", common.FullO2MMode, []string{"T1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses[0].State != plan.TableStateInit || statuses[0].Remains != 0 {
+		t.Fatalf("table [T1] after reset status [%s] remains [%d]", statuses[0].State, statuses[0].Remains)
+	}
+
+	// all 模式重置同时清理增量记录
+	if _, err = task.Reset(ctx, metaDB, "MARVIN", common.AllO2MMode, []string{"T2"}); err != nil {
+		t.Fatal(err)
+	}
+	counts, err := meta.NewIncrSyncMetaModel(metaDB).CountsIncrSyncMetaBySchemaTable(ctx, &meta.IncrSyncMeta{
+		DBTypeS:     common.TaskDBOracle,
+		DBTypeT:     common.TaskDBMySQL,
+		SchemaNameS: "MARVIN",
+		TableNameS:  "T2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts != 0 {
+		t.Fatalf("table [T2] incr_sync_meta records [%d] after reset, want 0", counts)
+	}
+
+	// 未指定表重置全部存在断点的表
+	resets, err = task.Reset(ctx, metaDB, "MARVIN", common.FullO2MMode, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resets) != 1 || resets[0] != "T3" {
+		t.Fatalf("reset all tables %v, want [T3]", resets)
+	}
+}
+
+func TestTaskErrors(t *testing.T) {
+	ctx := context.Background()
+	metaDB := newTaskMeta(t)
+
+	errLogs, err := task.ListErrors(ctx, metaDB, "MARVIN", common.FullO2MMode, []string{"T3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errLogs) != 2 {
+		t.Fatalf("full mode error records [%d], want 2", len(errLogs))
+	}
+	if out := task.ErrorsString("MARVIN", common.FullO2MMode, errLogs); !strings.Contains(out, "duplicate entry") {
+		t.Fatalf("errors output missing error detail:\n%s", out)
+	}
+
+	counts, err := task.ClearErrors(ctx, metaDB, "MARVIN", common.FullO2MMode, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts != 2 {
+		t.Fatalf("clear error records [%d], want 2", counts)
+	}
+	// 其余运行模式错误记录保留
+	errLogs, err = task.ListErrors(ctx, metaDB, "MARVIN", common.CheckO2MMode, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errLogs) != 1 {
+		t.Fatalf("check mode error records [%d], want 1", len(errLogs))
+	}
+}
+
+func TestTaskResume(t *testing.T) {
+	ctx := context.Background()
+	metaDB := newTaskMeta(t)
+
+	cfg := config.NewConfig()
+	cfg.Mode = "full"
+	cfg.TaskAction = common.TaskActionResume
+	cfg.OracleConfig.SchemaName = "marvin"
+
+	// 存在错误记录不允许续传
+	if err := task.Resume(ctx, cfg, metaDB); err == nil || !strings.Contains(err.Error(), "errors clear") {
+		t.Fatalf("resume with error records should fail, got: %v", err)
+	}
+	if _, err := task.ClearErrors(ctx, metaDB, "MARVIN", common.FullO2MMode, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := task.Resume(ctx, cfg, metaDB); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.FullConfig.EnableCheckpoint {
+		t.Fatal("resume should enable full checkpoint")
+	}
+
+	// 无断点记录不允许续传
+	cfg.Mode = "csv"
+	if err := task.Resume(ctx, cfg, metaDB); err == nil {
+		t.Fatal("resume without checkpoint should fail")
+	}
+	cfg.Mode = "check"
+	if err := task.Resume(ctx, cfg, metaDB); err == nil {
+		t.Fatal("resume mode check should fail")
+	}
+}
+
+func TestTaskParse(t *testing.T) {
+	cfg := config.NewConfig()
+	if err := cfg.ParseTask([]string{"errors", "Clear", "--config", "../example/config.toml", "--mode", "full", "--table", "t1, T2"}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.TaskAction != common.TaskActionErrorsClear {
+		t.Fatalf("task action [%s], want [%s]", cfg.TaskAction, common.TaskActionErrorsClear)
+	}
+	if tables := cfg.GetTaskTables(); len(tables) != 2 || tables[0] != "T1" || tables[1] != "T2" {
+		t.Fatalf("task tables %v, want [T1 T2]", tables)
+	}
+}